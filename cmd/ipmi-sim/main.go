@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/pkg/logger"
+)
+
+// ipmi-sim 启动一个本地IPMI BMC模拟器（UDP），用于离线联调电源、传感器和SEL接口
+// 将服务器的ipmi_ip设置为模拟器地址即可，例如 127.0.0.1:6230，未配置redfish_url时自动使用IPMI
+func main() {
+	addr := flag.String("addr", ":6230", "listen address")
+	username := flag.String("username", "admin", "BMC username")
+	password := flag.String("password", "password", "BMC password")
+	powerDelay := flag.Duration("power-delay", 3*time.Second, "time for power up and soft shutdown to take effect")
+	flag.Parse()
+
+	log := logger.New("info")
+
+	conn, err := net.ListenPacket("udp", *addr)
+	if err != nil {
+		log.Fatal("IPMI simulator failed to listen", "addr", *addr, "error", err)
+	}
+
+	sim := ipmi.NewSimulator(*username, *password)
+	sim.SetPowerDelay(*powerDelay)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	log.Info("IPMI simulator listening", "addr", conn.LocalAddr().String(), "username", *username)
+	if err := sim.Serve(conn); err != nil {
+		log.Fatal("IPMI simulator failed", "error", err)
+	}
+}
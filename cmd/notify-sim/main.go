@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gpu-management/internal/services/notify"
+	"gpu-management/pkg/logger"
+)
+
+// notify-sim 启动本地通知接收方模拟器，用于离线联调告警通知
+// webhook渠道的url设置为 http://localhost:9095/webhook，slack渠道设置为 http://localhost:9095/slack，
+// SMTP_HOST和SMTP_PORT设置为 localhost 和 2525；GET http://localhost:9095/messages 查看收到的通知
+func main() {
+	addr := flag.String("addr", ":9095", "HTTP listen address for webhook and slack receivers")
+	smtpAddr := flag.String("smtp-addr", ":2525", "SMTP listen address")
+	secret := flag.String("secret", "", "verify webhook signatures with this secret")
+	failNext := flag.Int("fail-next", 0, "reject the first N webhook/slack requests with 503")
+	flag.Parse()
+
+	log := logger.New("info")
+
+	sim := notify.NewSimulator(*secret)
+	sim.FailNext(*failNext)
+	listening, err := sim.ListenSMTP(*smtpAddr)
+	if err != nil {
+		log.Fatal("SMTP simulator failed", "error", err)
+	}
+	defer sim.Close()
+
+	server := &http.Server{Addr: *addr, Handler: sim}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Notification simulator listening", "addr", *addr, "smtp_addr", listening)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal("Notification simulator failed", "error", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gpu-management/internal/services/redfish"
+	"gpu-management/pkg/logger"
+)
+
+// redfish-sim 启动一个本地Redfish BMC模拟器，用于离线联调电源、清单、BIOS和固件更新接口
+// 将服务器的redfish_url设置为模拟器地址即可，例如 http://localhost:8443
+func main() {
+	addr := flag.String("addr", ":8443", "listen address")
+	username := flag.String("username", "admin", "BMC username")
+	password := flag.String("password", "password", "BMC password")
+	taskDuration := flag.Duration("task-duration", 3*time.Second, "time for a power task to complete")
+	updateDuration := flag.Duration("update-duration", 5*time.Second, "time for a firmware update task to complete")
+	updateFailure := flag.String("fail-updates", "", "fail every firmware update with this message")
+	flag.Parse()
+
+	log := logger.New("info")
+
+	sim := redfish.NewSimulator(*username, *password)
+	sim.SetTaskDuration(*taskDuration)
+	sim.SetUpdateDuration(*updateDuration)
+	sim.SetUpdateFailure(*updateFailure)
+
+	server := &http.Server{Addr: *addr, Handler: sim}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Redfish simulator listening", "addr", *addr, "username", *username)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal("Redfish simulator failed", "error", err)
+	}
+}
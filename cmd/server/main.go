@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/api/routes"
+	"gpu-management/internal/config"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/repository/migrations"
+	"gpu-management/internal/services/alert"
+	"gpu-management/internal/services/allocation"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/eventstore"
+	"gpu-management/internal/services/firmware"
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/internal/services/notify"
+	"gpu-management/internal/services/outbox"
+	"gpu-management/internal/services/power"
+	"gpu-management/internal/services/redfish"
+	"gpu-management/internal/services/rules"
+	"gpu-management/internal/services/scheduler"
+	"gpu-management/internal/services/stream"
+	"gpu-management/internal/services/task"
+	"gpu-management/internal/services/templates"
+	"gpu-management/internal/services/tinkerbell"
+	"gpu-management/internal/services/workflow"
+	"gpu-management/pkg/logger"
+)
+
+func main() {
+	// 加载配置
+	cfg := config.Load()
+
+	// 初始化日志
+	log := logger.New(cfg.LogLevel)
+
+	// 监听退出信号，收到SIGINT/SIGTERM时取消根Context
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 子命令: migrate up|down [steps]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, cfg, log, os.Args[2:]); err != nil {
+			log.Fatal("Migration failed", "error", err)
+		}
+		return
+	}
+
+	if err := run(ctx, cfg, log); err != nil {
+		log.Fatal("Server exited with error", "error", err)
+	}
+}
+
+// run 构建所有依赖并运行HTTP服务，直到ctx被取消
+func run(ctx context.Context, cfg *config.Config, log logger.Logger) error {
+	// 创建数据仓储
+	repos, closeRepos, err := newRepositories(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer closeRepos()
+
+	// 创建事件总线
+	eventBus, err := newEventBus(cfg, log, repos.DeadLetters)
+	if err != nil {
+		return err
+	}
+	defer eventBus.Close()
+
+	// 发件箱转发器把事务内写入的事件发布到事件总线，先于事件总线关闭
+	outboxRelay := outbox.NewRelay(repos, eventBus, outbox.Config{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		MaxBackoff:   cfg.Outbox.MaxBackoff,
+		Retention:    cfg.Outbox.Retention,
+	})
+	outboxRelay.Start()
+	defer outboxRelay.Close()
+
+	// 事件记录器把事件流收录的所有主题写入事件存储，供事件时间线查询
+	if err := eventstore.NewRecorder(repos, eventBus).Subscribe(ctx, cfg.NATS.Subjects); err != nil {
+		return err
+	}
+
+	// 事件推送中心以广播方式订阅同样的主题，向SSE和WebSocket客户端推送实时事件
+	eventHub := stream.NewHub(repos, eventBus)
+	if err := eventHub.Subscribe(ctx, cfg.NATS.Subjects); err != nil {
+		return err
+	}
+	defer eventHub.Close()
+
+	// 创建Redfish客户端池，退出时注销BMC会话
+	redfishPool := redfish.NewPool(redfish.Config{
+		Username:           cfg.Redfish.Username,
+		Password:           cfg.Redfish.Password,
+		InsecureSkipVerify: cfg.Redfish.InsecureSkipVerify,
+		Timeout:            cfg.Redfish.Timeout,
+	})
+	defer func() {
+		logoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		redfishPool.Close(logoutCtx)
+	}()
+
+	// 创建IPMI客户端池，用于未配置Redfish的服务器，退出时关闭会话
+	ipmiPool := ipmi.NewPool(ipmi.Config{
+		Username: cfg.IPMI.Username,
+		Password: cfg.IPMI.Password,
+		Timeout:  cfg.IPMI.Timeout,
+		Retries:  cfg.IPMI.Retries,
+	})
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		ipmiPool.Close(closeCtx)
+	}()
+
+	// 创建异步任务执行器，本实例上次退出时未结束的任务和其他实例租约过期的任务无法恢复，直接标记为失败
+	taskRunner := task.NewRunner(repos.Tasks, eventBus, task.Config{
+		Owner:             cfg.Task.InstanceID,
+		HeartbeatInterval: cfg.Task.HeartbeatInterval,
+		LeaseTimeout:      cfg.Task.LeaseTimeout,
+	})
+	interrupted, err := taskRunner.FailInterrupted(ctx)
+	if err != nil {
+		return err
+	}
+	if interrupted > 0 {
+		log.Warn("Marked interrupted tasks as failed", "count", interrupted)
+	}
+	// 先于BMC会话注销执行，等待运行中的任务记录结果
+	defer taskRunner.Close()
+
+	powerService := power.NewService(repos, redfishPool, ipmiPool, taskRunner, power.Config{
+		TaskTimeout:  cfg.Power.TaskTimeout,
+		PollInterval: cfg.Power.PollInterval,
+	})
+
+	// 固件驱动按注册顺序匹配服务器，目前只支持Redfish
+	firmwareService := firmware.NewService(repos, taskRunner, firmware.Config{
+		TaskTimeout:    cfg.Firmware.TaskTimeout,
+		RolloutTimeout: cfg.Firmware.RolloutTimeout,
+		VerifyChecksum: cfg.Firmware.VerifyChecksum,
+	})
+	firmwareService.RegisterDriver(firmware.NewRedfishDriver(redfishPool))
+	// 执行任务已被标记为失败的分批升级无法继续，同样标记为失败
+	interruptedRollouts, err := firmwareService.FailInterrupted(ctx)
+	if err != nil {
+		return err
+	}
+	if interruptedRollouts > 0 {
+		log.Warn("Marked interrupted firmware rollouts as failed", "count", interruptedRollouts)
+	}
+
+	// 告警服务在后台按配置升级未确认的告警，并随静默规则生效和过期更新告警的静默状态
+	alertService := alert.NewService(repos, alert.Config{
+		EscalateAfter:     cfg.Alert.EscalateAfter,
+		MaxEscalations:    cfg.Alert.MaxEscalations,
+		CheckInterval:     cfg.Alert.CheckInterval,
+		CorrelationWindow: cfg.Alert.CorrelationWindow,
+	})
+	alertService.Start()
+	defer alertService.Close()
+
+	// 告警规则引擎按上报的GPU指标产生和解除告警，启动时从未解除的告警恢复评估状态
+	rulesEngine := rules.NewEngine(repos, alertService, rules.Config{
+		SyncInterval: cfg.Alert.RuleSyncInterval,
+	})
+	if err := rulesEngine.Sync(ctx); err != nil {
+		return err
+	}
+	rulesEngine.Start()
+	defer rulesEngine.Close()
+
+	// 告警通知分发器订阅告警产生和解除事件，按路由发送到webhook、Slack和邮件渠道
+	// 上次退出时未发送完成的通知无法恢复，直接标记为失败
+	notifier := notify.NewDispatcher(repos, eventBus, notify.Config{
+		MaxAttempts:    cfg.Notify.MaxAttempts,
+		InitialBackoff: cfg.Notify.InitialBackoff,
+		MaxBackoff:     cfg.Notify.MaxBackoff,
+		SMTP: notify.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		},
+	})
+	interruptedNotifications, err := notifier.FailInterrupted(ctx)
+	if err != nil {
+		return err
+	}
+	if interruptedNotifications > 0 {
+		log.Warn("Marked interrupted notifications as failed", "count", interruptedNotifications)
+	}
+	if err := notifier.Subscribe(ctx); err != nil {
+		return err
+	}
+	defer notifier.Close()
+
+	// Tinkerbell同步器在数据库记录和Hardware、Template、Workflow CRD之间双向同步
+	tinkerbellSync := tinkerbell.NewReconciler(repos, tinkerbell.NewClient(newKubeClient(cfg, log)), tinkerbell.Config{
+		SyncInterval: cfg.Tinkerbell.SyncInterval,
+	})
+	tinkerbellSync.Start()
+	defer tinkerbellSync.Close()
+
+	// 工作流模板库保存带参数定义的Tinkerbell模板版本，只允许使用配置中的动作镜像
+	templateLibrary := templates.NewLibrary(repos, templates.Config{
+		AllowedActions: cfg.Tinkerbell.AllowedActions,
+	})
+
+	// 分配服务由API和工作流引擎共用
+	allocationService := allocation.NewService(repos, eventBus, scheduler.NewScheduler(repos))
+
+	// 工作流引擎把分配的部署和清理请求转换为Tinkerbell工作流，订阅工作流事件联动分配、服务器和GPU状态
+	workflowEngine := workflow.NewEngine(repos, allocationService, templateLibrary, tinkerbellSync)
+	if err := workflowEngine.InstallTemplates(ctx); err != nil {
+		return err
+	}
+	if err := workflowEngine.Subscribe(ctx, eventBus); err != nil {
+		return err
+	}
+
+	// 创建Echo实例
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	// 注册中间件
+	e.Use(echomiddleware.Recover())
+	e.Use(middleware.ContextMiddleware())
+
+	// 注册路由
+	routes.Setup(e, routes.Dependencies{
+		EventBus: eventBus,
+		Repos:    repos,
+		Redfish:  redfishPool,
+		IPMI:     ipmiPool,
+		Power:    powerService,
+		Firmware: firmwareService,
+		Stream:   eventHub,
+		Alerts:   alertService,
+		Rules:    rulesEngine,
+		Notifier: notifier,
+
+		Allocations: allocationService,
+		Tinkerbell:  tinkerbellSync,
+		Workflows:   workflowEngine,
+		Templates:   templateLibrary,
+	})
+	// 推送流是长连接，关闭HTTP服务时先结束推送，否则会等到关闭超时
+	e.Server.RegisterOnShutdown(eventHub.Close)
+
+	// 启动HTTP服务
+	addr := net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info("HTTP server starting", "addr", addr, "mode", cfg.Mode)
+		if err := e.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err, ok := <-serveErr:
+		if ok {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// 优雅关闭：停止接收新请求并等待在途请求完成
+	log.Info("Shutting down HTTP server", "timeout", cfg.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Error("HTTP server shutdown did not complete", "error", err)
+		return err
+	}
+
+	log.Info("HTTP server stopped")
+	return nil
+}
+
+// newEventBus 根据运行模式创建事件总线，在线模式默认使用JetStream，处理失败的事件写入deadLetters
+func newEventBus(cfg *config.Config, log logger.Logger, deadLetters event.DeadLetterStore) (event.EventBus, error) {
+	if cfg.IsOffline() {
+		log.Warn("Running in offline mode, using in-memory event bus")
+		return event.NewMemoryEventBus(event.MemoryConfig{Async: true}, deadLetters), nil
+	}
+
+	if !cfg.NATS.JetStream {
+		log.Warn("JetStream disabled, events published while consumers are down will be lost", "url", cfg.NATS.URL)
+		return event.NewEventBus(cfg.NATS.URL, deadLetters), nil
+	}
+
+	log.Info("Connecting to NATS JetStream", "url", cfg.NATS.URL, "stream", cfg.NATS.Stream)
+	return event.NewJetStreamEventBus(cfg.NATS.URL, event.JetStreamConfig{
+		Stream:     cfg.NATS.Stream,
+		Subjects:   cfg.NATS.Subjects,
+		MaxAge:     cfg.NATS.MaxAge,
+		Consumer:   cfg.NATS.Consumer,
+		AckWait:    cfg.NATS.AckWait,
+		MaxDeliver: cfg.NATS.MaxDeliver,
+		Backoff:    cfg.NATS.Backoff,
+	}, deadLetters)
+}
+
+// newKubeClient 根据运行模式创建访问Tinkerbell CRD的客户端
+// 离线模式使用模拟执行工作流的内存客户端，在线模式未配置Kubernetes API时同步失败并在日志中告警
+func newKubeClient(cfg *config.Config, log logger.Logger) tinkerbell.KubeClient {
+	if cfg.IsOffline() {
+		log.Warn("Running in offline mode, using in-memory tinkerbell client")
+		return tinkerbell.NewFakeClient(tinkerbell.FakeConfig{
+			AutoRun:        true,
+			ActionDuration: cfg.Tinkerbell.SimulatedActionDuration,
+		})
+	}
+
+	restConfig, err := tinkerbell.LoadRESTConfig(cfg.K8s.ConfigPath, cfg.K8s.Namespace)
+	if err == nil {
+		var client *tinkerbell.RESTClient
+		if client, err = tinkerbell.NewRESTClient(*restConfig); err == nil {
+			log.Info("Using kubernetes api for tinkerbell", "server", restConfig.Server, "namespace", client.Namespace())
+			return client
+		}
+	}
+	log.Warn("Tinkerbell sync disabled, kubernetes api is not available", "error", err)
+	return tinkerbell.NotConfigured
+}
+
+// newRepositories 根据运行模式创建数据仓储，返回的函数用于释放数据库连接
+func newRepositories(ctx context.Context, cfg *config.Config, log logger.Logger) (*repository.Repositories, func(), error) {
+	if cfg.IsOffline() {
+		log.Warn("Running in offline mode, using in-memory repositories")
+		return repository.NewMemoryRepositories(), func() {}, nil
+	}
+
+	log.Info("Connecting to database", "host", cfg.Database.Host, "db", cfg.Database.DBName)
+	db, err := repository.OpenPostgres(ctx, cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeDB := func() {
+		if err := db.Close(); err != nil {
+			log.Error("Failed to close database", "error", err)
+		}
+	}
+
+	if cfg.Database.AutoMigrate {
+		migrator, err := migrations.New(db)
+		if err != nil {
+			closeDB()
+			return nil, nil, err
+		}
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			closeDB()
+			return nil, nil, err
+		}
+		log.Info("Database migrations applied", "count", applied)
+	}
+
+	return repository.NewPostgresRepositories(db), closeDB, nil
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/alert"
+)
+
+// AlertHandler 告警处理器
+type AlertHandler struct {
+	alerts *alert.Service
+}
+
+// NewAlertHandler 创建新的告警处理器
+func NewAlertHandler(alerts *alert.Service) *AlertHandler {
+	return &AlertHandler{
+		alerts: alerts,
+	}
+}
+
+// List 列出告警，支持按来源、告警类型、状态和严重程度过滤，status和severity可用逗号分隔多个值
+func (h *AlertHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.AlertFilter{
+		SourceType: c.QueryParam("source_type"),
+		SourceID:   c.QueryParam("source_id"),
+		AlertType:  c.QueryParam("alert_type"),
+	}
+	if v := c.QueryParam("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+	if v := c.QueryParam("severity"); v != "" {
+		filter.Severities = strings.Split(v, ",")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	alerts, err := h.listAlerts(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  alerts,
+		"total": len(alerts),
+	})
+}
+
+// Create 产生告警
+func (h *AlertHandler) Create(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req alert.CreateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	created, err := h.createAlert(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "告警创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Get 获取告警详情
+func (h *AlertHandler) Get(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	found, err := h.getAlert(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, found)
+}
+
+// Update 更新告警状态，按状态机确认或解除告警
+func (h *AlertHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	var req alert.UpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return h.respondTransition(c, id, req.Status, req.Actor)
+}
+
+// Acknowledge 确认告警 (active -> acknowledged)
+func (h *AlertHandler) Acknowledge(c echo.Context) error {
+	var req alert.UpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return h.respondTransition(c, c.Param("id"), models.AlertStatusAcknowledged, req.Actor)
+}
+
+// Resolve 解除告警 (active/acknowledged -> resolved)
+func (h *AlertHandler) Resolve(c echo.Context) error {
+	var req alert.UpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return h.respondTransition(c, c.Param("id"), models.AlertStatusResolved, req.Actor)
+}
+
+// respondTransition 执行告警状态迁移并返回迁移后的告警
+func (h *AlertHandler) respondTransition(c echo.Context, id, status, actor string) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	updated, err := h.transitionAlert(businessCtx, id, status, actor)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "告警更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
+
+// GetCorrelation 获取告警关联分析，window指定关联的时间窗口，默认使用配置值
+func (h *AlertHandler) GetCorrelation(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var window time.Duration
+	if v := c.QueryParam("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "window must be a positive duration")
+		}
+		window = d
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 关联分析可能需要更长时间
+	defer cancel()
+
+	// 调用服务层，传递Context
+	correlation, err := h.getAlertCorrelation(businessCtx, id, window)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "关联分析超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, correlation)
+}
+
+// ListSilences 列出静默规则，active=true时只返回当前生效的规则
+func (h *AlertHandler) ListSilences(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	activeOnly := c.QueryParam("active") == "true"
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	silences, err := h.listSilences(businessCtx, activeOnly)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  silencesWithState(silences),
+		"total": len(silences),
+	})
+}
+
+// CreateSilence 创建静默规则
+func (h *AlertHandler) CreateSilence(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req alert.SilenceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	silence, err := h.createSilence(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "静默规则创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, silencesWithState([]models.Silence{*silence})[0])
+}
+
+// ExpireSilence 提前结束静默规则
+func (h *AlertHandler) ExpireSilence(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	silence, err := h.expireSilence(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "静默规则更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, silencesWithState([]models.Silence{*silence})[0])
+}
+
+// silenceView 带当前状态的静默规则
+type silenceView struct {
+	models.Silence
+	State string `json:"state"`
+}
+
+// silencesWithState 为静默规则附加当前状态
+func silencesWithState(silences []models.Silence) []silenceView {
+	now := time.Now().UTC()
+	views := make([]silenceView, len(silences))
+	for i := range silences {
+		views[i] = silenceView{Silence: silences[i], State: silences[i].State(now)}
+	}
+	return views
+}
+
+// actorOf 确定操作者：请求指定的用户优先，其次为认证上下文中的用户，尚未接入认证时为api
+func actorOf(ctx context.Context, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if userID := middleware.GetUserID(ctx); userID != "" {
+		return userID
+	}
+	return "api"
+}
+
+// 服务层方法实现
+func (h *AlertHandler) listAlerts(ctx context.Context, filter repository.AlertFilter) ([]models.Alert, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.List(ctx, filter)
+}
+
+func (h *AlertHandler) createAlert(ctx context.Context, req *alert.CreateRequest) (*models.Alert, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.Create(ctx, req)
+}
+
+func (h *AlertHandler) getAlert(ctx context.Context, id string) (*models.Alert, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.Get(ctx, id)
+}
+
+func (h *AlertHandler) transitionAlert(ctx context.Context, id, status, actor string) (*models.Alert, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.TransitionTo(ctx, id, status, actorOf(ctx, actor))
+}
+
+func (h *AlertHandler) getAlertCorrelation(ctx context.Context, id string, window time.Duration) (*alert.Correlation, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.Correlate(ctx, id, window)
+}
+
+func (h *AlertHandler) listSilences(ctx context.Context, activeOnly bool) ([]models.Silence, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.ListSilences(ctx, activeOnly)
+}
+
+func (h *AlertHandler) createSilence(ctx context.Context, req *alert.SilenceRequest) (*models.Silence, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.CreateSilence(ctx, req, actorOf(ctx, ""))
+}
+
+func (h *AlertHandler) expireSilence(ctx context.Context, id string) (*models.Silence, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.alerts.ExpireSilence(ctx, id)
+}
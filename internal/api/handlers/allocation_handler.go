@@ -3,22 +3,32 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/allocation"
 	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/workflow"
 )
 
 // AllocationHandler 分配处理器
 type AllocationHandler struct {
-	eventBus event.EventBus
+	eventBus    event.EventBus
+	allocations *allocation.Service
+	workflows   *workflow.Engine
 }
 
 // NewAllocationHandler 创建新的分配处理器
-func NewAllocationHandler(eventBus event.EventBus) *AllocationHandler {
+func NewAllocationHandler(eventBus event.EventBus, allocations *allocation.Service, workflows *workflow.Engine) *AllocationHandler {
 	return &AllocationHandler{
-		eventBus: eventBus,
+		eventBus:    eventBus,
+		allocations: allocations,
+		workflows:   workflows,
 	}
 }
 
@@ -32,7 +42,11 @@ func (h *AllocationHandler) List(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	allocations, err := h.listAllocations(businessCtx)
+	allocations, err := h.listAllocations(businessCtx, repository.AllocationFilter{
+		UserID:   c.QueryParam("user_id"),
+		ServerID: c.QueryParam("server_id"),
+		Status:   c.QueryParam("status"),
+	})
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -41,7 +55,7 @@ func (h *AllocationHandler) List(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -55,12 +69,24 @@ func (h *AllocationHandler) Create(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	var req allocation.CreateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	// 未显式指定用户时使用认证上下文中的用户
+	if req.UserID == "" {
+		req.UserID = middleware.GetUserID(ctx)
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 分配操作可能需要更长时间
 	defer cancel()
 
 	// 调用服务层，传递Context
-	allocation, err := h.createAllocation(businessCtx)
+	created, err := h.createAllocation(businessCtx, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -69,10 +95,10 @@ func (h *AllocationHandler) Create(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusCreated, allocation)
+	return c.JSON(http.StatusCreated, created)
 }
 
 // Get 获取分配详情
@@ -87,7 +113,7 @@ func (h *AllocationHandler) Get(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	allocation, err := h.getAllocation(businessCtx, id)
+	found, err := h.getAllocation(businessCtx, id)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -96,10 +122,10 @@ func (h *AllocationHandler) Get(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, allocation)
+	return c.JSON(http.StatusOK, found)
 }
 
 // Update 更新分配
@@ -109,12 +135,20 @@ func (h *AllocationHandler) Update(c echo.Context) error {
 
 	id := c.Param("id")
 
+	var req allocation.UpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.updateAllocation(businessCtx, id)
+	updated, err := h.updateAllocation(businessCtx, id, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -123,10 +157,10 @@ func (h *AllocationHandler) Update(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, updated)
 }
 
 // Delete 删除分配
@@ -150,7 +184,7 @@ func (h *AllocationHandler) Delete(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -168,7 +202,7 @@ func (h *AllocationHandler) Start(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.startAllocation(businessCtx, id)
+	updated, err := h.startAllocation(businessCtx, id)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -177,10 +211,10 @@ func (h *AllocationHandler) Start(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, updated)
 }
 
 // Stop 停止分配
@@ -195,7 +229,7 @@ func (h *AllocationHandler) Stop(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.stopAllocation(businessCtx, id)
+	updated, err := h.stopAllocation(businessCtx, id)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -204,10 +238,10 @@ func (h *AllocationHandler) Stop(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, updated)
 }
 
 // GetStatus 获取分配状态
@@ -231,7 +265,7 @@ func (h *AllocationHandler) GetStatus(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, status)
@@ -242,12 +276,20 @@ func (h *AllocationHandler) ListWorkflows(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	filter := repository.WorkflowFilter{
+		AllocationID: c.QueryParam("allocation_id"),
+		Type:         c.QueryParam("type"),
+	}
+	if v := c.QueryParam("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	workflows, err := h.listWorkflows(businessCtx)
+	workflows, err := h.listWorkflows(businessCtx, filter)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -256,10 +298,13 @@ func (h *AllocationHandler) ListWorkflows(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, workflows)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  workflows,
+		"total": len(workflows),
+	})
 }
 
 // CreateWorkflow 创建工作流
@@ -267,12 +312,20 @@ func (h *AllocationHandler) CreateWorkflow(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	var req workflow.CreateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 工作流创建可能需要更长时间
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	workflow, err := h.createWorkflow(businessCtx)
+	created, err := h.createWorkflow(businessCtx, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -281,10 +334,10 @@ func (h *AllocationHandler) CreateWorkflow(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusCreated, workflow)
+	return c.JSON(http.StatusCreated, created)
 }
 
 // GetWorkflow 获取工作流详情
@@ -299,7 +352,7 @@ func (h *AllocationHandler) GetWorkflow(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	workflow, err := h.getWorkflow(businessCtx, id)
+	found, err := h.getWorkflow(businessCtx, id)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -308,10 +361,10 @@ func (h *AllocationHandler) GetWorkflow(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, workflow)
+	return c.JSON(http.StatusOK, found)
 }
 
 // DeleteWorkflow 删除工作流
@@ -335,23 +388,58 @@ func (h *AllocationHandler) DeleteWorkflow(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+// RetryWorkflow 从失败的步骤继续执行失败的工作流
+func (h *AllocationHandler) RetryWorkflow(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	retried, err := h.retryWorkflow(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "重试超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, retried)
+}
+
 // CreateDeployWorkflow 创建部署工作流
 func (h *AllocationHandler) CreateDeployWorkflow(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	var req workflow.DeployRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 部署工作流可能需要更长时间
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	workflow, err := h.createDeployWorkflow(businessCtx)
+	created, err := h.createDeployWorkflow(businessCtx, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -360,10 +448,10 @@ func (h *AllocationHandler) CreateDeployWorkflow(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusCreated, workflow)
+	return c.JSON(http.StatusCreated, created)
 }
 
 // CreateCleanupWorkflow 创建清理工作流
@@ -371,12 +459,20 @@ func (h *AllocationHandler) CreateCleanupWorkflow(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	var req workflow.CleanupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 清理工作流可能需要更长时间
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	workflow, err := h.createCleanupWorkflow(businessCtx)
+	created, err := h.createCleanupWorkflow(businessCtx, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -385,14 +481,14 @@ func (h *AllocationHandler) CreateCleanupWorkflow(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusCreated, workflow)
+	return c.JSON(http.StatusCreated, created)
 }
 
 // 服务层方法实现
-func (h *AllocationHandler) listAllocations(ctx context.Context) ([]interface{}, error) {
+func (h *AllocationHandler) listAllocations(ctx context.Context, filter repository.AllocationFilter) ([]models.Allocation, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -400,11 +496,10 @@ func (h *AllocationHandler) listAllocations(ctx context.Context) ([]interface{},
 	default:
 	}
 
-	// TODO: 实现分配列表查询
-	return []interface{}{}, nil
+	return h.allocations.List(ctx, filter)
 }
 
-func (h *AllocationHandler) createAllocation(ctx context.Context) (interface{}, error) {
+func (h *AllocationHandler) createAllocation(ctx context.Context, req *allocation.CreateRequest) (*models.Allocation, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -412,11 +507,10 @@ func (h *AllocationHandler) createAllocation(ctx context.Context) (interface{},
 	default:
 	}
 
-	// TODO: 实现分配创建
-	return map[string]interface{}{}, nil
+	return h.allocations.Create(ctx, req)
 }
 
-func (h *AllocationHandler) getAllocation(ctx context.Context, id string) (interface{}, error) {
+func (h *AllocationHandler) getAllocation(ctx context.Context, id string) (*models.Allocation, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -424,20 +518,18 @@ func (h *AllocationHandler) getAllocation(ctx context.Context, id string) (inter
 	default:
 	}
 
-	// TODO: 实现分配详情查询
-	return map[string]interface{}{}, nil
+	return h.allocations.Get(ctx, id)
 }
 
-func (h *AllocationHandler) updateAllocation(ctx context.Context, id string) error {
+func (h *AllocationHandler) updateAllocation(ctx context.Context, id string, req *allocation.UpdateRequest) (*models.Allocation, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现分配更新
-	return nil
+	return h.allocations.Update(ctx, id, req)
 }
 
 func (h *AllocationHandler) deleteAllocation(ctx context.Context, id string) error {
@@ -448,32 +540,29 @@ func (h *AllocationHandler) deleteAllocation(ctx context.Context, id string) err
 	default:
 	}
 
-	// TODO: 实现分配删除
-	return nil
+	return h.allocations.Delete(ctx, id)
 }
 
-func (h *AllocationHandler) startAllocation(ctx context.Context, id string) error {
+func (h *AllocationHandler) startAllocation(ctx context.Context, id string) (*models.Allocation, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现分配启动
-	return nil
+	return h.allocations.Start(ctx, id)
 }
 
-func (h *AllocationHandler) stopAllocation(ctx context.Context, id string) error {
+func (h *AllocationHandler) stopAllocation(ctx context.Context, id string) (*models.Allocation, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现分配停止
-	return nil
+	return h.allocations.Stop(ctx, id)
 }
 
 func (h *AllocationHandler) getAllocationStatus(ctx context.Context, id string) (interface{}, error) {
@@ -484,11 +573,21 @@ func (h *AllocationHandler) getAllocationStatus(ctx context.Context, id string)
 	default:
 	}
 
-	// TODO: 实现分配状态查询
-	return map[string]interface{}{}, nil
+	found, err := h.allocations.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"allocation_id": found.ID,
+		"status":        found.Status,
+		"start_time":    found.StartTime,
+		"end_time":      found.EndTime,
+		"updated_at":    found.UpdatedAt,
+	}, nil
 }
 
-func (h *AllocationHandler) listWorkflows(ctx context.Context) ([]interface{}, error) {
+func (h *AllocationHandler) listWorkflows(ctx context.Context, filter repository.WorkflowFilter) ([]models.Workflow, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -496,11 +595,10 @@ func (h *AllocationHandler) listWorkflows(ctx context.Context) ([]interface{}, e
 	default:
 	}
 
-	// TODO: 实现工作流列表查询
-	return []interface{}{}, nil
+	return h.workflows.List(ctx, filter)
 }
 
-func (h *AllocationHandler) createWorkflow(ctx context.Context) (interface{}, error) {
+func (h *AllocationHandler) createWorkflow(ctx context.Context, req *workflow.CreateRequest) (*models.Workflow, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -508,11 +606,10 @@ func (h *AllocationHandler) createWorkflow(ctx context.Context) (interface{}, er
 	default:
 	}
 
-	// TODO: 实现工作流创建
-	return map[string]interface{}{}, nil
+	return h.workflows.Create(ctx, req)
 }
 
-func (h *AllocationHandler) getWorkflow(ctx context.Context, id string) (interface{}, error) {
+func (h *AllocationHandler) getWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -520,8 +617,7 @@ func (h *AllocationHandler) getWorkflow(ctx context.Context, id string) (interfa
 	default:
 	}
 
-	// TODO: 实现工作流详情查询
-	return map[string]interface{}{}, nil
+	return h.workflows.Get(ctx, id)
 }
 
 func (h *AllocationHandler) deleteWorkflow(ctx context.Context, id string) error {
@@ -532,11 +628,21 @@ func (h *AllocationHandler) deleteWorkflow(ctx context.Context, id string) error
 	default:
 	}
 
-	// TODO: 实现工作流删除
-	return nil
+	return h.workflows.Delete(ctx, id)
+}
+
+func (h *AllocationHandler) retryWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.workflows.Retry(ctx, id)
 }
 
-func (h *AllocationHandler) createDeployWorkflow(ctx context.Context) (interface{}, error) {
+func (h *AllocationHandler) createDeployWorkflow(ctx context.Context, req *workflow.DeployRequest) (*models.Workflow, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -544,11 +650,10 @@ func (h *AllocationHandler) createDeployWorkflow(ctx context.Context) (interface
 	default:
 	}
 
-	// TODO: 实现部署工作流创建
-	return map[string]interface{}{}, nil
+	return h.workflows.Deploy(ctx, req)
 }
 
-func (h *AllocationHandler) createCleanupWorkflow(ctx context.Context) (interface{}, error) {
+func (h *AllocationHandler) createCleanupWorkflow(ctx context.Context, req *workflow.CleanupRequest) (*models.Workflow, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -556,6 +661,5 @@ func (h *AllocationHandler) createCleanupWorkflow(ctx context.Context) (interfac
 	default:
 	}
 
-	// TODO: 实现清理工作流创建
-	return map[string]interface{}{}, nil
+	return h.workflows.Cleanup(ctx, req)
 }
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/deadletter"
+)
+
+// DeadLetterHandler 死信处理器
+type DeadLetterHandler struct {
+	repos       *repository.Repositories
+	deadLetters *deadletter.Service
+}
+
+// NewDeadLetterHandler 创建新的死信处理器
+func NewDeadLetterHandler(repos *repository.Repositories, deadLetterService *deadletter.Service) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		repos:       repos,
+		deadLetters: deadLetterService,
+	}
+}
+
+// List 列出死信
+func (h *DeadLetterHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.DeadLetterFilter{
+		Topic:   c.QueryParam("topic"),
+		Handler: c.QueryParam("handler"),
+	}
+	// status支持逗号分隔的多个状态
+	if status := c.QueryParam("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	letters, err := h.listDeadLetters(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  letters,
+		"total": len(letters),
+	})
+}
+
+// Get 获取死信详情，包含事件原始内容和失败原因
+func (h *DeadLetterHandler) Get(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	letter, err := h.getDeadLetter(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, letter)
+}
+
+// Replay 重放单条死信，处理器再次失败时返回422，死信保持pending并记录新的错误
+func (h *DeadLetterHandler) Replay(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	letter, err := h.replay(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "重放超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, letter)
+}
+
+// ReplayAll 按主题和处理器批量重放pending状态的死信
+func (h *DeadLetterHandler) ReplayAll(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req deadletter.ReplayRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时，批量重放逐条调用处理器
+	businessCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	summary, err := h.replayAll(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "批量重放超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+// Discard 丢弃死信
+func (h *DeadLetterHandler) Discard(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	letter, err := h.discard(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "丢弃超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, letter)
+}
+
+// 服务层方法实现
+
+func (h *DeadLetterHandler) listDeadLetters(ctx context.Context, filter repository.DeadLetterFilter) ([]models.DeadLetter, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.DeadLetters.List(ctx, filter)
+}
+
+func (h *DeadLetterHandler) getDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.DeadLetters.Get(ctx, id)
+}
+
+func (h *DeadLetterHandler) replay(ctx context.Context, id string) (*models.DeadLetter, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.deadLetters.Replay(ctx, id, middleware.GetUserID(ctx))
+}
+
+func (h *DeadLetterHandler) replayAll(ctx context.Context, req *deadletter.ReplayRequest) (*deadletter.ReplaySummary, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.deadLetters.ReplayAll(ctx, req, middleware.GetUserID(ctx))
+}
+
+func (h *DeadLetterHandler) discard(ctx context.Context, id string) (*models.DeadLetter, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.deadLetters.Discard(ctx, id, middleware.GetUserID(ctx))
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/allocation"
+	"gpu-management/internal/services/deadletter"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/eventstore"
+	"gpu-management/internal/services/firmware"
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/internal/services/notify"
+	"gpu-management/internal/services/power"
+	"gpu-management/internal/services/redfish"
+	"gpu-management/internal/services/scheduler"
+	"gpu-management/internal/services/stream"
+	"gpu-management/internal/services/templates"
+	"gpu-management/internal/services/tinkerbell"
+	"gpu-management/internal/services/workflow"
+)
+
+// toHTTPError 将服务层错误转换为HTTP错误
+func toHTTPError(err error) error {
+	// 非法状态迁移返回结构化的409响应
+	var transitionErr *models.TransitionError
+	if errors.As(err, &transitionErr) {
+		return echo.NewHTTPError(http.StatusConflict, map[string]interface{}{
+			"message":    transitionErr.Error(),
+			"transition": transitionErr,
+		})
+	}
+
+	// BMC返回的错误原样带回，便于定位是哪个Redfish资源拒绝了请求
+	var redfishErr *redfish.Error
+	if errors.As(err, &redfishErr) {
+		return echo.NewHTTPError(http.StatusBadGateway, map[string]interface{}{
+			"message":   redfishErr.Error(),
+			"bmc_error": redfishErr,
+		})
+	}
+	var taskErr *redfish.TaskError
+	if errors.As(err, &taskErr) {
+		return echo.NewHTTPError(http.StatusBadGateway, map[string]interface{}{
+			"message": taskErr.Error(),
+			"task":    taskErr.Task,
+		})
+	}
+
+	// IPMI完成码原样带回
+	var completionErr *ipmi.CompletionError
+	if errors.As(err, &completionErr) {
+		return echo.NewHTTPError(http.StatusBadGateway, map[string]interface{}{
+			"message":    completionErr.Error(),
+			"ipmi_error": completionErr,
+		})
+	}
+
+	// Kubernetes API Server返回的错误原样带回
+	var kubeErr *tinkerbell.APIError
+	if errors.As(err, &kubeErr) && !errors.Is(err, tinkerbell.ErrNotFound) && !errors.Is(err, tinkerbell.ErrConflict) {
+		return echo.NewHTTPError(http.StatusBadGateway, map[string]interface{}{
+			"message":          kubeErr.Error(),
+			"kubernetes_error": kubeErr,
+		})
+	}
+
+	switch {
+	case errors.Is(err, redfish.ErrNoEndpoint), errors.Is(err, ipmi.ErrNoEndpoint), errors.Is(err, power.ErrNoEndpoint):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, redfish.ErrUnsupportedReset), errors.Is(err, redfish.ErrUnknownAttribute):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, ipmi.ErrAuthFailed), errors.Is(err, ipmi.ErrUnsupported):
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	case errors.Is(err, ipmi.ErrTimeout):
+		return echo.NewHTTPError(http.StatusGatewayTimeout, err.Error())
+	case errors.Is(err, firmware.ErrNoDriver), errors.Is(err, firmware.ErrNotApplicable):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, firmware.ErrTaskInProgress), errors.Is(err, firmware.ErrNothingToRollback):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, power.ErrServerBusy), errors.Is(err, power.ErrTaskInProgress):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, deadletter.ErrReplayFailed), errors.Is(err, event.ErrHandlerNotFound):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, event.ErrUnknownEventType), errors.Is(err, event.ErrUnknownSchemaVersion):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, event.ErrSchemaValidation), errors.Is(err, event.ErrInvalidEvent):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, eventstore.ErrInvalidCursor), errors.Is(err, stream.ErrInvalidTopic):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, eventstore.ErrTypeNotPublishable):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, stream.ErrHubClosed):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, scheduler.ErrUnknownStrategy):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, scheduler.ErrInsufficientCapacity):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, allocation.ErrMultiServerPlacement):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, notify.ErrDeliveryFailed):
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	case errors.Is(err, tinkerbell.ErrNotConfigured):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, tinkerbell.ErrNotFound), errors.Is(err, tinkerbell.ErrConflict):
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	case errors.Is(err, workflow.ErrWorkflowInProgress), errors.Is(err, workflow.ErrAllocationState),
+		errors.Is(err, workflow.ErrNotResumable):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, workflow.ErrHardwareNotReady):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, templates.ErrInvalidTemplate), errors.Is(err, templates.ErrInvalidParameters):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, repository.ErrCheckViolation):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrConflict), errors.Is(err, repository.ErrReferenceViolation):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
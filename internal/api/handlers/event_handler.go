@@ -2,28 +2,74 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
 
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
 	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/eventstore"
+	"gpu-management/internal/services/stream"
 )
 
 // EventHandler 事件处理器
 type EventHandler struct {
 	eventBus event.EventBus
+	events   *eventstore.Service
+	hub      *stream.Hub
 }
 
 // NewEventHandler 创建新的事件处理器
-func NewEventHandler(eventBus event.EventBus) *EventHandler {
+func NewEventHandler(eventBus event.EventBus, eventStore *eventstore.Service, hub *stream.Hub) *EventHandler {
 	return &EventHandler{
 		eventBus: eventBus,
+		events:   eventStore,
+		hub:      hub,
 	}
 }
 
-// List 列出事件
+// streamHeartbeat SSE心跳间隔，避免代理关闭空闲连接
+const streamHeartbeat = 15 * time.Second
+
+// List 查询事件存储，支持按来源、服务器、GPU、事件类型、严重程度和时间范围过滤，按发生时间倒序游标分页
 func (h *EventHandler) List(c echo.Context) error {
+	query, err := parseEventQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return h.respondEvents(c, query)
+}
+
+// ServerTimeline 查询服务器的事件时间线，包含服务器本身及其GPU、分配和任务的事件
+func (h *EventHandler) ServerTimeline(c echo.Context) error {
+	query, err := parseEventQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	query.ServerID = c.Param("id")
+	return h.respondEvents(c, query)
+}
+
+// GPUTimeline 查询GPU的事件时间线
+func (h *EventHandler) GPUTimeline(c echo.Context) error {
+	query, err := parseEventQuery(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	query.GPUID = c.Param("id")
+	return h.respondEvents(c, query)
+}
+
+// respondEvents 执行事件查询并返回一页结果
+func (h *EventHandler) respondEvents(c echo.Context, query eventstore.Query) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
@@ -32,7 +78,7 @@ func (h *EventHandler) List(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	events, err := h.listEvents(businessCtx)
+	page, err := h.listEvents(businessCtx, query)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -41,26 +87,84 @@ func (h *EventHandler) List(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"data":  events,
-		"total": len(events),
+		"data":        page.Data,
+		"total":       len(page.Data),
+		"next_cursor": page.NextCursor,
 	})
 }
 
-// Create 创建事件
+// parseEventQuery 解析事件查询参数
+// event_type和severity支持逗号分隔的多个值；since和until接受RFC3339时间，since也接受 6h 这样的相对时长
+func parseEventQuery(c echo.Context) (eventstore.Query, error) {
+	query := eventstore.Query{
+		EventFilter: repository.EventFilter{
+			SourceType: c.QueryParam("source_type"),
+			SourceID:   c.QueryParam("source_id"),
+			ServerID:   c.QueryParam("server_id"),
+			GPUID:      c.QueryParam("gpu_id"),
+		},
+		Cursor: c.QueryParam("cursor"),
+	}
+	if v := c.QueryParam("event_type"); v != "" {
+		query.EventTypes = strings.Split(v, ",")
+	}
+	if v := c.QueryParam("severity"); v != "" {
+		query.Severities = strings.Split(v, ",")
+	}
+
+	if v := c.QueryParam("since"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			if d <= 0 {
+				return query, errors.New("since duration must be positive")
+			}
+			query.Since = time.Now().UTC().Add(-d)
+		} else if query.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, errors.New("since must be an RFC3339 time or a duration such as 6h")
+		}
+	}
+	if v := c.QueryParam("until"); v != "" {
+		var err error
+		if query.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, errors.New("until must be an RFC3339 time")
+		}
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		return query, errors.New("since must be before until")
+	}
+
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > eventstore.MaxLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", eventstore.MaxLimit)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// Create 发布事件，返回202和CloudEvent信封，事件由记录器异步写入事件存储
 func (h *EventHandler) Create(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	var req eventstore.PublishRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	event, err := h.createEvent(businessCtx)
+	ce, err := h.createEvent(businessCtx, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -69,10 +173,10 @@ func (h *EventHandler) Create(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusCreated, event)
+	return c.JSON(http.StatusAccepted, ce)
 }
 
 // Get 获取事件详情
@@ -87,7 +191,7 @@ func (h *EventHandler) Get(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	event, err := h.getEvent(businessCtx, id)
+	evt, err := h.getEvent(businessCtx, id)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -96,119 +200,194 @@ func (h *EventHandler) Get(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, event)
+	return c.JSON(http.StatusOK, evt)
 }
 
-// ListAlerts 列出告警
-func (h *EventHandler) ListAlerts(c echo.Context) error {
-	// 获取请求Context
+// Stream 以Server-Sent Events推送实时事件，支持按topic和resource_id过滤
+// 重新连接时通过Last-Event-ID请求头或last_event_id参数从最后收到的事件之后继续推送
+func (h *EventHandler) Stream(c echo.Context) error {
+	// 获取请求Context，客户端断开时取消
 	ctx := c.Request().Context()
 
-	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+	filter, lastEventID := parseStreamQuery(c)
 
 	// 调用服务层，传递Context
-	alerts, err := h.listAlerts(businessCtx)
+	s, err := h.openStream(ctx, filter, lastEventID)
 	if err != nil {
-		// 检查Context相关错误
-		if businessCtx.Err() == context.DeadlineExceeded {
-			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
-		}
-		if businessCtx.Err() == context.Canceled {
-			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"data":  alerts,
-		"total": len(alerts),
-	})
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ce, ok := <-s.Events():
+			if !ok {
+				// 流因服务关闭或读取过慢结束时告知客户端，客户端可携带最后的事件ID重连
+				if err := s.Err(); err != nil {
+					writeSSE(res, "", "error", map[string]string{"message": err.Error()})
+				}
+				return nil
+			}
+			if err := writeSSE(res, ce.ID, ce.Type, ce); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
 }
 
-// CreateAlert 创建告警
-func (h *EventHandler) CreateAlert(c echo.Context) error {
-	// 获取请求Context
-	ctx := c.Request().Context()
-
-	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+// StreamWebSocket 以WebSocket推送实时事件，每条消息是一个CloudEvent，过滤和续传参数与Stream相同
+func (h *EventHandler) StreamWebSocket(c echo.Context) error {
+	// 连接升级后请求Context不再随客户端断开取消，由读循环检测断开
+	ctx, cancel := context.WithCancel(c.Request().Context())
 	defer cancel()
 
+	filter, lastEventID := parseStreamQuery(c)
+
 	// 调用服务层，传递Context
-	alert, err := h.createAlert(businessCtx)
+	s, err := h.openStream(ctx, filter, lastEventID)
 	if err != nil {
-		// 检查Context相关错误
-		if businessCtx.Err() == context.DeadlineExceeded {
-			return echo.NewHTTPError(http.StatusRequestTimeout, "告警创建超时")
+		return toHTTPError(err)
+	}
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		// 客户端不需要发送消息，读取失败说明连接已关闭
+		go func() {
+			defer cancel()
+			var msg string
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+		}()
+
+		// 发送失败时取消推送流，继续读取直到Events关闭
+		for ce := range s.Events() {
+			if err := websocket.JSON.Send(ws, ce); err != nil {
+				cancel()
+			}
 		}
-		if businessCtx.Err() == context.Canceled {
-			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		if err := s.Err(); err != nil {
+			websocket.JSON.Send(ws, map[string]string{"error": err.Error()})
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// parseStreamQuery 解析推送参数：topic为逗号分隔的事件类型模式，resource_id为资源ID
+func parseStreamQuery(c echo.Context) (stream.Filter, string) {
+	filter := stream.Filter{ResourceID: c.QueryParam("resource_id")}
+	if v := c.QueryParam("topic"); v != "" {
+		filter.Topics = strings.Split(v, ",")
+	}
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
 	}
+	return filter, lastEventID
+}
 
-	return c.JSON(http.StatusCreated, alert)
+// writeSSE 写入一条SSE消息并立即发送
+func writeSSE(res *echo.Response, id, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(res, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
 }
 
-// UpdateAlert 更新告警
-func (h *EventHandler) UpdateAlert(c echo.Context) error {
+// ListSchemas 列出所有事件类型的结构定义版本
+func (h *EventHandler) ListSchemas(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
-	id := c.Param("id")
-
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.updateAlert(businessCtx, id)
+	schemas, err := h.listSchemas(businessCtx)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
-			return echo.NewHTTPError(http.StatusRequestTimeout, "告警更新超时")
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
 		}
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  schemas,
+		"total": len(schemas),
+	})
 }
 
-// GetAlertCorrelation 获取告警关联分析
-func (h *EventHandler) GetAlertCorrelation(c echo.Context) error {
+// GetSchema 获取事件类型的结构定义，未指定版本时返回最新版本
+func (h *EventHandler) GetSchema(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	eventType := c.Param("type")
+	version := 0
+	if v := c.Param("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "version must be a positive integer")
+		}
+		version = n
+	}
+
 	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 关联分析可能需要更长时间
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	correlation, err := h.getAlertCorrelation(businessCtx)
+	schema, err := h.getSchema(businessCtx, eventType, version)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
-			return echo.NewHTTPError(http.StatusRequestTimeout, "关联分析超时")
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
 		}
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, correlation)
+	return c.JSON(http.StatusOK, schema)
 }
 
 // 服务层方法实现
-func (h *EventHandler) listEvents(ctx context.Context) ([]interface{}, error) {
+func (h *EventHandler) listEvents(ctx context.Context, query eventstore.Query) (*eventstore.Page, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -216,11 +395,10 @@ func (h *EventHandler) listEvents(ctx context.Context) ([]interface{}, error) {
 	default:
 	}
 
-	// TODO: 实现事件列表查询
-	return []interface{}{}, nil
+	return h.events.List(ctx, query)
 }
 
-func (h *EventHandler) createEvent(ctx context.Context) (interface{}, error) {
+func (h *EventHandler) createEvent(ctx context.Context, req *eventstore.PublishRequest) (*event.CloudEvent, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -228,11 +406,10 @@ func (h *EventHandler) createEvent(ctx context.Context) (interface{}, error) {
 	default:
 	}
 
-	// TODO: 实现事件创建
-	return map[string]interface{}{}, nil
+	return h.events.Publish(ctx, req)
 }
 
-func (h *EventHandler) getEvent(ctx context.Context, id string) (interface{}, error) {
+func (h *EventHandler) getEvent(ctx context.Context, id string) (*models.Event, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -240,11 +417,10 @@ func (h *EventHandler) getEvent(ctx context.Context, id string) (interface{}, er
 	default:
 	}
 
-	// TODO: 实现事件详情查询
-	return map[string]interface{}{}, nil
+	return h.events.Get(ctx, id)
 }
 
-func (h *EventHandler) listAlerts(ctx context.Context) ([]interface{}, error) {
+func (h *EventHandler) openStream(ctx context.Context, filter stream.Filter, lastEventID string) (*stream.Stream, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -252,11 +428,10 @@ func (h *EventHandler) listAlerts(ctx context.Context) ([]interface{}, error) {
 	default:
 	}
 
-	// TODO: 实现告警列表查询
-	return []interface{}{}, nil
+	return h.hub.Open(ctx, filter, lastEventID)
 }
 
-func (h *EventHandler) createAlert(ctx context.Context) (interface{}, error) {
+func (h *EventHandler) listSchemas(ctx context.Context) ([]event.Schema, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -264,23 +439,10 @@ func (h *EventHandler) createAlert(ctx context.Context) (interface{}, error) {
 	default:
 	}
 
-	// TODO: 实现告警创建
-	return map[string]interface{}{}, nil
+	return event.Schemas.List(), nil
 }
 
-func (h *EventHandler) updateAlert(ctx context.Context, id string) error {
-	// 检查Context状态
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
-	// TODO: 实现告警更新
-	return nil
-}
-
-func (h *EventHandler) getAlertCorrelation(ctx context.Context) (interface{}, error) {
+func (h *EventHandler) getSchema(ctx context.Context, eventType string, version int) (*event.Schema, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -288,6 +450,8 @@ func (h *EventHandler) getAlertCorrelation(ctx context.Context) (interface{}, er
 	default:
 	}
 
-	// TODO: 实现告警关联分析
-	return map[string]interface{}{}, nil
+	if version == 0 {
+		return event.Schemas.Latest(eventType)
+	}
+	return event.Schemas.Get(eventType, version)
 }
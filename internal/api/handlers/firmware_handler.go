@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/firmware"
+)
+
+// FirmwareHandler 固件目录和分批升级处理器
+type FirmwareHandler struct {
+	repos    *repository.Repositories
+	firmware *firmware.Service
+}
+
+// NewFirmwareHandler 创建新的固件处理器
+func NewFirmwareHandler(repos *repository.Repositories, firmwareService *firmware.Service) *FirmwareHandler {
+	return &FirmwareHandler{
+		repos:    repos,
+		firmware: firmwareService,
+	}
+}
+
+// List 列出固件目录
+func (h *FirmwareHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.FirmwareFilter{
+		Component: c.QueryParam("component"),
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	items, err := h.listFirmware(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  items,
+		"total": len(items),
+	})
+}
+
+// Create 登记固件
+func (h *FirmwareHandler) Create(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var fw models.Firmware
+	if err := c.Bind(&fw); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := firmware.ValidateFirmware(&fw); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.createFirmware(businessCtx, &fw)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "固件登记超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, fw)
+}
+
+// Get 获取固件详情
+func (h *FirmwareHandler) Get(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	fw, err := h.getFirmware(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, fw)
+}
+
+// Delete 删除固件，已被分批升级引用的固件不能删除
+func (h *FirmwareHandler) Delete(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.deleteFirmware(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListRollouts 列出分批升级
+func (h *FirmwareHandler) ListRollouts(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.FirmwareRolloutFilter{
+		FirmwareID: c.QueryParam("firmware_id"),
+	}
+	// status支持逗号分隔的多个状态
+	if status := c.QueryParam("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rollouts, err := h.listRollouts(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  rollouts,
+		"total": len(rollouts),
+	})
+}
+
+// CreateRollout 创建分批升级
+// 升级以异步任务执行，返回202和分批升级记录，通过 /api/v1/firmware/rollouts/:id 查询进度
+func (h *FirmwareHandler) CreateRollout(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req firmware.RolloutRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时，只覆盖任务提交
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rollout, err := h.createRollout(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "分批升级创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/firmware/rollouts/"+rollout.ID)
+	return c.JSON(http.StatusAccepted, rollout)
+}
+
+// GetRollout 获取分批升级详情，包含每台服务器升级前后的版本
+func (h *FirmwareHandler) GetRollout(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rollout, err := h.getRollout(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, rollout)
+}
+
+// Rollback 回滚分批升级，将升级成功的服务器刷回升级前的版本
+func (h *FirmwareHandler) Rollback(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时，只覆盖任务提交
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rollout, err := h.rollback(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "回滚超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/firmware/rollouts/"+rollout.ID)
+	return c.JSON(http.StatusAccepted, rollout)
+}
+
+// 服务层方法实现
+
+func (h *FirmwareHandler) listFirmware(ctx context.Context, filter repository.FirmwareFilter) ([]models.Firmware, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.Firmware.List(ctx, filter)
+}
+
+func (h *FirmwareHandler) createFirmware(ctx context.Context, fw *models.Firmware) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	fw.CreatedBy = middleware.GetUserID(ctx)
+	return h.repos.Firmware.Create(ctx, fw)
+}
+
+func (h *FirmwareHandler) getFirmware(ctx context.Context, id string) (*models.Firmware, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.Firmware.Get(ctx, id)
+}
+
+func (h *FirmwareHandler) deleteFirmware(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.repos.Firmware.Delete(ctx, id)
+}
+
+func (h *FirmwareHandler) listRollouts(ctx context.Context, filter repository.FirmwareRolloutFilter) ([]models.FirmwareRollout, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.Rollouts.List(ctx, filter)
+}
+
+func (h *FirmwareHandler) createRollout(ctx context.Context, req *firmware.RolloutRequest) (*models.FirmwareRollout, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.firmware.CreateRollout(ctx, req, middleware.GetUserID(ctx))
+}
+
+func (h *FirmwareHandler) getRollout(ctx context.Context, id string) (*models.FirmwareRollout, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.Rollouts.Get(ctx, id)
+}
+
+func (h *FirmwareHandler) rollback(ctx context.Context, id string) (*models.FirmwareRollout, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.firmware.Rollback(ctx, id, middleware.GetUserID(ctx))
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -9,18 +10,37 @@ import (
 
 	"gpu-management/internal/api/middleware"
 	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
 	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/outbox"
+	"gpu-management/internal/services/rules"
 )
 
 // GPUHandler GPU处理器
 type GPUHandler struct {
 	eventBus event.EventBus
+	repos    *repository.Repositories
+	rules    *rules.Engine
+}
+
+// GPUUpdateRequest GPU更新请求，只更新提供的字段，状态变更需符合GPU状态机，reason记录到状态历史
+type GPUUpdateRequest struct {
+	models.GPU
+	Reason string `json:"reason"`
+}
+
+// GPUStatusRequest GPU状态变更请求，只迁移状态，reason记录到状态历史
+type GPUStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
 }
 
 // NewGPUHandler 创建新的GPU处理器
-func NewGPUHandler(eventBus event.EventBus) *GPUHandler {
+func NewGPUHandler(eventBus event.EventBus, repos *repository.Repositories, rulesEngine *rules.Engine) *GPUHandler {
 	return &GPUHandler{
 		eventBus: eventBus,
+		repos:    repos,
+		rules:    rulesEngine,
 	}
 }
 
@@ -33,12 +53,19 @@ func (h *GPUHandler) List(c echo.Context) error {
 	requestID := middleware.GetRequestID(ctx)
 	operation := middleware.GetOperation(ctx)
 
+	// 查询过滤条件
+	filter := repository.GPUFilter{
+		ServerID: c.QueryParam("server_id"),
+		Status:   c.QueryParam("status"),
+		Model:    c.QueryParam("model"),
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	gpus, err := h.listGPUs(businessCtx)
+	gpus, err := h.listGPUs(businessCtx, filter)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -47,7 +74,7 @@ func (h *GPUHandler) List(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -79,7 +106,7 @@ func (h *GPUHandler) Get(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, gpu)
@@ -92,8 +119,8 @@ func (h *GPUHandler) Update(c echo.Context) error {
 
 	id := c.Param("id")
 
-	var gpu models.GPU
-	if err := c.Bind(&gpu); err != nil {
+	var req GPUUpdateRequest
+	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
@@ -102,7 +129,7 @@ func (h *GPUHandler) Update(c echo.Context) error {
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.updateGPU(businessCtx, id, &gpu)
+	updated, err := h.updateGPU(businessCtx, id, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -111,11 +138,45 @@ func (h *GPUHandler) Update(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	gpu.ID = id
-	return c.JSON(http.StatusOK, gpu)
+	return c.JSON(http.StatusOK, updated)
+}
+
+// SetStatus 按GPU状态机变更GPU状态
+func (h *GPUHandler) SetStatus(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var req GPUStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.Status == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "status is required")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	updated, err := h.setGPUStatus(businessCtx, id, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, updated)
 }
 
 // GetStatus 获取GPU状态
@@ -139,12 +200,42 @@ func (h *GPUHandler) GetStatus(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, status)
 }
 
+// GetHistory 获取GPU状态迁移历史
+func (h *GPUHandler) GetHistory(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	history, err := h.getGPUHistory(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  history,
+		"total": len(history),
+	})
+}
+
 // GetMetrics 获取GPU指标
 func (h *GPUHandler) GetMetrics(c echo.Context) error {
 	// 获取请求Context
@@ -166,12 +257,48 @@ func (h *GPUHandler) GetMetrics(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, metrics)
 }
 
+// ReportMetrics 上报GPU指标，按告警规则评估并返回各规则的评估结果
+func (h *GPUHandler) ReportMetrics(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var sample models.GPUUtilization
+	if err := c.Bind(&sample); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	sample.GPUID = id
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	evaluations, err := h.reportGPUMetrics(businessCtx, &sample)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "指标评估超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"gpu_id":      id,
+		"evaluations": evaluations,
+	})
+}
+
 // UpdateConfig 更新GPU配置
 func (h *GPUHandler) UpdateConfig(c echo.Context) error {
 	// 获取请求Context
@@ -198,26 +325,9 @@ func (h *GPUHandler) UpdateConfig(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
-
-	// 发布配置更新事件
-	event := event.HardwareEvent{
-		Event: event.Event{
-			Type:   event.EventTypeHardwareProvisioned,
-			Source: "gpu-service",
-		},
-		HardwareID: id,
-		Status:     "config_updated",
+		return toHTTPError(err)
 	}
 
-	// 使用Context发布事件
-	if err := h.eventBus.Publish(businessCtx, "gpu.config.updated", event); err != nil {
-		// 事件发布失败不影响主流程，只记录日志
-		c.Logger().Errorf("Failed to publish event: %v", err)
-	}
-
-	config.GPUID = id
 	return c.JSON(http.StatusOK, config)
 }
 
@@ -242,7 +352,7 @@ func (h *GPUHandler) GetConfig(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, config)
@@ -269,14 +379,14 @@ func (h *GPUHandler) GetConfigVersions(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, versions)
 }
 
 // 服务层方法实现
-func (h *GPUHandler) listGPUs(ctx context.Context) ([]models.GPU, error) {
+func (h *GPUHandler) listGPUs(ctx context.Context, filter repository.GPUFilter) ([]models.GPU, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -284,21 +394,21 @@ func (h *GPUHandler) listGPUs(ctx context.Context) ([]models.GPU, error) {
 	default:
 	}
 
-	// TODO: 实现GPU列表查询
-	gpus := []models.GPU{
-		{
-			ID:       "gpu-001",
-			ServerID: "server-001",
-			Model:    "NVIDIA A100",
-			Status:   models.GPUStatusAvailable,
-			MemoryGB: 80,
-		},
+	return h.repos.GPUs.List(ctx, filter)
+}
+
+func (h *GPUHandler) getGPU(ctx context.Context, id string) (*models.GPU, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
 	}
 
-	return gpus, nil
+	return h.repos.GPUs.Get(ctx, id)
 }
 
-func (h *GPUHandler) getGPU(ctx context.Context, id string) (*models.GPU, error) {
+func (h *GPUHandler) updateGPU(ctx context.Context, id string, req *GPUUpdateRequest) (*models.GPU, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -306,28 +416,90 @@ func (h *GPUHandler) getGPU(ctx context.Context, id string) (*models.GPU, error)
 	default:
 	}
 
-	// TODO: 实现GPU详情查询
-	gpu := &models.GPU{
-		ID:       id,
-		ServerID: "server-001",
-		Model:    "NVIDIA A100",
-		Status:   models.GPUStatusAvailable,
-		MemoryGB: 80,
+	existing, err := h.repos.GPUs.Get(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	return gpu, nil
+	// 只合并请求中提供的字段，未提供的字段保持不变
+	gpu := *existing
+	mergeGPU(&gpu, &req.GPU)
+
+	// 状态变更必须符合状态机，并以当前状态为条件迁移，同时写入历史
+	// 其余字段随后整行更新，GPU仓储的Update不修改状态，不会覆盖调度器等并发的状态迁移
+	if gpu.Status != existing.Status {
+		if err := models.GPUTransitions.Validate("gpu", id, existing.Status, gpu.Status); err != nil {
+			return nil, err
+		}
+	}
+	err = h.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if gpu.Status != existing.Status {
+			change := repository.StatusChange{Actor: actorOf(ctx, ""), Reason: req.Reason}
+			if err := tx.GPUs.TransitionStatus(ctx, []string{id}, existing.Status, gpu.Status, change); err != nil {
+				return err
+			}
+		}
+		return tx.GPUs.Update(ctx, &gpu)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.repos.GPUs.Get(ctx, id)
+}
+
+// mergeGPU 把更新请求中的非零字段合并到gpu
+func mergeGPU(gpu, update *models.GPU) {
+	if update.ServerID != "" {
+		gpu.ServerID = update.ServerID
+	}
+	if update.Model != "" {
+		gpu.Model = update.Model
+	}
+	if update.Status != "" {
+		gpu.Status = update.Status
+	}
+	if update.MemoryGB != 0 {
+		gpu.MemoryGB = update.MemoryGB
+	}
+	if update.SerialNumber != "" {
+		gpu.SerialNumber = update.SerialNumber
+	}
+	if update.DriverVersion != "" {
+		gpu.DriverVersion = update.DriverVersion
+	}
+	if update.CUDAVersion != "" {
+		gpu.CUDAVersion = update.CUDAVersion
+	}
+	if update.GNMIDeviceID != "" {
+		gpu.GNMIDeviceID = update.GNMIDeviceID
+	}
 }
 
-func (h *GPUHandler) updateGPU(ctx context.Context, id string, gpu *models.GPU) error {
+func (h *GPUHandler) setGPUStatus(ctx context.Context, id string, req *GPUStatusRequest) (*models.GPU, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现GPU信息更新
-	return nil
+	existing, err := h.repos.GPUs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status == existing.Status {
+		return existing, nil
+	}
+
+	// 以当前状态为条件迁移，只修改状态并写入历史
+	if err := models.GPUTransitions.Validate("gpu", id, existing.Status, req.Status); err != nil {
+		return nil, err
+	}
+	change := repository.StatusChange{Actor: actorOf(ctx, ""), Reason: req.Reason}
+	if err := h.repos.GPUs.TransitionStatus(ctx, []string{id}, existing.Status, req.Status, change); err != nil {
+		return nil, err
+	}
+	return h.repos.GPUs.Get(ctx, id)
 }
 
 func (h *GPUHandler) getGPUStatus(ctx context.Context, id string) (map[string]interface{}, error) {
@@ -338,22 +510,36 @@ func (h *GPUHandler) getGPUStatus(ctx context.Context, id string) (map[string]in
 	default:
 	}
 
-	// TODO: 实现GPU状态查询
+	gpu, err := h.repos.GPUs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	status := map[string]interface{}{
-		"gpu_id": id,
-		"status": models.GPUStatusAvailable,
-		"utilization": map[string]interface{}{
-			"gpu_usage_percent":     45.2,
-			"memory_usage_percent":  60.8,
-			"compute_usage_percent": 42.1,
-			"temperature":           65.0,
-			"power_consumption":     180.5,
-		},
+		"gpu_id":     gpu.ID,
+		"server_id":  gpu.ServerID,
+		"status":     gpu.Status,
+		"updated_at": gpu.UpdatedAt,
 	}
 
 	return status, nil
 }
 
+func (h *GPUHandler) getGPUHistory(ctx context.Context, id string) ([]models.GPUStatusTransition, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := h.repos.GPUs.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return h.repos.GPUs.History(ctx, id)
+}
+
 func (h *GPUHandler) getGPUMetrics(ctx context.Context, id string) (*models.GPUUtilization, error) {
 	// 检查Context状态
 	select {
@@ -362,7 +548,11 @@ func (h *GPUHandler) getGPUMetrics(ctx context.Context, id string) (*models.GPUU
 	default:
 	}
 
-	// TODO: 实现GPU指标查询
+	if _, err := h.repos.GPUs.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// TODO: 接入gNMI遥测后返回实时指标
 	metrics := &models.GPUUtilization{
 		GPUID:                id,
 		GPUUsagePercent:      45.2,
@@ -376,6 +566,17 @@ func (h *GPUHandler) getGPUMetrics(ctx context.Context, id string) (*models.GPUU
 	return metrics, nil
 }
 
+func (h *GPUHandler) reportGPUMetrics(ctx context.Context, sample *models.GPUUtilization) ([]rules.Evaluation, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.rules.Observe(ctx, sample)
+}
+
 func (h *GPUHandler) updateGPUConfig(ctx context.Context, id string, config *models.GPUConfig) error {
 	// 检查Context状态
 	select {
@@ -384,8 +585,42 @@ func (h *GPUHandler) updateGPUConfig(ctx context.Context, id string, config *mod
 	default:
 	}
 
-	// TODO: 实现GPU配置更新
-	return nil
+	if _, err := h.repos.GPUs.Get(ctx, id); err != nil {
+		return err
+	}
+
+	// 配置按版本追加保存，未指定版本时自动递增
+	versions, err := h.repos.GPUConfigs.ListVersions(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	config.ID = ""
+	config.GPUID = id
+	if config.Version == "" {
+		config.Version = fmt.Sprintf("v%d", len(versions)+1)
+	}
+	if config.CreatedBy == "" {
+		config.CreatedBy = middleware.GetUserID(ctx)
+	}
+
+	// 配置和配置更新事件在同一事务中写入，事件由发件箱转发器发布
+	return h.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.GPUConfigs.Create(ctx, config); err != nil {
+			return err
+		}
+
+		evt, err := event.NewCloudEvent("gpu-service", event.EventTypeGPUConfigUpdated, id, event.GPUConfigEvent{
+			GPUID:     id,
+			ConfigID:  config.ID,
+			Version:   config.Version,
+			CreatedBy: config.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Enqueue(ctx, tx.Outbox, evt)
+	})
 }
 
 func (h *GPUHandler) getGPUConfig(ctx context.Context, id string) (*models.GPUConfig, error) {
@@ -396,19 +631,7 @@ func (h *GPUHandler) getGPUConfig(ctx context.Context, id string) (*models.GPUCo
 	default:
 	}
 
-	// TODO: 实现GPU配置查询
-	config := &models.GPUConfig{
-		GPUID:       id,
-		Version:     "v1.0",
-		PowerLimit:  200,
-		MemoryClock: 8000,
-		DriverConfig: map[string]string{
-			"driver_version": "470.82.01",
-			"cuda_version":   "11.4",
-		},
-	}
-
-	return config, nil
+	return h.repos.GPUConfigs.Latest(ctx, id)
 }
 
 func (h *GPUHandler) getGPUConfigVersions(ctx context.Context, id string) ([]models.GPUConfig, error) {
@@ -419,19 +642,9 @@ func (h *GPUHandler) getGPUConfigVersions(ctx context.Context, id string) ([]mod
 	default:
 	}
 
-	// TODO: 实现GPU配置版本查询
-	versions := []models.GPUConfig{
-		{
-			ID:      "config-001",
-			GPUID:   id,
-			Version: "v1.0",
-		},
-		{
-			ID:      "config-002",
-			GPUID:   id,
-			Version: "v1.1",
-		},
+	if _, err := h.repos.GPUs.Get(ctx, id); err != nil {
+		return nil, err
 	}
 
-	return versions, nil
+	return h.repos.GPUConfigs.ListVersions(ctx, id)
 }
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// newGPU 创建一台服务器和一张指定状态的GPU
+func newGPU(t *testing.T, repos *repository.Repositories, status string) *models.GPU {
+	t.Helper()
+	ctx := context.Background()
+	server := &models.Server{Name: "node-1", Status: models.ServerStatusReady}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	gpu := &models.GPU{ServerID: server.ID, Model: "A100", MemoryGB: 80, SerialNumber: "SN-1", Status: status}
+	if err := repos.GPUs.Create(ctx, gpu); err != nil {
+		t.Fatalf("failed to create gpu: %v", err)
+	}
+	return gpu
+}
+
+// serveGPU 以JSON请求体调用GPU处理器，返回响应状态码
+func serveGPU(t *testing.T, handle echo.HandlerFunc, method, id, body string) int {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(method, "/api/v1/gpus/"+id, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	if err := handle(c); err != nil {
+		var httpErr *echo.HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("handler error = %v, want an HTTP error", err)
+		}
+		return httpErr.Code
+	}
+	return rec.Code
+}
+
+func TestGPUHandlerSetStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        string
+		body        string
+		wantCode    int
+		wantStatus  string
+		wantHistory int
+	}{
+		{name: "to error", from: models.GPUStatusAvailable, body: `{"status":"error","reason":"xid 79"}`, wantCode: http.StatusOK, wantStatus: models.GPUStatusError, wantHistory: 1},
+		{name: "unchanged", from: models.GPUStatusAvailable, body: `{"status":"available"}`, wantCode: http.StatusOK, wantStatus: models.GPUStatusAvailable},
+		{name: "illegal transition", from: models.GPUStatusAvailable, body: `{"status":"in_use"}`, wantCode: http.StatusConflict, wantStatus: models.GPUStatusAvailable},
+		{name: "missing status", from: models.GPUStatusAvailable, body: `{"reason":"xid 79"}`, wantCode: http.StatusBadRequest, wantStatus: models.GPUStatusAvailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			h := NewGPUHandler(nil, repos, nil)
+			gpu := newGPU(t, repos, tt.from)
+
+			if code := serveGPU(t, h.SetStatus, http.MethodPost, gpu.ID, tt.body); code != tt.wantCode {
+				t.Fatalf("SetStatus() code = %d, want %d", code, tt.wantCode)
+			}
+
+			// 只迁移状态，其余字段保持不变
+			got, err := repos.GPUs.Get(context.Background(), gpu.ID)
+			if err != nil {
+				t.Fatalf("failed to get gpu: %v", err)
+			}
+			if got.Status != tt.wantStatus || got.Model != gpu.Model || got.MemoryGB != gpu.MemoryGB || got.SerialNumber != gpu.SerialNumber {
+				t.Errorf("gpu = %+v, want status %s with model, memory and serial unchanged", got, tt.wantStatus)
+			}
+			history, err := repos.GPUs.History(context.Background(), gpu.ID)
+			if err != nil {
+				t.Fatalf("History() error = %v", err)
+			}
+			if len(history) != tt.wantHistory {
+				t.Errorf("history = %d entries, want %d", len(history), tt.wantHistory)
+			}
+		})
+	}
+}
+
+func TestGPUHandlerUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantStatus string
+		wantGPU    func(gpu *models.GPU) bool
+	}{
+		{
+			name:       "status with reason only",
+			body:       `{"status":"error","reason":"fell off the bus"}`,
+			wantCode:   http.StatusOK,
+			wantStatus: models.GPUStatusError,
+			wantGPU:    func(gpu *models.GPU) bool { return gpu.Model == "A100" && gpu.MemoryGB == 80 },
+		},
+		{
+			name:       "partial field update",
+			body:       `{"driver_version":"535.104.05"}`,
+			wantCode:   http.StatusOK,
+			wantStatus: models.GPUStatusAvailable,
+			wantGPU: func(gpu *models.GPU) bool {
+				return gpu.DriverVersion == "535.104.05" && gpu.Model == "A100" && gpu.SerialNumber == "SN-1"
+			},
+		},
+		{
+			name:       "invalid memory",
+			body:       `{"memory_gb":-1}`,
+			wantCode:   http.StatusUnprocessableEntity,
+			wantStatus: models.GPUStatusAvailable,
+			wantGPU:    func(gpu *models.GPU) bool { return gpu.MemoryGB == 80 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			h := NewGPUHandler(nil, repos, nil)
+			gpu := newGPU(t, repos, models.GPUStatusAvailable)
+
+			if code := serveGPU(t, h.Update, http.MethodPut, gpu.ID, tt.body); code != tt.wantCode {
+				t.Fatalf("Update() code = %d, want %d", code, tt.wantCode)
+			}
+			got, err := repos.GPUs.Get(context.Background(), gpu.ID)
+			if err != nil {
+				t.Fatalf("failed to get gpu: %v", err)
+			}
+			if got.Status != tt.wantStatus || !tt.wantGPU(got) {
+				t.Errorf("gpu = %+v, want status %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,782 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/notify"
+)
+
+// NotificationHandler 告警通知处理器
+type NotificationHandler struct {
+	notifier *notify.Dispatcher
+}
+
+// NewNotificationHandler 创建新的告警通知处理器
+func NewNotificationHandler(notifier *notify.Dispatcher) *NotificationHandler {
+	return &NotificationHandler{
+		notifier: notifier,
+	}
+}
+
+// List 列出通知记录，支持按告警、渠道和状态过滤，status可用逗号分隔多个值
+func (h *NotificationHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.NotificationFilter{
+		AlertID:   c.QueryParam("alert_id"),
+		ChannelID: c.QueryParam("channel_id"),
+	}
+	if v := c.QueryParam("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listNotifications(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// ListChannels 列出通知渠道，密钥已隐藏
+func (h *NotificationHandler) ListChannels(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listChannels(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// CreateChannel 创建通知渠道
+func (h *NotificationHandler) CreateChannel(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req notify.ChannelRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	channel, err := h.createChannel(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "通知渠道创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, channel)
+}
+
+// GetChannel 获取通知渠道详情，密钥已隐藏
+func (h *NotificationHandler) GetChannel(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	channel, err := h.getChannel(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, channel)
+}
+
+// UpdateChannel 替换通知渠道，config.secret传回******时保留原密钥
+func (h *NotificationHandler) UpdateChannel(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var req notify.ChannelRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	channel, err := h.updateChannel(businessCtx, id, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "通知渠道更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel 删除通知渠道，仍被路由使用时返回409
+func (h *NotificationHandler) DeleteChannel(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.deleteChannel(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "通知渠道删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListRoutes 列出通知路由
+func (h *NotificationHandler) ListRoutes(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listRoutes(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// CreateRoute 创建通知路由
+func (h *NotificationHandler) CreateRoute(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req notify.RouteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	route, err := h.createRoute(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "通知路由创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, route)
+}
+
+// GetRoute 获取通知路由详情
+func (h *NotificationHandler) GetRoute(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	route, err := h.getRoute(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, route)
+}
+
+// UpdateRoute 替换通知路由
+func (h *NotificationHandler) UpdateRoute(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var req notify.RouteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	route, err := h.updateRoute(businessCtx, id, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "通知路由更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, route)
+}
+
+// DeleteRoute 删除通知路由
+func (h *NotificationHandler) DeleteRoute(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.deleteRoute(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "通知路由删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListSchedules 列出值班表
+func (h *NotificationHandler) ListSchedules(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listSchedules(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// CreateSchedule 创建值班表
+func (h *NotificationHandler) CreateSchedule(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req notify.ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	schedule, err := h.createSchedule(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "值班表创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, schedule)
+}
+
+// GetSchedule 获取值班表详情
+func (h *NotificationHandler) GetSchedule(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	schedule, err := h.getSchedule(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule 替换值班表
+func (h *NotificationHandler) UpdateSchedule(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var req notify.ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	schedule, err := h.updateSchedule(businessCtx, id, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "值班表更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule 删除值班表，仍被路由使用时返回409
+func (h *NotificationHandler) DeleteSchedule(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.deleteSchedule(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "值班表删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TestChannel 向渠道同步发送一条测试通知，发送失败时返回502
+func (h *NotificationHandler) TestChannel(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	msg, err := h.testChannel(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "测试通知发送超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"channel_id": id,
+		"delivered":  true,
+		"subject":    msg.Subject,
+		"text":       msg.Text,
+	})
+}
+
+// GetOnCall 获取值班表的值班人，at为RFC3339时间，为空时为当前时间
+func (h *NotificationHandler) GetOnCall(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+	at := time.Now().UTC()
+	if v := c.QueryParam("at"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid at: must be RFC3339")
+		}
+		at = parsed
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	member, err := h.getOnCall(businessCtx, id, at)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"schedule_id": id,
+		"at":          at,
+		"on_call":     member,
+	})
+}
+
+// 服务层方法实现
+func (h *NotificationHandler) listNotifications(ctx context.Context, filter repository.NotificationFilter) ([]models.Notification, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.ListNotifications(ctx, filter)
+}
+
+func (h *NotificationHandler) listChannels(ctx context.Context) ([]models.NotificationChannel, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.ListChannels(ctx)
+}
+
+func (h *NotificationHandler) createChannel(ctx context.Context, req *notify.ChannelRequest) (*models.NotificationChannel, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.CreateChannel(ctx, req)
+}
+
+func (h *NotificationHandler) getChannel(ctx context.Context, id string) (*models.NotificationChannel, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.GetChannel(ctx, id)
+}
+
+func (h *NotificationHandler) updateChannel(ctx context.Context, id string, req *notify.ChannelRequest) (*models.NotificationChannel, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.UpdateChannel(ctx, id, req)
+}
+
+func (h *NotificationHandler) deleteChannel(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.notifier.DeleteChannel(ctx, id)
+}
+
+func (h *NotificationHandler) listRoutes(ctx context.Context) ([]models.NotificationRoute, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.ListRoutes(ctx)
+}
+
+func (h *NotificationHandler) createRoute(ctx context.Context, req *notify.RouteRequest) (*models.NotificationRoute, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.CreateRoute(ctx, req)
+}
+
+func (h *NotificationHandler) getRoute(ctx context.Context, id string) (*models.NotificationRoute, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.GetRoute(ctx, id)
+}
+
+func (h *NotificationHandler) updateRoute(ctx context.Context, id string, req *notify.RouteRequest) (*models.NotificationRoute, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.UpdateRoute(ctx, id, req)
+}
+
+func (h *NotificationHandler) deleteRoute(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.notifier.DeleteRoute(ctx, id)
+}
+
+func (h *NotificationHandler) listSchedules(ctx context.Context) ([]models.OnCallSchedule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.ListSchedules(ctx)
+}
+
+func (h *NotificationHandler) createSchedule(ctx context.Context, req *notify.ScheduleRequest) (*models.OnCallSchedule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.CreateSchedule(ctx, req)
+}
+
+func (h *NotificationHandler) getSchedule(ctx context.Context, id string) (*models.OnCallSchedule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.GetSchedule(ctx, id)
+}
+
+func (h *NotificationHandler) updateSchedule(ctx context.Context, id string, req *notify.ScheduleRequest) (*models.OnCallSchedule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.UpdateSchedule(ctx, id, req)
+}
+
+func (h *NotificationHandler) deleteSchedule(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.notifier.DeleteSchedule(ctx, id)
+}
+
+func (h *NotificationHandler) testChannel(ctx context.Context, id string) (*notify.Message, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.TestChannel(ctx, id)
+}
+
+func (h *NotificationHandler) getOnCall(ctx context.Context, id string, at time.Time) (*models.OnCallMember, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.notifier.OnCall(ctx, id, at)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/rules"
+)
+
+// RuleHandler 告警规则处理器
+type RuleHandler struct {
+	engine *rules.Engine
+}
+
+// NewRuleHandler 创建新的告警规则处理器
+func NewRuleHandler(engine *rules.Engine) *RuleHandler {
+	return &RuleHandler{
+		engine: engine,
+	}
+}
+
+// List 列出告警规则
+func (h *RuleHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listRules(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// Create 创建告警规则
+func (h *RuleHandler) Create(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req rules.RuleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rule, err := h.createRule(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "告警规则创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// Get 获取告警规则详情
+func (h *RuleHandler) Get(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rule, err := h.getRule(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// Update 替换告警规则
+func (h *RuleHandler) Update(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	var req rules.RuleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	rule, err := h.updateRule(businessCtx, id, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "告警规则更新超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// Delete 删除告警规则
+func (h *RuleHandler) Delete(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	if err := h.deleteRule(businessCtx, id); err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "告警规则删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// 服务层方法实现
+func (h *RuleHandler) listRules(ctx context.Context) ([]models.AlertRule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.engine.ListRules(ctx)
+}
+
+func (h *RuleHandler) createRule(ctx context.Context, req *rules.RuleRequest) (*models.AlertRule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.engine.CreateRule(ctx, req)
+}
+
+func (h *RuleHandler) getRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.engine.GetRule(ctx, id)
+}
+
+func (h *RuleHandler) updateRule(ctx context.Context, id string, req *rules.RuleRequest) (*models.AlertRule, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.engine.UpdateRule(ctx, id, req)
+}
+
+func (h *RuleHandler) deleteRule(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.engine.DeleteRule(ctx, id)
+}
@@ -2,23 +2,47 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
 	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/firmware"
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/internal/services/power"
+	"gpu-management/internal/services/redfish"
 )
 
+// BIOSRequest BIOS配置请求
+type BIOSRequest struct {
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
 // ServerHandler 服务器处理器
 type ServerHandler struct {
 	eventBus event.EventBus
+	repos    *repository.Repositories
+	redfish  *redfish.Pool
+	ipmi     *ipmi.Pool
+	power    *power.Service
+	firmware *firmware.Service
 }
 
 // NewServerHandler 创建新的服务器处理器
-func NewServerHandler(eventBus event.EventBus) *ServerHandler {
+func NewServerHandler(eventBus event.EventBus, repos *repository.Repositories, redfishPool *redfish.Pool, ipmiPool *ipmi.Pool, powerService *power.Service, firmwareService *firmware.Service) *ServerHandler {
 	return &ServerHandler{
 		eventBus: eventBus,
+		repos:    repos,
+		redfish:  redfishPool,
+		ipmi:     ipmiPool,
+		power:    powerService,
+		firmware: firmwareService,
 	}
 }
 
@@ -41,7 +65,7 @@ func (h *ServerHandler) List(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -55,12 +79,20 @@ func (h *ServerHandler) Create(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
+	var server models.Server
+	if err := c.Bind(&server); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if server.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 服务器创建可能需要更长时间
 	defer cancel()
 
 	// 调用服务层，传递Context
-	server, err := h.createServer(businessCtx)
+	err := h.createServer(businessCtx, &server)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -69,7 +101,7 @@ func (h *ServerHandler) Create(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusCreated, server)
@@ -96,7 +128,7 @@ func (h *ServerHandler) Get(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, server)
@@ -109,12 +141,17 @@ func (h *ServerHandler) Update(c echo.Context) error {
 
 	id := c.Param("id")
 
+	var server models.Server
+	if err := c.Bind(&server); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.updateServer(businessCtx, id)
+	updated, err := h.updateServer(businessCtx, id, &server)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -123,10 +160,10 @@ func (h *ServerHandler) Update(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, updated)
 }
 
 // Delete 删除服务器
@@ -150,25 +187,34 @@ func (h *ServerHandler) Delete(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
 // PowerControl 电源控制
+// 电源操作以异步任务执行，返回202和任务，通过 /api/v1/tasks/:id 查询进度
 func (h *ServerHandler) PowerControl(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
 	id := c.Param("id")
 
-	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 电源控制可能需要更长时间
+	var req power.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时，只覆盖任务提交，任务执行时间由电源服务配置
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.powerControl(businessCtx, id)
+	task, err := h.powerControl(businessCtx, id, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -177,10 +223,11 @@ func (h *ServerHandler) PowerControl(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/tasks/"+task.ID)
+	return c.JSON(http.StatusAccepted, task)
 }
 
 // GetStatus 获取服务器状态
@@ -204,12 +251,82 @@ func (h *ServerHandler) GetStatus(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, status)
 }
 
+// GetSensors 获取服务器传感器读数（IPMI SDR）
+func (h *ServerHandler) GetSensors(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时，SDR需要逐条读取
+	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	sensors, err := h.getServerSensors(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  sensors,
+		"total": len(sensors),
+	})
+}
+
+// GetSEL 获取服务器系统事件日志（IPMI SEL）
+// 可选参数limit只返回最近的N条
+func (h *ServerHandler) GetSEL(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive integer")
+		}
+		limit = n
+	}
+
+	// 设置业务超时，SEL需要逐条读取
+	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	entries, err := h.getServerSEL(businessCtx, id, limit)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  entries,
+		"total": len(entries),
+	})
+}
+
 // ConfigureBIOS 配置BIOS
 func (h *ServerHandler) ConfigureBIOS(c echo.Context) error {
 	// 获取请求Context
@@ -217,12 +334,20 @@ func (h *ServerHandler) ConfigureBIOS(c echo.Context) error {
 
 	id := c.Param("id")
 
+	var req BIOSRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(req.Attributes) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "attributes is required")
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 120*time.Second) // BIOS配置可能需要更长时间
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.configureBIOS(businessCtx, id)
+	update, err := h.configureBIOS(businessCtx, id, req.Attributes)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -231,25 +356,34 @@ func (h *ServerHandler) ConfigureBIOS(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, update)
 }
 
 // UpgradeFirmware 升级固件
+// 升级以异步任务执行，返回202和任务，通过 /api/v1/tasks/:id 查询进度
 func (h *ServerHandler) UpgradeFirmware(c echo.Context) error {
 	// 获取请求Context
 	ctx := c.Request().Context()
 
 	id := c.Param("id")
 
-	// 设置业务超时
-	businessCtx, cancel := context.WithTimeout(ctx, 300*time.Second) // 固件升级可能需要更长时间
+	var req firmware.UpgradeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时，只覆盖任务提交，任务执行时间由固件服务配置
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.upgradeFirmware(businessCtx, id)
+	task, err := h.upgradeFirmware(businessCtx, id, &req)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -258,10 +392,41 @@ func (h *ServerHandler) UpgradeFirmware(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/tasks/"+task.ID)
+	return c.JSON(http.StatusAccepted, task)
+}
+
+// GetFirmware 获取服务器当前的固件版本
+func (h *ServerHandler) GetFirmware(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	components, err := h.getServerFirmware(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  components,
+		"total": len(components),
+	})
 }
 
 // GetGPUs 获取服务器的GPU
@@ -285,7 +450,7 @@ func (h *ServerHandler) GetGPUs(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, gpus)
@@ -298,12 +463,23 @@ func (h *ServerHandler) AddGPU(c echo.Context) error {
 
 	id := c.Param("id")
 
+	var gpu models.GPU
+	if err := c.Bind(&gpu); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if gpu.MemoryGB <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "memory_gb must be greater than 0")
+	}
+	if gpu.Status != "" && gpu.Status != models.GPUStatusAvailable {
+		return echo.NewHTTPError(http.StatusBadRequest, "status of a new gpu must be empty or "+models.GPUStatusAvailable)
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // GPU添加可能需要更长时间
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.addGPUToServer(businessCtx, id)
+	err := h.addGPUToServer(businessCtx, id, &gpu)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -312,10 +488,10 @@ func (h *ServerHandler) AddGPU(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusCreated, gpu)
 }
 
 // GetConfig 获取服务器配置
@@ -339,7 +515,7 @@ func (h *ServerHandler) GetConfig(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, config)
@@ -352,12 +528,17 @@ func (h *ServerHandler) UpdateConfig(c echo.Context) error {
 
 	id := c.Param("id")
 
+	var config models.ServerConfig
+	if err := c.Bind(&config); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	// 设置业务超时
 	businessCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 配置更新可能需要更长时间
 	defer cancel()
 
 	// 调用服务层，传递Context
-	err := h.updateServerConfig(businessCtx, id)
+	err := h.updateServerConfig(businessCtx, id, &config)
 	if err != nil {
 		// 检查Context相关错误
 		if businessCtx.Err() == context.DeadlineExceeded {
@@ -366,10 +547,10 @@ func (h *ServerHandler) UpdateConfig(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{})
+	return c.JSON(http.StatusOK, config)
 }
 
 // GetConfigVersions 获取配置版本
@@ -393,14 +574,14 @@ func (h *ServerHandler) GetConfigVersions(c echo.Context) error {
 		if businessCtx.Err() == context.Canceled {
 			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return toHTTPError(err)
 	}
 
 	return c.JSON(http.StatusOK, versions)
 }
 
 // 服务层方法实现
-func (h *ServerHandler) listServers(ctx context.Context) ([]interface{}, error) {
+func (h *ServerHandler) listServers(ctx context.Context) ([]models.Server, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -408,23 +589,25 @@ func (h *ServerHandler) listServers(ctx context.Context) ([]interface{}, error)
 	default:
 	}
 
-	// TODO: 实现服务器列表查询
-	return []interface{}{}, nil
+	return h.repos.Servers.List(ctx)
 }
 
-func (h *ServerHandler) createServer(ctx context.Context) (interface{}, error) {
+func (h *ServerHandler) createServer(ctx context.Context, server *models.Server) error {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return ctx.Err()
 	default:
 	}
 
-	// TODO: 实现服务器创建
-	return map[string]interface{}{}, nil
+	if server.Status == "" {
+		server.Status = models.ServerStatusDiscovered
+	}
+
+	return h.repos.Servers.Create(ctx, server)
 }
 
-func (h *ServerHandler) getServer(ctx context.Context, id string) (interface{}, error) {
+func (h *ServerHandler) getServer(ctx context.Context, id string) (*models.Server, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -432,20 +615,35 @@ func (h *ServerHandler) getServer(ctx context.Context, id string) (interface{},
 	default:
 	}
 
-	// TODO: 实现服务器详情查询
-	return map[string]interface{}{}, nil
+	return h.repos.Servers.Get(ctx, id)
 }
 
-func (h *ServerHandler) updateServer(ctx context.Context, id string) error {
+func (h *ServerHandler) updateServer(ctx context.Context, id string, server *models.Server) (*models.Server, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现服务器信息更新
-	return nil
+	existing, err := h.repos.Servers.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未提供的名称和状态保持不变
+	server.ID = existing.ID
+	if server.Name == "" {
+		server.Name = existing.Name
+	}
+	if server.Status == "" {
+		server.Status = existing.Status
+	}
+
+	if err := h.repos.Servers.Update(ctx, server); err != nil {
+		return nil, err
+	}
+	return server, nil
 }
 
 func (h *ServerHandler) deleteServer(ctx context.Context, id string) error {
@@ -456,20 +654,18 @@ func (h *ServerHandler) deleteServer(ctx context.Context, id string) error {
 	default:
 	}
 
-	// TODO: 实现服务器删除
-	return nil
+	return h.repos.Servers.Delete(ctx, id)
 }
 
-func (h *ServerHandler) powerControl(ctx context.Context, id string) error {
+func (h *ServerHandler) powerControl(ctx context.Context, id string, req *power.Request) (*models.Task, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现电源控制
-	return nil
+	return h.power.Submit(ctx, id, req, middleware.GetUserID(ctx))
 }
 
 func (h *ServerHandler) getServerStatus(ctx context.Context, id string) (interface{}, error) {
@@ -480,35 +676,159 @@ func (h *ServerHandler) getServerStatus(ctx context.Context, id string) (interfa
 	default:
 	}
 
-	// TODO: 实现服务器状态查询
-	return map[string]interface{}{}, nil
+	server, err := h.repos.Servers.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := map[string]interface{}{
+		"server_id":  server.ID,
+		"status":     server.Status,
+		"updated_at": server.UpdatedAt,
+	}
+	switch {
+	case server.RedfishURL != "":
+	case server.IPMIIP != "":
+		// 未配置Redfish的服务器通过IPMI查询
+		return h.getIPMIStatus(ctx, server, status)
+	default:
+		return status, nil
+	}
+
+	// 通过BMC查询实时硬件状态
+	client, err := h.redfish.Client(server.RedfishURL)
+	if err != nil {
+		return nil, err
+	}
+	system, err := client.System(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status["protocol"] = power.ProtocolRedfish
+	status["power_state"] = system.PowerState
+	status["health"] = system.Status.Health
+	status["bmc"] = map[string]interface{}{
+		"manufacturer":  system.Manufacturer,
+		"model":         system.Model,
+		"serial_number": system.SerialNumber,
+		"bios_version":  system.BiosVersion,
+		"processors":    system.ProcessorSummary.Count,
+		"memory_gib":    system.MemorySummary.TotalSystemMemoryGiB,
+	}
+	return status, nil
 }
 
-func (h *ServerHandler) configureBIOS(ctx context.Context, id string) error {
+func (h *ServerHandler) getIPMIStatus(ctx context.Context, server *models.Server, status map[string]interface{}) (interface{}, error) {
+	client, err := h.ipmi.Client(server.IPMIIP)
+	if err != nil {
+		return nil, err
+	}
+	chassis, err := client.ChassisStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	device, err := client.DeviceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	powerState := "Off"
+	if chassis.PowerOn {
+		powerState = "On"
+	}
+	status["protocol"] = power.ProtocolIPMI
+	status["power_state"] = powerState
+	status["chassis"] = chassis
+	status["bmc"] = map[string]interface{}{
+		"manufacturer_id":  device.ManufacturerID,
+		"product_id":       device.ProductID,
+		"firmware_version": device.FirmwareVersion,
+		"ipmi_version":     device.IPMIVersion,
+	}
+	return status, nil
+}
+
+func (h *ServerHandler) getServerSensors(ctx context.Context, id string) ([]ipmi.Sensor, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现BIOS配置
-	return nil
+	server, err := h.repos.Servers.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := h.ipmi.Client(server.IPMIIP)
+	if err != nil {
+		return nil, fmt.Errorf("server %s: %w", server.ID, err)
+	}
+	return client.Sensors(ctx)
 }
 
-func (h *ServerHandler) upgradeFirmware(ctx context.Context, id string) error {
+func (h *ServerHandler) getServerSEL(ctx context.Context, id string, limit int) ([]ipmi.SELEntry, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
+	default:
+	}
+
+	server, err := h.repos.Servers.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := h.ipmi.Client(server.IPMIIP)
+	if err != nil {
+		return nil, fmt.Errorf("server %s: %w", server.ID, err)
+	}
+	entries, err := client.SEL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// SEL按写入顺序返回，保留最近的limit条
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+func (h *ServerHandler) configureBIOS(ctx context.Context, id string, attributes map[string]interface{}) (*redfish.BiosUpdate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	server, err := h.repos.Servers.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := h.redfish.Client(server.RedfishURL)
+	if err != nil {
+		return nil, fmt.Errorf("server %s: %w", server.ID, err)
+	}
+
+	// 属性写入待生效设置，通常在下次重启后生效
+	return client.SetBiosAttributes(ctx, attributes)
+}
+
+func (h *ServerHandler) upgradeFirmware(ctx context.Context, id string, req *firmware.UpgradeRequest) (*models.Task, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	default:
 	}
 
-	// TODO: 实现固件升级
-	return nil
+	return h.firmware.Upgrade(ctx, id, req, middleware.GetUserID(ctx))
 }
 
-func (h *ServerHandler) getServerGPUs(ctx context.Context, id string) ([]interface{}, error) {
+func (h *ServerHandler) getServerFirmware(ctx context.Context, id string) ([]firmware.Component, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -516,11 +836,25 @@ func (h *ServerHandler) getServerGPUs(ctx context.Context, id string) ([]interfa
 	default:
 	}
 
-	// TODO: 实现GPU查询
-	return []interface{}{}, nil
+	return h.firmware.Inventory(ctx, id)
 }
 
-func (h *ServerHandler) addGPUToServer(ctx context.Context, id string) error {
+func (h *ServerHandler) getServerGPUs(ctx context.Context, id string) ([]models.GPU, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := h.repos.Servers.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return h.repos.GPUs.List(ctx, repository.GPUFilter{ServerID: id})
+}
+
+func (h *ServerHandler) addGPUToServer(ctx context.Context, id string, gpu *models.GPU) error {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -528,11 +862,18 @@ func (h *ServerHandler) addGPUToServer(ctx context.Context, id string) error {
 	default:
 	}
 
-	// TODO: 实现GPU添加
-	return nil
+	if _, err := h.repos.Servers.Get(ctx, id); err != nil {
+		return err
+	}
+
+	// 新GPU从状态机的初始状态开始，之后的状态只能按状态机迁移
+	gpu.ServerID = id
+	gpu.Status = models.GPUStatusAvailable
+
+	return h.repos.GPUs.Create(ctx, gpu)
 }
 
-func (h *ServerHandler) getServerConfig(ctx context.Context, id string) (interface{}, error) {
+func (h *ServerHandler) getServerConfig(ctx context.Context, id string) (*models.ServerConfig, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -540,11 +881,10 @@ func (h *ServerHandler) getServerConfig(ctx context.Context, id string) (interfa
 	default:
 	}
 
-	// TODO: 实现配置查询
-	return map[string]interface{}{}, nil
+	return h.repos.ServerConfigs.Latest(ctx, id)
 }
 
-func (h *ServerHandler) updateServerConfig(ctx context.Context, id string) error {
+func (h *ServerHandler) updateServerConfig(ctx context.Context, id string, config *models.ServerConfig) error {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -552,11 +892,29 @@ func (h *ServerHandler) updateServerConfig(ctx context.Context, id string) error
 	default:
 	}
 
-	// TODO: 实现配置更新
-	return nil
+	if _, err := h.repos.Servers.Get(ctx, id); err != nil {
+		return err
+	}
+
+	// 配置按版本追加保存，未指定版本时自动递增
+	versions, err := h.repos.ServerConfigs.ListVersions(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	config.ID = ""
+	config.ServerID = id
+	if config.Version == "" {
+		config.Version = fmt.Sprintf("v%d", len(versions)+1)
+	}
+	if config.CreatedBy == "" {
+		config.CreatedBy = middleware.GetUserID(ctx)
+	}
+
+	return h.repos.ServerConfigs.Create(ctx, config)
 }
 
-func (h *ServerHandler) getServerConfigVersions(ctx context.Context, id string) ([]interface{}, error) {
+func (h *ServerHandler) getServerConfigVersions(ctx context.Context, id string) ([]models.ServerConfig, error) {
 	// 检查Context状态
 	select {
 	case <-ctx.Done():
@@ -564,6 +922,9 @@ func (h *ServerHandler) getServerConfigVersions(ctx context.Context, id string)
 	default:
 	}
 
-	// TODO: 实现配置版本查询
-	return []interface{}{}, nil
+	if _, err := h.repos.Servers.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return h.repos.ServerConfigs.ListVersions(ctx, id)
 }
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// TaskHandler 异步任务处理器
+type TaskHandler struct {
+	repos *repository.Repositories
+}
+
+// NewTaskHandler 创建新的异步任务处理器
+func NewTaskHandler(repos *repository.Repositories) *TaskHandler {
+	return &TaskHandler{
+		repos: repos,
+	}
+}
+
+// List 列出异步任务
+func (h *TaskHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.TaskFilter{
+		Type:       c.QueryParam("type"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+	}
+	// status支持逗号分隔的多个状态
+	if status := c.QueryParam("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	tasks, err := h.listTasks(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  tasks,
+		"total": len(tasks),
+	})
+}
+
+// Get 获取异步任务详情，客户端轮询该接口直到任务结束
+func (h *TaskHandler) Get(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	task, err := h.getTask(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
+
+// 服务层方法实现
+func (h *TaskHandler) listTasks(ctx context.Context, filter repository.TaskFilter) ([]models.Task, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.Tasks.List(ctx, filter)
+}
+
+func (h *TaskHandler) getTask(ctx context.Context, id string) (*models.Task, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.repos.Tasks.Get(ctx, id)
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/api/middleware"
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/templates"
+)
+
+// TemplateHandler 工作流模板库处理器
+type TemplateHandler struct {
+	library *templates.Library
+}
+
+// NewTemplateHandler 创建新的工作流模板库处理器
+func NewTemplateHandler(library *templates.Library) *TemplateHandler {
+	return &TemplateHandler{
+		library: library,
+	}
+}
+
+// List 列出模板库中每个模板的最新版本
+func (h *TemplateHandler) List(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listTemplates(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// Create 创建模板，保存前校验参数定义、模板内容和动作
+func (h *TemplateHandler) Create(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req templates.CreateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.createTemplate(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "模板创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// Validate 校验模板内容和参数定义，不保存模板
+func (h *TemplateHandler) Validate(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req templates.ValidateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.validateTemplate(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "模板校验超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"valid": true,
+	})
+}
+
+// Get 获取模板的最新版本
+func (h *TemplateHandler) Get(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	name := c.Param("name")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.getTemplate(businessCtx, name)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// ListVersions 列出模板的所有版本
+func (h *TemplateHandler) ListVersions(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	name := c.Param("name")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listTemplateVersions(businessCtx, name)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// CreateVersion 为模板追加新版本，已有版本不可修改
+func (h *TemplateHandler) CreateVersion(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	name := c.Param("name")
+	var req templates.VersionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.createTemplateVersion(businessCtx, name, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "模板创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GetVersion 获取模板的指定版本
+func (h *TemplateHandler) GetVersion(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	name, version := c.Param("name"), c.Param("version")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.getTemplateVersion(businessCtx, name, version)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Diff 比较模板的两个版本，from和to为版本号
+func (h *TemplateHandler) Diff(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	name := c.Param("name")
+	from, to := c.QueryParam("from"), c.QueryParam("to")
+	if from == "" || to == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "from and to versions are required")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.diffTemplate(businessCtx, name, from, to)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Render 按参数渲染模板，返回提交给Tinkerbell的模板内容
+func (h *TemplateHandler) Render(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	name := c.Param("name")
+
+	var req templates.RenderRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.renderTemplate(businessCtx, name, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "模板渲染超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// 服务层方法实现
+func (h *TemplateHandler) listTemplates(ctx context.Context) ([]models.WorkflowTemplate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.List(ctx)
+}
+
+func (h *TemplateHandler) createTemplate(ctx context.Context, req *templates.CreateRequest) (*models.WorkflowTemplate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.Create(ctx, req, middleware.GetUserID(ctx))
+}
+
+func (h *TemplateHandler) validateTemplate(ctx context.Context, req *templates.ValidateRequest) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.library.Validate(req.Content, req.Parameters, req.Retries)
+}
+
+func (h *TemplateHandler) getTemplate(ctx context.Context, name string) (*models.WorkflowTemplate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.Get(ctx, name)
+}
+
+func (h *TemplateHandler) listTemplateVersions(ctx context.Context, name string) ([]models.WorkflowTemplate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.Versions(ctx, name)
+}
+
+func (h *TemplateHandler) createTemplateVersion(ctx context.Context, name string, req *templates.VersionRequest) (*models.WorkflowTemplate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.CreateVersion(ctx, name, req, middleware.GetUserID(ctx))
+}
+
+func (h *TemplateHandler) getTemplateVersion(ctx context.Context, name, version string) (*models.WorkflowTemplate, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.GetVersion(ctx, name, version)
+}
+
+func (h *TemplateHandler) diffTemplate(ctx context.Context, name, from, to string) (*templates.Diff, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.Diff(ctx, name, from, to)
+}
+
+func (h *TemplateHandler) renderTemplate(ctx context.Context, name string, req *templates.RenderRequest) (*templates.Rendered, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.library.Render(ctx, name, req.Version, req.Params)
+}
@@ -0,0 +1,589 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/tinkerbell"
+)
+
+// TinkerbellHandler Tinkerbell同步处理器
+type TinkerbellHandler struct {
+	reconciler *tinkerbell.Reconciler
+}
+
+// NewTinkerbellHandler 创建新的Tinkerbell同步处理器
+func NewTinkerbellHandler(reconciler *tinkerbell.Reconciler) *TinkerbellHandler {
+	return &TinkerbellHandler{
+		reconciler: reconciler,
+	}
+}
+
+// ListHardware 列出服务器与Tinkerbell Hardware的映射
+func (h *TinkerbellHandler) ListHardware(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listHardware(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// GetHardware 获取服务器对应的Hardware映射
+func (h *TinkerbellHandler) GetHardware(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	serverID := c.Param("server_id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.getHardware(businessCtx, serverID)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// SetHardware 设置服务器的装机参数（MAC和系统盘）并立即同步到Tinkerbell
+func (h *TinkerbellHandler) SetHardware(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	serverID := c.Param("server_id")
+	var req tinkerbell.HardwareRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.setHardware(businessCtx, serverID, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "硬件同步超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// ListTemplates 列出Tinkerbell模板
+func (h *TinkerbellHandler) ListTemplates(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listTemplates(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// CreateTemplate 创建Tinkerbell模板，下次同步时创建Template
+func (h *TinkerbellHandler) CreateTemplate(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req tinkerbell.TemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.createTemplate(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "模板创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GetTemplate 获取Tinkerbell模板详情
+func (h *TinkerbellHandler) GetTemplate(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.getTemplate(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// DeleteTemplate 删除Tinkerbell模板及其Template
+func (h *TinkerbellHandler) DeleteTemplate(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.deleteTemplate(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "模板删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListWorkflows 列出Tinkerbell工作流，支持按硬件、模板和状态过滤，status可用逗号分隔多个值
+func (h *TinkerbellHandler) ListWorkflows(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	filter := repository.WorkflowCRDFilter{
+		HardwareID: c.QueryParam("hardware_id"),
+		TemplateID: c.QueryParam("template_id"),
+	}
+	if v := c.QueryParam("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	list, err := h.listWorkflows(businessCtx, filter)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  list,
+		"total": len(list),
+	})
+}
+
+// CreateWorkflow 为服务器创建Tinkerbell工作流，下次同步时提交
+func (h *TinkerbellHandler) CreateWorkflow(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	var req tinkerbell.WorkflowRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.createWorkflow(businessCtx, &req)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "工作流创建超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GetWorkflow 获取Tinkerbell工作流详情
+func (h *TinkerbellHandler) GetWorkflow(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.getWorkflow(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// RetryWorkflow 重试失败的Tinkerbell工作流
+func (h *TinkerbellHandler) RetryWorkflow(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.retryWorkflow(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "工作流重试超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// DeleteWorkflow 删除Tinkerbell工作流及其Workflow
+func (h *TinkerbellHandler) DeleteWorkflow(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	id := c.Param("id")
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	err := h.deleteWorkflow(businessCtx, id)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "工作流删除超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Sync 立即执行一次Tinkerbell同步
+func (h *TinkerbellHandler) Sync(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.sync(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "同步超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetSyncStatus 获取最近一次Tinkerbell同步的结果
+func (h *TinkerbellHandler) GetSyncStatus(c echo.Context) error {
+	// 获取请求Context
+	ctx := c.Request().Context()
+
+	// 设置业务超时
+	businessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	// 调用服务层，传递Context
+	result, err := h.getSyncStatus(businessCtx)
+	if err != nil {
+		// 检查Context相关错误
+		if businessCtx.Err() == context.DeadlineExceeded {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "查询超时")
+		}
+		if businessCtx.Err() == context.Canceled {
+			return echo.NewHTTPError(http.StatusRequestTimeout, "请求被取消")
+		}
+		return toHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// 服务层方法实现
+func (h *TinkerbellHandler) listHardware(ctx context.Context) ([]models.HardwareCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.ListHardware(ctx)
+}
+
+func (h *TinkerbellHandler) getHardware(ctx context.Context, serverID string) (*models.HardwareCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.GetHardware(ctx, serverID)
+}
+
+func (h *TinkerbellHandler) setHardware(ctx context.Context, serverID string, req *tinkerbell.HardwareRequest) (*models.HardwareCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.SetHardware(ctx, serverID, req)
+}
+
+func (h *TinkerbellHandler) listTemplates(ctx context.Context) ([]models.TemplateCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.ListTemplates(ctx)
+}
+
+func (h *TinkerbellHandler) createTemplate(ctx context.Context, req *tinkerbell.TemplateRequest) (*models.TemplateCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.CreateTemplate(ctx, req)
+}
+
+func (h *TinkerbellHandler) getTemplate(ctx context.Context, id string) (*models.TemplateCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.GetTemplate(ctx, id)
+}
+
+func (h *TinkerbellHandler) deleteTemplate(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.reconciler.DeleteTemplate(ctx, id)
+}
+
+func (h *TinkerbellHandler) listWorkflows(ctx context.Context, filter repository.WorkflowCRDFilter) ([]models.WorkflowCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.ListWorkflows(ctx, filter)
+}
+
+func (h *TinkerbellHandler) createWorkflow(ctx context.Context, req *tinkerbell.WorkflowRequest) (*models.WorkflowCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.CreateWorkflow(ctx, req)
+}
+
+func (h *TinkerbellHandler) getWorkflow(ctx context.Context, id string) (*models.WorkflowCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.GetWorkflow(ctx, id)
+}
+
+func (h *TinkerbellHandler) retryWorkflow(ctx context.Context, id string) (*models.WorkflowCRD, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.RetryWorkflow(ctx, id)
+}
+
+func (h *TinkerbellHandler) deleteWorkflow(ctx context.Context, id string) error {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	return h.reconciler.DeleteWorkflow(ctx, id)
+}
+
+func (h *TinkerbellHandler) sync(ctx context.Context) (*tinkerbell.SyncResult, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return h.reconciler.Reconcile(ctx)
+}
+
+func (h *TinkerbellHandler) getSyncStatus(ctx context.Context) (*tinkerbell.SyncResult, error) {
+	// 检查Context状态
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	result := h.reconciler.LastResult()
+	if result == nil {
+		return nil, repository.ErrNotFound
+	}
+	return result, nil
+}
@@ -18,6 +18,12 @@ const (
 	OperationKey ContextKey = "operation"
 )
 
+// streamingOperations 长连接推送的操作类型
+var streamingOperations = map[string]bool{
+	"event_stream":    true,
+	"event_stream_ws": true,
+}
+
 // ContextMiddleware Context中间件
 func ContextMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -38,12 +44,15 @@ func ContextMiddleware() echo.MiddlewareFunc {
 			operation := getOperation(c)
 			ctx = context.WithValue(ctx, OperationKey, operation)
 
-			// 设置请求超时（60秒）
-			timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
-			defer cancel()
+			// 设置请求超时（60秒），事件推送的长连接不设超时，客户端断开或服务关闭时结束
+			if !streamingOperations[operation] {
+				timeoutCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+				defer cancel()
+				ctx = timeoutCtx
+			}
 
 			// 更新请求Context
-			c.SetRequest(c.Request().WithContext(timeoutCtx))
+			c.SetRequest(c.Request().WithContext(ctx))
 
 			// 调用下一个处理器
 			return next(c)
@@ -83,8 +92,14 @@ func getOperation(c echo.Context) string {
 		return "gpu_update"
 	case method == "GET" && path == "/api/v1/gpus/:id/status":
 		return "gpu_status"
+	case method == "GET" && path == "/api/v1/gpus/:id/history":
+		return "gpu_history"
+	case method == "GET" && path == "/api/v1/gpus/:id/events":
+		return "gpu_events"
 	case method == "GET" && path == "/api/v1/gpus/:id/metrics":
 		return "gpu_metrics"
+	case method == "POST" && path == "/api/v1/gpus/:id/metrics":
+		return "gpu_metrics_report"
 	case method == "PUT" && path == "/api/v1/gpus/:id/config":
 		return "gpu_config_update"
 	case method == "GET" && path == "/api/v1/gpus/:id/config":
@@ -107,6 +122,38 @@ func getOperation(c echo.Context) string {
 		return "allocation_stop"
 	case method == "GET" && path == "/api/v1/allocations/:id/status":
 		return "allocation_status"
+	case method == "GET" && path == "/api/v1/workflows":
+		return "workflow_list"
+	case method == "POST" && path == "/api/v1/workflows":
+		return "workflow_create"
+	case method == "GET" && path == "/api/v1/workflows/:id":
+		return "workflow_get"
+	case method == "DELETE" && path == "/api/v1/workflows/:id":
+		return "workflow_delete"
+	case method == "POST" && path == "/api/v1/workflows/:id/retry":
+		return "workflow_retry"
+	case method == "POST" && path == "/api/v1/workflows/deploy":
+		return "workflow_deploy"
+	case method == "POST" && path == "/api/v1/workflows/cleanup":
+		return "workflow_cleanup"
+	case method == "GET" && path == "/api/v1/templates":
+		return "template_list"
+	case method == "POST" && path == "/api/v1/templates":
+		return "template_create"
+	case method == "POST" && path == "/api/v1/templates/validate":
+		return "template_validate"
+	case method == "GET" && path == "/api/v1/templates/:name":
+		return "template_get"
+	case method == "GET" && path == "/api/v1/templates/:name/versions":
+		return "template_version_list"
+	case method == "POST" && path == "/api/v1/templates/:name/versions":
+		return "template_version_create"
+	case method == "GET" && path == "/api/v1/templates/:name/versions/:version":
+		return "template_version_get"
+	case method == "GET" && path == "/api/v1/templates/:name/diff":
+		return "template_diff"
+	case method == "POST" && path == "/api/v1/templates/:name/render":
+		return "template_render"
 	case method == "GET" && path == "/api/v1/servers":
 		return "server_list"
 	case method == "POST" && path == "/api/v1/servers":
@@ -121,8 +168,16 @@ func getOperation(c echo.Context) string {
 		return "server_power_control"
 	case method == "GET" && path == "/api/v1/servers/:id/status":
 		return "server_status"
+	case method == "GET" && path == "/api/v1/servers/:id/sensors":
+		return "server_sensors"
+	case method == "GET" && path == "/api/v1/servers/:id/sel":
+		return "server_sel"
+	case method == "GET" && path == "/api/v1/servers/:id/events":
+		return "server_events"
 	case method == "PUT" && path == "/api/v1/servers/:id/bios":
 		return "server_bios_config"
+	case method == "GET" && path == "/api/v1/servers/:id/firmware":
+		return "server_firmware_get"
 	case method == "POST" && path == "/api/v1/servers/:id/firmware":
 		return "server_firmware_upgrade"
 	case method == "GET" && path == "/api/v1/servers/:id/gpus":
@@ -135,20 +190,146 @@ func getOperation(c echo.Context) string {
 		return "server_config_update"
 	case method == "GET" && path == "/api/v1/servers/:id/config/versions":
 		return "server_config_versions"
+	case method == "GET" && path == "/api/v1/tasks":
+		return "task_list"
+	case method == "GET" && path == "/api/v1/tasks/:id":
+		return "task_get"
+	case method == "GET" && path == "/api/v1/firmware":
+		return "firmware_list"
+	case method == "POST" && path == "/api/v1/firmware":
+		return "firmware_create"
+	case method == "GET" && path == "/api/v1/firmware/:id":
+		return "firmware_get"
+	case method == "DELETE" && path == "/api/v1/firmware/:id":
+		return "firmware_delete"
+	case method == "GET" && path == "/api/v1/firmware/rollouts":
+		return "firmware_rollout_list"
+	case method == "POST" && path == "/api/v1/firmware/rollouts":
+		return "firmware_rollout_create"
+	case method == "GET" && path == "/api/v1/firmware/rollouts/:id":
+		return "firmware_rollout_get"
+	case method == "POST" && path == "/api/v1/firmware/rollouts/:id/rollback":
+		return "firmware_rollout_rollback"
 	case method == "GET" && path == "/api/v1/events":
 		return "event_list"
 	case method == "POST" && path == "/api/v1/events":
 		return "event_create"
+	case method == "GET" && path == "/api/v1/events/stream":
+		return "event_stream"
+	case method == "GET" && path == "/api/v1/events/ws":
+		return "event_stream_ws"
 	case method == "GET" && path == "/api/v1/events/:id":
 		return "event_get"
+	case method == "GET" && path == "/api/v1/events/dead-letters":
+		return "dead_letter_list"
+	case method == "POST" && path == "/api/v1/events/dead-letters/replay":
+		return "dead_letter_replay_all"
+	case method == "GET" && path == "/api/v1/events/dead-letters/:id":
+		return "dead_letter_get"
+	case method == "POST" && path == "/api/v1/events/dead-letters/:id/replay":
+		return "dead_letter_replay"
+	case method == "POST" && path == "/api/v1/events/dead-letters/:id/discard":
+		return "dead_letter_discard"
+	case method == "GET" && path == "/api/v1/events/schemas":
+		return "event_schema_list"
+	case method == "GET" && path == "/api/v1/events/schemas/:type":
+		return "event_schema_get"
+	case method == "GET" && path == "/api/v1/events/schemas/:type/:version":
+		return "event_schema_get"
 	case method == "GET" && path == "/api/v1/alerts":
 		return "alert_list"
 	case method == "POST" && path == "/api/v1/alerts":
 		return "alert_create"
 	case method == "PUT" && path == "/api/v1/alerts/:id":
 		return "alert_update"
-	case method == "GET" && path == "/api/v1/alerts/correlation":
+	case method == "GET" && path == "/api/v1/alerts/:id":
+		return "alert_get"
+	case method == "POST" && path == "/api/v1/alerts/:id/acknowledge":
+		return "alert_acknowledge"
+	case method == "POST" && path == "/api/v1/alerts/:id/resolve":
+		return "alert_resolve"
+	case method == "GET" && path == "/api/v1/alerts/:id/correlation":
 		return "alert_correlation"
+	case method == "GET" && path == "/api/v1/alerts/silences":
+		return "silence_list"
+	case method == "POST" && path == "/api/v1/alerts/silences":
+		return "silence_create"
+	case method == "DELETE" && path == "/api/v1/alerts/silences/:id":
+		return "silence_expire"
+	case method == "GET" && path == "/api/v1/alerts/rules":
+		return "alert_rule_list"
+	case method == "POST" && path == "/api/v1/alerts/rules":
+		return "alert_rule_create"
+	case method == "GET" && path == "/api/v1/alerts/rules/:id":
+		return "alert_rule_get"
+	case method == "PUT" && path == "/api/v1/alerts/rules/:id":
+		return "alert_rule_update"
+	case method == "DELETE" && path == "/api/v1/alerts/rules/:id":
+		return "alert_rule_delete"
+	case method == "GET" && path == "/api/v1/notifications":
+		return "notification_list"
+	case method == "GET" && path == "/api/v1/notifications/channels":
+		return "notification_channel_list"
+	case method == "POST" && path == "/api/v1/notifications/channels":
+		return "notification_channel_create"
+	case method == "GET" && path == "/api/v1/notifications/channels/:id":
+		return "notification_channel_get"
+	case method == "PUT" && path == "/api/v1/notifications/channels/:id":
+		return "notification_channel_update"
+	case method == "DELETE" && path == "/api/v1/notifications/channels/:id":
+		return "notification_channel_delete"
+	case method == "POST" && path == "/api/v1/notifications/channels/:id/test":
+		return "notification_channel_test"
+	case method == "GET" && path == "/api/v1/notifications/routes":
+		return "notification_route_list"
+	case method == "POST" && path == "/api/v1/notifications/routes":
+		return "notification_route_create"
+	case method == "GET" && path == "/api/v1/notifications/routes/:id":
+		return "notification_route_get"
+	case method == "PUT" && path == "/api/v1/notifications/routes/:id":
+		return "notification_route_update"
+	case method == "DELETE" && path == "/api/v1/notifications/routes/:id":
+		return "notification_route_delete"
+	case method == "GET" && path == "/api/v1/notifications/schedules":
+		return "oncall_schedule_list"
+	case method == "POST" && path == "/api/v1/notifications/schedules":
+		return "oncall_schedule_create"
+	case method == "GET" && path == "/api/v1/notifications/schedules/:id":
+		return "oncall_schedule_get"
+	case method == "PUT" && path == "/api/v1/notifications/schedules/:id":
+		return "oncall_schedule_update"
+	case method == "DELETE" && path == "/api/v1/notifications/schedules/:id":
+		return "oncall_schedule_delete"
+	case method == "GET" && path == "/api/v1/notifications/schedules/:id/oncall":
+		return "oncall_get"
+	case method == "GET" && path == "/api/v1/tinkerbell/hardware":
+		return "tinkerbell_hardware_list"
+	case method == "GET" && path == "/api/v1/tinkerbell/hardware/:server_id":
+		return "tinkerbell_hardware_get"
+	case method == "PUT" && path == "/api/v1/tinkerbell/hardware/:server_id":
+		return "tinkerbell_hardware_set"
+	case method == "GET" && path == "/api/v1/tinkerbell/templates":
+		return "tinkerbell_template_list"
+	case method == "POST" && path == "/api/v1/tinkerbell/templates":
+		return "tinkerbell_template_create"
+	case method == "GET" && path == "/api/v1/tinkerbell/templates/:id":
+		return "tinkerbell_template_get"
+	case method == "DELETE" && path == "/api/v1/tinkerbell/templates/:id":
+		return "tinkerbell_template_delete"
+	case method == "GET" && path == "/api/v1/tinkerbell/workflows":
+		return "tinkerbell_workflow_list"
+	case method == "POST" && path == "/api/v1/tinkerbell/workflows":
+		return "tinkerbell_workflow_create"
+	case method == "GET" && path == "/api/v1/tinkerbell/workflows/:id":
+		return "tinkerbell_workflow_get"
+	case method == "DELETE" && path == "/api/v1/tinkerbell/workflows/:id":
+		return "tinkerbell_workflow_delete"
+	case method == "POST" && path == "/api/v1/tinkerbell/workflows/:id/retry":
+		return "tinkerbell_workflow_retry"
+	case method == "GET" && path == "/api/v1/tinkerbell/sync":
+		return "tinkerbell_sync_status"
+	case method == "POST" && path == "/api/v1/tinkerbell/sync":
+		return "tinkerbell_sync"
 	default:
 		return "unknown_operation"
 	}
@@ -7,16 +7,65 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"gpu-management/internal/api/handlers"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/alert"
+	"gpu-management/internal/services/allocation"
+	"gpu-management/internal/services/deadletter"
 	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/eventstore"
+	"gpu-management/internal/services/firmware"
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/internal/services/notify"
+	"gpu-management/internal/services/power"
+	"gpu-management/internal/services/redfish"
+	"gpu-management/internal/services/rules"
+	"gpu-management/internal/services/stream"
+	"gpu-management/internal/services/templates"
+	"gpu-management/internal/services/tinkerbell"
+	"gpu-management/internal/services/workflow"
 )
 
+// Dependencies 路由依赖的组件，由main创建并负责关闭
+type Dependencies struct {
+	EventBus event.EventBus
+	Repos    *repository.Repositories
+	Redfish  *redfish.Pool
+	IPMI     *ipmi.Pool
+	Power    *power.Service
+	Firmware *firmware.Service
+	Stream   *stream.Hub
+	Alerts   *alert.Service
+	Rules    *rules.Engine
+	Notifier *notify.Dispatcher
+	// Allocations 分配服务，与工作流引擎共用同一个实例
+	Allocations *allocation.Service
+	// Tinkerbell 未配置Kubernetes API时所有操作返回503
+	Tinkerbell *tinkerbell.Reconciler
+	Workflows  *workflow.Engine
+	Templates  *templates.Library
+}
+
 // Setup 设置路由
-func Setup(e *echo.Echo, eventBus event.EventBus) {
+func Setup(e *echo.Echo, deps Dependencies) {
+	eventBus, repos := deps.EventBus, deps.Repos
+
+	// 创建服务
+	deadLetterService := deadletter.NewService(repos, eventBus)
+	eventStoreService := eventstore.NewService(repos, eventBus)
+
 	// 创建处理器
-	gpuHandler := handlers.NewGPUHandler(eventBus)
-	serverHandler := handlers.NewServerHandler(eventBus)
-	allocationHandler := handlers.NewAllocationHandler(eventBus)
-	eventHandler := handlers.NewEventHandler(eventBus)
+	gpuHandler := handlers.NewGPUHandler(eventBus, repos, deps.Rules)
+	serverHandler := handlers.NewServerHandler(eventBus, repos, deps.Redfish, deps.IPMI, deps.Power, deps.Firmware)
+	allocationHandler := handlers.NewAllocationHandler(eventBus, deps.Allocations, deps.Workflows)
+	eventHandler := handlers.NewEventHandler(eventBus, eventStoreService, deps.Stream)
+	taskHandler := handlers.NewTaskHandler(repos)
+	firmwareHandler := handlers.NewFirmwareHandler(repos, deps.Firmware)
+	deadLetterHandler := handlers.NewDeadLetterHandler(repos, deadLetterService)
+	alertHandler := handlers.NewAlertHandler(deps.Alerts)
+	ruleHandler := handlers.NewRuleHandler(deps.Rules)
+	notificationHandler := handlers.NewNotificationHandler(deps.Notifier)
+	tinkerbellHandler := handlers.NewTinkerbellHandler(deps.Tinkerbell)
+	templateHandler := handlers.NewTemplateHandler(deps.Templates)
 
 	// API v1 路由组
 	v1 := e.Group("/api/v1")
@@ -27,7 +76,11 @@ func Setup(e *echo.Echo, eventBus event.EventBus) {
 	gpus.GET("/:id", gpuHandler.Get)
 	gpus.PUT("/:id", gpuHandler.Update)
 	gpus.GET("/:id/status", gpuHandler.GetStatus)
+	gpus.POST("/:id/status", gpuHandler.SetStatus)
+	gpus.GET("/:id/history", gpuHandler.GetHistory)
+	gpus.GET("/:id/events", eventHandler.GPUTimeline)
 	gpus.GET("/:id/metrics", gpuHandler.GetMetrics)
+	gpus.POST("/:id/metrics", gpuHandler.ReportMetrics)
 	gpus.POST("/:id/config", gpuHandler.UpdateConfig)
 	gpus.GET("/:id/config", gpuHandler.GetConfig)
 	gpus.GET("/:id/config/versions", gpuHandler.GetConfigVersions)
@@ -41,7 +94,11 @@ func Setup(e *echo.Echo, eventBus event.EventBus) {
 	servers.DELETE("/:id", serverHandler.Delete)
 	servers.POST("/:id/power", serverHandler.PowerControl)
 	servers.GET("/:id/status", serverHandler.GetStatus)
+	servers.GET("/:id/sensors", serverHandler.GetSensors)
+	servers.GET("/:id/sel", serverHandler.GetSEL)
+	servers.GET("/:id/events", eventHandler.ServerTimeline)
 	servers.POST("/:id/bios", serverHandler.ConfigureBIOS)
+	servers.GET("/:id/firmware", serverHandler.GetFirmware)
 	servers.POST("/:id/firmware", serverHandler.UpgradeFirmware)
 	servers.GET("/:id/gpus", serverHandler.GetGPUs)
 	servers.POST("/:id/gpus", serverHandler.AddGPU)
@@ -60,27 +117,124 @@ func Setup(e *echo.Echo, eventBus event.EventBus) {
 	allocations.POST("/:id/stop", allocationHandler.Stop)
 	allocations.GET("/:id/status", allocationHandler.GetStatus)
 
+	// 异步任务路由
+	tasks := v1.Group("/tasks")
+	tasks.GET("", taskHandler.List)
+	tasks.GET("/:id", taskHandler.Get)
+
+	// 固件目录和分批升级路由
+	fw := v1.Group("/firmware")
+	fw.GET("", firmwareHandler.List)
+	fw.POST("", firmwareHandler.Create)
+	fw.GET("/rollouts", firmwareHandler.ListRollouts)
+	fw.POST("/rollouts", firmwareHandler.CreateRollout)
+	fw.GET("/rollouts/:id", firmwareHandler.GetRollout)
+	fw.POST("/rollouts/:id/rollback", firmwareHandler.Rollback)
+	fw.GET("/:id", firmwareHandler.Get)
+	fw.DELETE("/:id", firmwareHandler.Delete)
+
 	// 工作流路由
 	workflows := v1.Group("/workflows")
 	workflows.GET("", allocationHandler.ListWorkflows)
 	workflows.POST("", allocationHandler.CreateWorkflow)
 	workflows.GET("/:id", allocationHandler.GetWorkflow)
 	workflows.DELETE("/:id", allocationHandler.DeleteWorkflow)
+	workflows.POST("/:id/retry", allocationHandler.RetryWorkflow)
 	workflows.POST("/deploy", allocationHandler.CreateDeployWorkflow)
 	workflows.POST("/cleanup", allocationHandler.CreateCleanupWorkflow)
 
+	// 工作流模板库路由
+	tpl := v1.Group("/templates")
+	tpl.GET("", templateHandler.List)
+	tpl.POST("", templateHandler.Create)
+	tpl.POST("/validate", templateHandler.Validate)
+	tpl.GET("/:name", templateHandler.Get)
+	tpl.GET("/:name/versions", templateHandler.ListVersions)
+	tpl.POST("/:name/versions", templateHandler.CreateVersion)
+	tpl.GET("/:name/versions/:version", templateHandler.GetVersion)
+	tpl.GET("/:name/diff", templateHandler.Diff)
+	tpl.POST("/:name/render", templateHandler.Render)
+
 	// 事件路由
 	events := v1.Group("/events")
 	events.GET("", eventHandler.List)
 	events.POST("", eventHandler.Create)
+	events.GET("/stream", eventHandler.Stream)
+	events.GET("/ws", eventHandler.StreamWebSocket)
 	events.GET("/:id", eventHandler.Get)
 
+	// 事件结构定义路由，dataschema指向具体版本
+	events.GET("/schemas", eventHandler.ListSchemas)
+	events.GET("/schemas/:type", eventHandler.GetSchema)
+	events.GET("/schemas/:type/:version", eventHandler.GetSchema)
+
+	// 死信路由，重放只在注册了原处理器的实例上生效
+	deadLetters := events.Group("/dead-letters")
+	deadLetters.GET("", deadLetterHandler.List)
+	deadLetters.POST("/replay", deadLetterHandler.ReplayAll)
+	deadLetters.GET("/:id", deadLetterHandler.Get)
+	deadLetters.POST("/:id/replay", deadLetterHandler.Replay)
+	deadLetters.POST("/:id/discard", deadLetterHandler.Discard)
+
 	// 告警路由
 	alerts := v1.Group("/alerts")
-	alerts.GET("", eventHandler.ListAlerts)
-	alerts.POST("", eventHandler.CreateAlert)
-	alerts.PUT("/:id", eventHandler.UpdateAlert)
-	alerts.GET("/:id/correlation", eventHandler.GetAlertCorrelation)
+	alerts.GET("", alertHandler.List)
+	alerts.POST("", alertHandler.Create)
+	alerts.GET("/:id", alertHandler.Get)
+	alerts.PUT("/:id", alertHandler.Update)
+	alerts.POST("/:id/acknowledge", alertHandler.Acknowledge)
+	alerts.POST("/:id/resolve", alertHandler.Resolve)
+	alerts.GET("/:id/correlation", alertHandler.GetCorrelation)
+
+	// 告警静默规则
+	alerts.GET("/silences", alertHandler.ListSilences)
+	alerts.POST("/silences", alertHandler.CreateSilence)
+	alerts.DELETE("/silences/:id", alertHandler.ExpireSilence)
+
+	// 告警规则，按上报的GPU指标自动产生和解除告警
+	alerts.GET("/rules", ruleHandler.List)
+	alerts.POST("/rules", ruleHandler.Create)
+	alerts.GET("/rules/:id", ruleHandler.Get)
+	alerts.PUT("/rules/:id", ruleHandler.Update)
+	alerts.DELETE("/rules/:id", ruleHandler.Delete)
+
+	// 告警通知路由，通知渠道的密钥在返回时隐藏
+	notifications := v1.Group("/notifications")
+	notifications.GET("", notificationHandler.List)
+	notifications.GET("/channels", notificationHandler.ListChannels)
+	notifications.POST("/channels", notificationHandler.CreateChannel)
+	notifications.GET("/channels/:id", notificationHandler.GetChannel)
+	notifications.PUT("/channels/:id", notificationHandler.UpdateChannel)
+	notifications.DELETE("/channels/:id", notificationHandler.DeleteChannel)
+	notifications.POST("/channels/:id/test", notificationHandler.TestChannel)
+	notifications.GET("/routes", notificationHandler.ListRoutes)
+	notifications.POST("/routes", notificationHandler.CreateRoute)
+	notifications.GET("/routes/:id", notificationHandler.GetRoute)
+	notifications.PUT("/routes/:id", notificationHandler.UpdateRoute)
+	notifications.DELETE("/routes/:id", notificationHandler.DeleteRoute)
+	notifications.GET("/schedules", notificationHandler.ListSchedules)
+	notifications.POST("/schedules", notificationHandler.CreateSchedule)
+	notifications.GET("/schedules/:id", notificationHandler.GetSchedule)
+	notifications.PUT("/schedules/:id", notificationHandler.UpdateSchedule)
+	notifications.DELETE("/schedules/:id", notificationHandler.DeleteSchedule)
+	notifications.GET("/schedules/:id/oncall", notificationHandler.GetOnCall)
+
+	// Tinkerbell CRD同步
+	tink := v1.Group("/tinkerbell")
+	tink.GET("/hardware", tinkerbellHandler.ListHardware)
+	tink.GET("/hardware/:server_id", tinkerbellHandler.GetHardware)
+	tink.PUT("/hardware/:server_id", tinkerbellHandler.SetHardware)
+	tink.GET("/templates", tinkerbellHandler.ListTemplates)
+	tink.POST("/templates", tinkerbellHandler.CreateTemplate)
+	tink.GET("/templates/:id", tinkerbellHandler.GetTemplate)
+	tink.DELETE("/templates/:id", tinkerbellHandler.DeleteTemplate)
+	tink.GET("/workflows", tinkerbellHandler.ListWorkflows)
+	tink.POST("/workflows", tinkerbellHandler.CreateWorkflow)
+	tink.GET("/workflows/:id", tinkerbellHandler.GetWorkflow)
+	tink.DELETE("/workflows/:id", tinkerbellHandler.DeleteWorkflow)
+	tink.POST("/workflows/:id/retry", tinkerbellHandler.RetryWorkflow)
+	tink.GET("/sync", tinkerbellHandler.GetSyncStatus)
+	tink.POST("/sync", tinkerbellHandler.Sync)
 
 	// 健康检查
 	e.GET("/health", func(c echo.Context) error {
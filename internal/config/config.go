@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,24 +16,47 @@ type Config struct {
 	Redis    RedisConfig
 	NATS     NATSConfig
 	Tinkerbell TinkerbellConfig
+	Redfish  RedfishConfig
+	IPMI     IPMIConfig
+	Task     TaskConfig
+	Power    PowerConfig
+	Firmware FirmwareConfig
+	Outbox   OutboxConfig
+	Alert    AlertConfig
+	Notify   NotifyConfig
+	SMTP     SMTPConfig
 	K8s      K8sConfig
 	LogLevel string
+	Mode     string
+}
+
+// 运行模式常量
+const (
+	ModeOnline  = "online"
+	ModeOffline = "offline"
+)
+
+// IsOffline 是否为离线模式（不依赖NATS等外部组件）
+func (c *Config) IsOffline() bool {
+	return c.Mode == ModeOffline
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port string
-	Host string
+	Port            string
+	Host            string
+	ShutdownTimeout time.Duration
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host        string
+	Port        int
+	User        string
+	Password    string
+	DBName      string
+	SSLMode     string
+	AutoMigrate bool
 }
 
 // RedisConfig Redis配置
@@ -45,13 +70,128 @@ type RedisConfig struct {
 // NATSConfig NATS配置
 type NATSConfig struct {
 	URL string
+	// JetStream 使用JetStream持久化事件，关闭时退回NATS核心发布订阅（消费者离线期间的事件会丢失）
+	JetStream bool
+	// Stream 事件流名称
+	Stream string
+	// Subjects 事件流收录的主题
+	Subjects []string
+	// MaxAge 事件在流中保留的最长时间
+	MaxAge time.Duration
+	// Consumer 持久消费者名称前缀，多个实例使用相同前缀分担消息
+	Consumer string
+	// AckWait 处理单条事件的最长时间，超时未确认会重新投递
+	AckWait time.Duration
+	// MaxDeliver 单条事件的最大投递次数
+	MaxDeliver int
+	// Backoff 处理失败后的重投等待时间，依次使用，超过列表长度时使用最后一项
+	Backoff []time.Duration
 }
 
-// TinkerbellConfig Tinkerbell配置
+// TinkerbellConfig Tinkerbell配置，CRD通过K8sConfig指定的集群和命名空间访问
 type TinkerbellConfig struct {
 	URL      string
 	Username string
 	Password string
+
+	// SyncInterval 数据库记录与Tinkerbell CRD的同步间隔
+	SyncInterval time.Duration
+	// SimulatedActionDuration 离线模式模拟执行工作流时每个动作的耗时
+	SimulatedActionDuration time.Duration
+	// AllowedActions 工作流模板中允许使用的动作镜像仓库，不含标签和摘要
+	AllowedActions []string
+}
+
+// RedfishConfig Redfish BMC配置，凭据对所有服务器通用
+type RedfishConfig struct {
+	Username           string
+	Password           string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// IPMIConfig IPMI-over-LAN配置，服务器未配置Redfish地址时使用，凭据对所有服务器通用
+type IPMIConfig struct {
+	Username string
+	Password string
+	// Timeout 单次UDP请求等待响应的时间
+	Timeout time.Duration
+	// Retries 超时后的重传次数
+	Retries int
+}
+
+// TaskConfig 异步任务执行配置
+type TaskConfig struct {
+	// InstanceID 服务实例标识，多实例部署时每个实例必须不同，重启后保持不变
+	InstanceID string
+	// HeartbeatInterval 为本实例执行中的任务续约的间隔
+	HeartbeatInterval time.Duration
+	// LeaseTimeout 任务超过该时间未续约时视为执行实例已退出
+	LeaseTimeout time.Duration
+}
+
+// PowerConfig 电源控制配置
+type PowerConfig struct {
+	// TaskTimeout 电源任务从下发到BMC确认电源状态的最长时间
+	TaskTimeout time.Duration
+	// PollInterval 确认电源状态的轮询间隔
+	PollInterval time.Duration
+}
+
+// FirmwareConfig 固件升级配置
+type FirmwareConfig struct {
+	// TaskTimeout 单台服务器固件升级任务的最长时间
+	TaskTimeout time.Duration
+	// RolloutTimeout 分批升级或回滚任务的最长时间
+	RolloutTimeout time.Duration
+	// VerifyChecksum 下发前下载镜像并校验摘要
+	VerifyChecksum bool
+}
+
+// OutboxConfig 事务性发件箱转发配置
+type OutboxConfig struct {
+	// PollInterval 扫描待发布事件的间隔
+	PollInterval time.Duration
+	// BatchSize 每次扫描最多发布的事件数
+	BatchSize int
+	// MaxBackoff 发布失败后的最长重试间隔
+	MaxBackoff time.Duration
+	// Retention 已发布事件的保留时间
+	Retention time.Duration
+}
+
+// AlertConfig 告警配置
+type AlertConfig struct {
+	// EscalateAfter 告警保持未确认超过该时长后自动升级一级，0表示不自动升级
+	EscalateAfter time.Duration
+	// MaxEscalations 最多自动升级的次数
+	MaxEscalations int
+	// CheckInterval 检查升级和静默的间隔
+	CheckInterval time.Duration
+	// RuleSyncInterval 告警规则引擎重新加载规则的间隔
+	RuleSyncInterval time.Duration
+	// CorrelationWindow 告警关联分析默认的时间窗口
+	CorrelationWindow time.Duration
+}
+
+// NotifyConfig 告警通知配置
+type NotifyConfig struct {
+	// MaxAttempts 每条通知最多发送的次数
+	MaxAttempts int
+	// InitialBackoff 首次重试前的等待时间，之后每次翻倍
+	InitialBackoff time.Duration
+	// MaxBackoff 重试等待时间的上限
+	MaxBackoff time.Duration
+}
+
+// SMTPConfig 邮件服务器配置，email通知渠道使用
+type SMTPConfig struct {
+	// Host 为空时email渠道发送失败
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
 }
 
 // K8sConfig Kubernetes配置
@@ -69,6 +209,8 @@ func Load() *Config {
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			// 优雅关闭时等待在途请求完成的最长时间
+			ShutdownTimeout: getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -77,6 +219,8 @@ func Load() *Config {
 			Password: getEnv("DB_PASSWORD", "password"),
 			DBName:   getEnv("DB_NAME", "gpu_management"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			// 启动时自动执行数据库迁移
+			AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -85,21 +229,103 @@ func Load() *Config {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		NATS: NATSConfig{
-			URL: getEnv("NATS_URL", "nats://localhost:4222"),
+			URL:        getEnv("NATS_URL", "nats://localhost:4222"),
+			JetStream:  getEnvAsBool("NATS_JETSTREAM", true),
+			Stream:     getEnv("NATS_STREAM", "GPU_EVENTS"),
+			Subjects:   getEnvAsSlice("NATS_STREAM_SUBJECTS", []string{"hardware.>", "alert.>", "allocation.>", "task.>", "gpu.>", "server.>", "workflow.>", "annotation.>"}),
+			MaxAge:     getEnvAsDuration("NATS_STREAM_MAX_AGE", 72*time.Hour),
+			Consumer:   getEnv("NATS_CONSUMER", "gpu-management"),
+			AckWait:    getEnvAsDuration("NATS_ACK_WAIT", 30*time.Second),
+			MaxDeliver: getEnvAsInt("NATS_MAX_DELIVER", 5),
+			Backoff:    getEnvAsDurations("NATS_RETRY_BACKOFF", []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}),
 		},
 		Tinkerbell: TinkerbellConfig{
-			URL:      getEnv("TINKERBELL_URL", "http://localhost:50061"),
-			Username: getEnv("TINKERBELL_USERNAME", "admin"),
-			Password: getEnv("TINKERBELL_PASSWORD", "password"),
+			URL:                     getEnv("TINKERBELL_URL", "http://localhost:50061"),
+			Username:                getEnv("TINKERBELL_USERNAME", "admin"),
+			Password:                getEnv("TINKERBELL_PASSWORD", "password"),
+			SyncInterval:            getEnvAsDuration("TINKERBELL_SYNC_INTERVAL", 30*time.Second),
+			SimulatedActionDuration: getEnvAsDuration("TINKERBELL_SIMULATED_ACTION_DURATION", 2*time.Second),
+			AllowedActions: getEnvAsSlice("TINKERBELL_ALLOWED_ACTIONS", []string{
+				"quay.io/tinkerbell-actions/archive2disk",
+				"quay.io/tinkerbell-actions/cexec",
+				"quay.io/tinkerbell-actions/grub2disk",
+				"quay.io/tinkerbell-actions/image2disk",
+				"quay.io/tinkerbell-actions/kexec",
+				"quay.io/tinkerbell-actions/oci2disk",
+				"quay.io/tinkerbell-actions/qemuimg2disk",
+				"quay.io/tinkerbell-actions/rootio",
+				"quay.io/tinkerbell-actions/slurp",
+				"quay.io/tinkerbell-actions/syslinux",
+				"quay.io/tinkerbell-actions/writefile",
+			}),
+		},
+		Redfish: RedfishConfig{
+			Username: getEnv("REDFISH_USERNAME", "admin"),
+			Password: getEnv("REDFISH_PASSWORD", "password"),
+			// BMC通常使用自签名证书
+			InsecureSkipVerify: getEnvAsBool("REDFISH_INSECURE_SKIP_VERIFY", false),
+			Timeout:            getEnvAsDuration("REDFISH_TIMEOUT", 30*time.Second),
+		},
+		IPMI: IPMIConfig{
+			Username: getEnv("IPMI_USERNAME", "admin"),
+			Password: getEnv("IPMI_PASSWORD", "password"),
+			Timeout:  getEnvAsDuration("IPMI_TIMEOUT", 2*time.Second),
+			Retries:  getEnvAsInt("IPMI_RETRIES", 2),
+		},
+		Task: TaskConfig{
+			InstanceID:        getEnv("INSTANCE_ID", hostname()),
+			HeartbeatInterval: getEnvAsDuration("TASK_HEARTBEAT_INTERVAL", 10*time.Second),
+			LeaseTimeout:      getEnvAsDuration("TASK_LEASE_TIMEOUT", time.Minute),
+		},
+		Power: PowerConfig{
+			TaskTimeout:  getEnvAsDuration("POWER_TASK_TIMEOUT", 10*time.Minute),
+			PollInterval: getEnvAsDuration("POWER_POLL_INTERVAL", 5*time.Second),
+		},
+		Firmware: FirmwareConfig{
+			TaskTimeout:    getEnvAsDuration("FIRMWARE_TASK_TIMEOUT", time.Hour),
+			RolloutTimeout: getEnvAsDuration("FIRMWARE_ROLLOUT_TIMEOUT", 24*time.Hour),
+			VerifyChecksum: getEnvAsBool("FIRMWARE_VERIFY_CHECKSUM", true),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			MaxBackoff:   getEnvAsDuration("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+			Retention:    getEnvAsDuration("OUTBOX_RETENTION", 24*time.Hour),
+		},
+		Alert: AlertConfig{
+			EscalateAfter:     getEnvAsDuration("ALERT_ESCALATE_AFTER", 30*time.Minute),
+			MaxEscalations:    getEnvAsInt("ALERT_MAX_ESCALATIONS", 3),
+			CheckInterval:     getEnvAsDuration("ALERT_CHECK_INTERVAL", 30*time.Second),
+			RuleSyncInterval:  getEnvAsDuration("ALERT_RULE_SYNC_INTERVAL", 30*time.Second),
+			CorrelationWindow: getEnvAsDuration("ALERT_CORRELATION_WINDOW", 5*time.Minute),
+		},
+		Notify: NotifyConfig{
+			MaxAttempts:    getEnvAsInt("NOTIFY_MAX_ATTEMPTS", 5),
+			InitialBackoff: getEnvAsDuration("NOTIFY_INITIAL_BACKOFF", time.Second),
+			MaxBackoff:     getEnvAsDuration("NOTIFY_MAX_BACKOFF", time.Minute),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvAsInt("SMTP_PORT", 25),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "gpu-management@localhost"),
 		},
 		K8s: K8sConfig{
 			ConfigPath: getEnv("K8S_CONFIG_PATH", ""),
 			Namespace:  getEnv("K8S_NAMESPACE", "default"),
 		},
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+		Mode:     getEnv("APP_MODE", ModeOnline),
 	}
 }
 
+// hostname 返回主机名，获取失败时返回空字符串
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
 // getEnv 获取环境变量
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -117,3 +343,58 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsBool 获取环境变量并转换为bool
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsDuration 获取环境变量并转换为time.Duration
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice 获取逗号分隔的环境变量并转换为[]string
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return defaultValue
+	}
+	return items
+}
+
+// getEnvAsDurations 获取逗号分隔的环境变量并转换为[]time.Duration，任一项无效时使用默认值
+func getEnvAsDurations(key string, defaultValue []time.Duration) []time.Duration {
+	items := getEnvAsSlice(key, nil)
+	if items == nil {
+		return defaultValue
+	}
+	durations := make([]time.Duration, len(items))
+	for i, item := range items {
+		duration, err := time.ParseDuration(item)
+		if err != nil {
+			return defaultValue
+		}
+		durations[i] = duration
+	}
+	return durations
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+)
+
+// Alert 告警，状态按AlertTransitions迁移
+type Alert struct {
+	ID string `json:"id" db:"id"`
+	// SourceType和SourceID 产生告警的资源
+	SourceType string `json:"source_type" db:"source_type"`
+	SourceID   string `json:"source_id" db:"source_id"`
+	AlertType  string `json:"alert_type" db:"alert_type"`
+	Severity   string `json:"severity" db:"severity"`
+	Message    string `json:"message" db:"message"`
+	Status     string `json:"status" db:"status"`
+	// Labels 附加标签，静默规则按标签匹配
+	Labels map[string]string `json:"labels" db:"labels"`
+	// AcknowledgedBy和AcknowledgedAt 确认告警的用户和时间
+	AcknowledgedBy string     `json:"acknowledged_by" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at" db:"acknowledged_at"`
+	ResolvedBy     string     `json:"resolved_by" db:"resolved_by"`
+	// EscalationLevel 未确认而自动升级的次数，EscalatedAt为最近一次升级时间
+	EscalationLevel int        `json:"escalation_level" db:"escalation_level"`
+	EscalatedAt     *time.Time `json:"escalated_at" db:"escalated_at"`
+	// SilenceID 当前匹配的静默规则，为空表示未被静默
+	SilenceID   string     `json:"silence_id" db:"silence_id"`
+	TriggeredAt time.Time  `json:"triggered_at" db:"triggered_at"`
+	ResolvedAt  *time.Time `json:"resolved_at" db:"resolved_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// AlertStatus 告警状态枚举
+const (
+	AlertStatusActive       = "active"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertSeverity 告警严重程度枚举，按从低到高排列
+const (
+	AlertSeverityLow      = "low"
+	AlertSeverityMedium   = "medium"
+	AlertSeverityHigh     = "high"
+	AlertSeverityCritical = "critical"
+)
+
+// AlertSeverities 按从低到高排列的严重程度
+var AlertSeverities = []string{AlertSeverityLow, AlertSeverityMedium, AlertSeverityHigh, AlertSeverityCritical}
+
+// AlertTransitions 告警状态机
+// active -> acknowledged -> resolved, active -> resolved
+var AlertTransitions = TransitionTable{
+	AlertStatusActive:       {AlertStatusAcknowledged, AlertStatusResolved},
+	AlertStatusAcknowledged: {AlertStatusResolved},
+}
+
+// SeverityRank 返回严重程度的排序，未知的严重程度返回-1
+func SeverityRank(severity string) int {
+	for i, s := range AlertSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// NextSeverity 返回高一级的严重程度，已是critical时保持不变
+func NextSeverity(severity string) string {
+	rank := SeverityRank(severity)
+	if rank < 0 || rank == len(AlertSeverities)-1 {
+		return severity
+	}
+	return AlertSeverities[rank+1]
+}
+
+// MatchLabels 返回静默规则匹配使用的标签：附加标签加上alert_type、severity、source_type、source_id
+func (a *Alert) MatchLabels() map[string]string {
+	labels := make(map[string]string, len(a.Labels)+4)
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+	labels["alert_type"] = a.AlertType
+	labels["severity"] = a.Severity
+	labels["source_type"] = a.SourceType
+	labels["source_id"] = a.SourceID
+	return labels
+}
+
+// Silence 静默规则，在StartsAt到EndsAt之间静默标签全部匹配Matchers的告警
+// 被静默的告警照常记录和迁移状态，但不会自动升级
+type Silence struct {
+	ID string `json:"id" db:"id"`
+	// Matchers 标签名到取值的精确匹配，全部满足才算匹配
+	Matchers  map[string]string `json:"matchers" db:"matchers"`
+	Comment   string            `json:"comment" db:"comment"`
+	CreatedBy string            `json:"created_by" db:"created_by"`
+	StartsAt  time.Time         `json:"starts_at" db:"starts_at"`
+	EndsAt    time.Time         `json:"ends_at" db:"ends_at"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// SilenceState 静默规则状态枚举，由时间范围推导，不单独存储
+const (
+	SilenceStatePending = "pending"
+	SilenceStateActive  = "active"
+	SilenceStateExpired = "expired"
+)
+
+// State 返回静默规则在now时刻的状态
+func (s *Silence) State(now time.Time) string {
+	switch {
+	case now.Before(s.StartsAt):
+		return SilenceStatePending
+	case now.Before(s.EndsAt):
+		return SilenceStateActive
+	default:
+		return SilenceStateExpired
+	}
+}
+
+// Matches 判断标签是否满足全部匹配条件
+func (s *Silence) Matches(labels map[string]string) bool {
+	for k, v := range s.Matchers {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
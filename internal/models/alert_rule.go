@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertRule 告警规则，按GPU遥测指标判断是否产生告警
+// 指标满足Operator和Threshold并持续For后产生告警，不再满足Operator和ResolveThreshold并持续ResolveFor后解除
+type AlertRule struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// Metric 比较的指标，取GPUUtilization的JSON字段名，如temperature、memory_usage_percent
+	Metric    string  `json:"metric" db:"metric"`
+	Operator  string  `json:"operator" db:"operator"`
+	Threshold float64 `json:"threshold" db:"threshold"`
+	// For 指标持续满足条件的时长，为空表示立即产生告警
+	For string `json:"for" db:"for_duration"`
+	// ResolveThreshold 解除阈值，为空时与Threshold相同；与Threshold之间的区间用于防止告警反复产生和解除
+	ResolveThreshold *float64 `json:"resolve_threshold" db:"resolve_threshold"`
+	// ResolveFor 指标持续恢复的时长，为空表示恢复后立即解除
+	ResolveFor string `json:"resolve_for" db:"resolve_for"`
+	// Conditions GPU属性的精确匹配条件，支持status、model、server_id，全部满足时规则才生效
+	Conditions map[string]string `json:"conditions" db:"conditions"`
+	AlertType  string            `json:"alert_type" db:"alert_type"`
+	Severity   string            `json:"severity" db:"severity"`
+	// Message 告警内容，为空时按规则生成
+	Message string `json:"message" db:"message"`
+	// Overrides 按GPU型号覆盖阈值、时长和严重程度
+	Overrides map[string]AlertRuleOverride `json:"overrides" db:"overrides"`
+	Enabled   bool                         `json:"enabled" db:"enabled"`
+	CreatedAt time.Time                    `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at" db:"updated_at"`
+}
+
+// AlertRuleOverride 告警规则对某一GPU型号的覆盖，零值字段沿用规则本身的设置
+type AlertRuleOverride struct {
+	Threshold        *float64 `json:"threshold,omitempty"`
+	ResolveThreshold *float64 `json:"resolve_threshold,omitempty"`
+	For              *string  `json:"for,omitempty"`
+	Severity         string   `json:"severity,omitempty"`
+	// Disabled 对该型号停用规则
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// AlertRuleOperator 告警规则比较运算符
+const (
+	AlertRuleOperatorGT  = ">"
+	AlertRuleOperatorGTE = ">="
+	AlertRuleOperatorLT  = "<"
+	AlertRuleOperatorLTE = "<="
+)
+
+// ForModel 返回应用型号覆盖后的规则，第二个返回值为false表示规则对该型号停用
+func (r *AlertRule) ForModel(model string) (AlertRule, bool) {
+	effective := *r
+	override, ok := r.Overrides[model]
+	if !ok {
+		return effective, r.Enabled
+	}
+	if override.Threshold != nil {
+		effective.Threshold = *override.Threshold
+		// 只覆盖了触发阈值时，解除阈值随之覆盖，避免解除阈值落在触发阈值错误的一侧
+		if override.ResolveThreshold == nil {
+			effective.ResolveThreshold = nil
+		}
+	}
+	if override.ResolveThreshold != nil {
+		effective.ResolveThreshold = override.ResolveThreshold
+	}
+	if override.For != nil {
+		effective.For = *override.For
+	}
+	if override.Severity != "" {
+		effective.Severity = override.Severity
+	}
+	return effective, r.Enabled && !override.Disabled
+}
+
+// Compare 判断value与threshold是否满足规则的比较运算符
+func (r *AlertRule) Compare(value, threshold float64) bool {
+	switch r.Operator {
+	case AlertRuleOperatorGT:
+		return value > threshold
+	case AlertRuleOperatorGTE:
+		return value >= threshold
+	case AlertRuleOperatorLT:
+		return value < threshold
+	case AlertRuleOperatorLTE:
+		return value <= threshold
+	default:
+		return false
+	}
+}
+
+// ClearThreshold 返回解除阈值
+func (r *AlertRule) ClearThreshold() float64 {
+	if r.ResolveThreshold != nil {
+		return *r.ResolveThreshold
+	}
+	return r.Threshold
+}
+
+// MetricValue 返回GPU指标中名为metric的值，metric为GPUUtilization的JSON字段名
+func (u *GPUUtilization) MetricValue(metric string) (float64, bool) {
+	switch metric {
+	case "gpu_usage_percent":
+		return u.GPUUsagePercent, true
+	case "memory_usage_percent":
+		return u.MemoryUsagePercent, true
+	case "compute_usage_percent":
+		return u.ComputeUsagePercent, true
+	case "memory_bandwidth_usage":
+		return u.MemoryBandwidthUsage, true
+	case "temperature":
+		return u.Temperature, true
+	case "power_consumption":
+		return u.PowerConsumption, true
+	default:
+		return 0, false
+	}
+}
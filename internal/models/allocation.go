@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Allocation 资源分配模型
+type Allocation struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	ServerID  string     `json:"server_id" db:"server_id"`
+	Status    string     `json:"status" db:"status"`
+	StartTime *time.Time `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time" db:"end_time"`
+	Purpose   string     `json:"purpose" db:"purpose"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+
+	// GPUIDs 调度器为该分配选定的GPU，保存在allocation_gpus关联表中
+	GPUIDs []string `json:"gpu_ids" db:"-"`
+}
+
+// AllocationStatus 分配状态枚举
+const (
+	AllocationStatusPending   = "pending"
+	AllocationStatusActive    = "active"
+	AllocationStatusCompleted = "completed"
+	AllocationStatusFailed    = "failed"
+)
+
+// AllocationTransitions 分配状态机
+// pending -> active -> completed/failed, pending -> failed, failed -> pending(重新分配)
+var AllocationTransitions = TransitionTable{
+	AllocationStatusPending: {AllocationStatusActive, AllocationStatusFailed},
+	AllocationStatusActive:  {AllocationStatusCompleted, AllocationStatusFailed},
+	AllocationStatusFailed:  {AllocationStatusPending},
+}
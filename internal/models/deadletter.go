@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeadLetter 死信，事件处理器重试耗尽后仍失败的事件及失败原因
+type DeadLetter struct {
+	ID string `json:"id" db:"id"`
+	// Topic 事件原始主题
+	Topic string `json:"topic" db:"topic"`
+	// Handler 处理失败的处理器名称，重放时只交给该处理器
+	Handler string `json:"handler" db:"handler"`
+	// Payload 事件原始内容
+	Payload json.RawMessage `json:"payload" db:"payload"`
+	// Error 最近一次处理失败的错误，重放失败时更新
+	Error string `json:"error" db:"error"`
+	// Attempts 进入死信前的投递次数
+	Attempts int    `json:"attempts" db:"attempts"`
+	Status   string `json:"status" db:"status"`
+	// Replays 手动重放次数
+	Replays    int        `json:"replays" db:"replays"`
+	ResolvedBy string     `json:"resolved_by" db:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at" db:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// DeadLetterStatus 死信状态枚举
+const (
+	DeadLetterStatusPending   = "pending"
+	DeadLetterStatusReplayed  = "replayed"
+	DeadLetterStatusDiscarded = "discarded"
+)
+
+// DeadLetterTransitions 死信状态机
+// pending -> replayed(重放成功)/discarded(人工丢弃)，重放失败保持pending
+var DeadLetterTransitions = TransitionTable{
+	DeadLetterStatusPending: {DeadLetterStatusReplayed, DeadLetterStatusDiscarded},
+}
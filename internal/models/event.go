@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event 事件存储中的一条事件，由事件总线上的CloudEvent转换而来，写入后不再修改
+type Event struct {
+	// ID CloudEvent的id，同一事件重复投递时只保存一次
+	ID        string `json:"id" db:"id"`
+	EventType string `json:"event_type" db:"event_type"`
+	// SourceType和SourceID 事件涉及的资源
+	SourceType string `json:"source_type" db:"source_type"`
+	SourceID   string `json:"source_id" db:"source_id"`
+	// ServerID和GPUID 事件关联的服务器和GPU，用于按服务器或GPU查询时间线，无关联时为空
+	ServerID string `json:"server_id" db:"server_id"`
+	GPUID    string `json:"gpu_id" db:"gpu_id"`
+	Severity string `json:"severity" db:"severity"`
+	// Producer 产生事件的组件，即CloudEvent的source
+	Producer   string          `json:"producer" db:"producer"`
+	DataSchema string          `json:"dataschema" db:"dataschema"`
+	Data       json.RawMessage `json:"data" db:"data"`
+	OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// EventSourceType 事件来源资源类型枚举
+const (
+	EventSourceServer     = "server"
+	EventSourceGPU        = "gpu"
+	EventSourceAllocation = "allocation"
+	EventSourceTask       = "task"
+	EventSourceAlert      = "alert"
+	EventSourceAnnotation = "annotation"
+)
+
+// EventSeverity 事件严重程度枚举
+const (
+	EventSeverityInfo    = "info"
+	EventSeverityWarning = "warning"
+	EventSeverityError   = "error"
+)
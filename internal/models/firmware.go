@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+)
+
+// Firmware 固件目录条目，同一组件的每个版本一条记录
+type Firmware struct {
+	ID        string `json:"id" db:"id"`
+	Component string `json:"component" db:"component"`
+	Version   string `json:"version" db:"version"`
+	// ImageURI BMC拉取固件镜像的地址
+	ImageURI string `json:"image_uri" db:"image_uri"`
+	// Checksum 镜像摘要，格式为 算法:十六进制摘要，例如 sha256:9f86d0...
+	Checksum string `json:"checksum" db:"checksum"`
+	// Models 适用的服务器型号，为空表示不限型号
+	Models       []string  `json:"models" db:"models"`
+	ReleaseNotes string    `json:"release_notes" db:"release_notes"`
+	CreatedBy    string    `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// AppliesTo 固件是否适用于该型号的服务器
+func (f *Firmware) AppliesTo(model string) bool {
+	if len(f.Models) == 0 {
+		return true
+	}
+	for _, m := range f.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// FirmwareRollout 分批固件升级，每批并行升级BatchSize台服务器
+type FirmwareRollout struct {
+	ID         string `json:"id" db:"id"`
+	FirmwareID string `json:"firmware_id" db:"firmware_id"`
+	Status     string `json:"status" db:"status"`
+	BatchSize  int    `json:"batch_size" db:"batch_size"`
+	// MaxFailureRate 已完成服务器中失败比例超过该值时停止后续批次，取值0~1
+	MaxFailureRate float64 `json:"max_failure_rate" db:"max_failure_rate"`
+	// TaskID 当前执行升级或回滚的异步任务
+	TaskID    string                  `json:"task_id" db:"task_id"`
+	Targets   []FirmwareRolloutTarget `json:"targets" db:"targets"`
+	Message   string                  `json:"message" db:"message"`
+	CreatedBy string                  `json:"created_by" db:"created_by"`
+	CreatedAt time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at" db:"updated_at"`
+}
+
+// FirmwareRolloutTarget 分批升级中单台服务器的执行结果，记录升级前后的版本
+type FirmwareRolloutTarget struct {
+	ServerID    string     `json:"server_id"`
+	Batch       int        `json:"batch"`
+	Status      string     `json:"status"`
+	FromVersion string     `json:"from_version"`
+	ToVersion   string     `json:"to_version"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// FirmwareRolloutStatus 分批升级状态枚举
+const (
+	FirmwareRolloutStatusPending     = "pending"
+	FirmwareRolloutStatusRunning     = "running"
+	FirmwareRolloutStatusSucceeded   = "succeeded"
+	FirmwareRolloutStatusHalted      = "halted"
+	FirmwareRolloutStatusFailed      = "failed"
+	FirmwareRolloutStatusRollingBack = "rolling_back"
+	FirmwareRolloutStatusRolledBack  = "rolled_back"
+)
+
+// FirmwareTargetStatus 单台服务器的升级状态枚举
+const (
+	FirmwareTargetStatusPending = "pending"
+	FirmwareTargetStatusRunning = "running"
+	// FirmwareTargetStatusSucceeded 升级完成且BMC上报的版本与目标版本一致
+	FirmwareTargetStatusSucceeded = "succeeded"
+	FirmwareTargetStatusFailed    = "failed"
+	// FirmwareTargetStatusSkipped 已是目标版本，或因升级停止未执行
+	FirmwareTargetStatusSkipped        = "skipped"
+	FirmwareTargetStatusRolledBack     = "rolled_back"
+	FirmwareTargetStatusRollbackFailed = "rollback_failed"
+)
+
+// FirmwareRolloutTransitions 分批升级状态机
+// pending -> running -> succeeded/halted/failed -> rolling_back -> rolled_back/failed
+var FirmwareRolloutTransitions = TransitionTable{
+	FirmwareRolloutStatusPending:     {FirmwareRolloutStatusRunning, FirmwareRolloutStatusFailed},
+	FirmwareRolloutStatusRunning:     {FirmwareRolloutStatusSucceeded, FirmwareRolloutStatusHalted, FirmwareRolloutStatusFailed},
+	FirmwareRolloutStatusSucceeded:   {FirmwareRolloutStatusRollingBack},
+	FirmwareRolloutStatusHalted:      {FirmwareRolloutStatusRollingBack},
+	FirmwareRolloutStatusFailed:      {FirmwareRolloutStatusRollingBack},
+	FirmwareRolloutStatusRollingBack: {FirmwareRolloutStatusRolledBack, FirmwareRolloutStatusFailed},
+}
+
+// Finished 分批升级是否已结束（不再有任务在执行）
+func (r *FirmwareRollout) Finished() bool {
+	switch r.Status {
+	case FirmwareRolloutStatusPending, FirmwareRolloutStatusRunning, FirmwareRolloutStatusRollingBack:
+		return false
+	default:
+		return true
+	}
+}
@@ -27,6 +27,26 @@ const (
 	GPUStatusError     = "error"
 )
 
+// GPUTransitions GPU状态机
+// available -> allocated -> in_use -> available, allocated -> available(释放)，任意状态 -> error -> available
+var GPUTransitions = TransitionTable{
+	GPUStatusAvailable: {GPUStatusAllocated, GPUStatusError},
+	GPUStatusAllocated: {GPUStatusInUse, GPUStatusAvailable, GPUStatusError},
+	GPUStatusInUse:     {GPUStatusAvailable, GPUStatusError},
+	GPUStatusError:     {GPUStatusAvailable},
+}
+
+// GPUStatusTransition GPU状态迁移历史记录
+type GPUStatusTransition struct {
+	ID         int64     `json:"id" db:"id"`
+	GPUID      string    `json:"gpu_id" db:"gpu_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Actor      string    `json:"actor" db:"actor"`
+	Reason     string    `json:"reason" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
 // GPUConfig GPU配置模型
 type GPUConfig struct {
 	ID           string            `json:"id" db:"id"`
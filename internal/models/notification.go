@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationChannel 告警通知渠道
+type NotificationChannel struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	Type string `json:"type" db:"type"`
+	// Config 渠道配置：webhook为url和secret（可选，用于签名），slack为url，email为to（逗号分隔）和subject模板（可选）
+	Config map[string]string `json:"config" db:"config"`
+	// Template 通知正文的text/template模板，为空时使用默认模板
+	Template string `json:"template" db:"template"`
+	// RateLimit 每分钟最多发送的通知数，0表示不限制
+	RateLimit int       `json:"rate_limit" db:"rate_limit"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationChannelType 通知渠道类型枚举
+const (
+	NotificationChannelWebhook = "webhook"
+	NotificationChannelSlack   = "slack"
+	NotificationChannelEmail   = "email"
+)
+
+// RedactedSecret 接口返回的渠道配置中替代密钥的占位符，更新渠道时原样传回表示保留原密钥
+const RedactedSecret = "******"
+
+// Redacted 返回隐藏了密钥的渠道副本
+func (c *NotificationChannel) Redacted() NotificationChannel {
+	redacted := *c
+	if secret, ok := c.Config["secret"]; ok && secret != "" {
+		redacted.Config = make(map[string]string, len(c.Config))
+		for k, v := range c.Config {
+			redacted.Config[k] = v
+		}
+		redacted.Config["secret"] = RedactedSecret
+	}
+	return redacted
+}
+
+// NotificationRoute 告警通知路由，告警事件满足全部条件时发送到ChannelIDs中的每个渠道
+type NotificationRoute struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// Matchers 按告警标签精确匹配，标签包括告警的labels以及alert_type、severity、source_type、source_id
+	Matchers map[string]string `json:"matchers" db:"matchers"`
+	// MinSeverity 最低严重程度，为空表示不限制
+	MinSeverity string `json:"min_severity" db:"min_severity"`
+	// Events 匹配的告警事件类型，为空表示alert.raised和alert.resolved
+	Events     []string `json:"events" db:"events"`
+	ChannelIDs []string `json:"channel_ids" db:"channel_ids"`
+	// ScheduleID 值班表，通知中附带当前值班人，email渠道同时发送给值班人
+	ScheduleID string    `json:"schedule_id" db:"schedule_id"`
+	Enabled    bool      `json:"enabled" db:"enabled"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// OnCallSchedule 值班表，成员从RotationStart开始按ShiftLength轮流值班
+type OnCallSchedule struct {
+	ID            string         `json:"id" db:"id"`
+	Name          string         `json:"name" db:"name"`
+	Members       []OnCallMember `json:"members" db:"members"`
+	RotationStart time.Time      `json:"rotation_start" db:"rotation_start"`
+	ShiftLength   string         `json:"shift_length" db:"shift_length"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// OnCallMember 值班成员
+type OnCallMember struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	// ChatHandle 聊天工具中的用户ID，slack渠道用于@值班人
+	ChatHandle string `json:"chat_handle,omitempty"`
+}
+
+// OnCall 返回now时刻的值班人，没有成员或轮班时长无效时返回nil
+func (s *OnCallSchedule) OnCall(now time.Time) *OnCallMember {
+	shift, err := time.ParseDuration(s.ShiftLength)
+	if err != nil || shift <= 0 || len(s.Members) == 0 {
+		return nil
+	}
+	n := int64(len(s.Members))
+	idx := (int64(now.Sub(s.RotationStart)/shift)%n + n) % n
+	// 轮换开始前的不完整班次归前一位成员
+	if now.Before(s.RotationStart) && now.Sub(s.RotationStart)%shift != 0 {
+		idx = (idx - 1 + n) % n
+	}
+	member := s.Members[idx]
+	return &member
+}
+
+// Notification 一条告警事件到一个渠道的通知记录
+type Notification struct {
+	ID string `json:"id" db:"id"`
+	// EventID 触发通知的告警事件ID，同一事件对同一渠道只通知一次
+	EventID   string `json:"event_id" db:"event_id"`
+	EventType string `json:"event_type" db:"event_type"`
+	AlertID   string `json:"alert_id" db:"alert_id"`
+	ChannelID string `json:"channel_id" db:"channel_id"`
+	RouteID   string `json:"route_id" db:"route_id"`
+	// Recipient 值班人，没有值班表时为空
+	Recipient string     `json:"recipient" db:"recipient"`
+	Status    string     `json:"status" db:"status"`
+	Attempts  int        `json:"attempts" db:"attempts"`
+	LastError string     `json:"last_error" db:"last_error"`
+	Subject   string     `json:"subject" db:"subject"`
+	SentAt    *time.Time `json:"sent_at" db:"sent_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationStatus 通知状态枚举
+const (
+	NotificationStatusPending     = "pending"
+	NotificationStatusSent        = "sent"
+	NotificationStatusFailed      = "failed"
+	NotificationStatusRateLimited = "rate_limited"
+)
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent 事务性发件箱中的待发布事件，与业务数据在同一事务中写入，由后台转发到事件总线
+type OutboxEvent struct {
+	// ID 事件ID，同时写入事件内容和消息ID，消费者按该ID去重
+	ID      string          `json:"id" db:"id"`
+	Topic   string          `json:"topic" db:"topic"`
+	Payload json.RawMessage `json:"payload" db:"payload"`
+	Status  string          `json:"status" db:"status"`
+	// Attempts 发布尝试次数
+	Attempts  int    `json:"attempts" db:"attempts"`
+	LastError string `json:"last_error" db:"last_error"`
+	// NextAttemptAt 下次发布时间，发布失败后按退避时间推后
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at" db:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// OutboxStatus 发件箱事件状态枚举
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+)
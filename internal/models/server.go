@@ -15,6 +15,9 @@ type Server struct {
 	ManagementIP         string    `json:"management_ip" db:"management_ip"`
 	SerialNumber         string    `json:"serial_number" db:"serial_number"`
 	TinkerbellHardwareID string    `json:"tinkerbell_hardware_id" db:"tinkerbell_hardware_id"`
+	// Rack和PDU 服务器所在机柜和供电PDU，告警关联分析按此判断拓扑关系
+	Rack                 string    `json:"rack" db:"rack"`
+	PDU                  string    `json:"pdu" db:"pdu"`
 	CreatedAt            time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -50,6 +53,13 @@ type HardwareCRD struct {
 	UpdatedAt     time.Time              `json:"updated_at" db:"updated_at"`
 }
 
+// HardwareCRDStatus 硬件CRD同步状态枚举，Tinkerbell中硬件的状态记录在Metadata的state中
+const (
+	HardwareCRDStatusPending = "pending"
+	HardwareCRDStatusSynced  = "synced"
+	HardwareCRDStatusError   = "error"
+)
+
 // TemplateCRD Tinkerbell模板CRD模型
 type TemplateCRD struct {
 	ID          string    `json:"id" db:"id"`
@@ -74,6 +84,7 @@ type WorkflowCRD struct {
 	StartedAt     *time.Time             `json:"started_at" db:"started_at"`
 	CompletedAt   *time.Time             `json:"completed_at" db:"completed_at"`
 	CreatedAt     time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at" db:"updated_at"`
 }
 
 // WorkflowStatus 工作流状态枚举
@@ -83,3 +94,11 @@ const (
 	WorkflowStatusCompleted = "completed"
 	WorkflowStatusFailed    = "failed"
 )
+
+// WorkflowTransitions 工作流状态机
+// pending -> running -> completed/failed, pending -> failed, failed -> pending(重试)
+var WorkflowTransitions = TransitionTable{
+	WorkflowStatusPending: {WorkflowStatusRunning, WorkflowStatusFailed},
+	WorkflowStatusRunning: {WorkflowStatusCompleted, WorkflowStatusFailed},
+	WorkflowStatusFailed:  {WorkflowStatusPending},
+}
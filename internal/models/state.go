@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+)
+
+// TransitionTable 状态机迁移表：当前状态 -> 允许迁移到的状态
+type TransitionTable map[string][]string
+
+// Allows 判断是否允许从from迁移到to
+func (t TransitionTable) Allows(from, to string) bool {
+	for _, next := range t[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError 非法状态迁移错误
+type TransitionError struct {
+	Entity  string   `json:"entity"`
+	ID      string   `json:"id"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Allowed []string `json:"allowed"`
+}
+
+// Error 实现error接口
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid %s status transition for %s: %s -> %s", e.Entity, e.ID, e.From, e.To)
+}
+
+// Validate 校验状态迁移，非法时返回*TransitionError
+func (t TransitionTable) Validate(entity, id, from, to string) error {
+	if t.Allows(from, to) {
+		return nil
+	}
+	return &TransitionError{
+		Entity:  entity,
+		ID:      id,
+		From:    from,
+		To:      to,
+		Allowed: append([]string{}, t[from]...),
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// Task 异步任务模型，记录耗时较长的硬件操作（如电源控制、固件升级）的执行进度
+type Task struct {
+	ID          string                 `json:"id" db:"id"`
+	Type        string                 `json:"type" db:"type"`
+	TargetType  string                 `json:"target_type" db:"target_type"`
+	TargetID    string                 `json:"target_id" db:"target_id"`
+	Status      string                 `json:"status" db:"status"`
+	Message     string                 `json:"message" db:"message"`
+	Input       map[string]interface{} `json:"input" db:"input"`
+	Result      map[string]interface{} `json:"result" db:"result"`
+	Error       string                 `json:"error" db:"error"`
+	CreatedBy   string                 `json:"created_by" db:"created_by"`
+	StartedAt   *time.Time             `json:"started_at" db:"started_at"`
+	FinishedAt  *time.Time             `json:"finished_at" db:"finished_at"`
+	Owner       string                 `json:"owner" db:"owner"`               // 执行任务的服务实例
+	HeartbeatAt *time.Time             `json:"heartbeat_at" db:"heartbeat_at"` // 执行实例最近一次续约的时间
+	CreatedAt   time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// TaskStatus 任务状态枚举
+const (
+	TaskStatusPending   = "pending"
+	TaskStatusRunning   = "running"
+	TaskStatusSucceeded = "succeeded"
+	TaskStatusFailed    = "failed"
+)
+
+// TaskType 任务类型枚举
+const (
+	TaskTypeServerPower      = "server.power"
+	TaskTypeServerFirmware   = "server.firmware"
+	TaskTypeFirmwareRollout  = "firmware.rollout"
+	TaskTypeFirmwareRollback = "firmware.rollback"
+)
+
+// TaskTransitions 任务状态机
+// pending -> running -> succeeded/failed, pending -> failed(启动前被中断)
+var TaskTransitions = TransitionTable{
+	TaskStatusPending: {TaskStatusRunning, TaskStatusFailed},
+	TaskStatusRunning: {TaskStatusSucceeded, TaskStatusFailed},
+}
+
+// Finished 任务是否已结束
+func (t *Task) Finished() bool {
+	return t.Status == TaskStatusSucceeded || t.Status == TaskStatusFailed
+}
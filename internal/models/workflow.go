@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+)
+
+// Workflow 分配上的运维工作流模型
+// 部署和清理都通过一个Tinkerbell工作流CRD在目标服务器上执行，状态取值与WorkflowCRD一致
+type Workflow struct {
+	ID            string `json:"id" db:"id"`
+	AllocationID  string `json:"allocation_id" db:"allocation_id"`
+	WorkflowCRDID string `json:"workflow_crd_id" db:"workflow_crd_id"`
+	TinkerbellID  string `json:"tinkerbell_id" db:"tinkerbell_id"`
+	Type          string `json:"type" db:"type"`
+	Status        string `json:"status" db:"status"`
+	// Params 渲染模板使用的参数，如操作系统镜像、驱动和CUDA版本
+	Params map[string]string `json:"params" db:"params"`
+	// Steps 按模板动作顺序排列的步骤时间线，从工作流CRD同步执行进度
+	Steps       []WorkflowStep `json:"steps" db:"steps"`
+	Error       string         `json:"error,omitempty" db:"error"`
+	StartedAt   *time.Time     `json:"started_at" db:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at" db:"completed_at"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// WorkflowStep 工作流步骤，对应模板中的一个动作
+// 失败的步骤按重试策略从该步骤重新提交，之前已成功的步骤不再执行；时间、退出码和日志为最后一次执行的结果
+type WorkflowStep struct {
+	Task   string `json:"task"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	Status string `json:"status"`
+	// Attempts 已执行的次数，MaxAttempts 自动重试时最多执行的次数
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// ExitCode 动作进程的退出码，成功时为0，Tinkerbell未报告退出码时为空
+	ExitCode *int `json:"exit_code,omitempty"`
+	// Log 动作输出的末尾片段
+	Log string `json:"log,omitempty"`
+}
+
+// WorkflowStepStatus 工作流步骤状态枚举
+const (
+	WorkflowStepStatusPending   = "pending"
+	WorkflowStepStatusRunning   = "running"
+	WorkflowStepStatusSucceeded = "succeeded"
+	WorkflowStepStatusFailed    = "failed"
+	WorkflowStepStatusTimeout   = "timeout"
+)
+
+// RetryPolicy 工作流步骤的重试策略
+type RetryPolicy struct {
+	// MaxAttempts 步骤失败时最多执行的次数，包括第一次执行
+	MaxAttempts int `json:"max_attempts"`
+}
+
+// WorkflowType 工作流类型枚举
+const (
+	WorkflowTypeDeploy  = "deploy"
+	WorkflowTypeCleanup = "cleanup"
+	WorkflowTypeConfig  = "config"
+)
+
+// WorkflowTemplate 工作流模板库中的模板版本，版本创建后不可修改
+// Content为Tinkerbell模板YAML，其中[[ ]]括起的部分按Parameters声明的参数渲染，{{ }}留给Tinkerbell按硬件渲染
+type WorkflowTemplate struct {
+	ID          string `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	Version     string `json:"version" db:"version"`
+	Description string `json:"description" db:"description"`
+	OSType      string `json:"os_type" db:"os_type"`
+	Content     string `json:"content" db:"content"`
+	// Parameters 参数的JSON Schema，渲染前按其校验参数并填充默认值
+	Parameters map[string]interface{} `json:"parameters" db:"parameters"`
+	// Retries 步骤的重试策略，键为动作名称，可以使用wipe-disk-*形式的通配符
+	Retries   map[string]RetryPolicy `json:"retries" db:"retries"`
+	CreatedBy string                 `json:"created_by" db:"created_by"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// memoryStore 内存仓储的存储句柄，事务内外的仓储共用同一份数据
+type memoryStore struct {
+	*memoryData
+	// inTx 句柄属于InTx中的仓储，写入时已持有txMu
+	inTx bool
+}
+
+// memoryData 内存仓储的共享存储，所有内存仓储共用一把锁
+type memoryData struct {
+	mu            sync.RWMutex
+	servers       map[string]models.Server
+	gpus          map[string]models.GPU
+	serverConfigs map[string]models.ServerConfig
+	gpuConfigs    map[string]models.GPUConfig
+	allocations   map[string]models.Allocation
+	// allocationGPUs allocation_id -> gpu_id列表
+	allocationGPUs map[string][]string
+	// gpuHistory GPU状态迁移历史，historySeq模拟BIGSERIAL主键
+	gpuHistory    []models.GPUStatusTransition
+	historySeq    int64
+	tasks         map[string]models.Task
+	firmware      map[string]models.Firmware
+	rollouts      map[string]models.FirmwareRollout
+	deadLetters   map[string]models.DeadLetter
+	outbox        map[string]models.OutboxEvent
+	events        map[string]models.Event
+	alerts        map[string]models.Alert
+	silences      map[string]models.Silence
+	alertRules    map[string]models.AlertRule
+	channels      map[string]models.NotificationChannel
+	routes        map[string]models.NotificationRoute
+	schedules     map[string]models.OnCallSchedule
+	notifications map[string]models.Notification
+	hardwareCRDs  map[string]models.HardwareCRD
+	templateCRDs  map[string]models.TemplateCRD
+	workflowCRDs  map[string]models.WorkflowCRD
+	workflows     map[string]models.Workflow
+	templates     map[string]models.WorkflowTemplate
+
+	// txMu 串行化InTx和事务外的写入，事务进行中时事务外的写入等待事务结束，
+	// 避免事务回滚恢复快照时丢失这些写入
+	txMu sync.Mutex
+}
+
+// lock 获取写锁，事务外的写入先获取txMu
+func (s *memoryStore) lock() {
+	if !s.inTx {
+		s.txMu.Lock()
+	}
+	s.mu.Lock()
+}
+
+// unlock 释放lock获取的锁
+func (s *memoryStore) unlock() {
+	s.mu.Unlock()
+	if !s.inTx {
+		s.txMu.Unlock()
+	}
+}
+
+// NewMemoryRepositories 创建基于内存的仓储集合
+// 语义与PostgreSQL实现保持一致，用于测试和离线模式
+func NewMemoryRepositories() *Repositories {
+	store := &memoryStore{memoryData: &memoryData{
+		servers:        map[string]models.Server{},
+		gpus:           map[string]models.GPU{},
+		serverConfigs:  map[string]models.ServerConfig{},
+		gpuConfigs:     map[string]models.GPUConfig{},
+		allocations:    map[string]models.Allocation{},
+		allocationGPUs: map[string][]string{},
+		tasks:          map[string]models.Task{},
+		firmware:       map[string]models.Firmware{},
+		rollouts:       map[string]models.FirmwareRollout{},
+		deadLetters:    map[string]models.DeadLetter{},
+		outbox:         map[string]models.OutboxEvent{},
+		events:         map[string]models.Event{},
+		alerts:         map[string]models.Alert{},
+		silences:       map[string]models.Silence{},
+		alertRules:     map[string]models.AlertRule{},
+		channels:       map[string]models.NotificationChannel{},
+		routes:         map[string]models.NotificationRoute{},
+		schedules:      map[string]models.OnCallSchedule{},
+		notifications:  map[string]models.Notification{},
+		hardwareCRDs:   map[string]models.HardwareCRD{},
+		templateCRDs:   map[string]models.TemplateCRD{},
+		workflowCRDs:   map[string]models.WorkflowCRD{},
+		workflows:      map[string]models.Workflow{},
+		templates:      map[string]models.WorkflowTemplate{},
+	}}
+
+	repos := newMemoryRepositories(store)
+	repos.inTx = func(ctx context.Context, fn func(tx *Repositories) error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		store.txMu.Lock()
+		defer store.txMu.Unlock()
+
+		txRepos := newMemoryRepositories(&memoryStore{memoryData: store.memoryData, inTx: true})
+		// 事务内的嵌套调用加入当前事务
+		txRepos.inTx = func(_ context.Context, fn func(tx *Repositories) error) error {
+			return fn(txRepos)
+		}
+
+		snapshot := store.snapshot()
+		if err := fn(txRepos); err != nil {
+			store.restore(snapshot)
+			return err
+		}
+		return nil
+	}
+	return repos
+}
+
+// newMemoryRepositories 基于共享存储创建仓储集合
+func newMemoryRepositories(store *memoryStore) *Repositories {
+	return &Repositories{
+		Servers:       &memoryServerRepository{store: store},
+		GPUs:          &memoryGPURepository{store: store},
+		ServerConfigs: &memoryServerConfigRepository{store: store},
+		GPUConfigs:    &memoryGPUConfigRepository{store: store},
+		Allocations:   &memoryAllocationRepository{store: store},
+		Tasks:         &memoryTaskRepository{store: store},
+		Firmware:      &memoryFirmwareRepository{store: store},
+		Rollouts:      &memoryFirmwareRolloutRepository{store: store},
+		DeadLetters:   &memoryDeadLetterRepository{store: store},
+		Outbox:        &memoryOutboxRepository{store: store},
+		Events:        &memoryEventRepository{store: store},
+		Alerts:        &memoryAlertRepository{store: store},
+		Silences:      &memorySilenceRepository{store: store},
+		AlertRules:    &memoryAlertRuleRepository{store: store},
+		Channels:      &memoryNotificationChannelRepository{store: store},
+		Routes:        &memoryNotificationRouteRepository{store: store},
+		Schedules:     &memoryOnCallScheduleRepository{store: store},
+		Notifications: &memoryNotificationRepository{store: store},
+		HardwareCRDs:  &memoryHardwareCRDRepository{store: store},
+		TemplateCRDs:  &memoryTemplateCRDRepository{store: store},
+		WorkflowCRDs:  &memoryWorkflowCRDRepository{store: store},
+		Workflows:     &memoryWorkflowRepository{store: store},
+
+		WorkflowTemplates: &memoryWorkflowTemplateRepository{store: store},
+	}
+}
+
+// memorySnapshot 事务开始时的存储快照，事务失败时用于回滚
+// 仓储写入总是整体替换map中的值，浅复制即可；事务外的写入在事务期间等待，不会被回滚覆盖
+type memorySnapshot struct {
+	servers        map[string]models.Server
+	gpus           map[string]models.GPU
+	serverConfigs  map[string]models.ServerConfig
+	gpuConfigs     map[string]models.GPUConfig
+	allocations    map[string]models.Allocation
+	allocationGPUs map[string][]string
+	gpuHistory     []models.GPUStatusTransition
+	historySeq     int64
+	tasks          map[string]models.Task
+	firmware       map[string]models.Firmware
+	rollouts       map[string]models.FirmwareRollout
+	deadLetters    map[string]models.DeadLetter
+	outbox         map[string]models.OutboxEvent
+	events         map[string]models.Event
+	alerts         map[string]models.Alert
+	silences       map[string]models.Silence
+	alertRules     map[string]models.AlertRule
+	channels       map[string]models.NotificationChannel
+	routes         map[string]models.NotificationRoute
+	schedules      map[string]models.OnCallSchedule
+	notifications  map[string]models.Notification
+	hardwareCRDs   map[string]models.HardwareCRD
+	templateCRDs   map[string]models.TemplateCRD
+	workflowCRDs   map[string]models.WorkflowCRD
+	workflows      map[string]models.Workflow
+	templates      map[string]models.WorkflowTemplate
+}
+
+// snapshot 复制当前存储内容
+func (s *memoryStore) snapshot() *memorySnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &memorySnapshot{
+		servers:        maps.Clone(s.servers),
+		gpus:           maps.Clone(s.gpus),
+		serverConfigs:  maps.Clone(s.serverConfigs),
+		gpuConfigs:     maps.Clone(s.gpuConfigs),
+		allocations:    maps.Clone(s.allocations),
+		allocationGPUs: maps.Clone(s.allocationGPUs),
+		gpuHistory:     slices.Clone(s.gpuHistory),
+		historySeq:     s.historySeq,
+		tasks:          maps.Clone(s.tasks),
+		firmware:       maps.Clone(s.firmware),
+		rollouts:       maps.Clone(s.rollouts),
+		deadLetters:    maps.Clone(s.deadLetters),
+		outbox:         maps.Clone(s.outbox),
+		events:         maps.Clone(s.events),
+		alerts:         maps.Clone(s.alerts),
+		silences:       maps.Clone(s.silences),
+		alertRules:     maps.Clone(s.alertRules),
+		channels:       maps.Clone(s.channels),
+		routes:         maps.Clone(s.routes),
+		schedules:      maps.Clone(s.schedules),
+		notifications:  maps.Clone(s.notifications),
+		hardwareCRDs:   maps.Clone(s.hardwareCRDs),
+		templateCRDs:   maps.Clone(s.templateCRDs),
+		workflowCRDs:   maps.Clone(s.workflowCRDs),
+		workflows:      maps.Clone(s.workflows),
+		templates:      maps.Clone(s.templates),
+	}
+}
+
+// restore 将存储恢复到快照时的内容
+func (s *memoryStore) restore(snap *memorySnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.servers = snap.servers
+	s.gpus = snap.gpus
+	s.serverConfigs = snap.serverConfigs
+	s.gpuConfigs = snap.gpuConfigs
+	s.allocations = snap.allocations
+	s.allocationGPUs = snap.allocationGPUs
+	s.gpuHistory = snap.gpuHistory
+	s.historySeq = snap.historySeq
+	s.tasks = snap.tasks
+	s.firmware = snap.firmware
+	s.rollouts = snap.rollouts
+	s.deadLetters = snap.deadLetters
+	s.outbox = snap.outbox
+	s.events = snap.events
+	s.alerts = snap.alerts
+	s.silences = snap.silences
+	s.alertRules = snap.alertRules
+	s.channels = snap.channels
+	s.routes = snap.routes
+	s.schedules = snap.schedules
+	s.notifications = snap.notifications
+	s.hardwareCRDs = snap.hardwareCRDs
+	s.templateCRDs = snap.templateCRDs
+	s.workflowCRDs = snap.workflowCRDs
+	s.workflows = snap.workflows
+	s.templates = snap.templates
+}
+
+// sortByCreated 按创建时间和ID排序，与SQL实现的ORDER BY created_at, id一致
+func sortByCreated[T any](items []T, key func(T) (time.Time, string)) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ti, idi := key(items[i])
+		tj, idj := key(items[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return idi < idj
+	})
+}
+
+// cloneStringMap 复制map，避免调用方修改仓储内部数据
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// cloneMap 浅复制map，避免调用方修改仓储内部数据
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// uniqueStrings 去重并保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneAlert(a models.Alert) models.Alert {
+	a.Labels = cloneStringMap(a.Labels)
+	return a
+}
+
+// memoryAlertRepository 告警仓储的内存实现
+type memoryAlertRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAlertRepository) List(ctx context.Context, filter AlertFilter) ([]models.Alert, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+	severities := map[string]bool{}
+	for _, severity := range filter.Severities {
+		severities[severity] = true
+	}
+
+	alerts := []models.Alert{}
+	for _, a := range r.store.alerts {
+		if filter.SourceType != "" && a.SourceType != filter.SourceType {
+			continue
+		}
+		if filter.SourceID != "" && a.SourceID != filter.SourceID {
+			continue
+		}
+		if filter.AlertType != "" && a.AlertType != filter.AlertType {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[a.Status] {
+			continue
+		}
+		if len(severities) > 0 && !severities[a.Severity] {
+			continue
+		}
+		if !filter.TriggeredFrom.IsZero() && a.TriggeredAt.Before(filter.TriggeredFrom) {
+			continue
+		}
+		if !filter.TriggeredTo.IsZero() && a.TriggeredAt.After(filter.TriggeredTo) {
+			continue
+		}
+		alerts = append(alerts, cloneAlert(a))
+	}
+	sortByCreated(alerts, func(a models.Alert) (time.Time, string) { return a.CreatedAt, a.ID })
+	return alerts, nil
+}
+
+func (r *memoryAlertRepository) Get(ctx context.Context, id string) (*models.Alert, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	a, ok := r.store.alerts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	a = cloneAlert(a)
+	return &a, nil
+}
+
+// GetForUpdate 事务中的写入已由txMu串行化，无需额外加锁
+func (r *memoryAlertRepository) GetForUpdate(ctx context.Context, id string) (*models.Alert, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *memoryAlertRepository) Create(ctx context.Context, alert *models.Alert) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if alert.ID == "" {
+		alert.ID = uuid.New()
+	}
+	if _, exists := r.store.alerts[alert.ID]; exists {
+		return ErrConflict
+	}
+
+	now := time.Now().UTC()
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+	if alert.TriggeredAt.IsZero() {
+		alert.TriggeredAt = now
+	}
+	if err := checkAlert(alert); err != nil {
+		return err
+	}
+	r.store.alerts[alert.ID] = cloneAlert(*alert)
+	return nil
+}
+
+func (r *memoryAlertRepository) UpdateIfStatus(ctx context.Context, alert *models.Alert, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkAlert(alert); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.alerts[alert.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	alert.CreatedAt = existing.CreatedAt
+	alert.UpdatedAt = time.Now().UTC()
+	r.store.alerts[alert.ID] = cloneAlert(*alert)
+	return nil
+}
+
+func cloneSilence(s models.Silence) models.Silence {
+	s.Matchers = cloneStringMap(s.Matchers)
+	return s
+}
+
+// memorySilenceRepository 静默规则仓储的内存实现
+type memorySilenceRepository struct {
+	store *memoryStore
+}
+
+func (r *memorySilenceRepository) List(ctx context.Context, filter SilenceFilter) ([]models.Silence, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	silences := []models.Silence{}
+	for _, s := range r.store.silences {
+		if !filter.ActiveAt.IsZero() && s.State(filter.ActiveAt) != models.SilenceStateActive {
+			continue
+		}
+		silences = append(silences, cloneSilence(s))
+	}
+	sortByCreated(silences, func(s models.Silence) (time.Time, string) { return s.CreatedAt, s.ID })
+	return silences, nil
+}
+
+func (r *memorySilenceRepository) Get(ctx context.Context, id string) (*models.Silence, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	s, ok := r.store.silences[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	s = cloneSilence(s)
+	return &s, nil
+}
+
+func (r *memorySilenceRepository) Create(ctx context.Context, silence *models.Silence) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkSilence(silence); err != nil {
+		return err
+	}
+
+	if silence.ID == "" {
+		silence.ID = uuid.New()
+	}
+	if _, exists := r.store.silences[silence.ID]; exists {
+		return ErrConflict
+	}
+
+	now := time.Now().UTC()
+	silence.CreatedAt = now
+	silence.UpdatedAt = now
+	r.store.silences[silence.ID] = cloneSilence(*silence)
+	return nil
+}
+
+func (r *memorySilenceRepository) Update(ctx context.Context, silence *models.Silence) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkSilence(silence); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.silences[silence.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	silence.CreatedAt = existing.CreatedAt
+	silence.UpdatedAt = time.Now().UTC()
+	r.store.silences[silence.ID] = cloneSilence(*silence)
+	return nil
+}
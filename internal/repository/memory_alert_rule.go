@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneAlertRule(r models.AlertRule) models.AlertRule {
+	r.Conditions = cloneStringMap(r.Conditions)
+	if r.ResolveThreshold != nil {
+		v := *r.ResolveThreshold
+		r.ResolveThreshold = &v
+	}
+	// 覆盖项中的指针指向的值不会被原地修改，浅复制即可
+	if r.Overrides != nil {
+		r.Overrides = maps.Clone(r.Overrides)
+	}
+	return r
+}
+
+// memoryAlertRuleRepository 告警规则仓储的内存实现
+type memoryAlertRuleRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAlertRuleRepository) List(ctx context.Context, filter AlertRuleFilter) ([]models.AlertRule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rules := []models.AlertRule{}
+	for _, rule := range r.store.alertRules {
+		if filter.EnabledOnly && !rule.Enabled {
+			continue
+		}
+		rules = append(rules, cloneAlertRule(rule))
+	}
+	sortByCreated(rules, func(r models.AlertRule) (time.Time, string) { return r.CreatedAt, r.ID })
+	return rules, nil
+}
+
+func (r *memoryAlertRuleRepository) Get(ctx context.Context, id string) (*models.AlertRule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rule, ok := r.store.alertRules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rule = cloneAlertRule(rule)
+	return &rule, nil
+}
+
+func (r *memoryAlertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkAlertRule(rule); err != nil {
+		return err
+	}
+
+	if rule.ID == "" {
+		rule.ID = uuid.New()
+	}
+	if _, exists := r.store.alertRules[rule.ID]; exists {
+		return ErrConflict
+	}
+	if err := r.checkName(rule); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	r.store.alertRules[rule.ID] = cloneAlertRule(*rule)
+	return nil
+}
+
+func (r *memoryAlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkAlertRule(rule); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.alertRules[rule.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := r.checkName(rule); err != nil {
+		return err
+	}
+
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = time.Now().UTC()
+	r.store.alertRules[rule.ID] = cloneAlertRule(*rule)
+	return nil
+}
+
+func (r *memoryAlertRuleRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.alertRules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.alertRules, id)
+	return nil
+}
+
+// checkName 规则名称唯一（alert_rules_name_key），调用方需持有写锁
+func (r *memoryAlertRuleRepository) checkName(rule *models.AlertRule) error {
+	for _, existing := range r.store.alertRules {
+		if existing.ID != rule.ID && existing.Name == rule.Name {
+			return fmt.Errorf("%w: alert_rules_name_key", ErrConflict)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// memoryAllocationRepository 资源分配仓储的内存实现
+type memoryAllocationRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAllocationRepository) List(ctx context.Context, filter AllocationFilter) ([]models.Allocation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	allocations := []models.Allocation{}
+	for _, a := range r.store.allocations {
+		if filter.UserID != "" && a.UserID != filter.UserID {
+			continue
+		}
+		if filter.ServerID != "" && a.ServerID != filter.ServerID {
+			continue
+		}
+		if filter.Status != "" && a.Status != filter.Status {
+			continue
+		}
+		a.GPUIDs = append([]string{}, r.store.allocationGPUs[a.ID]...)
+		allocations = append(allocations, a)
+	}
+	sortByCreated(allocations, func(a models.Allocation) (time.Time, string) { return a.CreatedAt, a.ID })
+	return allocations, nil
+}
+
+func (r *memoryAllocationRepository) Get(ctx context.Context, id string) (*models.Allocation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	a, ok := r.store.allocations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	a.GPUIDs = append([]string{}, r.store.allocationGPUs[id]...)
+	return &a, nil
+}
+
+func (r *memoryAllocationRepository) Create(ctx context.Context, allocation *models.Allocation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkAllocation(allocation); err != nil {
+		return err
+	}
+
+	if allocation.ID == "" {
+		allocation.ID = uuid.New()
+	}
+	if _, exists := r.store.allocations[allocation.ID]; exists {
+		return ErrConflict
+	}
+	if _, ok := r.store.servers[allocation.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	now := time.Now().UTC()
+	allocation.CreatedAt = now
+	allocation.UpdatedAt = now
+	stored := *allocation
+	stored.GPUIDs = nil
+	r.store.allocations[allocation.ID] = stored
+	return nil
+}
+
+func (r *memoryAllocationRepository) UpdateIfStatus(ctx context.Context, allocation *models.Allocation, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkAllocation(allocation); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.allocations[allocation.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+	if _, ok := r.store.servers[allocation.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	allocation.CreatedAt = existing.CreatedAt
+	allocation.UpdatedAt = time.Now().UTC()
+	stored := *allocation
+	stored.GPUIDs = nil
+	r.store.allocations[allocation.ID] = stored
+	return nil
+}
+
+func (r *memoryAllocationRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.allocations[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.allocations, id)
+	// 级联删除GPU关联（allocation_gpus.allocation_id ON DELETE CASCADE）
+	delete(r.store.allocationGPUs, id)
+	// 级联删除工作流（workflows.allocation_id ON DELETE CASCADE）
+	for workflowID, w := range r.store.workflows {
+		if w.AllocationID == id {
+			delete(r.store.workflows, workflowID)
+		}
+	}
+	return nil
+}
+
+func (r *memoryAllocationRepository) AttachGPUs(ctx context.Context, allocationID string, gpuIDs []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.allocations[allocationID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	existing := r.store.allocationGPUs[allocationID]
+	gpuIDs = uniqueStrings(gpuIDs)
+	for _, gpuID := range gpuIDs {
+		if _, ok := r.store.gpus[gpuID]; !ok {
+			return ErrReferenceViolation
+		}
+		for _, attached := range existing {
+			if attached == gpuID {
+				return ErrConflict
+			}
+		}
+	}
+
+	r.store.allocationGPUs[allocationID] = append(existing, gpuIDs...)
+	return nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// 内存仓储按迁移中的CHECK约束校验写入的记录，约束名称与PostgreSQL一致，违反时同样返回ErrCheckViolation
+
+// violation 约束不满足时返回带约束名称的ErrCheckViolation
+func violation(ok bool, constraint string) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrCheckViolation, constraint)
+}
+
+// oneOf value是否为allowed之一
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// notBefore 两个时间都非空时end不早于start
+func notBefore(end, start *time.Time) bool {
+	return end == nil || start == nil || !end.Before(*start)
+}
+
+// firstViolation 返回第一个不满足的约束
+func firstViolation(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkServer(s *models.Server) error {
+	return violation(oneOf(s.Status, models.ServerStatusDiscovered, models.ServerStatusProvisioning,
+		models.ServerStatusReady, models.ServerStatusError), "servers_status_check")
+}
+
+func checkGPU(g *models.GPU) error {
+	return firstViolation(
+		violation(oneOf(g.Status, models.GPUStatusAvailable, models.GPUStatusAllocated,
+			models.GPUStatusInUse, models.GPUStatusError), "gpus_status_check"),
+		violation(g.MemoryGB > 0, "gpus_memory_gb_check"),
+	)
+}
+
+func checkGPUConfig(c *models.GPUConfig) error {
+	return violation(c.PowerLimit > 0, "gpu_configs_power_limit_check")
+}
+
+func checkWorkflowCRD(w *models.WorkflowCRD) error {
+	return firstViolation(
+		violation(oneOf(w.Status, models.WorkflowStatusPending, models.WorkflowStatusRunning,
+			models.WorkflowStatusCompleted, models.WorkflowStatusFailed), "workflow_crds_status_check"),
+		violation(notBefore(w.CompletedAt, w.StartedAt), "workflow_crds_time_check"),
+	)
+}
+
+func checkAllocation(a *models.Allocation) error {
+	return firstViolation(
+		violation(oneOf(a.Status, models.AllocationStatusPending, models.AllocationStatusActive,
+			models.AllocationStatusCompleted, models.AllocationStatusFailed), "allocations_status_check"),
+		violation(a.EndTime == nil || a.StartTime == nil || a.EndTime.After(*a.StartTime), "allocations_time_check"),
+	)
+}
+
+func checkWorkflow(w *models.Workflow) error {
+	return firstViolation(
+		violation(oneOf(w.Type, models.WorkflowTypeDeploy, models.WorkflowTypeCleanup, models.WorkflowTypeConfig), "workflows_type_check"),
+		violation(oneOf(w.Status, models.WorkflowStatusPending, models.WorkflowStatusRunning,
+			models.WorkflowStatusCompleted, models.WorkflowStatusFailed), "workflows_status_check"),
+		violation(notBefore(w.CompletedAt, w.StartedAt), "workflows_time_check"),
+	)
+}
+
+func checkEvent(e *models.Event) error {
+	return violation(oneOf(e.Severity, models.EventSeverityInfo, models.EventSeverityWarning, models.EventSeverityError), "events_severity_check")
+}
+
+func checkAlert(a *models.Alert) error {
+	triggeredAt := a.TriggeredAt
+	return firstViolation(
+		violation(oneOf(a.Severity, models.AlertSeverityLow, models.AlertSeverityMedium,
+			models.AlertSeverityHigh, models.AlertSeverityCritical), "alerts_severity_check"),
+		violation(oneOf(a.Status, models.AlertStatusActive, models.AlertStatusAcknowledged, models.AlertStatusResolved), "alerts_status_check"),
+		violation(notBefore(a.ResolvedAt, &triggeredAt), "alerts_time_check"),
+		violation(a.Status != models.AlertStatusAcknowledged || a.AcknowledgedAt != nil, "alerts_acknowledged_check"),
+		violation((a.Status == models.AlertStatusResolved) == (a.ResolvedAt != nil), "alerts_resolved_check"),
+	)
+}
+
+func checkSilence(s *models.Silence) error {
+	return violation(!s.EndsAt.Before(s.StartsAt), "silences_time_check")
+}
+
+func checkTask(t *models.Task) error {
+	return firstViolation(
+		violation(oneOf(t.Status, models.TaskStatusPending, models.TaskStatusRunning,
+			models.TaskStatusSucceeded, models.TaskStatusFailed), "tasks_status_check"),
+		violation(notBefore(t.FinishedAt, t.StartedAt), "tasks_time_check"),
+	)
+}
+
+func checkFirmwareRollout(r *models.FirmwareRollout) error {
+	return firstViolation(
+		violation(oneOf(r.Status, models.FirmwareRolloutStatusPending, models.FirmwareRolloutStatusRunning,
+			models.FirmwareRolloutStatusSucceeded, models.FirmwareRolloutStatusHalted, models.FirmwareRolloutStatusFailed,
+			models.FirmwareRolloutStatusRollingBack, models.FirmwareRolloutStatusRolledBack), "firmware_rollouts_status_check"),
+		violation(r.BatchSize > 0, "firmware_rollouts_batch_size_check"),
+		violation(r.MaxFailureRate >= 0 && r.MaxFailureRate <= 1, "firmware_rollouts_failure_rate_check"),
+	)
+}
+
+func checkDeadLetter(d *models.DeadLetter) error {
+	return violation(oneOf(d.Status, models.DeadLetterStatusPending, models.DeadLetterStatusReplayed,
+		models.DeadLetterStatusDiscarded), "dead_letters_status_check")
+}
+
+func checkOutboxEvent(e *models.OutboxEvent) error {
+	return violation(oneOf(e.Status, models.OutboxStatusPending, models.OutboxStatusSent), "outbox_status_check")
+}
+
+func checkAlertRule(r *models.AlertRule) error {
+	return violation(oneOf(r.Operator, models.AlertRuleOperatorGT, models.AlertRuleOperatorGTE,
+		models.AlertRuleOperatorLT, models.AlertRuleOperatorLTE), "alert_rules_operator_check")
+}
+
+func checkNotificationChannel(c *models.NotificationChannel) error {
+	return firstViolation(
+		violation(oneOf(c.Type, models.NotificationChannelWebhook, models.NotificationChannelSlack,
+			models.NotificationChannelEmail), "notification_channels_type_check"),
+		violation(c.RateLimit >= 0, "notification_channels_rate_limit_check"),
+	)
+}
+
+func checkNotification(n *models.Notification) error {
+	return violation(oneOf(n.Status, models.NotificationStatusPending, models.NotificationStatusSent,
+		models.NotificationStatusFailed, models.NotificationStatusRateLimited), "notifications_status_check")
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneDeadLetter(l models.DeadLetter) models.DeadLetter {
+	l.Payload = append(json.RawMessage(nil), l.Payload...)
+	return l
+}
+
+// memoryDeadLetterRepository 死信仓储的内存实现
+type memoryDeadLetterRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryDeadLetterRepository) List(ctx context.Context, filter DeadLetterFilter) ([]models.DeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	letters := []models.DeadLetter{}
+	for _, l := range r.store.deadLetters {
+		if filter.Topic != "" && l.Topic != filter.Topic {
+			continue
+		}
+		if filter.Handler != "" && l.Handler != filter.Handler {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[l.Status] {
+			continue
+		}
+		letters = append(letters, cloneDeadLetter(l))
+	}
+	sortByCreated(letters, func(l models.DeadLetter) (time.Time, string) { return l.CreatedAt, l.ID })
+	return letters, nil
+}
+
+func (r *memoryDeadLetterRepository) Get(ctx context.Context, id string) (*models.DeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	l, ok := r.store.deadLetters[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	l = cloneDeadLetter(l)
+	return &l, nil
+}
+
+func (r *memoryDeadLetterRepository) Create(ctx context.Context, letter *models.DeadLetter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkDeadLetter(letter); err != nil {
+		return err
+	}
+
+	if letter.ID == "" {
+		letter.ID = uuid.New()
+	}
+	if _, exists := r.store.deadLetters[letter.ID]; exists {
+		return ErrConflict
+	}
+
+	now := time.Now().UTC()
+	letter.CreatedAt = now
+	letter.UpdatedAt = now
+	r.store.deadLetters[letter.ID] = cloneDeadLetter(*letter)
+	return nil
+}
+
+func (r *memoryDeadLetterRepository) UpdateIfStatus(ctx context.Context, letter *models.DeadLetter, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkDeadLetter(letter); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.deadLetters[letter.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	letter.CreatedAt = existing.CreatedAt
+	letter.UpdatedAt = time.Now().UTC()
+	r.store.deadLetters[letter.ID] = cloneDeadLetter(*letter)
+	return nil
+}
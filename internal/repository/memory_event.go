@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneEvent(e models.Event) models.Event {
+	e.Data = append(json.RawMessage(nil), e.Data...)
+	return e
+}
+
+// memoryEventRepository 事件存储仓储的内存实现
+type memoryEventRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryEventRepository) List(ctx context.Context, filter EventFilter) ([]models.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	eventTypes := map[string]bool{}
+	for _, t := range filter.EventTypes {
+		eventTypes[t] = true
+	}
+	severities := map[string]bool{}
+	for _, s := range filter.Severities {
+		severities[s] = true
+	}
+
+	events := []models.Event{}
+	for _, e := range r.store.events {
+		if filter.SourceType != "" && e.SourceType != filter.SourceType {
+			continue
+		}
+		if filter.SourceID != "" && e.SourceID != filter.SourceID {
+			continue
+		}
+		if filter.ServerID != "" && e.ServerID != filter.ServerID {
+			continue
+		}
+		if filter.GPUID != "" && e.GPUID != filter.GPUID {
+			continue
+		}
+		if len(eventTypes) > 0 && !eventTypes[e.EventType] {
+			continue
+		}
+		if len(severities) > 0 && !severities[e.Severity] {
+			continue
+		}
+		if !filter.Since.IsZero() && e.OccurredAt.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !e.OccurredAt.Before(filter.Until) {
+			continue
+		}
+		if filter.After != nil && !filter.Ascending && !eventBefore(e, filter.After.OccurredAt, filter.After.ID) {
+			continue
+		}
+		if filter.After != nil && filter.Ascending && !eventAfter(e, filter.After.OccurredAt, filter.After.ID) {
+			continue
+		}
+		events = append(events, cloneEvent(e))
+	}
+
+	// 与SQL实现的ORDER BY occurred_at DESC, id DESC（正序时为ASC）一致
+	sort.Slice(events, func(i, j int) bool {
+		if filter.Ascending {
+			return eventBefore(events[i], events[j].OccurredAt, events[j].ID)
+		}
+		return eventBefore(events[j], events[i].OccurredAt, events[i].ID)
+	})
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// eventBefore 判断事件是否排在(occurredAt, id)之后，即 (e.OccurredAt, e.ID) < (occurredAt, id)
+func eventBefore(e models.Event, occurredAt time.Time, id string) bool {
+	if !e.OccurredAt.Equal(occurredAt) {
+		return e.OccurredAt.Before(occurredAt)
+	}
+	return e.ID < id
+}
+
+// eventAfter 判断事件是否排在(occurredAt, id)之后的更新位置，即 (e.OccurredAt, e.ID) > (occurredAt, id)
+func eventAfter(e models.Event, occurredAt time.Time, id string) bool {
+	if !e.OccurredAt.Equal(occurredAt) {
+		return e.OccurredAt.After(occurredAt)
+	}
+	return e.ID > id
+}
+
+func (r *memoryEventRepository) Get(ctx context.Context, id string) (*models.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	e, ok := r.store.events[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	e = cloneEvent(e)
+	return &e, nil
+}
+
+func (r *memoryEventRepository) Create(ctx context.Context, evt *models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkEvent(evt); err != nil {
+		return err
+	}
+
+	if evt.ID == "" {
+		evt.ID = uuid.New()
+	}
+	if _, exists := r.store.events[evt.ID]; exists {
+		return ErrConflict
+	}
+
+	evt.CreatedAt = time.Now().UTC()
+	if evt.OccurredAt.IsZero() {
+		evt.OccurredAt = evt.CreatedAt
+	}
+	r.store.events[evt.ID] = cloneEvent(*evt)
+	return nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneFirmware(f models.Firmware) models.Firmware {
+	f.Models = append([]string(nil), f.Models...)
+	return f
+}
+
+func cloneRollout(r models.FirmwareRollout) models.FirmwareRollout {
+	r.Targets = append([]models.FirmwareRolloutTarget(nil), r.Targets...)
+	return r
+}
+
+// memoryFirmwareRepository 固件目录仓储的内存实现
+type memoryFirmwareRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryFirmwareRepository) List(ctx context.Context, filter FirmwareFilter) ([]models.Firmware, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	items := []models.Firmware{}
+	for _, f := range r.store.firmware {
+		if filter.Component != "" && f.Component != filter.Component {
+			continue
+		}
+		items = append(items, cloneFirmware(f))
+	}
+	sortByCreated(items, func(f models.Firmware) (time.Time, string) { return f.CreatedAt, f.ID })
+	return items, nil
+}
+
+func (r *memoryFirmwareRepository) Get(ctx context.Context, id string) (*models.Firmware, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	f, ok := r.store.firmware[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	f = cloneFirmware(f)
+	return &f, nil
+}
+
+func (r *memoryFirmwareRepository) GetVersion(ctx context.Context, component, version string) (*models.Firmware, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, f := range r.store.firmware {
+		if f.Component == component && f.Version == version {
+			f = cloneFirmware(f)
+			return &f, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryFirmwareRepository) Create(ctx context.Context, firmware *models.Firmware) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if firmware.ID == "" {
+		firmware.ID = uuid.New()
+	}
+	if _, exists := r.store.firmware[firmware.ID]; exists {
+		return ErrConflict
+	}
+	// 同一组件的版本唯一（firmware_component_version_key）
+	for _, f := range r.store.firmware {
+		if f.Component == firmware.Component && f.Version == firmware.Version {
+			return fmt.Errorf("%w: firmware_component_version_key", ErrConflict)
+		}
+	}
+
+	firmware.CreatedAt = time.Now().UTC()
+	r.store.firmware[firmware.ID] = cloneFirmware(*firmware)
+	return nil
+}
+
+func (r *memoryFirmwareRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.firmware[id]; !ok {
+		return ErrNotFound
+	}
+	// 仍被分批升级引用的固件不允许删除（firmware_rollouts.firmware_id ON DELETE RESTRICT）
+	for _, rollout := range r.store.rollouts {
+		if rollout.FirmwareID == id {
+			return ErrReferenceViolation
+		}
+	}
+
+	delete(r.store.firmware, id)
+	return nil
+}
+
+// memoryFirmwareRolloutRepository 分批升级仓储的内存实现
+type memoryFirmwareRolloutRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryFirmwareRolloutRepository) List(ctx context.Context, filter FirmwareRolloutFilter) ([]models.FirmwareRollout, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	rollouts := []models.FirmwareRollout{}
+	for _, rollout := range r.store.rollouts {
+		if filter.FirmwareID != "" && rollout.FirmwareID != filter.FirmwareID {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[rollout.Status] {
+			continue
+		}
+		rollouts = append(rollouts, cloneRollout(rollout))
+	}
+	sortByCreated(rollouts, func(r models.FirmwareRollout) (time.Time, string) { return r.CreatedAt, r.ID })
+	return rollouts, nil
+}
+
+func (r *memoryFirmwareRolloutRepository) Get(ctx context.Context, id string) (*models.FirmwareRollout, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rollout, ok := r.store.rollouts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rollout = cloneRollout(rollout)
+	return &rollout, nil
+}
+
+func (r *memoryFirmwareRolloutRepository) Create(ctx context.Context, rollout *models.FirmwareRollout) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkFirmwareRollout(rollout); err != nil {
+		return err
+	}
+
+	if rollout.ID == "" {
+		rollout.ID = uuid.New()
+	}
+	if _, exists := r.store.rollouts[rollout.ID]; exists {
+		return ErrConflict
+	}
+	if _, ok := r.store.firmware[rollout.FirmwareID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	now := time.Now().UTC()
+	rollout.CreatedAt = now
+	rollout.UpdatedAt = now
+	r.store.rollouts[rollout.ID] = cloneRollout(*rollout)
+	return nil
+}
+
+func (r *memoryFirmwareRolloutRepository) UpdateIfStatus(ctx context.Context, rollout *models.FirmwareRollout, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkFirmwareRollout(rollout); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.rollouts[rollout.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	rollout.CreatedAt = existing.CreatedAt
+	rollout.UpdatedAt = time.Now().UTC()
+	r.store.rollouts[rollout.ID] = cloneRollout(*rollout)
+	return nil
+}
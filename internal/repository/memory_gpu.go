@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneGPUConfig(c models.GPUConfig) models.GPUConfig {
+	c.DriverConfig = cloneStringMap(c.DriverConfig)
+	return c
+}
+
+// memoryGPURepository GPU仓储的内存实现
+type memoryGPURepository struct {
+	store *memoryStore
+}
+
+func (r *memoryGPURepository) List(ctx context.Context, filter GPUFilter) ([]models.GPU, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	gpus := []models.GPU{}
+	for _, g := range r.store.gpus {
+		if filter.ServerID != "" && g.ServerID != filter.ServerID {
+			continue
+		}
+		if filter.Status != "" && g.Status != filter.Status {
+			continue
+		}
+		if filter.Model != "" && g.Model != filter.Model {
+			continue
+		}
+		gpus = append(gpus, g)
+	}
+	sortByCreated(gpus, func(g models.GPU) (time.Time, string) { return g.CreatedAt, g.ID })
+	return gpus, nil
+}
+
+func (r *memoryGPURepository) Get(ctx context.Context, id string) (*models.GPU, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	g, ok := r.store.gpus[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &g, nil
+}
+
+func (r *memoryGPURepository) Create(ctx context.Context, gpu *models.GPU) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkGPU(gpu); err != nil {
+		return err
+	}
+
+	if gpu.ID == "" {
+		gpu.ID = uuid.New()
+	}
+	if _, exists := r.store.gpus[gpu.ID]; exists {
+		return ErrConflict
+	}
+	if _, ok := r.store.servers[gpu.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	now := time.Now().UTC()
+	gpu.CreatedAt = now
+	gpu.UpdatedAt = now
+	r.store.gpus[gpu.ID] = *gpu
+	return nil
+}
+
+func (r *memoryGPURepository) Update(ctx context.Context, gpu *models.GPU) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	existing, ok := r.store.gpus[gpu.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, ok := r.store.servers[gpu.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	// 状态只通过TransitionStatus修改
+	gpu.Status = existing.Status
+	if err := checkGPU(gpu); err != nil {
+		return err
+	}
+	gpu.CreatedAt = existing.CreatedAt
+	gpu.UpdatedAt = time.Now().UTC()
+	r.store.gpus[gpu.ID] = *gpu
+	return nil
+}
+
+func (r *memoryGPURepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.gpus[id]; !ok {
+		return ErrNotFound
+	}
+	// 被分配引用的GPU不允许删除（allocation_gpus.gpu_id ON DELETE RESTRICT）
+	for _, gpuIDs := range r.store.allocationGPUs {
+		for _, gpuID := range gpuIDs {
+			if gpuID == id {
+				return ErrReferenceViolation
+			}
+		}
+	}
+
+	delete(r.store.gpus, id)
+	// 级联删除配置版本（gpu_configs.gpu_id ON DELETE CASCADE）
+	for configID, c := range r.store.gpuConfigs {
+		if c.GPUID == id {
+			delete(r.store.gpuConfigs, configID)
+		}
+	}
+	// 级联删除状态历史（gpu_status_history.gpu_id ON DELETE CASCADE）
+	history := r.store.gpuHistory[:0]
+	for _, h := range r.store.gpuHistory {
+		if h.GPUID != id {
+			history = append(history, h)
+		}
+	}
+	r.store.gpuHistory = history
+	return nil
+}
+
+func (r *memoryGPURepository) TransitionStatus(ctx context.Context, ids []string, from, to string, change StatusChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	ids = uniqueStrings(ids)
+	for _, id := range ids {
+		g, ok := r.store.gpus[id]
+		if !ok || g.Status != from {
+			return fmt.Errorf("%w: not all gpus are %s", ErrConflict, from)
+		}
+		g.Status = to
+		if err := checkGPU(&g); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, id := range ids {
+		g := r.store.gpus[id]
+		g.Status = to
+		g.UpdatedAt = now
+		r.store.gpus[id] = g
+
+		r.store.historySeq++
+		r.store.gpuHistory = append(r.store.gpuHistory, models.GPUStatusTransition{
+			ID:         r.store.historySeq,
+			GPUID:      id,
+			FromStatus: from,
+			ToStatus:   to,
+			Actor:      change.Actor,
+			Reason:     change.Reason,
+			CreatedAt:  now,
+		})
+	}
+	return nil
+}
+
+func (r *memoryGPURepository) History(ctx context.Context, gpuID string) ([]models.GPUStatusTransition, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// 按追加顺序保存，即按created_at, id有序
+	history := []models.GPUStatusTransition{}
+	for _, h := range r.store.gpuHistory {
+		if h.GPUID == gpuID {
+			history = append(history, h)
+		}
+	}
+	return history, nil
+}
+
+// memoryGPUConfigRepository GPU配置仓储的内存实现
+type memoryGPUConfigRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryGPUConfigRepository) Create(ctx context.Context, config *models.GPUConfig) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkGPUConfig(config); err != nil {
+		return err
+	}
+
+	if config.ID == "" {
+		config.ID = uuid.New()
+	}
+	if _, exists := r.store.gpuConfigs[config.ID]; exists {
+		return ErrConflict
+	}
+	if _, ok := r.store.gpus[config.GPUID]; !ok {
+		return ErrReferenceViolation
+	}
+	// 同一GPU的配置版本唯一
+	for _, c := range r.store.gpuConfigs {
+		if c.GPUID == config.GPUID && c.Version == config.Version {
+			return ErrConflict
+		}
+	}
+
+	config.CreatedAt = time.Now().UTC()
+	r.store.gpuConfigs[config.ID] = cloneGPUConfig(*config)
+	return nil
+}
+
+func (r *memoryGPUConfigRepository) Latest(ctx context.Context, gpuID string) (*models.GPUConfig, error) {
+	versions, err := r.ListVersions(ctx, gpuID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	latest := versions[len(versions)-1]
+	return &latest, nil
+}
+
+func (r *memoryGPUConfigRepository) ListVersions(ctx context.Context, gpuID string) ([]models.GPUConfig, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	versions := []models.GPUConfig{}
+	for _, c := range r.store.gpuConfigs {
+		if c.GPUID == gpuID {
+			versions = append(versions, cloneGPUConfig(c))
+		}
+	}
+	sortByCreated(versions, func(c models.GPUConfig) (time.Time, string) { return c.CreatedAt, c.ID })
+	return versions, nil
+}
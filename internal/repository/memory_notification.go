@@ -0,0 +1,455 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneChannel(c models.NotificationChannel) models.NotificationChannel {
+	c.Config = cloneStringMap(c.Config)
+	return c
+}
+
+func cloneRoute(r models.NotificationRoute) models.NotificationRoute {
+	r.Matchers = cloneStringMap(r.Matchers)
+	r.Events = append([]string(nil), r.Events...)
+	r.ChannelIDs = append([]string(nil), r.ChannelIDs...)
+	return r
+}
+
+func cloneSchedule(s models.OnCallSchedule) models.OnCallSchedule {
+	s.Members = append([]models.OnCallMember(nil), s.Members...)
+	return s
+}
+
+// memoryNotificationChannelRepository 通知渠道仓储的内存实现
+type memoryNotificationChannelRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryNotificationChannelRepository) List(ctx context.Context) ([]models.NotificationChannel, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	channels := []models.NotificationChannel{}
+	for _, c := range r.store.channels {
+		channels = append(channels, cloneChannel(c))
+	}
+	sortByCreated(channels, func(c models.NotificationChannel) (time.Time, string) { return c.CreatedAt, c.ID })
+	return channels, nil
+}
+
+func (r *memoryNotificationChannelRepository) Get(ctx context.Context, id string) (*models.NotificationChannel, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	c, ok := r.store.channels[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c = cloneChannel(c)
+	return &c, nil
+}
+
+func (r *memoryNotificationChannelRepository) Create(ctx context.Context, channel *models.NotificationChannel) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkNotificationChannel(channel); err != nil {
+		return err
+	}
+
+	if channel.ID == "" {
+		channel.ID = uuid.New()
+	}
+	if _, exists := r.store.channels[channel.ID]; exists {
+		return ErrConflict
+	}
+	// 渠道名称唯一（notification_channels_name_key）
+	for _, c := range r.store.channels {
+		if c.Name == channel.Name {
+			return fmt.Errorf("%w: notification_channels_name_key", ErrConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+	r.store.channels[channel.ID] = cloneChannel(*channel)
+	return nil
+}
+
+func (r *memoryNotificationChannelRepository) Update(ctx context.Context, channel *models.NotificationChannel) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkNotificationChannel(channel); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.channels[channel.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, c := range r.store.channels {
+		if c.ID != channel.ID && c.Name == channel.Name {
+			return fmt.Errorf("%w: notification_channels_name_key", ErrConflict)
+		}
+	}
+
+	channel.CreatedAt = existing.CreatedAt
+	channel.UpdatedAt = time.Now().UTC()
+	r.store.channels[channel.ID] = cloneChannel(*channel)
+	return nil
+}
+
+func (r *memoryNotificationChannelRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.channels[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.channels, id)
+	return nil
+}
+
+// memoryNotificationRouteRepository 通知路由仓储的内存实现
+type memoryNotificationRouteRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryNotificationRouteRepository) List(ctx context.Context) ([]models.NotificationRoute, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	routes := []models.NotificationRoute{}
+	for _, route := range r.store.routes {
+		routes = append(routes, cloneRoute(route))
+	}
+	sortByCreated(routes, func(r models.NotificationRoute) (time.Time, string) { return r.CreatedAt, r.ID })
+	return routes, nil
+}
+
+func (r *memoryNotificationRouteRepository) Get(ctx context.Context, id string) (*models.NotificationRoute, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	route, ok := r.store.routes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	route = cloneRoute(route)
+	return &route, nil
+}
+
+func (r *memoryNotificationRouteRepository) Create(ctx context.Context, route *models.NotificationRoute) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if route.ID == "" {
+		route.ID = uuid.New()
+	}
+	if _, exists := r.store.routes[route.ID]; exists {
+		return ErrConflict
+	}
+	// 路由名称唯一（notification_routes_name_key）
+	for _, existing := range r.store.routes {
+		if existing.Name == route.Name {
+			return fmt.Errorf("%w: notification_routes_name_key", ErrConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	route.CreatedAt = now
+	route.UpdatedAt = now
+	r.store.routes[route.ID] = cloneRoute(*route)
+	return nil
+}
+
+func (r *memoryNotificationRouteRepository) Update(ctx context.Context, route *models.NotificationRoute) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	existing, ok := r.store.routes[route.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, other := range r.store.routes {
+		if other.ID != route.ID && other.Name == route.Name {
+			return fmt.Errorf("%w: notification_routes_name_key", ErrConflict)
+		}
+	}
+
+	route.CreatedAt = existing.CreatedAt
+	route.UpdatedAt = time.Now().UTC()
+	r.store.routes[route.ID] = cloneRoute(*route)
+	return nil
+}
+
+func (r *memoryNotificationRouteRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.routes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.routes, id)
+	return nil
+}
+
+// memoryOnCallScheduleRepository 值班表仓储的内存实现
+type memoryOnCallScheduleRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryOnCallScheduleRepository) List(ctx context.Context) ([]models.OnCallSchedule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	schedules := []models.OnCallSchedule{}
+	for _, s := range r.store.schedules {
+		schedules = append(schedules, cloneSchedule(s))
+	}
+	sortByCreated(schedules, func(s models.OnCallSchedule) (time.Time, string) { return s.CreatedAt, s.ID })
+	return schedules, nil
+}
+
+func (r *memoryOnCallScheduleRepository) Get(ctx context.Context, id string) (*models.OnCallSchedule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	s, ok := r.store.schedules[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	s = cloneSchedule(s)
+	return &s, nil
+}
+
+func (r *memoryOnCallScheduleRepository) Create(ctx context.Context, schedule *models.OnCallSchedule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if schedule.ID == "" {
+		schedule.ID = uuid.New()
+	}
+	if _, exists := r.store.schedules[schedule.ID]; exists {
+		return ErrConflict
+	}
+	// 值班表名称唯一（oncall_schedules_name_key）
+	for _, s := range r.store.schedules {
+		if s.Name == schedule.Name {
+			return fmt.Errorf("%w: oncall_schedules_name_key", ErrConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	r.store.schedules[schedule.ID] = cloneSchedule(*schedule)
+	return nil
+}
+
+func (r *memoryOnCallScheduleRepository) Update(ctx context.Context, schedule *models.OnCallSchedule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	existing, ok := r.store.schedules[schedule.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, s := range r.store.schedules {
+		if s.ID != schedule.ID && s.Name == schedule.Name {
+			return fmt.Errorf("%w: oncall_schedules_name_key", ErrConflict)
+		}
+	}
+
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.UpdatedAt = time.Now().UTC()
+	r.store.schedules[schedule.ID] = cloneSchedule(*schedule)
+	return nil
+}
+
+func (r *memoryOnCallScheduleRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.schedules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.schedules, id)
+	return nil
+}
+
+// memoryNotificationRepository 通知记录仓储的内存实现
+type memoryNotificationRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryNotificationRepository) List(ctx context.Context, filter NotificationFilter) ([]models.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	notifications := []models.Notification{}
+	for _, n := range r.store.notifications {
+		if filter.AlertID != "" && n.AlertID != filter.AlertID {
+			continue
+		}
+		if filter.ChannelID != "" && n.ChannelID != filter.ChannelID {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[n.Status] {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	sortByCreated(notifications, func(n models.Notification) (time.Time, string) { return n.CreatedAt, n.ID })
+	return notifications, nil
+}
+
+func (r *memoryNotificationRepository) Get(ctx context.Context, id string) (*models.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	n, ok := r.store.notifications[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &n, nil
+}
+
+func (r *memoryNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkNotification(notification); err != nil {
+		return err
+	}
+
+	if notification.ID == "" {
+		notification.ID = uuid.New()
+	}
+	if _, exists := r.store.notifications[notification.ID]; exists {
+		return ErrConflict
+	}
+	// 同一事件对同一渠道只有一条记录（notifications_event_channel_key）
+	for _, n := range r.store.notifications {
+		if n.EventID == notification.EventID && n.ChannelID == notification.ChannelID {
+			return fmt.Errorf("%w: notifications_event_channel_key", ErrConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	notification.CreatedAt = now
+	notification.UpdatedAt = now
+	r.store.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (r *memoryNotificationRepository) UpdateIfStatus(ctx context.Context, notification *models.Notification, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkNotification(notification); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.notifications[notification.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	notification.CreatedAt = existing.CreatedAt
+	notification.UpdatedAt = time.Now().UTC()
+	r.store.notifications[notification.ID] = *notification
+	return nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneOutboxEvent(e models.OutboxEvent) models.OutboxEvent {
+	e.Payload = append(json.RawMessage(nil), e.Payload...)
+	return e
+}
+
+// memoryOutboxRepository 事务性发件箱仓储的内存实现
+type memoryOutboxRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryOutboxRepository) Create(ctx context.Context, evt *models.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkOutboxEvent(evt); err != nil {
+		return err
+	}
+
+	if evt.ID == "" {
+		evt.ID = uuid.New()
+	}
+	if _, exists := r.store.outbox[evt.ID]; exists {
+		return ErrConflict
+	}
+
+	evt.CreatedAt = time.Now().UTC()
+	if evt.NextAttemptAt.IsZero() {
+		evt.NextAttemptAt = evt.CreatedAt
+	}
+	r.store.outbox[evt.ID] = cloneOutboxEvent(*evt)
+	return nil
+}
+
+func (r *memoryOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := []models.OutboxEvent{}
+	for _, e := range r.store.outbox {
+		if e.Status != models.OutboxStatusPending || e.NextAttemptAt.After(now) {
+			continue
+		}
+		events = append(events, cloneOutboxEvent(e))
+	}
+	sortByCreated(events, func(e models.OutboxEvent) (time.Time, string) { return e.CreatedAt, e.ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *memoryOutboxRepository) Update(ctx context.Context, evt *models.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkOutboxEvent(evt); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.outbox[evt.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	evt.CreatedAt = existing.CreatedAt
+	r.store.outbox[evt.ID] = cloneOutboxEvent(*evt)
+	return nil
+}
+
+func (r *memoryOutboxRepository) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	var deleted int64
+	for id, e := range r.store.outbox {
+		if e.Status == models.OutboxStatusSent && e.SentAt != nil && e.SentAt.Before(before) {
+			delete(r.store.outbox, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneServerConfig(c models.ServerConfig) models.ServerConfig {
+	c.BIOSConfig = cloneStringMap(c.BIOSConfig)
+	c.RAIDConfig = cloneStringMap(c.RAIDConfig)
+	c.NetworkConfig = cloneStringMap(c.NetworkConfig)
+	return c
+}
+
+// memoryServerRepository 服务器仓储的内存实现
+type memoryServerRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryServerRepository) List(ctx context.Context) ([]models.Server, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	servers := make([]models.Server, 0, len(r.store.servers))
+	for _, s := range r.store.servers {
+		servers = append(servers, s)
+	}
+	sortByCreated(servers, func(s models.Server) (time.Time, string) { return s.CreatedAt, s.ID })
+	return servers, nil
+}
+
+func (r *memoryServerRepository) Get(ctx context.Context, id string) (*models.Server, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	s, ok := r.store.servers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *memoryServerRepository) Create(ctx context.Context, server *models.Server) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkServer(server); err != nil {
+		return err
+	}
+
+	if server.ID == "" {
+		server.ID = uuid.New()
+	}
+	if _, exists := r.store.servers[server.ID]; exists {
+		return ErrConflict
+	}
+	// 服务器名称唯一
+	for _, s := range r.store.servers {
+		if server.Name != "" && s.Name == server.Name {
+			return ErrConflict
+		}
+	}
+
+	now := time.Now().UTC()
+	server.CreatedAt = now
+	server.UpdatedAt = now
+	r.store.servers[server.ID] = *server
+	return nil
+}
+
+func (r *memoryServerRepository) Update(ctx context.Context, server *models.Server) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkServer(server); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.servers[server.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for id, s := range r.store.servers {
+		if id != server.ID && server.Name != "" && s.Name == server.Name {
+			return ErrConflict
+		}
+	}
+
+	server.CreatedAt = existing.CreatedAt
+	server.UpdatedAt = time.Now().UTC()
+	r.store.servers[server.ID] = *server
+	return nil
+}
+
+func (r *memoryServerRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.servers[id]; !ok {
+		return ErrNotFound
+	}
+	// 仍挂载GPU的服务器不允许删除（gpus.server_id ON DELETE RESTRICT）
+	for _, g := range r.store.gpus {
+		if g.ServerID == id {
+			return ErrReferenceViolation
+		}
+	}
+	// 仍有分配记录的服务器不允许删除（allocations.server_id ON DELETE RESTRICT）
+	for _, a := range r.store.allocations {
+		if a.ServerID == id {
+			return ErrReferenceViolation
+		}
+	}
+
+	delete(r.store.servers, id)
+	// 级联删除配置版本（server_configs.server_id ON DELETE CASCADE）
+	for configID, c := range r.store.serverConfigs {
+		if c.ServerID == id {
+			delete(r.store.serverConfigs, configID)
+		}
+	}
+	// 级联删除硬件CRD及其工作流（hardware_crds.server_id、workflow_crds.hardware_id ON DELETE CASCADE）
+	for hardwareID, h := range r.store.hardwareCRDs {
+		if h.ServerID != id {
+			continue
+		}
+		delete(r.store.hardwareCRDs, hardwareID)
+		for workflowID, w := range r.store.workflowCRDs {
+			if w.HardwareID == hardwareID {
+				delete(r.store.workflowCRDs, workflowID)
+			}
+		}
+	}
+	return nil
+}
+
+// memoryServerConfigRepository 服务器配置仓储的内存实现
+type memoryServerConfigRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryServerConfigRepository) Create(ctx context.Context, config *models.ServerConfig) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if config.ID == "" {
+		config.ID = uuid.New()
+	}
+	if _, exists := r.store.serverConfigs[config.ID]; exists {
+		return ErrConflict
+	}
+	if _, ok := r.store.servers[config.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+	// 同一服务器的配置版本唯一
+	for _, c := range r.store.serverConfigs {
+		if c.ServerID == config.ServerID && c.Version == config.Version {
+			return ErrConflict
+		}
+	}
+
+	config.CreatedAt = time.Now().UTC()
+	r.store.serverConfigs[config.ID] = cloneServerConfig(*config)
+	return nil
+}
+
+func (r *memoryServerConfigRepository) Latest(ctx context.Context, serverID string) (*models.ServerConfig, error) {
+	versions, err := r.ListVersions(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	latest := versions[len(versions)-1]
+	return &latest, nil
+}
+
+func (r *memoryServerConfigRepository) ListVersions(ctx context.Context, serverID string) ([]models.ServerConfig, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	versions := []models.ServerConfig{}
+	for _, c := range r.store.serverConfigs {
+		if c.ServerID == serverID {
+			versions = append(versions, cloneServerConfig(c))
+		}
+	}
+	sortByCreated(versions, func(c models.ServerConfig) (time.Time, string) { return c.CreatedAt, c.ID })
+	return versions, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneTask(t models.Task) models.Task {
+	t.Input = cloneMap(t.Input)
+	t.Result = cloneMap(t.Result)
+	return t
+}
+
+// memoryTaskRepository 异步任务仓储的内存实现
+type memoryTaskRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryTaskRepository) List(ctx context.Context, filter TaskFilter) ([]models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	tasks := []models.Task{}
+	for _, t := range r.store.tasks {
+		if filter.Type != "" && t.Type != filter.Type {
+			continue
+		}
+		if filter.TargetType != "" && t.TargetType != filter.TargetType {
+			continue
+		}
+		if filter.TargetID != "" && t.TargetID != filter.TargetID {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[t.Status] {
+			continue
+		}
+		tasks = append(tasks, cloneTask(t))
+	}
+	sortByCreated(tasks, func(t models.Task) (time.Time, string) { return t.CreatedAt, t.ID })
+	return tasks, nil
+}
+
+func (r *memoryTaskRepository) Get(ctx context.Context, id string) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	t = cloneTask(t)
+	return &t, nil
+}
+
+func (r *memoryTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkTask(task); err != nil {
+		return err
+	}
+
+	if task.ID == "" {
+		task.ID = uuid.New()
+	}
+	if _, exists := r.store.tasks[task.ID]; exists {
+		return ErrConflict
+	}
+
+	now := time.Now().UTC()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	r.store.tasks[task.ID] = cloneTask(*task)
+	return nil
+}
+
+func (r *memoryTaskRepository) UpdateIfStatus(ctx context.Context, task *models.Task, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkTask(task); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.tasks[task.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	task.CreatedAt = existing.CreatedAt
+	task.UpdatedAt = time.Now().UTC()
+	r.store.tasks[task.ID] = cloneTask(*task)
+	return nil
+}
+
+func (r *memoryTaskRepository) Heartbeat(ctx context.Context, owner string, at time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	count := 0
+	for id, t := range r.store.tasks {
+		if t.Owner != owner || t.Finished() {
+			continue
+		}
+		heartbeat := at
+		t.HeartbeatAt = &heartbeat
+		r.store.tasks[id] = t
+		count++
+	}
+	return count, nil
+}
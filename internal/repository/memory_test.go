@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// createGPUs 创建一台服务器和count张GPU，每张GPU记录一条状态迁移历史
+func createGPUs(t *testing.T, repos *Repositories, count int) []string {
+	t.Helper()
+	ctx := context.Background()
+
+	server := &models.Server{Name: "node-1", Status: models.ServerStatusReady}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ids := []string{}
+	for i := 0; i < count; i++ {
+		gpu := &models.GPU{ServerID: server.ID, Model: "A100", Status: models.GPUStatusAvailable, MemoryGB: 80}
+		if err := repos.GPUs.Create(ctx, gpu); err != nil {
+			t.Fatalf("failed to create gpu: %v", err)
+		}
+		ids = append(ids, gpu.ID)
+	}
+	if err := repos.GPUs.TransitionStatus(ctx, ids, models.GPUStatusAvailable, models.GPUStatusAllocated, StatusChange{Actor: "test"}); err != nil {
+		t.Fatalf("failed to transition gpus: %v", err)
+	}
+	return ids
+}
+
+func TestMemoryInTxRollback(t *testing.T) {
+	rollback := errors.New("rollback")
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, tx *Repositories, ids []string)
+	}{
+		{
+			// 删除GPU时原地压缩历史切片，回滚后其他GPU的历史必须完整
+			name: "delete gpu with history",
+			fn: func(t *testing.T, tx *Repositories, ids []string) {
+				if err := tx.GPUs.Delete(context.Background(), ids[0]); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "transition and append history",
+			fn: func(t *testing.T, tx *Repositories, ids []string) {
+				err := tx.GPUs.TransitionStatus(context.Background(), ids, models.GPUStatusAllocated, models.GPUStatusInUse, StatusChange{Actor: "test"})
+				if err != nil {
+					t.Fatalf("TransitionStatus() error = %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := NewMemoryRepositories()
+			ids := createGPUs(t, repos, 3)
+			ctx := context.Background()
+
+			err := repos.InTx(ctx, func(tx *Repositories) error {
+				tt.fn(t, tx, ids)
+				return rollback
+			})
+			if !errors.Is(err, rollback) {
+				t.Fatalf("InTx() error = %v, want %v", err, rollback)
+			}
+
+			for _, id := range ids {
+				gpu, err := repos.GPUs.Get(ctx, id)
+				if err != nil {
+					t.Fatalf("Get(%s) after rollback error = %v", id, err)
+				}
+				if gpu.Status != models.GPUStatusAllocated {
+					t.Errorf("gpu %s status = %s after rollback, want %s", id, gpu.Status, models.GPUStatusAllocated)
+				}
+				history, err := repos.GPUs.History(ctx, id)
+				if err != nil {
+					t.Fatalf("History(%s) error = %v", id, err)
+				}
+				if len(history) != 1 || history[0].GPUID != id || history[0].ToStatus != models.GPUStatusAllocated {
+					t.Errorf("history of gpu %s after rollback = %+v, want one transition to %s", id, history, models.GPUStatusAllocated)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryInTxKeepsConcurrentWrites(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	rollback := errors.New("rollback")
+
+	written := make(chan error, 1)
+	err := repos.InTx(ctx, func(tx *Repositories) error {
+		if err := tx.Servers.Create(ctx, &models.Server{Name: "tx", Status: models.ServerStatusReady}); err != nil {
+			return err
+		}
+
+		// 事务进行中时事务外的写入等待事务结束
+		go func() {
+			written <- repos.Servers.Create(ctx, &models.Server{Name: "outside", Status: models.ServerStatusReady})
+		}()
+		select {
+		case err := <-written:
+			t.Errorf("write outside the transaction finished before it ended: %v", err)
+			written <- err
+		case <-time.After(50 * time.Millisecond):
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("InTx() error = %v, want %v", err, rollback)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("Create() outside the transaction error = %v", err)
+	}
+
+	servers, err := repos.Servers.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(servers) != 1 || servers[0].Name != "outside" {
+		t.Errorf("servers after rollback = %+v, want only the one written outside the transaction", servers)
+	}
+}
@@ -0,0 +1,388 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneHardwareCRD(h models.HardwareCRD) models.HardwareCRD {
+	h.Metadata = cloneMap(h.Metadata)
+	return h
+}
+
+func cloneWorkflowCRD(w models.WorkflowCRD) models.WorkflowCRD {
+	w.Steps = cloneMap(w.Steps)
+	return w
+}
+
+// memoryHardwareCRDRepository 硬件CRD映射仓储的内存实现
+type memoryHardwareCRDRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryHardwareCRDRepository) List(ctx context.Context) ([]models.HardwareCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	hardware := make([]models.HardwareCRD, 0, len(r.store.hardwareCRDs))
+	for _, h := range r.store.hardwareCRDs {
+		hardware = append(hardware, cloneHardwareCRD(h))
+	}
+	sortByCreated(hardware, func(h models.HardwareCRD) (time.Time, string) { return h.CreatedAt, h.ID })
+	return hardware, nil
+}
+
+func (r *memoryHardwareCRDRepository) Get(ctx context.Context, id string) (*models.HardwareCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	h, ok := r.store.hardwareCRDs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	h = cloneHardwareCRD(h)
+	return &h, nil
+}
+
+func (r *memoryHardwareCRDRepository) GetByServer(ctx context.Context, serverID string) (*models.HardwareCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, h := range r.store.hardwareCRDs {
+		if h.ServerID == serverID {
+			h = cloneHardwareCRD(h)
+			return &h, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryHardwareCRDRepository) Create(ctx context.Context, hardware *models.HardwareCRD) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if hardware.ID == "" {
+		hardware.ID = uuid.New()
+	}
+	if _, exists := r.store.hardwareCRDs[hardware.ID]; exists {
+		return ErrConflict
+	}
+	// 服务器必须存在（hardware_crds.server_id REFERENCES servers）
+	if _, ok := r.store.servers[hardware.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+	// 服务器与硬件CRD一一对应（hardware_crds_server_id_key）
+	for _, h := range r.store.hardwareCRDs {
+		if h.ServerID == hardware.ServerID {
+			return fmt.Errorf("%w: hardware_crds_server_id_key", ErrConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	hardware.CreatedAt = now
+	hardware.UpdatedAt = now
+	r.store.hardwareCRDs[hardware.ID] = cloneHardwareCRD(*hardware)
+	return nil
+}
+
+func (r *memoryHardwareCRDRepository) Update(ctx context.Context, hardware *models.HardwareCRD) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	existing, ok := r.store.hardwareCRDs[hardware.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, ok := r.store.servers[hardware.ServerID]; !ok {
+		return ErrReferenceViolation
+	}
+	for id, h := range r.store.hardwareCRDs {
+		if id != hardware.ID && h.ServerID == hardware.ServerID {
+			return fmt.Errorf("%w: hardware_crds_server_id_key", ErrConflict)
+		}
+	}
+
+	hardware.CreatedAt = existing.CreatedAt
+	hardware.UpdatedAt = time.Now().UTC()
+	r.store.hardwareCRDs[hardware.ID] = cloneHardwareCRD(*hardware)
+	return nil
+}
+
+func (r *memoryHardwareCRDRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.hardwareCRDs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.hardwareCRDs, id)
+	// 级联删除工作流（workflow_crds.hardware_id ON DELETE CASCADE）
+	for workflowID, w := range r.store.workflowCRDs {
+		if w.HardwareID == id {
+			delete(r.store.workflowCRDs, workflowID)
+		}
+	}
+	return nil
+}
+
+// memoryTemplateCRDRepository 模板CRD映射仓储的内存实现
+type memoryTemplateCRDRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryTemplateCRDRepository) List(ctx context.Context) ([]models.TemplateCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	templates := make([]models.TemplateCRD, 0, len(r.store.templateCRDs))
+	for _, t := range r.store.templateCRDs {
+		templates = append(templates, t)
+	}
+	sortByCreated(templates, func(t models.TemplateCRD) (time.Time, string) { return t.CreatedAt, t.ID })
+	return templates, nil
+}
+
+func (r *memoryTemplateCRDRepository) Get(ctx context.Context, id string) (*models.TemplateCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.templateCRDs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *memoryTemplateCRDRepository) Create(ctx context.Context, template *models.TemplateCRD) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if template.ID == "" {
+		template.ID = uuid.New()
+	}
+	if _, exists := r.store.templateCRDs[template.ID]; exists {
+		return ErrConflict
+	}
+	// 同名模板的版本唯一（template_crds_name_version_key）
+	for _, t := range r.store.templateCRDs {
+		if t.Name == template.Name && t.Version == template.Version {
+			return fmt.Errorf("%w: template_crds_name_version_key", ErrConflict)
+		}
+	}
+
+	now := time.Now().UTC()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	r.store.templateCRDs[template.ID] = *template
+	return nil
+}
+
+func (r *memoryTemplateCRDRepository) Update(ctx context.Context, template *models.TemplateCRD) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	existing, ok := r.store.templateCRDs[template.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	for id, t := range r.store.templateCRDs {
+		if id != template.ID && t.Name == template.Name && t.Version == template.Version {
+			return fmt.Errorf("%w: template_crds_name_version_key", ErrConflict)
+		}
+	}
+
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = time.Now().UTC()
+	r.store.templateCRDs[template.ID] = *template
+	return nil
+}
+
+func (r *memoryTemplateCRDRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.templateCRDs[id]; !ok {
+		return ErrNotFound
+	}
+	// 仍被工作流引用的模板不允许删除（workflow_crds.template_id ON DELETE RESTRICT）
+	for _, w := range r.store.workflowCRDs {
+		if w.TemplateID == id {
+			return ErrReferenceViolation
+		}
+	}
+	delete(r.store.templateCRDs, id)
+	return nil
+}
+
+// memoryWorkflowCRDRepository 工作流CRD映射仓储的内存实现
+type memoryWorkflowCRDRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryWorkflowCRDRepository) List(ctx context.Context, filter WorkflowCRDFilter) ([]models.WorkflowCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	workflows := []models.WorkflowCRD{}
+	for _, w := range r.store.workflowCRDs {
+		if filter.HardwareID != "" && w.HardwareID != filter.HardwareID {
+			continue
+		}
+		if filter.TemplateID != "" && w.TemplateID != filter.TemplateID {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[w.Status] {
+			continue
+		}
+		workflows = append(workflows, cloneWorkflowCRD(w))
+	}
+	sortByCreated(workflows, func(w models.WorkflowCRD) (time.Time, string) { return w.CreatedAt, w.ID })
+	return workflows, nil
+}
+
+func (r *memoryWorkflowCRDRepository) Get(ctx context.Context, id string) (*models.WorkflowCRD, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	w, ok := r.store.workflowCRDs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	w = cloneWorkflowCRD(w)
+	return &w, nil
+}
+
+func (r *memoryWorkflowCRDRepository) Create(ctx context.Context, workflow *models.WorkflowCRD) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkWorkflowCRD(workflow); err != nil {
+		return err
+	}
+
+	if workflow.ID == "" {
+		workflow.ID = uuid.New()
+	}
+	if _, exists := r.store.workflowCRDs[workflow.ID]; exists {
+		return ErrConflict
+	}
+	// 硬件和模板必须存在（workflow_crds.hardware_id、template_id REFERENCES）
+	if _, ok := r.store.hardwareCRDs[workflow.HardwareID]; !ok {
+		return ErrReferenceViolation
+	}
+	if _, ok := r.store.templateCRDs[workflow.TemplateID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	now := time.Now().UTC()
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+	r.store.workflowCRDs[workflow.ID] = cloneWorkflowCRD(*workflow)
+	return nil
+}
+
+func (r *memoryWorkflowCRDRepository) UpdateIfStatus(ctx context.Context, workflow *models.WorkflowCRD, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkWorkflowCRD(workflow); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.workflowCRDs[workflow.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	workflow.CreatedAt = existing.CreatedAt
+	workflow.UpdatedAt = time.Now().UTC()
+	r.store.workflowCRDs[workflow.ID] = cloneWorkflowCRD(*workflow)
+	return nil
+}
+
+func (r *memoryWorkflowCRDRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.workflowCRDs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.workflowCRDs, id)
+	return nil
+}
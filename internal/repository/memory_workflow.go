@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+func cloneWorkflow(w models.Workflow) models.Workflow {
+	w.Params = cloneStringMap(w.Params)
+	if w.Steps != nil {
+		w.Steps = append([]models.WorkflowStep(nil), w.Steps...)
+	}
+	return w
+}
+
+// memoryWorkflowRepository 分配工作流仓储的内存实现
+type memoryWorkflowRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryWorkflowRepository) List(ctx context.Context, filter WorkflowFilter) ([]models.Workflow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	statuses := map[string]bool{}
+	for _, status := range filter.Statuses {
+		statuses[status] = true
+	}
+
+	workflows := []models.Workflow{}
+	for _, w := range r.store.workflows {
+		if filter.AllocationID != "" && w.AllocationID != filter.AllocationID {
+			continue
+		}
+		if filter.WorkflowCRDID != "" && w.WorkflowCRDID != filter.WorkflowCRDID {
+			continue
+		}
+		if filter.Type != "" && w.Type != filter.Type {
+			continue
+		}
+		if len(statuses) > 0 && !statuses[w.Status] {
+			continue
+		}
+		workflows = append(workflows, cloneWorkflow(w))
+	}
+	sortByCreated(workflows, func(w models.Workflow) (time.Time, string) { return w.CreatedAt, w.ID })
+	return workflows, nil
+}
+
+func (r *memoryWorkflowRepository) Get(ctx context.Context, id string) (*models.Workflow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	w, ok := r.store.workflows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	w = cloneWorkflow(w)
+	return &w, nil
+}
+
+func (r *memoryWorkflowRepository) Create(ctx context.Context, workflow *models.Workflow) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkWorkflow(workflow); err != nil {
+		return err
+	}
+
+	if workflow.ID == "" {
+		workflow.ID = uuid.New()
+	}
+	if _, exists := r.store.workflows[workflow.ID]; exists {
+		return ErrConflict
+	}
+	// 分配必须存在（workflows.allocation_id REFERENCES allocations）
+	if _, ok := r.store.allocations[workflow.AllocationID]; !ok {
+		return ErrReferenceViolation
+	}
+
+	now := time.Now().UTC()
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+	r.store.workflows[workflow.ID] = cloneWorkflow(*workflow)
+	return nil
+}
+
+func (r *memoryWorkflowRepository) UpdateIfStatus(ctx context.Context, workflow *models.Workflow, expectedStatus string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if err := checkWorkflow(workflow); err != nil {
+		return err
+	}
+
+	existing, ok := r.store.workflows[workflow.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Status != expectedStatus {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+
+	workflow.CreatedAt = existing.CreatedAt
+	workflow.UpdatedAt = time.Now().UTC()
+	r.store.workflows[workflow.ID] = cloneWorkflow(*workflow)
+	return nil
+}
+
+func (r *memoryWorkflowRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if _, ok := r.store.workflows[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.workflows, id)
+	return nil
+}
+
+func cloneWorkflowTemplate(t models.WorkflowTemplate) models.WorkflowTemplate {
+	t.Parameters = cloneMap(t.Parameters)
+	if t.Retries != nil {
+		retries := make(map[string]models.RetryPolicy, len(t.Retries))
+		for k, v := range t.Retries {
+			retries[k] = v
+		}
+		t.Retries = retries
+	}
+	return t
+}
+
+// memoryWorkflowTemplateRepository 工作流模板库仓储的内存实现
+type memoryWorkflowTemplateRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryWorkflowTemplateRepository) List(ctx context.Context) ([]models.WorkflowTemplate, error) {
+	return r.list(ctx, func(models.WorkflowTemplate) bool { return true })
+}
+
+func (r *memoryWorkflowTemplateRepository) ListVersions(ctx context.Context, name string) ([]models.WorkflowTemplate, error) {
+	return r.list(ctx, func(t models.WorkflowTemplate) bool { return t.Name == name })
+}
+
+func (r *memoryWorkflowTemplateRepository) list(ctx context.Context, match func(models.WorkflowTemplate) bool) ([]models.WorkflowTemplate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	templates := []models.WorkflowTemplate{}
+	for _, t := range r.store.templates {
+		if match(t) {
+			templates = append(templates, cloneWorkflowTemplate(t))
+		}
+	}
+	sortByCreated(templates, func(t models.WorkflowTemplate) (time.Time, string) { return t.CreatedAt, t.ID })
+	return templates, nil
+}
+
+func (r *memoryWorkflowTemplateRepository) Latest(ctx context.Context, name string) (*models.WorkflowTemplate, error) {
+	versions, err := r.ListVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	latest := versions[len(versions)-1]
+	return &latest, nil
+}
+
+func (r *memoryWorkflowTemplateRepository) Get(ctx context.Context, name, version string) (*models.WorkflowTemplate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, t := range r.store.templates {
+		if t.Name == name && t.Version == version {
+			t = cloneWorkflowTemplate(t)
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryWorkflowTemplateRepository) Create(ctx context.Context, template *models.WorkflowTemplate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.lock()
+	defer r.store.unlock()
+
+	if template.ID == "" {
+		template.ID = uuid.New()
+	}
+	if _, exists := r.store.templates[template.ID]; exists {
+		return ErrConflict
+	}
+	// 同名模板的版本唯一（workflow_templates_name_version_key）
+	for _, t := range r.store.templates {
+		if t.Name == template.Name && t.Version == template.Version {
+			return fmt.Errorf("%w: workflow_templates_name_version_key", ErrConflict)
+		}
+	}
+
+	template.CreatedAt = time.Now().UTC()
+	r.store.templates[template.ID] = cloneWorkflowTemplate(*template)
+	return nil
+}
@@ -0,0 +1,14 @@
+-- 用户表
+CREATE TABLE users (
+    id          VARCHAR(64)  PRIMARY KEY,
+    username    VARCHAR(128) NOT NULL,
+    email       VARCHAR(255) NOT NULL,
+    role        VARCHAR(32)  NOT NULL DEFAULT 'engineer',
+    status      VARCHAR(32)  NOT NULL DEFAULT 'active',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT users_username_key UNIQUE (username),
+    CONSTRAINT users_email_key UNIQUE (email),
+    CONSTRAINT users_role_check CHECK (role IN ('admin', 'engineer', 'operator')),
+    CONSTRAINT users_status_check CHECK (status IN ('active', 'inactive'))
+);
@@ -0,0 +1,16 @@
+-- 服务器表
+CREATE TABLE servers (
+    id                      VARCHAR(64)  PRIMARY KEY,
+    name                    VARCHAR(255) NOT NULL,
+    model                   VARCHAR(255) NOT NULL DEFAULT '',
+    status                  VARCHAR(32)  NOT NULL DEFAULT 'discovered',
+    ipmi_ip                 VARCHAR(64)  NOT NULL DEFAULT '',
+    redfish_url             VARCHAR(512) NOT NULL DEFAULT '',
+    management_ip           VARCHAR(64)  NOT NULL DEFAULT '',
+    serial_number           VARCHAR(128) NOT NULL DEFAULT '',
+    tinkerbell_hardware_id  VARCHAR(128) NOT NULL DEFAULT '',
+    created_at              TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT servers_name_key UNIQUE (name),
+    CONSTRAINT servers_status_check CHECK (status IN ('discovered', 'provisioning', 'ready', 'error'))
+);
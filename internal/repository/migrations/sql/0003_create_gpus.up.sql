@@ -0,0 +1,19 @@
+-- GPU表
+CREATE TABLE gpus (
+    id              VARCHAR(64)  PRIMARY KEY,
+    server_id       VARCHAR(64)  NOT NULL REFERENCES servers (id) ON DELETE RESTRICT,
+    model           VARCHAR(255) NOT NULL DEFAULT '',
+    status          VARCHAR(32)  NOT NULL DEFAULT 'available',
+    memory_gb       INTEGER      NOT NULL,
+    serial_number   VARCHAR(128) NOT NULL DEFAULT '',
+    driver_version  VARCHAR(64)  NOT NULL DEFAULT '',
+    cuda_version    VARCHAR(64)  NOT NULL DEFAULT '',
+    gnmi_device_id  VARCHAR(128) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT gpus_status_check CHECK (status IN ('available', 'allocated', 'in_use', 'error')),
+    CONSTRAINT gpus_memory_gb_check CHECK (memory_gb > 0)
+);
+
+CREATE INDEX gpus_server_id_idx ON gpus (server_id);
+CREATE INDEX gpus_status_idx ON gpus (status);
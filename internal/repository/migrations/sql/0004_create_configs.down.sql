@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS gpu_configs;
+DROP TABLE IF EXISTS server_configs;
@@ -0,0 +1,26 @@
+-- 服务器配置版本表
+CREATE TABLE server_configs (
+    id              VARCHAR(64)  PRIMARY KEY,
+    server_id       VARCHAR(64)  NOT NULL REFERENCES servers (id) ON DELETE CASCADE,
+    version         VARCHAR(64)  NOT NULL,
+    bios_config     JSONB,
+    raid_config     JSONB,
+    network_config  JSONB,
+    created_by      VARCHAR(128) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT server_configs_server_id_version_key UNIQUE (server_id, version)
+);
+
+-- GPU配置版本表
+CREATE TABLE gpu_configs (
+    id             VARCHAR(64)  PRIMARY KEY,
+    gpu_id         VARCHAR(64)  NOT NULL REFERENCES gpus (id) ON DELETE CASCADE,
+    version        VARCHAR(64)  NOT NULL,
+    power_limit    INTEGER      NOT NULL,
+    memory_clock   INTEGER      NOT NULL DEFAULT 0,
+    driver_config  JSONB,
+    created_by     VARCHAR(128) NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT gpu_configs_gpu_id_version_key UNIQUE (gpu_id, version),
+    CONSTRAINT gpu_configs_power_limit_check CHECK (power_limit > 0)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS workflow_crds;
+DROP TABLE IF EXISTS template_crds;
+DROP TABLE IF EXISTS hardware_crds;
@@ -0,0 +1,41 @@
+-- Tinkerbell硬件CRD映射表
+CREATE TABLE hardware_crds (
+    id             VARCHAR(64)  PRIMARY KEY,
+    tinkerbell_id  VARCHAR(255) NOT NULL DEFAULT '',
+    server_id      VARCHAR(64)  NOT NULL REFERENCES servers (id) ON DELETE CASCADE,
+    metadata       JSONB,
+    status         VARCHAR(32)  NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    -- 服务器与硬件CRD一一对应
+    CONSTRAINT hardware_crds_server_id_key UNIQUE (server_id)
+);
+
+-- Tinkerbell模板CRD映射表
+CREATE TABLE template_crds (
+    id             VARCHAR(64)  PRIMARY KEY,
+    tinkerbell_id  VARCHAR(255) NOT NULL DEFAULT '',
+    name           VARCHAR(255) NOT NULL,
+    description    TEXT         NOT NULL DEFAULT '',
+    content        TEXT         NOT NULL DEFAULT '',
+    version        VARCHAR(64)  NOT NULL DEFAULT '',
+    os_type        VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT template_crds_name_version_key UNIQUE (name, version)
+);
+
+-- Tinkerbell工作流CRD映射表
+CREATE TABLE workflow_crds (
+    id             VARCHAR(64)  PRIMARY KEY,
+    tinkerbell_id  VARCHAR(255) NOT NULL DEFAULT '',
+    hardware_id    VARCHAR(64)  NOT NULL REFERENCES hardware_crds (id) ON DELETE CASCADE,
+    template_id    VARCHAR(64)  NOT NULL REFERENCES template_crds (id) ON DELETE RESTRICT,
+    status         VARCHAR(32)  NOT NULL DEFAULT 'pending',
+    steps          JSONB,
+    started_at     TIMESTAMPTZ,
+    completed_at   TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT workflow_crds_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    CONSTRAINT workflow_crds_time_check CHECK (completed_at IS NULL OR started_at IS NULL OR completed_at >= started_at)
+);
@@ -0,0 +1,18 @@
+-- 资源分配表
+-- user_id来自认证上下文，用户管理接入前暂不对users表加外键
+CREATE TABLE allocations (
+    id          VARCHAR(64)  PRIMARY KEY,
+    user_id     VARCHAR(64)  NOT NULL,
+    server_id   VARCHAR(64)  NOT NULL REFERENCES servers (id) ON DELETE RESTRICT,
+    status      VARCHAR(32)  NOT NULL DEFAULT 'pending',
+    start_time  TIMESTAMPTZ,
+    end_time    TIMESTAMPTZ,
+    purpose     TEXT         NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT allocations_status_check CHECK (status IN ('pending', 'active', 'completed', 'failed')),
+    CONSTRAINT allocations_time_check CHECK (end_time IS NULL OR start_time IS NULL OR end_time > start_time)
+);
+
+CREATE INDEX allocations_user_id_idx ON allocations (user_id);
+CREATE INDEX allocations_server_id_idx ON allocations (server_id);
@@ -0,0 +1,17 @@
+-- 工作流表
+CREATE TABLE workflows (
+    id             VARCHAR(64)  PRIMARY KEY,
+    allocation_id  VARCHAR(64)  NOT NULL REFERENCES allocations (id) ON DELETE CASCADE,
+    tinkerbell_id  VARCHAR(255) NOT NULL DEFAULT '',
+    type           VARCHAR(32)  NOT NULL,
+    status         VARCHAR(32)  NOT NULL DEFAULT 'pending',
+    steps          JSONB,
+    started_at     TIMESTAMPTZ,
+    completed_at   TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT workflows_type_check CHECK (type IN ('deploy', 'cleanup', 'config')),
+    CONSTRAINT workflows_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    CONSTRAINT workflows_time_check CHECK (completed_at IS NULL OR started_at IS NULL OR completed_at >= started_at)
+);
+
+CREATE INDEX workflows_allocation_id_idx ON workflows (allocation_id);
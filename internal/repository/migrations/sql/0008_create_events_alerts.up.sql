@@ -0,0 +1,35 @@
+-- 事件表
+CREATE TABLE events (
+    id           VARCHAR(64)  PRIMARY KEY,
+    source_type  VARCHAR(32)  NOT NULL,
+    source_id    VARCHAR(64)  NOT NULL,
+    event_type   VARCHAR(128) NOT NULL,
+    severity     VARCHAR(32)  NOT NULL DEFAULT 'info',
+    data         JSONB,
+    occurred_at  TIMESTAMPTZ  NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT events_source_type_check CHECK (source_type IN ('server', 'gpu', 'allocation')),
+    CONSTRAINT events_severity_check CHECK (severity IN ('info', 'warning', 'error'))
+);
+
+CREATE INDEX events_source_idx ON events (source_type, source_id, occurred_at);
+
+-- 告警表
+CREATE TABLE alerts (
+    id            VARCHAR(64)  PRIMARY KEY,
+    source_type   VARCHAR(32)  NOT NULL,
+    source_id     VARCHAR(64)  NOT NULL,
+    alert_type    VARCHAR(128) NOT NULL,
+    severity      VARCHAR(32)  NOT NULL,
+    message       TEXT         NOT NULL DEFAULT '',
+    status        VARCHAR(32)  NOT NULL DEFAULT 'active',
+    triggered_at  TIMESTAMPTZ  NOT NULL,
+    resolved_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT alerts_severity_check CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT alerts_status_check CHECK (status IN ('active', 'acknowledged', 'resolved')),
+    CONSTRAINT alerts_time_check CHECK (resolved_at IS NULL OR resolved_at >= triggered_at)
+);
+
+CREATE INDEX alerts_source_idx ON alerts (source_type, source_id);
+CREATE INDEX alerts_status_idx ON alerts (status);
@@ -0,0 +1,9 @@
+-- 分配与GPU的关联表，记录调度器为分配选定的GPU
+CREATE TABLE allocation_gpus (
+    allocation_id  VARCHAR(64)  NOT NULL REFERENCES allocations (id) ON DELETE CASCADE,
+    gpu_id         VARCHAR(64)  NOT NULL REFERENCES gpus (id) ON DELETE RESTRICT,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (allocation_id, gpu_id)
+);
+
+CREATE INDEX allocation_gpus_gpu_id_idx ON allocation_gpus (gpu_id);
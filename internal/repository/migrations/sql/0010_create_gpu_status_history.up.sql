@@ -0,0 +1,12 @@
+-- GPU状态迁移历史表，由GPU仓储在迁移状态的同一条语句中写入
+CREATE TABLE gpu_status_history (
+    id           BIGSERIAL    PRIMARY KEY,
+    gpu_id       VARCHAR(64)  NOT NULL REFERENCES gpus (id) ON DELETE CASCADE,
+    from_status  VARCHAR(32)  NOT NULL,
+    to_status    VARCHAR(32)  NOT NULL,
+    actor        VARCHAR(255) NOT NULL DEFAULT '',
+    reason       TEXT         NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX gpu_status_history_gpu_id_idx ON gpu_status_history (gpu_id, created_at);
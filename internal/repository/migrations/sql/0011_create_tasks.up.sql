@@ -0,0 +1,25 @@
+-- 异步任务表，记录电源控制等长耗时操作的执行状态
+CREATE TABLE tasks (
+    id           VARCHAR(64)  PRIMARY KEY,
+    type         VARCHAR(64)  NOT NULL,
+    target_type  VARCHAR(32)  NOT NULL DEFAULT '',
+    target_id    VARCHAR(64)  NOT NULL DEFAULT '',
+    status       VARCHAR(32)  NOT NULL DEFAULT 'pending',
+    message      TEXT         NOT NULL DEFAULT '',
+    input        JSONB,
+    result       JSONB,
+    error        TEXT         NOT NULL DEFAULT '',
+    created_by   VARCHAR(64)  NOT NULL DEFAULT '',
+    owner        VARCHAR(255) NOT NULL DEFAULT '',
+    heartbeat_at TIMESTAMPTZ,
+    started_at   TIMESTAMPTZ,
+    finished_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT tasks_status_check CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    CONSTRAINT tasks_time_check CHECK (finished_at IS NULL OR started_at IS NULL OR finished_at >= started_at)
+);
+
+CREATE INDEX tasks_target_idx ON tasks (target_type, target_id, created_at);
+CREATE INDEX tasks_status_idx ON tasks (status);
+CREATE INDEX tasks_owner_idx ON tasks (owner);
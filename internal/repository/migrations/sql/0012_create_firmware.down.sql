@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS firmware_rollouts;
+DROP TABLE IF EXISTS firmware;
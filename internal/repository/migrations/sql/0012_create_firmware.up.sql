@@ -0,0 +1,34 @@
+-- 固件目录，同一组件的每个版本一条记录
+CREATE TABLE firmware (
+    id             VARCHAR(64)   PRIMARY KEY,
+    component      VARCHAR(64)   NOT NULL,
+    version        VARCHAR(64)   NOT NULL,
+    image_uri      TEXT          NOT NULL,
+    checksum       VARCHAR(160)  NOT NULL,
+    models         JSONB,
+    release_notes  TEXT          NOT NULL DEFAULT '',
+    created_by     VARCHAR(64)   NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT firmware_component_version_key UNIQUE (component, version)
+);
+
+-- 分批固件升级，每台服务器的执行结果（升级前后版本）保存在targets中
+CREATE TABLE firmware_rollouts (
+    id                VARCHAR(64)   PRIMARY KEY,
+    firmware_id       VARCHAR(64)   NOT NULL REFERENCES firmware (id) ON DELETE RESTRICT,
+    status            VARCHAR(32)   NOT NULL DEFAULT 'pending',
+    batch_size        INTEGER       NOT NULL,
+    max_failure_rate  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    task_id           VARCHAR(64)   NOT NULL DEFAULT '',
+    targets           JSONB,
+    message           TEXT          NOT NULL DEFAULT '',
+    created_by        VARCHAR(64)   NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT firmware_rollouts_status_check CHECK (status IN ('pending', 'running', 'succeeded', 'halted', 'failed', 'rolling_back', 'rolled_back')),
+    CONSTRAINT firmware_rollouts_batch_size_check CHECK (batch_size > 0),
+    CONSTRAINT firmware_rollouts_failure_rate_check CHECK (max_failure_rate >= 0 AND max_failure_rate <= 1)
+);
+
+CREATE INDEX firmware_rollouts_firmware_id_idx ON firmware_rollouts (firmware_id);
+CREATE INDEX firmware_rollouts_status_idx ON firmware_rollouts (status);
@@ -0,0 +1,19 @@
+-- 死信表，事件处理器重试耗尽后仍失败的事件
+CREATE TABLE dead_letters (
+    id           VARCHAR(64)   PRIMARY KEY,
+    topic        VARCHAR(255)  NOT NULL,
+    handler      VARCHAR(255)  NOT NULL,
+    payload      JSONB,
+    error        TEXT          NOT NULL DEFAULT '',
+    attempts     INTEGER       NOT NULL DEFAULT 0,
+    status       VARCHAR(32)   NOT NULL DEFAULT 'pending',
+    replays      INTEGER       NOT NULL DEFAULT 0,
+    resolved_by  VARCHAR(64)   NOT NULL DEFAULT '',
+    resolved_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT dead_letters_status_check CHECK (status IN ('pending', 'replayed', 'discarded'))
+);
+
+CREATE INDEX dead_letters_status_idx ON dead_letters (status, created_at);
+CREATE INDEX dead_letters_topic_handler_idx ON dead_letters (topic, handler);
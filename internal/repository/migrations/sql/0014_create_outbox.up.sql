@@ -0,0 +1,17 @@
+-- 事务性发件箱，事件与业务数据在同一事务中写入，由后台转发到事件总线
+CREATE TABLE outbox (
+    id               VARCHAR(64)   PRIMARY KEY,
+    topic            VARCHAR(255)  NOT NULL,
+    payload          JSONB,
+    status           VARCHAR(32)   NOT NULL DEFAULT 'pending',
+    attempts         INTEGER       NOT NULL DEFAULT 0,
+    last_error       TEXT          NOT NULL DEFAULT '',
+    next_attempt_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    sent_at          TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT outbox_status_check CHECK (status IN ('pending', 'sent'))
+);
+
+-- 转发只扫描待发布的事件
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at, created_at) WHERE status = 'pending';
+CREATE INDEX outbox_sent_at_idx ON outbox (sent_at) WHERE status = 'sent';
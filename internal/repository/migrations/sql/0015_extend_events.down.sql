@@ -0,0 +1,18 @@
+DROP RULE IF EXISTS events_no_update ON events;
+
+DROP INDEX IF EXISTS events_gpu_idx;
+DROP INDEX IF EXISTS events_server_idx;
+DROP INDEX IF EXISTS events_occurred_at_idx;
+DROP INDEX IF EXISTS events_source_idx;
+CREATE INDEX events_source_idx ON events (source_type, source_id, occurred_at);
+
+DELETE FROM events WHERE source_type NOT IN ('server', 'gpu', 'allocation');
+ALTER TABLE events ADD CONSTRAINT events_source_type_check
+    CHECK (source_type IN ('server', 'gpu', 'allocation'));
+
+ALTER TABLE events
+    DROP COLUMN dataschema,
+    DROP COLUMN producer,
+    DROP COLUMN gpu_id,
+    DROP COLUMN server_id,
+    ALTER COLUMN event_type TYPE VARCHAR(128);
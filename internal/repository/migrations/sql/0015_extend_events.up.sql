@@ -0,0 +1,20 @@
+-- 事件表改为事件存储：保存事件总线上发布的所有事件，只追加不修改
+ALTER TABLE events
+    ADD COLUMN server_id   VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN gpu_id      VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN producer    VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN dataschema  VARCHAR(255) NOT NULL DEFAULT '',
+    ALTER COLUMN event_type TYPE VARCHAR(255);
+
+-- 来源资源类型随事件类型增加，不再用约束限定
+ALTER TABLE events DROP CONSTRAINT events_source_type_check;
+
+-- 查询按发生时间倒序分页，时间线按服务器或GPU过滤
+DROP INDEX events_source_idx;
+CREATE INDEX events_source_idx ON events (source_type, source_id, occurred_at DESC);
+CREATE INDEX events_occurred_at_idx ON events (occurred_at DESC, id DESC);
+CREATE INDEX events_server_idx ON events (server_id, occurred_at DESC) WHERE server_id <> '';
+CREATE INDEX events_gpu_idx ON events (gpu_id, occurred_at DESC) WHERE gpu_id <> '';
+
+-- 事件写入后不允许修改
+CREATE RULE events_no_update AS ON UPDATE TO events DO INSTEAD NOTHING;
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS silences;
+
+DROP INDEX IF EXISTS alerts_open_idx;
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_resolved_check;
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_acknowledged_check;
+
+ALTER TABLE alerts
+    DROP COLUMN updated_at,
+    DROP COLUMN silence_id,
+    DROP COLUMN escalated_at,
+    DROP COLUMN escalation_level,
+    DROP COLUMN resolved_by,
+    DROP COLUMN acknowledged_at,
+    DROP COLUMN acknowledged_by,
+    DROP COLUMN labels;
@@ -0,0 +1,37 @@
+-- 告警生命周期：确认人、解除人、自动升级和静默
+ALTER TABLE alerts
+    ADD COLUMN labels           JSONB,
+    ADD COLUMN acknowledged_by  VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN acknowledged_at  TIMESTAMPTZ,
+    ADD COLUMN resolved_by      VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN escalation_level INTEGER      NOT NULL DEFAULT 0,
+    ADD COLUMN escalated_at     TIMESTAMPTZ,
+    ADD COLUMN silence_id       VARCHAR(64)  NOT NULL DEFAULT '',
+    ADD COLUMN updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW();
+
+-- 已有记录没有确认和解除时间，以触发时间补齐后再加约束
+UPDATE alerts SET acknowledged_at = triggered_at WHERE status = 'acknowledged';
+UPDATE alerts SET resolved_at = triggered_at WHERE status = 'resolved' AND resolved_at IS NULL;
+
+ALTER TABLE alerts ADD CONSTRAINT alerts_acknowledged_check
+    CHECK (status <> 'acknowledged' OR acknowledged_at IS NOT NULL);
+ALTER TABLE alerts ADD CONSTRAINT alerts_resolved_check
+    CHECK ((status = 'resolved') = (resolved_at IS NOT NULL));
+
+-- 升级检查只扫描未解除的告警
+CREATE INDEX alerts_open_idx ON alerts (triggered_at) WHERE status <> 'resolved';
+
+-- 静默规则表，状态由starts_at和ends_at推导，提前结束时ends_at可能等于starts_at
+CREATE TABLE silences (
+    id          VARCHAR(64)  PRIMARY KEY,
+    matchers    JSONB        NOT NULL,
+    comment     TEXT         NOT NULL DEFAULT '',
+    created_by  VARCHAR(64)  NOT NULL DEFAULT '',
+    starts_at   TIMESTAMPTZ  NOT NULL,
+    ends_at     TIMESTAMPTZ  NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT silences_time_check CHECK (ends_at >= starts_at)
+);
+
+CREATE INDEX silences_ends_at_idx ON silences (ends_at);
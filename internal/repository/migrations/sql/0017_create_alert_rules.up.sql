@@ -0,0 +1,20 @@
+-- 告警规则表，按GPU遥测指标产生和解除告警
+CREATE TABLE alert_rules (
+    id                 VARCHAR(64)       PRIMARY KEY,
+    name               VARCHAR(128)      NOT NULL,
+    metric             VARCHAR(64)       NOT NULL,
+    operator           VARCHAR(2)        NOT NULL CHECK (operator IN ('>', '>=', '<', '<=')),
+    threshold          DOUBLE PRECISION  NOT NULL,
+    for_duration       VARCHAR(32)       NOT NULL DEFAULT '',
+    resolve_threshold  DOUBLE PRECISION,
+    resolve_for        VARCHAR(32)       NOT NULL DEFAULT '',
+    conditions         JSONB,
+    alert_type         VARCHAR(128)      NOT NULL,
+    severity           VARCHAR(32)       NOT NULL,
+    message            TEXT              NOT NULL DEFAULT '',
+    overrides          JSONB,
+    enabled            BOOLEAN           NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMPTZ       NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ       NOT NULL DEFAULT NOW(),
+    CONSTRAINT alert_rules_name_key UNIQUE (name)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS alerts_triggered_at_idx;
+
+ALTER TABLE servers
+    DROP COLUMN pdu,
+    DROP COLUMN rack;
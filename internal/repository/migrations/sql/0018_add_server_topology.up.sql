@@ -0,0 +1,7 @@
+-- 服务器所在机柜和供电PDU，用于告警关联分析
+ALTER TABLE servers
+    ADD COLUMN rack VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN pdu  VARCHAR(64) NOT NULL DEFAULT '';
+
+-- 关联分析按触发时间窗口查询告警
+CREATE INDEX alerts_triggered_at_idx ON alerts (triggered_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_routes;
+DROP TABLE IF EXISTS oncall_schedules;
+DROP TABLE IF EXISTS notification_channels;
@@ -0,0 +1,62 @@
+-- 告警通知渠道
+CREATE TABLE notification_channels (
+    id          VARCHAR(64)  PRIMARY KEY,
+    name        VARCHAR(128) NOT NULL,
+    type        VARCHAR(32)  NOT NULL CHECK (type IN ('webhook', 'slack', 'email')),
+    config      JSONB        NOT NULL,
+    template    TEXT         NOT NULL DEFAULT '',
+    rate_limit  INTEGER      NOT NULL DEFAULT 0 CHECK (rate_limit >= 0),
+    enabled     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT notification_channels_name_key UNIQUE (name)
+);
+
+-- 值班表，成员按shift_length轮流值班
+CREATE TABLE oncall_schedules (
+    id              VARCHAR(64)  PRIMARY KEY,
+    name            VARCHAR(128) NOT NULL,
+    members         JSONB        NOT NULL,
+    rotation_start  TIMESTAMPTZ  NOT NULL,
+    shift_length    VARCHAR(32)  NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT oncall_schedules_name_key UNIQUE (name)
+);
+
+-- 通知路由，渠道和值班表以ID引用，删除仍被路由使用的渠道或值班表由服务拒绝
+CREATE TABLE notification_routes (
+    id            VARCHAR(64)  PRIMARY KEY,
+    name          VARCHAR(128) NOT NULL,
+    matchers      JSONB,
+    min_severity  VARCHAR(32)  NOT NULL DEFAULT '',
+    events        JSONB,
+    channel_ids   JSONB        NOT NULL,
+    schedule_id   VARCHAR(64)  NOT NULL DEFAULT '',
+    enabled       BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT notification_routes_name_key UNIQUE (name)
+);
+
+-- 通知记录，同一事件对同一渠道只通知一次，事件重复投递时不会重复发送
+CREATE TABLE notifications (
+    id          VARCHAR(64)  PRIMARY KEY,
+    event_id    VARCHAR(64)  NOT NULL,
+    event_type  VARCHAR(64)  NOT NULL,
+    alert_id    VARCHAR(64)  NOT NULL,
+    channel_id  VARCHAR(64)  NOT NULL,
+    route_id    VARCHAR(64)  NOT NULL,
+    recipient   VARCHAR(128) NOT NULL DEFAULT '',
+    status      VARCHAR(32)  NOT NULL CHECK (status IN ('pending', 'sent', 'failed', 'rate_limited')),
+    attempts    INTEGER      NOT NULL DEFAULT 0,
+    last_error  TEXT         NOT NULL DEFAULT '',
+    subject     TEXT         NOT NULL DEFAULT '',
+    sent_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT notifications_event_channel_key UNIQUE (event_id, channel_id)
+);
+
+CREATE INDEX notifications_alert_idx ON notifications (alert_id);
+CREATE INDEX notifications_pending_idx ON notifications (created_at) WHERE status = 'pending';
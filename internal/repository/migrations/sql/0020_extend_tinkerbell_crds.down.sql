@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS workflow_crds_hardware_id_idx;
+DROP INDEX IF EXISTS workflow_crds_status_idx;
+ALTER TABLE workflow_crds DROP COLUMN IF EXISTS updated_at;
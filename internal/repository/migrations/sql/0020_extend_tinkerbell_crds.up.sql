@@ -0,0 +1,5 @@
+-- 工作流CRD记录最后一次同步时间，按Tinkerbell中工作流的状态更新
+ALTER TABLE workflow_crds ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX workflow_crds_status_idx ON workflow_crds (status);
+CREATE INDEX workflow_crds_hardware_id_idx ON workflow_crds (hardware_id);
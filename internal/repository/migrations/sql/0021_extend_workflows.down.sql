@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS workflows_status_idx;
+DROP INDEX IF EXISTS workflows_workflow_crd_id_idx;
+ALTER TABLE workflows DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE workflows DROP COLUMN IF EXISTS error;
+ALTER TABLE workflows DROP COLUMN IF EXISTS params;
+ALTER TABLE workflows DROP COLUMN IF EXISTS workflow_crd_id;
@@ -0,0 +1,8 @@
+-- 工作流关联Tinkerbell工作流CRD，记录渲染参数和失败原因
+ALTER TABLE workflows ADD COLUMN workflow_crd_id VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE workflows ADD COLUMN params JSONB;
+ALTER TABLE workflows ADD COLUMN error TEXT NOT NULL DEFAULT '';
+ALTER TABLE workflows ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX workflows_workflow_crd_id_idx ON workflows (workflow_crd_id);
+CREATE INDEX workflows_status_idx ON workflows (status);
@@ -0,0 +1,15 @@
+-- 工作流模板库，每个版本一行，版本创建后不可修改
+CREATE TABLE workflow_templates (
+    id           VARCHAR(64)  PRIMARY KEY,
+    name         VARCHAR(255) NOT NULL,
+    version      VARCHAR(64)  NOT NULL,
+    description  TEXT         NOT NULL DEFAULT '',
+    os_type      VARCHAR(64)  NOT NULL DEFAULT '',
+    content      TEXT         NOT NULL,
+    parameters   JSONB,
+    created_by   VARCHAR(255) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT workflow_templates_name_version_key UNIQUE (name, version)
+);
+
+CREATE INDEX workflow_templates_name_idx ON workflow_templates (name, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE workflow_templates DROP COLUMN IF EXISTS retries;
+
+UPDATE workflows SET steps = NULL WHERE jsonb_typeof(steps) = 'array';
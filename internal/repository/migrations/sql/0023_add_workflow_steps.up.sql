@@ -0,0 +1,5 @@
+-- 工作流步骤改为按动作排列的数组，旧记录中按工作流CRD状态保存的对象无法转换，清空后由下次同步重新生成
+UPDATE workflows SET steps = NULL WHERE jsonb_typeof(steps) <> 'array';
+
+-- 模板库版本的步骤重试策略
+ALTER TABLE workflow_templates ADD COLUMN retries JSONB;
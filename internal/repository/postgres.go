@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"gpu-management/internal/config"
+)
+
+// OpenPostgres 打开PostgreSQL连接并校验连通性
+func OpenPostgres(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return db, nil
+}
+
+// NewPostgresRepositories 创建基于PostgreSQL的仓储集合
+func NewPostgresRepositories(db *sql.DB) *Repositories {
+	repos := newPostgresRepositories(db)
+	repos.inTx = func(ctx context.Context, fn func(tx *Repositories) error) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		txRepos := newPostgresRepositories(tx)
+		// 事务内的嵌套调用加入当前事务
+		txRepos.inTx = func(_ context.Context, fn func(tx *Repositories) error) error {
+			return fn(txRepos)
+		}
+		if err := fn(txRepos); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	}
+	return repos
+}
+
+// newPostgresRepositories 基于任意querier（连接池或事务）创建仓储集合
+func newPostgresRepositories(q querier) *Repositories {
+	return &Repositories{
+		Servers:       &postgresServerRepository{q: q},
+		GPUs:          &postgresGPURepository{q: q},
+		ServerConfigs: &postgresServerConfigRepository{q: q},
+		GPUConfigs:    &postgresGPUConfigRepository{q: q},
+		Allocations:   &postgresAllocationRepository{q: q},
+		Tasks:         &postgresTaskRepository{q: q},
+		Firmware:      &postgresFirmwareRepository{q: q},
+		Rollouts:      &postgresFirmwareRolloutRepository{q: q},
+		DeadLetters:   &postgresDeadLetterRepository{q: q},
+		Outbox:        &postgresOutboxRepository{q: q},
+		Events:        &postgresEventRepository{q: q},
+		Alerts:        &postgresAlertRepository{q: q},
+		Silences:      &postgresSilenceRepository{q: q},
+		AlertRules:    &postgresAlertRuleRepository{q: q},
+		Channels:      &postgresNotificationChannelRepository{q: q},
+		Routes:        &postgresNotificationRouteRepository{q: q},
+		Schedules:     &postgresOnCallScheduleRepository{q: q},
+		Notifications: &postgresNotificationRepository{q: q},
+		HardwareCRDs:  &postgresHardwareCRDRepository{q: q},
+		TemplateCRDs:  &postgresTemplateCRDRepository{q: q},
+		WorkflowCRDs:  &postgresWorkflowCRDRepository{q: q},
+		Workflows:     &postgresWorkflowRepository{q: q},
+
+		WorkflowTemplates: &postgresWorkflowTemplateRepository{q: q},
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresAlertRepository 告警仓储的PostgreSQL实现
+type postgresAlertRepository struct {
+	q querier
+}
+
+func (r *postgresAlertRepository) List(ctx context.Context, filter AlertFilter) ([]models.Alert, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	equals := []struct {
+		column string
+		value  string
+	}{
+		{"source_type", filter.SourceType},
+		{"source_id", filter.SourceID},
+		{"alert_type", filter.AlertType},
+	}
+	for _, eq := range equals {
+		if eq.value != "" {
+			args = append(args, eq.value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", eq.column, len(args)))
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+	if len(filter.Severities) > 0 {
+		args = append(args, pq.Array(filter.Severities))
+		conditions = append(conditions, fmt.Sprintf("severity = ANY($%d)", len(args)))
+	}
+	if !filter.TriggeredFrom.IsZero() {
+		args = append(args, filter.TriggeredFrom)
+		conditions = append(conditions, fmt.Sprintf("triggered_at >= $%d", len(args)))
+	}
+	if !filter.TriggeredTo.IsZero() {
+		args = append(args, filter.TriggeredTo)
+		conditions = append(conditions, fmt.Sprintf("triggered_at <= $%d", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM alerts", selectColumns(&models.Alert{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.Alert](ctx, r.q, query, args...)
+}
+
+func (r *postgresAlertRepository) Get(ctx context.Context, id string) (*models.Alert, error) {
+	query := fmt.Sprintf("SELECT %s FROM alerts WHERE id = $1", selectColumns(&models.Alert{}))
+	return selectOne[models.Alert](ctx, r.q, query, id)
+}
+
+func (r *postgresAlertRepository) GetForUpdate(ctx context.Context, id string) (*models.Alert, error) {
+	query := fmt.Sprintf("SELECT %s FROM alerts WHERE id = $1 FOR UPDATE", selectColumns(&models.Alert{}))
+	return selectOne[models.Alert](ctx, r.q, query, id)
+}
+
+func (r *postgresAlertRepository) Create(ctx context.Context, alert *models.Alert) error {
+	if alert.ID == "" {
+		alert.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	alert.CreatedAt = now
+	alert.UpdatedAt = now
+	if alert.TriggeredAt.IsZero() {
+		alert.TriggeredAt = now
+	}
+
+	return insertRow(ctx, r.q, "alerts", alert)
+}
+
+func (r *postgresAlertRepository) UpdateIfStatus(ctx context.Context, alert *models.Alert, expectedStatus string) error {
+	alert.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "alerts", alert.ID, alert, expectedStatus)
+}
+
+// postgresSilenceRepository 静默规则仓储的PostgreSQL实现
+type postgresSilenceRepository struct {
+	q querier
+}
+
+func (r *postgresSilenceRepository) List(ctx context.Context, filter SilenceFilter) ([]models.Silence, error) {
+	query := fmt.Sprintf("SELECT %s FROM silences", selectColumns(&models.Silence{}))
+	args := []interface{}{}
+	if !filter.ActiveAt.IsZero() {
+		args = append(args, filter.ActiveAt)
+		query += " WHERE starts_at <= $1 AND ends_at > $1"
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.Silence](ctx, r.q, query, args...)
+}
+
+func (r *postgresSilenceRepository) Get(ctx context.Context, id string) (*models.Silence, error) {
+	query := fmt.Sprintf("SELECT %s FROM silences WHERE id = $1", selectColumns(&models.Silence{}))
+	return selectOne[models.Silence](ctx, r.q, query, id)
+}
+
+func (r *postgresSilenceRepository) Create(ctx context.Context, silence *models.Silence) error {
+	if silence.ID == "" {
+		silence.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	silence.CreatedAt = now
+	silence.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "silences", silence)
+}
+
+func (r *postgresSilenceRepository) Update(ctx context.Context, silence *models.Silence) error {
+	silence.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "silences", silence.ID, silence)
+}
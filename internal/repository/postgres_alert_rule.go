@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresAlertRuleRepository 告警规则仓储的PostgreSQL实现
+type postgresAlertRuleRepository struct {
+	q querier
+}
+
+func (r *postgresAlertRuleRepository) List(ctx context.Context, filter AlertRuleFilter) ([]models.AlertRule, error) {
+	query := fmt.Sprintf("SELECT %s FROM alert_rules", selectColumns(&models.AlertRule{}))
+	if filter.EnabledOnly {
+		query += " WHERE enabled"
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.AlertRule](ctx, r.q, query)
+}
+
+func (r *postgresAlertRuleRepository) Get(ctx context.Context, id string) (*models.AlertRule, error) {
+	query := fmt.Sprintf("SELECT %s FROM alert_rules WHERE id = $1", selectColumns(&models.AlertRule{}))
+	return selectOne[models.AlertRule](ctx, r.q, query, id)
+}
+
+func (r *postgresAlertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	if rule.ID == "" {
+		rule.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "alert_rules", rule)
+}
+
+func (r *postgresAlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	rule.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "alert_rules", rule.ID, rule)
+}
+
+func (r *postgresAlertRuleRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "alert_rules", id)
+}
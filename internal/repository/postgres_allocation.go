@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresAllocationRepository 资源分配仓储的PostgreSQL实现
+type postgresAllocationRepository struct {
+	q querier
+}
+
+func (r *postgresAllocationRepository) List(ctx context.Context, filter AllocationFilter) ([]models.Allocation, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.ServerID != "" {
+		args = append(args, filter.ServerID)
+		conditions = append(conditions, fmt.Sprintf("server_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM allocations", selectColumns(&models.Allocation{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	allocations, err := selectRows[models.Allocation](ctx, r.q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadGPUs(ctx, allocations); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+func (r *postgresAllocationRepository) Get(ctx context.Context, id string) (*models.Allocation, error) {
+	query := fmt.Sprintf("SELECT %s FROM allocations WHERE id = $1", selectColumns(&models.Allocation{}))
+	allocation, err := selectOne[models.Allocation](ctx, r.q, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := []models.Allocation{*allocation}
+	if err := r.loadGPUs(ctx, allocations); err != nil {
+		return nil, err
+	}
+	return &allocations[0], nil
+}
+
+// loadGPUs 批量填充分配关联的GPU
+func (r *postgresAllocationRepository) loadGPUs(ctx context.Context, allocations []models.Allocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(allocations))
+	index := make(map[string]int, len(allocations))
+	for i, a := range allocations {
+		ids[i] = a.ID
+		index[a.ID] = i
+		allocations[i].GPUIDs = []string{}
+	}
+
+	rows, err := r.q.QueryContext(ctx,
+		"SELECT allocation_id, gpu_id FROM allocation_gpus WHERE allocation_id = ANY($1) ORDER BY created_at, gpu_id",
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var allocationID, gpuID string
+		if err := rows.Scan(&allocationID, &gpuID); err != nil {
+			return err
+		}
+		i := index[allocationID]
+		allocations[i].GPUIDs = append(allocations[i].GPUIDs, gpuID)
+	}
+	return rows.Err()
+}
+
+func (r *postgresAllocationRepository) Create(ctx context.Context, allocation *models.Allocation) error {
+	if allocation.ID == "" {
+		allocation.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	allocation.CreatedAt = now
+	allocation.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "allocations", allocation)
+}
+
+func (r *postgresAllocationRepository) UpdateIfStatus(ctx context.Context, allocation *models.Allocation, expectedStatus string) error {
+	allocation.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "allocations", allocation.ID, allocation, expectedStatus)
+}
+
+func (r *postgresAllocationRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "allocations", id)
+}
+
+func (r *postgresAllocationRepository) AttachGPUs(ctx context.Context, allocationID string, gpuIDs []string) error {
+	if len(gpuIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.q.ExecContext(ctx,
+		"INSERT INTO allocation_gpus (allocation_id, gpu_id) SELECT $1, unnest($2::varchar[])",
+		allocationID, pq.Array(uniqueStrings(gpuIDs)))
+	return translateError(err)
+}
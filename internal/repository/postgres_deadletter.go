@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresDeadLetterRepository 死信仓储的PostgreSQL实现
+type postgresDeadLetterRepository struct {
+	q querier
+}
+
+func (r *postgresDeadLetterRepository) List(ctx context.Context, filter DeadLetterFilter) ([]models.DeadLetter, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.Topic != "" {
+		args = append(args, filter.Topic)
+		conditions = append(conditions, fmt.Sprintf("topic = $%d", len(args)))
+	}
+	if filter.Handler != "" {
+		args = append(args, filter.Handler)
+		conditions = append(conditions, fmt.Sprintf("handler = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM dead_letters", selectColumns(&models.DeadLetter{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.DeadLetter](ctx, r.q, query, args...)
+}
+
+func (r *postgresDeadLetterRepository) Get(ctx context.Context, id string) (*models.DeadLetter, error) {
+	query := fmt.Sprintf("SELECT %s FROM dead_letters WHERE id = $1", selectColumns(&models.DeadLetter{}))
+	return selectOne[models.DeadLetter](ctx, r.q, query, id)
+}
+
+func (r *postgresDeadLetterRepository) Create(ctx context.Context, letter *models.DeadLetter) error {
+	if letter.ID == "" {
+		letter.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	letter.CreatedAt = now
+	letter.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "dead_letters", letter)
+}
+
+func (r *postgresDeadLetterRepository) UpdateIfStatus(ctx context.Context, letter *models.DeadLetter, expectedStatus string) error {
+	letter.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "dead_letters", letter.ID, letter, expectedStatus)
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresEventRepository 事件存储仓储的PostgreSQL实现
+type postgresEventRepository struct {
+	q querier
+}
+
+func (r *postgresEventRepository) List(ctx context.Context, filter EventFilter) ([]models.Event, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	equals := []struct {
+		column string
+		value  string
+	}{
+		{"source_type", filter.SourceType},
+		{"source_id", filter.SourceID},
+		{"server_id", filter.ServerID},
+		{"gpu_id", filter.GPUID},
+	}
+	for _, eq := range equals {
+		if eq.value != "" {
+			args = append(args, eq.value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", eq.column, len(args)))
+		}
+	}
+	if len(filter.EventTypes) > 0 {
+		args = append(args, pq.Array(filter.EventTypes))
+		conditions = append(conditions, fmt.Sprintf("event_type = ANY($%d)", len(args)))
+	}
+	if len(filter.Severities) > 0 {
+		args = append(args, pq.Array(filter.Severities))
+		conditions = append(conditions, fmt.Sprintf("severity = ANY($%d)", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", len(args)))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		conditions = append(conditions, fmt.Sprintf("occurred_at < $%d", len(args)))
+	}
+	op, order := "<", "DESC"
+	if filter.Ascending {
+		op, order = ">", "ASC"
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.OccurredAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(occurred_at, id) %s ($%d, $%d)", op, len(args)-1, len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM events", selectColumns(&models.Event{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY occurred_at %s, id %s", order, order)
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return selectRows[models.Event](ctx, r.q, query, args...)
+}
+
+func (r *postgresEventRepository) Get(ctx context.Context, id string) (*models.Event, error) {
+	query := fmt.Sprintf("SELECT %s FROM events WHERE id = $1", selectColumns(&models.Event{}))
+	return selectOne[models.Event](ctx, r.q, query, id)
+}
+
+func (r *postgresEventRepository) Create(ctx context.Context, evt *models.Event) error {
+	if evt.ID == "" {
+		evt.ID = uuid.New()
+	}
+	evt.CreatedAt = time.Now().UTC()
+	if evt.OccurredAt.IsZero() {
+		evt.OccurredAt = evt.CreatedAt
+	}
+
+	return insertRow(ctx, r.q, "events", evt)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresFirmwareRepository 固件目录仓储的PostgreSQL实现
+type postgresFirmwareRepository struct {
+	q querier
+}
+
+func (r *postgresFirmwareRepository) List(ctx context.Context, filter FirmwareFilter) ([]models.Firmware, error) {
+	query := fmt.Sprintf("SELECT %s FROM firmware", selectColumns(&models.Firmware{}))
+	args := []interface{}{}
+	if filter.Component != "" {
+		args = append(args, filter.Component)
+		query += " WHERE component = $1"
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.Firmware](ctx, r.q, query, args...)
+}
+
+func (r *postgresFirmwareRepository) Get(ctx context.Context, id string) (*models.Firmware, error) {
+	query := fmt.Sprintf("SELECT %s FROM firmware WHERE id = $1", selectColumns(&models.Firmware{}))
+	return selectOne[models.Firmware](ctx, r.q, query, id)
+}
+
+func (r *postgresFirmwareRepository) GetVersion(ctx context.Context, component, version string) (*models.Firmware, error) {
+	query := fmt.Sprintf("SELECT %s FROM firmware WHERE component = $1 AND version = $2", selectColumns(&models.Firmware{}))
+	return selectOne[models.Firmware](ctx, r.q, query, component, version)
+}
+
+func (r *postgresFirmwareRepository) Create(ctx context.Context, firmware *models.Firmware) error {
+	if firmware.ID == "" {
+		firmware.ID = uuid.New()
+	}
+	firmware.CreatedAt = time.Now().UTC()
+
+	return insertRow(ctx, r.q, "firmware", firmware)
+}
+
+func (r *postgresFirmwareRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "firmware", id)
+}
+
+// postgresFirmwareRolloutRepository 分批升级仓储的PostgreSQL实现
+type postgresFirmwareRolloutRepository struct {
+	q querier
+}
+
+func (r *postgresFirmwareRolloutRepository) List(ctx context.Context, filter FirmwareRolloutFilter) ([]models.FirmwareRollout, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.FirmwareID != "" {
+		args = append(args, filter.FirmwareID)
+		conditions = append(conditions, fmt.Sprintf("firmware_id = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM firmware_rollouts", selectColumns(&models.FirmwareRollout{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.FirmwareRollout](ctx, r.q, query, args...)
+}
+
+func (r *postgresFirmwareRolloutRepository) Get(ctx context.Context, id string) (*models.FirmwareRollout, error) {
+	query := fmt.Sprintf("SELECT %s FROM firmware_rollouts WHERE id = $1", selectColumns(&models.FirmwareRollout{}))
+	return selectOne[models.FirmwareRollout](ctx, r.q, query, id)
+}
+
+func (r *postgresFirmwareRolloutRepository) Create(ctx context.Context, rollout *models.FirmwareRollout) error {
+	if rollout.ID == "" {
+		rollout.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	rollout.CreatedAt = now
+	rollout.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "firmware_rollouts", rollout)
+}
+
+func (r *postgresFirmwareRolloutRepository) UpdateIfStatus(ctx context.Context, rollout *models.FirmwareRollout, expectedStatus string) error {
+	rollout.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "firmware_rollouts", rollout.ID, rollout, expectedStatus)
+}
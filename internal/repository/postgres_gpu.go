@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresGPURepository GPU仓储的PostgreSQL实现
+type postgresGPURepository struct {
+	q querier
+}
+
+func (r *postgresGPURepository) List(ctx context.Context, filter GPUFilter) ([]models.GPU, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.ServerID != "" {
+		args = append(args, filter.ServerID)
+		conditions = append(conditions, fmt.Sprintf("server_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Model != "" {
+		args = append(args, filter.Model)
+		conditions = append(conditions, fmt.Sprintf("model = $%d", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM gpus", selectColumns(&models.GPU{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.GPU](ctx, r.q, query, args...)
+}
+
+func (r *postgresGPURepository) Get(ctx context.Context, id string) (*models.GPU, error) {
+	query := fmt.Sprintf("SELECT %s FROM gpus WHERE id = $1", selectColumns(&models.GPU{}))
+	return selectOne[models.GPU](ctx, r.q, query, id)
+}
+
+func (r *postgresGPURepository) Create(ctx context.Context, gpu *models.GPU) error {
+	if gpu.ID == "" {
+		gpu.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	gpu.CreatedAt = now
+	gpu.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "gpus", gpu)
+}
+
+func (r *postgresGPURepository) Update(ctx context.Context, gpu *models.GPU) error {
+	gpu.UpdatedAt = time.Now().UTC()
+	// 状态只通过TransitionStatus按当前状态条件迁移，整行更新不能覆盖并发的状态变更
+	return updateRowOmit(ctx, r.q, "gpus", gpu.ID, gpu, "status")
+}
+
+func (r *postgresGPURepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "gpus", id)
+}
+
+func (r *postgresGPURepository) TransitionStatus(ctx context.Context, ids []string, from, to string, change StatusChange) error {
+	ids = uniqueStrings(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// 先锁定仍处于from状态的候选行，只有全部命中时才更新，保证整批原子生效
+	// 更新和历史写入在同一条语句中完成，历史插入行数即实际迁移的GPU数
+	query := `WITH candidates AS (
+		SELECT id FROM gpus WHERE id = ANY($1) AND status = $2 FOR UPDATE
+	), updated AS (
+		UPDATE gpus SET status = $3, updated_at = $4
+		WHERE id IN (SELECT id FROM candidates) AND (SELECT COUNT(*) FROM candidates) = $5
+		RETURNING id
+	)
+	INSERT INTO gpu_status_history (gpu_id, from_status, to_status, actor, reason, created_at)
+	SELECT id, $2, $3, $6, $7, $4 FROM updated`
+
+	result, err := r.q.ExecContext(ctx, query, pq.Array(ids), from, to, time.Now().UTC(), len(ids), change.Actor, change.Reason)
+	if err != nil {
+		return translateError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(affected) != len(ids) {
+		return fmt.Errorf("%w: not all gpus are %s", ErrConflict, from)
+	}
+	return nil
+}
+
+func (r *postgresGPURepository) History(ctx context.Context, gpuID string) ([]models.GPUStatusTransition, error) {
+	query := fmt.Sprintf("SELECT %s FROM gpu_status_history WHERE gpu_id = $1 ORDER BY created_at, id",
+		selectColumns(&models.GPUStatusTransition{}))
+	return selectRows[models.GPUStatusTransition](ctx, r.q, query, gpuID)
+}
+
+// postgresGPUConfigRepository GPU配置仓储的PostgreSQL实现
+type postgresGPUConfigRepository struct {
+	q querier
+}
+
+func (r *postgresGPUConfigRepository) Create(ctx context.Context, config *models.GPUConfig) error {
+	if config.ID == "" {
+		config.ID = uuid.New()
+	}
+	config.CreatedAt = time.Now().UTC()
+
+	return insertRow(ctx, r.q, "gpu_configs", config)
+}
+
+func (r *postgresGPUConfigRepository) Latest(ctx context.Context, gpuID string) (*models.GPUConfig, error) {
+	query := fmt.Sprintf("SELECT %s FROM gpu_configs WHERE gpu_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1",
+		selectColumns(&models.GPUConfig{}))
+	return selectOne[models.GPUConfig](ctx, r.q, query, gpuID)
+}
+
+func (r *postgresGPUConfigRepository) ListVersions(ctx context.Context, gpuID string) ([]models.GPUConfig, error) {
+	query := fmt.Sprintf("SELECT %s FROM gpu_configs WHERE gpu_id = $1 ORDER BY created_at, id",
+		selectColumns(&models.GPUConfig{}))
+	return selectRows[models.GPUConfig](ctx, r.q, query, gpuID)
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// querier 同时被*sql.DB和*sql.Tx实现，仓储方法只依赖该接口
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// dbField 通过db标签映射的结构体字段
+type dbField struct {
+	column string
+	index  int
+	json   bool // map/slice/struct类型字段以JSON形式存储
+}
+
+var fieldCache sync.Map // reflect.Type -> []dbField
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldsOf 解析结构体的db标签
+func fieldsOf(t reflect.Type) []dbField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]dbField)
+	}
+
+	fields := make([]dbField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column := f.Tag.Get("db")
+		if column == "" || column == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		isJSON := ft.Kind() == reflect.Map || ft.Kind() == reflect.Slice ||
+			(ft.Kind() == reflect.Struct && ft != timeType)
+
+		fields = append(fields, dbField{column: column, index: i, json: isJSON})
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// columnsOf 返回结构体对应的列名列表
+func columnsOf(v interface{}) []string {
+	fields := fieldsOf(reflect.Indirect(reflect.ValueOf(v)).Type())
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	return columns
+}
+
+// valuesOf 返回结构体各列的写入值
+func valuesOf(v interface{}) []interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields := fieldsOf(rv.Type())
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		value := rv.Field(f.index).Interface()
+		if f.json {
+			value = jsonColumn{value: value}
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// scanTargetsOf 返回结构体各列的扫描目标
+func scanTargetsOf(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v).Elem()
+	fields := fieldsOf(rv.Type())
+	targets := make([]interface{}, len(fields))
+	for i, f := range fields {
+		target := rv.Field(f.index).Addr().Interface()
+		if f.json {
+			target = &jsonColumn{value: target}
+		}
+		targets[i] = target
+	}
+	return targets
+}
+
+// jsonColumn JSON列的读写适配器
+type jsonColumn struct {
+	value interface{}
+}
+
+// Value 实现driver.Valuer
+func (j jsonColumn) Value() (driver.Value, error) {
+	rv := reflect.ValueOf(j.value)
+	if !rv.IsValid() || ((rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice || rv.Kind() == reflect.Ptr) && rv.IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(j.value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner
+func (j *jsonColumn) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, j.value)
+	case string:
+		return json.Unmarshal([]byte(data), j.value)
+	default:
+		return fmt.Errorf("unsupported json column type %T", src)
+	}
+}
+
+// selectColumns 生成SELECT语句使用的列列表
+func selectColumns(v interface{}) string {
+	return strings.Join(columnsOf(v), ", ")
+}
+
+// insertRow 插入一行记录
+func insertRow(ctx context.Context, q querier, table string, v interface{}) error {
+	columns := columnsOf(v)
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	_, err := q.ExecContext(ctx, query, valuesOf(v)...)
+	return translateError(err)
+}
+
+// updateRow 按id更新一行记录，除id和created_at外的列全部覆盖
+func updateRow(ctx context.Context, q querier, table string, id string, v interface{}) error {
+	return updateRowWhere(ctx, q, table, id, v, nil, "")
+}
+
+// updateRowOmit 按id更新一行记录，omit中的列保持不变
+func updateRowOmit(ctx context.Context, q querier, table string, id string, v interface{}, omit ...string) error {
+	return updateRowWhere(ctx, q, table, id, v, omit, "")
+}
+
+// updateRowIfStatus 按id和当前状态更新一行记录，状态不符时返回ErrConflict
+func updateRowIfStatus(ctx context.Context, q querier, table string, id string, v interface{}, expectedStatus string) error {
+	err := updateRowWhere(ctx, q, table, id, v, nil, "status", expectedStatus)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	// 区分记录不存在和状态已被并发修改
+	var exists bool
+	if err := q.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)", table), id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: status is no longer %s", ErrConflict, expectedStatus)
+	}
+	return ErrNotFound
+}
+
+// updateRowWhere 按id更新一行记录，omit中的列不更新，matchColumn非空时要求该列等于matchValue
+func updateRowWhere(ctx context.Context, q querier, table string, id string, v interface{}, omit []string, matchColumn string, matchValue ...interface{}) error {
+	columns := columnsOf(v)
+	values := valuesOf(v)
+
+	sets := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns)+2)
+	for i, column := range columns {
+		if column == "id" || column == "created_at" || oneOf(column, omit...) {
+			continue
+		}
+		args = append(args, values[i])
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	args = append(args, id)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", table, strings.Join(sets, ", "), len(args))
+	if matchColumn != "" {
+		args = append(args, matchValue...)
+		query += fmt.Sprintf(" AND %s = $%d", matchColumn, len(args))
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return translateError(err)
+	}
+	return expectAffected(result)
+}
+
+// deleteRow 按id删除一行记录
+func deleteRow(ctx context.Context, q querier, table string, id string) error {
+	result, err := q.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", table), id)
+	if err != nil {
+		return translateError(err)
+	}
+	return expectAffected(result)
+}
+
+// selectRows 执行查询并将结果映射为结构体列表
+func selectRows[T any](ctx context.Context, q querier, query string, args ...interface{}) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		var item T
+		if err := rows.Scan(scanTargetsOf(&item)...); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// selectOne 执行查询并返回第一条记录，无记录时返回ErrNotFound
+func selectOne[T any](ctx context.Context, q querier, query string, args ...interface{}) (*T, error) {
+	var item T
+	err := q.QueryRowContext(ctx, query, args...).Scan(scanTargetsOf(&item)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &item, nil
+}
+
+// expectAffected 校验写操作命中了记录
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// translateError 将PostgreSQL错误码转换为仓储层错误
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		return fmt.Errorf("%w: %s", ErrConflict, pqErr.Constraint)
+	case "23503": // foreign_key_violation
+		return fmt.Errorf("%w: %s", ErrReferenceViolation, pqErr.Constraint)
+	case "23514": // check_violation
+		return fmt.Errorf("%w: %s", ErrCheckViolation, pqErr.Constraint)
+	default:
+		return err
+	}
+}
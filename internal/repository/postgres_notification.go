@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresNotificationChannelRepository 通知渠道仓储的PostgreSQL实现
+type postgresNotificationChannelRepository struct {
+	q querier
+}
+
+func (r *postgresNotificationChannelRepository) List(ctx context.Context) ([]models.NotificationChannel, error) {
+	query := fmt.Sprintf("SELECT %s FROM notification_channels ORDER BY created_at, id", selectColumns(&models.NotificationChannel{}))
+	return selectRows[models.NotificationChannel](ctx, r.q, query)
+}
+
+func (r *postgresNotificationChannelRepository) Get(ctx context.Context, id string) (*models.NotificationChannel, error) {
+	query := fmt.Sprintf("SELECT %s FROM notification_channels WHERE id = $1", selectColumns(&models.NotificationChannel{}))
+	return selectOne[models.NotificationChannel](ctx, r.q, query, id)
+}
+
+func (r *postgresNotificationChannelRepository) Create(ctx context.Context, channel *models.NotificationChannel) error {
+	if channel.ID == "" {
+		channel.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "notification_channels", channel)
+}
+
+func (r *postgresNotificationChannelRepository) Update(ctx context.Context, channel *models.NotificationChannel) error {
+	channel.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "notification_channels", channel.ID, channel)
+}
+
+func (r *postgresNotificationChannelRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "notification_channels", id)
+}
+
+// postgresNotificationRouteRepository 通知路由仓储的PostgreSQL实现
+type postgresNotificationRouteRepository struct {
+	q querier
+}
+
+func (r *postgresNotificationRouteRepository) List(ctx context.Context) ([]models.NotificationRoute, error) {
+	query := fmt.Sprintf("SELECT %s FROM notification_routes ORDER BY created_at, id", selectColumns(&models.NotificationRoute{}))
+	return selectRows[models.NotificationRoute](ctx, r.q, query)
+}
+
+func (r *postgresNotificationRouteRepository) Get(ctx context.Context, id string) (*models.NotificationRoute, error) {
+	query := fmt.Sprintf("SELECT %s FROM notification_routes WHERE id = $1", selectColumns(&models.NotificationRoute{}))
+	return selectOne[models.NotificationRoute](ctx, r.q, query, id)
+}
+
+func (r *postgresNotificationRouteRepository) Create(ctx context.Context, route *models.NotificationRoute) error {
+	if route.ID == "" {
+		route.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	route.CreatedAt = now
+	route.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "notification_routes", route)
+}
+
+func (r *postgresNotificationRouteRepository) Update(ctx context.Context, route *models.NotificationRoute) error {
+	route.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "notification_routes", route.ID, route)
+}
+
+func (r *postgresNotificationRouteRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "notification_routes", id)
+}
+
+// postgresOnCallScheduleRepository 值班表仓储的PostgreSQL实现
+type postgresOnCallScheduleRepository struct {
+	q querier
+}
+
+func (r *postgresOnCallScheduleRepository) List(ctx context.Context) ([]models.OnCallSchedule, error) {
+	query := fmt.Sprintf("SELECT %s FROM oncall_schedules ORDER BY created_at, id", selectColumns(&models.OnCallSchedule{}))
+	return selectRows[models.OnCallSchedule](ctx, r.q, query)
+}
+
+func (r *postgresOnCallScheduleRepository) Get(ctx context.Context, id string) (*models.OnCallSchedule, error) {
+	query := fmt.Sprintf("SELECT %s FROM oncall_schedules WHERE id = $1", selectColumns(&models.OnCallSchedule{}))
+	return selectOne[models.OnCallSchedule](ctx, r.q, query, id)
+}
+
+func (r *postgresOnCallScheduleRepository) Create(ctx context.Context, schedule *models.OnCallSchedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "oncall_schedules", schedule)
+}
+
+func (r *postgresOnCallScheduleRepository) Update(ctx context.Context, schedule *models.OnCallSchedule) error {
+	schedule.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "oncall_schedules", schedule.ID, schedule)
+}
+
+func (r *postgresOnCallScheduleRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "oncall_schedules", id)
+}
+
+// postgresNotificationRepository 通知记录仓储的PostgreSQL实现
+type postgresNotificationRepository struct {
+	q querier
+}
+
+func (r *postgresNotificationRepository) List(ctx context.Context, filter NotificationFilter) ([]models.Notification, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.AlertID != "" {
+		args = append(args, filter.AlertID)
+		conditions = append(conditions, fmt.Sprintf("alert_id = $%d", len(args)))
+	}
+	if filter.ChannelID != "" {
+		args = append(args, filter.ChannelID)
+		conditions = append(conditions, fmt.Sprintf("channel_id = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM notifications", selectColumns(&models.Notification{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.Notification](ctx, r.q, query, args...)
+}
+
+func (r *postgresNotificationRepository) Get(ctx context.Context, id string) (*models.Notification, error) {
+	query := fmt.Sprintf("SELECT %s FROM notifications WHERE id = $1", selectColumns(&models.Notification{}))
+	return selectOne[models.Notification](ctx, r.q, query, id)
+}
+
+func (r *postgresNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.ID == "" {
+		notification.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	notification.CreatedAt = now
+	notification.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "notifications", notification)
+}
+
+func (r *postgresNotificationRepository) UpdateIfStatus(ctx context.Context, notification *models.Notification, expectedStatus string) error {
+	notification.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "notifications", notification.ID, notification, expectedStatus)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresOutboxRepository 事务性发件箱仓储的PostgreSQL实现
+type postgresOutboxRepository struct {
+	q querier
+}
+
+func (r *postgresOutboxRepository) Create(ctx context.Context, evt *models.OutboxEvent) error {
+	if evt.ID == "" {
+		evt.ID = uuid.New()
+	}
+	evt.CreatedAt = time.Now().UTC()
+	if evt.NextAttemptAt.IsZero() {
+		evt.NextAttemptAt = evt.CreatedAt
+	}
+
+	return insertRow(ctx, r.q, "outbox", evt)
+}
+
+func (r *postgresOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	query := fmt.Sprintf(`SELECT %s FROM outbox
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY created_at, id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`, selectColumns(&models.OutboxEvent{}))
+	return selectRows[models.OutboxEvent](ctx, r.q, query, models.OutboxStatusPending, now, limit)
+}
+
+func (r *postgresOutboxRepository) Update(ctx context.Context, evt *models.OutboxEvent) error {
+	return updateRow(ctx, r.q, "outbox", evt.ID, evt)
+}
+
+func (r *postgresOutboxRepository) DeleteSent(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.q.ExecContext(ctx, "DELETE FROM outbox WHERE status = $1 AND sent_at < $2", models.OutboxStatusSent, before)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresServerRepository 服务器仓储的PostgreSQL实现
+type postgresServerRepository struct {
+	q querier
+}
+
+func (r *postgresServerRepository) List(ctx context.Context) ([]models.Server, error) {
+	query := fmt.Sprintf("SELECT %s FROM servers ORDER BY created_at, id", selectColumns(&models.Server{}))
+	return selectRows[models.Server](ctx, r.q, query)
+}
+
+func (r *postgresServerRepository) Get(ctx context.Context, id string) (*models.Server, error) {
+	query := fmt.Sprintf("SELECT %s FROM servers WHERE id = $1", selectColumns(&models.Server{}))
+	return selectOne[models.Server](ctx, r.q, query, id)
+}
+
+func (r *postgresServerRepository) Create(ctx context.Context, server *models.Server) error {
+	if server.ID == "" {
+		server.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	server.CreatedAt = now
+	server.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "servers", server)
+}
+
+func (r *postgresServerRepository) Update(ctx context.Context, server *models.Server) error {
+	server.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "servers", server.ID, server)
+}
+
+func (r *postgresServerRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "servers", id)
+}
+
+// postgresServerConfigRepository 服务器配置仓储的PostgreSQL实现
+type postgresServerConfigRepository struct {
+	q querier
+}
+
+func (r *postgresServerConfigRepository) Create(ctx context.Context, config *models.ServerConfig) error {
+	if config.ID == "" {
+		config.ID = uuid.New()
+	}
+	config.CreatedAt = time.Now().UTC()
+
+	return insertRow(ctx, r.q, "server_configs", config)
+}
+
+func (r *postgresServerConfigRepository) Latest(ctx context.Context, serverID string) (*models.ServerConfig, error) {
+	query := fmt.Sprintf("SELECT %s FROM server_configs WHERE server_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1",
+		selectColumns(&models.ServerConfig{}))
+	return selectOne[models.ServerConfig](ctx, r.q, query, serverID)
+}
+
+func (r *postgresServerConfigRepository) ListVersions(ctx context.Context, serverID string) ([]models.ServerConfig, error) {
+	query := fmt.Sprintf("SELECT %s FROM server_configs WHERE server_id = $1 ORDER BY created_at, id",
+		selectColumns(&models.ServerConfig{}))
+	return selectRows[models.ServerConfig](ctx, r.q, query, serverID)
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresTaskRepository 异步任务仓储的PostgreSQL实现
+type postgresTaskRepository struct {
+	q querier
+}
+
+func (r *postgresTaskRepository) List(ctx context.Context, filter TaskFilter) ([]models.Task, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if filter.TargetType != "" {
+		args = append(args, filter.TargetType)
+		conditions = append(conditions, fmt.Sprintf("target_type = $%d", len(args)))
+	}
+	if filter.TargetID != "" {
+		args = append(args, filter.TargetID)
+		conditions = append(conditions, fmt.Sprintf("target_id = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM tasks", selectColumns(&models.Task{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.Task](ctx, r.q, query, args...)
+}
+
+func (r *postgresTaskRepository) Get(ctx context.Context, id string) (*models.Task, error) {
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE id = $1", selectColumns(&models.Task{}))
+	return selectOne[models.Task](ctx, r.q, query, id)
+}
+
+func (r *postgresTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if task.ID == "" {
+		task.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "tasks", task)
+}
+
+func (r *postgresTaskRepository) UpdateIfStatus(ctx context.Context, task *models.Task, expectedStatus string) error {
+	task.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "tasks", task.ID, task, expectedStatus)
+}
+
+func (r *postgresTaskRepository) Heartbeat(ctx context.Context, owner string, at time.Time) (int, error) {
+	result, err := r.q.ExecContext(ctx, "UPDATE tasks SET heartbeat_at = $1 WHERE owner = $2 AND status = ANY($3)",
+		at, owner, pq.Array([]string{models.TaskStatusPending, models.TaskStatusRunning}))
+	if err != nil {
+		return 0, translateError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
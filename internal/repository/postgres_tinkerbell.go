@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresHardwareCRDRepository 硬件CRD映射仓储的PostgreSQL实现
+type postgresHardwareCRDRepository struct {
+	q querier
+}
+
+func (r *postgresHardwareCRDRepository) List(ctx context.Context) ([]models.HardwareCRD, error) {
+	query := fmt.Sprintf("SELECT %s FROM hardware_crds ORDER BY created_at, id", selectColumns(&models.HardwareCRD{}))
+	return selectRows[models.HardwareCRD](ctx, r.q, query)
+}
+
+func (r *postgresHardwareCRDRepository) Get(ctx context.Context, id string) (*models.HardwareCRD, error) {
+	query := fmt.Sprintf("SELECT %s FROM hardware_crds WHERE id = $1", selectColumns(&models.HardwareCRD{}))
+	return selectOne[models.HardwareCRD](ctx, r.q, query, id)
+}
+
+func (r *postgresHardwareCRDRepository) GetByServer(ctx context.Context, serverID string) (*models.HardwareCRD, error) {
+	query := fmt.Sprintf("SELECT %s FROM hardware_crds WHERE server_id = $1", selectColumns(&models.HardwareCRD{}))
+	return selectOne[models.HardwareCRD](ctx, r.q, query, serverID)
+}
+
+func (r *postgresHardwareCRDRepository) Create(ctx context.Context, hardware *models.HardwareCRD) error {
+	if hardware.ID == "" {
+		hardware.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	hardware.CreatedAt = now
+	hardware.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "hardware_crds", hardware)
+}
+
+func (r *postgresHardwareCRDRepository) Update(ctx context.Context, hardware *models.HardwareCRD) error {
+	hardware.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "hardware_crds", hardware.ID, hardware)
+}
+
+func (r *postgresHardwareCRDRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "hardware_crds", id)
+}
+
+// postgresTemplateCRDRepository 模板CRD映射仓储的PostgreSQL实现
+type postgresTemplateCRDRepository struct {
+	q querier
+}
+
+func (r *postgresTemplateCRDRepository) List(ctx context.Context) ([]models.TemplateCRD, error) {
+	query := fmt.Sprintf("SELECT %s FROM template_crds ORDER BY created_at, id", selectColumns(&models.TemplateCRD{}))
+	return selectRows[models.TemplateCRD](ctx, r.q, query)
+}
+
+func (r *postgresTemplateCRDRepository) Get(ctx context.Context, id string) (*models.TemplateCRD, error) {
+	query := fmt.Sprintf("SELECT %s FROM template_crds WHERE id = $1", selectColumns(&models.TemplateCRD{}))
+	return selectOne[models.TemplateCRD](ctx, r.q, query, id)
+}
+
+func (r *postgresTemplateCRDRepository) Create(ctx context.Context, template *models.TemplateCRD) error {
+	if template.ID == "" {
+		template.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "template_crds", template)
+}
+
+func (r *postgresTemplateCRDRepository) Update(ctx context.Context, template *models.TemplateCRD) error {
+	template.UpdatedAt = time.Now().UTC()
+	return updateRow(ctx, r.q, "template_crds", template.ID, template)
+}
+
+func (r *postgresTemplateCRDRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "template_crds", id)
+}
+
+// postgresWorkflowCRDRepository 工作流CRD映射仓储的PostgreSQL实现
+type postgresWorkflowCRDRepository struct {
+	q querier
+}
+
+func (r *postgresWorkflowCRDRepository) List(ctx context.Context, filter WorkflowCRDFilter) ([]models.WorkflowCRD, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.HardwareID != "" {
+		args = append(args, filter.HardwareID)
+		conditions = append(conditions, fmt.Sprintf("hardware_id = $%d", len(args)))
+	}
+	if filter.TemplateID != "" {
+		args = append(args, filter.TemplateID)
+		conditions = append(conditions, fmt.Sprintf("template_id = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM workflow_crds", selectColumns(&models.WorkflowCRD{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.WorkflowCRD](ctx, r.q, query, args...)
+}
+
+func (r *postgresWorkflowCRDRepository) Get(ctx context.Context, id string) (*models.WorkflowCRD, error) {
+	query := fmt.Sprintf("SELECT %s FROM workflow_crds WHERE id = $1", selectColumns(&models.WorkflowCRD{}))
+	return selectOne[models.WorkflowCRD](ctx, r.q, query, id)
+}
+
+func (r *postgresWorkflowCRDRepository) Create(ctx context.Context, workflow *models.WorkflowCRD) error {
+	if workflow.ID == "" {
+		workflow.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "workflow_crds", workflow)
+}
+
+func (r *postgresWorkflowCRDRepository) UpdateIfStatus(ctx context.Context, workflow *models.WorkflowCRD, expectedStatus string) error {
+	workflow.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "workflow_crds", workflow.ID, workflow, expectedStatus)
+}
+
+func (r *postgresWorkflowCRDRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "workflow_crds", id)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"gpu-management/internal/models"
+	"gpu-management/pkg/uuid"
+)
+
+// postgresWorkflowRepository 分配工作流仓储的PostgreSQL实现
+type postgresWorkflowRepository struct {
+	q querier
+}
+
+func (r *postgresWorkflowRepository) List(ctx context.Context, filter WorkflowFilter) ([]models.Workflow, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.AllocationID != "" {
+		args = append(args, filter.AllocationID)
+		conditions = append(conditions, fmt.Sprintf("allocation_id = $%d", len(args)))
+	}
+	if filter.WorkflowCRDID != "" {
+		args = append(args, filter.WorkflowCRDID)
+		conditions = append(conditions, fmt.Sprintf("workflow_crd_id = $%d", len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM workflows", selectColumns(&models.Workflow{}))
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	return selectRows[models.Workflow](ctx, r.q, query, args...)
+}
+
+func (r *postgresWorkflowRepository) Get(ctx context.Context, id string) (*models.Workflow, error) {
+	query := fmt.Sprintf("SELECT %s FROM workflows WHERE id = $1", selectColumns(&models.Workflow{}))
+	return selectOne[models.Workflow](ctx, r.q, query, id)
+}
+
+func (r *postgresWorkflowRepository) Create(ctx context.Context, workflow *models.Workflow) error {
+	if workflow.ID == "" {
+		workflow.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+
+	return insertRow(ctx, r.q, "workflows", workflow)
+}
+
+func (r *postgresWorkflowRepository) UpdateIfStatus(ctx context.Context, workflow *models.Workflow, expectedStatus string) error {
+	workflow.UpdatedAt = time.Now().UTC()
+	return updateRowIfStatus(ctx, r.q, "workflows", workflow.ID, workflow, expectedStatus)
+}
+
+func (r *postgresWorkflowRepository) Delete(ctx context.Context, id string) error {
+	return deleteRow(ctx, r.q, "workflows", id)
+}
+
+// postgresWorkflowTemplateRepository 工作流模板库仓储的PostgreSQL实现
+type postgresWorkflowTemplateRepository struct {
+	q querier
+}
+
+func (r *postgresWorkflowTemplateRepository) List(ctx context.Context) ([]models.WorkflowTemplate, error) {
+	query := fmt.Sprintf("SELECT %s FROM workflow_templates ORDER BY created_at, id",
+		selectColumns(&models.WorkflowTemplate{}))
+	return selectRows[models.WorkflowTemplate](ctx, r.q, query)
+}
+
+func (r *postgresWorkflowTemplateRepository) ListVersions(ctx context.Context, name string) ([]models.WorkflowTemplate, error) {
+	query := fmt.Sprintf("SELECT %s FROM workflow_templates WHERE name = $1 ORDER BY created_at, id",
+		selectColumns(&models.WorkflowTemplate{}))
+	return selectRows[models.WorkflowTemplate](ctx, r.q, query, name)
+}
+
+func (r *postgresWorkflowTemplateRepository) Latest(ctx context.Context, name string) (*models.WorkflowTemplate, error) {
+	query := fmt.Sprintf("SELECT %s FROM workflow_templates WHERE name = $1 ORDER BY created_at DESC, id DESC LIMIT 1",
+		selectColumns(&models.WorkflowTemplate{}))
+	return selectOne[models.WorkflowTemplate](ctx, r.q, query, name)
+}
+
+func (r *postgresWorkflowTemplateRepository) Get(ctx context.Context, name, version string) (*models.WorkflowTemplate, error) {
+	query := fmt.Sprintf("SELECT %s FROM workflow_templates WHERE name = $1 AND version = $2",
+		selectColumns(&models.WorkflowTemplate{}))
+	return selectOne[models.WorkflowTemplate](ctx, r.q, query, name, version)
+}
+
+func (r *postgresWorkflowTemplateRepository) Create(ctx context.Context, template *models.WorkflowTemplate) error {
+	if template.ID == "" {
+		template.ID = uuid.New()
+	}
+	template.CreatedAt = time.Now().UTC()
+
+	return insertRow(ctx, r.q, "workflow_templates", template)
+}
@@ -0,0 +1,416 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// 仓储层通用错误
+var (
+	// ErrNotFound 记录不存在
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict 记录冲突（主键或唯一约束重复）
+	ErrConflict = errors.New("record already exists")
+	// ErrReferenceViolation 外键约束不满足（引用的记录不存在或仍被引用）
+	ErrReferenceViolation = errors.New("foreign key constraint violated")
+	// ErrCheckViolation 字段取值不满足检查约束（状态枚举、数值范围、时间先后等）
+	ErrCheckViolation = errors.New("check constraint violated")
+)
+
+// ServerRepository 服务器仓储接口
+type ServerRepository interface {
+	List(ctx context.Context) ([]models.Server, error)
+	Get(ctx context.Context, id string) (*models.Server, error)
+	Create(ctx context.Context, server *models.Server) error
+	Update(ctx context.Context, server *models.Server) error
+	Delete(ctx context.Context, id string) error
+}
+
+// HardwareCRDRepository Tinkerbell硬件CRD映射仓储接口
+type HardwareCRDRepository interface {
+	List(ctx context.Context) ([]models.HardwareCRD, error)
+	Get(ctx context.Context, id string) (*models.HardwareCRD, error)
+	// GetByServer 按服务器查询，服务器与硬件CRD一一对应
+	GetByServer(ctx context.Context, serverID string) (*models.HardwareCRD, error)
+	Create(ctx context.Context, hardware *models.HardwareCRD) error
+	Update(ctx context.Context, hardware *models.HardwareCRD) error
+	Delete(ctx context.Context, id string) error
+}
+
+// TemplateCRDRepository Tinkerbell模板CRD映射仓储接口
+type TemplateCRDRepository interface {
+	List(ctx context.Context) ([]models.TemplateCRD, error)
+	Get(ctx context.Context, id string) (*models.TemplateCRD, error)
+	// Create 名称和版本重复时返回ErrConflict
+	Create(ctx context.Context, template *models.TemplateCRD) error
+	Update(ctx context.Context, template *models.TemplateCRD) error
+	// Delete 仍被工作流引用时返回ErrReferenceViolation
+	Delete(ctx context.Context, id string) error
+}
+
+// WorkflowCRDFilter 工作流CRD查询条件，零值字段表示不过滤
+type WorkflowCRDFilter struct {
+	HardwareID string
+	TemplateID string
+	Statuses   []string
+}
+
+// WorkflowCRDRepository Tinkerbell工作流CRD映射仓储接口
+type WorkflowCRDRepository interface {
+	List(ctx context.Context, filter WorkflowCRDFilter) ([]models.WorkflowCRD, error)
+	Get(ctx context.Context, id string) (*models.WorkflowCRD, error)
+	Create(ctx context.Context, workflow *models.WorkflowCRD) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, workflow *models.WorkflowCRD, expectedStatus string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// WorkflowFilter 分配工作流查询条件，零值字段表示不过滤
+type WorkflowFilter struct {
+	AllocationID  string
+	WorkflowCRDID string
+	Type          string
+	Statuses      []string
+}
+
+// WorkflowRepository 分配工作流仓储接口
+type WorkflowRepository interface {
+	List(ctx context.Context, filter WorkflowFilter) ([]models.Workflow, error)
+	Get(ctx context.Context, id string) (*models.Workflow, error)
+	// Create 引用的分配不存在时返回ErrReferenceViolation
+	Create(ctx context.Context, workflow *models.Workflow) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, workflow *models.Workflow, expectedStatus string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// WorkflowTemplateRepository 工作流模板库仓储接口
+// 模板按版本追加保存，不做原地修改
+type WorkflowTemplateRepository interface {
+	// List 返回所有模板的所有版本，按创建时间排序
+	List(ctx context.Context) ([]models.WorkflowTemplate, error)
+	ListVersions(ctx context.Context, name string) ([]models.WorkflowTemplate, error)
+	Latest(ctx context.Context, name string) (*models.WorkflowTemplate, error)
+	Get(ctx context.Context, name, version string) (*models.WorkflowTemplate, error)
+	// Create 名称和版本重复时返回ErrConflict
+	Create(ctx context.Context, template *models.WorkflowTemplate) error
+}
+
+// GPUFilter GPU查询条件，零值字段表示不过滤
+type GPUFilter struct {
+	ServerID string
+	Status   string
+	Model    string
+}
+
+// StatusChange 状态迁移的操作者和原因，随迁移一起写入历史
+type StatusChange struct {
+	Actor  string
+	Reason string
+}
+
+// GPURepository GPU仓储接口
+type GPURepository interface {
+	List(ctx context.Context, filter GPUFilter) ([]models.GPU, error)
+	Get(ctx context.Context, id string) (*models.GPU, error)
+	Create(ctx context.Context, gpu *models.GPU) error
+	// Update 更新GPU属性，不修改状态，状态只能通过TransitionStatus变更
+	Update(ctx context.Context, gpu *models.GPU) error
+	Delete(ctx context.Context, id string) error
+	// TransitionStatus 原子地将一组GPU从from状态改为to状态，并为每张GPU记录一条迁移历史
+	// 任一GPU当前状态不是from时整体不生效并返回ErrConflict，状态机校验由调用方负责
+	TransitionStatus(ctx context.Context, ids []string, from, to string, change StatusChange) error
+	// History 按时间顺序返回GPU的状态迁移历史
+	History(ctx context.Context, gpuID string) ([]models.GPUStatusTransition, error)
+}
+
+// ServerConfigRepository 服务器配置仓储接口
+// 配置按版本追加保存，不做原地修改
+type ServerConfigRepository interface {
+	Create(ctx context.Context, config *models.ServerConfig) error
+	Latest(ctx context.Context, serverID string) (*models.ServerConfig, error)
+	ListVersions(ctx context.Context, serverID string) ([]models.ServerConfig, error)
+}
+
+// GPUConfigRepository GPU配置仓储接口
+// 配置按版本追加保存，不做原地修改
+type GPUConfigRepository interface {
+	Create(ctx context.Context, config *models.GPUConfig) error
+	Latest(ctx context.Context, gpuID string) (*models.GPUConfig, error)
+	ListVersions(ctx context.Context, gpuID string) ([]models.GPUConfig, error)
+}
+
+// AllocationFilter 分配查询条件，零值字段表示不过滤
+type AllocationFilter struct {
+	UserID   string
+	ServerID string
+	Status   string
+}
+
+// AllocationRepository 资源分配仓储接口
+type AllocationRepository interface {
+	List(ctx context.Context, filter AllocationFilter) ([]models.Allocation, error)
+	Get(ctx context.Context, id string) (*models.Allocation, error)
+	Create(ctx context.Context, allocation *models.Allocation) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, allocation *models.Allocation, expectedStatus string) error
+	Delete(ctx context.Context, id string) error
+	// AttachGPUs 记录分配使用的GPU
+	AttachGPUs(ctx context.Context, allocationID string, gpuIDs []string) error
+}
+
+// TaskFilter 任务查询条件，零值字段表示不过滤
+type TaskFilter struct {
+	Type       string
+	TargetType string
+	TargetID   string
+	// Statuses 匹配任一状态
+	Statuses []string
+}
+
+// TaskRepository 异步任务仓储接口
+type TaskRepository interface {
+	List(ctx context.Context, filter TaskFilter) ([]models.Task, error)
+	Get(ctx context.Context, id string) (*models.Task, error)
+	Create(ctx context.Context, task *models.Task) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, task *models.Task, expectedStatus string) error
+	// Heartbeat 将owner名下未结束任务的心跳时间更新为at，返回续约的任务数
+	Heartbeat(ctx context.Context, owner string, at time.Time) (int, error)
+}
+
+// FirmwareFilter 固件目录查询条件，零值字段表示不过滤
+type FirmwareFilter struct {
+	Component string
+}
+
+// FirmwareRepository 固件目录仓储接口，(component, version)唯一
+type FirmwareRepository interface {
+	List(ctx context.Context, filter FirmwareFilter) ([]models.Firmware, error)
+	Get(ctx context.Context, id string) (*models.Firmware, error)
+	// GetVersion 按组件和版本查找固件，回滚时用于定位升级前的版本
+	GetVersion(ctx context.Context, component, version string) (*models.Firmware, error)
+	Create(ctx context.Context, firmware *models.Firmware) error
+	// Delete 删除固件，仍被分批升级引用时返回ErrReferenceViolation
+	Delete(ctx context.Context, id string) error
+}
+
+// FirmwareRolloutFilter 分批升级查询条件，零值字段表示不过滤
+type FirmwareRolloutFilter struct {
+	FirmwareID string
+	// Statuses 匹配任一状态
+	Statuses []string
+}
+
+// FirmwareRolloutRepository 分批升级仓储接口
+type FirmwareRolloutRepository interface {
+	List(ctx context.Context, filter FirmwareRolloutFilter) ([]models.FirmwareRollout, error)
+	Get(ctx context.Context, id string) (*models.FirmwareRollout, error)
+	Create(ctx context.Context, rollout *models.FirmwareRollout) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, rollout *models.FirmwareRollout, expectedStatus string) error
+}
+
+// DeadLetterFilter 死信查询条件，零值字段表示不过滤
+type DeadLetterFilter struct {
+	Topic   string
+	Handler string
+	// Statuses 匹配任一状态
+	Statuses []string
+}
+
+// DeadLetterRepository 死信仓储接口
+type DeadLetterRepository interface {
+	List(ctx context.Context, filter DeadLetterFilter) ([]models.DeadLetter, error)
+	Get(ctx context.Context, id string) (*models.DeadLetter, error)
+	Create(ctx context.Context, letter *models.DeadLetter) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, letter *models.DeadLetter, expectedStatus string) error
+}
+
+// OutboxRepository 事务性发件箱仓储接口
+type OutboxRepository interface {
+	// Create 写入待发布事件，需要与业务数据在同一事务中调用
+	Create(ctx context.Context, evt *models.OutboxEvent) error
+	// ListDue 按写入顺序返回到期待发布的事件
+	// 在InTx中调用时PostgreSQL实现锁定返回的行（SKIP LOCKED），多个实例不会同时发布同一事件
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	Update(ctx context.Context, evt *models.OutboxEvent) error
+	// DeleteSent 删除before之前已发布的事件，返回删除的行数
+	DeleteSent(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventCursor 事件分页游标，指向上一页的最后一条事件
+type EventCursor struct {
+	OccurredAt time.Time
+	ID         string
+}
+
+// EventFilter 事件查询条件，零值字段表示不过滤
+type EventFilter struct {
+	SourceType string
+	SourceID   string
+	ServerID   string
+	GPUID      string
+	// EventTypes 匹配任一事件类型
+	EventTypes []string
+	// Severities 匹配任一严重程度
+	Severities []string
+	// Since和Until 发生时间范围，包含Since，不包含Until
+	Since time.Time
+	Until time.Time
+	// After 不为空时只返回排在游标之后的事件
+	After *EventCursor
+	// Ascending 为true时按发生时间正序返回，After随之表示发生在游标之后的更新事件
+	Ascending bool
+	// Limit 最多返回的条数，0表示不限制
+	Limit int
+}
+
+// EventRepository 事件存储仓储接口，事件只追加不修改
+type EventRepository interface {
+	// List 按发生时间倒序返回事件，时间相同时按ID倒序，filter.Ascending为true时按正序返回
+	List(ctx context.Context, filter EventFilter) ([]models.Event, error)
+	Get(ctx context.Context, id string) (*models.Event, error)
+	// Create 追加事件，ID已存在时返回ErrConflict
+	Create(ctx context.Context, evt *models.Event) error
+}
+
+// AlertFilter 告警查询条件，零值字段表示不过滤
+type AlertFilter struct {
+	SourceType string
+	SourceID   string
+	AlertType  string
+	// Statuses 匹配任一状态
+	Statuses []string
+	// Severities 匹配任一严重程度
+	Severities []string
+	// TriggeredFrom和TriggeredTo 触发时间范围（闭区间）
+	TriggeredFrom time.Time
+	TriggeredTo   time.Time
+}
+
+// AlertRepository 告警仓储接口
+type AlertRepository interface {
+	List(ctx context.Context, filter AlertFilter) ([]models.Alert, error)
+	Get(ctx context.Context, id string) (*models.Alert, error)
+	// GetForUpdate 查询告警并锁定记录直到事务结束，在InTx之外调用时等同于Get
+	GetForUpdate(ctx context.Context, id string) (*models.Alert, error)
+	Create(ctx context.Context, alert *models.Alert) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, alert *models.Alert, expectedStatus string) error
+}
+
+// SilenceFilter 静默规则查询条件，零值字段表示不过滤
+type SilenceFilter struct {
+	// ActiveAt 只返回在该时刻生效的静默规则
+	ActiveAt time.Time
+}
+
+// SilenceRepository 静默规则仓储接口
+type SilenceRepository interface {
+	List(ctx context.Context, filter SilenceFilter) ([]models.Silence, error)
+	Get(ctx context.Context, id string) (*models.Silence, error)
+	Create(ctx context.Context, silence *models.Silence) error
+	Update(ctx context.Context, silence *models.Silence) error
+}
+
+// AlertRuleFilter 告警规则查询条件，零值字段表示不过滤
+type AlertRuleFilter struct {
+	// EnabledOnly 只返回启用的规则
+	EnabledOnly bool
+}
+
+// AlertRuleRepository 告警规则仓储接口，规则名称唯一，重名时返回ErrConflict
+type AlertRuleRepository interface {
+	List(ctx context.Context, filter AlertRuleFilter) ([]models.AlertRule, error)
+	Get(ctx context.Context, id string) (*models.AlertRule, error)
+	Create(ctx context.Context, rule *models.AlertRule) error
+	Update(ctx context.Context, rule *models.AlertRule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// NotificationChannelRepository 通知渠道仓储接口，名称唯一，重名时返回ErrConflict
+type NotificationChannelRepository interface {
+	List(ctx context.Context) ([]models.NotificationChannel, error)
+	Get(ctx context.Context, id string) (*models.NotificationChannel, error)
+	Create(ctx context.Context, channel *models.NotificationChannel) error
+	Update(ctx context.Context, channel *models.NotificationChannel) error
+	Delete(ctx context.Context, id string) error
+}
+
+// NotificationRouteRepository 通知路由仓储接口，名称唯一，重名时返回ErrConflict
+type NotificationRouteRepository interface {
+	List(ctx context.Context) ([]models.NotificationRoute, error)
+	Get(ctx context.Context, id string) (*models.NotificationRoute, error)
+	Create(ctx context.Context, route *models.NotificationRoute) error
+	Update(ctx context.Context, route *models.NotificationRoute) error
+	Delete(ctx context.Context, id string) error
+}
+
+// OnCallScheduleRepository 值班表仓储接口，名称唯一，重名时返回ErrConflict
+type OnCallScheduleRepository interface {
+	List(ctx context.Context) ([]models.OnCallSchedule, error)
+	Get(ctx context.Context, id string) (*models.OnCallSchedule, error)
+	Create(ctx context.Context, schedule *models.OnCallSchedule) error
+	Update(ctx context.Context, schedule *models.OnCallSchedule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// NotificationFilter 通知记录查询条件，零值字段表示不过滤
+type NotificationFilter struct {
+	AlertID   string
+	ChannelID string
+	// Statuses 匹配任一状态
+	Statuses []string
+}
+
+// NotificationRepository 通知记录仓储接口
+type NotificationRepository interface {
+	List(ctx context.Context, filter NotificationFilter) ([]models.Notification, error)
+	Get(ctx context.Context, id string) (*models.Notification, error)
+	// Create 写入通知记录，同一事件对同一渠道已有记录时返回ErrConflict
+	Create(ctx context.Context, notification *models.Notification) error
+	// UpdateIfStatus 仅当记录当前状态为expectedStatus时更新，否则返回ErrConflict
+	UpdateIfStatus(ctx context.Context, notification *models.Notification, expectedStatus string) error
+}
+
+// Repositories 仓储集合，供处理器和服务层使用
+type Repositories struct {
+	Servers       ServerRepository
+	GPUs          GPURepository
+	ServerConfigs ServerConfigRepository
+	GPUConfigs    GPUConfigRepository
+	Allocations   AllocationRepository
+	Tasks         TaskRepository
+	Firmware      FirmwareRepository
+	Rollouts      FirmwareRolloutRepository
+	DeadLetters   DeadLetterRepository
+	Outbox        OutboxRepository
+	Events        EventRepository
+	Alerts        AlertRepository
+	Silences      SilenceRepository
+	AlertRules    AlertRuleRepository
+	Channels      NotificationChannelRepository
+	Routes        NotificationRouteRepository
+	Schedules     OnCallScheduleRepository
+	Notifications NotificationRepository
+	HardwareCRDs  HardwareCRDRepository
+	TemplateCRDs  TemplateCRDRepository
+	WorkflowCRDs  WorkflowCRDRepository
+	Workflows     WorkflowRepository
+	// WorkflowTemplates 工作流模板库，渲染后的模板保存在TemplateCRDs中同步到Tinkerbell
+	WorkflowTemplates WorkflowTemplateRepository
+
+	// inTx 在事务中执行fn，由具体实现设置
+	inTx func(ctx context.Context, fn func(tx *Repositories) error) error
+}
+
+// InTx 在一个事务中执行fn，fn返回错误时回滚，否则提交
+// fn只能通过tx访问仓储；在事务内再次调用tx.InTx会加入当前事务
+func (r *Repositories) InTx(ctx context.Context, fn func(tx *Repositories) error) error {
+	return r.inTx(ctx, fn)
+}
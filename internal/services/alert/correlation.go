@@ -0,0 +1,372 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// 告警所在的拓扑范围，从大到小
+const (
+	ScopePDU    = "pdu"
+	ScopeRack   = "rack"
+	ScopeServer = "server"
+	ScopeGPU    = "gpu"
+	// ScopeNone 无法确定拓扑位置的告警，只与同一来源的告警关联
+	ScopeNone = "none"
+)
+
+// 关联告警与被分析告警的拓扑关系，从近到远
+const (
+	RelationSelf       = "self"
+	RelationSameSource = "same_source"
+	RelationSameGPU    = "same_gpu"
+	RelationSameServer = "same_server"
+	RelationSameRack   = "same_rack"
+	RelationSamePDU    = "same_pdu"
+)
+
+// scopeLevels 拓扑范围的层级，数值越小范围越大
+var scopeLevels = map[string]int{
+	ScopePDU:    0,
+	ScopeRack:   1,
+	ScopeServer: 2,
+	ScopeGPU:    3,
+}
+
+// Correlation 告警关联分析结果
+type Correlation struct {
+	AlertID string `json:"alert_id"`
+	// Window 关联的时间窗口，被分析告警触发时间前后各Window
+	Window string `json:"window"`
+	// Root 疑似根因告警
+	Root *models.Alert `json:"root"`
+	// Group 时间窗口内拓扑相关的告警，包括被分析告警本身，按创建时间排序
+	Group []CorrelatedAlert `json:"group"`
+	// Suppressed 被根因告警覆盖的子告警ID
+	Suppressed []string `json:"suppressed"`
+	// Reasoning 推理过程
+	Reasoning []string `json:"reasoning"`
+}
+
+// CorrelatedAlert 关联组中的一条告警
+type CorrelatedAlert struct {
+	Alert models.Alert `json:"alert"`
+	// Scope 告警所在的拓扑范围
+	Scope string `json:"scope"`
+	// Relation 与被分析告警的拓扑关系
+	Relation string `json:"relation"`
+	// Suppressed 是否被根因告警覆盖
+	Suppressed bool `json:"suppressed"`
+}
+
+// topology 告警在拓扑中的位置，未知的层级为空
+type topology struct {
+	scope    string
+	pdu      string
+	rack     string
+	serverID string
+	gpuID    string
+}
+
+// relationTo 返回t与anchor最近的拓扑关系，没有关系时返回空
+func (t topology) relationTo(anchor topology) string {
+	switch {
+	case t.gpuID != "" && t.gpuID == anchor.gpuID:
+		return RelationSameGPU
+	case t.serverID != "" && t.serverID == anchor.serverID:
+		return RelationSameServer
+	case t.rack != "" && t.rack == anchor.rack:
+		return RelationSameRack
+	case t.pdu != "" && t.pdu == anchor.pdu:
+		return RelationSamePDU
+	default:
+		return ""
+	}
+}
+
+// covers 判断t的范围是否大于child且包含child
+func (t topology) covers(child topology) bool {
+	level, ok := scopeLevels[t.scope]
+	childLevel, childOK := scopeLevels[child.scope]
+	if !ok || !childOK || level >= childLevel {
+		return false
+	}
+	switch t.scope {
+	case ScopePDU:
+		return t.pdu != "" && t.pdu == child.pdu
+	case ScopeRack:
+		return t.rack != "" && t.rack == child.rack
+	case ScopeServer:
+		return t.serverID != "" && t.serverID == child.serverID
+	default:
+		return false
+	}
+}
+
+// describe 返回拓扑位置的可读描述
+func (t topology) describe() string {
+	switch t.scope {
+	case ScopePDU:
+		return "PDU " + t.pdu
+	case ScopeRack:
+		return "rack " + t.rack
+	case ScopeServer:
+		return "server " + t.serverID
+	case ScopeGPU:
+		return "GPU " + t.gpuID
+	default:
+		return "unknown location"
+	}
+}
+
+// topologyResolver 解析告警的拓扑位置，缓存一次分析中查询过的服务器和GPU
+type topologyResolver struct {
+	repos   *repository.Repositories
+	servers map[string]*models.Server
+	gpus    map[string]*models.GPU
+}
+
+// server 查询服务器，不存在时返回nil
+func (r *topologyResolver) server(ctx context.Context, id string) (*models.Server, error) {
+	if server, ok := r.servers[id]; ok {
+		return server, nil
+	}
+	server, err := r.repos.Servers.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		server, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.servers[id] = server
+	return server, nil
+}
+
+// gpu 查询GPU，不存在时返回nil
+func (r *topologyResolver) gpu(ctx context.Context, id string) (*models.GPU, error) {
+	if gpu, ok := r.gpus[id]; ok {
+		return gpu, nil
+	}
+	gpu, err := r.repos.GPUs.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		gpu, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.gpus[id] = gpu
+	return gpu, nil
+}
+
+// resolve 按告警来源确定拓扑位置，GPU和服务器已删除时退回告警的server_id标签
+func (r *topologyResolver) resolve(ctx context.Context, alert *models.Alert) (topology, error) {
+	t := topology{scope: ScopeNone}
+	serverID := alert.Labels["server_id"]
+
+	// 来源类型与拓扑范围同名
+	switch alert.SourceType {
+	case ScopePDU:
+		return topology{scope: ScopePDU, pdu: alert.SourceID}, nil
+	case ScopeRack:
+		return topology{scope: ScopeRack, rack: alert.SourceID}, nil
+	case ScopeServer:
+		t.scope = ScopeServer
+		serverID = alert.SourceID
+	case ScopeGPU:
+		t.scope = ScopeGPU
+		t.gpuID = alert.SourceID
+		gpu, err := r.gpu(ctx, alert.SourceID)
+		if err != nil {
+			return t, err
+		}
+		if gpu != nil {
+			serverID = gpu.ServerID
+		}
+	}
+
+	if serverID == "" {
+		return t, nil
+	}
+	t.serverID = serverID
+	server, err := r.server(ctx, serverID)
+	if err != nil || server == nil {
+		return t, err
+	}
+	t.rack = server.Rack
+	t.pdu = server.PDU
+	return t, nil
+}
+
+// Correlate 关联分析告警：取触发时间前后window内的告警，保留与其在同一GPU、服务器、机柜或PDU上的告警，
+// 在其中找出范围最大且覆盖最多其他告警的告警作为疑似根因，被根因覆盖的告警视为子告警
+// window不大于0时使用配置的CorrelationWindow
+func (s *Service) Correlate(ctx context.Context, id string, window time.Duration) (*Correlation, error) {
+	if window <= 0 {
+		window = s.config.CorrelationWindow
+	}
+
+	anchor, err := s.repos.Alerts.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.repos.Alerts.List(ctx, repository.AlertFilter{
+		TriggeredFrom: anchor.TriggeredAt.Add(-window),
+		TriggeredTo:   anchor.TriggeredAt.Add(window),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &topologyResolver{repos: s.repos, servers: map[string]*models.Server{}, gpus: map[string]*models.GPU{}}
+	anchorTopology, err := resolver.resolve(ctx, anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Correlation{AlertID: anchor.ID, Window: window.String(), Suppressed: []string{}}
+	result.Reasoning = append(result.Reasoning, fmt.Sprintf(
+		"alert %s (%s on %s) triggered at %s; considering %d alerts triggered within ±%s",
+		anchor.ID, anchor.AlertType, anchorTopology.describe(), anchor.TriggeredAt.Format(time.RFC3339), len(candidates), window))
+
+	// 关联组：被分析告警加上拓扑相关的告警
+	topologies := []topology{}
+	relations := map[string]int{}
+	for i := range candidates {
+		candidate := &candidates[i]
+		relation := RelationSelf
+		var t topology
+		if candidate.ID == anchor.ID {
+			t = anchorTopology
+		} else {
+			if t, err = resolver.resolve(ctx, candidate); err != nil {
+				return nil, err
+			}
+			relation = t.relationTo(anchorTopology)
+			if relation == "" && candidate.SourceType == anchor.SourceType && candidate.SourceID == anchor.SourceID {
+				relation = RelationSameSource
+			}
+			if relation == "" {
+				continue
+			}
+			relations[relation]++
+		}
+		result.Group = append(result.Group, CorrelatedAlert{Alert: *candidate, Scope: t.scope, Relation: relation})
+		topologies = append(topologies, t)
+	}
+	result.Reasoning = append(result.Reasoning, describeRelations(relations, anchorTopology))
+
+	root := findRoot(result.Group, topologies)
+	rootAlert := result.Group[root].Alert
+	result.Root = &rootAlert
+
+	for i := range result.Group {
+		if i != root && topologies[root].covers(topologies[i]) {
+			result.Group[i].Suppressed = true
+			result.Suppressed = append(result.Suppressed, result.Group[i].Alert.ID)
+		}
+	}
+
+	if len(result.Suppressed) > 0 {
+		result.Reasoning = append(result.Reasoning, fmt.Sprintf(
+			"alert %s (%s on %s) has the widest scope and covers %d other alerts in the group",
+			rootAlert.ID, rootAlert.AlertType, topologies[root].describe(), len(result.Suppressed)))
+		if first := firstChild(result.Group); !rootAlert.TriggeredAt.After(first.TriggeredAt) {
+			result.Reasoning = append(result.Reasoning, fmt.Sprintf(
+				"it triggered %s before the first child alert", first.TriggeredAt.Sub(rootAlert.TriggeredAt)))
+		} else {
+			result.Reasoning = append(result.Reasoning, fmt.Sprintf(
+				"it triggered %s after the first child alert, but its scope still contains every child",
+				rootAlert.TriggeredAt.Sub(first.TriggeredAt)))
+		}
+		result.Reasoning = append(result.Reasoning, fmt.Sprintf(
+			"suspected root cause: %s; %d child alerts suppressed", rootAlert.ID, len(result.Suppressed)))
+	} else {
+		result.Reasoning = append(result.Reasoning, fmt.Sprintf(
+			"no alert in the group covers another at a wider scope; the earliest alert %s (%s on %s) is the suspected root cause and nothing is suppressed",
+			rootAlert.ID, rootAlert.AlertType, topologies[root].describe()))
+	}
+	return result, nil
+}
+
+// findRoot 返回疑似根因在组中的下标：优先覆盖其他告警最多的，其次范围最大的，再次触发最早的
+func findRoot(group []CorrelatedAlert, topologies []topology) int {
+	covered := make([]int, len(group))
+	for i := range group {
+		for j := range group {
+			if i != j && topologies[i].covers(topologies[j]) {
+				covered[i]++
+			}
+		}
+	}
+
+	order := make([]int, len(group))
+	for i := range order {
+		order[i] = i
+	}
+	level := func(i int) int {
+		if l, ok := scopeLevels[topologies[i].scope]; ok {
+			return l
+		}
+		return len(scopeLevels)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if covered[i] != covered[j] {
+			return covered[i] > covered[j]
+		}
+		if covered[i] > 0 && level(i) != level(j) {
+			return level(i) < level(j)
+		}
+		if !group[i].Alert.TriggeredAt.Equal(group[j].Alert.TriggeredAt) {
+			return group[i].Alert.TriggeredAt.Before(group[j].Alert.TriggeredAt)
+		}
+		return group[i].Alert.ID < group[j].Alert.ID
+	})
+	return order[0]
+}
+
+// firstChild 返回组中最早触发的子告警
+func firstChild(group []CorrelatedAlert) *models.Alert {
+	var first *models.Alert
+	for i := range group {
+		if !group[i].Suppressed {
+			continue
+		}
+		if first == nil || group[i].Alert.TriggeredAt.Before(first.TriggeredAt) {
+			first = &group[i].Alert
+		}
+	}
+	return first
+}
+
+// describeRelations 描述关联组中各拓扑关系的告警数
+func describeRelations(relations map[string]int, anchor topology) string {
+	total := 0
+	parts := []string{}
+	for _, rel := range []struct {
+		relation string
+		where    string
+	}{
+		{RelationSameSource, "the same source"},
+		{RelationSameGPU, "GPU " + anchor.gpuID},
+		{RelationSameServer, "server " + anchor.serverID},
+		{RelationSameRack, "rack " + anchor.rack},
+		{RelationSamePDU, "PDU " + anchor.pdu},
+	} {
+		if n := relations[rel.relation]; n > 0 {
+			total += n
+			parts = append(parts, fmt.Sprintf("%d on %s", n, rel.where))
+		}
+	}
+	if total == 0 {
+		return "no other alert in the window shares its topology"
+	}
+	return fmt.Sprintf("%d alerts share its topology: %s", total, strings.Join(parts, ", "))
+}
@@ -0,0 +1,175 @@
+package alert
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// topologyFixture 一个PDU供电两个机柜：r1中有s1、s2，r2中有s3；s4在另一个PDU上
+// 返回服务器名称到ID和GPU名称到ID的映射
+func topologyFixture(t *testing.T, repos *repository.Repositories) (map[string]string, map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+	servers := map[string]string{}
+	gpus := map[string]string{}
+	for _, s := range []struct{ name, rack, pdu string }{
+		{"s1", "r1", "p1"},
+		{"s2", "r1", "p1"},
+		{"s3", "r2", "p1"},
+		{"s4", "r9", "p9"},
+	} {
+		server := &models.Server{Name: s.name, Status: models.ServerStatusReady, Rack: s.rack, PDU: s.pdu}
+		if err := repos.Servers.Create(ctx, server); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		servers[s.name] = server.ID
+		gpu := &models.GPU{ServerID: server.ID, Model: "A100", MemoryGB: 80, Status: models.GPUStatusAvailable}
+		if err := repos.GPUs.Create(ctx, gpu); err != nil {
+			t.Fatalf("failed to create gpu: %v", err)
+		}
+		gpus[s.name+"-gpu"] = gpu.ID
+	}
+	return servers, gpus
+}
+
+func TestServiceCorrelate(t *testing.T) {
+	// alertSpec 告警来源和相对基准时间的触发时间
+	type alertSpec struct {
+		sourceType string
+		source     string
+		at         time.Duration
+	}
+	specs := map[string]alertSpec{
+		"pdu":      {sourceType: ScopePDU, source: "p1", at: -time.Minute},
+		"rack":     {sourceType: ScopeRack, source: "r1", at: 0},
+		"s1":       {sourceType: ScopeServer, source: "s1", at: 30 * time.Second},
+		"s1-gpu":   {sourceType: ScopeGPU, source: "s1-gpu", at: time.Minute},
+		"s1-gpu-2": {sourceType: ScopeGPU, source: "s1-gpu", at: 2 * time.Minute},
+		"s2":       {sourceType: ScopeServer, source: "s2", at: 90 * time.Second},
+		"s3":       {sourceType: ScopeServer, source: "s3", at: 90 * time.Second},
+		"s4":       {sourceType: ScopeServer, source: "s4", at: time.Minute},
+		"late":     {sourceType: ScopeServer, source: "s2", at: time.Hour},
+	}
+
+	tests := []struct {
+		name           string
+		alerts         []string
+		anchor         string
+		wantGroup      []string
+		wantRoot       string
+		wantSuppressed []string
+	}{
+		{
+			// PDU告警覆盖同一PDU上的全部告警，另一个PDU和窗口外的告警不参与关联
+			name:           "pdu root",
+			alerts:         []string{"pdu", "s1", "s1-gpu", "s2", "s3", "s4", "late"},
+			anchor:         "s1-gpu",
+			wantGroup:      []string{"pdu", "s1", "s1-gpu", "s2", "s3"},
+			wantRoot:       "pdu",
+			wantSuppressed: []string{"s1", "s1-gpu", "s2", "s3"},
+		},
+		{
+			// 机柜告警只覆盖本机柜，同一PDU上其他机柜的告警保留
+			name:           "rack root",
+			alerts:         []string{"rack", "s1", "s1-gpu", "s3"},
+			anchor:         "s1",
+			wantGroup:      []string{"rack", "s1", "s1-gpu", "s3"},
+			wantRoot:       "rack",
+			wantSuppressed: []string{"s1", "s1-gpu"},
+		},
+		{
+			name:           "server root",
+			alerts:         []string{"s1", "s1-gpu", "s1-gpu-2", "s2"},
+			anchor:         "s1-gpu",
+			wantGroup:      []string{"s1", "s1-gpu", "s1-gpu-2", "s2"},
+			wantRoot:       "s1",
+			wantSuppressed: []string{"s1-gpu", "s1-gpu-2"},
+		},
+		{
+			// 没有更大范围的告警时最早的告警为根因，不抑制任何告警
+			name:      "no cover",
+			alerts:    []string{"s1-gpu", "s2", "s3"},
+			anchor:    "s3",
+			wantGroup: []string{"s1-gpu", "s2", "s3"},
+			wantRoot:  "s1-gpu",
+		},
+		{
+			name:      "isolated",
+			alerts:    []string{"s1", "s4"},
+			anchor:    "s4",
+			wantGroup: []string{"s4"},
+			wantRoot:  "s4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			s := NewService(repos, Config{CorrelationWindow: 10 * time.Minute})
+			servers, gpus := topologyFixture(t, repos)
+			ctx := context.Background()
+			base := time.Now().UTC().Add(-time.Hour)
+
+			ids := map[string]string{}
+			names := map[string]string{}
+			for _, name := range tt.alerts {
+				spec := specs[name]
+				source := spec.source
+				if id, ok := servers[source]; ok {
+					source = id
+				}
+				if id, ok := gpus[source]; ok {
+					source = id
+				}
+				alert := &models.Alert{
+					SourceType:  spec.sourceType,
+					SourceID:    source,
+					AlertType:   spec.sourceType + "-down",
+					Severity:    models.AlertSeverityHigh,
+					Status:      models.AlertStatusActive,
+					TriggeredAt: base.Add(spec.at),
+				}
+				if err := repos.Alerts.Create(ctx, alert); err != nil {
+					t.Fatalf("failed to create alert: %v", err)
+				}
+				ids[name] = alert.ID
+				names[alert.ID] = name
+			}
+
+			result, err := s.Correlate(ctx, ids[tt.anchor], 0)
+			if err != nil {
+				t.Fatalf("Correlate() error = %v", err)
+			}
+
+			var group []string
+			for _, member := range result.Group {
+				group = append(group, names[member.Alert.ID])
+			}
+			var suppressed []string
+			for _, id := range result.Suppressed {
+				suppressed = append(suppressed, names[id])
+			}
+			sort.Strings(group)
+			sort.Strings(suppressed)
+
+			if !slices.Equal(group, tt.wantGroup) {
+				t.Errorf("group = %v, want %v", group, tt.wantGroup)
+			}
+			if root := names[result.Root.ID]; root != tt.wantRoot {
+				t.Errorf("root = %s, want %s", root, tt.wantRoot)
+			}
+			if !slices.Equal(suppressed, tt.wantSuppressed) {
+				t.Errorf("suppressed = %v, want %v", suppressed, tt.wantSuppressed)
+			}
+			if len(result.Reasoning) == 0 {
+				t.Errorf("reasoning is empty")
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/outbox"
+)
+
+// eventSource 告警事件的CloudEvents source
+const eventSource = "alert-service"
+
+// Config 告警生命周期配置
+type Config struct {
+	// EscalateAfter 告警保持未确认超过该时长后自动升级一级，每次升级后重新计时，0表示不自动升级
+	EscalateAfter time.Duration
+	// MaxEscalations 最多自动升级的次数
+	MaxEscalations int
+	// CheckInterval 检查升级和静默的间隔
+	CheckInterval time.Duration
+	// CorrelationWindow 关联分析默认的时间窗口，取被分析告警触发时间前后各CorrelationWindow
+	CorrelationWindow time.Duration
+}
+
+// CreateRequest 产生告警请求
+type CreateRequest struct {
+	SourceType string            `json:"source_type"`
+	SourceID   string            `json:"source_id"`
+	AlertType  string            `json:"alert_type"`
+	Severity   string            `json:"severity"`
+	Message    string            `json:"message"`
+	Labels     map[string]string `json:"labels"`
+}
+
+// Validate 校验产生告警请求
+func (r *CreateRequest) Validate() error {
+	if r.SourceType == "" || r.SourceID == "" {
+		return errors.New("source_type and source_id are required")
+	}
+	if r.AlertType == "" {
+		return errors.New("alert_type is required")
+	}
+	if models.SeverityRank(r.Severity) < 0 {
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+	return nil
+}
+
+// UpdateRequest 更新告警请求，按状态机迁移到status；Actor为空时使用当前用户
+type UpdateRequest struct {
+	Status string `json:"status"`
+	Actor  string `json:"actor"`
+}
+
+// Validate 校验更新告警请求
+func (r *UpdateRequest) Validate() error {
+	switch r.Status {
+	case models.AlertStatusAcknowledged, models.AlertStatusResolved:
+		return nil
+	case "":
+		return errors.New("status is required")
+	default:
+		return fmt.Errorf("status must be %s or %s", models.AlertStatusAcknowledged, models.AlertStatusResolved)
+	}
+}
+
+// SilenceRequest 创建静默规则请求，ends_at和duration二选一，starts_at为空时立即生效
+type SilenceRequest struct {
+	Matchers map[string]string `json:"matchers"`
+	Comment  string            `json:"comment"`
+	StartsAt *time.Time        `json:"starts_at"`
+	EndsAt   *time.Time        `json:"ends_at"`
+	Duration string            `json:"duration"`
+}
+
+// Validate 校验静默规则请求
+func (r *SilenceRequest) Validate() error {
+	if len(r.Matchers) == 0 {
+		return errors.New("matchers are required")
+	}
+	if (r.EndsAt == nil) == (r.Duration == "") {
+		return errors.New("exactly one of ends_at and duration is required")
+	}
+	if r.Duration != "" {
+		d, err := time.ParseDuration(r.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		if d <= 0 {
+			return errors.New("duration must be positive")
+		}
+	}
+	if r.EndsAt != nil {
+		if r.StartsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+			return errors.New("ends_at must be after starts_at")
+		}
+		if !r.EndsAt.After(time.Now()) {
+			return errors.New("ends_at must be in the future")
+		}
+	}
+	return nil
+}
+
+// window 计算静默规则的生效时间范围
+func (r *SilenceRequest) window(now time.Time) (time.Time, time.Time) {
+	startsAt := now
+	if r.StartsAt != nil {
+		startsAt = r.StartsAt.UTC()
+	}
+	if r.EndsAt != nil {
+		return startsAt, r.EndsAt.UTC()
+	}
+	d, _ := time.ParseDuration(r.Duration)
+	return startsAt, startsAt.Add(d)
+}
+
+// Service 告警服务，负责告警生命周期、静默和自动升级
+// 每次状态变化与对应的告警事件在同一事务中写入发件箱，由转发器发布
+type Service struct {
+	repos  *repository.Repositories
+	config Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService 创建告警服务，调用Start后开始检查升级
+func NewService(repos *repository.Repositories, config Config) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		repos:  repos,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// List 查询告警列表
+func (s *Service) List(ctx context.Context, filter repository.AlertFilter) ([]models.Alert, error) {
+	return s.repos.Alerts.List(ctx, filter)
+}
+
+// Get 查询告警详情
+func (s *Service) Get(ctx context.Context, id string) (*models.Alert, error) {
+	return s.repos.Alerts.Get(ctx, id)
+}
+
+// Create 产生处于active状态的告警并发布alert.raised，匹配生效中的静默规则时记录静默规则ID
+func (s *Service) Create(ctx context.Context, req *CreateRequest) (*models.Alert, error) {
+	now := time.Now().UTC()
+	alert := &models.Alert{
+		SourceType:  req.SourceType,
+		SourceID:    req.SourceID,
+		AlertType:   req.AlertType,
+		Severity:    req.Severity,
+		Message:     req.Message,
+		Status:      models.AlertStatusActive,
+		Labels:      req.Labels,
+		TriggeredAt: now,
+	}
+
+	silences, err := s.repos.Silences.List(ctx, repository.SilenceFilter{ActiveAt: now})
+	if err != nil {
+		return nil, err
+	}
+	alert.SilenceID = matchSilence(silences, alert)
+
+	err = s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Alerts.Create(ctx, alert); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, event.EventTypeAlertRaised, alert, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// Acknowledge 确认告警 (active -> acknowledged)，确认后不再自动升级
+func (s *Service) Acknowledge(ctx context.Context, id, actor string) (*models.Alert, error) {
+	return s.TransitionTo(ctx, id, models.AlertStatusAcknowledged, actor)
+}
+
+// Resolve 解除告警 (active/acknowledged -> resolved)
+func (s *Service) Resolve(ctx context.Context, id, actor string) (*models.Alert, error) {
+	return s.TransitionTo(ctx, id, models.AlertStatusResolved, actor)
+}
+
+// TransitionTo 将告警迁移到指定状态，非法迁移返回*models.TransitionError
+// 在事务中锁定并重新读取告警，避免覆盖并发的升级或静默匹配
+func (s *Service) TransitionTo(ctx context.Context, id, to, actor string) (*models.Alert, error) {
+	var alert *models.Alert
+	err := s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		var err error
+		alert, err = tx.Alerts.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		from := alert.Status
+		if err := models.AlertTransitions.Validate("alert", alert.ID, from, to); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		alert.Status = to
+		topic := event.EventTypeAlertAcknowledged
+		switch to {
+		case models.AlertStatusAcknowledged:
+			alert.AcknowledgedBy = actor
+			alert.AcknowledgedAt = &now
+		case models.AlertStatusResolved:
+			alert.ResolvedBy = actor
+			alert.ResolvedAt = &now
+			topic = event.EventTypeAlertResolved
+		}
+
+		if err := tx.Alerts.UpdateIfStatus(ctx, alert, from); err != nil {
+			return err
+		}
+		return enqueue(ctx, tx, topic, alert, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ListSilences 查询静默规则，activeOnly为true时只返回当前生效的规则
+func (s *Service) ListSilences(ctx context.Context, activeOnly bool) ([]models.Silence, error) {
+	var filter repository.SilenceFilter
+	if activeOnly {
+		filter.ActiveAt = time.Now().UTC()
+	}
+	return s.repos.Silences.List(ctx, filter)
+}
+
+// CreateSilence 创建静默规则，并立即应用到未解除的告警
+func (s *Service) CreateSilence(ctx context.Context, req *SilenceRequest, createdBy string) (*models.Silence, error) {
+	startsAt, endsAt := req.window(time.Now().UTC())
+	silence := &models.Silence{
+		Matchers:  req.Matchers,
+		Comment:   req.Comment,
+		CreatedBy: createdBy,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+	}
+	if err := s.repos.Silences.Create(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	if err := s.Check(ctx, time.Now().UTC()); err != nil {
+		log.Printf("Failed to apply silence %s: %v", silence.ID, err)
+	}
+	return silence, nil
+}
+
+// ExpireSilence 立即结束静默规则，尚未开始的规则直接失效
+func (s *Service) ExpireSilence(ctx context.Context, id string) (*models.Silence, error) {
+	silence, err := s.repos.Silences.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if silence.State(now) == models.SilenceStateExpired {
+		return silence, nil
+	}
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+	silence.EndsAt = now
+	if err := s.repos.Silences.Update(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	if err := s.Check(ctx, now); err != nil {
+		log.Printf("Failed to lift silence %s: %v", silence.ID, err)
+	}
+	return silence, nil
+}
+
+// Start 在后台按CheckInterval检查告警升级和静默
+func (s *Service) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Close 停止后台检查并等待进行中的检查结束
+func (s *Service) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop 检查循环
+func (s *Service) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Check(s.ctx, time.Now().UTC()); err != nil && s.ctx.Err() == nil {
+			log.Printf("Alert check failed: %v", err)
+		}
+	}
+}
+
+// Check 按now时刻重新匹配未解除告警的静默规则，并升级超时未确认且未被静默的告警
+// 每个告警在各自的事务中锁定并重新读取后再判断，检查期间被并发确认、解除或升级的告警按最新状态处理
+func (s *Service) Check(ctx context.Context, now time.Time) error {
+	alerts, err := s.repos.Alerts.List(ctx, repository.AlertFilter{
+		Statuses: []string{models.AlertStatusActive, models.AlertStatusAcknowledged},
+	})
+	if err != nil {
+		return err
+	}
+	silences, err := s.repos.Silences.List(ctx, repository.SilenceFilter{ActiveAt: now})
+	if err != nil {
+		return err
+	}
+
+	for i := range alerts {
+		id := alerts[i].ID
+		err := s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+			return s.check(ctx, tx, id, silences, now)
+		})
+		if errors.Is(err, repository.ErrNotFound) {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("alert %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// check 在事务中重新匹配一个告警的静默规则，到期时升级，tx必须来自InTx
+func (s *Service) check(ctx context.Context, tx *repository.Repositories, id string, silences []models.Silence, now time.Time) error {
+	alert, err := tx.Alerts.GetForUpdate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if alert.Status == models.AlertStatusResolved {
+		return nil
+	}
+
+	silenceID := matchSilence(silences, alert)
+	silenceChanged := silenceID != alert.SilenceID
+	alert.SilenceID = silenceID
+
+	if s.dueForEscalation(alert, now) {
+		return escalate(ctx, tx, alert, now)
+	}
+	if silenceChanged {
+		return tx.Alerts.UpdateIfStatus(ctx, alert, alert.Status)
+	}
+	return nil
+}
+
+// dueForEscalation 判断告警是否需要升级：active、未被静默、未达到升级上限，且距上次升级（或触发）已超过EscalateAfter
+func (s *Service) dueForEscalation(alert *models.Alert, now time.Time) bool {
+	if s.config.EscalateAfter <= 0 || alert.Status != models.AlertStatusActive || alert.SilenceID != "" {
+		return false
+	}
+	if alert.EscalationLevel >= s.config.MaxEscalations {
+		return false
+	}
+	since := alert.TriggeredAt
+	if alert.EscalatedAt != nil {
+		since = *alert.EscalatedAt
+	}
+	return now.Sub(since) >= s.config.EscalateAfter
+}
+
+// escalate 将告警严重程度提高一级并发布alert.escalated，已是critical时只增加升级次数，tx必须来自InTx
+func escalate(ctx context.Context, tx *repository.Repositories, alert *models.Alert, now time.Time) error {
+	alert.EscalationLevel++
+	alert.EscalatedAt = &now
+	alert.Severity = models.NextSeverity(alert.Severity)
+
+	if err := tx.Alerts.UpdateIfStatus(ctx, alert, models.AlertStatusActive); err != nil {
+		return err
+	}
+	return enqueue(ctx, tx, event.EventTypeAlertEscalated, alert, "")
+}
+
+// matchSilence 返回第一个匹配告警标签的静默规则ID，没有匹配时返回空
+func matchSilence(silences []models.Silence, alert *models.Alert) string {
+	labels := alert.MatchLabels()
+	for i := range silences {
+		if silences[i].Matches(labels) {
+			return silences[i].ID
+		}
+	}
+	return ""
+}
+
+// enqueue 在事务中写入告警事件，tx必须来自与告警写入相同的InTx
+func enqueue(ctx context.Context, tx *repository.Repositories, topic string, alert *models.Alert, actor string) error {
+	evt, err := event.NewCloudEvent(eventSource, topic, alert.ID, event.AlertEvent{
+		AlertID:         alert.ID,
+		AlertType:       alert.AlertType,
+		Severity:        alert.Severity,
+		Message:         alert.Message,
+		Status:          alert.Status,
+		SourceType:      alert.SourceType,
+		SourceID:        alert.SourceID,
+		Labels:          alert.Labels,
+		EscalationLevel: alert.EscalationLevel,
+		SilenceID:       alert.SilenceID,
+		Actor:           actor,
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(ctx, tx.Outbox, evt)
+}
@@ -0,0 +1,313 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// newAlert 产生一个GPU温度告警
+func newAlert(t *testing.T, s *Service, gpuID, severity string) *models.Alert {
+	t.Helper()
+	alert, err := s.Create(context.Background(), &CreateRequest{
+		SourceType: "gpu",
+		SourceID:   gpuID,
+		AlertType:  "gpu-overheat",
+		Severity:   severity,
+		Labels:     map[string]string{"server_id": "server-1"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return alert
+}
+
+// topics 返回发件箱中待发布事件的主题计数
+func topics(t *testing.T, repos *repository.Repositories) map[string]int {
+	t.Helper()
+	due, err := repos.Outbox.ListDue(context.Background(), time.Now().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("ListDue() error = %v", err)
+	}
+	counts := map[string]int{}
+	for _, evt := range due {
+		counts[evt.Topic]++
+	}
+	return counts
+}
+
+// getAlert 查询告警
+func getAlert(t *testing.T, repos *repository.Repositories, id string) *models.Alert {
+	t.Helper()
+	alert, err := repos.Alerts.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("failed to get alert: %v", err)
+	}
+	return alert
+}
+
+func TestServiceTransitionTo(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps 依次迁移到的状态，最后一步的结果与wantErr比较
+		steps      []string
+		wantErr    bool
+		wantStatus string
+		wantTopics map[string]int
+	}{
+		{
+			name:       "acknowledge then resolve",
+			steps:      []string{models.AlertStatusAcknowledged, models.AlertStatusResolved},
+			wantStatus: models.AlertStatusResolved,
+			wantTopics: map[string]int{event.EventTypeAlertRaised: 1, event.EventTypeAlertAcknowledged: 1, event.EventTypeAlertResolved: 1},
+		},
+		{
+			name:       "resolve active",
+			steps:      []string{models.AlertStatusResolved},
+			wantStatus: models.AlertStatusResolved,
+			wantTopics: map[string]int{event.EventTypeAlertRaised: 1, event.EventTypeAlertResolved: 1},
+		},
+		{
+			name:       "acknowledge twice",
+			steps:      []string{models.AlertStatusAcknowledged, models.AlertStatusAcknowledged},
+			wantErr:    true,
+			wantStatus: models.AlertStatusAcknowledged,
+			wantTopics: map[string]int{event.EventTypeAlertRaised: 1, event.EventTypeAlertAcknowledged: 1},
+		},
+		{
+			name:       "acknowledge resolved",
+			steps:      []string{models.AlertStatusResolved, models.AlertStatusAcknowledged},
+			wantErr:    true,
+			wantStatus: models.AlertStatusResolved,
+			wantTopics: map[string]int{event.EventTypeAlertRaised: 1, event.EventTypeAlertResolved: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			s := NewService(repos, Config{})
+			alert := newAlert(t, s, "gpu-1", models.AlertSeverityHigh)
+
+			var err error
+			for _, to := range tt.steps {
+				_, err = s.TransitionTo(context.Background(), alert.ID, to, "alice")
+			}
+			var transitionErr *models.TransitionError
+			if errors.As(err, &transitionErr) != tt.wantErr {
+				t.Fatalf("TransitionTo() error = %v, want transition error %v", err, tt.wantErr)
+			}
+
+			got := getAlert(t, repos, alert.ID)
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if got.Status == models.AlertStatusResolved && (got.ResolvedBy != "alice" || got.ResolvedAt == nil) {
+				t.Errorf("resolved by %q at %v, want alice", got.ResolvedBy, got.ResolvedAt)
+			}
+			counts := topics(t, repos)
+			for topic, want := range tt.wantTopics {
+				if counts[topic] != want {
+					t.Errorf("%s events = %d, want %d", topic, counts[topic], want)
+				}
+			}
+			if len(counts) != len(tt.wantTopics) {
+				t.Errorf("outbox topics = %v, want %v", counts, tt.wantTopics)
+			}
+		})
+	}
+}
+
+func TestServiceCheckEscalation(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare 在检查前修改告警
+		prepare func(t *testing.T, s *Service, alert *models.Alert)
+		// checks 依次检查的时间，相对告警触发时间
+		checks        []time.Duration
+		wantSeverity  string
+		wantLevel     int
+		wantEscalated int
+	}{
+		{
+			name:         "not due",
+			checks:       []time.Duration{5 * time.Minute},
+			wantSeverity: models.AlertSeverityMedium,
+		},
+		{
+			// 每次升级后重新计时
+			name:          "escalate and restart timer",
+			checks:        []time.Duration{10 * time.Minute, 15 * time.Minute},
+			wantSeverity:  models.AlertSeverityHigh,
+			wantLevel:     1,
+			wantEscalated: 1,
+		},
+		{
+			// 达到升级上限后不再升级
+			name:          "max escalations",
+			checks:        []time.Duration{10 * time.Minute, 20 * time.Minute, 30 * time.Minute, 40 * time.Minute},
+			wantSeverity:  models.AlertSeverityCritical,
+			wantLevel:     2,
+			wantEscalated: 2,
+		},
+		{
+			name: "acknowledged",
+			prepare: func(t *testing.T, s *Service, alert *models.Alert) {
+				if _, err := s.Acknowledge(context.Background(), alert.ID, "alice"); err != nil {
+					t.Fatalf("Acknowledge() error = %v", err)
+				}
+			},
+			checks:       []time.Duration{10 * time.Minute},
+			wantSeverity: models.AlertSeverityMedium,
+		},
+		{
+			name: "silenced",
+			prepare: func(t *testing.T, s *Service, alert *models.Alert) {
+				if _, err := s.CreateSilence(context.Background(), &SilenceRequest{
+					Matchers: map[string]string{"source_id": alert.SourceID},
+					Duration: "24h",
+				}, "alice"); err != nil {
+					t.Fatalf("CreateSilence() error = %v", err)
+				}
+			},
+			checks:       []time.Duration{10 * time.Minute},
+			wantSeverity: models.AlertSeverityMedium,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			s := NewService(repos, Config{EscalateAfter: 10 * time.Minute, MaxEscalations: 2})
+			alert := newAlert(t, s, "gpu-1", models.AlertSeverityMedium)
+			if tt.prepare != nil {
+				tt.prepare(t, s, alert)
+			}
+
+			for _, at := range tt.checks {
+				if err := s.Check(context.Background(), alert.TriggeredAt.Add(at)); err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+			}
+
+			got := getAlert(t, repos, alert.ID)
+			if got.Severity != tt.wantSeverity || got.EscalationLevel != tt.wantLevel {
+				t.Errorf("severity = %s, level = %d, want %s, %d", got.Severity, got.EscalationLevel, tt.wantSeverity, tt.wantLevel)
+			}
+			if escalated := topics(t, repos)[event.EventTypeAlertEscalated]; escalated != tt.wantEscalated {
+				t.Errorf("%s events = %d, want %d", event.EventTypeAlertEscalated, escalated, tt.wantEscalated)
+			}
+		})
+	}
+}
+
+func TestServiceSilence(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers map[string]string
+		// wantSilenced 按GPU标识是否被静默
+		wantSilenced map[string]bool
+	}{
+		{name: "by source", matchers: map[string]string{"source_id": "gpu-1"}, wantSilenced: map[string]bool{"gpu-1": true}},
+		{name: "by label", matchers: map[string]string{"server_id": "server-1"}, wantSilenced: map[string]bool{"gpu-1": true, "gpu-2": true}},
+		{name: "all matchers", matchers: map[string]string{"server_id": "server-1", "severity": models.AlertSeverityCritical}, wantSilenced: map[string]bool{"gpu-2": true}},
+		{name: "no match", matchers: map[string]string{"server_id": "server-9"}, wantSilenced: map[string]bool{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			s := NewService(repos, Config{})
+			ctx := context.Background()
+			alerts := map[string]*models.Alert{
+				"gpu-1": newAlert(t, s, "gpu-1", models.AlertSeverityHigh),
+				"gpu-2": newAlert(t, s, "gpu-2", models.AlertSeverityCritical),
+			}
+
+			// 创建静默规则立即应用到未解除的告警，之后产生的告警在创建时匹配
+			silence, err := s.CreateSilence(ctx, &SilenceRequest{Matchers: tt.matchers, Duration: "1h"}, "alice")
+			if err != nil {
+				t.Fatalf("CreateSilence() error = %v", err)
+			}
+			alerts["gpu-1 later"] = newAlert(t, s, "gpu-1", models.AlertSeverityHigh)
+			for name, alert := range alerts {
+				gpu := alert.SourceID
+				want := ""
+				if tt.wantSilenced[gpu] {
+					want = silence.ID
+				}
+				if got := getAlert(t, repos, alert.ID).SilenceID; got != want {
+					t.Errorf("%s silence = %q, want %q", name, got, want)
+				}
+			}
+
+			// 结束静默规则后解除静默
+			if _, err := s.ExpireSilence(ctx, silence.ID); err != nil {
+				t.Fatalf("ExpireSilence() error = %v", err)
+			}
+			for name, alert := range alerts {
+				if got := getAlert(t, repos, alert.ID).SilenceID; got != "" {
+					t.Errorf("%s silence after expiry = %q, want none", name, got)
+				}
+			}
+		})
+	}
+}
+
+// racingAlerts 在List返回快照后调用onList，模拟检查期间其他实例对告警的并发修改
+type racingAlerts struct {
+	repository.AlertRepository
+	onList func()
+}
+
+func (r *racingAlerts) List(ctx context.Context, filter repository.AlertFilter) ([]models.Alert, error) {
+	alerts, err := r.AlertRepository.List(ctx, filter)
+	if err == nil && r.onList != nil {
+		r.onList()
+		r.onList = nil
+	}
+	return alerts, err
+}
+
+func TestServiceCheckConcurrentUpdate(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	s := NewService(repos, Config{EscalateAfter: 10 * time.Minute, MaxEscalations: 3})
+	ctx := context.Background()
+	alert := newAlert(t, s, "gpu-1", models.AlertSeverityMedium)
+	if err := repos.Silences.Create(ctx, &models.Silence{
+		Matchers: map[string]string{"source_id": "gpu-1"},
+		StartsAt: alert.TriggeredAt,
+		EndsAt:   alert.TriggeredAt.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("failed to create silence: %v", err)
+	}
+
+	// 检查取得快照后，另一个实例升级了告警，状态仍为active
+	now := alert.TriggeredAt.Add(10 * time.Minute)
+	repos.Alerts = &racingAlerts{AlertRepository: repos.Alerts, onList: func() {
+		raced := getAlert(t, repos, alert.ID)
+		raced.Severity = models.AlertSeverityHigh
+		raced.EscalationLevel = 1
+		raced.EscalatedAt = &now
+		if err := repos.Alerts.UpdateIfStatus(ctx, raced, models.AlertStatusActive); err != nil {
+			t.Fatalf("failed to update alert: %v", err)
+		}
+	}}
+
+	// 匹配静默规则的写入基于最新的告警，不覆盖并发的升级
+	if err := s.Check(ctx, now); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	got := getAlert(t, repos, alert.ID)
+	if got.SilenceID == "" {
+		t.Errorf("alert not silenced")
+	}
+	if got.Severity != models.AlertSeverityHigh || got.EscalationLevel != 1 {
+		t.Errorf("severity = %s, level = %d, want the concurrent escalation %s, 1", got.Severity, got.EscalationLevel, models.AlertSeverityHigh)
+	}
+}
@@ -0,0 +1,385 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/outbox"
+	"gpu-management/internal/services/scheduler"
+)
+
+// ErrMultiServerPlacement 调度结果跨越多台服务器，分配只能使用同一台服务器上的GPU
+var ErrMultiServerPlacement = errors.New("allocation gpus must be on one server")
+
+// CreateRequest 创建分配请求
+// GPUCount为0时按整机分配，必须指定server_id；
+// GPUCount大于0时由调度器选择GPU，server_id作为可选的服务器亲和性
+type CreateRequest struct {
+	UserID      string `json:"user_id"`
+	ServerID    string `json:"server_id"`
+	Purpose     string `json:"purpose"`
+	GPUCount    int    `json:"gpu_count"`
+	GPUModel    string `json:"gpu_model"`
+	MinMemoryGB int    `json:"min_memory_gb"`
+	Strategy    string `json:"strategy"`
+}
+
+// Validate 校验创建请求
+func (r *CreateRequest) Validate() error {
+	if r.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	if r.GPUCount < 0 {
+		return errors.New("gpu_count must not be negative")
+	}
+	if r.MinMemoryGB < 0 {
+		return errors.New("min_memory_gb must not be negative")
+	}
+	if r.GPUCount == 0 && r.ServerID == "" {
+		return errors.New("server_id is required when gpu_count is not set")
+	}
+	return nil
+}
+
+// UpdateRequest 更新分配请求，nil字段表示不修改
+type UpdateRequest struct {
+	Purpose *string `json:"purpose"`
+	Status  *string `json:"status"`
+}
+
+// Validate 校验更新请求
+func (r *UpdateRequest) Validate() error {
+	if r.Purpose == nil && r.Status == nil {
+		return errors.New("nothing to update")
+	}
+	if r.Status != nil {
+		switch *r.Status {
+		case models.AllocationStatusPending, models.AllocationStatusActive,
+			models.AllocationStatusCompleted, models.AllocationStatusFailed:
+		default:
+			return fmt.Errorf("unknown status %q", *r.Status)
+		}
+	}
+	return nil
+}
+
+// Service 资源分配服务，负责分配生命周期和状态迁移
+type Service struct {
+	repos     *repository.Repositories
+	eventBus  event.EventBus
+	scheduler *scheduler.Scheduler
+}
+
+// NewService 创建资源分配服务
+func NewService(repos *repository.Repositories, eventBus event.EventBus, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		repos:     repos,
+		eventBus:  eventBus,
+		scheduler: scheduler,
+	}
+}
+
+// List 查询分配列表
+func (s *Service) List(ctx context.Context, filter repository.AllocationFilter) ([]models.Allocation, error) {
+	return s.repos.Allocations.List(ctx, filter)
+}
+
+// Get 查询分配详情
+func (s *Service) Get(ctx context.Context, id string) (*models.Allocation, error) {
+	return s.repos.Allocations.Get(ctx, id)
+}
+
+// Create 创建处于pending状态的分配，需要GPU时先由调度器占用GPU
+// 占用GPU、写入分配和关联GPU在同一个事务中完成，任一步失败时全部回滚
+func (s *Service) Create(ctx context.Context, req *CreateRequest) (*models.Allocation, error) {
+	if req.ServerID != "" {
+		if _, err := s.repos.Servers.Get(ctx, req.ServerID); err != nil {
+			return nil, fmt.Errorf("server %s: %w", req.ServerID, err)
+		}
+	}
+
+	allocation := &models.Allocation{
+		UserID:   req.UserID,
+		ServerID: req.ServerID,
+		Status:   models.AllocationStatusPending,
+		Purpose:  req.Purpose,
+	}
+
+	err := s.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		var placement *scheduler.Placement
+		if req.GPUCount > 0 {
+			var err error
+			placement, err = s.scheduler.ScheduleInTx(ctx, tx, &scheduler.Request{
+				GPUCount:    req.GPUCount,
+				Model:       req.GPUModel,
+				MinMemoryGB: req.MinMemoryGB,
+				ServerID:    req.ServerID,
+				Strategy:    req.Strategy,
+			})
+			if err != nil {
+				return err
+			}
+			// 部署、清理和电源检查都按分配的服务器执行，分配的GPU必须在同一台服务器上
+			if servers := placement.ServerIDs(); len(servers) > 1 {
+				return fmt.Errorf("%w: %s placement spans servers %v", ErrMultiServerPlacement, placement.Strategy, servers)
+			}
+			allocation.ServerID = placement.ServerID()
+		}
+
+		if err := tx.Allocations.Create(ctx, allocation); err != nil {
+			return err
+		}
+		if placement != nil {
+			if err := tx.Allocations.AttachGPUs(ctx, allocation.ID, placement.GPUIDs()); err != nil {
+				return err
+			}
+			allocation.GPUIDs = placement.GPUIDs()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, event.EventTypeAllocationCreated, allocation, "")
+	return allocation, nil
+}
+
+// Update 更新分配用途，或按状态机迁移状态
+func (s *Service) Update(ctx context.Context, id string, req *UpdateRequest) (*models.Allocation, error) {
+	allocation, err := s.repos.Allocations.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != nil && *req.Status != allocation.Status {
+		return s.transition(ctx, allocation, *req.Status, func(a *models.Allocation) {
+			if req.Purpose != nil {
+				a.Purpose = *req.Purpose
+			}
+		})
+	}
+
+	if req.Purpose != nil {
+		allocation.Purpose = *req.Purpose
+	}
+	if err := s.repos.Allocations.UpdateIfStatus(ctx, allocation, allocation.Status); err != nil {
+		return nil, err
+	}
+	return allocation, nil
+}
+
+// Start 激活分配 (pending -> active)
+func (s *Service) Start(ctx context.Context, id string) (*models.Allocation, error) {
+	return s.TransitionTo(ctx, id, models.AllocationStatusActive)
+}
+
+// Stop 完成分配 (active -> completed)
+func (s *Service) Stop(ctx context.Context, id string) (*models.Allocation, error) {
+	return s.TransitionTo(ctx, id, models.AllocationStatusCompleted)
+}
+
+// Fail 将分配标记为失败 (pending/active -> failed)
+func (s *Service) Fail(ctx context.Context, id string) (*models.Allocation, error) {
+	return s.TransitionTo(ctx, id, models.AllocationStatusFailed)
+}
+
+// TransitionTo 将分配迁移到指定状态，非法迁移返回*models.TransitionError
+func (s *Service) TransitionTo(ctx context.Context, id string, to string) (*models.Allocation, error) {
+	allocation, err := s.repos.Allocations.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, allocation, to, nil)
+}
+
+// Delete 删除分配，处于active状态的分配需先停止
+func (s *Service) Delete(ctx context.Context, id string) error {
+	allocation, err := s.repos.Allocations.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if allocation.Status == models.AllocationStatusActive {
+		return fmt.Errorf("%w: allocation %s is active, stop it first", repository.ErrConflict, id)
+	}
+
+	// pending分配仍占用着调度的GPU，删除前先归还
+	if allocation.Status == models.AllocationStatusPending {
+		reason := fmt.Sprintf("allocation %s deleted", allocation.ID)
+		if err := s.moveGPUs(ctx, allocation.GPUIDs, models.GPUStatusAllocated, models.GPUStatusAvailable, reason); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repos.Allocations.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.publish(ctx, event.EventTypeAllocationReleased, allocation, allocation.Status)
+	return nil
+}
+
+// TransitionInTx 在调用方的事务tx中迁移分配状态，GPU状态随之迁移，分配事件写入tx的发件箱
+// 事务回滚时分配、GPU和事件都不生效，用于需要与其他记录原子更新的状态迁移
+func (s *Service) TransitionInTx(ctx context.Context, tx *repository.Repositories, id string, to string) (*models.Allocation, error) {
+	allocation, err := tx.Allocations.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	from := allocation.Status
+	if err := applyTransition(allocation, to, nil); err != nil {
+		return nil, err
+	}
+
+	gpuFrom, gpuTo := gpuStatusesFor(from, to)
+	if err := moveGPUs(ctx, tx, allocation.GPUIDs, gpuFrom, gpuTo, transitionReason(allocation, from)); err != nil {
+		return nil, err
+	}
+	if err := tx.Allocations.UpdateIfStatus(ctx, allocation, from); err != nil {
+		return nil, err
+	}
+	evt, err := newEvent(topicForStatus(to), allocation, from)
+	if err != nil {
+		return nil, err
+	}
+	if err := outbox.Enqueue(ctx, tx.Outbox, evt); err != nil {
+		return nil, err
+	}
+	return allocation, nil
+}
+
+// transition 校验并执行状态迁移，mutate用于在同一次写入中附带修改其他字段
+func (s *Service) transition(ctx context.Context, allocation *models.Allocation, to string, mutate func(*models.Allocation)) (*models.Allocation, error) {
+	from := allocation.Status
+	if err := applyTransition(allocation, to, mutate); err != nil {
+		return nil, err
+	}
+
+	// GPU状态随分配状态联动
+	gpuFrom, gpuTo := gpuStatusesFor(from, to)
+	if err := s.moveGPUs(ctx, allocation.GPUIDs, gpuFrom, gpuTo, transitionReason(allocation, from)); err != nil {
+		return nil, err
+	}
+
+	// 以迁移前状态作为条件更新，防止并发迁移互相覆盖
+	if err := s.repos.Allocations.UpdateIfStatus(ctx, allocation, from); err != nil {
+		// 分配状态未能写入时回退GPU状态
+		revertReason := fmt.Sprintf("revert: allocation %s was modified concurrently", allocation.ID)
+		if revertErr := s.moveGPUs(ctx, allocation.GPUIDs, gpuTo, gpuFrom, revertReason); revertErr != nil {
+			log.Printf("Failed to revert gpus of allocation %s: %v", allocation.ID, revertErr)
+		}
+		return nil, err
+	}
+
+	s.publish(ctx, topicForStatus(to), allocation, from)
+	return allocation, nil
+}
+
+// applyTransition 校验状态迁移并修改分配的状态和时间记录，不写入数据库
+func applyTransition(allocation *models.Allocation, to string, mutate func(*models.Allocation)) error {
+	if err := models.AllocationTransitions.Validate("allocation", allocation.ID, allocation.Status, to); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	allocation.Status = to
+	switch to {
+	case models.AllocationStatusActive:
+		allocation.StartTime = &now
+		allocation.EndTime = nil
+	case models.AllocationStatusCompleted, models.AllocationStatusFailed:
+		allocation.EndTime = &now
+	case models.AllocationStatusPending:
+		// 重新分配时清空上一轮的时间记录
+		allocation.StartTime = nil
+		allocation.EndTime = nil
+	}
+	if mutate != nil {
+		mutate(allocation)
+	}
+	return nil
+}
+
+// transitionReason 分配状态迁移写入GPU状态历史的原因
+func transitionReason(allocation *models.Allocation, from string) string {
+	return fmt.Sprintf("allocation %s %s -> %s", allocation.ID, from, allocation.Status)
+}
+
+// gpuStatusesFor 分配状态迁移对应的GPU状态迁移
+func gpuStatusesFor(from, to string) (string, string) {
+	switch to {
+	case models.AllocationStatusActive:
+		return models.GPUStatusAllocated, models.GPUStatusInUse
+	case models.AllocationStatusCompleted:
+		return models.GPUStatusInUse, models.GPUStatusAvailable
+	case models.AllocationStatusFailed:
+		if from == models.AllocationStatusActive {
+			return models.GPUStatusInUse, models.GPUStatusAvailable
+		}
+		return models.GPUStatusAllocated, models.GPUStatusAvailable
+	default:
+		// 重新分配时重新占用原来的GPU
+		return models.GPUStatusAvailable, models.GPUStatusAllocated
+	}
+}
+
+// moveGPUs 原子地迁移分配关联的GPU状态，reason写入GPU状态历史
+func (s *Service) moveGPUs(ctx context.Context, gpuIDs []string, from, to, reason string) error {
+	return moveGPUs(ctx, s.repos, gpuIDs, from, to, reason)
+}
+
+// moveGPUs 通过repos迁移GPU状态，repos可以是事务中的仓储
+func moveGPUs(ctx context.Context, repos *repository.Repositories, gpuIDs []string, from, to, reason string) error {
+	if len(gpuIDs) == 0 {
+		return nil
+	}
+	change := repository.StatusChange{Actor: "allocation-service", Reason: reason}
+	if err := repos.GPUs.TransitionStatus(ctx, gpuIDs, from, to, change); err != nil {
+		return fmt.Errorf("move gpus %s -> %s: %w", from, to, err)
+	}
+	return nil
+}
+
+// topicForStatus 状态对应的事件主题
+func topicForStatus(status string) string {
+	switch status {
+	case models.AllocationStatusActive:
+		return event.EventTypeAllocationActivated
+	case models.AllocationStatusCompleted:
+		return event.EventTypeAllocationCompleted
+	case models.AllocationStatusFailed:
+		return event.EventTypeAllocationFailed
+	default:
+		return event.EventTypeAllocationRequeued
+	}
+}
+
+// publish 发布分配事件，发布失败不影响主流程，只记录日志
+func (s *Service) publish(ctx context.Context, topic string, allocation *models.Allocation, from string) {
+	evt, err := newEvent(topic, allocation, from)
+	if err != nil {
+		log.Printf("Failed to build %s for allocation %s: %v", topic, allocation.ID, err)
+		return
+	}
+
+	if err := s.eventBus.Publish(ctx, topic, evt, event.WithMessageID(evt.ID)); err != nil {
+		log.Printf("Failed to publish %s for allocation %s: %v", topic, allocation.ID, err)
+	}
+}
+
+// newEvent 构造分配事件
+func newEvent(topic string, allocation *models.Allocation, from string) (*event.CloudEvent, error) {
+	return event.NewCloudEvent("allocation-service", topic, allocation.ID, event.AllocationEvent{
+		AllocationID: allocation.ID,
+		ServerID:     allocation.ServerID,
+		UserID:       allocation.UserID,
+		FromStatus:   from,
+		ToStatus:     allocation.Status,
+	})
+}
@@ -0,0 +1,119 @@
+package allocation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/scheduler"
+)
+
+// newServers 创建servers台服务器，每台perServer张空闲A100，返回服务器ID
+func newServers(t *testing.T, repos *repository.Repositories, servers, perServer int) []string {
+	t.Helper()
+	ctx := context.Background()
+	var ids []string
+	for i := 0; i < servers; i++ {
+		server := &models.Server{Name: "node-" + string(rune('1'+i)), Status: models.ServerStatusReady}
+		if err := repos.Servers.Create(ctx, server); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		for j := 0; j < perServer; j++ {
+			gpu := &models.GPU{ServerID: server.ID, Model: "A100", MemoryGB: 80, Status: models.GPUStatusAvailable}
+			if err := repos.GPUs.Create(ctx, gpu); err != nil {
+				t.Fatalf("failed to create gpu: %v", err)
+			}
+		}
+		ids = append(ids, server.ID)
+	}
+	return ids
+}
+
+func TestServiceCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CreateRequest
+		// affinity 非负时把第affinity台服务器作为server_id
+		affinity      int
+		wantErr       error
+		wantGPUs      int
+		wantAllocated int
+	}{
+		{name: "binpack", req: CreateRequest{GPUCount: 2}, affinity: -1, wantGPUs: 2, wantAllocated: 2},
+		{name: "spread on one server", req: CreateRequest{GPUCount: 2, Strategy: scheduler.StrategySpread}, affinity: 1, wantGPUs: 2, wantAllocated: 2},
+		{name: "whole server", req: CreateRequest{}, affinity: 0},
+		{
+			// 跨服务器的放置被拒绝，事务回滚后GPU全部仍可用
+			name:     "spread across servers",
+			req:      CreateRequest{GPUCount: 2, Strategy: scheduler.StrategySpread},
+			affinity: -1,
+			wantErr:  ErrMultiServerPlacement,
+		},
+		{name: "insufficient capacity", req: CreateRequest{GPUCount: 3}, affinity: -1, wantErr: scheduler.ErrInsufficientCapacity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			bus := event.NewMemoryEventBus(event.MemoryConfig{Record: true}, nil)
+			defer bus.Close()
+			service := NewService(repos, bus, scheduler.NewScheduler(repos))
+			servers := newServers(t, repos, 2, 2)
+			ctx := context.Background()
+
+			req := tt.req
+			req.UserID = "user-1"
+			if tt.affinity >= 0 {
+				req.ServerID = servers[tt.affinity]
+			}
+			allocation, err := service.Create(ctx, &req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			allocations, listErr := repos.Allocations.List(ctx, repository.AllocationFilter{})
+			if listErr != nil {
+				t.Fatalf("failed to list allocations: %v", listErr)
+			}
+			allocated, listErr := repos.GPUs.List(ctx, repository.GPUFilter{Status: models.GPUStatusAllocated})
+			if listErr != nil {
+				t.Fatalf("failed to list gpus: %v", listErr)
+			}
+			if len(allocated) != tt.wantAllocated {
+				t.Errorf("allocated gpus = %d, want %d", len(allocated), tt.wantAllocated)
+			}
+			created := bus.PublishedTo(event.EventTypeAllocationCreated)
+
+			if tt.wantErr != nil {
+				// 失败时不留下分配，也不留下已占用但没有分配的GPU
+				if len(allocations) != 0 || len(created) != 0 {
+					t.Errorf("after failed Create() allocations = %d, created events = %d, want 0", len(allocations), len(created))
+				}
+				return
+			}
+			if len(allocations) != 1 || len(created) != 1 {
+				t.Fatalf("allocations = %d, created events = %d, want 1", len(allocations), len(created))
+			}
+			stored, err := repos.Allocations.Get(ctx, allocation.ID)
+			if err != nil {
+				t.Fatalf("failed to get allocation: %v", err)
+			}
+			if stored.Status != models.AllocationStatusPending || len(stored.GPUIDs) != tt.wantGPUs {
+				t.Errorf("allocation = %s with %d gpus, want %s with %d", stored.Status, len(stored.GPUIDs), models.AllocationStatusPending, tt.wantGPUs)
+			}
+			// 分配记录的服务器即所有GPU所在的服务器
+			for _, id := range stored.GPUIDs {
+				gpu, err := repos.GPUs.Get(ctx, id)
+				if err != nil {
+					t.Fatalf("failed to get gpu: %v", err)
+				}
+				if gpu.ServerID != stored.ServerID {
+					t.Errorf("gpu %s on server %s, allocation on %s", id, gpu.ServerID, stored.ServerID)
+				}
+			}
+		})
+	}
+}
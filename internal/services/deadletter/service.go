@@ -0,0 +1,144 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// ErrReplayFailed 重放时处理器再次返回错误，死信保持pending并记录新的错误
+var ErrReplayFailed = errors.New("dead letter replay failed")
+
+// ReplayRequest 批量重放条件，零值字段表示不过滤，只重放pending状态的死信
+type ReplayRequest struct {
+	Topic   string `json:"topic"`
+	Handler string `json:"handler"`
+}
+
+// ReplayResult 单条死信的重放结果
+type ReplayResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReplaySummary 批量重放结果
+type ReplaySummary struct {
+	Replayed int            `json:"replayed"`
+	Failed   int            `json:"failed"`
+	Results  []ReplayResult `json:"results"`
+}
+
+// Service 死信服务，负责重放和丢弃死信
+// 重放在当前实例上同步调用原处理器，处理器需要能够幂等地处理同一事件
+type Service struct {
+	repo     repository.DeadLetterRepository
+	eventBus event.EventBus
+}
+
+// NewService 创建死信服务
+func NewService(repos *repository.Repositories, eventBus event.EventBus) *Service {
+	return &Service{
+		repo:     repos.DeadLetters,
+		eventBus: eventBus,
+	}
+}
+
+// Replay 将死信交给原处理器重新处理，成功后标记为replayed
+func (s *Service) Replay(ctx context.Context, id, resolvedBy string) (*models.DeadLetter, error) {
+	letter, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.replay(ctx, letter, resolvedBy); err != nil {
+		return letter, err
+	}
+	return letter, nil
+}
+
+// ReplayAll 依次重放符合条件的pending死信，单条失败不影响其他死信
+func (s *Service) ReplayAll(ctx context.Context, req *ReplayRequest, resolvedBy string) (*ReplaySummary, error) {
+	letters, err := s.repo.List(ctx, repository.DeadLetterFilter{
+		Topic:    req.Topic,
+		Handler:  req.Handler,
+		Statuses: []string{models.DeadLetterStatusPending},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReplaySummary{Results: []ReplayResult{}}
+	for i := range letters {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		letter := &letters[i]
+		result := ReplayResult{ID: letter.ID}
+		if err := s.replay(ctx, letter, resolvedBy); err != nil {
+			summary.Failed++
+			result.Error = err.Error()
+		} else {
+			summary.Replayed++
+		}
+		result.Status = letter.Status
+		summary.Results = append(summary.Results, result)
+	}
+	return summary, nil
+}
+
+// Discard 丢弃死信，不再重放
+func (s *Service) Discard(ctx context.Context, id, resolvedBy string) (*models.DeadLetter, error) {
+	letter, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.DeadLetterTransitions.Validate("dead_letter", letter.ID, letter.Status, models.DeadLetterStatusDiscarded); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	letter.Status = models.DeadLetterStatusDiscarded
+	letter.ResolvedBy = resolvedBy
+	letter.ResolvedAt = &now
+	if err := s.repo.UpdateIfStatus(ctx, letter, models.DeadLetterStatusPending); err != nil {
+		return nil, err
+	}
+	return letter, nil
+}
+
+// replay 重放单条死信并保存结果，处理器失败时记录错误并返回ErrReplayFailed
+func (s *Service) replay(ctx context.Context, letter *models.DeadLetter, resolvedBy string) error {
+	if err := models.DeadLetterTransitions.Validate("dead_letter", letter.ID, letter.Status, models.DeadLetterStatusReplayed); err != nil {
+		return err
+	}
+
+	handleErr := s.eventBus.Replay(ctx, letter.Handler, letter.Payload)
+	// 本实例没有该处理器时不算一次重放
+	if errors.Is(handleErr, event.ErrHandlerNotFound) {
+		return handleErr
+	}
+
+	letter.Replays++
+	if handleErr != nil {
+		letter.Error = handleErr.Error()
+	} else {
+		now := time.Now().UTC()
+		letter.Status = models.DeadLetterStatusReplayed
+		letter.ResolvedBy = resolvedBy
+		letter.ResolvedAt = &now
+	}
+	if err := s.repo.UpdateIfStatus(ctx, letter, models.DeadLetterStatusPending); err != nil {
+		return err
+	}
+
+	if handleErr != nil {
+		return fmt.Errorf("%w: %s: %v", ErrReplayFailed, letter.ID, handleErr)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// errHandler 处理器返回的错误
+var errHandler = errors.New("downstream unavailable")
+
+// newTestService 创建死信服务，事件总线上登记名为ok和failing的处理器，返回ok处理器被调用的次数
+func newTestService(t *testing.T) (*Service, *repository.Repositories, *int) {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	bus := event.NewMemoryEventBus(event.MemoryConfig{}, repos.DeadLetters)
+	t.Cleanup(bus.Close)
+
+	handled := 0
+	handlers := map[string]event.EventHandler{
+		"ok":      func(ctx context.Context, data []byte) error { handled++; return nil },
+		"failing": func(ctx context.Context, data []byte) error { return errHandler },
+	}
+	for name, handler := range handlers {
+		if err := bus.Subscribe(context.Background(), "hardware."+name, handler, event.WithHandlerName(name)); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	}
+	return NewService(repos, bus), repos, &handled
+}
+
+// newLetter 创建一条指定处理器和状态的死信
+func newLetter(t *testing.T, repos *repository.Repositories, handler, status string) *models.DeadLetter {
+	t.Helper()
+	letter := &models.DeadLetter{
+		Topic:    "hardware." + handler,
+		Handler:  handler,
+		Payload:  json.RawMessage(`{"hardware_id":"server-1"}`),
+		Error:    "first failure",
+		Attempts: 5,
+		Status:   status,
+	}
+	if err := repos.DeadLetters.Create(context.Background(), letter); err != nil {
+		t.Fatalf("failed to create dead letter: %v", err)
+	}
+	return letter
+}
+
+func TestServiceReplay(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     string
+		status      string
+		wantErr     error
+		wantStatus  string
+		wantReplays int
+		wantError   string
+		wantHandled int
+	}{
+		{name: "handler succeeds", handler: "ok", status: models.DeadLetterStatusPending, wantStatus: models.DeadLetterStatusReplayed, wantReplays: 1, wantError: "first failure", wantHandled: 1},
+		{
+			// 处理器再次失败时保持pending并记录新的错误
+			name:        "handler fails again",
+			handler:     "failing",
+			status:      models.DeadLetterStatusPending,
+			wantErr:     ErrReplayFailed,
+			wantStatus:  models.DeadLetterStatusPending,
+			wantReplays: 1,
+			wantError:   errHandler.Error(),
+		},
+		{
+			// 本实例没有该处理器时不算一次重放
+			name:       "handler not on this instance",
+			handler:    "retired",
+			status:     models.DeadLetterStatusPending,
+			wantErr:    event.ErrHandlerNotFound,
+			wantStatus: models.DeadLetterStatusPending,
+			wantError:  "first failure",
+		},
+		{name: "already discarded", handler: "ok", status: models.DeadLetterStatusDiscarded, wantStatus: models.DeadLetterStatusDiscarded, wantError: "first failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repos, handled := newTestService(t)
+			letter := newLetter(t, repos, tt.handler, tt.status)
+
+			_, err := s.Replay(context.Background(), letter.ID, "alice")
+			if tt.status != models.DeadLetterStatusPending {
+				var transitionErr *models.TransitionError
+				if !errors.As(err, &transitionErr) {
+					t.Fatalf("Replay() error = %v, want a transition error", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Replay() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := repos.DeadLetters.Get(context.Background(), letter.ID)
+			if err != nil {
+				t.Fatalf("failed to get dead letter: %v", err)
+			}
+			if got.Status != tt.wantStatus || got.Replays != tt.wantReplays || got.Error != tt.wantError {
+				t.Errorf("dead letter status = %s, replays = %d, error = %q, want %s, %d, %q",
+					got.Status, got.Replays, got.Error, tt.wantStatus, tt.wantReplays, tt.wantError)
+			}
+			if resolved := got.ResolvedBy == "alice" && got.ResolvedAt != nil; resolved != (tt.wantStatus == models.DeadLetterStatusReplayed) {
+				t.Errorf("resolved by %q at %v", got.ResolvedBy, got.ResolvedAt)
+			}
+			if *handled != tt.wantHandled {
+				t.Errorf("handler called %d times, want %d", *handled, tt.wantHandled)
+			}
+		})
+	}
+}
+
+func TestServiceReplayAll(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          ReplayRequest
+		wantReplayed int
+		wantFailed   int
+	}{
+		{name: "all pending", wantReplayed: 2, wantFailed: 1},
+		{name: "by handler", req: ReplayRequest{Handler: "ok"}, wantReplayed: 2},
+		{name: "by topic", req: ReplayRequest{Topic: "hardware.failing"}, wantFailed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repos, handled := newTestService(t)
+			newLetter(t, repos, "ok", models.DeadLetterStatusPending)
+			newLetter(t, repos, "ok", models.DeadLetterStatusPending)
+			newLetter(t, repos, "failing", models.DeadLetterStatusPending)
+			// 已处理的死信不参与批量重放
+			newLetter(t, repos, "ok", models.DeadLetterStatusDiscarded)
+
+			summary, err := s.ReplayAll(context.Background(), &tt.req, "alice")
+			if err != nil {
+				t.Fatalf("ReplayAll() error = %v", err)
+			}
+			if summary.Replayed != tt.wantReplayed || summary.Failed != tt.wantFailed || len(summary.Results) != tt.wantReplayed+tt.wantFailed {
+				t.Errorf("summary = %+v, want %d replayed and %d failed", summary, tt.wantReplayed, tt.wantFailed)
+			}
+			for _, result := range summary.Results {
+				if (result.Error == "") != (result.Status == models.DeadLetterStatusReplayed) {
+					t.Errorf("result %+v: error and status disagree", result)
+				}
+			}
+			if *handled != tt.wantReplayed {
+				t.Errorf("handler called %d times, want %d", *handled, tt.wantReplayed)
+			}
+		})
+	}
+}
+
+func TestServiceDiscard(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		wantErr bool
+	}{
+		{name: "pending", status: models.DeadLetterStatusPending},
+		{name: "replayed", status: models.DeadLetterStatusReplayed, wantErr: true},
+		{name: "discarded", status: models.DeadLetterStatusDiscarded, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repos, _ := newTestService(t)
+			letter := newLetter(t, repos, "ok", tt.status)
+
+			_, err := s.Discard(context.Background(), letter.ID, "alice")
+			var transitionErr *models.TransitionError
+			if errors.As(err, &transitionErr) != tt.wantErr {
+				t.Fatalf("Discard() error = %v, want transition error %v", err, tt.wantErr)
+			}
+			got, err := repos.DeadLetters.Get(context.Background(), letter.ID)
+			if err != nil {
+				t.Fatalf("failed to get dead letter: %v", err)
+			}
+			if tt.wantErr {
+				if got.Status != tt.status {
+					t.Errorf("status = %s, want %s unchanged", got.Status, tt.status)
+				}
+				return
+			}
+			if got.Status != models.DeadLetterStatusDiscarded || got.ResolvedBy != "alice" || got.ResolvedAt == nil {
+				t.Errorf("dead letter = %+v, want discarded by alice", got)
+			}
+			// 丢弃后不能再重放
+			if _, err := s.Replay(context.Background(), letter.ID, "alice"); !errors.As(err, &transitionErr) {
+				t.Errorf("Replay() after Discard() error = %v, want a transition error", err)
+			}
+		})
+	}
+}
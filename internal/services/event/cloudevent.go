@@ -0,0 +1,120 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gpu-management/pkg/uuid"
+)
+
+// CloudEvents 1.0 信封常量
+const (
+	CloudEventsSpecVersion = "1.0"
+	ContentTypeJSON        = "application/json"
+)
+
+// ErrInvalidEvent 发布的内容不是合法的CloudEvents信封，或与发布主题不一致
+var ErrInvalidEvent = errors.New("invalid cloud event")
+
+// CloudEvent CloudEvents 1.0 结构化JSON格式的事件信封，所有发布的事件都使用该格式
+// 主题与type一致，id在重试和重放之间保持不变，消费者可据此去重
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewCloudEvent 创建事件信封，data按该类型最新版本的结构定义校验
+// source标识产生事件的组件，subject为事件涉及的资源ID
+func NewCloudEvent(source, eventType, subject string, data interface{}) (*CloudEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s data: %w", eventType, err)
+	}
+
+	schema, err := Schemas.Latest(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	ce := &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              uuid.New(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: ContentTypeJSON,
+		DataSchema:      schema.URI,
+		Data:            raw,
+	}
+	if err := Schemas.Validate(ce); err != nil {
+		return nil, err
+	}
+	return ce, nil
+}
+
+// ParseCloudEvent 解析处理器收到的事件，不校验data
+func ParseCloudEvent(data []byte) (*CloudEvent, error) {
+	var ce CloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if err := ce.validateEnvelope(); err != nil {
+		return nil, err
+	}
+	return &ce, nil
+}
+
+// DecodeData 将data反序列化到v
+func (e *CloudEvent) DecodeData(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// validateEnvelope 校验CloudEvents必填属性
+func (e *CloudEvent) validateEnvelope() error {
+	switch {
+	case e.SpecVersion != CloudEventsSpecVersion:
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEvent, e.SpecVersion)
+	case e.ID == "":
+		return fmt.Errorf("%w: id is required", ErrInvalidEvent)
+	case e.Source == "":
+		return fmt.Errorf("%w: source is required", ErrInvalidEvent)
+	case e.Type == "":
+		return fmt.Errorf("%w: type is required", ErrInvalidEvent)
+	case e.Time.IsZero():
+		return fmt.Errorf("%w: time is required", ErrInvalidEvent)
+	case e.DataContentType != ContentTypeJSON:
+		return fmt.Errorf("%w: unsupported datacontenttype %q", ErrInvalidEvent, e.DataContentType)
+	}
+	return nil
+}
+
+// encodeEvent 序列化要发布的事件，内容必须是与主题一致且符合结构定义的CloudEvents信封
+// 所有事件总线实现在发布前调用，保证外部消费者只会收到符合契约的事件
+func encodeEvent(topic string, event interface{}) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var ce CloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if ce.Type != topic {
+		return nil, fmt.Errorf("%w: type %q does not match topic %q", ErrInvalidEvent, ce.Type, topic)
+	}
+	if err := Schemas.Validate(&ce); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,132 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// 死信相关错误
+var (
+	// ErrHandlerNotFound 本实例没有注册该名称的处理器
+	ErrHandlerNotFound = errors.New("event handler not registered")
+	// ErrDuplicateHandler 同一事件总线上处理器名称重复
+	ErrDuplicateHandler = errors.New("event handler already registered")
+)
+
+// deadLetterWriteTimeout 写入死信的超时，与消息处理的Context无关
+const deadLetterWriteTimeout = 10 * time.Second
+
+// DeadLetterStore 死信存储，repository.DeadLetterRepository实现该接口
+type DeadLetterStore interface {
+	Create(ctx context.Context, letter *models.DeadLetter) error
+}
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	name      string
+	broadcast bool
+}
+
+// WithHandlerName 设置处理器名称，死信按名称记录和重放，同一主题有多个处理器时必须设置
+// 未设置时使用主题作为名称
+func WithHandlerName(name string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.name = name
+	}
+}
+
+// Broadcast 以广播方式订阅：每个服务实例都收到全部消息，只投递订阅之后发布的消息，
+// 不保存消费进度，处理失败时只记录日志，不重试也不写入死信，适合推送实时事件等可丢失的场景
+// 广播处理器不登记名称，不能通过Replay重放
+func Broadcast() SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.broadcast = true
+	}
+}
+
+// newSubscribeOptions 应用订阅选项
+func newSubscribeOptions(topic string, opts []SubscribeOption) subscribeOptions {
+	o := subscribeOptions{name: topic}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// handlerRegistry 按名称登记处理器，用于重放死信
+type handlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]EventHandler
+}
+
+// register 登记处理器，名称重复时返回ErrDuplicateHandler
+func (r *handlerRegistry) register(name string, handler EventHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.handlers == nil {
+		r.handlers = map[string]EventHandler{}
+	}
+	if _, exists := r.handlers[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateHandler, name)
+	}
+	r.handlers[name] = handler
+	return nil
+}
+
+// unregister 注销处理器，订阅取消后同名处理器可以重新登记
+func (r *handlerRegistry) unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.handlers, name)
+}
+
+// replay 将事件交给指定名称的处理器
+func (r *handlerRegistry) replay(ctx context.Context, name string, event []byte) error {
+	r.mu.RLock()
+	handler, ok := r.handlers[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHandlerNotFound, name)
+	}
+	return handler(ctx, event)
+}
+
+// recordDeadLetter 记录处理失败的事件，未配置死信存储或写入失败时只记录日志
+func recordDeadLetter(store DeadLetterStore, topic, handler string, data []byte, attempts int, handleErr error) {
+	if store == nil {
+		return
+	}
+
+	// 非JSON内容按字符串保存，保证payload列可写入
+	payload := json.RawMessage(data)
+	if !json.Valid(data) {
+		payload, _ = json.Marshal(string(data))
+	}
+
+	letter := &models.DeadLetter{
+		Topic:    topic,
+		Handler:  handler,
+		Payload:  payload,
+		Error:    handleErr.Error(),
+		Attempts: attempts,
+		Status:   models.DeadLetterStatusPending,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterWriteTimeout)
+	defer cancel()
+	if err := store.Create(ctx, letter); err != nil {
+		log.Printf("Failed to record dead letter for handler %s on topic %s: %v", handler, topic, err)
+		return
+	}
+	log.Printf("Recorded dead letter %s for handler %s on topic %s", letter.ID, handler, topic)
+}
@@ -2,8 +2,7 @@ package event
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"time"
 
@@ -12,33 +11,63 @@ import (
 
 // EventBus 事件总线接口
 type EventBus interface {
-	Publish(ctx context.Context, topic string, event interface{}) error
-	Subscribe(ctx context.Context, topic string, handler EventHandler) error
+	// Publish 发布事件，event必须是type与topic一致且data符合结构定义的CloudEvent，否则返回错误
+	Publish(ctx context.Context, topic string, event interface{}, opts ...PublishOption) error
+	// Subscribe 订阅事件，处理最终失败的事件写入死信存储；ctx结束时取消订阅并注销处理器
+	Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error
+	// Replay 将事件直接交给本实例上指定名称的处理器，用于重放死信
+	Replay(ctx context.Context, handler string, event []byte) error
 	Close()
 }
 
 // EventHandler 事件处理器接口
 type EventHandler func(ctx context.Context, event []byte) error
 
+// PublishOption 发布选项
+type PublishOption func(*publishOptions)
+
+type publishOptions struct {
+	messageID string
+}
+
+// WithMessageID 设置消息ID，JetStream在去重窗口内丢弃相同ID的重复发布
+func WithMessageID(id string) PublishOption {
+	return func(o *publishOptions) {
+		o.messageID = id
+	}
+}
+
+// newPublishOptions 应用发布选项
+func newPublishOptions(opts []PublishOption) publishOptions {
+	var o publishOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // NATSEventBus NATS事件总线实现
 type NATSEventBus struct {
-	conn *nats.Conn
+	conn        *nats.Conn
+	deadLetters DeadLetterStore
+	handlers    handlerRegistry
 }
 
-// NewEventBus 创建新的事件总线
-func NewEventBus(natsURL string) EventBus {
+// NewEventBus 创建新的事件总线，处理失败的事件不重试，直接写入deadLetters
+func NewEventBus(natsURL string, deadLetters DeadLetterStore) EventBus {
 	conn, err := nats.Connect(natsURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to NATS: %v", err)
 	}
 
 	return &NATSEventBus{
-		conn: conn,
+		conn:        conn,
+		deadLetters: deadLetters,
 	}
 }
 
 // Publish 发布事件
-func (e *NATSEventBus) Publish(ctx context.Context, topic string, event interface{}) error {
+func (e *NATSEventBus) Publish(ctx context.Context, topic string, event interface{}, opts ...PublishOption) error {
 	// 检查Context是否已取消
 	select {
 	case <-ctx.Done():
@@ -46,9 +75,9 @@ func (e *NATSEventBus) Publish(ctx context.Context, topic string, event interfac
 	default:
 	}
 
-	data, err := json.Marshal(event)
+	data, err := encodeEvent(topic, event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return err
 	}
 
 	// 使用Context控制发布超时
@@ -61,18 +90,55 @@ func (e *NATSEventBus) Publish(ctx context.Context, topic string, event interfac
 }
 
 // Subscribe 订阅事件
-func (e *NATSEventBus) Subscribe(ctx context.Context, topic string, handler EventHandler) error {
-	_, err := e.conn.Subscribe(topic, func(msg *nats.Msg) {
+func (e *NATSEventBus) Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error {
+	options := newSubscribeOptions(topic, opts)
+	if !options.broadcast {
+		if err := e.handlers.register(options.name, handler); err != nil {
+			return err
+		}
+	}
+
+	sub, err := e.conn.Subscribe(topic, func(msg *nats.Msg) {
 		// 为每个消息创建Context
 		msgCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
 
 		if err := handler(msgCtx, msg.Data); err != nil {
 			log.Printf("Error handling event on topic %s: %v", topic, err)
+			if !options.broadcast {
+				recordDeadLetter(e.deadLetters, msg.Subject, options.name, msg.Data, 1, err)
+			}
 		}
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	unsubscribeOnDone(ctx, sub, &e.handlers, options)
+	return nil
+}
+
+// unsubscribeOnDone 在ctx结束时取消NATS订阅并注销处理器，ctx不会结束时不做任何事
+// 连接已关闭时订阅已随连接失效，不再记录错误
+func unsubscribeOnDone(ctx context.Context, sub *nats.Subscription, handlers *handlerRegistry, options subscribeOptions) {
+	if ctx.Done() == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		err := sub.Unsubscribe()
+		if err != nil && !errors.Is(err, nats.ErrConnectionClosed) && !errors.Is(err, nats.ErrBadSubscription) {
+			log.Printf("Failed to unsubscribe from %s: %v", sub.Subject, err)
+		}
+		if !options.broadcast {
+			handlers.unregister(options.name)
+		}
+	}()
+}
+
+// Replay 将事件交给指定名称的处理器重新处理
+func (e *NATSEventBus) Replay(ctx context.Context, handler string, event []byte) error {
+	return e.handlers.replay(ctx, handler, event)
 }
 
 // Close 关闭连接
@@ -82,28 +148,74 @@ func (e *NATSEventBus) Close() {
 	}
 }
 
-// Event 基础事件结构
-type Event struct {
-	ID        string                 `json:"id"`
-	Type      string                 `json:"type"`
-	Source    string                 `json:"source"`
-	Data      map[string]interface{} `json:"data"`
-	Timestamp int64                  `json:"timestamp"`
-}
+// 以下为各类事件的data结构，字段与schemas目录下的结构定义保持一致
 
 // HardwareEvent 硬件事件
 type HardwareEvent struct {
-	Event
 	HardwareID string `json:"hardware_id"`
 	Status     string `json:"status"`
 }
 
-// AlertEvent 告警事件
+// GPUConfigEvent GPU配置更新事件
+type GPUConfigEvent struct {
+	GPUID     string `json:"gpu_id"`
+	ConfigID  string `json:"config_id"`
+	Version   string `json:"version"`
+	CreatedBy string `json:"created_by"`
+}
+
+// AlertEvent 告警事件，携带状态变化后的告警快照
 type AlertEvent struct {
-	Event
-	AlertID  string `json:"alert_id"`
-	Severity string `json:"severity"`
+	AlertID         string            `json:"alert_id"`
+	AlertType       string            `json:"alert_type"`
+	Severity        string            `json:"severity"`
+	Message         string            `json:"message"`
+	Status          string            `json:"status"`
+	SourceType      string            `json:"source_type"`
+	SourceID        string            `json:"source_id"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	EscalationLevel int               `json:"escalation_level"`
+	// SilenceID 告警被静默时为匹配的静默规则，通知方据此跳过
+	SilenceID string `json:"silence_id,omitempty"`
+	// Actor 确认或解除告警的用户，自动产生的变化为空
+	Actor string `json:"actor,omitempty"`
+}
+
+// AllocationEvent 资源分配事件
+type AllocationEvent struct {
+	AllocationID string `json:"allocation_id"`
+	ServerID     string `json:"server_id"`
+	UserID       string `json:"user_id"`
+	FromStatus   string `json:"from_status"`
+	ToStatus     string `json:"to_status"`
+}
+
+// TaskEvent 异步任务事件
+type TaskEvent struct {
+	TaskID     string `json:"task_id"`
+	TaskType   string `json:"task_type"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// WorkflowEvent Tinkerbell工作流状态变化事件，workflow_id为工作流CRD映射记录
+type WorkflowEvent struct {
+	WorkflowID   string `json:"workflow_id"`
+	TinkerbellID string `json:"tinkerbell_id"`
+	HardwareID   string `json:"hardware_id"`
+	TemplateID   string `json:"template_id"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+// AnnotationEvent 时间线注释事件，server_id或gpu_id非空时出现在对应的时间线上
+type AnnotationEvent struct {
 	Message  string `json:"message"`
+	Author   string `json:"author,omitempty"`
+	ServerID string `json:"server_id,omitempty"`
+	GPUID    string `json:"gpu_id,omitempty"`
 }
 
 // EventType 事件类型常量
@@ -111,6 +223,21 @@ const (
 	EventTypeHardwareDiscovered  = "hardware.discovered"
 	EventTypeHardwareProvisioned = "hardware.provisioned"
 	EventTypeHardwareFailed      = "hardware.failed"
+	EventTypeGPUConfigUpdated    = "gpu.config.updated"
 	EventTypeAlertRaised         = "alert.raised"
+	EventTypeAlertAcknowledged   = "alert.acknowledged"
+	EventTypeAlertEscalated      = "alert.escalated"
 	EventTypeAlertResolved       = "alert.resolved"
+	EventTypeAllocationCreated   = "allocation.created"
+	EventTypeAllocationActivated = "allocation.activated"
+	EventTypeAllocationCompleted = "allocation.completed"
+	EventTypeAllocationFailed    = "allocation.failed"
+	EventTypeAllocationRequeued  = "allocation.requeued"
+	EventTypeAllocationReleased  = "allocation.released"
+	EventTypeTaskSucceeded       = "task.succeeded"
+	EventTypeTaskFailed          = "task.failed"
+	EventTypeWorkflowStarted     = "workflow.started"
+	EventTypeWorkflowCompleted   = "workflow.completed"
+	EventTypeWorkflowFailed      = "workflow.failed"
+	EventTypeAnnotationCreated   = "annotation.created"
 )
@@ -0,0 +1,340 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// JetStreamConfig JetStream事件总线配置
+type JetStreamConfig struct {
+	// Stream 事件流名称，启动时不存在则创建，存在则按配置更新
+	Stream string
+	// Subjects 事件流收录的主题，发布到其他主题会失败
+	Subjects []string
+	// MaxAge 事件在流中保留的最长时间
+	MaxAge time.Duration
+	// Consumer 持久消费者名称前缀，同名的多个实例作为队列组分担消息
+	Consumer string
+	// AckWait 处理单条消息的最长时间，超时未确认由服务端重新投递
+	AckWait time.Duration
+	// MaxDeliver 单条消息的最大投递次数，达到后不再重试
+	MaxDeliver int
+	// Backoff 处理失败后第N次重新投递前的等待时间，次数超过列表长度时使用最后一项
+	Backoff []time.Duration
+}
+
+// defaultSubjects 默认收录的事件主题
+var defaultSubjects = []string{"hardware.>", "alert.>", "allocation.>", "task.>", "gpu.>", "server.>", "workflow.>", "annotation.>"}
+
+// JetStream配置默认值
+const (
+	defaultStream     = "GPU_EVENTS"
+	defaultConsumer   = "gpu-management"
+	defaultMaxAge     = 72 * time.Hour
+	defaultAckWait    = 30 * time.Second
+	defaultMaxDeliver = 5
+	// publishTimeout 发布时Context没有截止时间时等待服务端确认的时长
+	publishTimeout = 5 * time.Second
+	// drainTimeout 关闭时等待处理中消息完成的最长时间
+	drainTimeout = 30 * time.Second
+)
+
+// withDefaults 补全未设置的配置项
+func (c JetStreamConfig) withDefaults() JetStreamConfig {
+	if c.Stream == "" {
+		c.Stream = defaultStream
+	}
+	if len(c.Subjects) == 0 {
+		c.Subjects = defaultSubjects
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaultMaxAge
+	}
+	if c.Consumer == "" {
+		c.Consumer = defaultConsumer
+	}
+	if c.AckWait <= 0 {
+		c.AckWait = defaultAckWait
+	}
+	if c.MaxDeliver <= 0 {
+		c.MaxDeliver = defaultMaxDeliver
+	}
+	if len(c.Backoff) == 0 {
+		c.Backoff = []time.Duration{time.Second}
+	}
+	return c
+}
+
+// JetStreamEventBus 基于NATS JetStream的事件总线实现
+// 发布等待服务端持久化确认，订阅使用持久消费者，消费者离线期间的事件在恢复后补投
+type JetStreamEventBus struct {
+	conn        *nats.Conn
+	js          nats.JetStreamContext
+	config      JetStreamConfig
+	closed      chan struct{}
+	deadLetters DeadLetterStore
+	handlers    handlerRegistry
+}
+
+// NewJetStreamEventBus 连接NATS并创建或更新事件流，重试耗尽的事件写入deadLetters
+func NewJetStreamEventBus(natsURL string, config JetStreamConfig, deadLetters DeadLetterStore) (*JetStreamEventBus, error) {
+	config = config.withDefaults()
+	closed := make(chan struct{})
+
+	conn, err := nats.Connect(natsURL,
+		nats.Name(config.Consumer),
+		nats.MaxReconnects(-1),
+		nats.DrainTimeout(drainTimeout),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("NATS disconnected: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Printf("NATS reconnected to %s", conn.ConnectedUrl())
+		}),
+		nats.ClosedHandler(func(*nats.Conn) {
+			close(closed)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream context: %w", err)
+	}
+
+	bus := &JetStreamEventBus{
+		conn:        conn,
+		js:          js,
+		config:      config,
+		closed:      closed,
+		deadLetters: deadLetters,
+	}
+	if err := bus.ensureStream(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return bus, nil
+}
+
+// ensureStream 创建事件流，已存在时同步主题和保留时间
+func (e *JetStreamEventBus) ensureStream() error {
+	cfg := &nats.StreamConfig{
+		Name:      e.config.Stream,
+		Subjects:  e.config.Subjects,
+		Retention: nats.LimitsPolicy,
+		Storage:   nats.FileStorage,
+		MaxAge:    e.config.MaxAge,
+	}
+
+	_, err := e.js.StreamInfo(e.config.Stream)
+	switch {
+	case errors.Is(err, nats.ErrStreamNotFound):
+		_, err = e.js.AddStream(cfg)
+	case err == nil:
+		_, err = e.js.UpdateStream(cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to provision stream %s: %w", e.config.Stream, err)
+	}
+	return nil
+}
+
+// Publish 发布事件，服务端确认写入事件流后返回
+// 设置了WithMessageID时，相同ID的重复发布在去重窗口内只保留一条
+func (e *JetStreamEventBus) Publish(ctx context.Context, topic string, event interface{}, opts ...PublishOption) error {
+	// 检查Context是否已取消
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	data, err := encodeEvent(topic, event)
+	if err != nil {
+		return err
+	}
+
+	// 没有截止时间时限制等待确认的时长，避免服务端不可用时无限阻塞
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, publishTimeout)
+		defer cancel()
+	}
+
+	pubOpts := []nats.PubOpt{nats.Context(ctx)}
+	if id := newPublishOptions(opts).messageID; id != "" {
+		pubOpts = append(pubOpts, nats.MsgId(id))
+	}
+	if _, err := e.js.Publish(topic, data, pubOpts...); err != nil {
+		return fmt.Errorf("failed to publish event to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe 订阅事件
+// 每个处理器对应一个持久消费者，handler返回nil时确认消息，返回错误时按Backoff延迟重新投递，
+// 达到MaxDeliver后终止该消息并写入死信；使用Broadcast选项时改为临时消费者，见subscribeBroadcast
+func (e *JetStreamEventBus) Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error {
+	// 检查Context是否已取消
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	options := newSubscribeOptions(topic, opts)
+	if options.broadcast {
+		return e.subscribeBroadcast(ctx, topic, handler, options)
+	}
+	durable := e.durableName(options.name)
+	if err := e.ensureConsumer(topic, durable); err != nil {
+		return err
+	}
+	if err := e.handlers.register(options.name, handler); err != nil {
+		return err
+	}
+
+	sub, err := e.js.QueueSubscribe(topic, durable, func(msg *nats.Msg) {
+		e.handle(ctx, options.name, msg, handler)
+	}, nats.Bind(e.config.Stream, durable), nats.ManualAck())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	// 绑定的持久消费者不是由订阅创建的，取消订阅时保留
+	unsubscribeOnDone(ctx, sub, &e.handlers, options)
+	return nil
+}
+
+// subscribeBroadcast 使用临时消费者订阅，只接收订阅之后写入的消息且不需要确认
+// 临时消费者在ctx结束、连接断开或总线关闭后删除
+func (e *JetStreamEventBus) subscribeBroadcast(ctx context.Context, topic string, handler EventHandler, options subscribeOptions) error {
+	sub, err := e.js.Subscribe(topic, func(msg *nats.Msg) {
+		msgCtx, cancel := context.WithTimeout(ctx, e.config.AckWait)
+		defer cancel()
+
+		if err := handler(msgCtx, msg.Data); err != nil {
+			log.Printf("Error handling broadcast event on topic %s: %v", msg.Subject, err)
+		}
+	}, nats.BindStream(e.config.Stream), nats.DeliverNew(), nats.AckNone())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+	unsubscribeOnDone(ctx, sub, &e.handlers, options)
+	return nil
+}
+
+// durableName 由消费者前缀和处理器名称生成持久消费者名称，名称中不能包含 . * >
+func (e *JetStreamEventBus) durableName(handler string) string {
+	name := e.config.Consumer + "_" + handler
+	return strings.NewReplacer(".", "_", "*", "any", ">", "all", " ", "_").Replace(name)
+}
+
+// ensureConsumer 创建持久消费者，已存在时同步过滤主题、确认超时和最大投递次数
+// 消费者由总线显式创建再绑定订阅，关闭连接时不会被删除，离线期间的消息得以保留
+func (e *JetStreamEventBus) ensureConsumer(topic, durable string) error {
+	info, err := e.js.ConsumerInfo(e.config.Stream, durable)
+	switch {
+	case errors.Is(err, nats.ErrConsumerNotFound):
+		_, err = e.js.AddConsumer(e.config.Stream, &nats.ConsumerConfig{
+			Durable:        durable,
+			DeliverSubject: "_DELIVER." + e.config.Stream + "." + durable,
+			DeliverGroup:   durable,
+			// 首次创建只投递新事件，之后从消费者记录的位置继续
+			DeliverPolicy: nats.DeliverNewPolicy,
+			AckPolicy:     nats.AckExplicitPolicy,
+			AckWait:       e.config.AckWait,
+			MaxDeliver:    e.config.MaxDeliver,
+			FilterSubject: topic,
+		})
+	case err == nil:
+		cfg := info.Config
+		// 处理器改为订阅其他主题时，沿用旧的过滤主题会继续收到旧主题的消息
+		cfg.FilterSubject = topic
+		cfg.FilterSubjects = nil
+		cfg.AckWait = e.config.AckWait
+		cfg.MaxDeliver = e.config.MaxDeliver
+		_, err = e.js.UpdateConsumer(e.config.Stream, &cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to provision consumer %s: %w", durable, err)
+	}
+	return nil
+}
+
+// handle 处理单条消息并根据处理结果确认、延迟重投或终止
+func (e *JetStreamEventBus) handle(ctx context.Context, name string, msg *nats.Msg, handler EventHandler) {
+	topic := msg.Subject
+
+	// 为每个消息创建Context，超过AckWait后服务端会重新投递
+	msgCtx, cancel := context.WithTimeout(ctx, e.config.AckWait)
+	defer cancel()
+
+	delivered := 1
+	if meta, err := msg.Metadata(); err == nil {
+		delivered = int(meta.NumDelivered)
+	}
+
+	err := handler(msgCtx, msg.Data)
+	if err == nil {
+		if ackErr := msg.Ack(); ackErr != nil {
+			log.Printf("Failed to ack event on topic %s: %v", topic, ackErr)
+		}
+		return
+	}
+
+	if delivered >= e.config.MaxDeliver {
+		log.Printf("Giving up event on topic %s after %d deliveries: %v", topic, delivered, err)
+		recordDeadLetter(e.deadLetters, topic, name, msg.Data, delivered, err)
+		if termErr := msg.Term(); termErr != nil {
+			log.Printf("Failed to terminate event on topic %s: %v", topic, termErr)
+		}
+		return
+	}
+
+	delay := e.backoff(delivered)
+	log.Printf("Error handling event on topic %s (delivery %d/%d), retrying in %s: %v",
+		topic, delivered, e.config.MaxDeliver, delay, err)
+	if nakErr := msg.NakWithDelay(delay); nakErr != nil {
+		log.Printf("Failed to nak event on topic %s: %v", topic, nakErr)
+	}
+}
+
+// Replay 将事件交给指定名称的处理器重新处理
+func (e *JetStreamEventBus) Replay(ctx context.Context, handler string, event []byte) error {
+	return e.handlers.replay(ctx, handler, event)
+}
+
+// backoff 返回第delivered次投递失败后的重投等待时间
+func (e *JetStreamEventBus) backoff(delivered int) time.Duration {
+	i := delivered - 1
+	if i >= len(e.config.Backoff) {
+		i = len(e.config.Backoff) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return e.config.Backoff[i]
+}
+
+// Close 停止接收新消息，等待处理中的消息确认后关闭连接
+func (e *JetStreamEventBus) Close() {
+	if e.conn == nil {
+		return
+	}
+	if err := e.conn.Drain(); err != nil {
+		e.conn.Close()
+		return
+	}
+	<-e.closed
+}
@@ -0,0 +1,344 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBusClosed 事件总线已关闭
+var ErrBusClosed = errors.New("event bus is closed")
+
+// memoryHandlerTimeout 单个处理器处理一条消息的超时，与NATS实现一致
+const memoryHandlerTimeout = 30 * time.Second
+
+// MemoryConfig 内存事件总线配置
+type MemoryConfig struct {
+	// Async 异步投递：每个订阅有独立的队列和goroutine，按发布顺序处理
+	// 同步投递时Publish在返回前依次调用所有匹配的处理器
+	Async bool
+	// Buffer 异步投递时每个订阅的队列长度，队列满时Publish阻塞直到有空位或Context结束
+	Buffer int
+	// Record 记录所有发布的消息供Published和PublishedTo断言，记录不会被清理，长期运行的服务不应开启
+	Record bool
+}
+
+// PublishedMessage 内存事件总线记录的已发布消息，用于测试断言
+type PublishedMessage struct {
+	Topic     string
+	Data      []byte
+	MessageID string
+	Time      time.Time
+}
+
+// Decode 将消息内容反序列化到v
+func (m PublishedMessage) Decode(v interface{}) error {
+	return json.Unmarshal(m.Data, v)
+}
+
+// memorySubscription 内存事件总线上的一个订阅
+type memorySubscription struct {
+	pattern   string
+	name      string
+	broadcast bool
+	ctx       context.Context
+	handler   EventHandler
+	queue     chan PublishedMessage
+}
+
+// MemoryEventBus 进程内事件总线，按NATS主题通配规则（* 匹配一段，> 匹配剩余所有段）路由消息
+// 开启Record时记录所有发布的消息供测试断言，处理失败的事件不重试，直接写入死信
+type MemoryEventBus struct {
+	config      MemoryConfig
+	deadLetters DeadLetterStore
+	handlers    handlerRegistry
+
+	// mu 异步投递的Publish持有读锁直到入队完成，Close持有写锁关闭队列，避免向已关闭的队列发送
+	mu     sync.RWMutex
+	subs   []*memorySubscription
+	closed bool
+
+	// closing Close开始时关闭，唤醒阻塞在已满队列上的Publish，使其释放读锁
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	recordMu  sync.Mutex
+	published []PublishedMessage
+
+	// pending 已入队但未处理完的异步消息数，Flush等待其归零
+	pendingMu sync.Mutex
+	pendingCV *sync.Cond
+	pending   int
+
+	wg sync.WaitGroup
+}
+
+// NewMemoryEventBus 创建内存事件总线，deadLetters为nil时处理失败只记录日志
+func NewMemoryEventBus(config MemoryConfig, deadLetters DeadLetterStore) *MemoryEventBus {
+	if config.Buffer <= 0 {
+		config.Buffer = 256
+	}
+	bus := &MemoryEventBus{
+		config:      config,
+		deadLetters: deadLetters,
+		closing:     make(chan struct{}),
+	}
+	bus.pendingCV = sync.NewCond(&bus.pendingMu)
+	return bus
+}
+
+// Publish 发布事件并投递给所有匹配的订阅
+func (e *MemoryEventBus) Publish(ctx context.Context, topic string, event interface{}, opts ...PublishOption) error {
+	// 检查Context是否已取消
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	data, err := encodeEvent(topic, event)
+	if err != nil {
+		return err
+	}
+	msg := PublishedMessage{
+		Topic:     topic,
+		Data:      data,
+		MessageID: newPublishOptions(opts).messageID,
+		Time:      time.Now().UTC(),
+	}
+
+	e.mu.RLock()
+	if e.closed {
+		e.mu.RUnlock()
+		return ErrBusClosed
+	}
+	e.record(msg)
+	var matched []*memorySubscription
+	for _, sub := range e.subs {
+		if MatchSubject(sub.pattern, topic) {
+			matched = append(matched, sub)
+		}
+	}
+
+	if !e.config.Async {
+		// 同步投递在锁外调用处理器，处理器中可以再次发布
+		e.mu.RUnlock()
+		for _, sub := range matched {
+			e.deliver(sub, msg)
+		}
+		return nil
+	}
+
+	defer e.mu.RUnlock()
+	for _, sub := range matched {
+		e.addPending(1)
+		select {
+		case sub.queue <- msg:
+		case <-e.closing:
+			e.addPending(-1)
+			return ErrBusClosed
+		case <-ctx.Done():
+			e.addPending(-1)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// record 开启Record时记录已发布的消息
+func (e *MemoryEventBus) record(msg PublishedMessage) {
+	if !e.config.Record {
+		return
+	}
+	e.recordMu.Lock()
+	e.published = append(e.published, msg)
+	e.recordMu.Unlock()
+}
+
+// Subscribe 订阅事件，topic支持 * 和 > 通配，ctx结束时取消订阅
+func (e *MemoryEventBus) Subscribe(ctx context.Context, topic string, handler EventHandler, opts ...SubscribeOption) error {
+	// 检查Context是否已取消
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	options := newSubscribeOptions(topic, opts)
+	sub := &memorySubscription{
+		pattern:   topic,
+		name:      options.name,
+		broadcast: options.broadcast,
+		ctx:       ctx,
+		handler:   handler,
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrBusClosed
+	}
+	if !options.broadcast {
+		if err := e.handlers.register(options.name, handler); err != nil {
+			return err
+		}
+	}
+	if e.config.Async {
+		sub.queue = make(chan PublishedMessage, e.config.Buffer)
+		e.wg.Add(1)
+		go e.consume(sub)
+	}
+	e.subs = append(e.subs, sub)
+	if ctx.Done() != nil {
+		e.wg.Add(1)
+		go e.unsubscribeOnDone(sub)
+	}
+	return nil
+}
+
+// unsubscribeOnDone 在订阅的ctx结束时移除订阅、关闭其队列并注销处理器，总线关闭时直接退出
+// 队列中剩余的消息仍由consume处理完，处理器收到的Context已结束
+func (e *MemoryEventBus) unsubscribeOnDone(sub *memorySubscription) {
+	defer e.wg.Done()
+	select {
+	case <-sub.ctx.Done():
+	case <-e.closing:
+		return
+	}
+
+	// 写锁等待进行中的入队结束，之后的Publish不会再匹配到该订阅
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return
+	}
+	e.subs = slices.DeleteFunc(e.subs, func(s *memorySubscription) bool { return s == sub })
+	if sub.queue != nil {
+		close(sub.queue)
+	}
+	if !sub.broadcast {
+		e.handlers.unregister(sub.name)
+	}
+}
+
+// consume 异步订阅的处理循环，队列关闭后退出
+func (e *MemoryEventBus) consume(sub *memorySubscription) {
+	defer e.wg.Done()
+	for msg := range sub.queue {
+		e.deliver(sub, msg)
+		e.addPending(-1)
+	}
+}
+
+// deliver 调用处理器，失败时写入死信，广播订阅只记录日志
+func (e *MemoryEventBus) deliver(sub *memorySubscription, msg PublishedMessage) {
+	// 为每个消息创建Context
+	ctx, cancel := context.WithTimeout(sub.ctx, memoryHandlerTimeout)
+	defer cancel()
+
+	if err := sub.handler(ctx, msg.Data); err != nil {
+		log.Printf("Error handling event on topic %s: %v", msg.Topic, err)
+		if !sub.broadcast {
+			recordDeadLetter(e.deadLetters, msg.Topic, sub.name, msg.Data, 1, err)
+		}
+	}
+}
+
+// addPending 调整未处理完的异步消息数
+func (e *MemoryEventBus) addPending(delta int) {
+	e.pendingMu.Lock()
+	e.pending += delta
+	if e.pending == 0 {
+		e.pendingCV.Broadcast()
+	}
+	e.pendingMu.Unlock()
+}
+
+// Flush 等待已发布的异步消息全部处理完，同步投递时立即返回
+func (e *MemoryEventBus) Flush() {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	for e.pending > 0 {
+		e.pendingCV.Wait()
+	}
+}
+
+// Replay 将事件交给指定名称的处理器重新处理
+func (e *MemoryEventBus) Replay(ctx context.Context, handler string, event []byte) error {
+	return e.handlers.replay(ctx, handler, event)
+}
+
+// Published 返回所有已发布消息的副本，按发布顺序排列，未开启Record时为空
+func (e *MemoryEventBus) Published() []PublishedMessage {
+	e.recordMu.Lock()
+	defer e.recordMu.Unlock()
+	return append([]PublishedMessage(nil), e.published...)
+}
+
+// PublishedTo 返回主题匹配pattern的已发布消息，pattern支持 * 和 > 通配
+func (e *MemoryEventBus) PublishedTo(pattern string) []PublishedMessage {
+	e.recordMu.Lock()
+	defer e.recordMu.Unlock()
+
+	var messages []PublishedMessage
+	for _, msg := range e.published {
+		if MatchSubject(pattern, msg.Topic) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Reset 清空已发布消息的记录，订阅保持不变
+func (e *MemoryEventBus) Reset() {
+	e.recordMu.Lock()
+	defer e.recordMu.Unlock()
+	e.published = nil
+}
+
+// Close 停止接收新消息，异步投递时等待队列中的消息处理完
+// 阻塞在已满队列上的Publish返回ErrBusClosed
+func (e *MemoryEventBus) Close() {
+	e.closeOnce.Do(func() { close(e.closing) })
+
+	// 写锁等待所有进行中的入队结束，之后的Publish都会看到closed
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	for _, sub := range e.subs {
+		if sub.queue != nil {
+			close(sub.queue)
+		}
+	}
+	e.mu.Unlock()
+	e.wg.Wait()
+}
+
+// MatchSubject 判断主题是否匹配NATS风格的订阅模式
+// * 匹配恰好一段，> 只能出现在末尾并匹配剩余的一段或多段
+func MatchSubject(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return i == len(patternTokens)-1 && len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
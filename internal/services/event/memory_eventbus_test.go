@@ -0,0 +1,283 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// hardwareEvent 创建指定类型的硬件事件
+func hardwareEvent(t *testing.T, eventType, id string) *CloudEvent {
+	t.Helper()
+	evt, err := NewCloudEvent("test", eventType, id, HardwareEvent{HardwareID: id, Status: models.ServerStatusDiscovered})
+	if err != nil {
+		t.Fatalf("NewCloudEvent() error = %v", err)
+	}
+	return evt
+}
+
+func TestMatchSubject(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{pattern: "hardware.failed", subject: "hardware.failed", want: true},
+		{pattern: "hardware.failed", subject: "hardware.discovered"},
+		{pattern: "hardware.*", subject: "hardware.failed", want: true},
+		{pattern: "hardware.*", subject: "hardware"},
+		{pattern: "*.failed", subject: "allocation.failed", want: true},
+		{pattern: "gpu.>", subject: "gpu.config.updated", want: true},
+		{pattern: "gpu.>", subject: "gpu"},
+		{pattern: ">", subject: "alert.raised", want: true},
+		{pattern: "gpu.>.updated", subject: "gpu.config.updated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.subject, func(t *testing.T) {
+			if got := MatchSubject(tt.pattern, tt.subject); got != tt.want {
+				t.Errorf("MatchSubject(%q, %q) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryEventBusRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		record      bool
+		pattern     string
+		wantAll     int
+		wantMatched []string
+	}{
+		{name: "recording disabled", pattern: "hardware.*"},
+		{name: "exact topic", record: true, pattern: EventTypeHardwareFailed, wantAll: 3, wantMatched: []string{"server-2"}},
+		{name: "wildcard", record: true, pattern: "*.failed", wantAll: 3, wantMatched: []string{"server-2"}},
+		{name: "full wildcard", record: true, pattern: "hardware.>", wantAll: 3, wantMatched: []string{"server-1", "server-2", "server-3"}},
+		{name: "no match", record: true, pattern: "alert.>", wantAll: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryEventBus(MemoryConfig{Record: tt.record}, nil)
+			defer bus.Close()
+			ctx := context.Background()
+
+			published := map[string]string{
+				"server-1": EventTypeHardwareDiscovered,
+				"server-2": EventTypeHardwareFailed,
+				"server-3": EventTypeHardwareProvisioned,
+			}
+			for _, id := range []string{"server-1", "server-2", "server-3"} {
+				topic := published[id]
+				if err := bus.Publish(ctx, topic, hardwareEvent(t, topic, id), WithMessageID(id)); err != nil {
+					t.Fatalf("Publish(%s) error = %v", topic, err)
+				}
+			}
+			// 类型与主题不一致的事件被拒绝，不会被记录
+			if err := bus.Publish(ctx, "hardware.provisioned.extra", hardwareEvent(t, EventTypeHardwareProvisioned, "server-4")); !errors.Is(err, ErrInvalidEvent) {
+				t.Fatalf("Publish() with mismatched type error = %v, want %v", err, ErrInvalidEvent)
+			}
+
+			if got := len(bus.Published()); got != tt.wantAll {
+				t.Fatalf("Published() = %d messages, want %d", got, tt.wantAll)
+			}
+			matched := bus.PublishedTo(tt.pattern)
+			if len(matched) != len(tt.wantMatched) {
+				t.Fatalf("PublishedTo(%q) = %d messages, want %d", tt.pattern, len(matched), len(tt.wantMatched))
+			}
+			for i, msg := range matched {
+				var evt CloudEvent
+				if err := msg.Decode(&evt); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if evt.Subject != tt.wantMatched[i] || msg.MessageID != tt.wantMatched[i] {
+					t.Errorf("message %d subject = %q, message id = %q, want %q", i, evt.Subject, msg.MessageID, tt.wantMatched[i])
+				}
+			}
+
+			bus.Reset()
+			if got := len(bus.Published()); got != 0 {
+				t.Errorf("Published() after Reset() = %d messages, want 0", got)
+			}
+		})
+	}
+}
+
+func TestMemoryEventBusFlush(t *testing.T) {
+	tests := []struct {
+		name  string
+		async bool
+	}{
+		{name: "sync"},
+		{name: "async", async: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryEventBus(MemoryConfig{Async: tt.async, Buffer: 4}, nil)
+			defer bus.Close()
+			ctx := context.Background()
+
+			var handled atomic.Int32
+			handler := func(ctx context.Context, data []byte) error {
+				time.Sleep(time.Millisecond)
+				handled.Add(1)
+				return nil
+			}
+			if err := bus.Subscribe(ctx, "hardware.*", handler, WithHandlerName("counter")); err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			if err := bus.Subscribe(ctx, "alert.>", handler, WithHandlerName("unmatched")); err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+
+			const count = 20
+			for i := 0; i < count; i++ {
+				if err := bus.Publish(ctx, EventTypeHardwareFailed, hardwareEvent(t, EventTypeHardwareFailed, "server-1")); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+			bus.Flush()
+			if got := handled.Load(); got != count {
+				t.Errorf("handled %d messages after Flush(), want %d", got, count)
+			}
+		})
+	}
+}
+
+func TestMemoryEventBusPublishDuringClose(t *testing.T) {
+	bus := NewMemoryEventBus(MemoryConfig{Async: true, Buffer: 1}, nil)
+	ctx := context.Background()
+
+	// 处理器较慢并再次发布，队列经常是满的，Publish阻塞在入队上
+	handler := func(ctx context.Context, data []byte) error {
+		time.Sleep(time.Millisecond)
+		err := bus.Publish(ctx, EventTypeHardwareProvisioned, hardwareEvent(t, EventTypeHardwareProvisioned, "server-1"))
+		if errors.Is(err, ErrBusClosed) {
+			return nil
+		}
+		return err
+	}
+	if err := bus.Subscribe(ctx, EventTypeHardwareFailed, handler, WithHandlerName("republish")); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if err := bus.Subscribe(ctx, EventTypeHardwareProvisioned, func(ctx context.Context, data []byte) error { return nil }, WithHandlerName("sink")); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// 并发发布与关闭不能向已关闭的队列发送，关闭后的发布返回ErrBusClosed
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				err := bus.Publish(ctx, EventTypeHardwareFailed, hardwareEvent(t, EventTypeHardwareFailed, "server-1"))
+				if errors.Is(err, ErrBusClosed) {
+					return
+				}
+				if err != nil {
+					t.Errorf("Publish() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		bus.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return while publishers were blocked")
+	}
+	wg.Wait()
+
+	if err := bus.Publish(ctx, EventTypeHardwareFailed, hardwareEvent(t, EventTypeHardwareFailed, "server-1")); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Publish() after Close() error = %v, want %v", err, ErrBusClosed)
+	}
+}
+
+func TestMemoryEventBusUnsubscribeOnDone(t *testing.T) {
+	tests := []struct {
+		name      string
+		async     bool
+		broadcast bool
+	}{
+		{name: "sync"},
+		{name: "async", async: true},
+		{name: "async broadcast", async: true, broadcast: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryEventBus(MemoryConfig{Async: tt.async}, nil)
+			defer bus.Close()
+			ctx := context.Background()
+
+			var handled atomic.Int32
+			handler := func(ctx context.Context, data []byte) error {
+				handled.Add(1)
+				return nil
+			}
+			opts := []SubscribeOption{WithHandlerName("client")}
+			if tt.broadcast {
+				opts = append(opts, Broadcast())
+			}
+			subCtx, cancel := context.WithCancel(ctx)
+			if err := bus.Subscribe(subCtx, "hardware.*", handler, opts...); err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			publish := func() {
+				t.Helper()
+				if err := bus.Publish(ctx, EventTypeHardwareFailed, hardwareEvent(t, EventTypeHardwareFailed, "server-1")); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+				bus.Flush()
+			}
+			publish()
+
+			// 订阅的ctx结束后移除订阅，不再投递
+			cancel()
+			deadline := time.Now().Add(time.Second)
+			for {
+				bus.mu.RLock()
+				subs := len(bus.subs)
+				bus.mu.RUnlock()
+				if subs == 0 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("%d subscriptions left after their context ended", subs)
+				}
+				time.Sleep(time.Millisecond)
+			}
+			publish()
+			if got := handled.Load(); got != 1 {
+				t.Errorf("handled %d messages, want 1 before cancel", got)
+			}
+
+			// 处理器已注销，同名处理器可以重新订阅
+			if err := bus.Replay(ctx, "client", nil); !errors.Is(err, ErrHandlerNotFound) {
+				t.Errorf("Replay() after cancel error = %v, want %v", err, ErrHandlerNotFound)
+			}
+			if err := bus.Subscribe(ctx, "hardware.*", handler, opts...); err != nil {
+				t.Fatalf("Subscribe() again error = %v", err)
+			}
+			publish()
+			if got := handled.Load(); got != 2 {
+				t.Errorf("handled %d messages, want 2 after subscribing again", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package event
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// 结构定义相关错误
+var (
+	// ErrUnknownEventType 事件类型没有登记结构定义
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrUnknownSchemaVersion 事件类型没有该版本的结构定义
+	ErrUnknownSchemaVersion = errors.New("unknown event schema version")
+	// ErrSchemaValidation 事件data不符合结构定义
+	ErrSchemaValidation = errors.New("event data does not match schema")
+	// ErrDuplicateSchema 同一事件类型的版本重复登记
+	ErrDuplicateSchema = errors.New("event schema version already registered")
+)
+
+// SchemaBasePath 结构定义的查询路径，事件的dataschema为 SchemaBasePath/{type}/{version}
+const SchemaBasePath = "/api/v1/events/schemas"
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// builtinSchemas 内置事件类型与结构定义文件的对应关系
+// 已发布的版本不可修改，字段变化时新增版本文件并在此追加条目，新事件使用最新版本
+var builtinSchemas = []struct {
+	file    string
+	version int
+	types   []string
+}{
+	{"schemas/hardware.v1.json", 1, []string{EventTypeHardwareDiscovered, EventTypeHardwareProvisioned, EventTypeHardwareFailed}},
+	{"schemas/gpu_config.v1.json", 1, []string{EventTypeGPUConfigUpdated}},
+	{"schemas/alert.v1.json", 1, []string{EventTypeAlertRaised, EventTypeAlertResolved}},
+	{"schemas/alert.v2.json", 2, []string{
+		EventTypeAlertRaised, EventTypeAlertAcknowledged, EventTypeAlertEscalated, EventTypeAlertResolved,
+	}},
+	{"schemas/allocation.v1.json", 1, []string{
+		EventTypeAllocationCreated, EventTypeAllocationActivated, EventTypeAllocationCompleted,
+		EventTypeAllocationFailed, EventTypeAllocationRequeued, EventTypeAllocationReleased,
+	}},
+	{"schemas/task.v1.json", 1, []string{EventTypeTaskSucceeded, EventTypeTaskFailed}},
+	{"schemas/workflow.v1.json", 1, []string{EventTypeWorkflowStarted, EventTypeWorkflowCompleted, EventTypeWorkflowFailed}},
+	{"schemas/annotation.v1.json", 1, []string{EventTypeAnnotationCreated}},
+}
+
+// Schemas 默认结构定义注册表，包含所有内置事件类型，事件总线发布时按其校验
+var Schemas = mustLoadBuiltinSchemas()
+
+// Schema 某个事件类型某个版本的结构定义
+type Schema struct {
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	URI     string          `json:"uri"`
+	Schema  json.RawMessage `json:"schema"`
+
+	compiled *jsonschema.Schema
+}
+
+// SchemaRegistry 事件结构定义注册表，按事件类型保存各版本的JSON Schema
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string][]*Schema
+}
+
+// NewSchemaRegistry 创建空的结构定义注册表
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: map[string][]*Schema{}}
+}
+
+// SchemaURI 返回事件类型某个版本的dataschema
+func SchemaURI(eventType string, version int) string {
+	return fmt.Sprintf("%s/%s/%d", SchemaBasePath, eventType, version)
+}
+
+// Register 登记事件类型的一个版本，schema为JSON Schema文档
+func (r *SchemaRegistry) Register(eventType string, version int, schema []byte) error {
+	if eventType == "" || version < 1 {
+		return fmt.Errorf("invalid schema registration %q version %d", eventType, version)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	url := "mem:///" + eventType + "/" + strconv.Itoa(version) + ".json"
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return fmt.Errorf("invalid schema for %s version %d: %w", eventType, version, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s version %d: %w", eventType, version, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.schemas[eventType] {
+		if existing.Version == version {
+			return fmt.Errorf("%w: %s version %d", ErrDuplicateSchema, eventType, version)
+		}
+	}
+	versions := append(r.schemas[eventType], &Schema{
+		Type:     eventType,
+		Version:  version,
+		URI:      SchemaURI(eventType, version),
+		Schema:   append(json.RawMessage(nil), schema...),
+		compiled: compiled,
+	})
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	r.schemas[eventType] = versions
+	return nil
+}
+
+// Latest 返回事件类型的最新版本
+func (r *SchemaRegistry) Latest(eventType string) (*Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.schemas[eventType]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Get 返回事件类型的指定版本
+func (r *SchemaRegistry) Get(eventType string, version int) (*Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.schemas[eventType]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+	for _, schema := range versions {
+		if schema.Version == version {
+			return schema, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", ErrUnknownSchemaVersion, eventType, version)
+}
+
+// List 返回所有结构定义，按事件类型和版本排序
+func (r *SchemaRegistry) List() []Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var schemas []Schema
+	for _, versions := range r.schemas {
+		for _, schema := range versions {
+			schemas = append(schemas, *schema)
+		}
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].Type != schemas[j].Type {
+			return schemas[i].Type < schemas[j].Type
+		}
+		return schemas[i].Version < schemas[j].Version
+	})
+	return schemas
+}
+
+// Validate 校验事件信封，并按dataschema指定的版本校验data，未指定版本时使用最新版本
+func (r *SchemaRegistry) Validate(ce *CloudEvent) error {
+	if err := ce.validateEnvelope(); err != nil {
+		return err
+	}
+
+	schema, err := r.resolve(ce)
+	if err != nil {
+		return err
+	}
+
+	// 数字按json.Number解码，避免大整数精度丢失导致误判
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(ce.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return fmt.Errorf("%w: %s: data is not valid JSON: %v", ErrSchemaValidation, ce.Type, err)
+	}
+	if err := schema.compiled.Validate(data); err != nil {
+		return fmt.Errorf("%w: %s version %d: %v", ErrSchemaValidation, ce.Type, schema.Version, err)
+	}
+	return nil
+}
+
+// resolve 查找事件对应的结构定义版本
+func (r *SchemaRegistry) resolve(ce *CloudEvent) (*Schema, error) {
+	if ce.DataSchema == "" {
+		return r.Latest(ce.Type)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.schemas[ce.Type]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, ce.Type)
+	}
+	for _, schema := range versions {
+		if schema.URI == ce.DataSchema {
+			return schema, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s dataschema %s", ErrUnknownSchemaVersion, ce.Type, ce.DataSchema)
+}
+
+// mustLoadBuiltinSchemas 登记内置结构定义，文件缺失或定义错误属于编码错误，直接panic
+func mustLoadBuiltinSchemas() *SchemaRegistry {
+	registry := NewSchemaRegistry()
+	for _, builtin := range builtinSchemas {
+		schema, err := schemaFiles.ReadFile(builtin.file)
+		if err != nil {
+			panic(fmt.Sprintf("failed to read event schema %s: %v", builtin.file, err))
+		}
+		for _, eventType := range builtin.types {
+			if err := registry.Register(eventType, builtin.version, schema); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return registry
+}
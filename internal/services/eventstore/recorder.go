@@ -0,0 +1,179 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// handlerPrefix 记录器处理器名称前缀，每个订阅主题一个处理器
+const handlerPrefix = "event-store."
+
+// Recorder 事件记录器，订阅事件总线并把所有事件追加到事件存储
+// 同一事件重复投递时按ID去重，只保存一次
+type Recorder struct {
+	repos    *repository.Repositories
+	eventBus event.EventBus
+}
+
+// NewRecorder 创建事件记录器，调用Subscribe后开始记录
+func NewRecorder(repos *repository.Repositories, eventBus event.EventBus) *Recorder {
+	return &Recorder{
+		repos:    repos,
+		eventBus: eventBus,
+	}
+}
+
+// Subscribe 订阅subjects中的每个主题，subjects通常与事件流收录的主题一致
+func (r *Recorder) Subscribe(ctx context.Context, subjects []string) error {
+	for _, subject := range subjects {
+		if err := r.eventBus.Subscribe(ctx, subject, r.record, event.WithHandlerName(handlerPrefix+subject)); err != nil {
+			return fmt.Errorf("failed to subscribe event store to %s: %w", subject, err)
+		}
+	}
+	return nil
+}
+
+// record 将一条事件写入事件存储，事件格式错误时返回错误，由事件总线写入死信
+func (r *Recorder) record(ctx context.Context, data []byte) error {
+	ce, err := event.ParseCloudEvent(data)
+	if err != nil {
+		return err
+	}
+
+	evt, err := FromCloudEvent(ctx, r.repos.GPUs, ce)
+	if err != nil {
+		return err
+	}
+
+	if err := r.repos.Events.Create(ctx, evt); err != nil && !errors.Is(err, repository.ErrConflict) {
+		return fmt.Errorf("failed to store event %s: %w", ce.ID, err)
+	}
+	return nil
+}
+
+// FromCloudEvent 将CloudEvent转换为事件存储中的事件，按事件类型确定来源资源以及关联的服务器和GPU
+// GPU事件通过gpus查询所属服务器
+func FromCloudEvent(ctx context.Context, gpus repository.GPURepository, ce *event.CloudEvent) (*models.Event, error) {
+	evt := &models.Event{
+		ID:         ce.ID,
+		EventType:  ce.Type,
+		SourceID:   ce.Subject,
+		Severity:   severityOf(ce),
+		Producer:   ce.Source,
+		DataSchema: ce.DataSchema,
+		Data:       ce.Data,
+		OccurredAt: ce.Time,
+	}
+
+	switch domain(ce.Type) {
+	case "hardware":
+		evt.SourceType = models.EventSourceServer
+		evt.ServerID = ce.Subject
+	case "gpu":
+		evt.SourceType = models.EventSourceGPU
+		evt.GPUID = ce.Subject
+		serverID, err := gpuServer(ctx, gpus, ce.Subject)
+		if err != nil {
+			return nil, err
+		}
+		evt.ServerID = serverID
+	case "allocation":
+		evt.SourceType = models.EventSourceAllocation
+		var data event.AllocationEvent
+		if err := ce.DecodeData(&data); err == nil {
+			evt.ServerID = data.ServerID
+		}
+	case "task":
+		evt.SourceType = models.EventSourceTask
+		var data event.TaskEvent
+		if err := ce.DecodeData(&data); err == nil && data.TargetType == models.EventSourceServer {
+			evt.ServerID = data.TargetID
+		}
+	case "alert":
+		evt.SourceType = models.EventSourceAlert
+		// 服务器和GPU告警同时出现在对应的时间线上
+		var data event.AlertEvent
+		if err := ce.DecodeData(&data); err == nil {
+			switch data.SourceType {
+			case models.EventSourceServer:
+				evt.ServerID = data.SourceID
+			case models.EventSourceGPU:
+				evt.GPUID = data.SourceID
+				serverID, err := gpuServer(ctx, gpus, data.SourceID)
+				if err != nil {
+					return nil, err
+				}
+				evt.ServerID = serverID
+			}
+		}
+	case "annotation":
+		evt.SourceType = models.EventSourceAnnotation
+		// 注释出现在关联服务器或GPU的时间线上，只关联GPU时补上所在服务器
+		var data event.AnnotationEvent
+		if err := ce.DecodeData(&data); err == nil {
+			evt.ServerID = data.ServerID
+			evt.GPUID = data.GPUID
+			if data.ServerID == "" && data.GPUID != "" {
+				serverID, err := gpuServer(ctx, gpus, data.GPUID)
+				if err != nil {
+					return nil, err
+				}
+				evt.ServerID = serverID
+			}
+		}
+	default:
+		evt.SourceType = domain(ce.Type)
+	}
+	return evt, nil
+}
+
+// gpuServer 返回GPU所在的服务器，GPU已被删除时返回空
+func gpuServer(ctx context.Context, gpus repository.GPURepository, gpuID string) (string, error) {
+	gpu, err := gpus.Get(ctx, gpuID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return gpu.ServerID, nil
+}
+
+// ToCloudEvent 将存储的事件还原为CloudEvent，与当初发布的事件内容一致
+func ToCloudEvent(evt *models.Event) *event.CloudEvent {
+	return &event.CloudEvent{
+		SpecVersion:     event.CloudEventsSpecVersion,
+		ID:              evt.ID,
+		Source:          evt.Producer,
+		Type:            evt.EventType,
+		Subject:         evt.SourceID,
+		Time:            evt.OccurredAt,
+		DataContentType: event.ContentTypeJSON,
+		DataSchema:      evt.DataSchema,
+		Data:            evt.Data,
+	}
+}
+
+// domain 返回事件类型的第一段，如 allocation.created 返回 allocation
+func domain(eventType string) string {
+	prefix, _, _ := strings.Cut(eventType, ".")
+	return prefix
+}
+
+// severityOf 按事件类型确定严重程度：失败类事件为error，告警产生和升级为warning，其余为info
+func severityOf(ce *event.CloudEvent) string {
+	switch {
+	case strings.HasSuffix(ce.Type, ".failed"):
+		return models.EventSeverityError
+	case ce.Type == event.EventTypeAlertRaised, ce.Type == event.EventTypeAlertEscalated:
+		return models.EventSeverityWarning
+	default:
+		return models.EventSeverityInfo
+	}
+}
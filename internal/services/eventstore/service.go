@@ -0,0 +1,165 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// 分页参数
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// 事件存储错误
+var (
+	// ErrInvalidCursor 分页游标无法解析
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrTypeNotPublishable 事件类型只能由系统内部发布
+	ErrTypeNotPublishable = errors.New("event type cannot be published by clients")
+)
+
+// PublishableTypes 允许通过API发布的事件类型：时间线注释和由外部发现系统上报的硬件发现
+// 分配、工作流、告警等事件由内部组件发布并驱动状态联动和通知，客户端不能伪造
+var PublishableTypes = map[string]bool{
+	event.EventTypeAnnotationCreated:  true,
+	event.EventTypeHardwareDiscovered: true,
+}
+
+// Query 事件查询条件，Cursor为上一页返回的next_cursor
+type Query struct {
+	repository.EventFilter
+	Cursor string
+}
+
+// Page 一页查询结果，NextCursor为空表示没有更多事件
+type Page struct {
+	Data       []models.Event `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// PublishRequest 通过API发布事件的请求，data按事件类型的最新结构定义校验
+type PublishRequest struct {
+	Type    string          `json:"type"`
+	Source  string          `json:"source"`
+	Subject string          `json:"subject"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Validate 校验发布请求
+func (r *PublishRequest) Validate() error {
+	if r.Type == "" {
+		return errors.New("type is required")
+	}
+	if r.Source == "" {
+		return errors.New("source is required")
+	}
+	if _, err := event.Schemas.Latest(r.Type); err != nil {
+		return err
+	}
+	if len(r.Data) == 0 {
+		return errors.New("data is required")
+	}
+	return nil
+}
+
+// Service 事件存储查询服务
+type Service struct {
+	repo     repository.EventRepository
+	eventBus event.EventBus
+}
+
+// NewService 创建事件存储查询服务
+func NewService(repos *repository.Repositories, eventBus event.EventBus) *Service {
+	return &Service{
+		repo:     repos.Events,
+		eventBus: eventBus,
+	}
+}
+
+// List 按发生时间倒序分页查询事件
+func (s *Service) List(ctx context.Context, query Query) (*Page, error) {
+	filter := query.EventFilter
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+	if filter.Limit > MaxLimit {
+		filter.Limit = MaxLimit
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = cursor
+	}
+
+	// 多取一条判断是否还有下一页
+	limit := filter.Limit
+	filter.Limit++
+	events, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Data: events}
+	if len(events) > limit {
+		page.Data = events[:limit]
+		last := page.Data[limit-1]
+		page.NextCursor = encodeCursor(last.OccurredAt, last.ID)
+	}
+	return page, nil
+}
+
+// Get 查询事件详情
+func (s *Service) Get(ctx context.Context, id string) (*models.Event, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Publish 将请求包装为CloudEvent发布到事件总线，事件由记录器异步写入事件存储
+// 只能发布PublishableTypes中的事件类型
+func (s *Service) Publish(ctx context.Context, req *PublishRequest) (*event.CloudEvent, error) {
+	if !PublishableTypes[req.Type] {
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotPublishable, req.Type)
+	}
+	ce, err := event.NewCloudEvent(req.Source, req.Type, req.Subject, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.eventBus.Publish(ctx, ce.Type, ce, event.WithMessageID(ce.ID)); err != nil {
+		return nil, err
+	}
+	return ce, nil
+}
+
+// encodeCursor 将最后一条事件的排序键编码为游标
+func encodeCursor(occurredAt time.Time, id string) string {
+	raw := occurredAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor 解析游标
+func decodeCursor(cursor string) (*repository.EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	occurredAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return &repository.EventCursor{OccurredAt: occurredAt, ID: id}, nil
+}
@@ -0,0 +1,120 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+func TestServicePublish(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     PublishRequest
+		wantErr error
+	}{
+		{
+			name: "annotation",
+			req: PublishRequest{Type: event.EventTypeAnnotationCreated, Source: "oncall", Subject: "server-1",
+				Data: json.RawMessage(`{"message":"replaced PSU","author":"alice","server_id":"server-1"}`)},
+		},
+		{
+			name: "external hardware discovery",
+			req: PublishRequest{Type: event.EventTypeHardwareDiscovered, Source: "discovery-agent", Subject: "server-9",
+				Data: json.RawMessage(`{"hardware_id":"server-9","status":"discovered"}`)},
+		},
+		{
+			// 分配事件会驱动工作流和通知，客户端不能伪造
+			name: "internal allocation event",
+			req: PublishRequest{Type: event.EventTypeAllocationFailed, Source: "client", Subject: "alloc-1",
+				Data: json.RawMessage(`{"allocation_id":"alloc-1","server_id":"server-1","user_id":"u","from_status":"active","to_status":"failed"}`)},
+			wantErr: ErrTypeNotPublishable,
+		},
+		{
+			name: "internal workflow event",
+			req: PublishRequest{Type: event.EventTypeWorkflowCompleted, Source: "client", Subject: "wf-1",
+				Data: json.RawMessage(`{"workflow_id":"wf-1","tinkerbell_id":"t","hardware_id":"h","template_id":"tp","status":"completed"}`)},
+			wantErr: ErrTypeNotPublishable,
+		},
+		{
+			name: "internal alert event",
+			req: PublishRequest{Type: event.EventTypeAlertRaised, Source: "client", Subject: "alert-1",
+				Data: json.RawMessage(`{}`)},
+			wantErr: ErrTypeNotPublishable,
+		},
+		{
+			name: "annotation without message",
+			req: PublishRequest{Type: event.EventTypeAnnotationCreated, Source: "oncall",
+				Data: json.RawMessage(`{"author":"alice"}`)},
+			wantErr: event.ErrSchemaValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := event.NewMemoryEventBus(event.MemoryConfig{Record: true}, nil)
+			defer bus.Close()
+			s := NewService(repository.NewMemoryRepositories(), bus)
+
+			ce, err := s.Publish(context.Background(), &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Publish() error = %v, want %v", err, tt.wantErr)
+			}
+			published := bus.PublishedTo(tt.req.Type)
+			if tt.wantErr != nil {
+				if len(published) != 0 {
+					t.Errorf("rejected event was published %d times", len(published))
+				}
+				return
+			}
+			if len(published) != 1 || published[0].MessageID != ce.ID {
+				t.Errorf("published = %d messages, want the event %s once", len(published), ce.ID)
+			}
+		})
+	}
+}
+
+func TestFromCloudEventAnnotation(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	ctx := context.Background()
+	server := &models.Server{Name: "node-1", Status: models.ServerStatusReady}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	gpu := &models.GPU{ServerID: server.ID, Model: "A100", MemoryGB: 80, Status: models.GPUStatusAvailable}
+	if err := repos.GPUs.Create(ctx, gpu); err != nil {
+		t.Fatalf("failed to create gpu: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		data       event.AnnotationEvent
+		wantServer string
+		wantGPU    string
+	}{
+		{name: "unlinked", data: event.AnnotationEvent{Message: "maintenance window"}},
+		{name: "server", data: event.AnnotationEvent{Message: "replaced PSU", ServerID: server.ID}, wantServer: server.ID},
+		{name: "gpu", data: event.AnnotationEvent{Message: "reseated", GPUID: gpu.ID}, wantServer: server.ID, wantGPU: gpu.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce, err := event.NewCloudEvent("oncall", event.EventTypeAnnotationCreated, "", tt.data)
+			if err != nil {
+				t.Fatalf("NewCloudEvent() error = %v", err)
+			}
+			evt, err := FromCloudEvent(ctx, repos.GPUs, ce)
+			if err != nil {
+				t.Fatalf("FromCloudEvent() error = %v", err)
+			}
+			if evt.SourceType != models.EventSourceAnnotation || evt.ServerID != tt.wantServer || evt.GPUID != tt.wantGPU {
+				t.Errorf("event source = %s, server = %q, gpu = %q, want %s, %q, %q",
+					evt.SourceType, evt.ServerID, evt.GPUID, models.EventSourceAnnotation, tt.wantServer, tt.wantGPU)
+			}
+		})
+	}
+}
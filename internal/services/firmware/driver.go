@@ -0,0 +1,120 @@
+package firmware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/redfish"
+)
+
+// Component BMC上报的单个固件组件
+type Component struct {
+	// Name 归一化后的组件名，与固件目录的component对应，例如 bios、bmc
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Updateable bool   `json:"updateable"`
+}
+
+// Driver 固件下发驱动，每种BMC协议实现一个驱动并通过Service.RegisterDriver注册
+type Driver interface {
+	// Name 驱动名称，记录在任务结果中
+	Name() string
+	// Supports 驱动能否管理该服务器，按注册顺序选择第一个支持的驱动
+	Supports(server *models.Server) bool
+	// Inventory 读取服务器当前的固件组件及版本
+	Inventory(ctx context.Context, server *models.Server) ([]Component, error)
+	// Apply 下发固件并等待BMC完成刷写，版本校验由Service负责
+	Apply(ctx context.Context, server *models.Server, fw *models.Firmware, report func(string)) error
+}
+
+// findComponent 按组件名查找，忽略大小写
+func findComponent(components []Component, name string) (*Component, bool) {
+	for i := range components {
+		if strings.EqualFold(components[i].Name, name) {
+			return &components[i], true
+		}
+	}
+	return nil, false
+}
+
+// RedfishDriver 通过Redfish UpdateService.SimpleUpdate升级固件
+// BMC从镜像地址拉取固件，部分组件（如BIOS）在BMC上报完成后仍需重启才会生效
+type RedfishDriver struct {
+	pool *redfish.Pool
+}
+
+// NewRedfishDriver 创建Redfish固件驱动
+func NewRedfishDriver(pool *redfish.Pool) *RedfishDriver {
+	return &RedfishDriver{pool: pool}
+}
+
+// Name 实现Driver
+func (d *RedfishDriver) Name() string { return "redfish" }
+
+// Supports 实现Driver，只管理配置了redfish_url的服务器
+func (d *RedfishDriver) Supports(server *models.Server) bool {
+	return server.RedfishURL != ""
+}
+
+// Inventory 实现Driver，组件名取FirmwareInventory成员Id的小写形式
+func (d *RedfishDriver) Inventory(ctx context.Context, server *models.Server) ([]Component, error) {
+	client, err := d.pool.Client(server.RedfishURL)
+	if err != nil {
+		return nil, err
+	}
+	items, err := client.FirmwareInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]Component, len(items))
+	for i, item := range items {
+		components[i] = Component{
+			Name:       strings.ToLower(item.ID),
+			Version:    item.Version,
+			Updateable: item.Updateable,
+		}
+	}
+	return components, nil
+}
+
+// Apply 实现Driver
+func (d *RedfishDriver) Apply(ctx context.Context, server *models.Server, fw *models.Firmware, report func(string)) error {
+	client, err := d.pool.Client(server.RedfishURL)
+	if err != nil {
+		return err
+	}
+	items, err := client.FirmwareInventory(ctx)
+	if err != nil {
+		return err
+	}
+
+	var target *redfish.SoftwareInventory
+	for i := range items {
+		if strings.EqualFold(items[i].ID, fw.Component) {
+			target = &items[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownComponent, fw.Component)
+	}
+	if !target.Updateable {
+		return fmt.Errorf("%w: %s is not updateable", ErrUnknownComponent, fw.Component)
+	}
+
+	report(fmt.Sprintf("sending SimpleUpdate for %s to BMC", target.ID))
+	monitor, err := client.SimpleUpdate(ctx, fw.ImageURI, []string{target.ODataID})
+	if err != nil {
+		return err
+	}
+	if monitor != "" {
+		report("waiting for BMC task " + monitor)
+		if _, err := client.WaitTask(ctx, monitor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,563 @@
+package firmware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/pkg/uuid"
+)
+
+// 分批升级错误
+var (
+	// ErrRolloutHalted 失败比例超过阈值，后续批次未执行
+	ErrRolloutHalted = errors.New("rollout halted")
+	// ErrNothingToRollback 分批升级中没有升级成功、可以回滚的服务器
+	ErrNothingToRollback = errors.New("rollout has no upgraded servers to roll back")
+)
+
+// RolloutRequest 分批升级请求
+type RolloutRequest struct {
+	FirmwareID string   `json:"firmware_id"`
+	ServerIDs  []string `json:"server_ids"`
+	// BatchSize 每批并行升级的服务器数量，默认1
+	BatchSize int `json:"batch_size"`
+	// MaxFailureRate 已完成服务器中失败比例超过该值时停止，默认0即任一失败就停止
+	MaxFailureRate float64 `json:"max_failure_rate"`
+}
+
+// Validate 校验分批升级请求并填充默认值
+func (r *RolloutRequest) Validate() error {
+	if r.FirmwareID == "" {
+		return errors.New("firmware_id is required")
+	}
+	if len(r.ServerIDs) == 0 {
+		return errors.New("server_ids is required")
+	}
+	seen := map[string]bool{}
+	for _, id := range r.ServerIDs {
+		if id == "" {
+			return errors.New("server_ids must not contain empty ids")
+		}
+		if seen[id] {
+			return fmt.Errorf("server %s is listed more than once", id)
+		}
+		seen[id] = true
+	}
+	if r.BatchSize < 0 {
+		return errors.New("batch_size must be greater than 0")
+	}
+	if r.BatchSize == 0 {
+		r.BatchSize = 1
+	}
+	if r.MaxFailureRate < 0 || r.MaxFailureRate > 1 {
+		return errors.New("max_failure_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// CreateRollout 校验所有目标服务器并提交分批升级任务，返回pending状态的分批升级
+func (s *Service) CreateRollout(ctx context.Context, req *RolloutRequest, createdBy string) (*models.FirmwareRollout, error) {
+	fw, err := s.repos.Firmware.Get(ctx, req.FirmwareID)
+	if err != nil {
+		return nil, fmt.Errorf("firmware %s: %w", req.FirmwareID, err)
+	}
+
+	targets := make([]models.FirmwareRolloutTarget, len(req.ServerIDs))
+	for i, id := range req.ServerIDs {
+		server, err := s.repos.Servers.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("server %s: %w", id, err)
+		}
+		if _, err := s.checkTarget(server, fw); err != nil {
+			return nil, err
+		}
+		targets[i] = models.FirmwareRolloutTarget{
+			ServerID: id,
+			Batch:    i/req.BatchSize + 1,
+			Status:   models.FirmwareTargetStatusPending,
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkIdle(ctx, req.ServerIDs); err != nil {
+		return nil, err
+	}
+
+	// 预先分配任务ID，分批升级记录创建后即可关联任务
+	t := &models.Task{
+		ID:         uuid.New(),
+		Type:       models.TaskTypeFirmwareRollout,
+		TargetType: "firmware_rollout",
+		Message:    "queued",
+		Input: map[string]interface{}{
+			"firmware_id":      fw.ID,
+			"servers":          len(targets),
+			"batch_size":       req.BatchSize,
+			"max_failure_rate": req.MaxFailureRate,
+		},
+		CreatedBy: createdBy,
+	}
+	rollout := &models.FirmwareRollout{
+		FirmwareID:     fw.ID,
+		Status:         models.FirmwareRolloutStatusPending,
+		BatchSize:      req.BatchSize,
+		MaxFailureRate: req.MaxFailureRate,
+		TaskID:         t.ID,
+		Targets:        targets,
+		Message:        "queued",
+		CreatedBy:      createdBy,
+	}
+	if err := s.repos.Rollouts.Create(ctx, rollout); err != nil {
+		return nil, err
+	}
+
+	t.TargetID = rollout.ID
+	rolloutID := rollout.ID
+	err = s.runner.Submit(ctx, t, s.config.RolloutTimeout, func(ctx context.Context, report func(string)) (map[string]interface{}, error) {
+		return s.runRollout(ctx, rolloutID, fw, report)
+	})
+	if err != nil {
+		rollout.Status = models.FirmwareRolloutStatusFailed
+		rollout.Message = "failed to submit task: " + err.Error()
+		if saveErr := s.saveRollout(rollout, models.FirmwareRolloutStatusPending); saveErr != nil {
+			return nil, fmt.Errorf("%v (and failed to mark rollout %s: %v)", err, rollout.ID, saveErr)
+		}
+		return nil, err
+	}
+	return rollout, nil
+}
+
+// runRollout 按批次升级，每批结束后检查失败比例，超过阈值时停止后续批次
+func (s *Service) runRollout(ctx context.Context, rolloutID string, fw *models.Firmware, report func(string)) (map[string]interface{}, error) {
+	rollout, err := s.repos.Rollouts.Get(ctx, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	rollout.Status = models.FirmwareRolloutStatusRunning
+	rollout.Message = "verifying image"
+	if err := s.saveRollout(rollout, models.FirmwareRolloutStatusPending); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyImage(ctx, fw, report); err != nil {
+		return nil, s.finishRollout(rollout, models.FirmwareRolloutStatusFailed, err)
+	}
+
+	batches := batchesOf(rollout.Targets)
+	for i, batch := range batches {
+		if err := ctx.Err(); err != nil {
+			return nil, s.finishRollout(rollout, models.FirmwareRolloutStatusFailed, err)
+		}
+
+		rollout.Message = fmt.Sprintf("upgrading batch %d/%d", i+1, len(batches))
+		report(rollout.Message)
+		s.runBatch(ctx, rollout, batch, func(*models.Server) *models.Firmware { return fw }, models.FirmwareTargetStatusFailed, report)
+
+		done, failed := 0, 0
+		for _, target := range rollout.Targets {
+			switch target.Status {
+			case models.FirmwareTargetStatusSucceeded, models.FirmwareTargetStatusSkipped:
+				done++
+			case models.FirmwareTargetStatusFailed:
+				done++
+				failed++
+			}
+		}
+		rate := float64(failed) / float64(done)
+		if failed > 0 && rate > rollout.MaxFailureRate {
+			haltErr := fmt.Errorf("%w after batch %d/%d: failure rate %.2f exceeds %.2f",
+				ErrRolloutHalted, i+1, len(batches), rate, rollout.MaxFailureRate)
+			return nil, s.finishRollout(rollout, models.FirmwareRolloutStatusHalted, haltErr)
+		}
+		if err := s.saveRollout(rollout, models.FirmwareRolloutStatusRunning); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.finishRollout(rollout, models.FirmwareRolloutStatusSucceeded, nil); err != nil {
+		return nil, err
+	}
+	return rolloutSummary(rollout), nil
+}
+
+// Rollback 将分批升级中升级成功的服务器刷回升级前的版本，升级前的版本必须仍在固件目录中
+func (s *Service) Rollback(ctx context.Context, rolloutID string, createdBy string) (*models.FirmwareRollout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rollout, err := s.repos.Rollouts.Get(ctx, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.FirmwareRolloutTransitions.Validate("firmware_rollout", rollout.ID, rollout.Status, models.FirmwareRolloutStatusRollingBack); err != nil {
+		return nil, err
+	}
+	fw, err := s.repos.Firmware.Get(ctx, rollout.FirmwareID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{}
+	for _, target := range rollout.Targets {
+		if rollbackCandidate(target) {
+			candidates = append(candidates, target.ServerID)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNothingToRollback, rollout.ID)
+	}
+	if err := s.checkIdle(ctx, candidates); err != nil {
+		return nil, err
+	}
+
+	t := &models.Task{
+		ID:         uuid.New(),
+		Type:       models.TaskTypeFirmwareRollback,
+		TargetType: "firmware_rollout",
+		TargetID:   rollout.ID,
+		Message:    "queued",
+		Input: map[string]interface{}{
+			"firmware_id": fw.ID,
+			"servers":     len(candidates),
+		},
+		CreatedBy: createdBy,
+	}
+
+	previous := rollout.Status
+	rollout.Status = models.FirmwareRolloutStatusRollingBack
+	rollout.TaskID = t.ID
+	rollout.Message = "rollback queued"
+	if err := s.repos.Rollouts.UpdateIfStatus(ctx, rollout, previous); err != nil {
+		return nil, err
+	}
+
+	err = s.runner.Submit(ctx, t, s.config.RolloutTimeout, func(ctx context.Context, report func(string)) (map[string]interface{}, error) {
+		return s.runRollback(ctx, rolloutID, fw, report)
+	})
+	if err != nil {
+		rollout.Status = models.FirmwareRolloutStatusFailed
+		rollout.Message = "failed to submit rollback task: " + err.Error()
+		if saveErr := s.saveRollout(rollout, models.FirmwareRolloutStatusRollingBack); saveErr != nil {
+			return nil, fmt.Errorf("%v (and failed to mark rollout %s: %v)", err, rollout.ID, saveErr)
+		}
+		return nil, err
+	}
+	return rollout, nil
+}
+
+// rollbackCandidate 只回滚确实改变了版本的服务器
+func rollbackCandidate(target models.FirmwareRolloutTarget) bool {
+	return target.Status == models.FirmwareTargetStatusSucceeded &&
+		target.FromVersion != "" && target.FromVersion != target.ToVersion
+}
+
+// runRollback 按原批次大小回滚，单台失败不影响其他服务器
+func (s *Service) runRollback(ctx context.Context, rolloutID string, fw *models.Firmware, report func(string)) (map[string]interface{}, error) {
+	rollout, err := s.repos.Rollouts.Get(ctx, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个升级前版本只查找和校验一次镜像
+	previous := map[string]*models.Firmware{}
+	failures := map[string]error{}
+	targets := []*models.FirmwareRolloutTarget{}
+	for i := range rollout.Targets {
+		target := &rollout.Targets[i]
+		if !rollbackCandidate(*target) {
+			continue
+		}
+		targets = append(targets, target)
+		version := target.FromVersion
+		if _, ok := previous[version]; ok || failures[version] != nil {
+			continue
+		}
+		prev, err := s.repos.Firmware.GetVersion(ctx, fw.Component, version)
+		if err == nil {
+			err = s.verifyImage(ctx, prev, report)
+		} else if errors.Is(err, repository.ErrNotFound) {
+			err = fmt.Errorf("%s %s is not in the firmware catalog", fw.Component, version)
+		}
+		if err != nil {
+			failures[version] = err
+			continue
+		}
+		previous[version] = prev
+	}
+
+	// 升级前版本不可用的服务器直接标记回滚失败
+	runnable := []*models.FirmwareRolloutTarget{}
+	for _, target := range targets {
+		if err := failures[target.FromVersion]; err != nil {
+			target.Status = models.FirmwareTargetStatusRollbackFailed
+			target.Error = err.Error()
+			continue
+		}
+		runnable = append(runnable, target)
+	}
+
+	versions := map[string]string{}
+	for _, target := range runnable {
+		versions[target.ServerID] = target.FromVersion
+	}
+	for start := 0; start < len(runnable); start += rollout.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, s.finishRollout(rollout, models.FirmwareRolloutStatusFailed, err)
+		}
+		end := start + rollout.BatchSize
+		if end > len(runnable) {
+			end = len(runnable)
+		}
+
+		rollout.Message = fmt.Sprintf("rolling back %d/%d servers", end, len(runnable))
+		report(rollout.Message)
+		s.runBatch(ctx, rollout, runnable[start:end], func(server *models.Server) *models.Firmware {
+			return previous[versions[server.ID]]
+		}, models.FirmwareTargetStatusRollbackFailed, report)
+		for _, target := range runnable[start:end] {
+			if target.Status == models.FirmwareTargetStatusSucceeded || target.Status == models.FirmwareTargetStatusSkipped {
+				target.Status = models.FirmwareTargetStatusRolledBack
+			}
+		}
+		if err := s.saveRollout(rollout, models.FirmwareRolloutStatusRollingBack); err != nil {
+			return nil, err
+		}
+	}
+
+	failed := 0
+	for _, target := range targets {
+		if target.Status == models.FirmwareTargetStatusRollbackFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return nil, s.finishRollout(rollout, models.FirmwareRolloutStatusFailed,
+			fmt.Errorf("rollback failed on %d of %d servers", failed, len(targets)))
+	}
+	if err := s.finishRollout(rollout, models.FirmwareRolloutStatusRolledBack, nil); err != nil {
+		return nil, err
+	}
+	return rolloutSummary(rollout), nil
+}
+
+// runBatch 并行升级一批服务器，结果直接写入targets
+// 回滚时保留升级时记录的前后版本，只更新状态
+func (s *Service) runBatch(ctx context.Context, rollout *models.FirmwareRollout, batch []*models.FirmwareRolloutTarget, firmwareFor func(*models.Server) *models.Firmware, failStatus string, report func(string)) {
+	// 任务进度在各服务器之间共享，串行化更新
+	var reportMu sync.Mutex
+	serialReport := func(message string) {
+		reportMu.Lock()
+		defer reportMu.Unlock()
+		report(message)
+	}
+
+	now := time.Now().UTC()
+	for _, target := range batch {
+		target.StartedAt = &now
+		target.FinishedAt = nil
+		target.Error = ""
+		if rollout.Status == models.FirmwareRolloutStatusRunning {
+			target.Status = models.FirmwareTargetStatusRunning
+		}
+	}
+	if err := s.saveRollout(rollout, rollout.Status); err != nil {
+		serialReport(fmt.Sprintf("failed to record batch start: %v", err))
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range batch {
+		wg.Add(1)
+		go func(target *models.FirmwareRolloutTarget) {
+			defer wg.Done()
+
+			result := models.FirmwareRolloutTarget{ServerID: target.ServerID}
+			err := s.upgradeTarget(ctx, target.ServerID, firmwareFor, &result, serialReport)
+
+			finished := time.Now().UTC()
+			target.FinishedAt = &finished
+			if rollout.Status == models.FirmwareRolloutStatusRunning {
+				target.FromVersion = result.FromVersion
+				target.ToVersion = result.ToVersion
+			}
+			if err != nil {
+				target.Status = failStatus
+				target.Error = err.Error()
+				return
+			}
+			target.Status = result.Status
+		}(target)
+	}
+	wg.Wait()
+}
+
+// upgradeTarget 读取最新的服务器信息并升级
+func (s *Service) upgradeTarget(ctx context.Context, serverID string, firmwareFor func(*models.Server) *models.Firmware, result *models.FirmwareRolloutTarget, report func(string)) error {
+	server, err := s.repos.Servers.Get(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("server %s: %w", serverID, err)
+	}
+	fw := firmwareFor(server)
+	driver, err := s.checkTarget(server, fw)
+	if err != nil {
+		return err
+	}
+	return s.upgradeServer(ctx, driver, server, fw, result, report)
+}
+
+// batchesOf 按批次号分组，返回指向targets元素的指针
+func batchesOf(targets []models.FirmwareRolloutTarget) [][]*models.FirmwareRolloutTarget {
+	batches := [][]*models.FirmwareRolloutTarget{}
+	for i := range targets {
+		batch := targets[i].Batch
+		for len(batches) < batch {
+			batches = append(batches, nil)
+		}
+		batches[batch-1] = append(batches[batch-1], &targets[i])
+	}
+	return batches
+}
+
+// finishRollout 结束分批升级，未执行的服务器标记为跳过，返回cause便于调用方直接返回
+func (s *Service) finishRollout(rollout *models.FirmwareRollout, status string, cause error) error {
+	expected := rollout.Status
+	for i := range rollout.Targets {
+		target := &rollout.Targets[i]
+		if target.Status == models.FirmwareTargetStatusPending || target.Status == models.FirmwareTargetStatusRunning {
+			target.Status = models.FirmwareTargetStatusSkipped
+			if cause != nil {
+				target.Error = cause.Error()
+			}
+		}
+	}
+
+	rollout.Status = status
+	rollout.Message = status
+	if cause != nil {
+		rollout.Message = cause.Error()
+	}
+	if err := s.saveRollout(rollout, expected); err != nil {
+		if cause != nil {
+			return fmt.Errorf("%w (and failed to record rollout result: %v)", cause, err)
+		}
+		return err
+	}
+	return cause
+}
+
+// rolloutSummary 任务结果中记录各状态的服务器数量
+func rolloutSummary(rollout *models.FirmwareRollout) map[string]interface{} {
+	counts := map[string]int{}
+	for _, target := range rollout.Targets {
+		counts[target.Status]++
+	}
+	return map[string]interface{}{
+		"rollout_id": rollout.ID,
+		"status":     rollout.Status,
+		"servers":    counts,
+	}
+}
+
+// saveRollout 以期望状态为条件写入分批升级
+func (s *Service) saveRollout(rollout *models.FirmwareRollout, expectedStatus string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return s.repos.Rollouts.UpdateIfStatus(ctx, rollout, expectedStatus)
+}
+
+// FailInterrupted 将执行任务已结束或不存在的未结束分批升级标记为失败，启动时在任务执行器的FailInterrupted之后调用
+// 任务仍在其他实例上执行的分批升级不受影响
+// 执行中的服务器可能已刷入新版本，需要人工确认后再决定是否回滚
+func (s *Service) FailInterrupted(ctx context.Context) (int, error) {
+	rollouts, err := s.repos.Rollouts.List(ctx, repository.FirmwareRolloutFilter{
+		Statuses: []string{
+			models.FirmwareRolloutStatusPending,
+			models.FirmwareRolloutStatusRunning,
+			models.FirmwareRolloutStatusRollingBack,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range rollouts {
+		rollout := &rollouts[i]
+		t, err := s.repos.Tasks.Get(ctx, rollout.TaskID)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			// 提交任务前会先写入分批升级，刚写入的记录可能还没有对应的任务
+			if time.Since(rollout.UpdatedAt) < writeTimeout {
+				continue
+			}
+		case err != nil:
+			return count, err
+		case !t.Finished():
+			continue
+		}
+
+		failed, err := s.failRollout(ctx, rollout, "interrupted by server restart")
+		if err != nil {
+			return count, err
+		}
+		if failed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// taskInterrupted 执行分批升级或回滚的任务被标记为中断时，将分批升级标记为失败
+func (s *Service) taskInterrupted(ctx context.Context, t *models.Task) {
+	if t.Type != models.TaskTypeFirmwareRollout && t.Type != models.TaskTypeFirmwareRollback {
+		return
+	}
+	rollout, err := s.repos.Rollouts.Get(ctx, t.TargetID)
+	if err != nil {
+		log.Printf("Failed to load firmware rollout %s of interrupted task %s: %v", t.TargetID, t.ID, err)
+		return
+	}
+	if rollout.TaskID != t.ID {
+		return
+	}
+	if _, err := s.failRollout(ctx, rollout, t.Error); err != nil {
+		log.Printf("Failed to mark firmware rollout %s of interrupted task %s as failed: %v", rollout.ID, t.ID, err)
+	}
+}
+
+// failRollout 将执行被中断的分批升级标记为失败，分批升级已结束时返回false
+func (s *Service) failRollout(ctx context.Context, rollout *models.FirmwareRollout, reason string) (bool, error) {
+	expected := rollout.Status
+	switch expected {
+	case models.FirmwareRolloutStatusPending, models.FirmwareRolloutStatusRunning, models.FirmwareRolloutStatusRollingBack:
+	default:
+		return false, nil
+	}
+
+	for j := range rollout.Targets {
+		target := &rollout.Targets[j]
+		switch target.Status {
+		case models.FirmwareTargetStatusRunning:
+			target.Status = models.FirmwareTargetStatusFailed
+			target.Error = reason
+		case models.FirmwareTargetStatusPending:
+			target.Status = models.FirmwareTargetStatusSkipped
+		}
+	}
+	rollout.Status = models.FirmwareRolloutStatusFailed
+	rollout.Message = reason
+	if err := s.repos.Rollouts.UpdateIfStatus(ctx, rollout, expected); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+package firmware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/task"
+)
+
+// newTestService 创建使用内存仓储的固件服务，任务执行器的实例标识为owner
+func newTestService(t *testing.T, owner string) (*repository.Repositories, *task.Runner, *Service) {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	runner := task.NewRunner(repos.Tasks, event.NewMemoryEventBus(event.MemoryConfig{}, nil), task.Config{
+		Owner:        owner,
+		LeaseTimeout: time.Minute,
+	})
+	t.Cleanup(runner.Close)
+	return repos, runner, NewService(repos, runner, Config{})
+}
+
+// createRollout 创建运行中的分批升级，taskStatus为空时不创建对应的任务
+func createRollout(t *testing.T, repos *repository.Repositories, taskStatus, owner string, heartbeatAt time.Time) *models.FirmwareRollout {
+	t.Helper()
+	ctx := context.Background()
+
+	fw := &models.Firmware{Component: "BIOS", Version: "2.1.0", ImageURI: "http://images/bios-2.1.0.bin"}
+	if err := repos.Firmware.Create(ctx, fw); err != nil {
+		t.Fatalf("failed to create firmware: %v", err)
+	}
+	rollout := &models.FirmwareRollout{
+		FirmwareID: fw.ID,
+		Status:     models.FirmwareRolloutStatusRunning,
+		BatchSize:  1,
+		TaskID:     "task-1",
+		Targets: []models.FirmwareRolloutTarget{
+			{ServerID: "server-1", Status: models.FirmwareTargetStatusRunning},
+			{ServerID: "server-2", Status: models.FirmwareTargetStatusPending},
+		},
+	}
+	if err := repos.Rollouts.Create(ctx, rollout); err != nil {
+		t.Fatalf("failed to create rollout: %v", err)
+	}
+	if taskStatus != "" {
+		if err := repos.Tasks.Create(ctx, &models.Task{
+			ID:          rollout.TaskID,
+			Type:        models.TaskTypeFirmwareRollout,
+			TargetType:  "firmware_rollout",
+			TargetID:    rollout.ID,
+			Status:      taskStatus,
+			Owner:       owner,
+			HeartbeatAt: &heartbeatAt,
+		}); err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+	}
+	return rollout
+}
+
+func TestFailInterruptedRollouts(t *testing.T) {
+	tests := []struct {
+		name       string
+		taskStatus string
+		wantFailed bool
+	}{
+		{name: "task still running on another instance", taskStatus: models.TaskStatusRunning},
+		{name: "task already failed", taskStatus: models.TaskStatusFailed, wantFailed: true},
+		{name: "task not submitted yet", taskStatus: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, _, service := newTestService(t, "a")
+			rollout := createRollout(t, repos, tt.taskStatus, "b", time.Now().UTC())
+			ctx := context.Background()
+
+			count, err := service.FailInterrupted(ctx)
+			if err != nil {
+				t.Fatalf("FailInterrupted() error = %v", err)
+			}
+			if (count == 1) != tt.wantFailed {
+				t.Errorf("FailInterrupted() = %d, want failed = %v", count, tt.wantFailed)
+			}
+
+			got, err := repos.Rollouts.Get(ctx, rollout.ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if failed := got.Status == models.FirmwareRolloutStatusFailed; failed != tt.wantFailed {
+				t.Errorf("rollout status = %s, want failed = %v", got.Status, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestRolloutFailsWhenTaskLeaseExpires(t *testing.T) {
+	repos, runner, _ := newTestService(t, "a")
+	rollout := createRollout(t, repos, models.TaskStatusRunning, "b", time.Now().UTC().Add(-time.Hour))
+	ctx := context.Background()
+
+	// 执行器回收租约过期的任务，同时结束对应的分批升级
+	if count, err := runner.FailInterrupted(ctx); err != nil || count != 1 {
+		t.Fatalf("runner FailInterrupted() = %d, %v, want 1", count, err)
+	}
+
+	got, err := repos.Rollouts.Get(ctx, rollout.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != models.FirmwareRolloutStatusFailed {
+		t.Fatalf("rollout status = %s, want %s", got.Status, models.FirmwareRolloutStatusFailed)
+	}
+	want := []string{models.FirmwareTargetStatusFailed, models.FirmwareTargetStatusSkipped}
+	for i, target := range got.Targets {
+		if target.Status != want[i] {
+			t.Errorf("target %s status = %s, want %s", target.ServerID, target.Status, want[i])
+		}
+	}
+}
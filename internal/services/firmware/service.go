@@ -0,0 +1,349 @@
+package firmware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/task"
+)
+
+// 固件服务错误
+var (
+	// ErrNoDriver 没有驱动能管理该服务器
+	ErrNoDriver = errors.New("no firmware driver supports server")
+	// ErrNotApplicable 固件不适用于服务器型号
+	ErrNotApplicable = errors.New("firmware is not applicable to server model")
+	// ErrTaskInProgress 服务器已有未完成的固件升级
+	ErrTaskInProgress = errors.New("firmware upgrade already in progress")
+	// ErrUnknownComponent BMC清单中没有该组件或组件不可更新
+	ErrUnknownComponent = errors.New("component not found in firmware inventory")
+	// ErrChecksumMismatch 镜像摘要与目录记录不一致
+	ErrChecksumMismatch = errors.New("firmware image checksum mismatch")
+	// ErrVersionMismatch BMC上报完成后版本仍不是目标版本
+	ErrVersionMismatch = errors.New("firmware version mismatch after update")
+)
+
+// writeTimeout 分批升级状态写入的超时，任务Context结束后仍需落库
+const writeTimeout = 10 * time.Second
+
+// hashes 支持的镜像摘要算法
+var hashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ValidateFirmware 校验固件目录条目
+func ValidateFirmware(f *models.Firmware) error {
+	if f.Component == "" {
+		return errors.New("component is required")
+	}
+	if f.Version == "" {
+		return errors.New("version is required")
+	}
+	u, err := url.Parse(f.ImageURI)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("image_uri %q must be an absolute URL", f.ImageURI)
+	}
+	if _, _, err := parseChecksum(f.Checksum); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseChecksum 解析 算法:十六进制摘要 格式的摘要
+func parseChecksum(checksum string) (string, []byte, error) {
+	algorithm, digest, ok := strings.Cut(checksum, ":")
+	newHash, known := hashes[strings.ToLower(algorithm)]
+	if !ok || !known {
+		return "", nil, fmt.Errorf("checksum %q must be sha256:<hex> or sha512:<hex>", checksum)
+	}
+	sum, err := hex.DecodeString(digest)
+	if err != nil || len(sum) != newHash().Size() {
+		return "", nil, fmt.Errorf("checksum %q has an invalid %s digest", checksum, algorithm)
+	}
+	return strings.ToLower(algorithm), sum, nil
+}
+
+// UpgradeRequest 单台服务器固件升级请求
+type UpgradeRequest struct {
+	FirmwareID string `json:"firmware_id"`
+}
+
+// Validate 校验升级请求
+func (r *UpgradeRequest) Validate() error {
+	if r.FirmwareID == "" {
+		return errors.New("firmware_id is required")
+	}
+	return nil
+}
+
+// Config 固件服务配置
+type Config struct {
+	// TaskTimeout 单台服务器升级任务的最长时间
+	TaskTimeout time.Duration
+	// RolloutTimeout 分批升级或回滚任务的最长时间
+	RolloutTimeout time.Duration
+	// VerifyChecksum 下发前下载镜像并校验摘要
+	VerifyChecksum bool
+}
+
+// Service 固件服务，管理固件目录并以异步任务执行升级
+type Service struct {
+	repos      *repository.Repositories
+	runner     *task.Runner
+	config     Config
+	drivers    []Driver
+	httpClient *http.Client
+
+	// mu 串行化任务提交，保证同一服务器同时只有一个固件升级
+	mu sync.Mutex
+}
+
+// NewService 创建固件服务，驱动通过RegisterDriver注册
+func NewService(repos *repository.Repositories, runner *task.Runner, config Config) *Service {
+	s := &Service{
+		repos:      repos,
+		runner:     runner,
+		config:     config,
+		httpClient: &http.Client{},
+	}
+	// 其他实例退出后由本实例回收其任务时，同时结束对应的分批升级
+	runner.OnInterrupted(s.taskInterrupted)
+	return s
+}
+
+// RegisterDriver 注册固件驱动，选择驱动时按注册顺序匹配
+func (s *Service) RegisterDriver(driver Driver) {
+	s.drivers = append(s.drivers, driver)
+}
+
+// driverFor 返回第一个支持该服务器的驱动
+func (s *Service) driverFor(server *models.Server) (Driver, error) {
+	for _, driver := range s.drivers {
+		if driver.Supports(server) {
+			return driver, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %s", ErrNoDriver, server.ID)
+}
+
+// Inventory 读取服务器当前的固件版本
+func (s *Service) Inventory(ctx context.Context, serverID string) ([]Component, error) {
+	server, err := s.repos.Servers.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := s.driverFor(server)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Inventory(ctx, server)
+}
+
+// Upgrade 校验并提交单台服务器的固件升级任务，返回pending状态的任务
+func (s *Service) Upgrade(ctx context.Context, serverID string, req *UpgradeRequest, createdBy string) (*models.Task, error) {
+	server, err := s.repos.Servers.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	fw, err := s.repos.Firmware.Get(ctx, req.FirmwareID)
+	if err != nil {
+		return nil, fmt.Errorf("firmware %s: %w", req.FirmwareID, err)
+	}
+	driver, err := s.checkTarget(server, fw)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkIdle(ctx, []string{serverID}); err != nil {
+		return nil, err
+	}
+
+	t := &models.Task{
+		Type:       models.TaskTypeServerFirmware,
+		TargetType: "server",
+		TargetID:   serverID,
+		Message:    "queued",
+		Input: map[string]interface{}{
+			"firmware_id": fw.ID,
+			"component":   fw.Component,
+			"version":     fw.Version,
+		},
+		CreatedBy: createdBy,
+	}
+	err = s.runner.Submit(ctx, t, s.config.TaskTimeout, func(ctx context.Context, report func(string)) (map[string]interface{}, error) {
+		if err := s.verifyImage(ctx, fw, report); err != nil {
+			return nil, err
+		}
+		target := models.FirmwareRolloutTarget{ServerID: serverID}
+		if err := s.upgradeServer(ctx, driver, server, fw, &target, report); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"firmware_id":  fw.ID,
+			"component":    fw.Component,
+			"driver":       driver.Name(),
+			"status":       target.Status,
+			"from_version": target.FromVersion,
+			"to_version":   target.ToVersion,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkTarget 校验固件适用于服务器并返回驱动
+func (s *Service) checkTarget(server *models.Server, fw *models.Firmware) (Driver, error) {
+	if !fw.AppliesTo(server.Model) {
+		return nil, fmt.Errorf("%w: %s %s does not support model %q (supported: %s)",
+			ErrNotApplicable, fw.Component, fw.Version, server.Model, strings.Join(fw.Models, ", "))
+	}
+	return s.driverFor(server)
+}
+
+// checkIdle 服务器有未结束的单机升级任务或处于未结束的分批升级中时拒绝，调用方需持有mu
+func (s *Service) checkIdle(ctx context.Context, serverIDs []string) error {
+	wanted := map[string]bool{}
+	for _, id := range serverIDs {
+		wanted[id] = true
+	}
+
+	tasks, err := s.repos.Tasks.List(ctx, repository.TaskFilter{
+		Type:     models.TaskTypeServerFirmware,
+		Statuses: []string{models.TaskStatusPending, models.TaskStatusRunning},
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if wanted[t.TargetID] {
+			return fmt.Errorf("%w: server %s has task %s", ErrTaskInProgress, t.TargetID, t.ID)
+		}
+	}
+
+	rollouts, err := s.repos.Rollouts.List(ctx, repository.FirmwareRolloutFilter{
+		Statuses: []string{
+			models.FirmwareRolloutStatusPending,
+			models.FirmwareRolloutStatusRunning,
+			models.FirmwareRolloutStatusRollingBack,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, rollout := range rollouts {
+		for _, target := range rollout.Targets {
+			if wanted[target.ServerID] {
+				return fmt.Errorf("%w: server %s is in rollout %s", ErrTaskInProgress, target.ServerID, rollout.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// upgradeServer 升级单台服务器并在target中记录升级前后的版本
+// 已是目标版本时跳过，BMC上报完成后重新读取版本确认升级生效
+func (s *Service) upgradeServer(ctx context.Context, driver Driver, server *models.Server, fw *models.Firmware, target *models.FirmwareRolloutTarget, report func(string)) error {
+	report(fmt.Sprintf("reading %s version of server %s", fw.Component, server.ID))
+	before, err := s.componentVersion(ctx, driver, server, fw.Component)
+	if err != nil {
+		return err
+	}
+	target.FromVersion = before
+	if before == fw.Version {
+		target.ToVersion = before
+		target.Status = models.FirmwareTargetStatusSkipped
+		report(fmt.Sprintf("server %s is already at %s %s", server.ID, fw.Component, fw.Version))
+		return nil
+	}
+
+	if err := driver.Apply(ctx, server, fw, report); err != nil {
+		return err
+	}
+
+	after, err := s.componentVersion(ctx, driver, server, fw.Component)
+	if err != nil {
+		return err
+	}
+	target.ToVersion = after
+	if after != fw.Version {
+		return fmt.Errorf("%w: server %s reports %s %s, expected %s", ErrVersionMismatch, server.ID, fw.Component, after, fw.Version)
+	}
+	target.Status = models.FirmwareTargetStatusSucceeded
+	return nil
+}
+
+// componentVersion 读取服务器上组件的当前版本
+func (s *Service) componentVersion(ctx context.Context, driver Driver, server *models.Server, name string) (string, error) {
+	components, err := driver.Inventory(ctx, server)
+	if err != nil {
+		return "", err
+	}
+	component, ok := findComponent(components, name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s on server %s", ErrUnknownComponent, name, server.ID)
+	}
+	return component.Version, nil
+}
+
+// verifyImage 下载镜像并校验摘要，避免BMC刷入损坏或被替换的镜像
+// 只能校验HTTP(S)地址，其他协议需要关闭FIRMWARE_VERIFY_CHECKSUM
+func (s *Service) verifyImage(ctx context.Context, fw *models.Firmware, report func(string)) error {
+	if !s.config.VerifyChecksum {
+		return nil
+	}
+
+	algorithm, expected, err := parseChecksum(fw.Checksum)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(fw.ImageURI)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("cannot verify checksum of %s image %s", u.Scheme, fw.ImageURI)
+	}
+
+	report("verifying image checksum " + fw.ImageURI)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fw.ImageURI, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download firmware image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download firmware image: %s", resp.Status)
+	}
+
+	h := hashes[algorithm]()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return fmt.Errorf("download firmware image: %w", err)
+	}
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		return fmt.Errorf("%w: %s is %s:%x", ErrChecksumMismatch, fw.ImageURI, algorithm, actual)
+	}
+	return nil
+}
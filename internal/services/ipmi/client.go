@@ -0,0 +1,465 @@
+package ipmi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPort    = "623"
+	defaultTimeout = 2 * time.Second
+
+	maxUsernameLen = 16
+	maxPacketSize  = 1024
+)
+
+// 客户端错误
+var (
+	// ErrNoEndpoint 服务器未配置IPMI地址
+	ErrNoEndpoint = errors.New("server has no ipmi address")
+	// ErrTimeout BMC在重传次数内没有响应
+	ErrTimeout = errors.New("ipmi: bmc did not respond")
+	// ErrAuthFailed RMCP+会话建立失败，通常是用户名或密码错误
+	ErrAuthFailed = errors.New("ipmi: session authentication failed")
+	// ErrUnsupported BMC不支持IPMI v2.0 RMCP+
+	ErrUnsupported = errors.New("ipmi: bmc does not support ipmi v2.0 rmcp+")
+)
+
+// Config IPMI客户端配置，BMC凭据对所有服务器通用
+type Config struct {
+	Username string
+	Password string
+	// Timeout 单次请求等待响应的时间，超时后重传
+	Timeout time.Duration
+	// Retries 超时后的重传次数
+	Retries int
+}
+
+// session 已建立的RMCP+会话
+type session struct {
+	consoleID uint32
+	bmcID     uint32
+	seq       uint32
+	keys      sessionKeys
+}
+
+// Client IPMI v2.0 RMCP+客户端，通过UDP访问单个BMC
+// BMC同一会话内只处理一个未完成请求，所有命令串行执行
+type Client struct {
+	address string
+	config  Config
+
+	mu      sync.Mutex
+	conn    net.Conn
+	session *session
+	rqSeq   uint8
+}
+
+// NewClient 创建IPMI客户端，address为BMC地址，未指定端口时使用623
+func NewClient(address string, config Config) (*Client, error) {
+	address = strings.TrimSpace(address)
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, defaultPort
+	}
+	if host == "" {
+		return nil, fmt.Errorf("invalid ipmi address %q", address)
+	}
+	if len(config.Username) > maxUsernameLen {
+		return nil, fmt.Errorf("ipmi username longer than %d bytes", maxUsernameLen)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+
+	return &Client{
+		address: net.JoinHostPort(host, port),
+		config:  config,
+	}, nil
+}
+
+// Address 返回BMC地址
+func (c *Client) Address() string {
+	return c.address
+}
+
+// Close 关闭会话并释放UDP连接
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	if c.session != nil {
+		_, err = c.command(ctx, netFnApp, cmdCloseSession, le32(c.session.bmcID))
+	}
+	c.reset()
+	return err
+}
+
+// reset 丢弃会话和连接，下次请求时重新建立
+func (c *Client) reset() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.conn = nil
+	c.session = nil
+}
+
+// execute 在会话中执行命令，返回去掉完成码的响应数据
+// 会话被BMC回收时请求会超时，此时重新建立会话并重试一次
+func (c *Client) execute(ctx context.Context, netFn, cmd uint8, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reused := c.session != nil
+	if !reused {
+		if err := c.open(ctx); err != nil {
+			c.reset()
+			return nil, err
+		}
+	}
+
+	resp, err := c.command(ctx, netFn, cmd, data)
+	if errors.Is(err, ErrTimeout) && reused {
+		c.reset()
+		if err := c.open(ctx); err != nil {
+			c.reset()
+			return nil, err
+		}
+		resp, err = c.command(ctx, netFn, cmd, data)
+	}
+	return resp, err
+}
+
+// open 建立RMCP+会话：能力探测、Open Session、RAKP 1-4和提升会话权限
+func (c *Client) open(ctx context.Context) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", c.address)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	if err := c.checkCapabilities(ctx); err != nil {
+		return err
+	}
+
+	params := rakpParams{
+		consoleRN: make([]byte, randomLen),
+		role:      privilegeMax | rakpNameOnlyLookup,
+		username:  c.config.Username,
+	}
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	params.consoleID = binary.LittleEndian.Uint32(id[:]) | 1 // 会话ID不能为0
+	if _, err := rand.Read(params.consoleRN); err != nil {
+		return err
+	}
+
+	if err := c.openSession(ctx, &params); err != nil {
+		return err
+	}
+	kuid := userKey(c.config.Password)
+	if err := c.rakp12(ctx, &params, kuid); err != nil {
+		return err
+	}
+	sik := params.sik(kuid)
+	if err := c.rakp34(ctx, &params, kuid, sik); err != nil {
+		return err
+	}
+
+	c.session = &session{
+		consoleID: params.consoleID,
+		bmcID:     params.bmcID,
+		keys:      deriveKeys(sik),
+	}
+
+	// 会话默认为User权限，电源控制需要Administrator
+	_, err = c.command(ctx, netFnApp, cmdSetSessionPrivilege, []byte{privilegeMax})
+	return err
+}
+
+// checkCapabilities 以IPMI v1.5无认证报文查询通道能力，确认支持RMCP+
+func (c *Client) checkCapabilities(ctx context.Context) error {
+	seq := c.nextSeq()
+	// 通道0x0E表示当前通道，最高位请求IPMI v2.0扩展能力
+	req := message{netFn: netFnApp, cmd: cmdGetChannelAuthCaps, seq: seq, data: []byte{0x8E, privilegeMax}}
+	raw := encodeLegacyPacket(req.encode(false))
+
+	var resp message
+	err := c.roundTrip(ctx, func() ([]byte, error) { return raw, nil }, func(b []byte) bool {
+		msg, err := decodeLegacyPacket(b)
+		if err != nil {
+			return false
+		}
+		m, err := decodeMessage(msg)
+		if err != nil || m.cmd != cmdGetChannelAuthCaps || m.seq != seq {
+			return false
+		}
+		resp = m
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(resp.data) < 1 || resp.data[0] != completionOK {
+		code := uint8(0xFF)
+		if len(resp.data) > 0 {
+			code = resp.data[0]
+		}
+		return &CompletionError{NetFn: netFnApp, Cmd: cmdGetChannelAuthCaps, Code: code}
+	}
+	// data: 完成码, 通道号, 认证类型支持, 认证状态, 扩展能力, OEM...
+	if len(resp.data) < 5 || resp.data[2]&0x80 == 0 || resp.data[4]&0x02 == 0 {
+		return ErrUnsupported
+	}
+	return nil
+}
+
+// openSession 发送Open Session请求，协商cipher suite 3
+func (c *Client) openSession(ctx context.Context, params *rakpParams) error {
+	tag := c.nextSeq()
+	req := make([]byte, 0, 32)
+	req = append(req, tag, privilegeMax, 0x00, 0x00)
+	req = append(req, le32(params.consoleID)...)
+	req = append(req, algorithmPayload(0x00, authAlgHMACSHA1)...)
+	req = append(req, algorithmPayload(0x01, integrityAlgHMACSHA96)...)
+	req = append(req, algorithmPayload(0x02, cryptAlgAESCBC128)...)
+
+	resp, err := c.handshake(ctx, payloadOpenSessionRequest, payloadOpenSessionResponse, req, tag, 36)
+	if err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(resp[4:8]) != params.consoleID {
+		return fmt.Errorf("%w: open session response for another console session", ErrAuthFailed)
+	}
+	params.bmcID = binary.LittleEndian.Uint32(resp[8:12])
+	return nil
+}
+
+// rakp12 发送RAKP1并校验RAKP2，确认BMC持有相同的用户密码
+func (c *Client) rakp12(ctx context.Context, params *rakpParams, kuid []byte) error {
+	tag := c.nextSeq()
+	req := make([]byte, 0, 28+len(params.username))
+	req = append(req, tag, 0x00, 0x00, 0x00)
+	req = append(req, le32(params.bmcID)...)
+	req = append(req, params.consoleRN...)
+	req = append(req, params.role, 0x00, 0x00, byte(len(params.username)))
+	req = append(req, params.username...)
+
+	resp, err := c.handshake(ctx, payloadRAKP1, payloadRAKP2, req, tag, 60)
+	if err != nil {
+		return err
+	}
+	params.bmcRN = append([]byte(nil), resp[8:24]...)
+	params.bmcGUID = append([]byte(nil), resp[24:40]...)
+	if !bytes.Equal(resp[40:60], params.rakp2AuthCode(kuid)) {
+		return fmt.Errorf("%w: invalid password for user %q", ErrAuthFailed, params.username)
+	}
+	return nil
+}
+
+// rakp34 发送RAKP3并校验RAKP4，确认双方的会话密钥一致
+func (c *Client) rakp34(ctx context.Context, params *rakpParams, kuid, sik []byte) error {
+	tag := c.nextSeq()
+	req := make([]byte, 0, 8+rakpHMACLen)
+	req = append(req, tag, 0x00, 0x00, 0x00)
+	req = append(req, le32(params.bmcID)...)
+	req = append(req, params.rakp3AuthCode(kuid)...)
+
+	resp, err := c.handshake(ctx, payloadRAKP3, payloadRAKP4, req, tag, 8+authCodeLen)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(resp[8:8+authCodeLen], params.rakp4ICV(sik)) {
+		return fmt.Errorf("%w: rakp4 integrity check failed", ErrAuthFailed)
+	}
+	return nil
+}
+
+// handshake 发送会话建立报文并等待对应响应，校验消息标签、状态码和最小长度
+func (c *Client) handshake(ctx context.Context, reqType, respType uint8, req []byte, tag uint8, minLen int) ([]byte, error) {
+	raw, err := encodePacket(packet{payloadType: reqType, payload: req}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []byte
+	err = c.roundTrip(ctx, func() ([]byte, error) { return raw, nil }, func(b []byte) bool {
+		p, err := decodePacket(b, func(uint32) *sessionKeys { return nil })
+		if err != nil || p.payloadType != respType || len(p.payload) < 2 || p.payload[0] != tag {
+			return false
+		}
+		resp = p.payload
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status := resp[1]; status != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAuthFailed, rakpStatusText(status))
+	}
+	if len(resp) < minLen {
+		return nil, fmt.Errorf("%w: short handshake response", errMalformed)
+	}
+	return resp, nil
+}
+
+// command 在已建立的会话中发送一条命令
+func (c *Client) command(ctx context.Context, netFn, cmd uint8, data []byte) ([]byte, error) {
+	s := c.session
+	seq := c.nextSeq()
+	req := message{netFn: netFn, cmd: cmd, seq: seq, data: data}.encode(false)
+	lookup := func(id uint32) *sessionKeys {
+		if id != s.consoleID {
+			return nil
+		}
+		return &s.keys
+	}
+
+	var resp message
+	err := c.roundTrip(ctx, func() ([]byte, error) {
+		// 重传时使用新的会话序号，避免被BMC当作重放丢弃
+		s.seq++
+		return encodePacket(packet{payloadType: payloadIPMI, sessionID: s.bmcID, seq: s.seq, payload: req}, &s.keys)
+	}, func(b []byte) bool {
+		p, err := decodePacket(b, lookup)
+		if err != nil || !p.authenticated || p.payloadType != payloadIPMI {
+			return false
+		}
+		m, err := decodeMessage(p.payload)
+		if err != nil || m.netFn != netFn+1 || m.cmd != cmd || m.seq != seq {
+			return false
+		}
+		resp = m
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.data) == 0 {
+		return nil, fmt.Errorf("%w: missing completion code", errMalformed)
+	}
+	if resp.data[0] != completionOK {
+		return nil, &CompletionError{NetFn: netFn, Cmd: cmd, Code: resp.data[0]}
+	}
+	return resp.data[1:], nil
+}
+
+// roundTrip 发送请求并等待accept认可的响应，超时后按配置重传
+// 不匹配的报文（迟到的重传响应等）直接丢弃
+func (c *Client) roundTrip(ctx context.Context, build func() ([]byte, error), accept func([]byte) bool) error {
+	buf := make([]byte, maxPacketSize)
+	for attempt := 0; attempt <= c.config.Retries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req, err := build()
+		if err != nil {
+			return err
+		}
+		if _, err := c.conn.Write(req); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(c.config.Timeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := c.conn.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+
+		for {
+			n, err := c.conn.Read(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return err
+			}
+			if accept(buf[:n]) {
+				return nil
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s after %d attempts", ErrTimeout, c.address, c.config.Retries+1)
+}
+
+// nextSeq 返回下一个6位请求序号，也用作握手消息标签
+func (c *Client) nextSeq() uint8 {
+	c.rqSeq = (c.rqSeq + 1) & 0x3F
+	return c.rqSeq
+}
+
+// DeviceID 查询BMC设备信息
+func (c *Client) DeviceID(ctx context.Context) (*DeviceID, error) {
+	data, err := c.execute(ctx, netFnApp, cmdGetDeviceID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 11 {
+		return nil, fmt.Errorf("%w: short get device id response", errMalformed)
+	}
+	return &DeviceID{
+		DeviceID:        data[0],
+		DeviceRevision:  data[1] & 0x0F,
+		FirmwareVersion: fmt.Sprintf("%d.%02x", data[2]&0x7F, data[3]),
+		IPMIVersion:     fmt.Sprintf("%d.%d", data[4]&0x0F, data[4]>>4),
+		ManufacturerID:  uint32(data[6]) | uint32(data[7])<<8 | uint32(data[8]&0x0F)<<16,
+		ProductID:       binary.LittleEndian.Uint16(data[9:11]),
+	}, nil
+}
+
+// ChassisStatus 查询机箱电源状态
+func (c *Client) ChassisStatus(ctx context.Context) (*ChassisStatus, error) {
+	data, err := c.execute(ctx, netFnChassis, cmdGetChassisStatus, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 3 {
+		return nil, fmt.Errorf("%w: short chassis status response", errMalformed)
+	}
+
+	power, misc := data[0], data[2]
+	policies := []string{"always_off", "previous", "always_on", "unknown"}
+	return &ChassisStatus{
+		PowerOn:           power&0x01 != 0,
+		PowerOverload:     power&0x02 != 0,
+		PowerInterlock:    power&0x04 != 0,
+		PowerFault:        power&0x08 != 0,
+		PowerControlFault: power&0x10 != 0,
+		RestorePolicy:     policies[(power>>5)&0x03],
+		ChassisIntrusion:  misc&0x01 != 0,
+		DriveFault:        misc&0x04 != 0,
+		CoolingFault:      misc&0x08 != 0,
+	}, nil
+}
+
+// ChassisControl 下发机箱电源操作，BMC受理后立即返回，不等待电源状态变化
+func (c *Client) ChassisControl(ctx context.Context, control ChassisControl) error {
+	_, err := c.execute(ctx, netFnChassis, cmdChassisControl, []byte{byte(control)})
+	return err
+}
@@ -0,0 +1,193 @@
+package ipmi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient 启动模拟器并创建指向它的客户端
+func newTestClient(t *testing.T, username, password string) (*Simulator, *Client) {
+	t.Helper()
+	sim, conn := NewTestServer("admin", "secret")
+	t.Cleanup(func() { conn.Close() })
+
+	client, err := NewClient(conn.LocalAddr().String(), Config{
+		Username: username,
+		Password: password,
+		Timeout:  200 * time.Millisecond,
+		Retries:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return sim, client
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		username string
+		want     string
+		wantErr  bool
+	}{
+		{name: "default port", address: "10.0.0.1", want: "10.0.0.1:623"},
+		{name: "explicit port", address: " 10.0.0.1:6230 ", want: "10.0.0.1:6230"},
+		{name: "ipv6", address: "[fd00::1]:623", want: "[fd00::1]:623"},
+		{name: "empty host", address: ":623", wantErr: true},
+		{name: "username too long", address: "10.0.0.1", username: strings.Repeat("u", maxUsernameLen+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.address, Config{Username: tt.username})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewClient(%q) succeeded, want error", tt.address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient(%q) error = %v", tt.address, err)
+			}
+			if c.Address() != tt.want {
+				t.Errorf("Address() = %q, want %q", c.Address(), tt.want)
+			}
+		})
+	}
+}
+
+func TestClientHandshake(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  error
+	}{
+		{name: "valid credentials", username: "admin", password: "secret"},
+		{name: "wrong password", username: "admin", password: "wrong", wantErr: ErrAuthFailed},
+		{name: "unknown user", username: "operator", password: "secret", wantErr: ErrAuthFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, client := newTestClient(t, tt.username, tt.password)
+			ctx := context.Background()
+
+			id, err := client.DeviceID(ctx)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DeviceID() error = %v, want %v", err, tt.wantErr)
+				}
+				if client.session != nil {
+					t.Error("client kept a session after a failed handshake")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeviceID() error = %v", err)
+			}
+			if id.IPMIVersion != "2.0" || id.FirmwareVersion != "2.45" {
+				t.Errorf("DeviceID() = %+v, want ipmi 2.0 firmware 2.45", id)
+			}
+
+			// 会话已提升到Administrator权限，机箱电源控制可以执行
+			sim.mu.Lock()
+			sess := sim.sessions[client.session.bmcID]
+			sim.mu.Unlock()
+			if sess == nil || sess.privilege != privilegeMax {
+				t.Fatalf("bmc session = %+v, want privilege %d", sess, privilegeMax)
+			}
+
+			if err := client.Close(ctx); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			sim.mu.Lock()
+			sessions := len(sim.sessions)
+			sim.mu.Unlock()
+			if sessions != 0 {
+				t.Errorf("%d bmc sessions left after Close()", sessions)
+			}
+		})
+	}
+}
+
+func TestClientReopensExpiredSession(t *testing.T) {
+	sim, client := newTestClient(t, "admin", "secret")
+	ctx := context.Background()
+
+	if _, err := client.ChassisStatus(ctx); err != nil {
+		t.Fatalf("ChassisStatus() error = %v", err)
+	}
+	first := client.session.bmcID
+
+	// BMC回收会话后不再响应旧会话的请求，客户端超时后重新握手
+	sim.ExpireSessions()
+	if _, err := client.ChassisStatus(ctx); err != nil {
+		t.Fatalf("ChassisStatus() after session expiry error = %v", err)
+	}
+	if client.session.bmcID == first {
+		t.Error("client reused the expired session")
+	}
+}
+
+func TestClientChassisControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial bool
+		control ChassisControl
+		want    bool
+	}{
+		{name: "power up", initial: false, control: ChassisPowerUp, want: true},
+		{name: "power down", initial: true, control: ChassisPowerDown, want: false},
+		{name: "soft shutdown", initial: true, control: ChassisSoftShutdown, want: false},
+		{name: "power cycle", initial: true, control: ChassisPowerCycle, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, client := newTestClient(t, "admin", "secret")
+			sim.SetPowerDelay(0)
+			sim.SetPowerOn(tt.initial)
+			ctx := context.Background()
+
+			if err := client.ChassisControl(ctx, tt.control); err != nil {
+				t.Fatalf("ChassisControl(%s) error = %v", tt.control, err)
+			}
+			status, err := client.ChassisStatus(ctx)
+			if err != nil {
+				t.Fatalf("ChassisStatus() error = %v", err)
+			}
+			if status.PowerOn != tt.want {
+				t.Errorf("PowerOn = %v, want %v", status.PowerOn, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	// 监听后不处理报文，模拟不响应的BMC
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	client, err := NewClient(conn.LocalAddr().String(), Config{
+		Username: "admin",
+		Password: "secret",
+		Timeout:  50 * time.Millisecond,
+		Retries:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.DeviceID(context.Background()); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("DeviceID() error = %v, want %v", err, ErrTimeout)
+	}
+}
@@ -0,0 +1,56 @@
+package ipmi
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool 按BMC地址复用IPMI客户端，会话建立需要多次握手，应尽量复用
+type Pool struct {
+	config Config
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewPool 创建IPMI客户端池
+func NewPool(config Config) *Pool {
+	return &Pool{
+		config:  config,
+		clients: map[string]*Client{},
+	}
+}
+
+// Client 返回address对应的客户端，不存在时创建
+func (p *Pool) Client(address string) (*Client, error) {
+	if address == "" {
+		return nil, ErrNoEndpoint
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[address]; ok {
+		return client, nil
+	}
+
+	client, err := NewClient(address, p.config)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[address] = client
+	return client, nil
+}
+
+// Close 关闭所有会话，BMC会话数有限，退出时应主动释放
+func (p *Pool) Close(ctx context.Context) {
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = map[string]*Client{}
+	p.mu.Unlock()
+
+	for _, client := range clients {
+		// 关闭失败时会话会在BMC侧超时，不影响退出
+		_ = client.Close(ctx)
+	}
+}
@@ -0,0 +1,340 @@
+package ipmi
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// RMCP/RMCP+ 报文常量，客户端和模拟器共用
+const (
+	rmcpVersion   = 0x06
+	rmcpNoAck     = 0xFF
+	rmcpClassIPMI = 0x07
+	rmcpHeaderLen = 4
+
+	authTypeNone     = 0x00
+	authTypeRMCPPlus = 0x06
+
+	payloadIPMI                = 0x00
+	payloadOpenSessionRequest  = 0x10
+	payloadOpenSessionResponse = 0x11
+	payloadRAKP1               = 0x12
+	payloadRAKP2               = 0x13
+	payloadRAKP3               = 0x14
+	payloadRAKP4               = 0x15
+
+	payloadEncrypted     = 0x80
+	payloadAuthenticated = 0x40
+	payloadTypeMask      = 0x3F
+
+	// 会话头：AuthType + PayloadType + SessionID(4) + Seq(4) + Length(2)
+	sessionHeaderLen = 12
+	nextHeaderIPMI   = 0x07
+
+	bmcAddress     = 0x20
+	consoleAddress = 0x81
+
+	// 只实现cipher suite 3：RAKP-HMAC-SHA1 / HMAC-SHA1-96 / AES-CBC-128
+	authAlgHMACSHA1       = 0x01
+	integrityAlgHMACSHA96 = 0x01
+	cryptAlgAESCBC128     = 0x01
+
+	authCodeLen  = 12
+	rakpHMACLen  = sha1.Size
+	randomLen    = 16
+	guidLen      = 16
+	privilegeMax = 0x04 // Administrator
+
+	// rakpNameOnlyLookup RAKP1中的角色位，按用户名查找而非用户名+权限
+	rakpNameOnlyLookup = 0x10
+)
+
+// errMalformed 报文格式错误，接收方直接丢弃
+var errMalformed = errors.New("malformed ipmi packet")
+
+// sessionKeys 会话密钥，RAKP握手完成后由SIK派生
+type sessionKeys struct {
+	integrity []byte // K1
+	crypt     []byte // K2的前16字节
+}
+
+// userKey 用户密码作为K_UID，按规范补零到20字节
+func userKey(password string) []byte {
+	key := make([]byte, rakpHMACLen)
+	copy(key, password)
+	return key
+}
+
+// hmacSHA1 计算多个字段拼接后的HMAC-SHA1
+func hmacSHA1(key []byte, parts ...[]byte) []byte {
+	mac := hmac.New(sha1.New, key)
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return mac.Sum(nil)
+}
+
+// deriveKeys 由会话完整性密钥SIK派生K1和K2
+func deriveKeys(sik []byte) sessionKeys {
+	return sessionKeys{
+		integrity: hmacSHA1(sik, bytes.Repeat([]byte{0x01}, rakpHMACLen)),
+		crypt:     hmacSHA1(sik, bytes.Repeat([]byte{0x02}, rakpHMACLen))[:aes.BlockSize],
+	}
+}
+
+// rakpParams RAKP握手双方都参与计算的字段
+type rakpParams struct {
+	consoleID uint32
+	bmcID     uint32
+	consoleRN []byte
+	bmcRN     []byte
+	bmcGUID   []byte
+	role      byte
+	username  string
+}
+
+// le32 小端编码uint32
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+// rakp2AuthCode RAKP2中BMC证明自己持有用户密码
+func (p rakpParams) rakp2AuthCode(kuid []byte) []byte {
+	return hmacSHA1(kuid, le32(p.consoleID), le32(p.bmcID), p.consoleRN, p.bmcRN, p.bmcGUID,
+		[]byte{p.role, byte(len(p.username))}, []byte(p.username))
+}
+
+// rakp3AuthCode RAKP3中控制台证明自己持有用户密码
+func (p rakpParams) rakp3AuthCode(kuid []byte) []byte {
+	return hmacSHA1(kuid, p.bmcRN, le32(p.consoleID), []byte{p.role, byte(len(p.username))}, []byte(p.username))
+}
+
+// sik 会话完整性密钥，未配置BMC密钥K_G时使用K_UID
+func (p rakpParams) sik(kuid []byte) []byte {
+	return hmacSHA1(kuid, p.consoleRN, p.bmcRN, []byte{p.role, byte(len(p.username))}, []byte(p.username))
+}
+
+// rakp4ICV RAKP4中BMC证明会话密钥一致，截断为12字节
+func (p rakpParams) rakp4ICV(sik []byte) []byte {
+	return hmacSHA1(sik, p.consoleRN, le32(p.bmcID), p.bmcGUID)[:authCodeLen]
+}
+
+// message IPMI LAN消息，请求和响应格式相同，响应的data首字节为完成码
+type message struct {
+	netFn uint8
+	cmd   uint8
+	seq   uint8
+	data  []byte
+}
+
+// checksum IPMI二进制补码校验和
+func checksum(b []byte) byte {
+	var sum byte
+	for _, v := range b {
+		sum += v
+	}
+	return -sum
+}
+
+// encode 编码消息，请求由控制台发往BMC，响应方向相反
+func (m message) encode(response bool) []byte {
+	rsAddr, rqAddr := byte(bmcAddress), byte(consoleAddress)
+	if response {
+		rsAddr, rqAddr = rqAddr, rsAddr
+	}
+	b := make([]byte, 0, 7+len(m.data))
+	b = append(b, rsAddr, m.netFn<<2)
+	b = append(b, checksum(b))
+	b = append(b, rqAddr, m.seq<<2, m.cmd)
+	b = append(b, m.data...)
+	return append(b, checksum(b[3:]))
+}
+
+// decodeMessage 解码并校验IPMI LAN消息
+func decodeMessage(b []byte) (message, error) {
+	if len(b) < 7 {
+		return message{}, errMalformed
+	}
+	if checksum(b[:2]) != b[2] || checksum(b[3:len(b)-1]) != b[len(b)-1] {
+		return message{}, fmt.Errorf("%w: bad checksum", errMalformed)
+	}
+	return message{
+		netFn: b[1] >> 2,
+		seq:   b[4] >> 2,
+		cmd:   b[5],
+		data:  append([]byte(nil), b[6:len(b)-1]...),
+	}, nil
+}
+
+// packet RMCP+会话报文，payload为明文
+type packet struct {
+	payloadType uint8
+	sessionID   uint32
+	seq         uint32
+	payload     []byte
+	// authenticated 解码时完整性校验已通过
+	authenticated bool
+}
+
+// encodePacket 编码RMCP+报文，keys非空时加密并附加完整性校验
+func encodePacket(p packet, keys *sessionKeys) ([]byte, error) {
+	payloadType := p.payloadType
+	payload := p.payload
+	if keys != nil {
+		encrypted, err := encryptPayload(keys.crypt, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = encrypted
+		payloadType |= payloadEncrypted | payloadAuthenticated
+	}
+
+	b := make([]byte, 0, rmcpHeaderLen+sessionHeaderLen+len(payload)+32)
+	b = append(b, rmcpVersion, 0x00, rmcpNoAck, rmcpClassIPMI)
+	b = append(b, authTypeRMCPPlus, payloadType)
+	b = binary.LittleEndian.AppendUint32(b, p.sessionID)
+	b = binary.LittleEndian.AppendUint32(b, p.seq)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(payload)))
+	b = append(b, payload...)
+
+	if keys != nil {
+		// 完整性覆盖范围从AuthType到Next Header，需按4字节对齐
+		covered := len(b) - rmcpHeaderLen + 2
+		pad := (4 - covered%4) % 4
+		b = append(b, bytes.Repeat([]byte{0xFF}, pad)...)
+		b = append(b, byte(pad), nextHeaderIPMI)
+		b = append(b, hmacSHA1(keys.integrity, b[rmcpHeaderLen:])[:authCodeLen]...)
+	}
+	return b, nil
+}
+
+// decodePacket 解码RMCP+报文，lookup按会话ID返回校验和解密所需的密钥
+// 已认证的报文找不到密钥或校验失败时返回错误，由调用方丢弃
+func decodePacket(b []byte, lookup func(sessionID uint32) *sessionKeys) (packet, error) {
+	if len(b) < rmcpHeaderLen+sessionHeaderLen || b[0] != rmcpVersion || b[3] != rmcpClassIPMI || b[4] != authTypeRMCPPlus {
+		return packet{}, errMalformed
+	}
+
+	flags := b[5]
+	p := packet{
+		payloadType: flags & payloadTypeMask,
+		sessionID:   binary.LittleEndian.Uint32(b[6:10]),
+		seq:         binary.LittleEndian.Uint32(b[10:14]),
+	}
+	length := int(binary.LittleEndian.Uint16(b[14:16]))
+	end := rmcpHeaderLen + sessionHeaderLen + length
+	if len(b) < end {
+		return packet{}, fmt.Errorf("%w: truncated payload", errMalformed)
+	}
+	payload := b[rmcpHeaderLen+sessionHeaderLen : end]
+
+	if flags&(payloadAuthenticated|payloadEncrypted) == 0 {
+		p.payload = append([]byte(nil), payload...)
+		return p, nil
+	}
+
+	keys := lookup(p.sessionID)
+	if keys == nil {
+		return packet{}, fmt.Errorf("%w: unknown session 0x%08x", errMalformed, p.sessionID)
+	}
+
+	if flags&payloadAuthenticated != 0 {
+		p.authenticated = true
+		if len(b) < end+2+authCodeLen {
+			return packet{}, fmt.Errorf("%w: truncated trailer", errMalformed)
+		}
+		authStart := len(b) - authCodeLen
+		expected := hmacSHA1(keys.integrity, b[rmcpHeaderLen:authStart])[:authCodeLen]
+		if !hmac.Equal(expected, b[authStart:]) {
+			return packet{}, fmt.Errorf("%w: integrity check failed", errMalformed)
+		}
+	}
+
+	if flags&payloadEncrypted != 0 {
+		plain, err := decryptPayload(keys.crypt, payload)
+		if err != nil {
+			return packet{}, err
+		}
+		p.payload = plain
+	} else {
+		p.payload = append([]byte(nil), payload...)
+	}
+	return p, nil
+}
+
+// encryptPayload AES-CBC-128加密，输出为IV加密文，明文尾部填充1,2,3...和填充长度
+func encryptPayload(key, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	pad := (aes.BlockSize - (len(plain)+1)%aes.BlockSize) % aes.BlockSize
+	buf := make([]byte, 0, len(plain)+pad+1)
+	buf = append(buf, plain...)
+	for i := 1; i <= pad; i++ {
+		buf = append(buf, byte(i))
+	}
+	buf = append(buf, byte(pad))
+
+	out := make([]byte, aes.BlockSize+len(buf))
+	if _, err := rand.Read(out[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], buf)
+	return out, nil
+}
+
+// decryptPayload 解密AES-CBC-128负载并去除填充
+func decryptPayload(key, data []byte) ([]byte, error) {
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: bad encrypted payload length %d", errMalformed, len(data))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plain, data[aes.BlockSize:])
+	pad := int(plain[len(plain)-1])
+	if pad+1 > len(plain) {
+		return nil, fmt.Errorf("%w: bad confidentiality pad", errMalformed)
+	}
+	return plain[:len(plain)-1-pad], nil
+}
+
+// encodeLegacyPacket 编码IPMI v1.5无认证报文，仅用于会话建立前的Get Channel Authentication Capabilities
+func encodeLegacyPacket(msg []byte) []byte {
+	b := make([]byte, 0, rmcpHeaderLen+10+len(msg))
+	b = append(b, rmcpVersion, 0x00, rmcpNoAck, rmcpClassIPMI)
+	b = append(b, authTypeNone, 0, 0, 0, 0, 0, 0, 0, 0, byte(len(msg)))
+	return append(b, msg...)
+}
+
+// decodeLegacyPacket 解码IPMI v1.5无认证报文，返回其中的IPMI消息
+func decodeLegacyPacket(b []byte) ([]byte, error) {
+	const headerLen = rmcpHeaderLen + 10
+	if len(b) < headerLen || b[0] != rmcpVersion || b[3] != rmcpClassIPMI || b[4] != authTypeNone {
+		return nil, errMalformed
+	}
+	length := int(b[headerLen-1])
+	if len(b) < headerLen+length {
+		return nil, fmt.Errorf("%w: truncated message", errMalformed)
+	}
+	return b[headerLen : headerLen+length], nil
+}
+
+// algorithmPayload Open Session请求和响应中的算法描述
+func algorithmPayload(payloadType, algorithm byte) []byte {
+	return []byte{payloadType, 0x00, 0x00, 0x08, algorithm, 0x00, 0x00, 0x00}
+}
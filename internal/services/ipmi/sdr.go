@@ -0,0 +1,421 @@
+package ipmi
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	sdrHeaderLen       = 5
+	sdrTypeFull        = 0x01
+	sdrChunkSize       = 16 // 很多BMC不支持一次读取整条记录，按16字节分段读取
+	sdrLastRecord      = 0xFFFF
+	eventTypeThreshold = 0x01
+	eventTypeSpecific  = 0x6F
+	selRecordLen       = 16
+	selTypeSystem      = 0x02
+	selTypeOEMStamped  = 0xC0
+	selTypeOEMRaw      = 0xE0
+
+	// maxRecords 防止BMC返回的记录链成环
+	maxRecords = 4096
+)
+
+// fullSensorRecord SDR类型01h：完整传感器记录中用于读数换算的字段
+type fullSensorRecord struct {
+	owner       uint8
+	lun         uint8
+	number      uint8
+	sensorType  uint8
+	readingType uint8
+	format      uint8 // 0无符号 1反码 2补码 3无模拟读数
+	unit        uint8
+	percentage  bool
+	linear      uint8
+	m, b        int
+	bExp, rExp  int
+	name        string
+}
+
+// parseFullSensorRecord 解析完整传感器记录，其他类型的记录返回false
+func parseFullSensorRecord(r []byte) (*fullSensorRecord, bool) {
+	if len(r) < 48 || r[3] != sdrTypeFull {
+		return nil, false
+	}
+
+	rec := &fullSensorRecord{
+		owner:       r[5],
+		lun:         r[6] & 0x03,
+		number:      r[7],
+		sensorType:  r[12],
+		readingType: r[13],
+		format:      r[20] >> 6,
+		percentage:  r[20]&0x01 != 0,
+		unit:        r[21],
+		linear:      r[23] & 0x7F,
+		m:           signExtend(int(r[24])|int(r[25]&0xC0)<<2, 10),
+		b:           signExtend(int(r[26])|int(r[27]&0xC0)<<2, 10),
+		rExp:        signExtend(int(r[29]>>4), 4),
+		bExp:        signExtend(int(r[29]&0x0F), 4),
+	}
+
+	// ID字符串类型/长度字节的低5位为长度，这里只处理8位ASCII
+	nameLen := int(r[47] & 0x1F)
+	if 48+nameLen <= len(r) {
+		rec.name = strings.TrimRight(string(r[48:48+nameLen]), "\x00 ")
+	}
+	return rec, true
+}
+
+// signExtend 将bits位的补码扩展为int
+func signExtend(v, bits int) int {
+	if v&(1<<(bits-1)) != 0 {
+		return v - 1<<bits
+	}
+	return v
+}
+
+// convert 按 y = L[(M*x + B*10^Bexp) * 10^Rexp] 将原始读数换算为实际值
+func (r *fullSensorRecord) convert(raw byte) (float64, bool) {
+	var x float64
+	switch r.format {
+	case 0:
+		x = float64(raw)
+	case 1:
+		v := int(int8(raw))
+		if v < 0 {
+			v++
+		}
+		x = float64(v)
+	case 2:
+		x = float64(int8(raw))
+	default:
+		return 0, false
+	}
+
+	y := (float64(r.m)*x + float64(r.b)*math.Pow10(r.bExp)) * math.Pow10(r.rExp)
+	switch r.linear {
+	case 0:
+	case 1:
+		y = math.Log(y)
+	case 2:
+		y = math.Log10(y)
+	case 3:
+		y = math.Log2(y)
+	case 4:
+		y = math.Exp(y)
+	case 5:
+		y = math.Pow(10, y)
+	case 6:
+		y = math.Exp2(y)
+	case 7:
+		y = 1 / y
+	case 8:
+		y = y * y
+	case 9:
+		y = y * y * y
+	case 10:
+		y = math.Sqrt(y)
+	case 11:
+		y = math.Cbrt(y)
+	default:
+		// 非线性传感器需要Get Sensor Reading Factors，暂不支持
+		return 0, false
+	}
+	return math.Round(y*1000) / 1000, true
+}
+
+// unitName 返回传感器单位
+func (r *fullSensorRecord) unitName() string {
+	if r.percentage {
+		return "%"
+	}
+	return sensorUnits[r.unit]
+}
+
+// thresholdStatus 由Get Sensor Reading返回的阈值比较位得到传感器状态
+func thresholdStatus(bits byte) string {
+	switch {
+	case bits&0x24 != 0:
+		return SensorStatusNonRecoverable
+	case bits&0x12 != 0:
+		return SensorStatusCritical
+	case bits&0x09 != 0:
+		return SensorStatusWarning
+	default:
+		return SensorStatusOK
+	}
+}
+
+// Sensors 遍历SDR仓库并读取所有阈值型传感器的当前值
+// 只读取BMC自身（从地址0x20、LUN 0）拥有的传感器，其他控制器的传感器需要桥接
+func (c *Client) Sensors(ctx context.Context) ([]Sensor, error) {
+	records, err := c.sdrRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sensors := []Sensor{}
+	for _, raw := range records {
+		rec, ok := parseFullSensorRecord(raw)
+		if !ok || rec.readingType != eventTypeThreshold || rec.owner != bmcAddress || rec.lun != 0 {
+			continue
+		}
+
+		sensor := Sensor{
+			Number: rec.number,
+			Name:   rec.name,
+			Type:   sensorTypeName(rec.sensorType),
+			Unit:   rec.unitName(),
+			Status: SensorStatusUnavailable,
+		}
+
+		data, err := c.execute(ctx, netFnSensorEvent, cmdGetSensorReading, []byte{rec.number})
+		var completionErr *CompletionError
+		if errors.As(err, &completionErr) {
+			// 传感器不存在或暂不可读时保留为unavailable，不影响其他传感器
+			sensors = append(sensors, sensor)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// data: 读数, 状态位(bit6=0表示禁止扫描, bit5=1表示读数不可用), 阈值比较位
+		if len(data) >= 2 && data[1]&0x40 != 0 && data[1]&0x20 == 0 {
+			if value, ok := rec.convert(data[0]); ok {
+				sensor.Reading = &value
+				sensor.Status = SensorStatusOK
+				if len(data) >= 3 {
+					sensor.Status = thresholdStatus(data[2])
+				}
+			}
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors, nil
+}
+
+// sdrRecords 读取SDR仓库中的全部记录
+func (c *Client) sdrRecords(ctx context.Context) ([][]byte, error) {
+	reservation, err := c.reserve(ctx, netFnStorage, cmdReserveSDRRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	var records [][]byte
+	id := uint16(0)
+	for len(records) < maxRecords {
+		record, next, err := c.sdrRecord(ctx, &reservation, id)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+		if next == sdrLastRecord || next == id {
+			return records, nil
+		}
+		id = next
+	}
+	return records, nil
+}
+
+// sdrRecord 分段读取一条SDR记录，预留被取消时重新预留并从头读取
+func (c *Client) sdrRecord(ctx context.Context, reservation *uint16, id uint16) ([]byte, uint16, error) {
+	for attempt := 0; ; attempt++ {
+		record, next, err := c.readSDR(ctx, *reservation, id)
+		var completionErr *CompletionError
+		if errors.As(err, &completionErr) && completionErr.Code == completionReservationLost && attempt < 3 {
+			if *reservation, err = c.reserve(ctx, netFnStorage, cmdReserveSDRRepository); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		return record, next, err
+	}
+}
+
+// readSDR 先读取记录头获得长度，再按块读取记录体
+func (c *Client) readSDR(ctx context.Context, reservation, id uint16) ([]byte, uint16, error) {
+	header, next, err := c.getSDR(ctx, reservation, id, 0, sdrHeaderLen)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(header) < sdrHeaderLen {
+		return nil, 0, fmt.Errorf("%w: short sdr header", errMalformed)
+	}
+
+	// 请求0000h时记录头中是实际的记录ID，后续分段读取必须使用它
+	recordID := binary.LittleEndian.Uint16(header[0:2])
+	total := sdrHeaderLen + int(header[4])
+	record := append(make([]byte, 0, total), header...)
+	for len(record) < total {
+		size := total - len(record)
+		if size > sdrChunkSize {
+			size = sdrChunkSize
+		}
+		chunk, _, err := c.getSDR(ctx, reservation, recordID, byte(len(record)), byte(size))
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(chunk) == 0 {
+			return nil, 0, fmt.Errorf("%w: empty sdr chunk", errMalformed)
+		}
+		record = append(record, chunk...)
+	}
+	return record[:total], next, nil
+}
+
+// getSDR 执行Get SDR命令，返回记录数据和下一条记录ID
+func (c *Client) getSDR(ctx context.Context, reservation, id uint16, offset, size byte) ([]byte, uint16, error) {
+	req := make([]byte, 0, 6)
+	req = binary.LittleEndian.AppendUint16(req, reservation)
+	req = binary.LittleEndian.AppendUint16(req, id)
+	req = append(req, offset, size)
+
+	data, err := c.execute(ctx, netFnStorage, cmdGetSDR, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("%w: short get sdr response", errMalformed)
+	}
+	return data[2:], binary.LittleEndian.Uint16(data[0:2]), nil
+}
+
+// reserve 执行Reserve SDR Repository或Reserve SEL，返回预留ID
+func (c *Client) reserve(ctx context.Context, netFn, cmd uint8) (uint16, error) {
+	data, err := c.execute(ctx, netFn, cmd, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("%w: short reservation response", errMalformed)
+	}
+	return binary.LittleEndian.Uint16(data[0:2]), nil
+}
+
+// SEL 按记录顺序读取全部系统事件日志
+func (c *Client) SEL(ctx context.Context) ([]SELEntry, error) {
+	info, err := c.execute(ctx, netFnStorage, cmdGetSELInfo, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(info) < 3 {
+		return nil, fmt.Errorf("%w: short sel info response", errMalformed)
+	}
+	entries := []SELEntry{}
+	if binary.LittleEndian.Uint16(info[1:3]) == 0 {
+		return entries, nil
+	}
+
+	id := uint16(0)
+	for len(entries) < maxRecords {
+		// 一次读取整条记录（偏移0、长度FFh）不需要预留
+		req := []byte{0x00, 0x00, byte(id), byte(id >> 8), 0x00, 0xFF}
+		data, err := c.execute(ctx, netFnStorage, cmdGetSELEntry, req)
+		var completionErr *CompletionError
+		if errors.As(err, &completionErr) && completionErr.Code == completionNotPresent {
+			// 读取过程中日志被清除
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 2+selRecordLen {
+			return nil, fmt.Errorf("%w: short sel entry", errMalformed)
+		}
+
+		entries = append(entries, parseSELRecord(data[2:2+selRecordLen]))
+		next := binary.LittleEndian.Uint16(data[0:2])
+		if next == sdrLastRecord || next == id {
+			break
+		}
+		id = next
+	}
+	return entries, nil
+}
+
+// parseSELRecord 解析16字节的SEL记录
+func parseSELRecord(r []byte) SELEntry {
+	entry := SELEntry{
+		ID:         binary.LittleEndian.Uint16(r[0:2]),
+		RecordType: r[2],
+	}
+
+	switch {
+	case entry.RecordType == selTypeSystem:
+		entry.Timestamp = time.Unix(int64(binary.LittleEndian.Uint32(r[3:7])), 0).UTC()
+		entry.GeneratorID = binary.LittleEndian.Uint16(r[7:9])
+		entry.SensorType = sensorTypeName(r[10])
+		entry.SensorNumber = r[11]
+		entry.Assertion = r[12]&0x80 == 0
+		entry.EventType = r[12] & 0x7F
+		entry.EventData = hex.EncodeToString(r[13:16])
+		entry.Description = describeEvent(r[10], entry.EventType, r[13]&0x0F)
+	case entry.RecordType >= selTypeOEMStamped && entry.RecordType < selTypeOEMRaw:
+		entry.Timestamp = time.Unix(int64(binary.LittleEndian.Uint32(r[3:7])), 0).UTC()
+		entry.EventData = hex.EncodeToString(r[7:16])
+		entry.Description = "OEM timestamped record"
+	default:
+		entry.EventData = hex.EncodeToString(r[3:16])
+		entry.Description = "OEM record"
+	}
+	return entry
+}
+
+// thresholdEvents 阈值型事件偏移说明（IPMI v2.0 表42-2）
+var thresholdEvents = []string{
+	"Lower Non-critical going low",
+	"Lower Non-critical going high",
+	"Lower Critical going low",
+	"Lower Critical going high",
+	"Lower Non-recoverable going low",
+	"Lower Non-recoverable going high",
+	"Upper Non-critical going low",
+	"Upper Non-critical going high",
+	"Upper Critical going low",
+	"Upper Critical going high",
+	"Upper Non-recoverable going low",
+	"Upper Non-recoverable going high",
+}
+
+// specificEvents 常见传感器专用事件偏移说明（IPMI v2.0 表42-3）
+var specificEvents = map[uint8][]string{
+	0x07: {"IERR", "Thermal Trip", "FRB1/BIST failure", "FRB2/Hang in POST failure", "FRB3/Processor startup failure",
+		"Configuration Error", "Uncorrectable CPU-complex Error", "Presence detected", "Processor disabled",
+		"Terminator presence detected", "Processor automatically throttled", "Machine Check Exception"},
+	0x08: {"Presence detected", "Power Supply Failure detected", "Predictive Failure", "Power Supply input lost (AC/DC)",
+		"Power Supply input lost or out-of-range", "Power Supply input out-of-range, but present", "Configuration error"},
+	0x09: {"Power Off / Power Down", "Power Cycle", "240VA Power Down", "Interlock Power Down", "AC lost",
+		"Soft Power Control Failure", "Power Unit Failure detected", "Predictive Failure"},
+	0x0C: {"Correctable ECC", "Uncorrectable ECC", "Parity", "Memory Scrub Failed", "Memory Device Disabled",
+		"Correctable ECC logging limit reached", "Presence detected", "Configuration error", "Spare",
+		"Memory Automatically Throttled", "Critical Overtemperature"},
+	0x10: {"Correctable Memory Error Logging Disabled", "Event Type Logging Disabled", "Log Area Reset/Cleared",
+		"All Event Logging Disabled", "SEL Full", "SEL Almost Full"},
+	0x12: {"System Reconfigured", "OEM System Boot Event", "Undetermined system hardware failure",
+		"Entry added to Auxiliary Log", "PEF Action", "Timestamp Clock Synch"},
+	0x1D: {"Initiated by power up", "Initiated by hard reset", "Initiated by warm reset",
+		"User requested PXE boot", "Automatic boot to diagnostic"},
+}
+
+// describeEvent 返回事件的可读说明，未收录的事件返回偏移量
+func describeEvent(sensorType, eventType, offset uint8) string {
+	switch eventType {
+	case eventTypeThreshold:
+		if int(offset) < len(thresholdEvents) {
+			return thresholdEvents[offset]
+		}
+	case eventTypeSpecific:
+		if names, ok := specificEvents[sensorType]; ok && int(offset) < len(names) {
+			return names[offset]
+		}
+	}
+	return fmt.Sprintf("event type 0x%02x offset 0x%02x", eventType, offset)
+}
@@ -0,0 +1,600 @@
+package ipmi
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// simSessionTimeout 空闲会话的回收时间，与常见BMC一致
+	simSessionTimeout = 60 * time.Second
+	simMaxSessions    = 32
+	simSELCapacity    = 1024
+
+	// simPowerUnitSensor 模拟器记录电源事件使用的传感器编号
+	simPowerUnitSensor = 0x50
+	simBootSensor      = 0x51
+	simEventLogSensor  = 0x52
+)
+
+// simSensor 模拟器中的阈值型传感器，读数以原始值保存
+type simSensor struct {
+	number     uint8
+	name       string
+	sensorType uint8
+	entity     uint8
+	unit       uint8
+	m          int
+	rExp       int
+	raw        byte
+	// offRaw 关机时的原始读数，小于0表示关机时不可读
+	offRaw        int
+	upperCritical byte
+}
+
+// simSession 模拟器中的会话，keys在RAKP3校验通过后才设置
+type simSession struct {
+	params       rakpParams
+	keys         *sessionKeys
+	privilege    byte
+	seq          uint32
+	lastActivity time.Time
+}
+
+// simPowerChange 延迟生效的电源状态变化
+type simPowerChange struct {
+	on  bool
+	due time.Time
+}
+
+// Simulator 内存中的IPMI BMC模拟器，监听UDP
+// 实现RMCP+会话（cipher suite 3）、机箱电源、SDR传感器和SEL命令，用于离线联调和测试
+type Simulator struct {
+	username string
+	password string
+	guid     []byte
+
+	mu             sync.Mutex
+	sessions       map[uint32]*simSession
+	powerOn        bool
+	powerDelay     time.Duration
+	pending        *simPowerChange
+	sensors        []simSensor
+	sdr            [][]byte
+	sel            [][]byte
+	nextSELID      uint16
+	sdrReservation uint16
+	selReservation uint16
+}
+
+// NewSimulator 创建模拟器，调用Serve开始处理请求
+func NewSimulator(username, password string) *Simulator {
+	s := &Simulator{
+		username:   username,
+		password:   password,
+		guid:       make([]byte, guidLen),
+		sessions:   map[uint32]*simSession{},
+		powerOn:    true,
+		powerDelay: time.Second,
+		nextSELID:  1,
+		sensors: []simSensor{
+			{number: 0x01, name: "CPU1 Temp", sensorType: 0x01, entity: 0x03, unit: 1, m: 1, raw: 48, offRaw: -1, upperCritical: 90},
+			{number: 0x02, name: "Inlet Temp", sensorType: 0x01, entity: 0x37, unit: 1, m: 1, raw: 24, offRaw: 23, upperCritical: 42},
+			{number: 0x10, name: "FAN1", sensorType: 0x04, entity: 0x1D, unit: 18, m: 60, raw: 110, offRaw: -1, upperCritical: 255},
+			{number: 0x20, name: "12V", sensorType: 0x02, entity: 0x07, unit: 4, m: 6, rExp: -2, raw: 200, offRaw: -1, upperCritical: 220},
+			{number: 0x30, name: "PSU1 Input Power", sensorType: 0x0B, entity: 0x0A, unit: 6, m: 4, raw: 112, offRaw: 3, upperCritical: 250},
+		},
+	}
+	rand.Read(s.guid)
+	for i, sensor := range s.sensors {
+		s.sdr = append(s.sdr, sensor.record(uint16(i+1)))
+	}
+	s.addSELEvent(0x10, simEventLogSensor, eventTypeSpecific, 0x02, true)
+	return s
+}
+
+// NewTestServer 创建模拟器并在127.0.0.1的随机端口上启动，调用方负责关闭返回的连接
+func NewTestServer(username, password string) (*Simulator, net.PacketConn) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("ipmi: failed to listen on a port: %v", err))
+	}
+	sim := NewSimulator(username, password)
+	go sim.Serve(conn)
+	return sim, conn
+}
+
+// SetPowerDelay 设置开机和软关机从受理到生效的耗时
+func (s *Simulator) SetPowerDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.powerDelay = d
+}
+
+// SetPowerOn 直接设置电源状态
+func (s *Simulator) SetPowerOn(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = nil
+	s.powerOn = on
+}
+
+// PowerOn 返回当前电源状态
+func (s *Simulator) PowerOn() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+	return s.powerOn
+}
+
+// ExpireSessions 回收所有会话，模拟BMC会话超时
+func (s *Simulator) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[uint32]*simSession{}
+}
+
+// Serve 在conn上处理请求，直到conn被关闭
+func (s *Simulator) Serve(conn net.PacketConn) error {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if resp := s.handle(buf[:n]); resp != nil {
+			conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// handle 处理一个UDP报文，返回响应，无法识别的报文返回nil（真实BMC同样静默丢弃）
+func (s *Simulator) handle(b []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(b) <= rmcpHeaderLen {
+		return nil
+	}
+	s.expireIdle()
+	s.advance()
+
+	if b[rmcpHeaderLen] == authTypeNone {
+		return s.handleLegacy(b)
+	}
+
+	p, err := decodePacket(b, func(id uint32) *sessionKeys {
+		if sess, ok := s.sessions[id]; ok {
+			return sess.keys
+		}
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+
+	switch p.payloadType {
+	case payloadOpenSessionRequest:
+		return s.handleOpenSession(p.payload)
+	case payloadRAKP1:
+		return s.handleRAKP1(p.payload)
+	case payloadRAKP3:
+		return s.handleRAKP3(p.payload)
+	case payloadIPMI:
+		sess, ok := s.sessions[p.sessionID]
+		if !ok || !p.authenticated {
+			return nil
+		}
+		return s.handleCommand(p.sessionID, sess, p.payload)
+	default:
+		return nil
+	}
+}
+
+// handleLegacy 处理会话建立前的IPMI v1.5报文，只支持Get Channel Authentication Capabilities
+func (s *Simulator) handleLegacy(b []byte) []byte {
+	msg, err := decodeLegacyPacket(b)
+	if err != nil {
+		return nil
+	}
+	req, err := decodeMessage(msg)
+	if err != nil || req.netFn != netFnApp || req.cmd != cmdGetChannelAuthCaps {
+		return nil
+	}
+	// 通道1；支持IPMI v2.0扩展能力；非匿名登录；支持IPMI v2.0连接
+	data := []byte{completionOK, 0x01, 0x80, 0x04, 0x02, 0x00, 0x00, 0x00, 0x00}
+	resp := message{netFn: req.netFn + 1, cmd: req.cmd, seq: req.seq, data: data}
+	return encodeLegacyPacket(resp.encode(true))
+}
+
+// handleOpenSession 分配会话ID并确认cipher suite
+func (s *Simulator) handleOpenSession(req []byte) []byte {
+	if len(req) < 32 {
+		return nil
+	}
+	tag, consoleID := req[0], binary.LittleEndian.Uint32(req[4:8])
+	fail := func(status byte) []byte {
+		payload := append([]byte{tag, status, 0x00, 0x00}, le32(consoleID)...)
+		b, _ := encodePacket(packet{payloadType: payloadOpenSessionResponse, payload: payload}, nil)
+		return b
+	}
+
+	if req[12] != authAlgHMACSHA1 || req[20] != integrityAlgHMACSHA96 || req[28] != cryptAlgAESCBC128 {
+		return fail(0x11)
+	}
+	if len(s.sessions) >= simMaxSessions {
+		return fail(0x01)
+	}
+
+	var bmcID uint32
+	for bmcID == 0 || s.sessions[bmcID] != nil {
+		var id [4]byte
+		rand.Read(id[:])
+		bmcID = binary.LittleEndian.Uint32(id[:])
+	}
+	s.sessions[bmcID] = &simSession{
+		params:       rakpParams{consoleID: consoleID, bmcID: bmcID},
+		lastActivity: time.Now(),
+	}
+
+	payload := []byte{tag, 0x00, privilegeMax, 0x00}
+	payload = append(payload, le32(consoleID)...)
+	payload = append(payload, le32(bmcID)...)
+	payload = append(payload, algorithmPayload(0x00, authAlgHMACSHA1)...)
+	payload = append(payload, algorithmPayload(0x01, integrityAlgHMACSHA96)...)
+	payload = append(payload, algorithmPayload(0x02, cryptAlgAESCBC128)...)
+	b, _ := encodePacket(packet{payloadType: payloadOpenSessionResponse, payload: payload}, nil)
+	return b
+}
+
+// handleRAKP1 校验用户名并返回BMC随机数和认证码
+func (s *Simulator) handleRAKP1(req []byte) []byte {
+	if len(req) < 28 {
+		return nil
+	}
+	tag := req[0]
+	sess, ok := s.sessions[binary.LittleEndian.Uint32(req[4:8])]
+	if !ok {
+		return nil
+	}
+	fail := func(status byte) []byte {
+		payload := append([]byte{tag, status, 0x00, 0x00}, le32(sess.params.consoleID)...)
+		b, _ := encodePacket(packet{payloadType: payloadRAKP2, payload: payload}, nil)
+		return b
+	}
+
+	nameLen := int(req[27])
+	if nameLen > maxUsernameLen || len(req) < 28+nameLen {
+		return fail(0x0C)
+	}
+	username := string(req[28 : 28+nameLen])
+	if username != s.username {
+		return fail(0x0D)
+	}
+
+	sess.params.consoleRN = append([]byte(nil), req[8:24]...)
+	sess.params.role = req[24]
+	sess.params.username = username
+	sess.params.bmcRN = make([]byte, randomLen)
+	rand.Read(sess.params.bmcRN)
+	sess.params.bmcGUID = s.guid
+	sess.lastActivity = time.Now()
+
+	payload := []byte{tag, 0x00, 0x00, 0x00}
+	payload = append(payload, le32(sess.params.consoleID)...)
+	payload = append(payload, sess.params.bmcRN...)
+	payload = append(payload, s.guid...)
+	payload = append(payload, sess.params.rakp2AuthCode(userKey(s.password))...)
+	b, _ := encodePacket(packet{payloadType: payloadRAKP2, payload: payload}, nil)
+	return b
+}
+
+// handleRAKP3 校验控制台的认证码，通过后激活会话
+func (s *Simulator) handleRAKP3(req []byte) []byte {
+	if len(req) < 8 {
+		return nil
+	}
+	tag, bmcID := req[0], binary.LittleEndian.Uint32(req[4:8])
+	sess, ok := s.sessions[bmcID]
+	if !ok || sess.params.bmcRN == nil {
+		return nil
+	}
+
+	kuid := userKey(s.password)
+	status := byte(0x00)
+	if len(req) < 8+rakpHMACLen || !hmac.Equal(req[8:8+rakpHMACLen], sess.params.rakp3AuthCode(kuid)) {
+		status = 0x0F
+		delete(s.sessions, bmcID)
+	}
+
+	payload := append([]byte{tag, status, 0x00, 0x00}, le32(sess.params.consoleID)...)
+	if status == 0x00 {
+		sik := sess.params.sik(kuid)
+		keys := deriveKeys(sik)
+		sess.keys = &keys
+		// 会话建立后为User权限，需要Set Session Privilege Level提升
+		sess.privilege = 0x02
+		sess.lastActivity = time.Now()
+		payload = append(payload, sess.params.rakp4ICV(sik)...)
+	}
+	b, _ := encodePacket(packet{payloadType: payloadRAKP4, payload: payload}, nil)
+	return b
+}
+
+// handleCommand 处理会话内的IPMI命令
+func (s *Simulator) handleCommand(bmcID uint32, sess *simSession, payload []byte) []byte {
+	req, err := decodeMessage(payload)
+	if err != nil {
+		return nil
+	}
+	sess.lastActivity = time.Now()
+
+	data := s.dispatch(sess, req)
+	if req.netFn == netFnApp && req.cmd == cmdCloseSession && data[0] == completionOK {
+		defer delete(s.sessions, bmcID)
+	}
+
+	sess.seq++
+	resp := message{netFn: req.netFn + 1, cmd: req.cmd, seq: req.seq, data: data}.encode(true)
+	b, err := encodePacket(packet{payloadType: payloadIPMI, sessionID: sess.params.consoleID, seq: sess.seq, payload: resp}, sess.keys)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// dispatch 执行命令，返回以完成码开头的响应数据
+func (s *Simulator) dispatch(sess *simSession, req message) []byte {
+	switch {
+	case req.netFn == netFnApp && req.cmd == cmdGetDeviceID:
+		// 设备ID 20h，固件2.45，IPMI 2.0，厂商10876，产品0001h
+		return []byte{completionOK, 0x20, 0x01, 0x02, 0x45, 0x02, 0xBF, 0x7C, 0x2A, 0x00, 0x01, 0x00}
+	case req.netFn == netFnApp && req.cmd == cmdSetSessionPrivilege:
+		if len(req.data) < 1 || req.data[0] > privilegeMax {
+			return []byte{0xCC}
+		}
+		if req.data[0] != 0 {
+			sess.privilege = req.data[0]
+		}
+		return []byte{completionOK, sess.privilege}
+	case req.netFn == netFnApp && req.cmd == cmdCloseSession:
+		return []byte{completionOK}
+	case req.netFn == netFnChassis && req.cmd == cmdGetChassisStatus:
+		power := byte(0x20) // 断电恢复策略：恢复到断电前状态
+		if s.powerOn {
+			power |= 0x01
+		}
+		return []byte{completionOK, power, 0x00, 0x00}
+	case req.netFn == netFnChassis && req.cmd == cmdChassisControl:
+		if sess.privilege < privilegeMax {
+			return []byte{0xD4}
+		}
+		if len(req.data) < 1 {
+			return []byte{0xC7}
+		}
+		return []byte{s.chassisControl(ChassisControl(req.data[0]))}
+	case req.netFn == netFnSensorEvent && req.cmd == cmdGetSensorReading:
+		return s.sensorReading(req.data)
+	case req.netFn == netFnStorage && req.cmd == cmdReserveSDRRepository:
+		s.sdrReservation = nextReservation(s.sdrReservation)
+		return []byte{completionOK, byte(s.sdrReservation), byte(s.sdrReservation >> 8)}
+	case req.netFn == netFnStorage && req.cmd == cmdGetSDR:
+		return s.getRecord(req.data, s.sdr, s.sdrReservation)
+	case req.netFn == netFnStorage && req.cmd == cmdGetSELInfo:
+		free := uint16((simSELCapacity - len(s.sel)) * selRecordLen)
+		data := []byte{completionOK, 0x51, byte(len(s.sel)), byte(len(s.sel) >> 8), byte(free), byte(free >> 8)}
+		data = append(data, 0, 0, 0, 0, 0, 0, 0, 0, 0x02)
+		return data
+	case req.netFn == netFnStorage && req.cmd == cmdReserveSEL:
+		s.selReservation = nextReservation(s.selReservation)
+		return []byte{completionOK, byte(s.selReservation), byte(s.selReservation >> 8)}
+	case req.netFn == netFnStorage && req.cmd == cmdGetSELEntry:
+		return s.getRecord(req.data, s.sel, s.selReservation)
+	default:
+		return []byte{completionInvalidCommand}
+	}
+}
+
+// chassisControl 执行电源操作，开机和软关机经过powerDelay后生效
+func (s *Simulator) chassisControl(control ChassisControl) byte {
+	switch control {
+	case ChassisPowerDown:
+		s.pending = nil
+		s.setPower(false)
+	case ChassisPowerUp:
+		if !s.powerOn {
+			s.schedule(true)
+		}
+	case ChassisPowerCycle:
+		if !s.powerOn {
+			return completionInvalidState
+		}
+		s.setPower(false)
+		s.schedule(true)
+	case ChassisHardReset:
+		if !s.powerOn {
+			return completionInvalidState
+		}
+		s.addSELEvent(0x1D, simBootSensor, eventTypeSpecific, 0x01, true)
+	case ChassisSoftShutdown:
+		if !s.powerOn {
+			return completionInvalidState
+		}
+		s.schedule(false)
+	default:
+		return 0xCC
+	}
+	return completionOK
+}
+
+// schedule 安排电源状态在powerDelay后变化
+func (s *Simulator) schedule(on bool) {
+	s.pending = &simPowerChange{on: on, due: time.Now().Add(s.powerDelay)}
+	s.advance()
+}
+
+// advance 应用已到期的电源状态变化
+func (s *Simulator) advance() {
+	if s.pending != nil && !time.Now().Before(s.pending.due) {
+		s.setPower(s.pending.on)
+		s.pending = nil
+	}
+}
+
+// setPower 改变电源状态并记录SEL事件
+func (s *Simulator) setPower(on bool) {
+	if s.powerOn == on {
+		return
+	}
+	s.powerOn = on
+	// Power Unit传感器偏移0（Power Off）断言表示关机，撤销表示开机
+	s.addSELEvent(0x09, simPowerUnitSensor, eventTypeSpecific, 0x00, !on)
+}
+
+// addSELEvent 追加一条系统事件记录
+func (s *Simulator) addSELEvent(sensorType, sensorNumber, eventType, offset uint8, assertion bool) {
+	if len(s.sel) >= simSELCapacity {
+		return
+	}
+	dir := eventType
+	if !assertion {
+		dir |= 0x80
+	}
+	r := make([]byte, 0, selRecordLen)
+	r = binary.LittleEndian.AppendUint16(r, s.nextSELID)
+	r = append(r, selTypeSystem)
+	r = binary.LittleEndian.AppendUint32(r, uint32(time.Now().Unix()))
+	r = append(r, bmcAddress, 0x00, 0x04, sensorType, sensorNumber, dir, offset, 0xFF, 0xFF)
+	s.sel = append(s.sel, r)
+	s.nextSELID++
+}
+
+// sensorReading 返回传感器原始读数和阈值比较位
+func (s *Simulator) sensorReading(data []byte) []byte {
+	if len(data) < 1 {
+		return []byte{0xC7}
+	}
+	for _, sensor := range s.sensors {
+		if sensor.number != data[0] {
+			continue
+		}
+		raw := int(sensor.raw)
+		if !s.powerOn {
+			raw = sensor.offRaw
+		}
+		if raw < 0 {
+			// 扫描已启用但读数不可用
+			return []byte{completionOK, 0x00, 0x60, 0x00}
+		}
+		var thresholds byte
+		if byte(raw) >= sensor.upperCritical {
+			thresholds = 0x18
+		}
+		return []byte{completionOK, byte(raw), 0x40, thresholds}
+	}
+	return []byte{completionNotPresent}
+}
+
+// getRecord Get SDR和Get SEL Entry共用的按记录ID分段读取
+// 请求: 预留ID(2) 记录ID(2) 偏移 长度；记录ID 0000h为第一条，FFFFh为最后一条
+func (s *Simulator) getRecord(data []byte, records [][]byte, reservation uint16) []byte {
+	if len(data) < 6 {
+		return []byte{0xC7}
+	}
+	if len(records) == 0 {
+		return []byte{completionNotPresent}
+	}
+
+	id := binary.LittleEndian.Uint16(data[2:4])
+	offset, size := int(data[4]), int(data[5])
+	if offset != 0 && binary.LittleEndian.Uint16(data[0:2]) != reservation {
+		return []byte{completionReservationLost}
+	}
+
+	index := -1
+	switch id {
+	case 0x0000:
+		index = 0
+	case sdrLastRecord:
+		index = len(records) - 1
+	default:
+		for i, r := range records {
+			if binary.LittleEndian.Uint16(r[0:2]) == id {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 {
+		return []byte{completionNotPresent}
+	}
+
+	record := records[index]
+	if offset > len(record) {
+		return []byte{completionOutOfRange}
+	}
+	end := len(record)
+	if size != 0xFF && offset+size < end {
+		end = offset + size
+	}
+
+	next := uint16(sdrLastRecord)
+	if index+1 < len(records) {
+		next = binary.LittleEndian.Uint16(records[index+1][0:2])
+	}
+	resp := []byte{completionOK, byte(next), byte(next >> 8)}
+	return append(resp, record[offset:end]...)
+}
+
+// expireIdle 回收空闲超时的会话
+func (s *Simulator) expireIdle() {
+	for id, sess := range s.sessions {
+		if time.Since(sess.lastActivity) > simSessionTimeout {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// nextReservation 返回下一个非零预留ID
+func nextReservation(current uint16) uint16 {
+	current++
+	if current == 0 {
+		current = 1
+	}
+	return current
+}
+
+// record 生成传感器的SDR完整传感器记录（类型01h）
+func (sensor simSensor) record(id uint16) []byte {
+	r := make([]byte, 48+len(sensor.name))
+	binary.LittleEndian.PutUint16(r[0:2], id)
+	r[2] = 0x51 // SDR版本1.5
+	r[3] = sdrTypeFull
+	r[4] = byte(len(r) - sdrHeaderLen)
+	r[5] = bmcAddress
+	r[7] = sensor.number
+	r[8] = sensor.entity
+	r[9] = 0x01
+	r[10] = 0x7F
+	r[11] = 0x68
+	r[12] = sensor.sensorType
+	r[13] = eventTypeThreshold
+	r[18] = 0x10 // 上临界阈值可读
+	r[21] = sensor.unit
+	r[24] = byte(sensor.m)
+	r[25] = byte(sensor.m>>8) << 6
+	r[29] = byte(sensor.rExp&0x0F) << 4
+	r[37] = sensor.upperCritical
+	r[47] = 0xC0 | byte(len(sensor.name))
+	copy(r[48:], sensor.name)
+	return r
+}
@@ -0,0 +1,261 @@
+package ipmi
+
+import (
+	"fmt"
+	"time"
+)
+
+// NetFn 网络功能码，响应的NetFn为请求NetFn+1
+const (
+	netFnChassis     = 0x00
+	netFnSensorEvent = 0x04
+	netFnApp         = 0x06
+	netFnStorage     = 0x0A
+)
+
+// 命令码
+const (
+	cmdGetDeviceID          = 0x01
+	cmdGetChannelAuthCaps   = 0x38
+	cmdSetSessionPrivilege  = 0x3B
+	cmdCloseSession         = 0x3C
+	cmdGetChassisStatus     = 0x01
+	cmdChassisControl       = 0x02
+	cmdGetSensorReading     = 0x2D
+	cmdReserveSDRRepository = 0x22
+	cmdGetSDR               = 0x23
+	cmdGetSELInfo           = 0x40
+	cmdReserveSEL           = 0x42
+	cmdGetSELEntry          = 0x43
+)
+
+// 需要特殊处理的完成码
+const (
+	completionOK              = 0x00
+	completionReservationLost = 0xC5
+	completionNotPresent      = 0xCB
+	completionInvalidCommand  = 0xC1
+	completionOutOfRange      = 0xC9
+	completionInvalidState    = 0xD5
+)
+
+// ChassisControl Chassis Control命令的操作
+type ChassisControl uint8
+
+// 机箱电源操作
+const (
+	ChassisPowerDown    ChassisControl = 0x00
+	ChassisPowerUp      ChassisControl = 0x01
+	ChassisPowerCycle   ChassisControl = 0x02
+	ChassisHardReset    ChassisControl = 0x03
+	ChassisSoftShutdown ChassisControl = 0x05
+)
+
+// String 返回操作名称
+func (c ChassisControl) String() string {
+	switch c {
+	case ChassisPowerDown:
+		return "power down"
+	case ChassisPowerUp:
+		return "power up"
+	case ChassisPowerCycle:
+		return "power cycle"
+	case ChassisHardReset:
+		return "hard reset"
+	case ChassisSoftShutdown:
+		return "soft shutdown"
+	default:
+		return fmt.Sprintf("chassis control 0x%02x", uint8(c))
+	}
+}
+
+// 传感器状态
+const (
+	SensorStatusOK             = "ok"
+	SensorStatusWarning        = "warning"
+	SensorStatusCritical       = "critical"
+	SensorStatusNonRecoverable = "non_recoverable"
+	SensorStatusUnavailable    = "unavailable"
+)
+
+// DeviceID Get Device ID命令返回的BMC信息
+type DeviceID struct {
+	DeviceID        uint8  `json:"device_id"`
+	DeviceRevision  uint8  `json:"device_revision"`
+	FirmwareVersion string `json:"firmware_version"`
+	IPMIVersion     string `json:"ipmi_version"`
+	ManufacturerID  uint32 `json:"manufacturer_id"`
+	ProductID       uint16 `json:"product_id"`
+}
+
+// ChassisStatus Get Chassis Status命令返回的机箱状态
+type ChassisStatus struct {
+	PowerOn           bool   `json:"power_on"`
+	PowerOverload     bool   `json:"power_overload"`
+	PowerInterlock    bool   `json:"power_interlock"`
+	PowerFault        bool   `json:"power_fault"`
+	PowerControlFault bool   `json:"power_control_fault"`
+	RestorePolicy     string `json:"restore_policy"`
+	ChassisIntrusion  bool   `json:"chassis_intrusion"`
+	DriveFault        bool   `json:"drive_fault"`
+	CoolingFault      bool   `json:"cooling_fault"`
+}
+
+// Sensor 阈值型传感器的当前读数
+type Sensor struct {
+	Number uint8  `json:"number"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	// Reading 换算后的读数，传感器不可读（如关机状态）时为空
+	Reading *float64 `json:"reading"`
+	Unit    string   `json:"unit"`
+	Status  string   `json:"status"`
+}
+
+// SELEntry 系统事件日志记录
+type SELEntry struct {
+	ID         uint16    `json:"id"`
+	RecordType uint8     `json:"record_type"`
+	Timestamp  time.Time `json:"timestamp"`
+	// GeneratorID 产生事件的控制器或软件ID，BMC为0x0020
+	GeneratorID  uint16 `json:"generator_id"`
+	SensorType   string `json:"sensor_type"`
+	SensorNumber uint8  `json:"sensor_number"`
+	EventType    uint8  `json:"event_type"`
+	// Assertion 事件是断言（进入状态）还是撤销（离开状态）
+	Assertion   bool   `json:"assertion"`
+	EventData   string `json:"event_data"`
+	Description string `json:"description"`
+}
+
+// sensorTypes 传感器类型名称（IPMI v2.0 表42-3）
+var sensorTypes = map[uint8]string{
+	0x01: "temperature",
+	0x02: "voltage",
+	0x03: "current",
+	0x04: "fan",
+	0x05: "physical_security",
+	0x06: "platform_security",
+	0x07: "processor",
+	0x08: "power_supply",
+	0x09: "power_unit",
+	0x0A: "cooling_device",
+	0x0B: "other_units",
+	0x0C: "memory",
+	0x0D: "drive_slot",
+	0x0F: "system_firmware",
+	0x10: "event_logging",
+	0x11: "watchdog_1",
+	0x12: "system_event",
+	0x13: "critical_interrupt",
+	0x14: "button",
+	0x19: "chipset",
+	0x1D: "system_boot",
+	0x1F: "os_boot",
+	0x20: "os_stop",
+	0x21: "slot_connector",
+	0x22: "acpi_power_state",
+	0x23: "watchdog_2",
+	0x25: "entity_presence",
+	0x28: "management_subsystem_health",
+	0x29: "battery",
+}
+
+// sensorTypeName 返回传感器类型名称
+func sensorTypeName(t uint8) string {
+	if name, ok := sensorTypes[t]; ok {
+		return name
+	}
+	if t >= 0xC0 {
+		return fmt.Sprintf("oem_0x%02x", t)
+	}
+	return fmt.Sprintf("unknown_0x%02x", t)
+}
+
+// sensorUnits 传感器基本单位（IPMI v2.0 表43-15，仅常用单位）
+var sensorUnits = map[uint8]string{
+	1:  "C",
+	2:  "F",
+	3:  "K",
+	4:  "V",
+	5:  "A",
+	6:  "W",
+	7:  "J",
+	9:  "VA",
+	18: "RPM",
+	19: "Hz",
+}
+
+// completionCodes 通用完成码说明（IPMI v2.0 表5-2）
+var completionCodes = map[uint8]string{
+	0xC0: "node busy",
+	0xC1: "invalid command",
+	0xC2: "command invalid for given LUN",
+	0xC3: "timeout while processing command",
+	0xC4: "out of space",
+	0xC5: "reservation canceled or invalid",
+	0xC6: "request data truncated",
+	0xC7: "request data length invalid",
+	0xC8: "request data field length limit exceeded",
+	0xC9: "parameter out of range",
+	0xCA: "cannot return number of requested data bytes",
+	0xCB: "requested sensor, data, or record not present",
+	0xCC: "invalid data field in request",
+	0xCD: "command illegal for specified sensor or record type",
+	0xCE: "command response could not be provided",
+	0xCF: "cannot execute duplicated request",
+	0xD0: "SDR repository in update mode",
+	0xD1: "device in firmware update mode",
+	0xD2: "BMC initialization in progress",
+	0xD3: "destination unavailable",
+	0xD4: "insufficient privilege level",
+	0xD5: "command not supported in present state",
+	0xD6: "command sub-function disabled or unavailable",
+	0xFF: "unspecified error",
+}
+
+// CompletionError BMC以非零完成码拒绝了命令
+type CompletionError struct {
+	NetFn uint8 `json:"netfn"`
+	Cmd   uint8 `json:"cmd"`
+	Code  uint8 `json:"completion_code"`
+}
+
+// Error 实现error接口
+func (e *CompletionError) Error() string {
+	desc, ok := completionCodes[e.Code]
+	if !ok {
+		desc = "command specific error"
+	}
+	return fmt.Sprintf("ipmi: netfn 0x%02x cmd 0x%02x failed with completion code 0x%02x (%s)", e.NetFn, e.Cmd, e.Code, desc)
+}
+
+// rakpStatusCodes RMCP+会话建立状态码（IPMI v2.0 表13-15）
+var rakpStatusCodes = map[uint8]string{
+	0x01: "insufficient resources to create a session",
+	0x02: "invalid session ID",
+	0x03: "invalid payload type",
+	0x04: "invalid authentication algorithm",
+	0x05: "invalid integrity algorithm",
+	0x06: "no matching authentication payload",
+	0x07: "no matching integrity payload",
+	0x08: "inactive session ID",
+	0x09: "invalid role",
+	0x0A: "unauthorized role or privilege level requested",
+	0x0B: "insufficient resources to create a session at the requested role",
+	0x0C: "invalid name length",
+	0x0D: "unauthorized name",
+	0x0E: "unauthorized GUID",
+	0x0F: "invalid integrity check value",
+	0x10: "invalid confidentiality algorithm",
+	0x11: "no cipher suite match with proposed security algorithms",
+	0x12: "illegal or unrecognized parameter",
+}
+
+// rakpStatusText 返回会话建立状态码说明
+func rakpStatusText(code uint8) string {
+	if text, ok := rakpStatusCodes[code]; ok {
+		return text
+	}
+	return fmt.Sprintf("status 0x%02x", code)
+}
@@ -0,0 +1,245 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// ListNotifications 查询通知记录
+func (d *Dispatcher) ListNotifications(ctx context.Context, filter repository.NotificationFilter) ([]models.Notification, error) {
+	return d.repos.Notifications.List(ctx, filter)
+}
+
+// ListChannels 查询通知渠道，密钥已隐藏
+func (d *Dispatcher) ListChannels(ctx context.Context) ([]models.NotificationChannel, error) {
+	channels, err := d.repos.Channels.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range channels {
+		channels[i] = channels[i].Redacted()
+	}
+	return channels, nil
+}
+
+// GetChannel 查询通知渠道详情，密钥已隐藏
+func (d *Dispatcher) GetChannel(ctx context.Context, id string) (*models.NotificationChannel, error) {
+	channel, err := d.repos.Channels.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	redacted := channel.Redacted()
+	return &redacted, nil
+}
+
+// CreateChannel 创建通知渠道
+func (d *Dispatcher) CreateChannel(ctx context.Context, req *ChannelRequest) (*models.NotificationChannel, error) {
+	channel, err := req.channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.repos.Channels.Create(ctx, channel); err != nil {
+		return nil, err
+	}
+	redacted := channel.Redacted()
+	return &redacted, nil
+}
+
+// UpdateChannel 替换通知渠道，secret为RedactedSecret时保留原密钥
+func (d *Dispatcher) UpdateChannel(ctx context.Context, id string, req *ChannelRequest) (*models.NotificationChannel, error) {
+	channel, err := req.channel()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := d.repos.Channels.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if channel.Config["secret"] == models.RedactedSecret {
+		channel.Config["secret"] = existing.Config["secret"]
+	}
+
+	channel.ID = id
+	if err := d.repos.Channels.Update(ctx, channel); err != nil {
+		return nil, err
+	}
+	redacted := channel.Redacted()
+	return &redacted, nil
+}
+
+// DeleteChannel 删除通知渠道，仍被路由使用时返回ErrReferenceViolation
+func (d *Dispatcher) DeleteChannel(ctx context.Context, id string) error {
+	routes, err := d.repos.Routes.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range routes {
+		for _, channelID := range routes[i].ChannelIDs {
+			if channelID == id {
+				return fmt.Errorf("%w: channel is used by route %s", repository.ErrReferenceViolation, routes[i].Name)
+			}
+		}
+	}
+	return d.repos.Channels.Delete(ctx, id)
+}
+
+// TestChannel 向渠道同步发送一条测试通知，不受速率限制，不记录通知
+func (d *Dispatcher) TestChannel(ctx context.Context, id string) (*Message, error) {
+	channel, err := d.repos.Channels.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		EventType: event.EventTypeAlertRaised,
+		Status:    StatusFiring,
+		Alert: event.AlertEvent{
+			AlertType:  "notification_test",
+			Severity:   models.AlertSeverityLow,
+			Message:    fmt.Sprintf("Test notification for channel %s", channel.Name),
+			Status:     models.AlertStatusActive,
+			SourceType: "notification_channel",
+			SourceID:   channel.ID,
+		},
+		Route: "test",
+		Time:  time.Now().UTC(),
+	}
+	msg.Subject, msg.Text, err = render(channel, templateData(channel, msg))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDeliveryFailed, err)
+	}
+	if err := d.send(ctx, channel, msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDeliveryFailed, err)
+	}
+	return msg, nil
+}
+
+// ListRoutes 查询通知路由
+func (d *Dispatcher) ListRoutes(ctx context.Context) ([]models.NotificationRoute, error) {
+	return d.repos.Routes.List(ctx)
+}
+
+// GetRoute 查询通知路由详情
+func (d *Dispatcher) GetRoute(ctx context.Context, id string) (*models.NotificationRoute, error) {
+	return d.repos.Routes.Get(ctx, id)
+}
+
+// CreateRoute 创建通知路由，引用的渠道和值班表必须存在
+func (d *Dispatcher) CreateRoute(ctx context.Context, req *RouteRequest) (*models.NotificationRoute, error) {
+	route, err := req.route()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkReferences(ctx, route); err != nil {
+		return nil, err
+	}
+	if err := d.repos.Routes.Create(ctx, route); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// UpdateRoute 替换通知路由
+func (d *Dispatcher) UpdateRoute(ctx context.Context, id string, req *RouteRequest) (*models.NotificationRoute, error) {
+	route, err := req.route()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.repos.Routes.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := d.checkReferences(ctx, route); err != nil {
+		return nil, err
+	}
+	route.ID = id
+	if err := d.repos.Routes.Update(ctx, route); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// DeleteRoute 删除通知路由
+func (d *Dispatcher) DeleteRoute(ctx context.Context, id string) error {
+	return d.repos.Routes.Delete(ctx, id)
+}
+
+// checkReferences 检查路由引用的渠道和值班表是否存在
+func (d *Dispatcher) checkReferences(ctx context.Context, route *models.NotificationRoute) error {
+	for _, channelID := range route.ChannelIDs {
+		if _, err := d.repos.Channels.Get(ctx, channelID); err != nil {
+			return fmt.Errorf("channel %s: %w", channelID, err)
+		}
+	}
+	if route.ScheduleID != "" {
+		if _, err := d.repos.Schedules.Get(ctx, route.ScheduleID); err != nil {
+			return fmt.Errorf("schedule %s: %w", route.ScheduleID, err)
+		}
+	}
+	return nil
+}
+
+// ListSchedules 查询值班表
+func (d *Dispatcher) ListSchedules(ctx context.Context) ([]models.OnCallSchedule, error) {
+	return d.repos.Schedules.List(ctx)
+}
+
+// GetSchedule 查询值班表详情
+func (d *Dispatcher) GetSchedule(ctx context.Context, id string) (*models.OnCallSchedule, error) {
+	return d.repos.Schedules.Get(ctx, id)
+}
+
+// CreateSchedule 创建值班表
+func (d *Dispatcher) CreateSchedule(ctx context.Context, req *ScheduleRequest) (*models.OnCallSchedule, error) {
+	schedule, err := req.schedule()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.repos.Schedules.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule 替换值班表
+func (d *Dispatcher) UpdateSchedule(ctx context.Context, id string, req *ScheduleRequest) (*models.OnCallSchedule, error) {
+	schedule, err := req.schedule()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.repos.Schedules.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	schedule.ID = id
+	if err := d.repos.Schedules.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule 删除值班表，仍被路由使用时返回ErrReferenceViolation
+func (d *Dispatcher) DeleteSchedule(ctx context.Context, id string) error {
+	routes, err := d.repos.Routes.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range routes {
+		if routes[i].ScheduleID == id {
+			return fmt.Errorf("%w: schedule is used by route %s", repository.ErrReferenceViolation, routes[i].Name)
+		}
+	}
+	return d.repos.Schedules.Delete(ctx, id)
+}
+
+// OnCall 查询值班表在at时刻的值班人
+func (d *Dispatcher) OnCall(ctx context.Context, id string, at time.Time) (*models.OnCallMember, error) {
+	schedule, err := d.repos.Schedules.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.OnCall(at), nil
+}
@@ -0,0 +1,406 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// ErrDeliveryFailed 测试通知发送失败
+var ErrDeliveryFailed = errors.New("notification delivery failed")
+
+// handlerPrefix 通知处理器名称前缀，每个订阅主题一个处理器
+const handlerPrefix = "notifier."
+
+// sendTimeout 单次发送的超时
+const sendTimeout = 15 * time.Second
+
+// writeTimeout 通知状态写入的超时，发送在后台进行，与事件处理的Context无关
+const writeTimeout = 10 * time.Second
+
+// Config 通知配置
+type Config struct {
+	// MaxAttempts 每条通知最多发送的次数，可重试的错误按指数退避重试
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	SMTP           SMTPConfig
+}
+
+// Dispatcher 告警通知分发器，订阅alert.raised和alert.resolved，按路由的标签、严重程度和事件类型
+// 匹配渠道，附带值班表的当前值班人，渲染模板后在后台发送并记录通知
+// 被静默的告警不通知；同一事件对同一渠道只通知一次，多条路由命中同一渠道时按第一条路由发送
+type Dispatcher struct {
+	repos    *repository.Repositories
+	eventBus event.EventBus
+	config   Config
+	senders  map[string]Sender
+	limiter  *rateLimiter
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher 创建告警通知分发器，调用Subscribe后开始通知
+func NewDispatcher(repos *repository.Repositories, eventBus event.EventBus, config Config) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &http.Client{Timeout: sendTimeout}
+	return &Dispatcher{
+		repos:    repos,
+		eventBus: eventBus,
+		config:   config,
+		senders: map[string]Sender{
+			models.NotificationChannelWebhook: &webhookSender{client: client},
+			models.NotificationChannelSlack:   &slackSender{client: client},
+			models.NotificationChannelEmail:   &emailSender{config: config.SMTP},
+		},
+		limiter: newRateLimiter(time.Minute),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// RegisterSender 替换一种渠道类型的发送实现，需在Subscribe之前调用
+func (d *Dispatcher) RegisterSender(channelType string, sender Sender) {
+	d.senders[channelType] = sender
+}
+
+// Subscribe 订阅告警产生和解除事件
+func (d *Dispatcher) Subscribe(ctx context.Context) error {
+	for _, topic := range []string{event.EventTypeAlertRaised, event.EventTypeAlertResolved} {
+		if err := d.eventBus.Subscribe(ctx, topic, d.handle, event.WithHandlerName(handlerPrefix+topic)); err != nil {
+			return fmt.Errorf("failed to subscribe notifier to %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// handle 处理一条告警事件，读取路由或渠道失败时返回错误，由事件总线写入死信，重放时已通知的渠道不会重复通知
+func (d *Dispatcher) handle(ctx context.Context, data []byte) error {
+	ce, err := event.ParseCloudEvent(data)
+	if err != nil {
+		return err
+	}
+	status, ok := notifiedEvents[ce.Type]
+	if !ok {
+		return nil
+	}
+	var alert event.AlertEvent
+	if err := ce.DecodeData(&alert); err != nil {
+		return fmt.Errorf("%w: %v", event.ErrInvalidEvent, err)
+	}
+	if alert.SilenceID != "" {
+		return nil
+	}
+
+	routes, err := d.repos.Routes.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	labels := matchLabels(&alert)
+	notified := map[string]bool{}
+	var errs []error
+	for i := range routes {
+		route := &routes[i]
+		if !matchRoute(route, ce.Type, &alert, labels) {
+			continue
+		}
+		onCall, err := d.onCall(ctx, route.ScheduleID, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("route %s: %w", route.Name, err))
+			continue
+		}
+
+		for _, channelID := range route.ChannelIDs {
+			if notified[channelID] {
+				continue
+			}
+			notified[channelID] = true
+
+			channel, err := d.repos.Channels.Get(ctx, channelID)
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("channel %s: %w", channelID, err))
+				continue
+			}
+			if !channel.Enabled {
+				continue
+			}
+
+			msg := &Message{
+				EventID:   ce.ID,
+				EventType: ce.Type,
+				Status:    status,
+				Alert:     alert,
+				OnCall:    onCall,
+				Route:     route.Name,
+				Time:      now,
+			}
+			if err := d.dispatch(ctx, route, channel, msg); err != nil {
+				errs = append(errs, fmt.Errorf("channel %s: %w", channel.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// onCall 返回值班表的当前值班人，路由未关联值班表或值班表已删除时返回nil
+func (d *Dispatcher) onCall(ctx context.Context, scheduleID string, now time.Time) (*models.OnCallMember, error) {
+	if scheduleID == "" {
+		return nil, nil
+	}
+	schedule, err := d.repos.Schedules.Get(ctx, scheduleID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schedule.OnCall(now), nil
+}
+
+// dispatch 记录通知并在后台发送，事件重复投递时跳过已记录的通知
+// 模板渲染失败的通知直接记录为failed，超过渠道速率限制的记录为rate_limited
+func (d *Dispatcher) dispatch(ctx context.Context, route *models.NotificationRoute, channel *models.NotificationChannel, msg *Message) error {
+	notification := &models.Notification{
+		EventID:   msg.EventID,
+		EventType: msg.EventType,
+		AlertID:   msg.Alert.AlertID,
+		ChannelID: channel.ID,
+		RouteID:   route.ID,
+		Status:    models.NotificationStatusPending,
+	}
+	if msg.OnCall != nil {
+		notification.Recipient = msg.OnCall.Name
+	}
+
+	subject, text, renderErr := render(channel, templateData(channel, msg))
+	msg.Subject, msg.Text = subject, text
+	notification.Subject = subject
+	if renderErr != nil {
+		notification.Status = models.NotificationStatusFailed
+		notification.LastError = renderErr.Error()
+	}
+
+	if err := d.repos.Notifications.Create(ctx, notification); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil
+		}
+		return err
+	}
+	if renderErr != nil {
+		log.Printf("Notification %s to channel %s failed: %v", notification.ID, channel.Name, renderErr)
+		return nil
+	}
+
+	if !d.limiter.allow(channel.ID, channel.RateLimit, msg.Time) {
+		notification.Status = models.NotificationStatusRateLimited
+		notification.LastError = fmt.Sprintf("rate limit of %d per minute exceeded", channel.RateLimit)
+		return d.repos.Notifications.UpdateIfStatus(ctx, notification, models.NotificationStatusPending)
+	}
+
+	// 分发器关闭后不再发送，通知保持pending，重启时由FailInterrupted标记为失败
+	if d.ctx.Err() != nil {
+		return nil
+	}
+	d.wg.Add(1)
+	go d.deliver(notification, channel, msg)
+	return nil
+}
+
+// deliver 发送通知，可重试的错误按指数退避重试，每次尝试后更新通知记录
+func (d *Dispatcher) deliver(notification *models.Notification, channel *models.NotificationChannel, msg *Message) {
+	defer d.wg.Done()
+
+	backoff := d.config.InitialBackoff
+	for {
+		notification.Attempts++
+		err := d.send(d.ctx, channel, msg)
+		if err == nil {
+			now := time.Now().UTC()
+			notification.Status = models.NotificationStatusSent
+			notification.LastError = ""
+			notification.SentAt = &now
+			d.save(notification)
+			return
+		}
+		if d.ctx.Err() != nil {
+			return
+		}
+
+		notification.LastError = err.Error()
+		var permanentErr *PermanentError
+		if errors.As(err, &permanentErr) || notification.Attempts >= d.config.MaxAttempts {
+			notification.Status = models.NotificationStatusFailed
+			d.save(notification)
+			log.Printf("Notification %s to channel %s failed after %d attempts: %v",
+				notification.ID, channel.Name, notification.Attempts, err)
+			return
+		}
+		d.save(notification)
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > d.config.MaxBackoff {
+			backoff = d.config.MaxBackoff
+		}
+	}
+}
+
+// send 按渠道类型发送一次
+func (d *Dispatcher) send(ctx context.Context, channel *models.NotificationChannel, msg *Message) error {
+	sender, ok := d.senders[channel.Type]
+	if !ok {
+		return permanent(fmt.Errorf("unsupported channel type %q", channel.Type))
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	return sender.Send(sendCtx, channel, msg)
+}
+
+// save 更新通知记录
+func (d *Dispatcher) save(notification *models.Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := d.repos.Notifications.UpdateIfStatus(ctx, notification, models.NotificationStatusPending); err != nil {
+		log.Printf("Failed to update notification %s: %v", notification.ID, err)
+	}
+}
+
+// FailInterrupted 将上次进程退出时未发送完成的通知标记为失败，启动时调用
+func (d *Dispatcher) FailInterrupted(ctx context.Context) (int, error) {
+	pending, err := d.repos.Notifications.List(ctx, repository.NotificationFilter{
+		Statuses: []string{models.NotificationStatusPending},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range pending {
+		notification := &pending[i]
+		notification.Status = models.NotificationStatusFailed
+		notification.LastError = "interrupted by server restart"
+		if err := d.repos.Notifications.UpdateIfStatus(ctx, notification, models.NotificationStatusPending); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				continue
+			}
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Close 停止发送并等待进行中的发送结束，未发送完成的通知保持pending
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// templateData 模板数据
+func templateData(channel *models.NotificationChannel, msg *Message) *TemplateData {
+	return &TemplateData{
+		EventType: msg.EventType,
+		Status:    msg.Status,
+		Alert:     msg.Alert,
+		OnCall:    msg.OnCall,
+		Route:     msg.Route,
+		Channel:   channel.Name,
+		Time:      msg.Time,
+	}
+}
+
+// matchLabels 返回路由匹配使用的标签，与静默规则一致
+func matchLabels(alert *event.AlertEvent) map[string]string {
+	labels := make(map[string]string, len(alert.Labels)+4)
+	for k, v := range alert.Labels {
+		labels[k] = v
+	}
+	labels["alert_type"] = alert.AlertType
+	labels["severity"] = alert.Severity
+	labels["source_type"] = alert.SourceType
+	labels["source_id"] = alert.SourceID
+	return labels
+}
+
+// matchRoute 判断告警事件是否满足路由的全部条件
+func matchRoute(route *models.NotificationRoute, eventType string, alert *event.AlertEvent, labels map[string]string) bool {
+	if !route.Enabled {
+		return false
+	}
+	if len(route.Events) > 0 {
+		matched := false
+		for _, evt := range route.Events {
+			if evt == eventType {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if route.MinSeverity != "" && models.SeverityRank(alert.Severity) < models.SeverityRank(route.MinSeverity) {
+		return false
+	}
+	for key, value := range route.Matchers {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// rateLimiter 按渠道的滑动窗口速率限制，只在本实例内生效
+type rateLimiter struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	sent map[string][]time.Time
+}
+
+func newRateLimiter(window time.Duration) *rateLimiter {
+	return &rateLimiter{window: window, sent: map[string][]time.Time{}}
+}
+
+// allow 判断渠道在窗口内是否还能发送，允许时记录本次发送，limit为0表示不限制
+func (l *rateLimiter) allow(channelID string, limit int, now time.Time) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+	recent := l.sent[channelID][:0]
+	for _, t := range l.sent[channelID] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= limit {
+		l.sent[channelID] = recent
+		return false
+	}
+	l.sent[channelID] = append(recent, now)
+	return true
+}
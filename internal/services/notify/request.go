@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/event"
+)
+
+// 通知的告警状态，alert.raised为firing，alert.resolved为resolved
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// notifiedEvents 会产生通知的告警事件
+var notifiedEvents = map[string]string{
+	event.EventTypeAlertRaised:   StatusFiring,
+	event.EventTypeAlertResolved: StatusResolved,
+}
+
+// 默认模板，渠道未指定时使用
+const (
+	defaultSubject  = `[{{.Status | upper}}] {{.Alert.Severity}} {{.Alert.AlertType}} on {{.Alert.SourceType}} {{.Alert.SourceID}}`
+	defaultTemplate = `[{{.Status | upper}}] {{.Alert.Severity}} {{.Alert.AlertType}} on {{.Alert.SourceType}} {{.Alert.SourceID}}: {{.Alert.Message}}` +
+		`{{if .OnCall}} (on-call: {{.OnCall.Name}}){{end}}`
+)
+
+// templateFuncs 模板可用的函数
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"label": func(labels map[string]string, key string) string { return labels[key] },
+	"join":  strings.Join,
+}
+
+// TemplateData 模板数据
+// 例如 "{{.Alert.Severity | upper}} {{label .Alert.Labels \"rule\"}} on-call={{if .OnCall}}{{.OnCall.Name}}{{end}}"
+type TemplateData struct {
+	EventType string
+	Status    string
+	Alert     event.AlertEvent
+	OnCall    *models.OnCallMember
+	Route     string
+	Channel   string
+	Time      time.Time
+}
+
+// parseTemplate 解析模板，text为空时使用fallback
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// render 按渠道模板生成主题和正文
+func render(channel *models.NotificationChannel, data *TemplateData) (subject, text string, err error) {
+	subjectTmpl, err := parseTemplate("subject", channel.Config["subject"], defaultSubject)
+	if err != nil {
+		return "", "", err
+	}
+	bodyTmpl, err := parseTemplate("body", channel.Template, defaultTemplate)
+	if err != nil {
+		return "", "", err
+	}
+
+	var sb, tb strings.Builder
+	if err := subjectTmpl.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := bodyTmpl.Execute(&tb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+	return strings.TrimSpace(sb.String()), tb.String(), nil
+}
+
+// ChannelRequest 创建或替换通知渠道请求
+type ChannelRequest struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+	// Template 正文模板，为空时使用默认模板
+	Template  string `json:"template"`
+	RateLimit int    `json:"rate_limit"`
+	// Enabled 为空时启用
+	Enabled *bool `json:"enabled"`
+}
+
+// Validate 校验通知渠道请求
+func (r *ChannelRequest) Validate() error {
+	_, err := r.channel()
+	return err
+}
+
+// channel 将请求转换为通知渠道并校验
+func (r *ChannelRequest) channel() (*models.NotificationChannel, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if r.RateLimit < 0 {
+		return nil, errors.New("rate_limit must not be negative")
+	}
+
+	config := map[string]string{}
+	for k, v := range r.Config {
+		config[k] = v
+	}
+	switch r.Type {
+	case models.NotificationChannelWebhook, models.NotificationChannelSlack:
+		if err := validateURL(config["url"]); err != nil {
+			return nil, err
+		}
+	case models.NotificationChannelEmail:
+		if config["to"] == "" {
+			return nil, errors.New("config.to is required for email channels")
+		}
+		if err := validateEmails(config["to"]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown channel type %q: must be webhook, slack or email", r.Type)
+	}
+
+	if _, err := parseTemplate("subject", config["subject"], defaultSubject); err != nil {
+		return nil, err
+	}
+	if _, err := parseTemplate("body", r.Template, defaultTemplate); err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationChannel{
+		Name:      r.Name,
+		Type:      r.Type,
+		Config:    config,
+		Template:  r.Template,
+		RateLimit: r.RateLimit,
+		Enabled:   r.Enabled == nil || *r.Enabled,
+	}, nil
+}
+
+// validateURL 校验webhook地址
+func validateURL(raw string) error {
+	if raw == "" {
+		return errors.New("config.url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid config.url %q: must be an http or https URL", raw)
+	}
+	return nil
+}
+
+// RouteRequest 创建或替换通知路由请求
+type RouteRequest struct {
+	Name        string            `json:"name"`
+	Matchers    map[string]string `json:"matchers"`
+	MinSeverity string            `json:"min_severity"`
+	Events      []string          `json:"events"`
+	ChannelIDs  []string          `json:"channel_ids"`
+	ScheduleID  string            `json:"schedule_id"`
+	// Enabled 为空时启用
+	Enabled *bool `json:"enabled"`
+}
+
+// Validate 校验通知路由请求，不检查渠道和值班表是否存在
+func (r *RouteRequest) Validate() error {
+	_, err := r.route()
+	return err
+}
+
+// route 将请求转换为通知路由并校验
+func (r *RouteRequest) route() (*models.NotificationRoute, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(r.ChannelIDs) == 0 {
+		return nil, errors.New("channel_ids is required")
+	}
+	if r.MinSeverity != "" && models.SeverityRank(r.MinSeverity) < 0 {
+		return nil, fmt.Errorf("unknown min_severity %q", r.MinSeverity)
+	}
+	for _, evt := range r.Events {
+		if _, ok := notifiedEvents[evt]; !ok {
+			return nil, fmt.Errorf("unsupported event %q: must be %s or %s", evt, event.EventTypeAlertRaised, event.EventTypeAlertResolved)
+		}
+	}
+
+	return &models.NotificationRoute{
+		Name:        r.Name,
+		Matchers:    r.Matchers,
+		MinSeverity: r.MinSeverity,
+		Events:      r.Events,
+		ChannelIDs:  r.ChannelIDs,
+		ScheduleID:  r.ScheduleID,
+		Enabled:     r.Enabled == nil || *r.Enabled,
+	}, nil
+}
+
+// ScheduleRequest 创建或替换值班表请求
+type ScheduleRequest struct {
+	Name    string                `json:"name"`
+	Members []models.OnCallMember `json:"members"`
+	// RotationStart 第一位成员开始值班的时间，为空时为当前时间
+	RotationStart time.Time `json:"rotation_start"`
+	ShiftLength   string    `json:"shift_length"`
+}
+
+// Validate 校验值班表请求
+func (r *ScheduleRequest) Validate() error {
+	_, err := r.schedule()
+	return err
+}
+
+// schedule 将请求转换为值班表并校验
+func (r *ScheduleRequest) schedule() (*models.OnCallSchedule, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(r.Members) == 0 {
+		return nil, errors.New("members is required")
+	}
+	for i, member := range r.Members {
+		if member.Name == "" {
+			return nil, fmt.Errorf("members[%d].name is required", i)
+		}
+		if member.Email != "" {
+			if err := validateEmails(member.Email); err != nil {
+				return nil, fmt.Errorf("members[%d]: %w", i, err)
+			}
+		}
+	}
+	shift, err := time.ParseDuration(r.ShiftLength)
+	if err != nil || shift <= 0 {
+		return nil, fmt.Errorf("invalid shift_length %q: must be a positive duration", r.ShiftLength)
+	}
+
+	rotationStart := r.RotationStart
+	if rotationStart.IsZero() {
+		rotationStart = time.Now()
+	}
+	return &models.OnCallSchedule{
+		Name:          r.Name,
+		Members:       r.Members,
+		RotationStart: rotationStart.UTC(),
+		ShiftLength:   r.ShiftLength,
+	}, nil
+}
@@ -0,0 +1,304 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/event"
+	"gpu-management/pkg/uuid"
+)
+
+// webhook签名相关请求头，签名为 sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Message 发送到一个渠道的通知
+type Message struct {
+	EventID   string           `json:"event_id"`
+	EventType string           `json:"event_type"`
+	Status    string           `json:"status"`
+	Subject   string           `json:"subject"`
+	Text      string           `json:"text"`
+	Alert     event.AlertEvent `json:"alert"`
+	// OnCall 路由关联值班表时的当前值班人
+	OnCall *models.OnCallMember `json:"on_call,omitempty"`
+	Route  string               `json:"route"`
+	Time   time.Time            `json:"time"`
+}
+
+// Sender 一种渠道类型的发送实现，返回PermanentError表示重试也不会成功
+type Sender interface {
+	Send(ctx context.Context, channel *models.NotificationChannel, msg *Message) error
+}
+
+// PermanentError 不可重试的发送错误，例如地址错误或接收方拒绝
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// permanent 包装为不可重试错误
+func permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// Sign 计算webhook签名
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureTolerance 接收方允许的签名时间戳与本地时间的最大偏差，超出的请求视为重放
+const SignatureTolerance = 5 * time.Minute
+
+// VerifySignature 校验webhook签名，接收方可用于确认请求来自本服务且未被篡改
+// 时间戳与当前时间相差超过SignatureTolerance时校验失败，防止截获的请求被重放
+func VerifySignature(secret, timestamp, signature string, body []byte) bool {
+	return verifySignature(secret, timestamp, signature, body, time.Now())
+}
+
+// verifySignature 以now为当前时间校验签名
+func verifySignature(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(sec, 0)); skew > SignatureTolerance || skew < -SignatureTolerance {
+		return false
+	}
+	expected := Sign(secret, timestamp, body)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
+
+// postJSON 发送JSON请求，5xx、429和网络错误可重试，其他非2xx响应不可重试
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s responded %d: %s", url, resp.StatusCode, strings.TrimSpace(string(detail)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return permanent(err)
+}
+
+// webhookSender 通用webhook，POST完整的通知JSON，配置了secret时附带签名
+type webhookSender struct {
+	client *http.Client
+}
+
+func (s *webhookSender) Send(ctx context.Context, channel *models.NotificationChannel, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return permanent(err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	headers := map[string]string{
+		HeaderEvent:     msg.EventType,
+		HeaderTimestamp: timestamp,
+	}
+	if secret := channel.Config["secret"]; secret != "" {
+		headers[HeaderSignature] = Sign(secret, timestamp, body)
+	}
+	return postJSON(ctx, s.client, channel.Config["url"], body, headers)
+}
+
+// slackSender Slack兼容的incoming webhook，正文放在text中，有值班人时@值班人
+type slackSender struct {
+	client *http.Client
+}
+
+func (s *slackSender) Send(ctx context.Context, channel *models.NotificationChannel, msg *Message) error {
+	text := msg.Text
+	if msg.OnCall != nil && msg.OnCall.ChatHandle != "" {
+		text = fmt.Sprintf("<@%s> %s", msg.OnCall.ChatHandle, text)
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return permanent(err)
+	}
+	return postJSON(ctx, s.client, channel.Config["url"], body, nil)
+}
+
+// SMTPConfig 邮件服务器配置，所有email渠道共用
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username 为空时不认证
+	Username string
+	Password string
+	From     string
+}
+
+// emailSender 通过SMTP发送纯文本邮件，收件人为渠道配置的to加上值班人
+// 服务器支持STARTTLS时升级为TLS连接
+type emailSender struct {
+	config SMTPConfig
+}
+
+func (s *emailSender) Send(ctx context.Context, channel *models.NotificationChannel, msg *Message) error {
+	if s.config.Host == "" {
+		return permanent(errors.New("SMTP is not configured"))
+	}
+	recipients := emailRecipients(channel, msg.OnCall)
+	if len(recipients) == 0 {
+		return permanent(errors.New("no email recipients"))
+	}
+
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError(err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return smtpError(err)
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return smtpError(err)
+		}
+	}
+
+	if err := client.Mail(s.config.From); err != nil {
+		return smtpError(err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return smtpError(err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(composeEmail(s.config.From, recipients, msg)); err != nil {
+		return smtpError(err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return smtpError(client.Quit())
+}
+
+// emailRecipients 返回去重后的收件人
+func emailRecipients(channel *models.NotificationChannel, onCall *models.OnCallMember) []string {
+	seen := map[string]bool{}
+	recipients := []string{}
+	add := func(addr string) {
+		addr = strings.TrimSpace(addr)
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			recipients = append(recipients, addr)
+		}
+	}
+	for _, addr := range strings.Split(channel.Config["to"], ",") {
+		add(addr)
+	}
+	if onCall != nil {
+		add(onCall.Email)
+	}
+	return recipients
+}
+
+// composeEmail 生成邮件内容，主题使用MIME编码，正文使用quoted-printable编码
+func composeEmail(from string, to []string, msg *Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", msg.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@gpu-management>\r\n", uuid.New())
+	fmt.Fprintf(&buf, "X-Alert-ID: %s\r\n", msg.Alert.AlertID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(msg.Text))
+	qp.Close()
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// smtpError 5xx响应不可重试
+func smtpError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return permanent(err)
+	}
+	return err
+}
+
+// validateEmails 校验逗号分隔的邮件地址
+func validateEmails(list string) error {
+	for _, addr := range strings.Split(list, ",") {
+		if _, err := mail.ParseAddress(strings.TrimSpace(addr)); err != nil {
+			return fmt.Errorf("invalid email address %q: %w", strings.TrimSpace(addr), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+func TestVerifySignature(t *testing.T) {
+	signedAt := time.Unix(1700000000, 0)
+	body := []byte(`{"event_type":"alert.raised"}`)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := Sign("secret", timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		// elapsed 接收方校验时距离签名时间的间隔，为负表示接收方时钟落后
+		elapsed time.Duration
+		want    bool
+	}{
+		{name: "valid", secret: "secret", timestamp: timestamp, signature: signature, body: body, want: true},
+		{name: "within tolerance", secret: "secret", timestamp: timestamp, signature: signature, body: body, elapsed: SignatureTolerance - time.Second, want: true},
+		{name: "receiver clock behind", secret: "secret", timestamp: timestamp, signature: signature, body: body, elapsed: -SignatureTolerance + time.Second, want: true},
+		{name: "replayed after tolerance", secret: "secret", timestamp: timestamp, signature: signature, body: body, elapsed: SignatureTolerance + time.Second},
+		{name: "too far in the future", secret: "secret", timestamp: timestamp, signature: signature, body: body, elapsed: -SignatureTolerance - time.Second},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, signature: signature, body: body},
+		{name: "tampered body", secret: "secret", timestamp: timestamp, signature: signature, body: []byte(`{"event_type":"alert.resolved"}`)},
+		{name: "tampered timestamp", secret: "secret", timestamp: strconv.FormatInt(signedAt.Unix()+1, 10), signature: signature, body: body},
+		{name: "missing signature", secret: "secret", timestamp: timestamp, body: body},
+		{name: "missing timestamp", secret: "secret", signature: Sign("secret", "", body), body: body},
+		{name: "malformed timestamp", secret: "secret", timestamp: "yesterday", signature: Sign("secret", "yesterday", body), body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifySignature(tt.secret, tt.timestamp, tt.signature, tt.body, signedAt.Add(tt.elapsed)); got != tt.want {
+				t.Errorf("verifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSenderSignature(t *testing.T) {
+	tests := []struct {
+		name         string
+		channel      string
+		receiver     string
+		wantErr      bool
+		wantVerified bool
+	}{
+		{name: "signed", channel: "secret", receiver: "secret", wantVerified: true},
+		{name: "secret mismatch", channel: "other", receiver: "secret", wantErr: true},
+		{name: "unsigned", channel: "", receiver: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := NewSimulator(tt.receiver)
+			server := httptest.NewServer(sim)
+			defer server.Close()
+
+			sender := &webhookSender{client: server.Client()}
+			channel := &models.NotificationChannel{
+				Type:   models.NotificationChannelWebhook,
+				Config: map[string]string{"url": server.URL + "/webhook", "secret": tt.channel},
+			}
+			msg := &Message{EventType: "alert.raised", Subject: "GPU overheating", Text: "gpu-1 at 95C", Time: time.Now().UTC()}
+
+			err := sender.Send(context.Background(), channel, msg)
+			if tt.wantErr {
+				// 签名错误是401，重试也不会成功
+				var permanentErr *PermanentError
+				if !errors.As(err, &permanentErr) {
+					t.Fatalf("Send() error = %v, want a permanent error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			messages := sim.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("simulator received %d messages, want 1", len(messages))
+			}
+			if got := messages[0]; got.Event != msg.EventType || got.Verified != tt.wantVerified {
+				t.Errorf("received event = %q, verified = %v, want %q, %v", got.Event, got.Verified, msg.EventType, tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestPostJSONRetryClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent},
+		{name: "bad request", status: http.StatusBadRequest, wantErr: true, wantPermanent: true},
+		{name: "unauthorized", status: http.StatusUnauthorized, wantErr: true, wantPermanent: true},
+		{name: "not found", status: http.StatusNotFound, wantErr: true, wantPermanent: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/json" || r.Header.Get(HeaderEvent) != "alert.raised" {
+					t.Errorf("request headers = %v", r.Header)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := postJSON(context.Background(), server.Client(), server.URL, []byte(`{}`), map[string]string{HeaderEvent: "alert.raised"})
+			checkRetryable(t, err, tt.wantErr, tt.wantPermanent)
+		})
+	}
+
+	t.Run("connection refused", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+
+		err := postJSON(context.Background(), http.DefaultClient, url, []byte(`{}`), nil)
+		checkRetryable(t, err, true, false)
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		err := postJSON(context.Background(), http.DefaultClient, "://missing-scheme", []byte(`{}`), nil)
+		checkRetryable(t, err, true, true)
+	})
+}
+
+func TestSMTPErrorRetryClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "success"},
+		{name: "mailbox unavailable", err: &textproto.Error{Code: 550, Msg: "no such user"}, wantErr: true, wantPermanent: true},
+		{name: "authentication failed", err: fmt.Errorf("auth: %w", &textproto.Error{Code: 535, Msg: "bad credentials"}), wantErr: true, wantPermanent: true},
+		{name: "mailbox busy", err: &textproto.Error{Code: 450, Msg: "try again later"}, wantErr: true},
+		{name: "connection reset", err: errors.New("connection reset by peer"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkRetryable(t, smtpError(tt.err), tt.wantErr, tt.wantPermanent)
+		})
+	}
+}
+
+// checkRetryable 校验err是否为错误以及是否为不可重试错误
+func checkRetryable(t *testing.T, err error, wantErr, wantPermanent bool) {
+	t.Helper()
+	if (err != nil) != wantErr {
+		t.Fatalf("error = %v, want error = %v", err, wantErr)
+	}
+	var permanentErr *PermanentError
+	if got := errors.As(err, &permanentErr); got != wantPermanent {
+		t.Errorf("error = %v, permanent = %v, want %v", err, got, wantPermanent)
+	}
+}
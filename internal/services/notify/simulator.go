@@ -0,0 +1,288 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimMessage 模拟器收到的一条通知
+type SimMessage struct {
+	// Kind 为webhook、slack或email
+	Kind       string          `json:"kind"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Event      string          `json:"event,omitempty"`
+	Verified   bool            `json:"verified,omitempty"`
+	Subject    string          `json:"subject,omitempty"`
+	From       string          `json:"from,omitempty"`
+	To         []string        `json:"to,omitempty"`
+	Text       string          `json:"text"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// Simulator 通知接收方模拟器，用于离线联调和测试
+// HTTP部分接收webhook（POST /webhook，配置了secret时校验签名）和Slack incoming webhook（POST /slack），
+// GET /messages 返回收到的通知，DELETE /messages 清空；SMTP部分接收邮件
+type Simulator struct {
+	secret string
+
+	mu       sync.Mutex
+	messages []SimMessage
+	// failures 接下来需要以503拒绝的HTTP请求数，用于验证重试
+	failures int
+	listener net.Listener
+}
+
+// NewSimulator 创建模拟器，secret为webhook签名密钥，为空时不校验签名；返回值实现http.Handler
+func NewSimulator(secret string) *Simulator {
+	return &Simulator{secret: secret}
+}
+
+// FailNext 让接下来n个webhook或Slack请求返回503
+func (s *Simulator) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+// Messages 返回收到的通知
+func (s *Simulator) Messages() []SimMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SimMessage(nil), s.messages...)
+}
+
+// Reset 清空收到的通知
+func (s *Simulator) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+func (s *Simulator) record(msg SimMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg.ReceivedAt = time.Now().UTC()
+	s.messages = append(s.messages, msg)
+}
+
+// ServeHTTP 处理webhook、Slack和查询请求
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/messages" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": s.Messages()})
+	case r.URL.Path == "/messages" && r.Method == http.MethodDelete:
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	case (r.URL.Path == "/webhook" || r.URL.Path == "/slack") && r.Method == http.MethodPost:
+		s.receive(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// receive 接收webhook或Slack请求
+func (s *Simulator) receive(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	failing := s.failures > 0
+	if failing {
+		s.failures--
+	}
+	s.mu.Unlock()
+	if failing {
+		http.Error(w, "simulated outage", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Path == "/slack" {
+		var payload struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Text == "" {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+		s.record(SimMessage{Kind: "slack", Text: payload.Text})
+		w.Write([]byte("ok"))
+		return
+	}
+
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	verified := false
+	if s.secret != "" {
+		verified = VerifySignature(s.secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body)
+		if !verified {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+	s.record(SimMessage{
+		Kind:     "webhook",
+		Event:    r.Header.Get(HeaderEvent),
+		Verified: verified,
+		Subject:  msg.Subject,
+		Text:     msg.Text,
+		Payload:  body,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListenSMTP 在addr上启动SMTP服务，返回实际监听地址
+// 只实现发送邮件所需的最小命令集，接受任意AUTH PLAIN凭据，不支持STARTTLS
+func (s *Simulator) ListenSMTP(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveSMTP(conn)
+		}
+	}()
+	return listener.Addr().String(), nil
+}
+
+// Close 停止SMTP服务
+func (s *Simulator) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// serveSMTP 处理一个SMTP会话
+func (s *Simulator) serveSMTP(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var from string
+	var to []string
+	reply("220 notify-sim ESMTP ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-notify-sim")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 notify-sim")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			from, to = smtpAddress(arg), nil
+			reply("250 OK")
+		case "RCPT":
+			to = append(to, smtpAddress(arg))
+			reply("250 OK")
+		case "DATA":
+			if from == "" || len(to) == 0 {
+				reply("503 5.5.1 need MAIL and RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			s.recordEmail(from, to, data)
+			from, to = "", nil
+			reply("250 OK queued")
+		case "RSET":
+			from, to = "", nil
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 5.5.2 command not implemented")
+		}
+	}
+}
+
+// smtpAddress 从 "FROM:<addr>" 或 "TO:<addr>" 中取出地址
+func smtpAddress(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
+
+// readData 读取DATA内容直到单独一行的"."，并还原点号转义
+func readData(r *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "." {
+			return sb.String(), nil
+		}
+		sb.WriteString(strings.TrimPrefix(trimmed, "."))
+		sb.WriteString("\r\n")
+	}
+}
+
+// recordEmail 解析邮件并记录，解析失败时记录原文
+func (s *Simulator) recordEmail(from string, to []string, data string) {
+	msg := SimMessage{Kind: "email", From: from, To: to, Text: data}
+	parsed, err := mail.ReadMessage(strings.NewReader(data))
+	if err == nil {
+		var decoder mime.WordDecoder
+		if subject, err := decoder.DecodeHeader(parsed.Header.Get("Subject")); err == nil {
+			msg.Subject = subject
+		}
+		var body io.Reader = parsed.Body
+		if strings.EqualFold(parsed.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+			body = quotedprintable.NewReader(body)
+		}
+		if text, err := io.ReadAll(body); err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+			msg.Text = strings.TrimRight(string(text), "\r\n")
+		}
+	}
+	s.record(msg)
+}
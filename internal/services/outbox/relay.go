@@ -0,0 +1,216 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// Enqueue 在事务中写入待发布事件，repo必须来自与业务写入相同的InTx
+// 事件ID即发件箱记录ID，按事件类型发布，消费者按该ID去重
+func Enqueue(ctx context.Context, repo repository.OutboxRepository, evt *event.CloudEvent) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return repo.Create(ctx, &models.OutboxEvent{
+		ID:      evt.ID,
+		Topic:   evt.Type,
+		Payload: payload,
+		Status:  models.OutboxStatusPending,
+	})
+}
+
+const (
+	// claimTimeout 认领一批事件后完成发布的最长时间，超过后事件可以被再次认领
+	claimTimeout = time.Minute
+	// writeTimeout 发布结果写入的超时
+	writeTimeout = 10 * time.Second
+)
+
+// Config 发件箱转发配置
+type Config struct {
+	// PollInterval 扫描待发布事件的间隔
+	PollInterval time.Duration
+	// BatchSize 每次扫描最多发布的事件数
+	BatchSize int
+	// MaxBackoff 发布失败后的最长重试间隔，从1秒开始按2倍递增
+	MaxBackoff time.Duration
+	// Retention 已发布事件的保留时间，超过后删除
+	Retention time.Duration
+}
+
+// Relay 发件箱转发器，将已提交的待发布事件通过事件总线发布并标记为已发送
+// 发布成功但标记失败时事件会被再次发布，消费者得到的是至少一次投递
+type Relay struct {
+	repos    *repository.Repositories
+	eventBus event.EventBus
+	config   Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRelay 创建发件箱转发器，调用Start后开始转发
+func NewRelay(repos *repository.Repositories, eventBus event.EventBus, config Config) *Relay {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Relay{
+		repos:    repos,
+		eventBus: eventBus,
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start 在后台按PollInterval转发待发布事件并清理过期的已发布事件
+func (r *Relay) Start() {
+	r.wg.Add(1)
+	go r.loop()
+}
+
+// Close 停止转发并等待进行中的批次结束，需在事件总线关闭前调用
+func (r *Relay) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// loop 转发循环
+func (r *Relay) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Now()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// 一批发满时说明还有积压，立即继续
+		for {
+			sent, err := r.relayBatch(r.ctx)
+			if err != nil {
+				if r.ctx.Err() == nil {
+					log.Printf("Outbox relay failed: %v", err)
+				}
+				break
+			}
+			if sent < r.config.BatchSize {
+				break
+			}
+		}
+
+		if time.Since(lastCleanup) >= time.Hour {
+			lastCleanup = time.Now()
+			r.cleanup(r.ctx)
+		}
+	}
+}
+
+// relayBatch 认领一批到期事件后逐条发布，返回处理的事件数
+// 发布在事务外进行，不会因事件总线变慢而长时间持有行锁和数据库连接
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	events, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range events {
+		evt := &events[i]
+		r.publish(ctx, evt)
+		// 发布结果使用独立的Context写入，关闭时已发布的事件也能标记为已发送
+		if err := r.save(evt); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// claim 在短事务中锁定一批到期事件，并将下次发布时间推后claimTimeout
+// 认领期间其他实例不会再取到这些事件；进程在发布完成前退出时，认领到期后事件被重新发布
+func (r *Relay) claim(ctx context.Context) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		now := time.Now().UTC()
+		due, err := tx.Outbox.ListDue(ctx, now, r.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range due {
+			claimed := due[i]
+			claimed.NextAttemptAt = now.Add(claimTimeout)
+			if err := tx.Outbox.Update(ctx, &claimed); err != nil {
+				return err
+			}
+		}
+		events = due
+		return nil
+	})
+	return events, err
+}
+
+// save 写入单个事件的发布结果
+func (r *Relay) save(evt *models.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return r.repos.Outbox.Update(ctx, evt)
+}
+
+// publish 发布单个事件并更新其状态，失败时按退避时间安排下次重试
+func (r *Relay) publish(ctx context.Context, evt *models.OutboxEvent) {
+	evt.Attempts++
+	now := time.Now().UTC()
+
+	err := r.eventBus.Publish(ctx, evt.Topic, evt.Payload, event.WithMessageID(evt.ID))
+	if err != nil {
+		evt.LastError = err.Error()
+		evt.NextAttemptAt = now.Add(r.backoff(evt.Attempts))
+		log.Printf("Failed to publish outbox event %s to %s (attempt %d), retrying at %s: %v",
+			evt.ID, evt.Topic, evt.Attempts, evt.NextAttemptAt.Format(time.RFC3339), err)
+		return
+	}
+
+	evt.Status = models.OutboxStatusSent
+	evt.LastError = ""
+	evt.SentAt = &now
+}
+
+// backoff 返回第attempts次失败后的重试间隔
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.config.MaxBackoff {
+		delay = r.config.MaxBackoff
+	}
+	return delay
+}
+
+// cleanup 删除超过保留时间的已发布事件
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.repos.Outbox.DeleteSent(ctx, time.Now().UTC().Add(-r.config.Retention))
+	if err != nil {
+		log.Printf("Failed to clean up outbox: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d sent outbox events", deleted)
+	}
+}
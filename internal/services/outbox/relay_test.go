@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+// fakeBus 记录发布的事件，publish不为nil时由它决定发布结果
+type fakeBus struct {
+	event.EventBus
+	published []string
+	publish   func(ctx context.Context) error
+}
+
+func (b *fakeBus) Publish(ctx context.Context, topic string, evt interface{}, opts ...event.PublishOption) error {
+	if b.publish != nil {
+		if err := b.publish(ctx); err != nil {
+			return err
+		}
+	}
+	b.published = append(b.published, topic)
+	return nil
+}
+
+func TestRelayBatch(t *testing.T) {
+	publishErr := errors.New("nats unavailable")
+
+	tests := []struct {
+		name       string
+		publishErr error
+		wantStatus string
+	}{
+		{name: "published", wantStatus: models.OutboxStatusSent},
+		{name: "publish fails", publishErr: publishErr, wantStatus: models.OutboxStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			ctx := context.Background()
+
+			evt, err := event.NewCloudEvent("test", event.EventTypeHardwareDiscovered, "server-1", event.HardwareEvent{HardwareID: "server-1", Status: models.ServerStatusDiscovered})
+			if err != nil {
+				t.Fatalf("NewCloudEvent() error = %v", err)
+			}
+			if err := Enqueue(ctx, repos.Outbox, evt); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+
+			bus := &fakeBus{}
+			bus.publish = func(ctx context.Context) error {
+				// 发布在事务外进行，认领的事件不会被再次取到，事务外的写入也不会被阻塞
+				due, err := repos.Outbox.ListDue(ctx, time.Now().UTC(), 10)
+				if err != nil {
+					return err
+				}
+				if len(due) != 0 {
+					t.Errorf("ListDue() during publish returned %d claimed events", len(due))
+				}
+				if _, err := repos.Outbox.DeleteSent(ctx, time.Now().UTC()); err != nil {
+					return err
+				}
+				return tt.publishErr
+			}
+			relay := NewRelay(repos, bus, Config{BatchSize: 10, MaxBackoff: time.Minute})
+
+			started := time.Now().UTC()
+			processed, err := relay.relayBatch(ctx)
+			if err != nil || processed != 1 {
+				t.Fatalf("relayBatch() = %d, %v, want 1", processed, err)
+			}
+
+			due, err := repos.Outbox.ListDue(ctx, started.Add(time.Hour), 10)
+			if err != nil {
+				t.Fatalf("ListDue() error = %v", err)
+			}
+			if tt.wantStatus == models.OutboxStatusSent {
+				if len(due) != 0 || len(bus.published) != 1 {
+					t.Fatalf("pending events = %d, published = %v, want the event sent", len(due), bus.published)
+				}
+				return
+			}
+
+			if len(due) != 1 {
+				t.Fatalf("pending events = %d, want 1", len(due))
+			}
+			got := due[0]
+			if got.Attempts != 1 || got.LastError != publishErr.Error() {
+				t.Errorf("attempts = %d, last error = %q", got.Attempts, got.LastError)
+			}
+			// 第一次失败后1秒重试，而不是等到认领超时
+			if delay := got.NextAttemptAt.Sub(started); delay < time.Second || delay > claimTimeout/2 {
+				t.Errorf("next attempt in %s, want about 1s", delay)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/internal/services/redfish"
+)
+
+// 管理协议
+const (
+	ProtocolRedfish = "redfish"
+	ProtocolIPMI    = "ipmi"
+)
+
+// 电源状态，与Redfish的PowerState取值一致
+const (
+	stateOn  = "On"
+	stateOff = "Off"
+)
+
+// ErrNoEndpoint 服务器既没有Redfish地址也没有IPMI地址
+var ErrNoEndpoint = errors.New("server has no redfish_url or ipmi_ip")
+
+// powerStatus BMC报告的系统电源状态
+type powerStatus struct {
+	state string
+	// lastReset 系统最近一次复位的标记，BMC不提供时为空
+	lastReset string
+}
+
+// backend 执行电源操作的BMC协议
+type backend interface {
+	protocol() string
+	// apply 下发电源操作并等待BMC受理，返回下发的具体操作名，以及BMC是否已确认操作执行完成
+	apply(ctx context.Context, p plan, report func(string)) (string, bool, error)
+	powerStatus(ctx context.Context) (powerStatus, error)
+}
+
+// backendFor 按服务器的BMC配置选择协议，优先Redfish，未配置时回退到IPMI
+func (s *Service) backendFor(server *models.Server) (backend, error) {
+	switch {
+	case server.RedfishURL != "":
+		client, err := s.redfish.Client(server.RedfishURL)
+		if err != nil {
+			return nil, err
+		}
+		return &redfishBackend{client: client}, nil
+	case server.IPMIIP != "":
+		client, err := s.ipmi.Client(server.IPMIIP)
+		if err != nil {
+			return nil, err
+		}
+		return &ipmiBackend{client: client}, nil
+	default:
+		return nil, ErrNoEndpoint
+	}
+}
+
+// redfishBackend 通过Redfish ComputerSystem.Reset控制电源
+type redfishBackend struct {
+	client *redfish.Client
+}
+
+func (b *redfishBackend) protocol() string { return ProtocolRedfish }
+
+// apply BMC以异步任务受理时等待任务结束，任务完成即确认操作已执行；同步受理时只表示BMC接受了请求
+func (b *redfishBackend) apply(ctx context.Context, p plan, report func(string)) (string, bool, error) {
+	report(fmt.Sprintf("sending %s to BMC", p.resetType))
+	monitor, err := b.client.Reset(ctx, p.resetType)
+	if err != nil {
+		return "", false, err
+	}
+
+	if monitor == "" {
+		return string(p.resetType), false, nil
+	}
+	report("waiting for BMC task " + monitor)
+	if _, err := b.client.WaitTask(ctx, monitor); err != nil {
+		return "", false, err
+	}
+	return string(p.resetType), true, nil
+}
+
+func (b *redfishBackend) powerStatus(ctx context.Context) (powerStatus, error) {
+	system, err := b.client.System(ctx)
+	if err != nil {
+		return powerStatus{}, err
+	}
+	return powerStatus{state: system.PowerState, lastReset: system.LastResetTime}, nil
+}
+
+// ipmiBackend 通过IPMI Chassis Control控制电源
+type ipmiBackend struct {
+	client *ipmi.Client
+}
+
+func (b *ipmiBackend) protocol() string { return ProtocolIPMI }
+
+// apply 硬复位不断电，电源状态无法反映复位是否发生，以BMC返回成功作为确认；其他操作需要轮询电源状态确认
+func (b *ipmiBackend) apply(ctx context.Context, p plan, report func(string)) (string, bool, error) {
+	report(fmt.Sprintf("sending chassis control %s to BMC", p.chassis))
+	if err := b.client.ChassisControl(ctx, p.chassis); err != nil {
+		return "", false, err
+	}
+	return p.chassis.String(), p.chassis == ipmi.ChassisHardReset, nil
+}
+
+func (b *ipmiBackend) powerStatus(ctx context.Context) (powerStatus, error) {
+	status, err := b.client.ChassisStatus(ctx)
+	if err != nil {
+		return powerStatus{}, err
+	}
+	if status.PowerOn {
+		return powerStatus{state: stateOn}, nil
+	}
+	return powerStatus{state: stateOff}, nil
+}
@@ -0,0 +1,244 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/ipmi"
+	"gpu-management/internal/services/redfish"
+	"gpu-management/internal/services/task"
+)
+
+// 电源操作
+const (
+	ActionOn               = "on"
+	ActionOff              = "off"
+	ActionGracefulShutdown = "graceful_shutdown"
+	ActionReset            = "reset"
+	ActionPowerCycle       = "power_cycle"
+)
+
+// 电源服务错误
+var (
+	// ErrServerBusy 服务器上仍有运行中的分配
+	ErrServerBusy = errors.New("server has active allocations")
+	// ErrTaskInProgress 服务器已有未完成的电源任务
+	ErrTaskInProgress = errors.New("power task already in progress")
+)
+
+// plan 电源操作在各协议下对应的命令和期望的最终电源状态
+type plan struct {
+	resetType redfish.ResetType
+	chassis   ipmi.ChassisControl
+	expected  string
+	// restart 操作结束时的电源状态与操作前相同，BMC未确认完成时需要先观察到系统重启
+	restart bool
+}
+
+var plans = map[string]plan{
+	ActionOn:               {resetType: redfish.ResetOn, chassis: ipmi.ChassisPowerUp, expected: stateOn},
+	ActionOff:              {resetType: redfish.ResetForceOff, chassis: ipmi.ChassisPowerDown, expected: stateOff},
+	ActionGracefulShutdown: {resetType: redfish.ResetGracefulShutdown, chassis: ipmi.ChassisSoftShutdown, expected: stateOff},
+	ActionReset:            {resetType: redfish.ResetForceRestart, chassis: ipmi.ChassisHardReset, expected: stateOn, restart: true},
+	ActionPowerCycle:       {resetType: redfish.ResetPowerCycle, chassis: ipmi.ChassisPowerCycle, expected: stateOn, restart: true},
+}
+
+// Request 电源控制请求
+type Request struct {
+	Action string `json:"action"`
+	// Force 服务器上有运行中的分配时仍然执行
+	Force bool `json:"force"`
+}
+
+// Validate 校验电源控制请求
+func (r *Request) Validate() error {
+	if r.Action == "" {
+		return errors.New("action is required")
+	}
+	if _, ok := plans[r.Action]; !ok {
+		return fmt.Errorf("unknown action %q, expected one of on, off, graceful_shutdown, reset, power_cycle", r.Action)
+	}
+	return nil
+}
+
+// Config 电源服务配置
+type Config struct {
+	// TaskTimeout 单个电源任务从下发到确认电源状态的最长时间
+	TaskTimeout time.Duration
+	// PollInterval 确认电源状态时的轮询间隔
+	PollInterval time.Duration
+}
+
+// Service 电源控制服务，电源操作以异步任务执行
+type Service struct {
+	repos   *repository.Repositories
+	redfish *redfish.Pool
+	ipmi    *ipmi.Pool
+	runner  *task.Runner
+	config  Config
+
+	// mu 串行化任务提交，保证同一服务器同时只有一个电源任务
+	mu sync.Mutex
+}
+
+// NewService 创建电源控制服务
+func NewService(repos *repository.Repositories, redfishPool *redfish.Pool, ipmiPool *ipmi.Pool, runner *task.Runner, config Config) *Service {
+	return &Service{
+		repos:   repos,
+		redfish: redfishPool,
+		ipmi:    ipmiPool,
+		runner:  runner,
+		config:  config,
+	}
+}
+
+// Submit 校验并提交电源任务，返回pending状态的任务
+func (s *Service) Submit(ctx context.Context, serverID string, req *Request, createdBy string) (*models.Task, error) {
+	server, err := s.repos.Servers.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.backendFor(server)
+	if err != nil {
+		return nil, fmt.Errorf("server %s: %w", serverID, err)
+	}
+
+	if !req.Force {
+		if err := s.checkIdle(ctx, serverID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inflight, err := s.repos.Tasks.List(ctx, repository.TaskFilter{
+		Type:       models.TaskTypeServerPower,
+		TargetType: "server",
+		TargetID:   serverID,
+		Statuses:   []string{models.TaskStatusPending, models.TaskStatusRunning},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(inflight) > 0 {
+		return nil, fmt.Errorf("%w: task %s", ErrTaskInProgress, inflight[0].ID)
+	}
+
+	p := plans[req.Action]
+	t := &models.Task{
+		Type:       models.TaskTypeServerPower,
+		TargetType: "server",
+		TargetID:   serverID,
+		Message:    "queued",
+		Input: map[string]interface{}{
+			"action":   req.Action,
+			"force":    req.Force,
+			"protocol": b.protocol(),
+		},
+		CreatedBy: createdBy,
+	}
+	err = s.runner.Submit(ctx, t, s.config.TaskTimeout, func(ctx context.Context, report func(string)) (map[string]interface{}, error) {
+		return s.execute(ctx, b, req.Action, p, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkIdle 服务器上有运行中的分配或正在使用的GPU时拒绝电源操作
+func (s *Service) checkIdle(ctx context.Context, serverID string) error {
+	allocations, err := s.repos.Allocations.List(ctx, repository.AllocationFilter{
+		ServerID: serverID,
+		Status:   models.AllocationStatusActive,
+	})
+	if err != nil {
+		return err
+	}
+	if len(allocations) > 0 {
+		ids := make([]string, len(allocations))
+		for i, a := range allocations {
+			ids[i] = a.ID
+		}
+		return fmt.Errorf("%w: %s (use force=true to override)", ErrServerBusy, strings.Join(ids, ", "))
+	}
+
+	// 跨服务器放置的分配只记录主服务器，按GPU状态兜底检查
+	gpus, err := s.repos.GPUs.List(ctx, repository.GPUFilter{
+		ServerID: serverID,
+		Status:   models.GPUStatusInUse,
+	})
+	if err != nil {
+		return err
+	}
+	if len(gpus) > 0 {
+		return fmt.Errorf("%w: %d gpus in use (use force=true to override)", ErrServerBusy, len(gpus))
+	}
+	return nil
+}
+
+// execute 下发电源操作并等待BMC确认最终电源状态
+func (s *Service) execute(ctx context.Context, b backend, action string, p plan, report func(string)) (map[string]interface{}, error) {
+	var before powerStatus
+	if p.restart {
+		var err error
+		if before, err = b.powerStatus(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	operation, confirmed, err := b.apply(ctx, p, report)
+	if err != nil {
+		return nil, err
+	}
+
+	report("waiting for power state " + p.expected)
+	state, err := s.waitPowerState(ctx, b, p.expected, p.restart && !confirmed, before)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"action":      action,
+		"protocol":    b.protocol(),
+		"operation":   operation,
+		"power_state": state,
+	}, nil
+}
+
+// waitPowerState 轮询系统电源状态直到达到期望值
+// restart为true时操作前后的电源状态相同，必须先观察到其他电源状态或复位标记相对before变化，之后的期望状态才算确认
+func (s *Service) waitPowerState(ctx context.Context, b backend, expected string, restart bool, before powerStatus) (string, error) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	restarted := !restart
+	for {
+		status, err := b.powerStatus(ctx)
+		if err != nil {
+			return "", err
+		}
+		if status.state != expected || status.lastReset != before.lastReset {
+			restarted = true
+		}
+		if restarted && status.state == expected {
+			return status.state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if !restarted {
+				return status.state, fmt.Errorf("power state is still %s, no restart observed: %w", status.state, ctx.Err())
+			}
+			return status.state, fmt.Errorf("power state is still %s: %w", status.state, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package power
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gpu-management/internal/repository"
+)
+
+// fakeBackend 按顺序返回预设电源状态的BMC，状态用完后保持最后一个
+type fakeBackend struct {
+	// confirmed BMC是否确认操作已执行完成
+	confirmed bool
+	statuses  []powerStatus
+	// polls apply之后查询电源状态的次数
+	polls   int
+	applied bool
+}
+
+func (b *fakeBackend) protocol() string { return "fake" }
+
+func (b *fakeBackend) apply(ctx context.Context, p plan, report func(string)) (string, bool, error) {
+	b.applied = true
+	return string(p.resetType), b.confirmed, nil
+}
+
+func (b *fakeBackend) powerStatus(ctx context.Context) (powerStatus, error) {
+	if !b.applied {
+		// 操作前的状态
+		return b.statuses[0], nil
+	}
+	i := b.polls
+	if i >= len(b.statuses) {
+		i = len(b.statuses) - 1
+	}
+	b.polls++
+	return b.statuses[i], nil
+}
+
+// on 开机状态，reset为复位标记
+func on(reset string) powerStatus { return powerStatus{state: stateOn, lastReset: reset} }
+
+func TestServiceExecute(t *testing.T) {
+	off := powerStatus{state: stateOff}
+	poweringOn := powerStatus{state: "PoweringOn"}
+
+	tests := []struct {
+		name      string
+		action    string
+		confirmed bool
+		// statuses 操作前和每次轮询返回的电源状态，第一个同时作为操作前的状态
+		statuses  []powerStatus
+		wantPolls int
+		wantErr   bool
+	}{
+		{name: "on", action: ActionOn, statuses: []powerStatus{off, off, on("")}, wantPolls: 3},
+		{name: "off", action: ActionOff, statuses: []powerStatus{on(""), off}, wantPolls: 2},
+		{
+			// 服务器在复位开始前仍报告开机，必须等到断电后再上电
+			name:      "reset waits for power off",
+			action:    ActionReset,
+			statuses:  []powerStatus{on(""), on(""), off, off, on("")},
+			wantPolls: 5,
+		},
+		{
+			name:      "power cycle waits for powering on",
+			action:    ActionPowerCycle,
+			statuses:  []powerStatus{on(""), on(""), poweringOn, on("")},
+			wantPolls: 4,
+		},
+		{
+			// 热复位不断电，复位标记变化即表示已重启
+			name:      "reset marker changed",
+			action:    ActionReset,
+			statuses:  []powerStatus{on("t1"), on("t1"), on("t2")},
+			wantPolls: 3,
+		},
+		{
+			// BMC任务已完成时不需要观察重启
+			name:      "reset confirmed by BMC",
+			action:    ActionReset,
+			confirmed: true,
+			statuses:  []powerStatus{on("")},
+			wantPolls: 1,
+		},
+		{name: "reset never restarts", action: ActionReset, statuses: []powerStatus{on("t1")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(repository.NewMemoryRepositories(), nil, nil, nil, Config{PollInterval: time.Millisecond})
+			b := &fakeBackend{confirmed: tt.confirmed, statuses: tt.statuses}
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			result, err := s.execute(ctx, b, tt.action, plans[tt.action], func(string) {})
+			if tt.wantErr {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("execute() error = %v, want %v", err, context.DeadlineExceeded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("execute() error = %v", err)
+			}
+			if result["power_state"] != plans[tt.action].expected {
+				t.Errorf("power_state = %v, want %s", result["power_state"], plans[tt.action].expected)
+			}
+			if b.polls != tt.wantPolls {
+				t.Errorf("polls = %d, want %d", b.polls, tt.wantPolls)
+			}
+		})
+	}
+}
@@ -0,0 +1,709 @@
+package redfish
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	serviceRootURI = "/redfish/v1"
+	systemsURI     = "/redfish/v1/Systems"
+	sessionsURI    = "/redfish/v1/SessionService/Sessions"
+	updateURI      = "/redfish/v1/UpdateService"
+
+	// defaultPollInterval 任务监视器默认轮询间隔
+	defaultPollInterval = 2 * time.Second
+)
+
+// 客户端错误
+var (
+	// ErrNoEndpoint 服务器未配置Redfish地址
+	ErrNoEndpoint = errors.New("server has no redfish endpoint")
+	// ErrNoSystem BMC上没有ComputerSystem资源
+	ErrNoSystem = errors.New("redfish service exposes no computer system")
+	// ErrUnsupportedReset 系统不支持该重置类型
+	ErrUnsupportedReset = errors.New("reset type not supported by system")
+	// ErrUnknownAttribute BIOS属性不存在
+	ErrUnknownAttribute = errors.New("unknown bios attribute")
+	// ErrUpdateDisabled BMC未启用固件更新服务
+	ErrUpdateDisabled = errors.New("redfish update service is disabled")
+)
+
+// Error BMC返回的Redfish错误响应
+type Error struct {
+	StatusCode int       `json:"status_code"`
+	Code       string    `json:"code"`
+	Message    string    `json:"message"`
+	Extended   []Message `json:"extended,omitempty"`
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	msg := e.Message
+	if len(e.Extended) > 0 && e.Extended[0].Message != "" {
+		msg = e.Extended[0].Message
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("redfish: %d %s", e.StatusCode, msg)
+}
+
+// TaskError 任务以非Completed状态结束
+type TaskError struct {
+	Task *Task
+}
+
+// Error 实现error接口
+func (e *TaskError) Error() string {
+	msg := ""
+	if len(e.Task.Messages) > 0 {
+		msg = ": " + e.Task.Messages[0].Message
+	}
+	return fmt.Sprintf("redfish task %s ended in state %s%s", e.Task.ID, e.Task.TaskState, msg)
+}
+
+// Config Redfish客户端配置，BMC凭据对所有服务器通用
+type Config struct {
+	Username string
+	Password string
+	// InsecureSkipVerify 跳过BMC自签名证书校验
+	InsecureSkipVerify bool
+	// Timeout 单个HTTP请求超时
+	Timeout time.Duration
+	// PollInterval 任务监视器轮询间隔
+	PollInterval time.Duration
+}
+
+// Client Redfish客户端，通过会话认证访问单个BMC
+type Client struct {
+	baseURL    string
+	config     Config
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	token      string
+	sessionURI string
+	basicAuth  bool   // BMC不支持会话时退化为Basic认证
+	systemURI  string // 缓存的ComputerSystem地址
+}
+
+// NewClient 创建Redfish客户端
+// endpoint可以是BMC根地址，也可以直接指向某个ComputerSystem，例如 https://bmc/redfish/v1/Systems/1
+func NewClient(endpoint string, config Config) (*Client, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("invalid redfish endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid redfish endpoint %q: scheme and host are required", endpoint)
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	c := &Client{
+		baseURL:    u.Scheme + "://" + u.Host,
+		config:     config,
+		httpClient: &http.Client{Transport: transport, Timeout: config.Timeout},
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	if strings.HasPrefix(path, systemsURI+"/") {
+		c.systemURI = path
+	}
+	return c, nil
+}
+
+// Endpoint 返回BMC根地址
+func (c *Client) Endpoint() string {
+	return c.baseURL
+}
+
+// response 已读取完毕的HTTP响应
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// decode 将响应体解析到out，响应体为空时不做处理
+func (r *response) decode(out interface{}) error {
+	if out == nil || len(bytes.TrimSpace(r.body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.body, out); err != nil {
+		return fmt.Errorf("redfish: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Login 建立Redfish会话，BMC不支持会话服务时改用Basic认证
+func (c *Client) Login(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loginLocked(ctx)
+}
+
+func (c *Client) loginLocked(ctx context.Context) error {
+	payload := map[string]string{
+		"UserName": c.config.Username,
+		"Password": c.config.Password,
+	}
+	resp, err := c.send(ctx, http.MethodPost, sessionsURI, payload, nil, "")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.status == http.StatusNotFound || resp.status == http.StatusMethodNotAllowed:
+		c.basicAuth = true
+		return nil
+	case resp.status >= 400:
+		return parseError(resp)
+	}
+
+	token := resp.header.Get("X-Auth-Token")
+	if token == "" {
+		return fmt.Errorf("redfish: session created without X-Auth-Token")
+	}
+	c.token = token
+	c.sessionURI = c.relative(resp.header.Get("Location"))
+	return nil
+}
+
+// Logout 删除会话
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	sessionURI, token := c.sessionURI, c.token
+	c.token, c.sessionURI = "", ""
+	c.mu.Unlock()
+
+	if token == "" || sessionURI == "" {
+		return nil
+	}
+	resp, err := c.send(ctx, http.MethodDelete, sessionURI, nil, nil, token)
+	if err != nil {
+		return err
+	}
+	if resp.status >= 400 && resp.status != http.StatusNotFound {
+		return parseError(resp)
+	}
+	return nil
+}
+
+// request 发送认证请求，会话过期时重新登录并重试一次，4xx/5xx响应转换为*Error
+func (c *Client) request(ctx context.Context, method, uri string, body interface{}, header http.Header) (*response, error) {
+	token, err := c.ensureSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, method, uri, body, header, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.status == http.StatusUnauthorized && token != "" {
+		token, err = c.renewSession(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = c.send(ctx, method, uri, body, header, token); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.status >= 400 {
+		return nil, parseError(resp)
+	}
+	return resp, nil
+}
+
+// ensureSession 返回当前会话令牌，尚未登录时先登录
+func (c *Client) ensureSession(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" && !c.basicAuth {
+		if err := c.loginLocked(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// renewSession 令牌失效后重新登录，并发请求只会触发一次登录
+func (c *Client) renewSession(ctx context.Context, stale string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != stale {
+		return c.token, nil
+	}
+	c.token, c.sessionURI = "", ""
+	if err := c.loginLocked(ctx); err != nil {
+		return "", err
+	}
+	return c.token, nil
+}
+
+// send 发送单个HTTP请求并读取完整响应体
+func (c *Client) send(ctx context.Context, method, uri string, body interface{}, header http.Header, token string) (*response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.absolute(uri), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OData-Version", "4.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	switch {
+	case token != "":
+		req.Header.Set("X-Auth-Token", token)
+	case c.basicAuth:
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("redfish %s %s: %w", method, uri, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("redfish %s %s: %w", method, uri, err)
+	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: data}, nil
+}
+
+// absolute 将资源路径补全为完整URL
+func (c *Client) absolute(uri string) string {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return uri
+	}
+	return c.baseURL + uri
+}
+
+// relative 将BMC返回的Location统一为资源路径
+func (c *Client) relative(location string) string {
+	if location == "" {
+		return ""
+	}
+	if u, err := url.Parse(location); err == nil && u.Host != "" {
+		return u.RequestURI()
+	}
+	return location
+}
+
+// parseError 解析Redfish错误响应体
+func parseError(resp *response) error {
+	e := &Error{StatusCode: resp.status}
+
+	var body struct {
+		Error struct {
+			Code     string    `json:"code"`
+			Message  string    `json:"message"`
+			Extended []Message `json:"@Message.ExtendedInfo"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.body, &body); err == nil {
+		e.Code = body.Error.Code
+		e.Message = body.Error.Message
+		e.Extended = body.Error.Extended
+	}
+	return e
+}
+
+// get 读取资源并解析
+func (c *Client) get(ctx context.Context, uri string, out interface{}) (*response, error) {
+	resp, err := c.request(ctx, http.MethodGet, uri, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp, resp.decode(out)
+}
+
+// ServiceRoot 读取服务根
+func (c *Client) ServiceRoot(ctx context.Context) (*ServiceRoot, error) {
+	var root ServiceRoot
+	if _, err := c.get(ctx, serviceRootURI, &root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// Systems 列出BMC管理的ComputerSystem地址
+func (c *Client) Systems(ctx context.Context) ([]string, error) {
+	var collection Collection
+	if _, err := c.get(ctx, systemsURI, &collection); err != nil {
+		return nil, err
+	}
+
+	uris := make([]string, 0, len(collection.Members))
+	for _, member := range collection.Members {
+		uris = append(uris, member.ID)
+	}
+	return uris, nil
+}
+
+// systemPath 返回目标ComputerSystem地址，未指定时使用集合中的第一个系统
+func (c *Client) systemPath(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	cached := c.systemURI
+	c.mu.Unlock()
+	if cached != "" {
+		return cached, nil
+	}
+
+	uris, err := c.Systems(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(uris) == 0 {
+		return "", ErrNoSystem
+	}
+
+	c.mu.Lock()
+	c.systemURI = uris[0]
+	c.mu.Unlock()
+	return uris[0], nil
+}
+
+// System 读取ComputerSystem清单
+func (c *Client) System(ctx context.Context) (*ComputerSystem, error) {
+	uri, err := c.systemPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var system ComputerSystem
+	if _, err := c.get(ctx, uri, &system); err != nil {
+		return nil, err
+	}
+	if system.ODataID == "" {
+		system.ODataID = uri
+	}
+	return &system, nil
+}
+
+// Reset 执行ComputerSystem.Reset，BMC以异步任务受理时返回任务监视器地址
+func (c *Client) Reset(ctx context.Context, resetType ResetType) (string, error) {
+	system, err := c.System(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	action := system.Actions.Reset
+	if len(action.AllowableValues) > 0 && !containsReset(action.AllowableValues, resetType) {
+		return "", fmt.Errorf("%w: %s (allowed: %v)", ErrUnsupportedReset, resetType, action.AllowableValues)
+	}
+
+	target := action.Target
+	if target == "" {
+		target = system.ODataID + "/Actions/ComputerSystem.Reset"
+	}
+
+	resp, err := c.request(ctx, http.MethodPost, target, map[string]ResetType{"ResetType": resetType}, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.status == http.StatusAccepted {
+		return c.relative(resp.header.Get("Location")), nil
+	}
+	return "", nil
+}
+
+func containsReset(values []ResetType, v ResetType) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Task 读取任务监视器或任务资源
+// 任务监视器在任务运行期间返回202，结束后返回200/204
+func (c *Client) Task(ctx context.Context, uri string) (*Task, error) {
+	resp, err := c.request(ctx, http.MethodGet, uri, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var task Task
+	if err := resp.decode(&task); err != nil {
+		return nil, err
+	}
+
+	if task.TaskState == "" {
+		// 监视器只返回了操作结果，按状态码推断任务状态
+		if resp.status == http.StatusAccepted {
+			task.TaskState = TaskStateRunning
+		} else {
+			task.TaskState = TaskStateCompleted
+		}
+	}
+	if task.TaskMonitor == "" {
+		task.TaskMonitor = uri
+	}
+	return &task, nil
+}
+
+// WaitTask 轮询任务直到结束，任务失败时返回*TaskError
+func (c *Client) WaitTask(ctx context.Context, uri string) (*Task, error) {
+	ticker := time.NewTicker(c.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		task, err := c.Task(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		if task.Finished() {
+			if task.TaskState != TaskStateCompleted {
+				return task, &TaskError{Task: task}
+			}
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return task, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Bios 读取当前生效的BIOS属性
+func (c *Client) Bios(ctx context.Context) (*Bios, error) {
+	system, err := c.System(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := system.Bios.ID
+	if uri == "" {
+		uri = system.ODataID + "/Bios"
+	}
+
+	var bios Bios
+	if _, err := c.get(ctx, uri, &bios); err != nil {
+		return nil, err
+	}
+	if bios.ODataID == "" {
+		bios.ODataID = uri
+	}
+	return &bios, nil
+}
+
+// settingsPath 返回保存待生效BIOS设置的资源地址
+func settingsPath(bios *Bios) string {
+	if bios.Settings != nil && bios.Settings.SettingsObject.ID != "" {
+		return bios.Settings.SettingsObject.ID
+	}
+	return bios.ODataID + "/Settings"
+}
+
+// PendingBiosAttributes 返回已写入设置对象但尚未生效的BIOS属性
+func (c *Client) PendingBiosAttributes(ctx context.Context) (map[string]interface{}, error) {
+	bios, err := c.Bios(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending, _, err := c.pendingAttributes(ctx, bios)
+	return pending, err
+}
+
+// pendingAttributes 比较设置对象与当前属性，同时返回设置对象的ETag
+func (c *Client) pendingAttributes(ctx context.Context, bios *Bios) (map[string]interface{}, string, error) {
+	var settings Bios
+	resp, err := c.get(ctx, settingsPath(bios), &settings)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pending := map[string]interface{}{}
+	for name, value := range settings.Attributes {
+		if current, ok := bios.Attributes[name]; !ok || !reflect.DeepEqual(current, value) {
+			pending[name] = value
+		}
+	}
+	return pending, resp.header.Get("ETag"), nil
+}
+
+// SetBiosAttributes 修改BIOS属性
+// 属性写入@Redfish.Settings指向的设置对象，通常在下次重启时生效
+func (c *Client) SetBiosAttributes(ctx context.Context, attributes map[string]interface{}) (*BiosUpdate, error) {
+	bios, err := c.Bios(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name := range attributes {
+		if _, ok := bios.Attributes[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttribute, name)
+		}
+	}
+
+	// 读取设置对象的ETag，以If-Match防止覆盖其他客户端的并发修改
+	_, etag, err := c.pendingAttributes(ctx, bios)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	}
+
+	applyTime := ApplyTimeOnReset
+	payload := map[string]interface{}{"Attributes": attributes}
+	if bios.Settings != nil && len(bios.Settings.SupportedApplyTimes) > 0 {
+		if !containsString(bios.Settings.SupportedApplyTimes, ApplyTimeOnReset) {
+			applyTime = bios.Settings.SupportedApplyTimes[0]
+		}
+		payload["@Redfish.SettingsApplyTime"] = map[string]string{"ApplyTime": applyTime}
+	}
+
+	if _, err := c.request(ctx, http.MethodPatch, settingsPath(bios), payload, header); err != nil {
+		return nil, err
+	}
+
+	// 重新读取，区分立即生效和等待重启的属性
+	current, err := c.Bios(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending, _, err := c.pendingAttributes(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BiosUpdate{
+		Pending:       pending,
+		ApplyTime:     applyTime,
+		RequiresReset: len(pending) > 0,
+	}, nil
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateService 读取固件更新服务，服务根未给出地址时使用标准路径
+func (c *Client) UpdateService(ctx context.Context) (*UpdateService, error) {
+	uri := updateURI
+	root, err := c.ServiceRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if root.UpdateService.ID != "" {
+		uri = root.UpdateService.ID
+	}
+
+	var service UpdateService
+	if _, err := c.get(ctx, uri, &service); err != nil {
+		return nil, err
+	}
+	if service.ODataID == "" {
+		service.ODataID = uri
+	}
+	return &service, nil
+}
+
+// FirmwareInventory 读取BMC上报的全部固件组件及版本
+func (c *Client) FirmwareInventory(ctx context.Context) ([]SoftwareInventory, error) {
+	service, err := c.UpdateService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := service.FirmwareInventory.ID
+	if uri == "" {
+		uri = service.ODataID + "/FirmwareInventory"
+	}
+	var collection Collection
+	if _, err := c.get(ctx, uri, &collection); err != nil {
+		return nil, err
+	}
+
+	items := make([]SoftwareInventory, 0, len(collection.Members))
+	for _, member := range collection.Members {
+		var item SoftwareInventory
+		if _, err := c.get(ctx, member.ID, &item); err != nil {
+			return nil, err
+		}
+		if item.ODataID == "" {
+			item.ODataID = member.ID
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// SimpleUpdate 执行UpdateService.SimpleUpdate，由BMC从imageURI拉取镜像并刷写targets指定的组件
+// BMC以异步任务受理时返回任务监视器地址
+func (c *Client) SimpleUpdate(ctx context.Context, imageURI string, targets []string) (string, error) {
+	service, err := c.UpdateService(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !service.ServiceEnabled {
+		return "", ErrUpdateDisabled
+	}
+
+	target := service.Actions.SimpleUpdate.Target
+	if target == "" {
+		target = service.ODataID + "/Actions/UpdateService.SimpleUpdate"
+	}
+
+	body := map[string]interface{}{"ImageURI": imageURI}
+	if len(targets) > 0 {
+		body["Targets"] = targets
+	}
+	resp, err := c.request(ctx, http.MethodPost, target, body, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.status == http.StatusAccepted {
+		return c.relative(resp.header.Get("Location")), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,260 @@
+package redfish
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestClient 启动模拟器并创建指向它的客户端
+func newTestClient(t *testing.T, password string) (*Simulator, *Client) {
+	t.Helper()
+	sim, server := NewTestServer("admin", "secret")
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, Config{
+		Username:     "admin",
+		Password:     password,
+		Timeout:      5 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return sim, client
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		endpoint   string
+		wantErr    bool
+		wantBase   string
+		wantSystem string
+	}{
+		{name: "root", endpoint: "https://bmc.example:8443", wantBase: "https://bmc.example:8443"},
+		{name: "system", endpoint: " https://bmc/redfish/v1/Systems/2/ ", wantBase: "https://bmc", wantSystem: "/redfish/v1/Systems/2"},
+		{name: "missing scheme", endpoint: "bmc.example", wantErr: true},
+		{name: "invalid", endpoint: "http://[::1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.endpoint, Config{})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewClient(%q) succeeded, want error", tt.endpoint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient(%q) error = %v", tt.endpoint, err)
+			}
+			if c.Endpoint() != tt.wantBase {
+				t.Errorf("Endpoint() = %q, want %q", c.Endpoint(), tt.wantBase)
+			}
+			if c.systemURI != tt.wantSystem {
+				t.Errorf("systemURI = %q, want %q", c.systemURI, tt.wantSystem)
+			}
+			if c.config.PollInterval != defaultPollInterval {
+				t.Errorf("PollInterval = %v, want %v", c.config.PollInterval, defaultPollInterval)
+			}
+		})
+	}
+}
+
+func TestClientSessionAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		expire     bool // 登录后使会话失效，客户端应重新登录
+		wantStatus int  // 非0时期望返回该状态码的*Error
+	}{
+		{name: "valid credentials", password: "secret"},
+		{name: "expired session is renewed", password: "secret", expire: true},
+		{name: "invalid credentials", password: "wrong", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, client := newTestClient(t, tt.password)
+			ctx := context.Background()
+
+			err := client.Login(ctx)
+			if tt.wantStatus != 0 {
+				var rfErr *Error
+				if !errors.As(err, &rfErr) || rfErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Login() error = %v, want redfish error %d", err, tt.wantStatus)
+				}
+				if _, err := client.System(ctx); err == nil {
+					t.Fatal("System() succeeded without a session")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			first := client.token
+
+			if tt.expire {
+				sim.ExpireSessions()
+			}
+			system, err := client.System(ctx)
+			if err != nil {
+				t.Fatalf("System() error = %v", err)
+			}
+			if system.ODataID != simSystemURI {
+				t.Errorf("System().ODataID = %q, want %q", system.ODataID, simSystemURI)
+			}
+			if renewed := client.token != first; renewed != tt.expire {
+				t.Errorf("session renewed = %v, want %v", renewed, tt.expire)
+			}
+
+			if err := client.Logout(ctx); err != nil {
+				t.Fatalf("Logout() error = %v", err)
+			}
+			sim.mu.Lock()
+			sessions := len(sim.sessions)
+			sim.mu.Unlock()
+			if sessions != 0 {
+				t.Errorf("%d sessions left after Logout()", sessions)
+			}
+		})
+	}
+}
+
+func TestClientReset(t *testing.T) {
+	tests := []struct {
+		name      string
+		initial   string
+		resetType ResetType
+		want      string
+		wantErr   error
+	}{
+		{name: "power on", initial: PowerStateOff, resetType: ResetOn, want: PowerStateOn},
+		{name: "force off", initial: PowerStateOn, resetType: ResetForceOff, want: PowerStateOff},
+		{name: "graceful shutdown", initial: PowerStateOn, resetType: ResetGracefulShutdown, want: PowerStateOff},
+		{name: "power cycle", initial: PowerStateOn, resetType: ResetPowerCycle, want: PowerStateOn},
+		{name: "unsupported", initial: PowerStateOn, resetType: "Nmi", wantErr: ErrUnsupportedReset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, client := newTestClient(t, "secret")
+			sim.SetPowerState(tt.initial)
+			sim.SetTaskDuration(0)
+			ctx := context.Background()
+
+			monitor, err := client.Reset(ctx, tt.resetType)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Reset(%s) error = %v, want %v", tt.resetType, err, tt.wantErr)
+				}
+				if sim.PowerState() != tt.initial {
+					t.Errorf("power state = %q after rejected reset, want %q", sim.PowerState(), tt.initial)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reset(%s) error = %v", tt.resetType, err)
+			}
+			if monitor == "" {
+				t.Fatalf("Reset(%s) returned no task monitor", tt.resetType)
+			}
+
+			task, err := client.WaitTask(ctx, monitor)
+			if err != nil {
+				t.Fatalf("WaitTask() error = %v", err)
+			}
+			if task.TaskState != TaskStateCompleted {
+				t.Errorf("TaskState = %q, want %q", task.TaskState, TaskStateCompleted)
+			}
+			if got := sim.PowerState(); got != tt.want {
+				t.Errorf("power state = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientWaitTask(t *testing.T) {
+	tests := []struct {
+		name      string
+		duration  time.Duration
+		failure   string
+		timeout   time.Duration
+		wantState string
+		wantErr   func(error) bool
+	}{
+		{
+			name:      "completes after polling",
+			duration:  50 * time.Millisecond,
+			timeout:   5 * time.Second,
+			wantState: TaskStateCompleted,
+		},
+		{
+			name:      "exception",
+			failure:   "image signature mismatch",
+			timeout:   5 * time.Second,
+			wantState: TaskStateException,
+			wantErr: func(err error) bool {
+				var taskErr *TaskError
+				return errors.As(err, &taskErr) && taskErr.Task.TaskState == TaskStateException
+			},
+		},
+		{
+			name:     "context expires while running",
+			duration: time.Hour,
+			timeout:  50 * time.Millisecond,
+			wantErr:  func(err error) bool { return errors.Is(err, context.DeadlineExceeded) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, client := newTestClient(t, "secret")
+			sim.SetUpdateDuration(tt.duration)
+			sim.SetUpdateFailure(tt.failure)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			monitor, err := client.SimpleUpdate(ctx, "http://images/bios-2.1.0.bin", nil)
+			if err != nil {
+				t.Fatalf("SimpleUpdate() error = %v", err)
+			}
+
+			// 任务受理后监视器先返回运行中
+			if tt.duration > 0 {
+				task, err := client.Task(ctx, monitor)
+				if err != nil {
+					t.Fatalf("Task() error = %v", err)
+				}
+				if task.TaskState != TaskStateRunning {
+					t.Errorf("initial TaskState = %q, want %q", task.TaskState, TaskStateRunning)
+				}
+			}
+
+			task, err := client.WaitTask(ctx, monitor)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("WaitTask() error = %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("WaitTask() error = %v", err)
+			}
+			// 上下文在请求途中到期时没有任务状态可返回
+			if tt.wantState != "" && (task == nil || task.TaskState != tt.wantState) {
+				t.Fatalf("WaitTask() task = %+v, want state %q", task, tt.wantState)
+			}
+
+			wantVersion := "1.0.0"
+			if tt.wantState == TaskStateCompleted {
+				wantVersion = "2.1.0"
+			}
+			if got := sim.FirmwareVersion("BIOS"); got != wantVersion {
+				t.Errorf("BIOS version = %q, want %q", got, wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package redfish
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool 按BMC地址复用Redfish客户端，避免每次请求都新建会话
+type Pool struct {
+	config Config
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewPool 创建Redfish客户端池
+func NewPool(config Config) *Pool {
+	return &Pool{
+		config:  config,
+		clients: map[string]*Client{},
+	}
+}
+
+// Client 返回endpoint对应的客户端，不存在时创建
+func (p *Pool) Client(endpoint string) (*Client, error) {
+	if endpoint == "" {
+		return nil, ErrNoEndpoint
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[endpoint]; ok {
+		return client, nil
+	}
+
+	client, err := NewClient(endpoint, p.config)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[endpoint] = client
+	return client, nil
+}
+
+// Close 注销所有会话，BMC会话数有限，退出时应主动释放
+func (p *Pool) Close(ctx context.Context) {
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = map[string]*Client{}
+	p.mu.Unlock()
+
+	for _, client := range clients {
+		// 注销失败时会话会在BMC侧超时，不影响退出
+		_ = client.Logout(ctx)
+	}
+}
@@ -0,0 +1,670 @@
+package redfish
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 模拟器资源地址，只模拟一个ComputerSystem
+const (
+	simSystemURI       = "/redfish/v1/Systems/1"
+	simBiosURI         = simSystemURI + "/Bios"
+	simBiosSettingsURI = simBiosURI + "/Settings"
+	simResetURI        = simSystemURI + "/Actions/ComputerSystem.Reset"
+	simTasksURI        = "/redfish/v1/TaskService/Tasks/"
+	simMonitorsURI     = "/redfish/v1/TaskService/TaskMonitors/"
+	simInventoryURI    = updateURI + "/FirmwareInventory"
+	simSimpleUpdateURI = updateURI + "/Actions/UpdateService.SimpleUpdate"
+)
+
+// imageVersion 从镜像文件名中提取版本号，例如 bios-2.1.0.bin -> 2.1.0
+var imageVersion = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// simTask 模拟器中的异步任务，到期后执行apply，failure非空时以Exception结束且不执行apply
+type simTask struct {
+	task    Task
+	started time.Time
+	due     time.Time
+	apply   func()
+	failure string
+}
+
+// Simulator 内存中的Redfish BMC模拟器
+// 支持会话认证、系统清单、电源重置任务、BIOS待生效设置和固件更新，用于离线联调和测试
+type Simulator struct {
+	username string
+	password string
+
+	mu           sync.Mutex
+	sessions     map[string]string // session id -> token
+	nextID       int
+	powerState   string
+	lastReset    string // 最近一次复位或启动完成的时间
+	health       string
+	bios         map[string]interface{}
+	pending      map[string]interface{}
+	etag         int
+	tasks        map[string]*simTask
+	taskDuration time.Duration
+	// firmware 组件Id -> 版本
+	firmware       map[string]string
+	updateFailure  string
+	updateDuration time.Duration
+}
+
+// NewSimulator 创建模拟器，返回值实现http.Handler
+func NewSimulator(username, password string) *Simulator {
+	return &Simulator{
+		username:   username,
+		password:   password,
+		sessions:   map[string]string{},
+		powerState: PowerStateOn,
+		health:     "OK",
+		bios: map[string]interface{}{
+			"BootMode":           "Uefi",
+			"ProcTurboMode":      "Enabled",
+			"LogicalProc":        "Enabled",
+			"SriovGlobalEnable":  "Disabled",
+			"AboveFourGDecoding": "Enabled",
+			"NumaNodesPerSocket": 1,
+		},
+		pending:      map[string]interface{}{},
+		tasks:        map[string]*simTask{},
+		taskDuration: time.Second,
+		firmware: map[string]string{
+			"BIOS": "1.0.0",
+			"BMC":  "2.45.0",
+			"NIC":  "22.31.6",
+		},
+		updateDuration: time.Second,
+	}
+}
+
+// NewTestServer 创建模拟器并以httptest.Server启动，调用方负责Close
+func NewTestServer(username, password string) (*Simulator, *httptest.Server) {
+	sim := NewSimulator(username, password)
+	return sim, httptest.NewServer(sim)
+}
+
+// SetTaskDuration 设置电源任务从受理到完成的耗时
+func (s *Simulator) SetTaskDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskDuration = d
+}
+
+// SetUpdateDuration 设置固件更新任务从受理到完成的耗时
+func (s *Simulator) SetUpdateDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateDuration = d
+}
+
+// SetUpdateFailure 设置后续固件更新任务的失败原因，为空时更新正常完成
+func (s *Simulator) SetUpdateFailure(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateFailure = message
+}
+
+// FirmwareVersion 返回组件当前的固件版本
+func (s *Simulator) FirmwareVersion(component string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+	return s.firmware[component]
+}
+
+// SetPowerState 直接设置电源状态
+func (s *Simulator) SetPowerState(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.powerState = state
+}
+
+// PowerState 返回当前电源状态
+func (s *Simulator) PowerState() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+	return s.powerState
+}
+
+// BiosAttributes 返回当前生效的BIOS属性副本
+func (s *Simulator) BiosAttributes() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance()
+	return copyAttributes(s.bios)
+}
+
+// ExpireSessions 使所有会话失效，用于验证客户端的重新登录
+func (s *Simulator) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]string{}
+}
+
+// ServeHTTP 实现http.Handler
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 每次请求前推进到期的任务
+	s.advance()
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == serviceRootURI:
+		s.handleRoot(w, r)
+		return
+	case path == sessionsURI && r.Method == http.MethodPost:
+		s.handleLogin(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeSimError(w, http.StatusUnauthorized, "Base.1.8.NoValidSession", "There is no valid session established with the implementation.")
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(path, sessionsURI+"/"):
+		s.handleLogout(w, r, strings.TrimPrefix(path, sessionsURI+"/"))
+	case path == systemsURI:
+		s.handleSystems(w, r)
+	case path == simSystemURI:
+		s.handleSystem(w, r)
+	case path == simResetURI:
+		s.handleReset(w, r)
+	case path == simBiosURI:
+		s.handleBios(w, r)
+	case path == simBiosSettingsURI:
+		s.handleBiosSettings(w, r)
+	case path == updateURI:
+		s.handleUpdateService(w, r)
+	case path == simInventoryURI:
+		s.handleInventory(w, r)
+	case strings.HasPrefix(path, simInventoryURI+"/"):
+		s.handleInventoryItem(w, r, strings.TrimPrefix(path, simInventoryURI+"/"))
+	case path == simSimpleUpdateURI:
+		s.handleSimpleUpdate(w, r)
+	case strings.HasPrefix(path, simMonitorsURI):
+		s.handleTask(w, r, strings.TrimPrefix(path, simMonitorsURI), true)
+	case strings.HasPrefix(path, simTasksURI):
+		s.handleTask(w, r, strings.TrimPrefix(path, simTasksURI), false)
+	default:
+		writeSimError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI", fmt.Sprintf("The resource at the URI %s was not found.", r.URL.Path))
+	}
+}
+
+// authorized 校验X-Auth-Token或Basic认证
+func (s *Simulator) authorized(r *http.Request) bool {
+	if token := r.Header.Get("X-Auth-Token"); token != "" {
+		for _, t := range s.sessions {
+			if t == token {
+				return true
+			}
+		}
+		return false
+	}
+	username, password, ok := r.BasicAuth()
+	return ok && username == s.username && password == s.password
+}
+
+func (s *Simulator) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeSimJSON(w, http.StatusOK, ServiceRoot{
+		ODataID:        serviceRootURI,
+		RedfishVersion: "1.15.0",
+		UUID:           "92384634-2938-2342-8820-489239905423",
+		Systems:        ODataID{ID: systemsURI},
+		SessionService: ODataID{ID: "/redfish/v1/SessionService"},
+		TaskService:    ODataID{ID: "/redfish/v1/TaskService"},
+		UpdateService:  ODataID{ID: updateURI},
+	})
+}
+
+func (s *Simulator) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var credentials struct {
+		UserName string `json:"UserName"`
+		Password string `json:"Password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON", "The request body submitted was malformed JSON.")
+		return
+	}
+	if credentials.UserName != s.username || credentials.Password != s.password {
+		writeSimError(w, http.StatusUnauthorized, "Base.1.8.InsufficientPrivilege", "Invalid username or password.")
+		return
+	}
+
+	id := s.newID()
+	token := randomToken()
+	s.sessions[id] = token
+
+	uri := sessionsURI + "/" + id
+	w.Header().Set("X-Auth-Token", token)
+	w.Header().Set("Location", uri)
+	writeSimJSON(w, http.StatusCreated, map[string]string{
+		"@odata.id": uri,
+		"Id":        id,
+		"UserName":  credentials.UserName,
+	})
+}
+
+func (s *Simulator) handleLogout(w http.ResponseWriter, r *http.Request, id string) {
+	if !allowMethod(w, r, http.MethodDelete) {
+		return
+	}
+	if _, ok := s.sessions[id]; !ok {
+		writeSimError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI", "Session not found.")
+		return
+	}
+	delete(s.sessions, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Simulator) handleSystems(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeSimJSON(w, http.StatusOK, Collection{
+		ODataID: systemsURI,
+		Name:    "Computer System Collection",
+		Members: []ODataID{{ID: simSystemURI}},
+		Count:   1,
+	})
+}
+
+func (s *Simulator) handleSystem(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeSimJSON(w, http.StatusOK, ComputerSystem{
+		ODataID:          simSystemURI,
+		ID:               "1",
+		Name:             "GPU Server",
+		Manufacturer:     "Simulated",
+		Model:            "SIM-8GPU",
+		SerialNumber:     "SIM0001",
+		UUID:             "38947555-7742-3448-3784-823347823834",
+		PowerState:       s.powerState,
+		LastResetTime:    s.lastReset,
+		BiosVersion:      s.firmware["BIOS"],
+		Status:           Status{State: "Enabled", Health: s.health, HealthRollup: s.health},
+		ProcessorSummary: ProcessorSummary{Count: 2, Model: "Simulated CPU"},
+		MemorySummary:    MemorySummary{TotalSystemMemoryGiB: 1024},
+		Bios:             ODataID{ID: simBiosURI},
+		Actions: SystemActions{Reset: ResetAction{
+			Target: simResetURI,
+			AllowableValues: []ResetType{ResetOn, ResetForceOff, ResetGracefulShutdown,
+				ResetGracefulRestart, ResetForceRestart, ResetPowerCycle},
+		}},
+	})
+}
+
+func (s *Simulator) handleReset(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var body struct {
+		ResetType ResetType `json:"ResetType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON", "The request body submitted was malformed JSON.")
+		return
+	}
+
+	var transient, final string
+	boots := false
+	switch body.ResetType {
+	case ResetOn:
+		transient, final, boots = PowerStatePoweringOn, PowerStateOn, s.powerState != PowerStateOn
+	case ResetForceOff, ResetGracefulShutdown:
+		transient, final = PowerStatePoweringOff, PowerStateOff
+	case ResetGracefulRestart, ResetForceRestart, ResetPowerCycle:
+		transient, final, boots = PowerStatePoweringOn, PowerStateOn, true
+	default:
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.ActionParameterValueNotInList",
+			fmt.Sprintf("The value %s for the parameter ResetType is not in the list of acceptable values.", body.ResetType))
+		return
+	}
+
+	s.powerState = transient
+	task := s.newTask(fmt.Sprintf("Reset %s", body.ResetType), func() {
+		s.powerState = final
+		if boots {
+			s.lastReset = time.Now().UTC().Format(time.RFC3339)
+		}
+		// 待生效的BIOS设置在系统重新启动时应用
+		if boots && len(s.pending) > 0 {
+			for name, value := range s.pending {
+				s.bios[name] = value
+			}
+			s.pending = map[string]interface{}{}
+		}
+	})
+
+	w.Header().Set("Location", task.task.TaskMonitor)
+	writeSimJSON(w, http.StatusAccepted, task.task)
+}
+
+func (s *Simulator) handleBios(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeSimJSON(w, http.StatusOK, Bios{
+		ODataID:           simBiosURI,
+		ID:                "Bios",
+		AttributeRegistry: "BiosAttributeRegistry.v1_0_0",
+		Attributes:        copyAttributes(s.bios),
+		Settings: &Settings{
+			SettingsObject:      ODataID{ID: simBiosSettingsURI},
+			ETag:                s.etagValue(),
+			SupportedApplyTimes: []string{ApplyTimeOnReset, ApplyTimeImmediate},
+		},
+	})
+}
+
+func (s *Simulator) handleBiosSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("ETag", s.etagValue())
+		writeSimJSON(w, http.StatusOK, Bios{
+			ODataID:    simBiosSettingsURI,
+			ID:         "Settings",
+			Attributes: copyAttributes(s.pending),
+		})
+	case http.MethodPatch:
+		s.patchBiosSettings(w, r)
+	default:
+		allowMethod(w, r, http.MethodGet, http.MethodPatch)
+	}
+}
+
+func (s *Simulator) patchBiosSettings(w http.ResponseWriter, r *http.Request) {
+	if match := r.Header.Get("If-Match"); match != "" && match != s.etagValue() {
+		writeSimError(w, http.StatusPreconditionFailed, "Base.1.8.PreconditionFailed", "The ETag supplied did not match the ETag required to change this resource.")
+		return
+	}
+
+	var body struct {
+		Attributes map[string]interface{} `json:"Attributes"`
+		ApplyTime  *struct {
+			ApplyTime string `json:"ApplyTime"`
+		} `json:"@Redfish.SettingsApplyTime"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON", "The request body submitted was malformed JSON.")
+		return
+	}
+	for name := range body.Attributes {
+		if _, ok := s.bios[name]; !ok {
+			writeSimError(w, http.StatusBadRequest, "Base.1.8.PropertyUnknown",
+				fmt.Sprintf("The property %s is not in the list of valid properties for the resource.", name))
+			return
+		}
+	}
+
+	target := s.pending
+	if body.ApplyTime != nil && body.ApplyTime.ApplyTime == ApplyTimeImmediate {
+		target = s.bios
+	}
+	for name, value := range body.Attributes {
+		target[name] = value
+	}
+	s.etag++
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Simulator) handleUpdateService(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeSimJSON(w, http.StatusOK, UpdateService{
+		ODataID:           updateURI,
+		ServiceEnabled:    true,
+		FirmwareInventory: ODataID{ID: simInventoryURI},
+		Actions: UpdateServiceActions{SimpleUpdate: SimpleUpdateAction{
+			Target:            simSimpleUpdateURI,
+			TransferProtocols: []string{"HTTP", "HTTPS"},
+		}},
+	})
+}
+
+func (s *Simulator) handleInventory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	ids := make([]string, 0, len(s.firmware))
+	for id := range s.firmware {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	members := make([]ODataID, len(ids))
+	for i, id := range ids {
+		members[i] = ODataID{ID: simInventoryURI + "/" + id}
+	}
+	writeSimJSON(w, http.StatusOK, Collection{
+		ODataID: simInventoryURI,
+		Name:    "Firmware Inventory Collection",
+		Members: members,
+		Count:   len(members),
+	})
+}
+
+func (s *Simulator) handleInventoryItem(w http.ResponseWriter, r *http.Request, id string) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	version, ok := s.firmware[id]
+	if !ok {
+		writeSimError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI", fmt.Sprintf("The resource at the URI %s was not found.", r.URL.Path))
+		return
+	}
+	writeSimJSON(w, http.StatusOK, SoftwareInventory{
+		ODataID:    simInventoryURI + "/" + id,
+		ID:         id,
+		Name:       id + " Firmware",
+		Version:    version,
+		Updateable: true,
+		Status:     Status{State: "Enabled", Health: "OK"},
+	})
+}
+
+// handleSimpleUpdate 受理固件更新，新版本号取自镜像文件名，未指定Targets时按文件名前缀匹配组件
+func (s *Simulator) handleSimpleUpdate(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var body struct {
+		ImageURI string   `json:"ImageURI"`
+		Targets  []string `json:"Targets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.MalformedJSON", "The request body submitted was malformed JSON.")
+		return
+	}
+
+	file := path.Base(body.ImageURI)
+	version := imageVersion.FindString(file)
+	if body.ImageURI == "" || version == "" {
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.ActionParameterValueFormatError",
+			fmt.Sprintf("The value %s for the parameter ImageURI is not a recognized firmware image.", body.ImageURI))
+		return
+	}
+
+	components := []string{}
+	for _, target := range body.Targets {
+		id := path.Base(target)
+		if _, ok := s.firmware[id]; !ok {
+			writeSimError(w, http.StatusBadRequest, "Base.1.8.ActionParameterValueNotInList",
+				fmt.Sprintf("The value %s for the parameter Targets is not in the list of acceptable values.", target))
+			return
+		}
+		components = append(components, id)
+	}
+	if len(components) == 0 {
+		for id := range s.firmware {
+			if strings.HasPrefix(strings.ToUpper(file), id) {
+				components = append(components, id)
+			}
+		}
+	}
+	if len(components) == 0 {
+		writeSimError(w, http.StatusBadRequest, "Base.1.8.ActionParameterMissing",
+			"The action SimpleUpdate requires the parameter Targets to be present in the request body.")
+		return
+	}
+
+	task := s.newTask(fmt.Sprintf("Update %s to %s", strings.Join(components, ", "), version), func() {
+		for _, id := range components {
+			s.firmware[id] = version
+		}
+	})
+	task.due = task.started.Add(s.updateDuration)
+	task.failure = s.updateFailure
+
+	w.Header().Set("Location", task.task.TaskMonitor)
+	writeSimJSON(w, http.StatusAccepted, task.task)
+}
+
+func (s *Simulator) handleTask(w http.ResponseWriter, r *http.Request, id string, monitor bool) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	task, ok := s.tasks[id]
+	if !ok {
+		writeSimError(w, http.StatusNotFound, "Base.1.8.ResourceMissingAtURI", "Task not found.")
+		return
+	}
+
+	// 任务监视器在任务结束前返回202
+	status := http.StatusOK
+	if monitor && !task.task.Finished() {
+		status = http.StatusAccepted
+		w.Header().Set("Location", task.task.TaskMonitor)
+	}
+	if !task.task.Finished() && task.due.After(task.started) {
+		elapsed := time.Since(task.started)
+		task.task.PercentComplete = int(100 * elapsed / task.due.Sub(task.started))
+	}
+	writeSimJSON(w, status, task.task)
+}
+
+// newTask 创建运行中的任务，taskDuration为0时在下次请求前完成
+func (s *Simulator) newTask(name string, apply func()) *simTask {
+	id := s.newID()
+	now := time.Now()
+	task := &simTask{
+		task: Task{
+			ODataID:     simTasksURI + id,
+			ID:          id,
+			Name:        name,
+			TaskState:   TaskStateRunning,
+			TaskStatus:  "OK",
+			StartTime:   now.UTC().Format(time.RFC3339),
+			TaskMonitor: simMonitorsURI + id,
+		},
+		started: now,
+		due:     now.Add(s.taskDuration),
+		apply:   apply,
+	}
+	s.tasks[id] = task
+	return task
+}
+
+// advance 完成所有已到期的任务
+func (s *Simulator) advance() {
+	now := time.Now()
+	for _, task := range s.tasks {
+		if task.task.Finished() || now.Before(task.due) {
+			continue
+		}
+		if task.failure != "" {
+			task.task.TaskState = TaskStateException
+			task.task.TaskStatus = "Critical"
+			task.task.EndTime = now.UTC().Format(time.RFC3339)
+			task.task.Messages = []Message{{MessageID: "Update.1.0.ApplyFailed", Message: task.failure, Severity: "Critical"}}
+			continue
+		}
+		task.apply()
+		task.task.TaskState = TaskStateCompleted
+		task.task.PercentComplete = 100
+		task.task.EndTime = now.UTC().Format(time.RFC3339)
+		task.task.Messages = []Message{{MessageID: "Base.1.8.Success", Message: "Successfully Completed Request"}}
+	}
+}
+
+func (s *Simulator) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%d", s.nextID)
+}
+
+func (s *Simulator) etagValue() string {
+	return fmt.Sprintf(`W/"%d"`, s.etag)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeSimError(w, http.StatusMethodNotAllowed, "Base.1.8.OperationNotAllowed", "The HTTP method is not allowed on this resource.")
+	return false
+}
+
+func writeSimJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("OData-Version", "4.0")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeSimError(w http.ResponseWriter, status int, messageID, message string) {
+	writeSimJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    messageID,
+			"message": message,
+			"@Message.ExtendedInfo": []Message{{
+				MessageID: messageID,
+				Message:   message,
+				Severity:  "Critical",
+			}},
+		},
+	})
+}
+
+func copyAttributes(attributes map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(attributes))
+	for k, v := range attributes {
+		out[k] = v
+	}
+	return out
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("token-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,203 @@
+package redfish
+
+// ResetType ComputerSystem.Reset支持的重置类型
+type ResetType string
+
+// 常用重置类型
+const (
+	ResetOn               ResetType = "On"
+	ResetForceOff         ResetType = "ForceOff"
+	ResetGracefulShutdown ResetType = "GracefulShutdown"
+	ResetGracefulRestart  ResetType = "GracefulRestart"
+	ResetForceRestart     ResetType = "ForceRestart"
+	ResetPowerCycle       ResetType = "PowerCycle"
+)
+
+// PowerState 电源状态
+const (
+	PowerStateOn          = "On"
+	PowerStateOff         = "Off"
+	PowerStatePoweringOn  = "PoweringOn"
+	PowerStatePoweringOff = "PoweringOff"
+)
+
+// TaskState 任务状态
+const (
+	TaskStateNew       = "New"
+	TaskStateStarting  = "Starting"
+	TaskStateRunning   = "Running"
+	TaskStatePending   = "Pending"
+	TaskStateCompleted = "Completed"
+	TaskStateException = "Exception"
+	TaskStateKilled    = "Killed"
+	TaskStateCancelled = "Cancelled"
+)
+
+// ApplyTime BIOS设置生效时机
+const (
+	ApplyTimeImmediate = "Immediate"
+	ApplyTimeOnReset   = "OnReset"
+)
+
+// ODataID 资源引用
+type ODataID struct {
+	ID string `json:"@odata.id"`
+}
+
+// Collection 资源集合
+type Collection struct {
+	ODataID string    `json:"@odata.id"`
+	Name    string    `json:"Name"`
+	Members []ODataID `json:"Members"`
+	Count   int       `json:"Members@odata.count"`
+}
+
+// ServiceRoot 服务根资源
+type ServiceRoot struct {
+	ODataID        string  `json:"@odata.id"`
+	RedfishVersion string  `json:"RedfishVersion"`
+	UUID           string  `json:"UUID"`
+	Systems        ODataID `json:"Systems"`
+	SessionService ODataID `json:"SessionService"`
+	TaskService    ODataID `json:"TaskService"`
+	UpdateService  ODataID `json:"UpdateService"`
+}
+
+// Status 资源健康状态
+type Status struct {
+	State        string `json:"State,omitempty"`
+	Health       string `json:"Health,omitempty"`
+	HealthRollup string `json:"HealthRollup,omitempty"`
+}
+
+// ProcessorSummary 处理器概要
+type ProcessorSummary struct {
+	Count int    `json:"Count"`
+	Model string `json:"Model,omitempty"`
+}
+
+// MemorySummary 内存概要
+type MemorySummary struct {
+	TotalSystemMemoryGiB float64 `json:"TotalSystemMemoryGiB"`
+}
+
+// ResetAction ComputerSystem.Reset动作描述
+type ResetAction struct {
+	Target          string      `json:"target"`
+	AllowableValues []ResetType `json:"ResetType@Redfish.AllowableValues,omitempty"`
+}
+
+// SystemActions ComputerSystem支持的动作
+type SystemActions struct {
+	Reset ResetAction `json:"#ComputerSystem.Reset"`
+}
+
+// ComputerSystem 计算机系统资源（服务器清单）
+type ComputerSystem struct {
+	ODataID          string           `json:"@odata.id"`
+	ID               string           `json:"Id"`
+	Name             string           `json:"Name"`
+	Manufacturer     string           `json:"Manufacturer,omitempty"`
+	Model            string           `json:"Model,omitempty"`
+	SerialNumber     string           `json:"SerialNumber,omitempty"`
+	UUID             string           `json:"UUID,omitempty"`
+	HostName         string           `json:"HostName,omitempty"`
+	PowerState       string           `json:"PowerState"`
+	LastResetTime    string           `json:"LastResetTime,omitempty"`
+	BiosVersion      string           `json:"BiosVersion,omitempty"`
+	Status           Status           `json:"Status"`
+	ProcessorSummary ProcessorSummary `json:"ProcessorSummary"`
+	MemorySummary    MemorySummary    `json:"MemorySummary"`
+	Bios             ODataID          `json:"Bios"`
+	Actions          SystemActions    `json:"Actions"`
+}
+
+// Settings @Redfish.Settings注解，指向保存待生效设置的资源
+type Settings struct {
+	SettingsObject      ODataID   `json:"SettingsObject"`
+	ETag                string    `json:"ETag,omitempty"`
+	Time                string    `json:"Time,omitempty"`
+	SupportedApplyTimes []string  `json:"SupportedApplyTimes,omitempty"`
+	Messages            []Message `json:"Messages,omitempty"`
+}
+
+// Bios BIOS资源
+type Bios struct {
+	ODataID           string                 `json:"@odata.id"`
+	ID                string                 `json:"Id"`
+	AttributeRegistry string                 `json:"AttributeRegistry,omitempty"`
+	Attributes        map[string]interface{} `json:"Attributes"`
+	Settings          *Settings              `json:"@Redfish.Settings,omitempty"`
+}
+
+// BiosUpdate BIOS属性修改结果
+type BiosUpdate struct {
+	// Pending 已写入设置对象、尚未生效的属性
+	Pending map[string]interface{} `json:"pending"`
+	// ApplyTime 设置生效时机
+	ApplyTime string `json:"apply_time"`
+	// RequiresReset 是否需要重启服务器才能生效
+	RequiresReset bool `json:"requires_reset"`
+}
+
+// SimpleUpdateAction UpdateService.SimpleUpdate动作描述
+type SimpleUpdateAction struct {
+	Target            string   `json:"target"`
+	TransferProtocols []string `json:"TransferProtocol@Redfish.AllowableValues,omitempty"`
+}
+
+// UpdateServiceActions UpdateService支持的动作
+type UpdateServiceActions struct {
+	SimpleUpdate SimpleUpdateAction `json:"#UpdateService.SimpleUpdate"`
+}
+
+// UpdateService 固件更新服务
+type UpdateService struct {
+	ODataID           string               `json:"@odata.id"`
+	ServiceEnabled    bool                 `json:"ServiceEnabled"`
+	FirmwareInventory ODataID              `json:"FirmwareInventory"`
+	Actions           UpdateServiceActions `json:"Actions"`
+}
+
+// SoftwareInventory 固件清单中的单个组件
+type SoftwareInventory struct {
+	ODataID    string `json:"@odata.id"`
+	ID         string `json:"Id"`
+	Name       string `json:"Name"`
+	Version    string `json:"Version"`
+	Updateable bool   `json:"Updateable"`
+	Status     Status `json:"Status"`
+}
+
+// Message Redfish消息
+type Message struct {
+	MessageID   string   `json:"MessageId"`
+	Message     string   `json:"Message"`
+	Severity    string   `json:"Severity,omitempty"`
+	Resolution  string   `json:"Resolution,omitempty"`
+	MessageArgs []string `json:"MessageArgs,omitempty"`
+}
+
+// Task 异步任务资源
+type Task struct {
+	ODataID         string    `json:"@odata.id"`
+	ID              string    `json:"Id"`
+	Name            string    `json:"Name"`
+	TaskState       string    `json:"TaskState"`
+	TaskStatus      string    `json:"TaskStatus,omitempty"`
+	PercentComplete int       `json:"PercentComplete,omitempty"`
+	StartTime       string    `json:"StartTime,omitempty"`
+	EndTime         string    `json:"EndTime,omitempty"`
+	TaskMonitor     string    `json:"TaskMonitor,omitempty"`
+	Messages        []Message `json:"Messages,omitempty"`
+}
+
+// Finished 任务是否已结束
+func (t *Task) Finished() bool {
+	switch t.TaskState {
+	case TaskStateCompleted, TaskStateException, TaskStateKilled, TaskStateCancelled:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,383 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/alert"
+)
+
+// engineActor 规则引擎解除告警时记录的操作者
+const engineActor = "rules-engine"
+
+// 规则引擎产生的告警携带的标签，rule_id用于重启后找回规则产生的告警
+const (
+	LabelRuleID   = "rule_id"
+	LabelRule     = "rule"
+	LabelMetric   = "metric"
+	LabelModel    = "model"
+	LabelServerID = "server_id"
+)
+
+// 规则对一块GPU的评估状态
+const (
+	// StateOK 指标正常
+	StateOK = "ok"
+	// StatePending 指标满足条件，持续时间未达到for
+	StatePending = "pending"
+	// StateFiring 已产生告警
+	StateFiring = "firing"
+	// StateResolving 已产生告警，指标已恢复，持续时间未达到resolve_for
+	StateResolving = "resolving"
+	// StateResolved 本次评估解除了告警
+	StateResolved = "resolved"
+)
+
+// Config 规则引擎配置
+type Config struct {
+	// SyncInterval 重新加载规则并与未解除告警对齐的间隔，其他实例修改的规则在此间隔内生效
+	SyncInterval time.Duration
+}
+
+// Evaluation 一条规则对一次指标上报的评估结果
+type Evaluation struct {
+	RuleID    string  `json:"rule_id"`
+	Rule      string  `json:"rule"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	State     string  `json:"state"`
+	AlertID   string  `json:"alert_id,omitempty"`
+}
+
+// stateKey 规则和GPU
+type stateKey struct {
+	ruleID string
+	gpuID  string
+}
+
+// ruleState 规则对一块GPU的评估状态，alertID不为空表示已产生告警
+type ruleState struct {
+	pendingSince time.Time
+	clearSince   time.Time
+	alertID      string
+}
+
+// Engine 告警规则引擎，按上报的GPU指标评估启用的规则，通过告警服务产生和解除告警
+// 评估状态保存在内存中，重启后由Sync从未解除的告警恢复已产生告警的状态，尚未达到for的计时重新开始
+type Engine struct {
+	repos  *repository.Repositories
+	alerts *alert.Service
+	config Config
+
+	// mu 串行化评估和同步，保证同一规则和GPU不会重复产生告警
+	mu     sync.Mutex
+	rules  []models.AlertRule
+	states map[stateKey]*ruleState
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEngine 创建告警规则引擎，调用Sync加载规则后开始评估，调用Start后定期同步
+func NewEngine(repos *repository.Repositories, alerts *alert.Service, config Config) *Engine {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Engine{
+		repos:  repos,
+		alerts: alerts,
+		config: config,
+		states: map[stateKey]*ruleState{},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// ListRules 查询告警规则
+func (e *Engine) ListRules(ctx context.Context) ([]models.AlertRule, error) {
+	return e.repos.AlertRules.List(ctx, repository.AlertRuleFilter{})
+}
+
+// GetRule 查询告警规则详情
+func (e *Engine) GetRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	return e.repos.AlertRules.Get(ctx, id)
+}
+
+// CreateRule 创建告警规则，立即生效
+func (e *Engine) CreateRule(ctx context.Context, req *RuleRequest) (*models.AlertRule, error) {
+	rule, err := req.rule()
+	if err != nil {
+		return nil, err
+	}
+	if err := e.repos.AlertRules.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+	e.resync(ctx)
+	return rule, nil
+}
+
+// UpdateRule 替换告警规则，停用规则会解除其产生的告警
+func (e *Engine) UpdateRule(ctx context.Context, id string, req *RuleRequest) (*models.AlertRule, error) {
+	rule, err := req.rule()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.repos.AlertRules.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	if err := e.repos.AlertRules.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	e.resync(ctx)
+	return rule, nil
+}
+
+// DeleteRule 删除告警规则，并解除其产生的告警
+func (e *Engine) DeleteRule(ctx context.Context, id string) error {
+	if err := e.repos.AlertRules.Delete(ctx, id); err != nil {
+		return err
+	}
+	e.resync(ctx)
+	return nil
+}
+
+// resync 规则变更后立即同步，失败时等待下一次定期同步
+func (e *Engine) resync(ctx context.Context) {
+	if err := e.Sync(ctx); err != nil {
+		log.Printf("Failed to sync alert rules: %v", err)
+	}
+}
+
+// Observe 按一次GPU指标上报评估所有启用的规则，返回对该GPU生效的规则的评估结果
+// 指标时间为空时使用当前时间；单条规则产生或解除告警失败不影响其他规则，失败的规则在下次上报时重试
+func (e *Engine) Observe(ctx context.Context, sample *models.GPUUtilization) ([]Evaluation, error) {
+	gpu, err := e.repos.GPUs.Get(ctx, sample.GPUID)
+	if err != nil {
+		return nil, err
+	}
+	at := sample.Timestamp
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	evaluations := []Evaluation{}
+	var errs []error
+	for i := range e.rules {
+		evaluation, err := e.evaluate(ctx, &e.rules[i], gpu, sample, at)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", e.rules[i].Name, err))
+		}
+		if evaluation != nil {
+			evaluations = append(evaluations, *evaluation)
+		}
+	}
+	return evaluations, errors.Join(errs...)
+}
+
+// evaluate 评估一条规则，规则对该GPU不生效且没有已产生的告警时返回nil，调用方需持有mu
+func (e *Engine) evaluate(ctx context.Context, rule *models.AlertRule, gpu *models.GPU, sample *models.GPUUtilization, at time.Time) (*Evaluation, error) {
+	effective, enabled := rule.ForModel(gpu.Model)
+	applicable := enabled && matchConditions(effective.Conditions, gpu)
+	value, _ := sample.MetricValue(effective.Metric)
+
+	key := stateKey{ruleID: rule.ID, gpuID: gpu.ID}
+	st := e.states[key]
+	evaluation := &Evaluation{
+		RuleID:    rule.ID,
+		Rule:      rule.Name,
+		Metric:    effective.Metric,
+		Value:     value,
+		Threshold: effective.Threshold,
+	}
+
+	// 尚未产生告警：指标持续满足条件达到for后产生告警
+	if st == nil || st.alertID == "" {
+		if !applicable || !effective.Compare(value, effective.Threshold) {
+			delete(e.states, key)
+			if !applicable {
+				return nil, nil
+			}
+			evaluation.State = StateOK
+			return evaluation, nil
+		}
+
+		if st == nil {
+			st = &ruleState{pendingSince: at}
+			e.states[key] = st
+		}
+		evaluation.State = StatePending
+		forDuration, _ := parseDuration("for", effective.For)
+		if at.Sub(st.pendingSince) < forDuration {
+			return evaluation, nil
+		}
+
+		raised, err := e.alerts.Create(ctx, &alert.CreateRequest{
+			SourceType: "gpu",
+			SourceID:   gpu.ID,
+			AlertType:  effective.AlertType,
+			Severity:   effective.Severity,
+			Message:    message(&effective, gpu, value),
+			Labels: map[string]string{
+				LabelRuleID:   rule.ID,
+				LabelRule:     rule.Name,
+				LabelMetric:   effective.Metric,
+				LabelModel:    gpu.Model,
+				LabelServerID: gpu.ServerID,
+			},
+		})
+		if err != nil {
+			return evaluation, err
+		}
+		st.alertID = raised.ID
+		st.clearSince = time.Time{}
+		evaluation.State = StateFiring
+		evaluation.AlertID = raised.ID
+		return evaluation, nil
+	}
+
+	// 已产生告警：指标越过解除阈值并持续resolve_for后解除，规则对该GPU不再生效时同样视为恢复
+	evaluation.AlertID = st.alertID
+	if applicable && effective.Compare(value, effective.ClearThreshold()) {
+		st.clearSince = time.Time{}
+		evaluation.State = StateFiring
+		return evaluation, nil
+	}
+
+	if st.clearSince.IsZero() {
+		st.clearSince = at
+	}
+	evaluation.State = StateResolving
+	resolveFor, _ := parseDuration("resolve_for", effective.ResolveFor)
+	if at.Sub(st.clearSince) < resolveFor {
+		return evaluation, nil
+	}
+
+	if err := e.resolve(ctx, st.alertID); err != nil {
+		return evaluation, err
+	}
+	delete(e.states, key)
+	evaluation.State = StateResolved
+	return evaluation, nil
+}
+
+// resolve 解除规则产生的告警，告警已被手动解除时视为成功
+func (e *Engine) resolve(ctx context.Context, alertID string) error {
+	_, err := e.alerts.Resolve(ctx, alertID, engineActor)
+	var transitionErr *models.TransitionError
+	if errors.As(err, &transitionErr) || errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Sync 重新加载启用的规则，并与规则产生的未解除告警对齐：
+// 恢复重启前产生的告警的状态，丢弃已被手动解除的告警的状态（之后指标仍满足条件时重新计时产生告警），
+// 解除已删除或停用的规则产生的告警
+func (e *Engine) Sync(ctx context.Context) error {
+	rules, err := e.repos.AlertRules.List(ctx, repository.AlertRuleFilter{EnabledOnly: true})
+	if err != nil {
+		return err
+	}
+	open, err := e.repos.Alerts.List(ctx, repository.AlertFilter{
+		SourceType: "gpu",
+		Statuses:   []string{models.AlertStatusActive, models.AlertStatusAcknowledged},
+	})
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rules = rules
+	enabled := map[string]bool{}
+	for i := range rules {
+		enabled[rules[i].ID] = true
+	}
+
+	var errs []error
+	firing := map[stateKey]string{}
+	for i := range open {
+		ruleID := open[i].Labels[LabelRuleID]
+		if ruleID == "" {
+			continue
+		}
+		if !enabled[ruleID] {
+			if err := e.resolve(ctx, open[i].ID); err != nil {
+				errs = append(errs, fmt.Errorf("alert %s: %w", open[i].ID, err))
+			}
+			continue
+		}
+		firing[stateKey{ruleID: ruleID, gpuID: open[i].SourceID}] = open[i].ID
+	}
+
+	for key, st := range e.states {
+		if !enabled[key.ruleID] {
+			delete(e.states, key)
+			continue
+		}
+		if st.alertID != "" && firing[key] != st.alertID {
+			delete(e.states, key)
+		}
+	}
+	for key, alertID := range firing {
+		if _, ok := e.states[key]; !ok {
+			e.states[key] = &ruleState{alertID: alertID}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start 在后台按SyncInterval同步规则
+func (e *Engine) Start() {
+	e.wg.Add(1)
+	go e.loop()
+}
+
+// Close 停止后台同步并等待进行中的同步结束
+func (e *Engine) Close() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// loop 同步循环
+func (e *Engine) loop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := e.Sync(e.ctx); err != nil && e.ctx.Err() == nil {
+			log.Printf("Alert rule sync failed: %v", err)
+		}
+	}
+}
+
+// message 返回告警内容，规则未指定时按规则生成
+func message(rule *models.AlertRule, gpu *models.GPU, value float64) string {
+	if rule.Message != "" {
+		return rule.Message
+	}
+	msg := fmt.Sprintf("GPU %s %s %g %s %g", gpu.ID, rule.Metric, value, rule.Operator, rule.Threshold)
+	if rule.For != "" {
+		msg += " for " + rule.For
+	}
+	return msg
+}
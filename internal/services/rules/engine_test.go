@@ -0,0 +1,277 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/alert"
+)
+
+// step 一次温度上报，at为相对首次上报的时间，wantState为空表示规则对该GPU不生效
+type step struct {
+	at          time.Duration
+	temperature float64
+	wantState   string
+	// wantOpen 上报后未解除的告警数量
+	wantOpen int
+}
+
+// newTestEngine 创建内存仓储上的规则引擎和一台装有A100、H100、L4的服务器，返回型号到GPU ID的映射
+func newTestEngine(t *testing.T) (*Engine, *repository.Repositories, map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	server := &models.Server{Name: "node-1", Status: models.ServerStatusReady}
+	if err := repos.Servers.Create(ctx, server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	gpus := map[string]string{}
+	for _, model := range []string{"A100", "H100", "L4"} {
+		gpu := &models.GPU{ServerID: server.ID, Model: model, MemoryGB: 80, Status: models.GPUStatusAvailable}
+		if err := repos.GPUs.Create(ctx, gpu); err != nil {
+			t.Fatalf("failed to create gpu: %v", err)
+		}
+		gpus[model] = gpu.ID
+	}
+	return NewEngine(repos, alert.NewService(repos, alert.Config{}), Config{}), repos, gpus
+}
+
+// createTemperatureRule 创建温度规则：超过85度持续5分钟产生告警，低于80度持续2分钟解除；
+// H100超过90度立即产生告警，L4停用
+func createTemperatureRule(t *testing.T, e *Engine) *models.AlertRule {
+	t.Helper()
+	resolve, h100 := 80.0, 90.0
+	immediately := "0s"
+	rule, err := e.CreateRule(context.Background(), &RuleRequest{
+		Name:             "gpu-overheat",
+		Expr:             "temperature > 85 for 5m",
+		ResolveThreshold: &resolve,
+		ResolveFor:       "2m",
+		Severity:         models.AlertSeverityHigh,
+		Overrides: map[string]models.AlertRuleOverride{
+			"H100": {Threshold: &h100, For: &immediately, Severity: models.AlertSeverityCritical},
+			"L4":   {Disabled: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	return rule
+}
+
+// observe 上报一次温度，返回该规则的评估结果，规则不生效时返回nil
+func observe(t *testing.T, e *Engine, gpuID string, at time.Time, temperature float64) *Evaluation {
+	t.Helper()
+	evaluations, err := e.Observe(context.Background(), &models.GPUUtilization{GPUID: gpuID, Temperature: temperature, Timestamp: at})
+	if err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	if len(evaluations) == 0 {
+		return nil
+	}
+	return &evaluations[0]
+}
+
+// openAlerts 返回未解除的告警
+func openAlerts(t *testing.T, repos *repository.Repositories) []models.Alert {
+	t.Helper()
+	alerts, err := repos.Alerts.List(context.Background(), repository.AlertFilter{
+		Statuses: []string{models.AlertStatusActive, models.AlertStatusAcknowledged},
+	})
+	if err != nil {
+		t.Fatalf("failed to list alerts: %v", err)
+	}
+	return alerts
+}
+
+// runSteps 依次上报并检查评估状态和未解除的告警数量
+func runSteps(t *testing.T, e *Engine, repos *repository.Repositories, gpuID string, base time.Time, steps []step) {
+	t.Helper()
+	for _, s := range steps {
+		evaluation := observe(t, e, gpuID, base.Add(s.at), s.temperature)
+		state := ""
+		if evaluation != nil {
+			state = evaluation.State
+		}
+		if state != s.wantState {
+			t.Fatalf("at %v with %g: state = %q, want %q", s.at, s.temperature, state, s.wantState)
+		}
+		if open := openAlerts(t, repos); len(open) != s.wantOpen {
+			t.Fatalf("at %v with %g: open alerts = %d, want %d", s.at, s.temperature, len(open), s.wantOpen)
+		}
+	}
+}
+
+func TestEngineEvaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        string
+		steps        []step
+		wantSeverity string
+	}{
+		{
+			// 持续超过阈值5分钟后产生告警，落在解除阈值和触发阈值之间时保持告警
+			name:  "for and hysteresis",
+			model: "A100",
+			steps: []step{
+				{at: 0, temperature: 86, wantState: StatePending},
+				{at: 3 * time.Minute, temperature: 87, wantState: StatePending},
+				{at: 5 * time.Minute, temperature: 88, wantState: StateFiring, wantOpen: 1},
+				{at: 6 * time.Minute, temperature: 82, wantState: StateFiring, wantOpen: 1},
+				{at: 7 * time.Minute, temperature: 79, wantState: StateResolving, wantOpen: 1},
+				{at: 8 * time.Minute, temperature: 81, wantState: StateFiring, wantOpen: 1},
+				{at: 9 * time.Minute, temperature: 79, wantState: StateResolving, wantOpen: 1},
+				{at: 10 * time.Minute, temperature: 78, wantState: StateResolving, wantOpen: 1},
+				{at: 11 * time.Minute, temperature: 78, wantState: StateResolved},
+				{at: 12 * time.Minute, temperature: 78, wantState: StateOK},
+			},
+			wantSeverity: models.AlertSeverityHigh,
+		},
+		{
+			// 持续时间未达到for前指标恢复，重新计时
+			name:  "pending reset",
+			model: "A100",
+			steps: []step{
+				{at: 0, temperature: 86, wantState: StatePending},
+				{at: 4 * time.Minute, temperature: 84, wantState: StateOK},
+				{at: 5 * time.Minute, temperature: 86, wantState: StatePending},
+				{at: 9 * time.Minute, temperature: 86, wantState: StatePending},
+				{at: 10 * time.Minute, temperature: 86, wantState: StateFiring, wantOpen: 1},
+			},
+			wantSeverity: models.AlertSeverityHigh,
+		},
+		{
+			// H100覆盖阈值和for，解除阈值随触发阈值覆盖为90，resolve_for沿用规则
+			name:  "model override",
+			model: "H100",
+			steps: []step{
+				{at: 0, temperature: 88, wantState: StateOK},
+				{at: time.Minute, temperature: 91, wantState: StateFiring, wantOpen: 1},
+				{at: 2 * time.Minute, temperature: 89, wantState: StateResolving, wantOpen: 1},
+				{at: 4 * time.Minute, temperature: 89, wantState: StateResolved},
+			},
+			wantSeverity: models.AlertSeverityCritical,
+		},
+		{
+			name:  "disabled for model",
+			model: "L4",
+			steps: []step{
+				{at: 0, temperature: 99},
+				{at: 10 * time.Minute, temperature: 99},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, repos, gpus := newTestEngine(t)
+			createTemperatureRule(t, e)
+			base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			runSteps(t, e, repos, gpus[tt.model], base, tt.steps)
+
+			alerts, err := repos.Alerts.List(context.Background(), repository.AlertFilter{})
+			if err != nil {
+				t.Fatalf("failed to list alerts: %v", err)
+			}
+			if tt.wantSeverity == "" {
+				if len(alerts) != 0 {
+					t.Errorf("alerts = %d, want none", len(alerts))
+				}
+				return
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("alerts = %d, want 1", len(alerts))
+			}
+			got := alerts[0]
+			if got.Severity != tt.wantSeverity || got.SourceID != gpus[tt.model] || got.Labels[LabelModel] != tt.model {
+				t.Errorf("alert severity = %s, source = %s, model = %s, want %s, %s, %s",
+					got.Severity, got.SourceID, got.Labels[LabelModel], tt.wantSeverity, gpus[tt.model], tt.model)
+			}
+		})
+	}
+}
+
+func TestEngineSync(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// change 在新引擎Sync之前修改告警或规则
+		change func(t *testing.T, e *Engine, repos *repository.Repositories, rule *models.AlertRule, alertID string)
+		// steps 新引擎Sync之后的上报，时间相对产生告警的上报
+		steps []step
+	}{
+		{
+			// 重启后恢复已产生告警的状态，恢复后按resolve_for解除而不是重新产生告警
+			name: "recover firing",
+			steps: []step{
+				{at: time.Minute, temperature: 82, wantState: StateFiring, wantOpen: 1},
+				{at: 2 * time.Minute, temperature: 79, wantState: StateResolving, wantOpen: 1},
+				{at: 4 * time.Minute, temperature: 79, wantState: StateResolved},
+			},
+		},
+		{
+			// 告警被手动解除后丢弃状态，指标仍超过阈值时重新计时
+			name: "manually resolved",
+			change: func(t *testing.T, e *Engine, repos *repository.Repositories, rule *models.AlertRule, alertID string) {
+				if _, err := e.alerts.Resolve(context.Background(), alertID, "alice"); err != nil {
+					t.Fatalf("Resolve() error = %v", err)
+				}
+			},
+			steps: []step{
+				{at: time.Minute, temperature: 90, wantState: StatePending},
+				{at: 6 * time.Minute, temperature: 90, wantState: StateFiring, wantOpen: 1},
+			},
+		},
+		{
+			// 停用规则后解除其产生的告警
+			name: "rule disabled",
+			change: func(t *testing.T, e *Engine, repos *repository.Repositories, rule *models.AlertRule, alertID string) {
+				rule.Enabled = false
+				if err := repos.AlertRules.Update(context.Background(), rule); err != nil {
+					t.Fatalf("failed to update rule: %v", err)
+				}
+			},
+			steps: []step{
+				{at: time.Minute, temperature: 90},
+			},
+		},
+		{
+			name: "rule deleted",
+			change: func(t *testing.T, e *Engine, repos *repository.Repositories, rule *models.AlertRule, alertID string) {
+				if err := repos.AlertRules.Delete(context.Background(), rule.ID); err != nil {
+					t.Fatalf("failed to delete rule: %v", err)
+				}
+			},
+			steps: []step{
+				{at: time.Minute, temperature: 90},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, repos, gpus := newTestEngine(t)
+			rule := createTemperatureRule(t, e)
+			observe(t, e, gpus["A100"], base, 90)
+			fired := observe(t, e, gpus["A100"], base.Add(5*time.Minute), 90)
+			if fired == nil || fired.State != StateFiring {
+				t.Fatalf("evaluation = %+v, want %s", fired, StateFiring)
+			}
+			if tt.change != nil {
+				tt.change(t, e, repos, rule, fired.AlertID)
+			}
+
+			// 新引擎模拟重启，评估状态只能从告警恢复
+			restarted := NewEngine(repos, e.alerts, Config{})
+			if err := restarted.Sync(context.Background()); err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			runSteps(t, restarted, repos, gpus["A100"], base.Add(5*time.Minute), tt.steps)
+		})
+	}
+}
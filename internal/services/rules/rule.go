@@ -0,0 +1,237 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gpu-management/internal/models"
+)
+
+// conditionKeys 规则条件支持的GPU属性
+var conditionKeys = map[string]func(gpu *models.GPU) string{
+	"status":    func(gpu *models.GPU) string { return gpu.Status },
+	"model":     func(gpu *models.GPU) string { return gpu.Model },
+	"server_id": func(gpu *models.GPU) string { return gpu.ServerID },
+}
+
+// Expr 解析后的规则表达式
+type Expr struct {
+	Metric     string
+	Operator   string
+	Threshold  float64
+	For        string
+	Conditions map[string]string
+}
+
+// ParseExpr 解析规则表达式，格式为 <metric> <op> <threshold> [for <duration>] [while <key>=<value> [and <key>=<value>]...]
+// 例如 "temperature > 85 for 5m"、"memory_usage_percent > 95 while status=available"
+func ParseExpr(expr string) (*Expr, error) {
+	tokens := strings.Fields(expr)
+	if len(tokens) < 3 {
+		return nil, fmt.Errorf("invalid expr %q: expected <metric> <op> <threshold> [for <duration>] [while <key>=<value>]", expr)
+	}
+
+	threshold, err := strconv.ParseFloat(tokens[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expr %q: threshold %q is not a number", expr, tokens[2])
+	}
+	parsed := &Expr{Metric: tokens[0], Operator: tokens[1], Threshold: threshold}
+
+	rest := tokens[3:]
+	if len(rest) > 0 && rest[0] == "for" {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("invalid expr %q: missing duration after for", expr)
+		}
+		parsed.For = rest[1]
+		rest = rest[2:]
+	}
+	if len(rest) > 0 && rest[0] == "while" {
+		parsed.Conditions = map[string]string{}
+		for _, token := range rest[1:] {
+			if token == "and" {
+				continue
+			}
+			for _, cond := range strings.Split(token, ",") {
+				if cond == "" {
+					continue
+				}
+				key, value, ok := strings.Cut(cond, "=")
+				if !ok || key == "" || value == "" {
+					return nil, fmt.Errorf("invalid expr %q: condition %q must be <key>=<value>", expr, cond)
+				}
+				parsed.Conditions[key] = value
+			}
+		}
+		if len(parsed.Conditions) == 0 {
+			return nil, fmt.Errorf("invalid expr %q: missing conditions after while", expr)
+		}
+		rest = nil
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("invalid expr %q: unexpected %q", expr, strings.Join(rest, " "))
+	}
+	return parsed, nil
+}
+
+// RuleRequest 创建或替换告警规则请求
+// expr与metric、operator、threshold、for、conditions二选一
+type RuleRequest struct {
+	Name             string                              `json:"name"`
+	Expr             string                              `json:"expr"`
+	Metric           string                              `json:"metric"`
+	Operator         string                              `json:"operator"`
+	Threshold        *float64                            `json:"threshold"`
+	For              string                              `json:"for"`
+	ResolveThreshold *float64                            `json:"resolve_threshold"`
+	ResolveFor       string                              `json:"resolve_for"`
+	Conditions       map[string]string                   `json:"conditions"`
+	AlertType        string                              `json:"alert_type"`
+	Severity         string                              `json:"severity"`
+	Message          string                              `json:"message"`
+	Overrides        map[string]models.AlertRuleOverride `json:"overrides"`
+	// Enabled 为空时启用
+	Enabled *bool `json:"enabled"`
+}
+
+// Validate 校验告警规则请求
+func (r *RuleRequest) Validate() error {
+	_, err := r.rule()
+	return err
+}
+
+// rule 将请求转换为告警规则并校验
+func (r *RuleRequest) rule() (*models.AlertRule, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	rule := &models.AlertRule{
+		Name:             r.Name,
+		Metric:           r.Metric,
+		Operator:         r.Operator,
+		For:              r.For,
+		ResolveThreshold: r.ResolveThreshold,
+		ResolveFor:       r.ResolveFor,
+		Conditions:       r.Conditions,
+		AlertType:        r.AlertType,
+		Severity:         r.Severity,
+		Message:          r.Message,
+		Overrides:        r.Overrides,
+		Enabled:          r.Enabled == nil || *r.Enabled,
+	}
+
+	if r.Expr != "" {
+		if r.Metric != "" || r.Operator != "" || r.Threshold != nil || r.For != "" || len(r.Conditions) > 0 {
+			return nil, errors.New("expr cannot be combined with metric, operator, threshold, for or conditions")
+		}
+		expr, err := ParseExpr(r.Expr)
+		if err != nil {
+			return nil, err
+		}
+		rule.Metric = expr.Metric
+		rule.Operator = expr.Operator
+		rule.Threshold = expr.Threshold
+		rule.For = expr.For
+		rule.Conditions = expr.Conditions
+	} else {
+		if r.Threshold == nil {
+			return nil, errors.New("expr or threshold is required")
+		}
+		rule.Threshold = *r.Threshold
+	}
+	if rule.AlertType == "" {
+		rule.AlertType = rule.Name
+	}
+
+	if err := validateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// validateRule 校验规则及每个型号覆盖后的规则
+func validateRule(rule *models.AlertRule) error {
+	if _, ok := (&models.GPUUtilization{}).MetricValue(rule.Metric); !ok {
+		return fmt.Errorf("unknown metric %q", rule.Metric)
+	}
+	switch rule.Operator {
+	case models.AlertRuleOperatorGT, models.AlertRuleOperatorGTE, models.AlertRuleOperatorLT, models.AlertRuleOperatorLTE:
+	default:
+		return fmt.Errorf("unknown operator %q", rule.Operator)
+	}
+	for key := range rule.Conditions {
+		if _, ok := conditionKeys[key]; !ok {
+			return fmt.Errorf("unknown condition %q: must be status, model or server_id", key)
+		}
+	}
+	if _, err := parseDuration("resolve_for", rule.ResolveFor); err != nil {
+		return err
+	}
+	if err := validateEffective(rule); err != nil {
+		return err
+	}
+
+	for model, override := range rule.Overrides {
+		if override.Severity != "" && models.SeverityRank(override.Severity) < 0 {
+			return fmt.Errorf("override %s: unknown severity %q", model, override.Severity)
+		}
+		effective, _ := rule.ForModel(model)
+		if err := validateEffective(&effective); err != nil {
+			return fmt.Errorf("override %s: %w", model, err)
+		}
+	}
+	return nil
+}
+
+// validateEffective 校验应用覆盖后的严重程度、持续时长和解除阈值
+func validateEffective(rule *models.AlertRule) error {
+	if models.SeverityRank(rule.Severity) < 0 {
+		return fmt.Errorf("unknown severity %q", rule.Severity)
+	}
+	if _, err := parseDuration("for", rule.For); err != nil {
+		return err
+	}
+
+	// 解除阈值必须落在触发阈值恢复的一侧，否则告警会在产生后立即解除
+	clear := rule.ClearThreshold()
+	switch rule.Operator {
+	case models.AlertRuleOperatorGT, models.AlertRuleOperatorGTE:
+		if clear > rule.Threshold {
+			return fmt.Errorf("resolve_threshold %g must not be above threshold %g", clear, rule.Threshold)
+		}
+	case models.AlertRuleOperatorLT, models.AlertRuleOperatorLTE:
+		if clear < rule.Threshold {
+			return fmt.Errorf("resolve_threshold %g must not be below threshold %g", clear, rule.Threshold)
+		}
+	}
+	return nil
+}
+
+// parseDuration 解析规则中的时长，空字符串表示0
+func parseDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, value, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", field)
+	}
+	return d, nil
+}
+
+// matchConditions 判断GPU是否满足规则的全部条件
+func matchConditions(conditions map[string]string, gpu *models.GPU) bool {
+	for key, value := range conditions {
+		get, ok := conditionKeys[key]
+		if !ok || get(gpu) != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// 调度错误
+var (
+	// ErrInsufficientCapacity 没有足够的空闲GPU满足请求
+	ErrInsufficientCapacity = errors.New("insufficient gpu capacity")
+	// ErrUnknownStrategy 未注册的放置策略
+	ErrUnknownStrategy = errors.New("unknown placement strategy")
+)
+
+// maxClaimAttempts 并发争抢同一批GPU时的最大重试次数
+const maxClaimAttempts = 5
+
+// actor 调度器写入GPU状态历史时使用的操作者
+const actor = "scheduler"
+
+// Request GPU调度请求
+type Request struct {
+	GPUCount    int    `json:"gpu_count"`
+	Model       string `json:"gpu_model"`
+	MinMemoryGB int    `json:"min_memory_gb"`
+	// ServerID 服务器亲和性，非空时只在该服务器上放置
+	ServerID string `json:"server_id"`
+	// Strategy 放置策略，默认binpack
+	Strategy string `json:"strategy"`
+}
+
+// Placement 调度结果
+type Placement struct {
+	Strategy string       `json:"strategy"`
+	GPUs     []models.GPU `json:"gpus"`
+}
+
+// ServerID 返回放置的主服务器（第一张GPU所在服务器）
+func (p *Placement) ServerID() string {
+	if len(p.GPUs) == 0 {
+		return ""
+	}
+	return p.GPUs[0].ServerID
+}
+
+// ServerIDs 按首次出现的顺序返回放置涉及的服务器
+func (p *Placement) ServerIDs() []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, gpu := range p.GPUs {
+		if !seen[gpu.ServerID] {
+			seen[gpu.ServerID] = true
+			ids = append(ids, gpu.ServerID)
+		}
+	}
+	return ids
+}
+
+// GPUIDs 返回选中的GPU ID
+func (p *Placement) GPUIDs() []string {
+	ids := make([]string, len(p.GPUs))
+	for i, gpu := range p.GPUs {
+		ids[i] = gpu.ID
+	}
+	return ids
+}
+
+// Scheduler GPU放置调度器
+type Scheduler struct {
+	repos *repository.Repositories
+
+	mu         sync.RWMutex
+	strategies map[string]Strategy
+}
+
+// NewScheduler 创建调度器，默认注册binpack和spread策略
+func NewScheduler(repos *repository.Repositories) *Scheduler {
+	s := &Scheduler{
+		repos:      repos,
+		strategies: map[string]Strategy{},
+	}
+	s.Register(NewBinPackStrategy())
+	s.Register(NewSpreadStrategy())
+	return s
+}
+
+// Register 注册放置策略，同名策略会被覆盖
+func (s *Scheduler) Register(strategy Strategy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strategies[strategy.Name()] = strategy
+}
+
+// strategy 按名称查找策略
+func (s *Scheduler) strategy(name string) (Strategy, error) {
+	if name == "" {
+		name = StrategyBinPack
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	strategy, ok := s.strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+	}
+	return strategy, nil
+}
+
+// Schedule 选择GPU并原子地将其从available改为allocated
+// 候选GPU被并发请求抢占时重新选择，直到成功或达到重试上限
+func (s *Scheduler) Schedule(ctx context.Context, req *Request) (*Placement, error) {
+	return s.schedule(ctx, s.repos, req)
+}
+
+// ScheduleInTx 在调用方的事务tx中选择并占用GPU，事务回滚时占用随之撤销
+func (s *Scheduler) ScheduleInTx(ctx context.Context, tx *repository.Repositories, req *Request) (*Placement, error) {
+	return s.schedule(ctx, tx, req)
+}
+
+// schedule 通过repos读取候选并占用GPU，repos可以是事务中的仓储
+func (s *Scheduler) schedule(ctx context.Context, repos *repository.Repositories, req *Request) (*Placement, error) {
+	if req.GPUCount <= 0 {
+		return nil, fmt.Errorf("gpu_count must be greater than 0")
+	}
+
+	strategy, err := s.strategy(req.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		pools, err := candidates(ctx, repos, req)
+		if err != nil {
+			return nil, err
+		}
+
+		gpus, err := strategy.Place(req, pools)
+		if err != nil {
+			return nil, err
+		}
+
+		placement := &Placement{Strategy: strategy.Name(), GPUs: gpus}
+		err = repos.GPUs.TransitionStatus(ctx, placement.GPUIDs(), models.GPUStatusAvailable, models.GPUStatusAllocated,
+			repository.StatusChange{Actor: actor, Reason: fmt.Sprintf("placed by %s strategy", strategy.Name())})
+		if err == nil {
+			for i := range placement.GPUs {
+				placement.GPUs[i].Status = models.GPUStatusAllocated
+			}
+			return placement, nil
+		}
+		if !errors.Is(err, repository.ErrConflict) {
+			return nil, err
+		}
+		// GPU已被其他请求抢占，重新读取候选后再试
+	}
+
+	return nil, fmt.Errorf("%w: gpus kept being claimed concurrently", ErrInsufficientCapacity)
+}
+
+// Release 将调度占用的GPU归还为available
+func (s *Scheduler) Release(ctx context.Context, gpuIDs []string) error {
+	if len(gpuIDs) == 0 {
+		return nil
+	}
+	return s.repos.GPUs.TransitionStatus(ctx, gpuIDs, models.GPUStatusAllocated, models.GPUStatusAvailable,
+		repository.StatusChange{Actor: actor, Reason: "placement released"})
+}
+
+// candidates 查询满足型号、显存和亲和性要求的空闲GPU，按服务器分组
+func candidates(ctx context.Context, repos *repository.Repositories, req *Request) ([]ServerPool, error) {
+	gpus, err := repos.GPUs.List(ctx, repository.GPUFilter{
+		ServerID: req.ServerID,
+		Status:   models.GPUStatusAvailable,
+		Model:    req.Model,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := repos.Servers.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 故障服务器上的GPU不参与调度
+	unhealthy := map[string]bool{}
+	for _, server := range servers {
+		if server.Status == models.ServerStatusError {
+			unhealthy[server.ID] = true
+		}
+	}
+
+	byServer := map[string][]models.GPU{}
+	for _, gpu := range gpus {
+		if gpu.MemoryGB < req.MinMemoryGB || unhealthy[gpu.ServerID] {
+			continue
+		}
+		byServer[gpu.ServerID] = append(byServer[gpu.ServerID], gpu)
+	}
+
+	pools := make([]ServerPool, 0, len(byServer))
+	for serverID, serverGPUs := range byServer {
+		pools = append(pools, ServerPool{ServerID: serverID, GPUs: serverGPUs})
+	}
+	// 固定顺序，保证相同输入得到相同放置结果
+	sort.Slice(pools, func(i, j int) bool { return pools[i].ServerID < pools[j].ServerID })
+
+	return pools, nil
+}
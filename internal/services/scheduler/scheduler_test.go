@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// cluster 测试用服务器，gpus为各张GPU的型号和显存
+type cluster struct {
+	name   string
+	status string
+	gpus   []models.GPU
+}
+
+// newCluster 在内存仓储中创建服务器和GPU，返回服务器名称到ID的映射
+func newCluster(t *testing.T, repos *repository.Repositories, servers ...cluster) map[string]string {
+	t.Helper()
+	ctx := context.Background()
+	ids := map[string]string{}
+	for _, c := range servers {
+		status := c.status
+		if status == "" {
+			status = models.ServerStatusReady
+		}
+		server := &models.Server{Name: c.name, Status: status}
+		if err := repos.Servers.Create(ctx, server); err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		ids[c.name] = server.ID
+		for _, gpu := range c.gpus {
+			gpu := gpu
+			gpu.ServerID = server.ID
+			if gpu.Status == "" {
+				gpu.Status = models.GPUStatusAvailable
+			}
+			if err := repos.GPUs.Create(ctx, &gpu); err != nil {
+				t.Fatalf("failed to create gpu: %v", err)
+			}
+		}
+	}
+	return ids
+}
+
+// a100s 创建count张80GB的A100
+func a100s(count int) []models.GPU {
+	gpus := make([]models.GPU, count)
+	for i := range gpus {
+		gpus[i] = models.GPU{Model: "A100", MemoryGB: 80}
+	}
+	return gpus
+}
+
+func TestSchedule(t *testing.T) {
+	servers := []cluster{
+		{name: "node-1", gpus: a100s(2)},
+		{name: "node-2", gpus: append(a100s(1), models.GPU{Model: "A100", MemoryGB: 40}, models.GPU{Model: "H100", MemoryGB: 80})},
+		{name: "node-3", gpus: a100s(4), status: models.ServerStatusError},
+		{name: "node-4", gpus: append(a100s(1), models.GPU{Model: "A100", MemoryGB: 80, Status: models.GPUStatusInUse})},
+	}
+
+	tests := []struct {
+		name string
+		req  Request
+		// wantServers 每张选中GPU所在的服务器
+		wantServers []string
+		wantErr     error
+	}{
+		{name: "binpack tightest server", req: Request{GPUCount: 1}, wantServers: []string{"node-4"}},
+		{name: "binpack whole server", req: Request{GPUCount: 2}, wantServers: []string{"node-1", "node-1"}},
+		{name: "model filter", req: Request{GPUCount: 1, Model: "H100"}, wantServers: []string{"node-2"}},
+		{name: "memory filter", req: Request{GPUCount: 2, MinMemoryGB: 80, Model: "A100", ServerID: "node-2"}, wantErr: ErrInsufficientCapacity},
+		{name: "server affinity", req: Request{GPUCount: 3, ServerID: "node-2"}, wantServers: []string{"node-2", "node-2", "node-2"}},
+		{name: "error server excluded", req: Request{GPUCount: 4}, wantErr: ErrInsufficientCapacity},
+		{name: "spread across servers", req: Request{GPUCount: 3, Strategy: StrategySpread, Model: "A100", MinMemoryGB: 80}, wantServers: []string{"node-1", "node-2", "node-4"}},
+		{name: "unknown strategy", req: Request{GPUCount: 1, Strategy: "random"}, wantErr: ErrUnknownStrategy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			ids := newCluster(t, repos, servers...)
+			names := map[string]string{}
+			for name, id := range ids {
+				names[id] = name
+			}
+			req := tt.req
+			if req.ServerID != "" {
+				req.ServerID = ids[req.ServerID]
+			}
+			ctx := context.Background()
+
+			placement, err := NewScheduler(repos).Schedule(ctx, &req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Schedule() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var got []string
+			for _, gpu := range placement.GPUs {
+				got = append(got, names[gpu.ServerID])
+				stored, err := repos.GPUs.Get(ctx, gpu.ID)
+				if err != nil {
+					t.Fatalf("failed to get gpu: %v", err)
+				}
+				if stored.Status != models.GPUStatusAllocated {
+					t.Errorf("gpu %s status = %s, want %s", gpu.ID, stored.Status, models.GPUStatusAllocated)
+				}
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.wantServers) {
+				t.Fatalf("placed on %v, want %v", got, tt.wantServers)
+			}
+			for i := range got {
+				if got[i] != tt.wantServers[i] {
+					t.Fatalf("placed on %v, want %v", got, tt.wantServers)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleConcurrent(t *testing.T) {
+	const (
+		servers  = 4
+		perNode  = 4
+		requests = 12
+		count    = 2
+	)
+
+	tests := []struct {
+		name     string
+		strategy string
+		inTx     bool
+	}{
+		{name: "binpack", strategy: StrategyBinPack},
+		{name: "spread", strategy: StrategySpread},
+		{name: "binpack in transaction", strategy: StrategyBinPack, inTx: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			var nodes []cluster
+			for i := 0; i < servers; i++ {
+				nodes = append(nodes, cluster{name: string(rune('a' + i)), gpus: a100s(perNode)})
+			}
+			newCluster(t, repos, nodes...)
+			s := NewScheduler(repos)
+			ctx := context.Background()
+
+			// 请求的GPU总数多于空闲GPU，部分请求必须因容量不足失败
+			var (
+				wg         sync.WaitGroup
+				mu         sync.Mutex
+				placements []*Placement
+			)
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := &Request{GPUCount: count, Strategy: tt.strategy}
+					var placement *Placement
+					var err error
+					if tt.inTx {
+						err = repos.InTx(ctx, func(tx *repository.Repositories) error {
+							placement, err = s.ScheduleInTx(ctx, tx, req)
+							return err
+						})
+					} else {
+						placement, err = s.Schedule(ctx, req)
+					}
+					if errors.Is(err, ErrInsufficientCapacity) {
+						return
+					}
+					if err != nil {
+						t.Errorf("Schedule() error = %v", err)
+						return
+					}
+					mu.Lock()
+					placements = append(placements, placement)
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+
+			// 任意两个请求拿到的GPU不相交，且每张选中的GPU只迁移过一次
+			owner := map[string]int{}
+			for i, placement := range placements {
+				if len(placement.GPUs) != count {
+					t.Fatalf("placement %d has %d gpus, want %d", i, len(placement.GPUs), count)
+				}
+				for _, id := range placement.GPUIDs() {
+					if prev, ok := owner[id]; ok {
+						t.Fatalf("gpu %s placed by requests %d and %d", id, prev, i)
+					}
+					owner[id] = i
+					history, err := repos.GPUs.History(ctx, id)
+					if err != nil {
+						t.Fatalf("History() error = %v", err)
+					}
+					if len(history) != 1 || history[0].ToStatus != models.GPUStatusAllocated {
+						t.Errorf("gpu %s history = %+v, want one transition to allocated", id, history)
+					}
+				}
+			}
+			allocated, err := repos.GPUs.List(ctx, repository.GPUFilter{Status: models.GPUStatusAllocated})
+			if err != nil {
+				t.Fatalf("failed to list gpus: %v", err)
+			}
+			if len(allocated) != len(owner) {
+				t.Errorf("allocated gpus = %d, want %d placed", len(allocated), len(owner))
+			}
+			if len(placements) == 0 || len(placements) > servers*perNode/count {
+				t.Errorf("%d requests succeeded, want between 1 and %d", len(placements), servers*perNode/count)
+			}
+		})
+	}
+}
+
+func TestScheduleInTxRollback(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	newCluster(t, repos, cluster{name: "node-1", gpus: a100s(2)})
+	s := NewScheduler(repos)
+	ctx := context.Background()
+
+	// 调用方事务回滚时占用随之撤销
+	rollback := errors.New("rollback")
+	err := repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if _, err := s.ScheduleInTx(ctx, tx, &Request{GPUCount: 2}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("InTx() error = %v, want %v", err, rollback)
+	}
+	available, err := repos.GPUs.List(ctx, repository.GPUFilter{Status: models.GPUStatusAvailable})
+	if err != nil {
+		t.Fatalf("failed to list gpus: %v", err)
+	}
+	if len(available) != 2 {
+		t.Errorf("available gpus after rollback = %d, want 2", len(available))
+	}
+	if _, err := s.Schedule(ctx, &Request{GPUCount: 2}); err != nil {
+		t.Errorf("Schedule() after rollback error = %v", err)
+	}
+}
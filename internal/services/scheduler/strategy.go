@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"sort"
+
+	"gpu-management/internal/models"
+)
+
+// 内置放置策略名称
+const (
+	StrategyBinPack = "binpack"
+	StrategySpread  = "spread"
+)
+
+// ServerPool 单台服务器上满足条件的空闲GPU
+type ServerPool struct {
+	ServerID string
+	GPUs     []models.GPU
+}
+
+// Strategy GPU放置策略
+// Place从候选池中挑选req.GPUCount张GPU，无法满足时返回ErrInsufficientCapacity
+type Strategy interface {
+	Name() string
+	Place(req *Request, pools []ServerPool) ([]models.GPU, error)
+}
+
+// binPackStrategy 装箱策略：所有GPU放在同一台服务器上，优先选择空闲GPU最少且仍能容纳的服务器
+// 适用于需要NVLink等机内互联的训练任务，同时为大任务保留完整的空闲服务器
+type binPackStrategy struct{}
+
+// NewBinPackStrategy 创建装箱策略
+func NewBinPackStrategy() Strategy {
+	return binPackStrategy{}
+}
+
+func (binPackStrategy) Name() string {
+	return StrategyBinPack
+}
+
+func (binPackStrategy) Place(req *Request, pools []ServerPool) ([]models.GPU, error) {
+	var best *ServerPool
+	for i := range pools {
+		pool := &pools[i]
+		if len(pool.GPUs) < req.GPUCount {
+			continue
+		}
+		if best == nil || len(pool.GPUs) < len(best.GPUs) {
+			best = pool
+		}
+	}
+	if best == nil {
+		return nil, ErrInsufficientCapacity
+	}
+
+	return append([]models.GPU{}, best.GPUs[:req.GPUCount]...), nil
+}
+
+// spreadStrategy 分散策略：轮询空闲GPU最多的服务器，每台每轮取一张
+// 适用于推理服务，单台服务器故障只影响少量副本
+type spreadStrategy struct{}
+
+// NewSpreadStrategy 创建分散策略
+func NewSpreadStrategy() Strategy {
+	return spreadStrategy{}
+}
+
+func (spreadStrategy) Name() string {
+	return StrategySpread
+}
+
+func (spreadStrategy) Place(req *Request, pools []ServerPool) ([]models.GPU, error) {
+	total := 0
+	for _, pool := range pools {
+		total += len(pool.GPUs)
+	}
+	if total < req.GPUCount {
+		return nil, ErrInsufficientCapacity
+	}
+
+	ordered := append([]ServerPool{}, pools...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return len(ordered[i].GPUs) > len(ordered[j].GPUs)
+	})
+
+	selected := make([]models.GPU, 0, req.GPUCount)
+	for round := 0; len(selected) < req.GPUCount; round++ {
+		for _, pool := range ordered {
+			if round < len(pool.GPUs) {
+				selected = append(selected, pool.GPUs[round])
+				if len(selected) == req.GPUCount {
+					break
+				}
+			}
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gpu-management/internal/models"
+)
+
+// pools 按服务器名称和空闲GPU数量构造候选池，GPU ID为<服务器>-<序号>
+func pools(counts ...interface{}) []ServerPool {
+	var result []ServerPool
+	for i := 0; i < len(counts); i += 2 {
+		serverID := counts[i].(string)
+		pool := ServerPool{ServerID: serverID}
+		for j := 0; j < counts[i+1].(int); j++ {
+			pool.GPUs = append(pool.GPUs, models.GPU{ID: fmt.Sprintf("%s-%d", serverID, j), ServerID: serverID})
+		}
+		result = append(result, pool)
+	}
+	return result
+}
+
+func TestStrategyPlace(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		count    int
+		pools    []ServerPool
+		want     []string
+		wantErr  error
+	}{
+		{
+			// 选择能容纳请求的服务器中空闲GPU最少的一台
+			name:     "binpack tightest fit",
+			strategy: NewBinPackStrategy(),
+			count:    2,
+			pools:    pools("s1", 3, "s2", 2, "s3", 4),
+			want:     []string{"s2-0", "s2-1"},
+		},
+		{
+			name:     "binpack skips servers that cannot fit",
+			strategy: NewBinPackStrategy(),
+			count:    3,
+			pools:    pools("s1", 3, "s2", 2, "s3", 4),
+			want:     []string{"s1-0", "s1-1", "s1-2"},
+		},
+		{
+			name:     "binpack keeps first server on tie",
+			strategy: NewBinPackStrategy(),
+			count:    1,
+			pools:    pools("s1", 2, "s2", 2),
+			want:     []string{"s1-0"},
+		},
+		{
+			// 总数足够但没有一台服务器能容纳全部GPU
+			name:     "binpack never splits",
+			strategy: NewBinPackStrategy(),
+			count:    5,
+			pools:    pools("s1", 3, "s2", 2, "s3", 4),
+			wantErr:  ErrInsufficientCapacity,
+		},
+		{
+			name:     "binpack no candidates",
+			strategy: NewBinPackStrategy(),
+			count:    1,
+			wantErr:  ErrInsufficientCapacity,
+		},
+		{
+			// 按空闲GPU从多到少轮询，每台每轮取一张
+			name:     "spread round robin",
+			strategy: NewSpreadStrategy(),
+			count:    4,
+			pools:    pools("s1", 1, "s2", 3, "s3", 2),
+			want:     []string{"s2-0", "s3-0", "s1-0", "s2-1"},
+		},
+		{
+			name:     "spread one per server first",
+			strategy: NewSpreadStrategy(),
+			count:    2,
+			pools:    pools("s1", 2, "s2", 2, "s3", 2),
+			want:     []string{"s1-0", "s2-0"},
+		},
+		{
+			name:     "spread drains remaining server",
+			strategy: NewSpreadStrategy(),
+			count:    6,
+			pools:    pools("s1", 1, "s2", 3, "s3", 2),
+			want:     []string{"s2-0", "s3-0", "s1-0", "s2-1", "s3-1", "s2-2"},
+		},
+		{
+			name:     "spread insufficient total",
+			strategy: NewSpreadStrategy(),
+			count:    7,
+			pools:    pools("s1", 1, "s2", 3, "s3", 2),
+			wantErr:  ErrInsufficientCapacity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gpus, err := tt.strategy.Place(&Request{GPUCount: tt.count}, tt.pools)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Place() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			got := (&Placement{GPUs: gpus}).GPUIDs()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Place() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/eventstore"
+)
+
+// 错误定义
+var (
+	ErrHubClosed = errors.New("event stream hub closed")
+	// ErrSlowConsumer 客户端读取过慢，缓冲区已满，客户端应携带最后收到的事件ID重新连接
+	ErrSlowConsumer = errors.New("event stream consumer too slow")
+	ErrInvalidTopic = errors.New("invalid topic pattern")
+)
+
+const (
+	// clientBuffer 每个客户端缓冲的实时事件数
+	clientBuffer = 256
+	// replayPageSize 断线续传时每次从事件存储读取的事件数
+	replayPageSize = eventstore.MaxLimit
+	// handlerPrefix 推送中心的处理器名称前缀，只用于日志
+	handlerPrefix = "event-stream."
+)
+
+// Filter 客户端的订阅条件，零值字段表示不过滤
+type Filter struct {
+	// Topics 事件类型模式，匹配任一即可，支持 * 和 > 通配
+	Topics []string
+	// ResourceID 只接收涉及该资源的事件，与事件的subject以及关联的服务器和GPU比较
+	ResourceID string
+}
+
+// Validate 校验订阅条件
+func (f Filter) Validate() error {
+	for _, topic := range f.Topics {
+		tokens := strings.Split(topic, ".")
+		for i, token := range tokens {
+			if token == "" || (token == ">" && i != len(tokens)-1) {
+				return fmt.Errorf("%w: %q", ErrInvalidTopic, topic)
+			}
+		}
+	}
+	return nil
+}
+
+// Match 判断事件是否满足订阅条件
+func (f Filter) Match(evt *models.Event) bool {
+	if len(f.Topics) > 0 {
+		matched := false
+		for _, topic := range f.Topics {
+			if event.MatchSubject(topic, evt.EventType) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.ResourceID != "" && evt.SourceID != f.ResourceID && evt.ServerID != f.ResourceID && evt.GPUID != f.ResourceID {
+		return false
+	}
+	return true
+}
+
+// Hub 事件推送中心，以广播方式订阅事件总线，把事件分发给本实例上所有打开的推送流
+// 每个服务实例都收到全部事件，客户端连接到任一实例都能收到完整的事件流
+type Hub struct {
+	repos    *repository.Repositories
+	eventBus event.EventBus
+
+	mu      sync.Mutex
+	streams map[*Stream]struct{}
+	closed  bool
+	done    chan struct{}
+}
+
+// NewHub 创建事件推送中心，调用Subscribe后开始接收事件
+func NewHub(repos *repository.Repositories, eventBus event.EventBus) *Hub {
+	return &Hub{
+		repos:    repos,
+		eventBus: eventBus,
+		streams:  map[*Stream]struct{}{},
+		done:     make(chan struct{}),
+	}
+}
+
+// Subscribe 订阅subjects中的每个主题，subjects通常与事件流收录的主题一致
+func (h *Hub) Subscribe(ctx context.Context, subjects []string) error {
+	for _, subject := range subjects {
+		err := h.eventBus.Subscribe(ctx, subject, h.dispatch,
+			event.WithHandlerName(handlerPrefix+subject), event.Broadcast())
+		if err != nil {
+			return fmt.Errorf("failed to subscribe event stream to %s: %w", subject, err)
+		}
+	}
+	return nil
+}
+
+// Open 打开推送流，流在ctx取消、推送中心关闭或客户端读取过慢时结束
+// lastEventID不为空时先从事件存储补发该事件之后满足条件的事件，再推送实时事件；
+// 事件存储中找不到lastEventID时只推送实时事件
+func (h *Hub) Open(ctx context.Context, filter Filter, lastEventID string) (*Stream, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Stream{
+		hub:    h,
+		filter: filter,
+		live:   make(chan *event.CloudEvent, clientBuffer),
+		out:    make(chan *event.CloudEvent),
+		failed: make(chan struct{}),
+	}
+
+	// 先登记再补发，补发期间到达的实时事件缓冲在live中，避免遗漏
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, ErrHubClosed
+	}
+	h.streams[s] = struct{}{}
+	h.mu.Unlock()
+
+	go s.run(ctx, lastEventID)
+	return s, nil
+}
+
+// Close 结束所有推送流，之后打开推送流返回ErrHubClosed
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	close(h.done)
+}
+
+// dispatch 把一条事件分发给订阅条件匹配的推送流，不阻塞事件总线
+func (h *Hub) dispatch(ctx context.Context, data []byte) error {
+	h.mu.Lock()
+	idle := len(h.streams) == 0
+	h.mu.Unlock()
+	if idle {
+		return nil
+	}
+
+	ce, err := event.ParseCloudEvent(data)
+	if err != nil {
+		return err
+	}
+	evt, err := eventstore.FromCloudEvent(ctx, h.repos.GPUs, ce)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.streams {
+		if !s.filter.Match(evt) {
+			continue
+		}
+		select {
+		case s.live <- ce:
+		default:
+			log.Printf("Dropping event stream client: %d events buffered", clientBuffer)
+			s.fail()
+			delete(h.streams, s)
+		}
+	}
+	return nil
+}
+
+// remove 注销推送流
+func (h *Hub) remove(s *Stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.streams, s)
+}
+
+// Stream 一个客户端的推送流
+type Stream struct {
+	hub    *Hub
+	filter Filter
+	live   chan *event.CloudEvent
+	out    chan *event.CloudEvent
+	// failed 在客户端读取过慢时关闭，由推送中心在持有锁时关闭一次
+	failed   chan struct{}
+	failOnce sync.Once
+	err      error
+}
+
+// Events 返回按发生顺序推送的事件，流结束后关闭
+func (s *Stream) Events() <-chan *event.CloudEvent {
+	return s.out
+}
+
+// Err 返回流结束的原因，ctx取消时返回nil，只能在Events关闭后调用
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// fail 标记客户端读取过慢
+func (s *Stream) fail() {
+	s.failOnce.Do(func() { close(s.failed) })
+}
+
+// run 先补发断线期间的事件，再转发实时事件，结束时关闭out并注销
+func (s *Stream) run(ctx context.Context, lastEventID string) {
+	defer close(s.out)
+	defer s.hub.remove(s)
+
+	replayed, err := s.replay(ctx, lastEventID)
+	if err != nil {
+		s.setErr(ctx, err)
+		return
+	}
+
+	for {
+		select {
+		case ce := <-s.live:
+			// 补发与实时推送可能包含同一事件
+			if replayed[ce.ID] {
+				continue
+			}
+			if err := s.send(ctx, ce); err != nil {
+				s.setErr(ctx, err)
+				return
+			}
+		case <-s.failed:
+			s.err = ErrSlowConsumer
+			return
+		case <-s.hub.done:
+			s.err = ErrHubClosed
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// setErr 记录流结束的原因，客户端断开导致的结束不视为错误
+func (s *Stream) setErr(ctx context.Context, err error) {
+	if ctx.Err() == nil {
+		s.err = err
+	}
+}
+
+// replay 从事件存储按发生时间正序补发lastEventID之后的事件，返回已补发的事件ID
+func (s *Stream) replay(ctx context.Context, lastEventID string) (map[string]bool, error) {
+	replayed := map[string]bool{}
+	if lastEventID == "" {
+		return replayed, nil
+	}
+
+	last, err := s.hub.repos.Events.Get(ctx, lastEventID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return replayed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last event %s: %w", lastEventID, err)
+	}
+
+	cursor := &repository.EventCursor{OccurredAt: last.OccurredAt, ID: last.ID}
+	for {
+		events, err := s.hub.repos.Events.List(ctx, repository.EventFilter{
+			After:     cursor,
+			Ascending: true,
+			Limit:     replayPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay events after %s: %w", lastEventID, err)
+		}
+		for i := range events {
+			evt := &events[i]
+			cursor = &repository.EventCursor{OccurredAt: evt.OccurredAt, ID: evt.ID}
+			if !s.filter.Match(evt) {
+				continue
+			}
+			if err := s.send(ctx, eventstore.ToCloudEvent(evt)); err != nil {
+				return nil, err
+			}
+			replayed[evt.ID] = true
+		}
+		if len(events) < replayPageSize {
+			return replayed, nil
+		}
+	}
+}
+
+// send 把事件交给客户端，客户端读取期间同样检查读取过慢和关闭
+func (s *Stream) send(ctx context.Context, ce *event.CloudEvent) error {
+	select {
+	case s.out <- ce:
+		return nil
+	case <-s.failed:
+		return ErrSlowConsumer
+	case <-s.hub.done:
+		return ErrHubClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,221 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/eventstore"
+)
+
+// subjects 测试中事件存储和推送中心订阅的主题
+var subjects = []string{"hardware.>"}
+
+// testHub 同步投递的内存事件总线上的事件记录器和推送中心，事件先写入事件存储再分发
+type testHub struct {
+	bus  *event.MemoryEventBus
+	hub  *Hub
+	base time.Time
+	seq  int
+}
+
+// newTestHub 创建推送中心，ctx结束时取消订阅
+func newTestHub(t *testing.T, ctx context.Context) *testHub {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	bus := event.NewMemoryEventBus(event.MemoryConfig{}, nil)
+	t.Cleanup(bus.Close)
+	if err := eventstore.NewRecorder(repos, bus).Subscribe(context.Background(), subjects); err != nil {
+		t.Fatalf("failed to subscribe recorder: %v", err)
+	}
+	hub := NewHub(repos, bus)
+	t.Cleanup(hub.Close)
+	if err := hub.Subscribe(ctx, subjects); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	return &testHub{bus: bus, hub: hub, base: time.Now().UTC().Add(-time.Hour)}
+}
+
+// publish 发布一条硬件事件并返回事件ID，发生时间按发布顺序递增
+func (h *testHub) publish(t *testing.T, eventType, serverID string) string {
+	t.Helper()
+	ce, err := event.NewCloudEvent("test", eventType, serverID, event.HardwareEvent{HardwareID: serverID, Status: models.ServerStatusReady})
+	if err != nil {
+		t.Fatalf("NewCloudEvent() error = %v", err)
+	}
+	h.seq++
+	ce.Time = h.base.Add(time.Duration(h.seq) * time.Second)
+	if err := h.bus.Publish(context.Background(), eventType, ce); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	return ce.ID
+}
+
+// receive 从推送流读取count个事件并返回事件ID
+func receive(t *testing.T, s *Stream, count int) []string {
+	t.Helper()
+	var ids []string
+	for len(ids) < count {
+		select {
+		case ce, ok := <-s.Events():
+			if !ok {
+				t.Fatalf("stream closed after %d events, want %d: %v", len(ids), count, s.Err())
+			}
+			ids = append(ids, ce.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d events, want %d", len(ids), count)
+		}
+	}
+	return ids
+}
+
+func TestHubResume(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		// lastEvent 断线前收到的事件序号，-1表示首次连接，-2表示事件存储中不存在的ID
+		lastEvent int
+		// wantReplay 重连后补发的断线期间的事件序号
+		wantReplay []int
+		// wantLive 是否收到重连后发布的server-1的实时事件
+		wantLive bool
+	}{
+		{name: "first connect", lastEvent: -1, wantLive: true},
+		{name: "resume after first", lastEvent: 0, wantReplay: []int{1, 2}, wantLive: true},
+		{name: "resume after last", lastEvent: 2, wantLive: true},
+		{name: "unknown last event", lastEvent: -2, wantLive: true},
+		{name: "resume with topic filter", filter: Filter{Topics: []string{event.EventTypeHardwareFailed}}, lastEvent: 0, wantReplay: []int{2}, wantLive: true},
+		{name: "resume with resource filter", filter: Filter{ResourceID: "server-2"}, lastEvent: 0, wantReplay: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h := newTestHub(t, ctx)
+
+			// 断线期间发生的事件
+			ids := []string{
+				h.publish(t, event.EventTypeHardwareDiscovered, "server-1"),
+				h.publish(t, event.EventTypeHardwareDiscovered, "server-2"),
+				h.publish(t, event.EventTypeHardwareFailed, "server-1"),
+			}
+			lastEventID := ""
+			switch {
+			case tt.lastEvent >= 0:
+				lastEventID = ids[tt.lastEvent]
+			case tt.lastEvent == -2:
+				lastEventID = "missing"
+			}
+
+			s, err := h.hub.Open(ctx, tt.filter, lastEventID)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			var want []string
+			for _, n := range tt.wantReplay {
+				want = append(want, ids[n])
+			}
+			if got := receive(t, s, len(want)); !slices.Equal(got, want) {
+				t.Fatalf("replayed events = %v, want %v", got, want)
+			}
+
+			live := h.publish(t, event.EventTypeHardwareFailed, "server-1")
+			if tt.wantLive {
+				if got := receive(t, s, 1); got[0] != live {
+					t.Fatalf("live event = %s, want %s", got[0], live)
+				}
+			}
+
+			// 没有重复或多余的事件
+			select {
+			case ce := <-s.Events():
+				t.Errorf("unexpected event %s", ce.ID)
+			case <-time.After(20 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestHubClientDisconnect(t *testing.T) {
+	h := newTestHub(t, context.Background())
+
+	clientCtx, disconnect := context.WithCancel(context.Background())
+	s, err := h.hub.Open(clientCtx, Filter{}, "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	h.publish(t, event.EventTypeHardwareFailed, "server-1")
+	receive(t, s, 1)
+
+	// 客户端断开后流结束并从推送中心注销，不视为错误
+	disconnect()
+	select {
+	case _, ok := <-s.Events():
+		if ok {
+			t.Fatal("received an event after disconnect")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream not closed after disconnect")
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	h.hub.mu.Lock()
+	streams := len(h.hub.streams)
+	h.hub.mu.Unlock()
+	if streams != 0 {
+		t.Errorf("%d streams registered after disconnect, want 0", streams)
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	h := newTestHub(t, context.Background())
+	s, err := h.hub.Open(context.Background(), Filter{}, "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	h.hub.Close()
+	for range s.Events() {
+	}
+	if !errors.Is(s.Err(), ErrHubClosed) {
+		t.Errorf("Err() = %v, want %v", s.Err(), ErrHubClosed)
+	}
+	if _, err := h.hub.Open(context.Background(), Filter{}, ""); !errors.Is(err, ErrHubClosed) {
+		t.Errorf("Open() after Close() error = %v, want %v", err, ErrHubClosed)
+	}
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	h := newTestHub(t, context.Background())
+	s, err := h.hub.Open(context.Background(), Filter{}, "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// 客户端不读取，超过缓冲区的事件使推送中心断开该客户端
+	for i := 0; i < clientBuffer+2; i++ {
+		h.publish(t, event.EventTypeHardwareFailed, "server-1")
+	}
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-s.Events():
+			if ok {
+				continue
+			}
+			if !errors.Is(s.Err(), ErrSlowConsumer) {
+				t.Errorf("Err() = %v, want %v", s.Err(), ErrSlowConsumer)
+			}
+			return
+		case <-deadline:
+			t.Fatal("slow stream not closed")
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/pkg/uuid"
+)
+
+// ErrRunnerClosed 执行器已关闭，不再接收新任务
+var ErrRunnerClosed = errors.New("task runner is closed")
+
+// writeTimeout 任务状态写入的超时，任务Context结束后仍需落库
+const writeTimeout = 10 * time.Second
+
+// Func 任务执行函数，report用于更新任务进度说明，返回值写入任务结果
+type Func func(ctx context.Context, report func(message string)) (map[string]interface{}, error)
+
+// Config 执行器配置
+type Config struct {
+	// Owner 服务实例标识，写入任务记录，为空时随机生成
+	// 重启后保持不变时，启动时可以立即接管本实例上次未结束的任务
+	Owner string
+	// HeartbeatInterval 为本实例未结束的任务续约的间隔
+	HeartbeatInterval time.Duration
+	// LeaseTimeout 任务超过该时间未续约时视为执行实例已退出，由其他实例标记为失败
+	LeaseTimeout time.Duration
+}
+
+// Runner 异步任务执行器
+// 任务记录先落库再在后台执行，生命周期与发起请求的Context无关
+// 多实例共享数据库时，每个实例定期为自己执行的任务续约，并回收租约过期的任务
+type Runner struct {
+	repo     repository.TaskRepository
+	eventBus event.EventBus
+	config   Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu 保护closed和onInterrupted，保证Close之后不再有新任务加入wg
+	mu            sync.Mutex
+	closed        bool
+	onInterrupted []func(ctx context.Context, task *models.Task)
+}
+
+// NewRunner 创建异步任务执行器并启动续约
+func NewRunner(repo repository.TaskRepository, eventBus event.EventBus, config Config) *Runner {
+	if config.Owner == "" {
+		config.Owner = uuid.New()
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 10 * time.Second
+	}
+	if config.LeaseTimeout <= config.HeartbeatInterval {
+		config.LeaseTimeout = 6 * config.HeartbeatInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Runner{
+		repo:     repo,
+		eventBus: eventBus,
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	r.wg.Add(1)
+	go r.heartbeat()
+	return r
+}
+
+// OnInterrupted 注册回调，任务因执行实例退出被标记为失败后调用，用于结束依赖该任务的业务记录
+func (r *Runner) OnInterrupted(fn func(ctx context.Context, task *models.Task)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onInterrupted = append(r.onInterrupted, fn)
+}
+
+// Submit 保存pending状态的任务并在后台执行fn，timeout限制整个任务的执行时间
+func (r *Runner) Submit(ctx context.Context, task *models.Task, timeout time.Duration, fn Func) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return ErrRunnerClosed
+	}
+	r.wg.Add(1)
+	r.mu.Unlock()
+
+	now := time.Now().UTC()
+	task.Status = models.TaskStatusPending
+	task.Owner = r.config.Owner
+	task.HeartbeatAt = &now
+	if err := r.repo.Create(ctx, task); err != nil {
+		r.wg.Done()
+		return err
+	}
+
+	go r.run(*task, timeout, fn)
+	return nil
+}
+
+// run 执行任务并记录结果
+func (r *Runner) run(task models.Task, timeout time.Duration, fn Func) {
+	defer r.wg.Done()
+
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	task.Status = models.TaskStatusRunning
+	task.StartedAt = &now
+	if err := r.save(&task, models.TaskStatusPending); err != nil {
+		log.Printf("Failed to start task %s: %v", task.ID, err)
+		return
+	}
+
+	report := func(message string) {
+		task.Message = message
+		if err := r.save(&task, models.TaskStatusRunning); err != nil {
+			log.Printf("Failed to report progress of task %s: %v", task.ID, err)
+		}
+	}
+
+	result, err := fn(ctx, report)
+
+	finished := time.Now().UTC()
+	task.FinishedAt = &finished
+	if err != nil {
+		task.Status = models.TaskStatusFailed
+		task.Error = describeFailure(ctx, r.ctx, timeout, err)
+	} else {
+		// 失败时保留最后的进度说明，便于定位失败发生在哪一步
+		task.Status = models.TaskStatusSucceeded
+		task.Message = "completed"
+		task.Result = result
+	}
+
+	if err := r.save(&task, models.TaskStatusRunning); err != nil {
+		log.Printf("Failed to finish task %s: %v", task.ID, err)
+		return
+	}
+	r.publish(&task)
+}
+
+// describeFailure 区分任务超时、服务关闭和执行错误
+func describeFailure(ctx, runnerCtx context.Context, timeout time.Duration, err error) string {
+	switch {
+	case runnerCtx.Err() != nil:
+		return fmt.Sprintf("interrupted by shutdown: %v", err)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Sprintf("timed out after %s: %v", timeout, err)
+	default:
+		return err.Error()
+	}
+}
+
+// save 以期望状态为条件写入任务，写入同时续约
+func (r *Runner) save(task *models.Task, expectedStatus string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	now := time.Now().UTC()
+	task.HeartbeatAt = &now
+	return r.repo.UpdateIfStatus(ctx, task, expectedStatus)
+}
+
+// publish 发布任务结束事件，发布失败不影响任务结果，只记录日志
+func (r *Runner) publish(task *models.Task) {
+	topic := event.EventTypeTaskSucceeded
+	if task.Status == models.TaskStatusFailed {
+		topic = event.EventTypeTaskFailed
+	}
+
+	evt, err := event.NewCloudEvent("task-runner", topic, task.ID, event.TaskEvent{
+		TaskID:     task.ID,
+		TaskType:   task.Type,
+		TargetType: task.TargetType,
+		TargetID:   task.TargetID,
+		Status:     task.Status,
+		Error:      task.Error,
+	})
+	if err != nil {
+		log.Printf("Failed to build %s for task %s: %v", topic, task.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := r.eventBus.Publish(ctx, topic, evt, event.WithMessageID(evt.ID)); err != nil {
+		log.Printf("Failed to publish %s for task %s: %v", topic, task.ID, err)
+	}
+}
+
+// heartbeat 定期为本实例的任务续约，并回收其他实例租约过期的任务
+func (r *Runner) heartbeat() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(r.ctx, writeTimeout)
+		if _, err := r.repo.Heartbeat(ctx, r.config.Owner, time.Now().UTC()); err != nil && r.ctx.Err() == nil {
+			log.Printf("Failed to renew task leases of %s: %v", r.config.Owner, err)
+		}
+		if count, err := r.failStale(ctx, false); err != nil && r.ctx.Err() == nil {
+			log.Printf("Failed to reclaim tasks with expired leases: %v", err)
+		} else if count > 0 {
+			log.Printf("Marked %d tasks with expired leases as failed", count)
+		}
+		cancel()
+	}
+}
+
+// FailInterrupted 将上次进程退出时未结束的任务标记为失败，启动时调用
+// 只处理本实例名下和租约已过期的任务，其他实例正在执行的任务不受影响
+func (r *Runner) FailInterrupted(ctx context.Context) (int, error) {
+	return r.failStale(ctx, true)
+}
+
+// failStale 将执行实例已退出的未结束任务标记为失败，includeOwn为true时包括本实例名下的任务
+func (r *Runner) failStale(ctx context.Context, includeOwn bool) (int, error) {
+	tasks, err := r.repo.List(ctx, repository.TaskFilter{
+		Statuses: []string{models.TaskStatusPending, models.TaskStatusRunning},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	hooks := append([]func(context.Context, *models.Task){}, r.onInterrupted...)
+	r.mu.Unlock()
+
+	count := 0
+	deadline := time.Now().UTC().Add(-r.config.LeaseTimeout)
+	for i := range tasks {
+		task := &tasks[i]
+		reason := "interrupted by server restart"
+		switch {
+		case task.Owner == r.config.Owner:
+			if !includeOwn {
+				continue
+			}
+		case task.HeartbeatAt != nil && task.HeartbeatAt.After(deadline):
+			continue
+		case task.Owner != "":
+			reason = fmt.Sprintf("lease of instance %s expired", task.Owner)
+		}
+
+		expected := task.Status
+		now := time.Now().UTC()
+		task.Status = models.TaskStatusFailed
+		task.Error = reason
+		task.FinishedAt = &now
+		if err := r.repo.UpdateIfStatus(ctx, task, expected); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				continue
+			}
+			return count, err
+		}
+		count++
+		for _, hook := range hooks {
+			hook(ctx, task)
+		}
+	}
+	return count, nil
+}
+
+// Close 取消所有运行中的任务并等待其记录结果
+func (r *Runner) Close() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,150 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+func newTestRunner(t *testing.T, repos *repository.Repositories, owner string) *Runner {
+	t.Helper()
+	bus := event.NewMemoryEventBus(event.MemoryConfig{}, nil)
+	r := NewRunner(repos.Tasks, bus, Config{
+		Owner:             owner,
+		HeartbeatInterval: 20 * time.Millisecond,
+		LeaseTimeout:      time.Minute,
+	})
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestFailInterrupted(t *testing.T) {
+	fresh := time.Now().UTC()
+	expired := fresh.Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		owner       string
+		heartbeatAt *time.Time
+		status      string
+		wantFailed  bool
+	}{
+		{name: "own running task", owner: "a", heartbeatAt: &fresh, status: models.TaskStatusRunning, wantFailed: true},
+		{name: "own pending task", owner: "a", heartbeatAt: &fresh, status: models.TaskStatusPending, wantFailed: true},
+		{name: "live task of another instance", owner: "b", heartbeatAt: &fresh, status: models.TaskStatusRunning},
+		{name: "expired lease of another instance", owner: "b", heartbeatAt: &expired, status: models.TaskStatusRunning, wantFailed: true},
+		{name: "task without lease", status: models.TaskStatusRunning, wantFailed: true},
+		{name: "finished task", owner: "b", heartbeatAt: &expired, status: models.TaskStatusSucceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			ctx := context.Background()
+			task := &models.Task{
+				Type:        models.TaskTypeServerPower,
+				Status:      tt.status,
+				Owner:       tt.owner,
+				HeartbeatAt: tt.heartbeatAt,
+			}
+			if err := repos.Tasks.Create(ctx, task); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			r := newTestRunner(t, repos, "a")
+			count, err := r.FailInterrupted(ctx)
+			if err != nil {
+				t.Fatalf("FailInterrupted() error = %v", err)
+			}
+			if (count == 1) != tt.wantFailed {
+				t.Errorf("FailInterrupted() = %d, want failed = %v", count, tt.wantFailed)
+			}
+
+			got, err := repos.Tasks.Get(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if failed := got.Status == models.TaskStatusFailed; failed != tt.wantFailed {
+				t.Errorf("status = %s, want failed = %v", got.Status, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestRunnerRenewsLeases(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	r := newTestRunner(t, repos, "a")
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	task := &models.Task{Type: models.TaskTypeServerPower}
+	err := r.Submit(ctx, task, time.Minute, func(ctx context.Context, report func(string)) (map[string]interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	defer close(release)
+
+	created, err := repos.Tasks.Get(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if created.Owner != "a" || created.HeartbeatAt == nil {
+		t.Fatalf("submitted task owner = %q, heartbeat = %v", created.Owner, created.HeartbeatAt)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := repos.Tasks.Get(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.HeartbeatAt.After(*created.HeartbeatAt) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lease of running task was not renewed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 其他实例看到的是仍在续约的任务，不会将其标记为失败
+	other := newTestRunner(t, repos, "b")
+	if count, err := other.FailInterrupted(ctx); err != nil || count != 0 {
+		t.Fatalf("FailInterrupted() on another instance = %d, %v, want 0", count, err)
+	}
+}
+
+func TestSubmitAfterClose(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	r := newTestRunner(t, repos, "a")
+	ctx := context.Background()
+	noop := func(ctx context.Context, report func(string)) (map[string]interface{}, error) { return nil, nil }
+
+	// 并发提交与关闭，关闭后的提交必须返回ErrRunnerClosed且不会启动任务
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := r.Submit(ctx, &models.Task{Type: models.TaskTypeServerPower}, time.Minute, noop)
+			if err != nil && !errors.Is(err, ErrRunnerClosed) {
+				t.Errorf("Submit() error = %v", err)
+			}
+		}()
+	}
+	r.Close()
+	wg.Wait()
+
+	if err := r.Submit(ctx, &models.Task{Type: models.TaskTypeServerPower}, time.Minute, noop); !errors.Is(err, ErrRunnerClosed) {
+		t.Fatalf("Submit() after Close() error = %v, want %v", err, ErrRunnerClosed)
+	}
+}
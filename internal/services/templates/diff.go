@@ -0,0 +1,183 @@
+package templates
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"gpu-management/internal/models"
+)
+
+// 内容差异行的操作
+const (
+	DiffEqual  = " "
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+// 参数定义的变化类型
+const (
+	ParameterAdded   = "added"
+	ParameterRemoved = "removed"
+	ParameterChanged = "changed"
+)
+
+// Diff 模板两个版本之间的差异
+type Diff struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Changed 内容、参数定义、描述或操作系统类型是否有变化
+	Changed bool `json:"changed"`
+	// Lines 逐行的内容差异，包含未变化的行
+	Lines []DiffLine `json:"lines"`
+	// Parameters 按参数名称比较的参数定义变化，required等顶层字段的变化记为名称为空的一项
+	Parameters []ParameterChange `json:"parameters"`
+	// Retries 按动作名称或通配符比较的重试策略变化
+	Retries []ParameterChange `json:"retries"`
+}
+
+// DiffLine 内容差异中的一行
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// ParameterChange 单个参数定义或重试策略的变化
+type ParameterChange struct {
+	Name   string      `json:"name"`
+	Change string      `json:"change"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+// diff 比较两个模板版本
+func diff(from, to *models.WorkflowTemplate) *Diff {
+	d := &Diff{
+		Name:       from.Name,
+		From:       from.Version,
+		To:         to.Version,
+		Lines:      diffLines(splitLines(from.Content), splitLines(to.Content)),
+		Parameters: diffParameters(from.Parameters, to.Parameters),
+		Retries:    diffEntries(retryEntries(from.Retries), retryEntries(to.Retries)),
+	}
+	d.Changed = from.Content != to.Content || len(d.Parameters) > 0 || len(d.Retries) > 0 ||
+		from.Description != to.Description || from.OSType != to.OSType
+	return d
+}
+
+// splitLines 按行拆分模板内容，忽略末尾换行
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines 按最长公共子序列计算逐行差异
+func diffLines(a, b []string) []DiffLine {
+	// lcs[i][j] 为a[i:]与b[j:]的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := []DiffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return lines
+}
+
+// diffParameters 按参数名称比较参数定义
+func diffParameters(from, to map[string]interface{}) []ParameterChange {
+	fromProps, _ := from["properties"].(map[string]interface{})
+	toProps, _ := to["properties"].(map[string]interface{})
+	changes := diffEntries(fromProps, toProps)
+
+	// properties以外的字段（required、additionalProperties等）整体比较
+	if !reflect.DeepEqual(withoutProperties(from), withoutProperties(to)) {
+		changes = append(changes, ParameterChange{Change: ParameterChanged, From: withoutProperties(from), To: withoutProperties(to)})
+	}
+	return changes
+}
+
+// diffEntries 按键比较两组定义
+func diffEntries(from, to map[string]interface{}) []ParameterChange {
+	changes := []ParameterChange{}
+	names := map[string]bool{}
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		before, hadBefore := from[name]
+		after, hasAfter := to[name]
+		switch {
+		case !hadBefore:
+			changes = append(changes, ParameterChange{Name: name, Change: ParameterAdded, To: after})
+		case !hasAfter:
+			changes = append(changes, ParameterChange{Name: name, Change: ParameterRemoved, From: before})
+		case !reflect.DeepEqual(before, after):
+			changes = append(changes, ParameterChange{Name: name, Change: ParameterChanged, From: before, To: after})
+		}
+	}
+	return changes
+}
+
+// retryEntries 把重试策略转换为diffEntries比较的定义
+func retryEntries(retries map[string]models.RetryPolicy) map[string]interface{} {
+	entries := make(map[string]interface{}, len(retries))
+	for pattern, policy := range retries {
+		entries[pattern] = policy
+	}
+	return entries
+}
+
+// withoutProperties 去掉properties后的参数定义
+func withoutProperties(schema map[string]interface{}) map[string]interface{} {
+	rest := map[string]interface{}{}
+	for k, v := range schema {
+		if k != "properties" {
+			rest[k] = v
+		}
+	}
+	return rest
+}
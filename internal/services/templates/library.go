@@ -0,0 +1,173 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// 模板库错误
+var (
+	// ErrInvalidTemplate 模板内容或参数定义不合法，例如YAML无法解析、引用未声明的参数或使用未允许的动作
+	ErrInvalidTemplate = errors.New("invalid workflow template")
+	// ErrInvalidParameters 渲染参数不符合模板的参数定义
+	ErrInvalidParameters = errors.New("invalid template parameters")
+)
+
+// Config 模板库配置
+type Config struct {
+	// AllowedActions 允许使用的动作镜像仓库，不含标签和摘要
+	AllowedActions []string
+}
+
+// Library 工作流模板库
+//
+// 模板按名称管理，每次修改追加一个不可变的版本，版本号未指定时按v1、v2递增
+// 模板和新版本保存前都会用参数定义生成的示例参数试渲染，校验YAML结构和动作镜像，渲染时再按参数定义校验实际参数
+type Library struct {
+	repos   *repository.Repositories
+	allowed map[string]bool
+}
+
+// NewLibrary 创建工作流模板库
+func NewLibrary(repos *repository.Repositories, config Config) *Library {
+	allowed := make(map[string]bool, len(config.AllowedActions))
+	for _, action := range config.AllowedActions {
+		allowed[action] = true
+	}
+	return &Library{repos: repos, allowed: allowed}
+}
+
+// List 返回每个模板的最新版本
+func (l *Library) List(ctx context.Context) ([]models.WorkflowTemplate, error) {
+	all, err := l.repos.WorkflowTemplates.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 版本按创建时间升序返回，同名模板后出现的版本覆盖先出现的版本
+	latest := map[string]int{}
+	result := []models.WorkflowTemplate{}
+	for _, t := range all {
+		if i, ok := latest[t.Name]; ok {
+			result[i] = t
+			continue
+		}
+		latest[t.Name] = len(result)
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// Get 返回模板的最新版本
+func (l *Library) Get(ctx context.Context, name string) (*models.WorkflowTemplate, error) {
+	return l.repos.WorkflowTemplates.Latest(ctx, name)
+}
+
+// Versions 返回模板的所有版本，按创建时间升序
+func (l *Library) Versions(ctx context.Context, name string) ([]models.WorkflowTemplate, error) {
+	versions, err := l.repos.WorkflowTemplates.ListVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return versions, nil
+}
+
+// GetVersion 返回模板的指定版本，version为空时返回最新版本
+func (l *Library) GetVersion(ctx context.Context, name, version string) (*models.WorkflowTemplate, error) {
+	if version == "" {
+		return l.Get(ctx, name)
+	}
+	return l.repos.WorkflowTemplates.Get(ctx, name, version)
+}
+
+// Create 创建模板的第一个版本，同名模板已存在时返回冲突
+func (l *Library) Create(ctx context.Context, req *CreateRequest, createdBy string) (*models.WorkflowTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := l.Validate(req.Content, req.Parameters, req.Retries); err != nil {
+		return nil, err
+	}
+
+	versions, err := l.repos.WorkflowTemplates.ListVersions(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) > 0 {
+		return nil, fmt.Errorf("%w: template %s already exists", repository.ErrConflict, req.Name)
+	}
+	return l.create(ctx, req.Name, &req.VersionRequest, "v1", createdBy)
+}
+
+// CreateVersion 为已有模板追加新版本
+func (l *Library) CreateVersion(ctx context.Context, name string, req *VersionRequest, createdBy string) (*models.WorkflowTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := l.Validate(req.Content, req.Parameters, req.Retries); err != nil {
+		return nil, err
+	}
+
+	versions, err := l.Versions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return l.create(ctx, name, req, fmt.Sprintf("v%d", len(versions)+1), createdBy)
+}
+
+// create 保存模板版本，未指定版本号时使用defaultVersion
+func (l *Library) create(ctx context.Context, name string, req *VersionRequest, defaultVersion, createdBy string) (*models.WorkflowTemplate, error) {
+	template := &models.WorkflowTemplate{
+		Name:        name,
+		Version:     req.Version,
+		Description: req.Description,
+		OSType:      req.OSType,
+		Content:     req.Content,
+		Parameters:  req.Parameters,
+		Retries:     req.Retries,
+		CreatedBy:   createdBy,
+	}
+	if template.Version == "" {
+		template.Version = defaultVersion
+	}
+	if template.Parameters == nil {
+		template.Parameters = map[string]interface{}{"type": "object"}
+	}
+	if err := l.repos.WorkflowTemplates.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Diff 比较模板的两个版本
+func (l *Library) Diff(ctx context.Context, name, from, to string) (*Diff, error) {
+	if from == "" || to == "" {
+		return nil, errors.New("from and to versions are required")
+	}
+	base, err := l.repos.WorkflowTemplates.Get(ctx, name, from)
+	if err != nil {
+		return nil, err
+	}
+	target, err := l.repos.WorkflowTemplates.Get(ctx, name, to)
+	if err != nil {
+		return nil, err
+	}
+	return diff(base, target), nil
+}
+
+// Render 按模板版本渲染参数，version为空时使用最新版本
+// 参数按参数定义校验并填充默认值，渲染结果同样需要通过动作校验
+func (l *Library) Render(ctx context.Context, name, version string, params map[string]interface{}) (*Rendered, error) {
+	template, err := l.GetVersion(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	return l.render(template, params)
+}
@@ -0,0 +1,368 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// testContent 单个写入镜像动作的模板，image为动作镜像
+func testContent(image string) string {
+	return `version: "0.1"
+name: provision
+global_timeout: 600
+tasks:
+  - name: "provision"
+    worker: "{{.device_1}}"
+    actions:
+      - name: "stream-image"
+        image: ` + image + `
+        timeout: 600
+        environment:
+          IMG_URL: "[[.os_image]]"
+          DEST_DISK: "[[.disk]]"
+`
+}
+
+// testParameters os_image必填，disk默认为/dev/sda
+func testParameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"os_image"},
+		"properties": map[string]interface{}{
+			"os_image": map[string]interface{}{"type": "string", "format": "uri"},
+			"disk":     map[string]interface{}{"type": "string", "default": "/dev/sda"},
+		},
+	}
+}
+
+// newTestLibrary 只允许image2disk和cexec动作的模板库
+func newTestLibrary() *Library {
+	return NewLibrary(repository.NewMemoryRepositories(), Config{AllowedActions: []string{
+		"quay.io/tinkerbell-actions/image2disk",
+		"quay.io/tinkerbell-actions/cexec",
+	}})
+}
+
+func TestLibraryValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		parameters map[string]interface{}
+		retries    map[string]models.RetryPolicy
+		wantErr    string
+	}{
+		{name: "valid", content: testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"), parameters: testParameters()},
+		{name: "image digest", content: testContent("quay.io/tinkerbell-actions/image2disk@sha256:abc"), parameters: testParameters()},
+		{
+			name:       "retry wildcard",
+			content:    testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+			parameters: testParameters(),
+			retries:    map[string]models.RetryPolicy{"stream-*": {MaxAttempts: 3}},
+		},
+		{
+			// 动作镜像不在允许列表中
+			name:       "unknown action",
+			content:    testContent("docker.io/evil/miner:latest"),
+			parameters: testParameters(),
+			wantErr:    `unknown image "docker.io/evil/miner:latest"`,
+		},
+		{
+			// 同名仓库的其他镜像同样被拒绝
+			name:       "unknown action on allowed registry",
+			content:    testContent("quay.io/tinkerbell-actions/rootio:v1.0.0"),
+			parameters: testParameters(),
+			wantErr:    "unknown image",
+		},
+		{
+			name:    "undeclared parameter",
+			content: testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+			parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"os_image": map[string]interface{}{"type": "string"}},
+			},
+			wantErr: "undeclared parameters disk",
+		},
+		{
+			name:       "parameters not an object",
+			content:    testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+			parameters: map[string]interface{}{"type": "array"},
+			wantErr:    "type object",
+		},
+		{
+			name: "duplicate action",
+			content: strings.Replace(testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"), `        environment:`,
+				`      - name: "stream-image"
+        image: quay.io/tinkerbell-actions/cexec:v1.0.0
+        environment:`, 1),
+			parameters: testParameters(),
+			wantErr:    "duplicate action stream-image",
+		},
+		{
+			name:       "retry matches no action",
+			content:    testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+			parameters: testParameters(),
+			retries:    map[string]models.RetryPolicy{"kexec": {MaxAttempts: 2}},
+			wantErr:    "matches no action",
+		},
+		{
+			name:       "retry attempts out of range",
+			content:    testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+			parameters: testParameters(),
+			retries:    map[string]models.RetryPolicy{"stream-image": {MaxAttempts: 11}},
+			wantErr:    "max_attempts",
+		},
+		{name: "not yaml", content: "tasks: [", parameters: testParameters(), wantErr: "invalid workflow template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestLibrary().Validate(tt.content, tt.parameters, tt.retries)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidTemplate) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v containing %q", err, ErrInvalidTemplate, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLibraryVersions(t *testing.T) {
+	l := newTestLibrary()
+	ctx := context.Background()
+	req := &CreateRequest{Name: "provision", VersionRequest: VersionRequest{
+		Content:    testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+		Parameters: testParameters(),
+	}}
+
+	v1, err := l.Create(ctx, req, "alice")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := l.Create(ctx, req, "alice"); !errors.Is(err, repository.ErrConflict) {
+		t.Fatalf("Create() twice error = %v, want %v", err, repository.ErrConflict)
+	}
+	v2, err := l.CreateVersion(ctx, "provision", &req.VersionRequest, "bob")
+	if err != nil {
+		t.Fatalf("CreateVersion() error = %v", err)
+	}
+	named, err := l.CreateVersion(ctx, "provision", &VersionRequest{Version: "2024.1", Content: req.Content, Parameters: req.Parameters}, "bob")
+	if err != nil {
+		t.Fatalf("CreateVersion() error = %v", err)
+	}
+	if v1.Version != "v1" || v2.Version != "v2" || named.Version != "2024.1" {
+		t.Errorf("versions = %s, %s, %s, want v1, v2, 2024.1", v1.Version, v2.Version, named.Version)
+	}
+
+	latest, err := l.Get(ctx, "provision")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if latest.Version != "2024.1" {
+		t.Errorf("latest version = %s, want 2024.1", latest.Version)
+	}
+	if _, err := l.CreateVersion(ctx, "missing", &req.VersionRequest, "bob"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("CreateVersion() of missing template error = %v, want %v", err, repository.ErrNotFound)
+	}
+	// 使用未允许动作的新版本不会保存
+	bad := &VersionRequest{Content: testContent("docker.io/evil/miner:latest"), Parameters: req.Parameters}
+	if _, err := l.CreateVersion(ctx, "provision", bad, "bob"); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("CreateVersion() with unknown action error = %v, want %v", err, ErrInvalidTemplate)
+	}
+	versions, err := l.Versions(ctx, "provision")
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	if len(versions) != 3 {
+		t.Errorf("versions = %d, want 3", len(versions))
+	}
+}
+
+func TestLibraryDiff(t *testing.T) {
+	l := newTestLibrary()
+	ctx := context.Background()
+	base := testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0")
+	if _, err := l.Create(ctx, &CreateRequest{Name: "provision", VersionRequest: VersionRequest{
+		Content:    base,
+		Parameters: testParameters(),
+	}}, "alice"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// v2：升级镜像标签，disk改为必填且去掉默认值，新增compressed参数，增加重试策略
+	parameters := testParameters()
+	parameters["required"] = []interface{}{"os_image", "disk"}
+	properties := parameters["properties"].(map[string]interface{})
+	properties["disk"] = map[string]interface{}{"type": "string"}
+	properties["compressed"] = map[string]interface{}{"type": "boolean", "default": true}
+	if _, err := l.CreateVersion(ctx, "provision", &VersionRequest{
+		Content:    strings.Replace(base, "image2disk:v1.0.0", "image2disk:v1.1.0", 1),
+		Parameters: parameters,
+		Retries:    map[string]models.RetryPolicy{"stream-image": {MaxAttempts: 3}},
+	}, "bob"); err != nil {
+		t.Fatalf("CreateVersion() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		from, to       string
+		wantChanged    bool
+		wantLines      []DiffLine
+		wantParameters []string
+		wantRetries    []string
+	}{
+		{name: "same version", from: "v1", to: "v1"},
+		{
+			name:        "v1 to v2",
+			from:        "v1",
+			to:          "v2",
+			wantChanged: true,
+			wantLines: []DiffLine{
+				{Op: DiffDelete, Text: "        image: quay.io/tinkerbell-actions/image2disk:v1.0.0"},
+				{Op: DiffInsert, Text: "        image: quay.io/tinkerbell-actions/image2disk:v1.1.0"},
+			},
+			// 参数按名称排序，required的变化记为名称为空的一项
+			wantParameters: []string{"compressed " + ParameterAdded, "disk " + ParameterChanged, " " + ParameterChanged},
+			wantRetries:    []string{"stream-image " + ParameterAdded},
+		},
+		{
+			name:        "v2 to v1",
+			from:        "v2",
+			to:          "v1",
+			wantChanged: true,
+			wantLines: []DiffLine{
+				{Op: DiffDelete, Text: "        image: quay.io/tinkerbell-actions/image2disk:v1.1.0"},
+				{Op: DiffInsert, Text: "        image: quay.io/tinkerbell-actions/image2disk:v1.0.0"},
+			},
+			wantParameters: []string{"compressed " + ParameterRemoved, "disk " + ParameterChanged, " " + ParameterChanged},
+			wantRetries:    []string{"stream-image " + ParameterRemoved},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := l.Diff(ctx, "provision", tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if d.Changed != tt.wantChanged {
+				t.Errorf("Changed = %v, want %v", d.Changed, tt.wantChanged)
+			}
+
+			// 未变化的行原样保留，只比较变化的行
+			var lines []DiffLine
+			equal := 0
+			for _, line := range d.Lines {
+				if line.Op == DiffEqual {
+					equal++
+					continue
+				}
+				lines = append(lines, line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("changed lines = %v, want %v", lines, tt.wantLines)
+			}
+			if want := len(splitLines(base)) - len(tt.wantLines)/2; equal != want {
+				t.Errorf("equal lines = %d, want %d", equal, want)
+			}
+
+			if got := changes(d.Parameters); !reflect.DeepEqual(got, tt.wantParameters) {
+				t.Errorf("parameter changes = %v, want %v", got, tt.wantParameters)
+			}
+			if got := changes(d.Retries); !reflect.DeepEqual(got, tt.wantRetries) {
+				t.Errorf("retry changes = %v, want %v", got, tt.wantRetries)
+			}
+		})
+	}
+
+	if _, err := l.Diff(ctx, "provision", "v1", "v9"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Diff() to missing version error = %v, want %v", err, repository.ErrNotFound)
+	}
+}
+
+// changes 把变化列表转换为"<名称> <变化类型>"，没有变化时返回nil
+func changes(list []ParameterChange) []string {
+	var result []string
+	for _, c := range list {
+		result = append(result, c.Name+" "+c.Change)
+	}
+	return result
+}
+
+func TestLibraryRender(t *testing.T) {
+	// image参数允许在两个镜像之间选择，其中rootio不在允许列表中
+	content := strings.Replace(testContent("quay.io/tinkerbell-actions/image2disk:v1.0.0"),
+		"quay.io/tinkerbell-actions/image2disk:v1.0.0", "[[.image]]", 1)
+	parameters := testParameters()
+	parameters["properties"].(map[string]interface{})["image"] = map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{"quay.io/tinkerbell-actions/image2disk:v1.0.0", "quay.io/tinkerbell-actions/rootio:v1.0.0"},
+	}
+
+	tests := []struct {
+		name        string
+		params      map[string]interface{}
+		wantErr     error
+		wantContain []string
+	}{
+		{
+			name:        "defaults filled",
+			params:      map[string]interface{}{"os_image": "http://images/ubuntu.img", "image": "quay.io/tinkerbell-actions/image2disk:v1.0.0"},
+			wantContain: []string{`IMG_URL: "http://images/ubuntu.img"`, `DEST_DISK: "/dev/sda"`, `worker: "{{.device_1}}"`},
+		},
+		{
+			name:    "missing required",
+			params:  map[string]interface{}{"image": "quay.io/tinkerbell-actions/image2disk:v1.0.0"},
+			wantErr: ErrInvalidParameters,
+		},
+		{
+			name:    "wrong type",
+			params:  map[string]interface{}{"os_image": 42, "image": "quay.io/tinkerbell-actions/image2disk:v1.0.0"},
+			wantErr: ErrInvalidParameters,
+		},
+		{
+			// 参数值符合定义，但渲染出的动作不在允许列表中
+			name:    "rendered unknown action",
+			params:  map[string]interface{}{"os_image": "http://images/ubuntu.img", "image": "quay.io/tinkerbell-actions/rootio:v1.0.0"},
+			wantErr: ErrInvalidParameters,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLibrary()
+			ctx := context.Background()
+			if _, err := l.Create(ctx, &CreateRequest{Name: "provision", VersionRequest: VersionRequest{
+				Content:    content,
+				Parameters: parameters,
+			}}, "alice"); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			rendered, err := l.Render(ctx, "provision", "", tt.params)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Render() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if rendered.Version != "v1" || rendered.Params["disk"] != "/dev/sda" {
+				t.Errorf("rendered version = %s, params = %v, want v1 with default disk", rendered.Version, rendered.Params)
+			}
+			for _, want := range tt.wantContain {
+				if !strings.Contains(rendered.Content, want) {
+					t.Errorf("rendered content does not contain %q:\n%s", want, rendered.Content)
+				}
+			}
+		})
+	}
+}
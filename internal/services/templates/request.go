@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"gpu-management/internal/models"
+)
+
+// namePattern 模板名称，渲染结果以同名Tinkerbell模板CRD保存，需要符合Kubernetes资源名称
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// versionPattern 模板版本号，例如v2、1.0.0
+var versionPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+// 模板名称和版本号的最大长度，渲染后的Tinkerbell模板版本为模板版本号加内容摘要
+const (
+	maxNameLength    = 63
+	maxVersionLength = 32
+)
+
+// VersionRequest 创建模板版本请求
+type VersionRequest struct {
+	// Version 版本号，为空时按已有版本数自动递增
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	OSType      string `json:"os_type"`
+	// Content Tinkerbell模板YAML，[[ ]]括起的部分按参数渲染
+	Content string `json:"content"`
+	// Parameters 参数的JSON Schema，顶层必须是object
+	Parameters map[string]interface{} `json:"parameters"`
+	// Retries 步骤的重试策略，键为动作名称或通配符
+	Retries map[string]models.RetryPolicy `json:"retries"`
+}
+
+// Validate 校验模板版本请求，模板内容的校验由Library.Validate完成
+func (r *VersionRequest) Validate() error {
+	if r.Version != "" && (len(r.Version) > maxVersionLength || !versionPattern.MatchString(r.Version)) {
+		return fmt.Errorf("invalid version %q", r.Version)
+	}
+	if r.Content == "" {
+		return errors.New("content is required")
+	}
+	return nil
+}
+
+// CreateRequest 创建模板请求
+type CreateRequest struct {
+	Name string `json:"name"`
+	VersionRequest
+}
+
+// Validate 校验创建模板请求
+func (r *CreateRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > maxNameLength || !namePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid name %q: must be lowercase alphanumeric characters or '-'", r.Name)
+	}
+	return r.VersionRequest.Validate()
+}
+
+// ValidateRequest 校验模板请求，不保存模板
+type ValidateRequest struct {
+	Content    string                        `json:"content"`
+	Parameters map[string]interface{}        `json:"parameters"`
+	Retries    map[string]models.RetryPolicy `json:"retries"`
+}
+
+// Validate 校验请求字段
+func (r *ValidateRequest) Validate() error {
+	if r.Content == "" {
+		return errors.New("content is required")
+	}
+	return nil
+}
+
+// RenderRequest 渲染模板请求
+type RenderRequest struct {
+	// Version 模板版本，为空时使用最新版本
+	Version string                 `json:"version"`
+	Params  map[string]interface{} `json:"params"`
+}
@@ -0,0 +1,334 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/tinkerbell"
+)
+
+// 模板参数使用[[ ]]作为渲染定界符，{{.device_1}}等Tinkerbell模板变量原样保留，由Tinkerbell按硬件渲染
+const (
+	LeftDelim  = "[["
+	RightDelim = "]]"
+)
+
+// schemaURL 编译参数定义时使用的资源地址
+const schemaURL = "mem:///workflow-template/parameters.json"
+
+// Rendered 模板渲染结果
+type Rendered struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	OSType  string `json:"os_type"`
+	// Params 填充默认值后的参数
+	Params  map[string]interface{} `json:"params"`
+	Content string                 `json:"content"`
+	// Retries 模板版本的步骤重试策略
+	Retries map[string]models.RetryPolicy `json:"retries"`
+}
+
+// compiled 编译后的模板和参数定义
+type compiled struct {
+	text       *template.Template
+	schema     *jsonschema.Schema
+	properties map[string]interface{}
+}
+
+// Validate 校验模板内容、参数定义和重试策略
+// 参数定义必须是object类型的JSON Schema，模板只能引用已声明的参数；按示例参数渲染后必须是合法的Tinkerbell模板，且只使用允许的动作；
+// 每个重试策略都要匹配至少一个动作
+func (l *Library) Validate(content string, parameters map[string]interface{}, retries map[string]models.RetryPolicy) error {
+	c, err := compile(content, parameters)
+	if err != nil {
+		return err
+	}
+	sample := map[string]interface{}{}
+	for name, property := range c.properties {
+		sample[name] = sampleValue(property)
+	}
+	var rendered bytes.Buffer
+	if err := c.text.Execute(&rendered, sample); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	tasks, err := l.checkActions(rendered.String())
+	if err != nil {
+		return err
+	}
+	return checkRetries(retries, tasks)
+}
+
+// render 按参数定义校验参数并渲染模板
+func (l *Library) render(t *models.WorkflowTemplate, params map[string]interface{}) (*Rendered, error) {
+	c, err := compile(t.Content, t.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// 统一转换为JSON解码后的类型，调用方传入的[]string等类型才能按JSON Schema校验
+	values := map[string]interface{}{}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+		}
+		if err := json.Unmarshal(b, &values); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+		}
+	}
+	for name, property := range c.properties {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		if p, ok := property.(map[string]interface{}); ok {
+			if def, ok := p["default"]; ok {
+				values[name] = def
+			}
+		}
+	}
+	if err := c.schema.Validate(values); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+	}
+
+	var rendered bytes.Buffer
+	if err := c.text.Execute(&rendered, values); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+	}
+	if _, err := l.checkActions(rendered.String()); err != nil {
+		// 模板保存时已按示例参数校验，此时失败说明参数值改变了模板结构
+		return nil, fmt.Errorf("%w: rendered template is invalid: %v", ErrInvalidParameters, err)
+	}
+	return &Rendered{
+		Name:    t.Name,
+		Version: t.Version,
+		OSType:  t.OSType,
+		Params:  values,
+		Content: rendered.String(),
+		Retries: t.Retries,
+	}, nil
+}
+
+// compile 编译参数定义和模板，并检查模板引用的参数都已声明
+func compile(content string, parameters map[string]interface{}) (*compiled, error) {
+	if parameters == nil {
+		parameters = map[string]interface{}{"type": "object"}
+	}
+	if parameters["type"] != "object" {
+		return nil, fmt.Errorf("%w: parameters must be a json schema of type object", ErrInvalidTemplate)
+	}
+	properties := map[string]interface{}{}
+	if raw, ok := parameters["properties"]; ok {
+		if properties, ok = raw.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: parameters.properties must be an object", ErrInvalidTemplate)
+		}
+	}
+
+	b, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid parameters: %v", ErrInvalidTemplate, err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("%w: invalid parameters: %v", ErrInvalidTemplate, err)
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid parameters: %v", ErrInvalidTemplate, err)
+	}
+
+	text, err := template.New("workflow-template").Delims(LeftDelim, RightDelim).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	var undeclared []string
+	for _, name := range referencedParams(text.Tree) {
+		if _, ok := properties[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		return nil, fmt.Errorf("%w: template references undeclared parameters %s", ErrInvalidTemplate, strings.Join(undeclared, ", "))
+	}
+	return &compiled{text: text, schema: schema, properties: properties}, nil
+}
+
+// checkActions 解析渲染后的模板，每个动作都要有在任务内唯一的名称，且镜像仓库在允许列表中
+func (l *Library) checkActions(content string) ([]tinkerbell.WorkflowTask, error) {
+	tasks, err := tinkerbell.ParseTemplate(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	for _, task := range tasks {
+		if task.Name == "" {
+			return nil, fmt.Errorf("%w: task name is required", ErrInvalidTemplate)
+		}
+		if len(task.Actions) == 0 {
+			return nil, fmt.Errorf("%w: task %s has no actions", ErrInvalidTemplate, task.Name)
+		}
+		names := map[string]bool{}
+		for _, action := range task.Actions {
+			if action.Name == "" {
+				return nil, fmt.Errorf("%w: task %s has an action without name", ErrInvalidTemplate, task.Name)
+			}
+			if names[action.Name] {
+				return nil, fmt.Errorf("%w: task %s has duplicate action %s", ErrInvalidTemplate, task.Name, action.Name)
+			}
+			names[action.Name] = true
+			if !l.allowed[imageRepository(action.Image)] {
+				return nil, fmt.Errorf("%w: action %s uses unknown image %q", ErrInvalidTemplate, action.Name, action.Image)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// maxAttempts 重试策略允许的最大执行次数
+const maxAttempts = 10
+
+// checkRetries 校验重试策略：键是合法的通配符并匹配至少一个动作，执行次数在1到maxAttempts之间
+func checkRetries(retries map[string]models.RetryPolicy, tasks []tinkerbell.WorkflowTask) error {
+	for pattern, policy := range retries {
+		if policy.MaxAttempts < 1 || policy.MaxAttempts > maxAttempts {
+			return fmt.Errorf("%w: retry policy %s: max_attempts must be between 1 and %d", ErrInvalidTemplate, pattern, maxAttempts)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: retry policy %s: %v", ErrInvalidTemplate, pattern, err)
+		}
+		matched := false
+		for _, task := range tasks {
+			for _, action := range task.Actions {
+				if ok, _ := path.Match(pattern, action.Name); ok {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%w: retry policy %s matches no action", ErrInvalidTemplate, pattern)
+		}
+	}
+	return nil
+}
+
+// RetryPolicyFor 返回动作适用的重试策略：优先使用与动作名称相同的键，其次按键的字典序使用第一个匹配的通配符，没有时只执行一次
+func RetryPolicyFor(retries map[string]models.RetryPolicy, action string) models.RetryPolicy {
+	if policy, ok := retries[action]; ok {
+		return policy
+	}
+	patterns := make([]string, 0, len(retries))
+	for pattern := range retries {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, action); ok {
+			return retries[pattern]
+		}
+	}
+	return models.RetryPolicy{MaxAttempts: 1}
+}
+
+// imageRepository 去掉镜像引用中的摘要和标签，例如quay.io/tinkerbell-actions/cexec:v1.0.0对应quay.io/tinkerbell-actions/cexec
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// referencedParams 模板中按顶层数据引用的参数名称，range和with内部的.x引用的是元素字段，只检查$.x
+func referencedParams(tree *parse.Tree) []string {
+	seen := map[string]bool{}
+	var walk func(node parse.Node, root bool)
+	walk = func(node parse.Node, root bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, root)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, root)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd, root)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, root)
+			}
+		case *parse.FieldNode:
+			if root {
+				seen[n.Ident[0]] = true
+			}
+		case *parse.ChainNode:
+			walk(n.Node, root)
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				seen[n.Ident[1]] = true
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, root)
+			walk(n.List, root)
+			walk(n.ElseList, root)
+		case *parse.RangeNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.WithNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.TemplateNode:
+			walk(n.Pipe, root)
+		}
+	}
+	walk(tree.Root, true)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sampleValue 按参数定义生成试渲染使用的示例值：优先使用默认值和第一个枚举值，否则按类型生成
+func sampleValue(property interface{}) interface{} {
+	p, _ := property.(map[string]interface{})
+	if def, ok := p["default"]; ok {
+		return def
+	}
+	if enum, ok := p["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	switch p["type"] {
+	case "array":
+		return []interface{}{sampleValue(p["items"])}
+	case "object":
+		return map[string]interface{}{}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return "sample"
+	}
+}
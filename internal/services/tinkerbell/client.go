@@ -0,0 +1,323 @@
+package tinkerbell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gpu-management/internal/models"
+)
+
+// HardwareParams 创建Hardware需要的、服务器模型之外的装机参数
+type HardwareParams struct {
+	// MAC 网络启动网卡的MAC地址，为空时不改写CR中已有的MAC
+	MAC string
+	// Disks 系统盘设备，为空时不改写CR中已有的硬盘
+	Disks []string
+}
+
+// Client 类型化的Tinkerbell客户端，在KubeClient之上读写Hardware、Template和Workflow
+type Client struct {
+	kube KubeClient
+}
+
+// NewClient 创建Tinkerbell客户端
+func NewClient(kube KubeClient) *Client {
+	return &Client{kube: kube}
+}
+
+// Kube 返回底层的KubeClient
+func (c *Client) Kube() KubeClient {
+	return c.kube
+}
+
+// ApplyHardware 按服务器创建或更新Hardware
+// 更新时只改写主机名、管理IP、实例信息以及params中给出的字段，其他字段保留在Tinkerbell中的值
+func (c *Client) ApplyHardware(ctx context.Context, name string, server *models.Server, params HardwareParams) (*Object, error) {
+	existing, err := c.kube.Get(ctx, ResourceHardware, name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	var spec map[string]interface{}
+	if existing != nil {
+		if spec, err = decodeSpecMap(existing.Spec); err != nil {
+			return nil, fmt.Errorf("hardware %s: %w", name, err)
+		}
+	} else {
+		spec = map[string]interface{}{}
+	}
+
+	iface := firstInterface(spec)
+	dhcp := childMap(iface, "dhcp")
+	dhcp["hostname"] = server.Name
+	if params.MAC != "" {
+		dhcp["mac"] = strings.ToLower(params.MAC)
+	}
+	if server.ManagementIP != "" {
+		childMap(dhcp, "ip")["address"] = server.ManagementIP
+	}
+	if existing == nil {
+		iface["netboot"] = map[string]interface{}{"allowPXE": true, "allowWorkflow": true}
+	}
+	instance := childMap(childMap(spec, "metadata"), "instance")
+	instance["id"] = server.ID
+	instance["hostname"] = server.Name
+	if len(params.Disks) > 0 {
+		disks := make([]interface{}, 0, len(params.Disks))
+		for _, device := range params.Disks {
+			disks = append(disks, map[string]interface{}{"device": device})
+		}
+		spec["disks"] = disks
+	}
+
+	labels := map[string]string{LabelManagedBy: ManagedBy, LabelServerID: server.ID}
+	return c.apply(ctx, ResourceHardware, name, existing, labels, spec)
+}
+
+// ApplyTemplate 创建或更新Template
+func (c *Client) ApplyTemplate(ctx context.Context, name, templateID, data string) (*Object, error) {
+	existing, err := c.kube.Get(ctx, ResourceTemplates, name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	labels := map[string]string{LabelManagedBy: ManagedBy, LabelTemplateID: templateID}
+	return c.apply(ctx, ResourceTemplates, name, existing, labels, TemplateSpec{Data: data})
+}
+
+// SubmitWorkflow 提交工作流，工作流创建后spec不再修改，同名工作流已存在时返回ErrConflict
+func (c *Client) SubmitWorkflow(ctx context.Context, name, workflowID string, spec WorkflowSpec) (*Object, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	return c.kube.Create(ctx, ResourceWorkflows, &Object{
+		Metadata: ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{LabelManagedBy: ManagedBy, LabelWorkflowID: workflowID},
+		},
+		Spec: data,
+	})
+}
+
+// GetWorkflow 查询工作流及其执行状态
+func (c *Client) GetWorkflow(ctx context.Context, name string) (*Object, *WorkflowStatus, error) {
+	obj, err := c.kube.Get(ctx, ResourceWorkflows, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	status, err := DecodeWorkflowStatus(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	return obj, status, nil
+}
+
+// List 按标签选择器查询对象
+func (c *Client) List(ctx context.Context, resource Resource, selector string) ([]Object, error) {
+	return c.kube.List(ctx, resource, selector)
+}
+
+// Get 查询对象
+func (c *Client) Get(ctx context.Context, resource Resource, name string) (*Object, error) {
+	return c.kube.Get(ctx, resource, name)
+}
+
+// Adopt 给未由本系统管理的对象加上管理标签
+func (c *Client) Adopt(ctx context.Context, resource Resource, obj *Object, labels map[string]string) (*Object, error) {
+	updated := *cloneObject(*obj)
+	if updated.Metadata.Labels == nil {
+		updated.Metadata.Labels = map[string]string{}
+	}
+	updated.Metadata.Labels[LabelManagedBy] = ManagedBy
+	for k, v := range labels {
+		updated.Metadata.Labels[k] = v
+	}
+	return c.kube.Update(ctx, resource, &updated)
+}
+
+// Delete 删除对象，对象不存在时视为成功
+func (c *Client) Delete(ctx context.Context, resource Resource, name string) error {
+	if err := c.kube.Delete(ctx, resource, name); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// apply 对象不存在时创建，存在时在spec或标签变化时更新
+func (c *Client) apply(ctx context.Context, resource Resource, name string, existing *Object, labels map[string]string, spec interface{}) (*Object, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		return c.kube.Create(ctx, resource, &Object{
+			Metadata: ObjectMeta{Name: name, Labels: labels},
+			Spec:     data,
+		})
+	}
+
+	updated := *cloneObject(*existing)
+	if updated.Metadata.Labels == nil {
+		updated.Metadata.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		updated.Metadata.Labels[k] = v
+	}
+	updated.Spec = data
+	if reflect.DeepEqual(updated.Metadata.Labels, existing.Metadata.Labels) && jsonEqual(updated.Spec, existing.Spec) {
+		return existing, nil
+	}
+	return c.kube.Update(ctx, resource, &updated)
+}
+
+// DecodeHardware 解析Hardware的spec和status
+func DecodeHardware(obj *Object) (*HardwareSpec, *HardwareStatus, error) {
+	spec := &HardwareSpec{}
+	status := &HardwareStatus{}
+	if err := decodeRaw(obj.Spec, spec); err != nil {
+		return nil, nil, fmt.Errorf("hardware %s: invalid spec: %w", obj.Metadata.Name, err)
+	}
+	if err := decodeRaw(obj.Status, status); err != nil {
+		return nil, nil, fmt.Errorf("hardware %s: invalid status: %w", obj.Metadata.Name, err)
+	}
+	return spec, status, nil
+}
+
+// DecodeTemplate 解析Template的spec
+func DecodeTemplate(obj *Object) (*TemplateSpec, error) {
+	spec := &TemplateSpec{}
+	if err := decodeRaw(obj.Spec, spec); err != nil {
+		return nil, fmt.Errorf("template %s: invalid spec: %w", obj.Metadata.Name, err)
+	}
+	return spec, nil
+}
+
+// DecodeWorkflow 解析Workflow的spec
+func DecodeWorkflow(obj *Object) (*WorkflowSpec, error) {
+	spec := &WorkflowSpec{}
+	if err := decodeRaw(obj.Spec, spec); err != nil {
+		return nil, fmt.Errorf("workflow %s: invalid spec: %w", obj.Metadata.Name, err)
+	}
+	return spec, nil
+}
+
+// DecodeWorkflowStatus 解析Workflow的status，控制器尚未处理时返回STATE_PENDING
+func DecodeWorkflowStatus(obj *Object) (*WorkflowStatus, error) {
+	status := &WorkflowStatus{}
+	if err := decodeRaw(obj.Status, status); err != nil {
+		return nil, fmt.Errorf("workflow %s: invalid status: %w", obj.Metadata.Name, err)
+	}
+	if status.State == "" {
+		status.State = StatePending
+	}
+	return status, nil
+}
+
+// MAC 返回第一块网卡的MAC地址
+func (s *HardwareSpec) MAC() string {
+	for _, iface := range s.Interfaces {
+		if iface.DHCP != nil && iface.DHCP.MAC != "" {
+			return iface.DHCP.MAC
+		}
+	}
+	return ""
+}
+
+// Hostname 返回第一块网卡的主机名，没有时返回实例主机名
+func (s *HardwareSpec) Hostname() string {
+	for _, iface := range s.Interfaces {
+		if iface.DHCP != nil && iface.DHCP.Hostname != "" {
+			return iface.DHCP.Hostname
+		}
+	}
+	if s.Metadata != nil && s.Metadata.Instance != nil {
+		return s.Metadata.Instance.Hostname
+	}
+	return ""
+}
+
+// IPAddress 返回第一块网卡的IP地址
+func (s *HardwareSpec) IPAddress() string {
+	for _, iface := range s.Interfaces {
+		if iface.DHCP != nil && iface.DHCP.IP != nil && iface.DHCP.IP.Address != "" {
+			return iface.DHCP.IP.Address
+		}
+	}
+	return ""
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ObjectName 把任意字符串转换为合法的Kubernetes对象名（DNS-1123标签），转换结果为空时返回fallback
+func ObjectName(s, fallback string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(s), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// decodeRaw 解析原始JSON，为空时不做处理
+func decodeRaw(raw json.RawMessage, out interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// decodeSpecMap 把spec解析为map，保留本系统未声明的字段
+func decodeSpecMap(raw json.RawMessage) (map[string]interface{}, error) {
+	spec := map[string]interface{}{}
+	if err := decodeRaw(raw, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	return spec, nil
+}
+
+// childMap 返回m[key]，不存在或不是对象时创建
+func childMap(m map[string]interface{}, key string) map[string]interface{} {
+	if child, ok := m[key].(map[string]interface{}); ok {
+		return child
+	}
+	child := map[string]interface{}{}
+	m[key] = child
+	return child
+}
+
+// firstInterface 返回spec.interfaces[0]，不存在时创建
+func firstInterface(spec map[string]interface{}) map[string]interface{} {
+	interfaces, _ := spec["interfaces"].([]interface{})
+	if len(interfaces) > 0 {
+		if iface, ok := interfaces[0].(map[string]interface{}); ok {
+			return iface
+		}
+	}
+	iface := map[string]interface{}{}
+	spec["interfaces"] = append([]interface{}{iface}, interfaces...)
+	return iface
+}
+
+// jsonEqual 判断两段JSON语义是否相同
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if err := decodeRaw(a, &va); err != nil {
+		return false
+	}
+	if err := decodeRaw(b, &vb); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,434 @@
+package tinkerbell
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"gpu-management/pkg/uuid"
+)
+
+// FakeConfig 内存客户端配置
+type FakeConfig struct {
+	// AutoRun 模拟Tinkerbell执行工作流：按模板中的动作依次执行，每个动作耗时ActionDuration
+	AutoRun        bool
+	ActionDuration time.Duration
+}
+
+// fakeRun 自动执行的工作流
+type fakeRun struct {
+	started    time.Time
+	failAction string
+}
+
+// FakeClient 内存中的KubeClient实现，用于离线模式和测试
+// 语义与API Server一致：创建时分配uid和resourceVersion，更新时校验resourceVersion
+type FakeClient struct {
+	config FakeConfig
+
+	mu      sync.Mutex
+	objects map[Resource]map[string]Object
+	version int64
+	runs    map[string]*fakeRun
+}
+
+// NewFakeClient 创建内存客户端
+func NewFakeClient(config FakeConfig) *FakeClient {
+	if config.ActionDuration <= 0 {
+		config.ActionDuration = 2 * time.Second
+	}
+	return &FakeClient{
+		config: config,
+		objects: map[Resource]map[string]Object{
+			ResourceHardware:  {},
+			ResourceTemplates: {},
+			ResourceWorkflows: {},
+		},
+		runs: map[string]*fakeRun{},
+	}
+}
+
+// Seed 直接写入对象，用于模拟在Tinkerbell中由其他途径创建的对象
+func (f *FakeClient) Seed(resource Resource, obj Object) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(resource, obj)
+}
+
+// SetStatus 写入对象的status，用于模拟Tinkerbell控制器，写入后该工作流不再自动执行
+func (f *FakeClient) SetStatus(resource Resource, name string, status interface{}) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[resource][name]
+	if !ok {
+		return ErrNotFound
+	}
+	obj.Status = data
+	f.bump(&obj)
+	f.objects[resource][name] = obj
+	delete(f.runs, name)
+	return nil
+}
+
+// FailAction 自动执行工作流时让指定动作失败
+func (f *FakeClient) FailAction(workflow, action string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	run, ok := f.runs[workflow]
+	if !ok {
+		return ErrNotFound
+	}
+	run.failAction = action
+	return nil
+}
+
+// Get 查询对象
+func (f *FakeClient) Get(ctx context.Context, resource Resource, name string) (*Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[resource][name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	obj = f.progress(resource, obj)
+	return cloneObject(obj), nil
+}
+
+// List 按标签选择器查询对象，按名称排序
+func (f *FakeClient) List(ctx context.Context, resource Resource, selector string) ([]Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	match, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objects := []Object{}
+	for _, obj := range f.objects[resource] {
+		if !match(obj.Metadata.Labels) {
+			continue
+		}
+		obj = f.progress(resource, obj)
+		objects = append(objects, *cloneObject(obj))
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Metadata.Name < objects[j].Metadata.Name })
+	return objects, nil
+}
+
+// Create 创建对象，忽略传入的status
+func (f *FakeClient) Create(ctx context.Context, resource Resource, obj *Object) (*Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if obj.Metadata.Name == "" {
+		return nil, &APIError{StatusCode: 422, Reason: "Invalid", Message: "metadata.name is required"}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.objects[resource][obj.Metadata.Name]; exists {
+		return nil, fmt.Errorf("%w: %s %q already exists", ErrConflict, resource, obj.Metadata.Name)
+	}
+	created := *cloneObject(*obj)
+	created.Status = nil
+	f.store(resource, created)
+	if resource == ResourceWorkflows && f.config.AutoRun {
+		f.runs[created.Metadata.Name] = &fakeRun{started: time.Now().UTC()}
+	}
+	return cloneObject(f.objects[resource][created.Metadata.Name]), nil
+}
+
+// Update 更新对象的元数据和spec，保留status
+func (f *FakeClient) Update(ctx context.Context, resource Resource, obj *Object) (*Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, ok := f.objects[resource][obj.Metadata.Name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if obj.Metadata.ResourceVersion != "" && obj.Metadata.ResourceVersion != existing.Metadata.ResourceVersion {
+		return nil, fmt.Errorf("%w: %s %q has been modified", ErrConflict, resource, obj.Metadata.Name)
+	}
+
+	updated := *cloneObject(*obj)
+	updated.Metadata.UID = existing.Metadata.UID
+	updated.Metadata.CreationTimestamp = existing.Metadata.CreationTimestamp
+	updated.Status = existing.Status
+	f.bump(&updated)
+	f.objects[resource][updated.Metadata.Name] = updated
+	return cloneObject(updated), nil
+}
+
+// Delete 删除对象
+func (f *FakeClient) Delete(ctx context.Context, resource Resource, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.objects[resource][name]; !ok {
+		return ErrNotFound
+	}
+	delete(f.objects[resource], name)
+	if resource == ResourceWorkflows {
+		delete(f.runs, name)
+	}
+	return nil
+}
+
+// store 补全元数据后写入，调用方需持有锁
+func (f *FakeClient) store(resource Resource, obj Object) {
+	obj.APIVersion = APIVersion
+	obj.Kind = resource.Kind()
+	if obj.Metadata.UID == "" {
+		obj.Metadata.UID = uuid.New()
+	}
+	if obj.Metadata.CreationTimestamp == nil {
+		now := time.Now().UTC().Truncate(time.Second)
+		obj.Metadata.CreationTimestamp = &now
+	}
+	f.bump(&obj)
+	f.objects[resource][obj.Metadata.Name] = obj
+}
+
+// bump 分配新的resourceVersion，调用方需持有锁
+func (f *FakeClient) bump(obj *Object) {
+	f.version++
+	obj.Metadata.ResourceVersion = strconv.FormatInt(f.version, 10)
+}
+
+// progress 按经过的时间推进自动执行的工作流，调用方需持有锁
+func (f *FakeClient) progress(resource Resource, obj Object) Object {
+	if resource != ResourceWorkflows {
+		return obj
+	}
+	run, ok := f.runs[obj.Metadata.Name]
+	if !ok {
+		return obj
+	}
+
+	status := f.simulate(obj, run)
+	data, err := json.Marshal(status)
+	if err != nil || bytes.Equal(data, obj.Status) {
+		return obj
+	}
+	obj.Status = data
+	f.bump(&obj)
+	f.objects[resource][obj.Metadata.Name] = obj
+	if status.Terminal() {
+		delete(f.runs, obj.Metadata.Name)
+	}
+	return obj
+}
+
+// simulate 计算自动执行的工作流当前的状态，模板不存在或无法解析时保持等待
+func (f *FakeClient) simulate(obj Object, run *fakeRun) *WorkflowStatus {
+	pending := &WorkflowStatus{State: StatePending}
+
+	var spec WorkflowSpec
+	if err := json.Unmarshal(obj.Spec, &spec); err != nil {
+		return pending
+	}
+	tmpl, ok := f.objects[ResourceTemplates][spec.TemplateRef]
+	if !ok {
+		return pending
+	}
+	var tspec TemplateSpec
+	if err := json.Unmarshal(tmpl.Spec, &tspec); err != nil {
+		return pending
+	}
+	status, err := parseWorkflowTemplate(tspec.Data, spec.HardwareMap)
+	if err != nil {
+		return pending
+	}
+
+	// 动作依次执行，每个动作耗时ActionDuration
+	elapsed := time.Since(run.started)
+	step := int64(f.config.ActionDuration / time.Second)
+	index := 0
+	status.State = StateRunning
+	for i := range status.Tasks {
+		for j := range status.Tasks[i].Actions {
+			action := &status.Tasks[i].Actions[j]
+			begin := time.Duration(index) * f.config.ActionDuration
+			index++
+			if elapsed < begin {
+				action.Status = StatePending
+				continue
+			}
+			startedAt := run.started.Add(begin).Truncate(time.Second)
+			action.StartedAt = &startedAt
+			if elapsed < begin+f.config.ActionDuration {
+				action.Status = StateRunning
+				return status
+			}
+			action.Seconds = step
+			if action.Name == run.failAction {
+				action.Status = StateFailed
+				action.Message = "action exited with status 1"
+				status.State = StateFailed
+				return status
+			}
+			action.Status = StateSuccess
+		}
+	}
+	status.State = StateSuccess
+	return status
+}
+
+// templateDocument Tinkerbell模板YAML中执行需要的字段
+type templateDocument struct {
+	GlobalTimeout int64 `yaml:"global_timeout"`
+	Tasks         []struct {
+		Name        string            `yaml:"name"`
+		Worker      string            `yaml:"worker"`
+		Environment map[string]string `yaml:"environment"`
+		Actions     []struct {
+			Name    string `yaml:"name"`
+			Image   string `yaml:"image"`
+			Timeout int64  `yaml:"timeout"`
+		} `yaml:"actions"`
+	} `yaml:"tasks"`
+}
+
+// parseWorkflowTemplate 用hardwareMap渲染模板并生成所有动作都处于等待状态的工作流状态
+func parseWorkflowTemplate(data string, hardwareMap map[string]string) (*WorkflowStatus, error) {
+	t, err := template.New("workflow").Option("missingkey=zero").Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	if err := t.Execute(&rendered, hardwareMap); err != nil {
+		return nil, err
+	}
+
+	var doc templateDocument
+	if err := yaml.Unmarshal(rendered.Bytes(), &doc); err != nil {
+		return nil, err
+	}
+	status := &WorkflowStatus{State: StatePending, GlobalTimeout: doc.GlobalTimeout}
+	for _, task := range doc.Tasks {
+		wt := WorkflowTask{Name: task.Name, WorkerAddr: task.Worker, Environment: task.Environment}
+		for _, action := range task.Actions {
+			wt.Actions = append(wt.Actions, WorkflowAction{
+				Name:    action.Name,
+				Image:   action.Image,
+				Timeout: action.Timeout,
+				Status:  StatePending,
+			})
+		}
+		status.Tasks = append(status.Tasks, wt)
+	}
+	return status, nil
+}
+
+// parseSelector 解析等值标签选择器，例如 a=b,c!=d,e
+func parseSelector(selector string) (func(map[string]string) bool, error) {
+	type requirement struct {
+		key, value string
+		op         string
+	}
+	var reqs []requirement
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			reqs = append(reqs, requirement{strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]), "!="})
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(strings.Replace(part, "==", "=", 1), "=", 2)
+			reqs = append(reqs, requirement{strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]), "="})
+		case strings.HasPrefix(part, "!"):
+			reqs = append(reqs, requirement{key: strings.TrimPrefix(part, "!"), op: "!"})
+		default:
+			reqs = append(reqs, requirement{key: part, op: "exists"})
+		}
+	}
+
+	return func(labels map[string]string) bool {
+		for _, r := range reqs {
+			value, ok := labels[r.key]
+			switch r.op {
+			case "=":
+				if !ok || value != r.value {
+					return false
+				}
+			case "!=":
+				if ok && value == r.value {
+					return false
+				}
+			case "!":
+				if ok {
+					return false
+				}
+			case "exists":
+				if !ok {
+					return false
+				}
+			}
+		}
+		return true
+	}, nil
+}
+
+// cloneObject 深复制对象，避免调用方修改内部数据
+func cloneObject(obj Object) *Object {
+	out := obj
+	out.Metadata.Labels = cloneLabels(obj.Metadata.Labels)
+	out.Metadata.Annotations = cloneLabels(obj.Metadata.Annotations)
+	out.Spec = append(json.RawMessage(nil), obj.Spec...)
+	out.Status = append(json.RawMessage(nil), obj.Status...)
+	if obj.Metadata.CreationTimestamp != nil {
+		ts := *obj.Metadata.CreationTimestamp
+		out.Metadata.CreationTimestamp = &ts
+	}
+	return &out
+}
+
+// cloneLabels 复制标签
+func cloneLabels(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package tinkerbell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Tinkerbell CRD所在的API组和版本
+const (
+	Group      = "tinkerbell.org"
+	Version    = "v1alpha1"
+	APIVersion = Group + "/" + Version
+)
+
+// Resource Tinkerbell CRD资源类型，即URL中的复数资源名
+type Resource string
+
+// Tinkerbell CRD资源类型
+const (
+	ResourceHardware  Resource = "hardware"
+	ResourceTemplates Resource = "templates"
+	ResourceWorkflows Resource = "workflows"
+)
+
+// Kind 返回资源对应的Kind
+func (r Resource) Kind() string {
+	switch r {
+	case ResourceHardware:
+		return "Hardware"
+	case ResourceTemplates:
+		return "Template"
+	case ResourceWorkflows:
+		return "Workflow"
+	}
+	return string(r)
+}
+
+// Kubernetes客户端错误
+var (
+	// ErrNotFound 资源不存在
+	ErrNotFound = errors.New("tinkerbell: resource not found")
+	// ErrConflict 资源已存在，或更新时resourceVersion已过期
+	ErrConflict = errors.New("tinkerbell: resource conflict")
+	// ErrNotConfigured 未配置Kubernetes API访问方式
+	ErrNotConfigured = errors.New("tinkerbell: kubernetes api is not configured")
+)
+
+// ObjectMeta Kubernetes对象元数据，只保留同步用到的字段
+type ObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	ResourceVersion   string            `json:"resourceVersion,omitempty"`
+	UID               string            `json:"uid,omitempty"`
+	CreationTimestamp *time.Time        `json:"creationTimestamp,omitempty"`
+}
+
+// Object 未解析spec和status的CRD对象
+// spec保留原始JSON，更新时只改写本系统管理的字段，其他字段原样写回
+type Object struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Metadata   ObjectMeta      `json:"metadata"`
+	Spec       json.RawMessage `json:"spec,omitempty"`
+	Status     json.RawMessage `json:"status,omitempty"`
+}
+
+// objectList Kubernetes列表响应
+type objectList struct {
+	Items []Object `json:"items"`
+}
+
+// KubeClient 访问Tinkerbell CRD的Kubernetes客户端接口
+// 在线模式使用RESTClient访问API Server，离线模式和测试使用FakeClient
+type KubeClient interface {
+	// Get 查询对象，不存在时返回ErrNotFound
+	Get(ctx context.Context, resource Resource, name string) (*Object, error)
+	// List 按标签选择器查询对象，selector为空时返回全部，格式与kubectl -l相同，例如 a=b,c=d
+	List(ctx context.Context, resource Resource, selector string) ([]Object, error)
+	// Create 创建对象，同名对象已存在时返回ErrConflict
+	Create(ctx context.Context, resource Resource, obj *Object) (*Object, error)
+	// Update 更新对象的元数据和spec，resourceVersion与当前版本不一致时返回ErrConflict
+	Update(ctx context.Context, resource Resource, obj *Object) (*Object, error)
+	// Delete 删除对象，不存在时返回ErrNotFound
+	Delete(ctx context.Context, resource Resource, name string) error
+}
+
+// NotConfigured 未配置Kubernetes API时使用的客户端，所有操作返回ErrNotConfigured
+var NotConfigured KubeClient = notConfiguredClient{}
+
+type notConfiguredClient struct{}
+
+func (notConfiguredClient) Get(context.Context, Resource, string) (*Object, error) {
+	return nil, ErrNotConfigured
+}
+
+func (notConfiguredClient) List(context.Context, Resource, string) ([]Object, error) {
+	return nil, ErrNotConfigured
+}
+
+func (notConfiguredClient) Create(context.Context, Resource, *Object) (*Object, error) {
+	return nil, ErrNotConfigured
+}
+
+func (notConfiguredClient) Update(context.Context, Resource, *Object) (*Object, error) {
+	return nil, ErrNotConfigured
+}
+
+func (notConfiguredClient) Delete(context.Context, Resource, string) error {
+	return ErrNotConfigured
+}
@@ -0,0 +1,843 @@
+package tinkerbell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/outbox"
+)
+
+// eventSource 同步导入服务器时发布事件的来源
+const eventSource = "tinkerbell-reconciler"
+
+// 硬件CRD映射的Metadata中记录的字段
+const (
+	MetadataMAC             = "mac"
+	MetadataDisks           = "disks"
+	MetadataState           = "state"
+	MetadataResourceVersion = "resource_version"
+	MetadataError           = "error"
+)
+
+// Config Tinkerbell同步配置
+type Config struct {
+	// SyncInterval 两次同步的间隔
+	SyncInterval time.Duration
+}
+
+// SyncCounts 一类资源在一次同步中的变化数量
+type SyncCounts struct {
+	// Created 本系统在Tinkerbell中创建的对象
+	Created int `json:"created"`
+	// Updated 双向更新的对象
+	Updated int `json:"updated"`
+	// Adopted 接管的Tinkerbell中已有对象
+	Adopted int `json:"adopted"`
+	// Imported 从Tinkerbell导入为本系统记录的对象
+	Imported int `json:"imported"`
+	// Deleted 本系统记录已删除而从Tinkerbell中删除的对象
+	Deleted int `json:"deleted"`
+}
+
+// SyncResult 一次同步的结果，单个对象同步失败不中断同步，错误记录在Errors中
+type SyncResult struct {
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	Hardware   SyncCounts `json:"hardware"`
+	Templates  SyncCounts `json:"templates"`
+	Workflows  SyncCounts `json:"workflows"`
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+// errorf 记录单个对象的同步错误
+func (r *SyncResult) errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
+
+// Reconciler 在本系统的数据库记录和Tinkerbell CRD之间双向同步
+//
+//	服务器 -> Hardware：为每台服务器创建或更新Hardware，Tinkerbell中未管理的Hardware按主机名接管或导入为新发现的服务器
+//	模板   -> Template：数据库中的模板内容为准，Tinkerbell中未管理的Template导入为模板
+//	工作流 <-> Workflow：提交待执行的工作流，按Workflow的执行状态更新工作流状态和步骤
+//
+// 本系统管理的对象带有LabelManagedBy标签，记录删除后对应的对象随之删除
+type Reconciler struct {
+	repos  *repository.Repositories
+	client *Client
+	config Config
+
+	// mu 串行化同步，定时同步和手动触发的同步不会同时执行
+	mu   sync.Mutex
+	last *SyncResult
+	// trigger 请求后台立即同步一次，缓冲为1，多次请求合并
+	trigger chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReconciler 创建同步器，调用Start后定期同步
+func NewReconciler(repos *repository.Repositories, client *Client, config Config) *Reconciler {
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = 30 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Reconciler{
+		repos:   repos,
+		client:  client,
+		config:  config,
+		trigger: make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start 启动后台同步
+func (r *Reconciler) Start() {
+	r.wg.Add(1)
+	go r.loop()
+}
+
+// Close 停止后台同步并等待进行中的同步结束
+func (r *Reconciler) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Trigger 请求后台尽快同步一次，不等待同步完成
+func (r *Reconciler) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Client 返回Tinkerbell客户端
+func (r *Reconciler) Client() *Client {
+	return r.client
+}
+
+// LastResult 返回最近一次同步的结果，尚未同步时返回nil
+func (r *Reconciler) LastResult() *SyncResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+func (r *Reconciler) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Reconcile(r.ctx); err != nil && r.ctx.Err() == nil {
+			log.Printf("Tinkerbell sync failed: %v", err)
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+// Reconcile 执行一次完整同步，依次同步硬件、模板和工作流
+// 读取数据库或Tinkerbell失败时返回错误，单个对象的失败记录在结果中
+func (r *Reconciler) Reconcile(ctx context.Context) (*SyncResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &SyncResult{StartedAt: time.Now().UTC()}
+	steps := []func(context.Context, *SyncResult) error{r.syncHardware, r.syncTemplates, r.syncWorkflows}
+	for _, step := range steps {
+		if err := step(ctx, result); err != nil {
+			return nil, err
+		}
+	}
+	result.FinishedAt = time.Now().UTC()
+	for _, msg := range result.Errors {
+		log.Printf("Tinkerbell sync: %s", msg)
+	}
+	r.last = result
+	return result, nil
+}
+
+// syncHardware 同步服务器和Hardware
+func (r *Reconciler) syncHardware(ctx context.Context, result *SyncResult) error {
+	servers, err := r.repos.Servers.List(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := r.repos.HardwareCRDs.List(ctx)
+	if err != nil {
+		return err
+	}
+	objects, err := r.client.List(ctx, ResourceHardware, "")
+	if err != nil {
+		return fmt.Errorf("failed to list tinkerbell hardware: %w", err)
+	}
+
+	rowsByServer := make(map[string]*models.HardwareCRD, len(rows))
+	for i := range rows {
+		rowsByServer[rows[i].ServerID] = &rows[i]
+	}
+	serverIDs := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		serverIDs[server.ID] = true
+	}
+	objectsByName := make(map[string]*Object, len(objects))
+	for i := range objects {
+		objectsByName[objects[i].Metadata.Name] = &objects[i]
+	}
+	claimed := map[string]bool{}
+
+	for i := range servers {
+		server := &servers[i]
+		row := rowsByServer[server.ID]
+		name, adopted := r.hardwareName(server, row, objectsByName, claimed)
+		claimed[name] = true
+
+		if err := r.applyHardware(ctx, server, row, name, objectsByName[name], adopted, result); err != nil {
+			result.errorf("hardware for server %s: %v", server.ID, err)
+		}
+	}
+
+	for i := range objects {
+		obj := &objects[i]
+		if claimed[obj.Metadata.Name] {
+			continue
+		}
+		if isManaged(obj) {
+			// 服务器已删除，对应的Hardware随之删除
+			serverID := obj.Metadata.Labels[LabelServerID]
+			if serverID != "" && !serverIDs[serverID] {
+				if err := r.client.Delete(ctx, ResourceHardware, obj.Metadata.Name); err != nil {
+					result.errorf("delete hardware %s: %v", obj.Metadata.Name, err)
+					continue
+				}
+				result.Hardware.Deleted++
+			}
+			continue
+		}
+		if err := r.importHardware(ctx, obj); err != nil {
+			result.errorf("import hardware %s: %v", obj.Metadata.Name, err)
+			continue
+		}
+		result.Hardware.Imported++
+	}
+	return nil
+}
+
+// hardwareName 确定服务器对应的Hardware名称
+// 已有映射时沿用，否则接管主机名与服务器名称相同的未管理Hardware，都没有时按服务器名称生成
+func (r *Reconciler) hardwareName(server *models.Server, row *models.HardwareCRD, objects map[string]*Object, claimed map[string]bool) (string, bool) {
+	if row != nil && row.TinkerbellID != "" {
+		return row.TinkerbellID, false
+	}
+	if server.TinkerbellHardwareID != "" {
+		return server.TinkerbellHardwareID, false
+	}
+
+	for name, obj := range objects {
+		if claimed[name] || isManaged(obj) {
+			continue
+		}
+		spec, _, err := DecodeHardware(obj)
+		if err != nil {
+			continue
+		}
+		if name == server.Name || spec.Hostname() == server.Name {
+			return name, true
+		}
+	}
+
+	name := ObjectName(server.Name, server.ID)
+	if obj, exists := objects[name]; claimed[name] || (exists && obj.Metadata.Labels[LabelServerID] != server.ID) {
+		name = server.ID
+	}
+	return name, false
+}
+
+// applyHardware 创建或更新Hardware，把Tinkerbell中的MAC和状态写回映射记录
+func (r *Reconciler) applyHardware(ctx context.Context, server *models.Server, row *models.HardwareCRD, name string, existing *Object, adopted bool, result *SyncResult) error {
+	if row == nil {
+		row = &models.HardwareCRD{ServerID: server.ID, Status: models.HardwareCRDStatusPending}
+	}
+	original := *row
+	original.Metadata = cloneMetadata(row.Metadata)
+	row.Metadata = cloneMetadata(row.Metadata)
+	row.TinkerbellID = name
+
+	obj, applyErr := r.client.ApplyHardware(ctx, name, server, hardwareParams(row))
+	if applyErr == nil {
+		spec, status, err := DecodeHardware(obj)
+		if err != nil {
+			applyErr = err
+		} else {
+			row.Status = models.HardwareCRDStatusSynced
+			row.Metadata[MetadataState] = status.State
+			row.Metadata[MetadataResourceVersion] = obj.Metadata.ResourceVersion
+			delete(row.Metadata, MetadataError)
+			// MAC在Tinkerbell中修改时以Tinkerbell为准
+			if mac := spec.MAC(); mac != "" {
+				row.Metadata[MetadataMAC] = mac
+			}
+		}
+	}
+	if applyErr != nil {
+		row.Status = models.HardwareCRDStatusError
+		row.Metadata[MetadataError] = applyErr.Error()
+	}
+
+	switch {
+	case applyErr != nil:
+	case adopted:
+		result.Hardware.Adopted++
+	case existing == nil:
+		result.Hardware.Created++
+	case existing.Metadata.ResourceVersion != obj.Metadata.ResourceVersion:
+		result.Hardware.Updated++
+	}
+
+	if row.ID == "" {
+		if err := r.repos.HardwareCRDs.Create(ctx, row); err != nil {
+			return err
+		}
+	} else if row.TinkerbellID != original.TinkerbellID || row.Status != original.Status || !metadataEqual(row.Metadata, original.Metadata) {
+		if err := r.repos.HardwareCRDs.Update(ctx, row); err != nil {
+			return err
+		}
+	}
+	if applyErr != nil {
+		return applyErr
+	}
+
+	if server.TinkerbellHardwareID != name {
+		server.TinkerbellHardwareID = name
+		if err := r.repos.Servers.Update(ctx, server); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importHardware 把Tinkerbell中未管理的Hardware导入为新发现的服务器，并发布硬件发现事件
+func (r *Reconciler) importHardware(ctx context.Context, obj *Object) error {
+	spec, status, err := DecodeHardware(obj)
+	if err != nil {
+		return err
+	}
+	name := spec.Hostname()
+	if name == "" {
+		name = obj.Metadata.Name
+	}
+
+	server := &models.Server{
+		Name:                 name,
+		Status:               models.ServerStatusDiscovered,
+		ManagementIP:         spec.IPAddress(),
+		TinkerbellHardwareID: obj.Metadata.Name,
+	}
+	row := &models.HardwareCRD{
+		TinkerbellID: obj.Metadata.Name,
+		Status:       models.HardwareCRDStatusSynced,
+		Metadata: map[string]interface{}{
+			MetadataMAC:   spec.MAC(),
+			MetadataState: status.State,
+		},
+	}
+
+	err = r.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Servers.Create(ctx, server); err != nil {
+			return err
+		}
+		row.ServerID = server.ID
+		if err := tx.HardwareCRDs.Create(ctx, row); err != nil {
+			return err
+		}
+		evt, err := event.NewCloudEvent(eventSource, event.EventTypeHardwareDiscovered, server.ID, event.HardwareEvent{
+			HardwareID: server.ID,
+			Status:     server.Status,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Enqueue(ctx, tx.Outbox, evt)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 接管失败时下次同步按TinkerbellHardwareID找到该Hardware并补上标签
+	adopted, err := r.client.Adopt(ctx, ResourceHardware, obj, map[string]string{LabelServerID: server.ID})
+	if err != nil {
+		return err
+	}
+	row.Metadata[MetadataResourceVersion] = adopted.Metadata.ResourceVersion
+	return r.repos.HardwareCRDs.Update(ctx, row)
+}
+
+// syncTemplates 同步模板和Template，数据库中的模板内容为准
+func (r *Reconciler) syncTemplates(ctx context.Context, result *SyncResult) error {
+	rows, err := r.repos.TemplateCRDs.List(ctx)
+	if err != nil {
+		return err
+	}
+	objects, err := r.client.List(ctx, ResourceTemplates, "")
+	if err != nil {
+		return fmt.Errorf("failed to list tinkerbell templates: %w", err)
+	}
+
+	templateIDs := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		templateIDs[row.ID] = true
+	}
+	objectsByName := make(map[string]*Object, len(objects))
+	for i := range objects {
+		objectsByName[objects[i].Metadata.Name] = &objects[i]
+	}
+	claimed := map[string]bool{}
+
+	for i := range rows {
+		row := &rows[i]
+		name := row.TinkerbellID
+		if name == "" {
+			name = ObjectName(row.Name+"-"+row.Version, row.ID)
+			if obj, exists := objectsByName[name]; claimed[name] || (exists && obj.Metadata.Labels[LabelTemplateID] != row.ID) {
+				name = row.ID
+			}
+		}
+		claimed[name] = true
+
+		existing := objectsByName[name]
+		obj, err := r.client.ApplyTemplate(ctx, name, row.ID, row.Content)
+		if err != nil {
+			result.errorf("template %s: %v", row.ID, err)
+			continue
+		}
+		switch {
+		case existing == nil:
+			result.Templates.Created++
+		case existing.Metadata.ResourceVersion != obj.Metadata.ResourceVersion:
+			result.Templates.Updated++
+		}
+		if row.TinkerbellID != name {
+			row.TinkerbellID = name
+			if err := r.repos.TemplateCRDs.Update(ctx, row); err != nil {
+				result.errorf("template %s: %v", row.ID, err)
+			}
+		}
+	}
+
+	for i := range objects {
+		obj := &objects[i]
+		if claimed[obj.Metadata.Name] {
+			continue
+		}
+		if isManaged(obj) {
+			templateID := obj.Metadata.Labels[LabelTemplateID]
+			if templateID != "" && !templateIDs[templateID] {
+				if err := r.client.Delete(ctx, ResourceTemplates, obj.Metadata.Name); err != nil {
+					result.errorf("delete template %s: %v", obj.Metadata.Name, err)
+					continue
+				}
+				result.Templates.Deleted++
+			}
+			continue
+		}
+		if err := r.importTemplate(ctx, obj); err != nil {
+			result.errorf("import template %s: %v", obj.Metadata.Name, err)
+			continue
+		}
+		result.Templates.Imported++
+	}
+	return nil
+}
+
+// importTemplate 把Tinkerbell中未管理的Template导入为模板
+func (r *Reconciler) importTemplate(ctx context.Context, obj *Object) error {
+	spec, err := DecodeTemplate(obj)
+	if err != nil {
+		return err
+	}
+	row := &models.TemplateCRD{
+		TinkerbellID: obj.Metadata.Name,
+		Name:         obj.Metadata.Name,
+		Description:  "imported from tinkerbell",
+		Content:      spec.Data,
+		Version:      ImportedVersion,
+	}
+	if err := r.repos.TemplateCRDs.Create(ctx, row); err != nil {
+		return err
+	}
+	_, err = r.client.Adopt(ctx, ResourceTemplates, obj, map[string]string{LabelTemplateID: row.ID})
+	return err
+}
+
+// ImportedVersion 从Tinkerbell导入的模板的版本
+const ImportedVersion = "imported"
+
+// syncWorkflows 提交待执行的工作流，按Workflow的执行状态更新工作流
+func (r *Reconciler) syncWorkflows(ctx context.Context, result *SyncResult) error {
+	rows, err := r.repos.WorkflowCRDs.List(ctx, repository.WorkflowCRDFilter{})
+	if err != nil {
+		return err
+	}
+	hardware, err := r.repos.HardwareCRDs.List(ctx)
+	if err != nil {
+		return err
+	}
+	templates, err := r.repos.TemplateCRDs.List(ctx)
+	if err != nil {
+		return err
+	}
+	objects, err := r.client.List(ctx, ResourceWorkflows, "")
+	if err != nil {
+		return fmt.Errorf("failed to list tinkerbell workflows: %w", err)
+	}
+
+	hardwareByID := make(map[string]*models.HardwareCRD, len(hardware))
+	hardwareByName := make(map[string]*models.HardwareCRD, len(hardware))
+	for i := range hardware {
+		hardwareByID[hardware[i].ID] = &hardware[i]
+		if hardware[i].TinkerbellID != "" {
+			hardwareByName[hardware[i].TinkerbellID] = &hardware[i]
+		}
+	}
+	templatesByID := make(map[string]*models.TemplateCRD, len(templates))
+	templatesByName := make(map[string]*models.TemplateCRD, len(templates))
+	for i := range templates {
+		templatesByID[templates[i].ID] = &templates[i]
+		if templates[i].TinkerbellID != "" {
+			templatesByName[templates[i].TinkerbellID] = &templates[i]
+		}
+	}
+	workflowIDs := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		workflowIDs[row.ID] = true
+	}
+	objectsByName := make(map[string]*Object, len(objects))
+	for i := range objects {
+		objectsByName[objects[i].Metadata.Name] = &objects[i]
+	}
+	claimed := map[string]bool{}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.TinkerbellID != "" {
+			claimed[row.TinkerbellID] = true
+		}
+		switch {
+		case row.Status == models.WorkflowStatusPending && row.TinkerbellID == "":
+			name, err := r.submitWorkflow(ctx, row, hardwareByID[row.HardwareID], templatesByID[row.TemplateID])
+			if err != nil {
+				result.errorf("submit workflow %s: %v", row.ID, err)
+				continue
+			}
+			if name != "" {
+				claimed[name] = true
+				result.Workflows.Created++
+			}
+		case row.Status == models.WorkflowStatusPending || row.Status == models.WorkflowStatusRunning:
+			changed, err := r.refreshWorkflow(ctx, row, objectsByName[row.TinkerbellID])
+			if err != nil {
+				result.errorf("workflow %s: %v", row.ID, err)
+				continue
+			}
+			if changed {
+				result.Workflows.Updated++
+			}
+		}
+	}
+
+	for i := range objects {
+		obj := &objects[i]
+		if claimed[obj.Metadata.Name] {
+			continue
+		}
+		if isManaged(obj) {
+			workflowID := obj.Metadata.Labels[LabelWorkflowID]
+			if workflowID != "" && !workflowIDs[workflowID] {
+				if err := r.client.Delete(ctx, ResourceWorkflows, obj.Metadata.Name); err != nil {
+					result.errorf("delete workflow %s: %v", obj.Metadata.Name, err)
+					continue
+				}
+				result.Workflows.Deleted++
+			}
+			continue
+		}
+		imported, err := r.importWorkflow(ctx, obj, hardwareByName, templatesByName)
+		if err != nil {
+			result.errorf("import workflow %s: %v", obj.Metadata.Name, err)
+			continue
+		}
+		if imported {
+			result.Workflows.Imported++
+		}
+	}
+	return nil
+}
+
+// submitWorkflow 提交待执行的工作流，硬件或模板尚未同步到Tinkerbell时等待下次同步，返回空名称
+func (r *Reconciler) submitWorkflow(ctx context.Context, row *models.WorkflowCRD, hardware *models.HardwareCRD, template *models.TemplateCRD) (string, error) {
+	if hardware == nil || template == nil {
+		return "", fmt.Errorf("hardware or template mapping not found")
+	}
+	if hardware.Status != models.HardwareCRDStatusSynced || template.TinkerbellID == "" {
+		return "", nil
+	}
+	mac, _ := hardware.Metadata[MetadataMAC].(string)
+	if mac == "" {
+		return "", fmt.Errorf("hardware %s has no mac address", hardware.TinkerbellID)
+	}
+
+	name := ObjectName(row.ID, row.ID)
+	_, err := r.client.SubmitWorkflow(ctx, name, row.ID, WorkflowSpec{
+		TemplateRef: template.TinkerbellID,
+		HardwareRef: hardware.TinkerbellID,
+		HardwareMap: map[string]string{DefaultDeviceKey: mac},
+	})
+	// 上次同步创建后未能写回记录时Workflow已存在，直接沿用
+	if err != nil && !errors.Is(err, ErrConflict) {
+		return "", err
+	}
+
+	row.TinkerbellID = name
+	if err := r.repos.WorkflowCRDs.UpdateIfStatus(ctx, row, models.WorkflowStatusPending); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// refreshWorkflow 按Workflow的执行状态更新工作流，Workflow已不存在时工作流失败
+func (r *Reconciler) refreshWorkflow(ctx context.Context, row *models.WorkflowCRD, obj *Object) (bool, error) {
+	if obj == nil {
+		steps := cloneMetadata(row.Steps)
+		steps[MetadataError] = "workflow no longer exists in tinkerbell"
+		return r.advanceWorkflow(ctx, row, models.WorkflowStatusFailed, steps, nil)
+	}
+	status, err := DecodeWorkflowStatus(obj)
+	if err != nil {
+		return false, err
+	}
+	steps, err := stepsFromStatus(status)
+	if err != nil {
+		return false, err
+	}
+	return r.advanceWorkflow(ctx, row, WorkflowRowStatus(status.State), steps, status)
+}
+
+// workflowEvents 工作流进入各状态时发布的事件
+var workflowEvents = map[string]string{
+	models.WorkflowStatusRunning:   event.EventTypeWorkflowStarted,
+	models.WorkflowStatusCompleted: event.EventTypeWorkflowCompleted,
+	models.WorkflowStatusFailed:    event.EventTypeWorkflowFailed,
+}
+
+// advanceWorkflow 按状态机迁移工作流状态并更新步骤，跳过running的迁移会先经过running
+// 状态变化时在同一事务中通过发件箱发布工作流事件
+func (r *Reconciler) advanceWorkflow(ctx context.Context, row *models.WorkflowCRD, to string, steps map[string]interface{}, status *WorkflowStatus) (bool, error) {
+	if row.Status == to && metadataEqual(row.Steps, steps) {
+		return false, nil
+	}
+	if row.Status != to && !models.WorkflowTransitions.Allows(row.Status, to) {
+		if !models.WorkflowTransitions.Allows(row.Status, models.WorkflowStatusRunning) {
+			return false, models.WorkflowTransitions.Validate("workflow", row.ID, row.Status, to)
+		}
+		if _, err := r.advanceWorkflow(ctx, row, models.WorkflowStatusRunning, steps, status); err != nil {
+			return false, err
+		}
+	}
+
+	now := time.Now().UTC()
+	expected := row.Status
+	row.Status = to
+	row.Steps = steps
+	if to != models.WorkflowStatusPending && row.StartedAt == nil {
+		row.StartedAt = &now
+		if status != nil && status.StartedAt() != nil {
+			startedAt := status.StartedAt().UTC()
+			row.StartedAt = &startedAt
+		}
+	}
+	if (to == models.WorkflowStatusCompleted || to == models.WorkflowStatusFailed) && row.CompletedAt == nil {
+		row.CompletedAt = &now
+		if status != nil && status.CompletedAt() != nil {
+			completedAt := status.CompletedAt().UTC()
+			row.CompletedAt = &completedAt
+		}
+		if row.StartedAt != nil && row.CompletedAt.Before(*row.StartedAt) {
+			row.CompletedAt = row.StartedAt
+		}
+	}
+	err := r.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.WorkflowCRDs.UpdateIfStatus(ctx, row, expected); err != nil {
+			return err
+		}
+		eventType, ok := workflowEvents[to]
+		if expected == to || !ok {
+			return nil
+		}
+		errMsg, _ := row.Steps[MetadataError].(string)
+		evt, err := event.NewCloudEvent(eventSource, eventType, row.ID, event.WorkflowEvent{
+			WorkflowID:   row.ID,
+			TinkerbellID: row.TinkerbellID,
+			HardwareID:   row.HardwareID,
+			TemplateID:   row.TemplateID,
+			Status:       row.Status,
+			Error:        errMsg,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Enqueue(ctx, tx.Outbox, evt)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// importWorkflow 把Tinkerbell中引用已管理硬件和模板的未管理Workflow导入为工作流，其他Workflow忽略
+func (r *Reconciler) importWorkflow(ctx context.Context, obj *Object, hardware map[string]*models.HardwareCRD, templates map[string]*models.TemplateCRD) (bool, error) {
+	spec, err := DecodeWorkflow(obj)
+	if err != nil {
+		return false, err
+	}
+	hw, template := hardware[spec.HardwareRef], templates[spec.TemplateRef]
+	if hw == nil || template == nil {
+		return false, nil
+	}
+	status, err := DecodeWorkflowStatus(obj)
+	if err != nil {
+		return false, err
+	}
+	steps, err := stepsFromStatus(status)
+	if err != nil {
+		return false, err
+	}
+
+	row := &models.WorkflowCRD{
+		TinkerbellID: obj.Metadata.Name,
+		HardwareID:   hw.ID,
+		TemplateID:   template.ID,
+		Status:       WorkflowRowStatus(status.State),
+		Steps:        steps,
+		StartedAt:    status.StartedAt(),
+	}
+	if status.Terminal() {
+		row.CompletedAt = status.CompletedAt()
+	}
+	if err := r.repos.WorkflowCRDs.Create(ctx, row); err != nil {
+		return false, err
+	}
+	if _, err := r.client.Adopt(ctx, ResourceWorkflows, obj, map[string]string{LabelWorkflowID: row.ID}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WorkflowRowStatus 把Tinkerbell工作流状态转换为工作流记录的状态
+func WorkflowRowStatus(state string) string {
+	switch state {
+	case StateRunning:
+		return models.WorkflowStatusRunning
+	case StateSuccess:
+		return models.WorkflowStatusCompleted
+	case StateFailed, StateTimeout:
+		return models.WorkflowStatusFailed
+	}
+	return models.WorkflowStatusPending
+}
+
+// stepsFromStatus 把Workflow的执行状态转换为工作流记录的步骤
+func stepsFromStatus(status *WorkflowStatus) (map[string]interface{}, error) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	steps := map[string]interface{}{}
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, err
+	}
+	if action := status.FailedAction(); action != nil {
+		steps[MetadataError] = fmt.Sprintf("action %s ended in %s: %s", action.Name, action.Status, action.Message)
+	}
+	return steps, nil
+}
+
+// DecodeSteps 把工作流记录的Steps解析为Workflow的status，stepsFromStatus的逆过程
+func DecodeSteps(steps map[string]interface{}) (*WorkflowStatus, error) {
+	status := &WorkflowStatus{}
+	if len(steps) == 0 {
+		status.State = StatePending
+		return status, nil
+	}
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, err
+	}
+	if status.State == "" {
+		status.State = StatePending
+	}
+	return status, nil
+}
+
+// hardwareParams 从映射记录读取装机参数
+func hardwareParams(row *models.HardwareCRD) HardwareParams {
+	params := HardwareParams{}
+	params.MAC, _ = row.Metadata[MetadataMAC].(string)
+	switch disks := row.Metadata[MetadataDisks].(type) {
+	case []string:
+		params.Disks = disks
+	case []interface{}:
+		for _, d := range disks {
+			if s, ok := d.(string); ok {
+				params.Disks = append(params.Disks, s)
+			}
+		}
+	}
+	return params
+}
+
+// isManaged 判断对象是否由本系统管理
+func isManaged(obj *Object) bool {
+	return obj.Metadata.Labels[LabelManagedBy] == ManagedBy
+}
+
+// cloneMetadata 复制元数据，nil时返回空map
+func cloneMetadata(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// metadataEqual 按JSON语义比较，数据库读出的数字和切片类型与写入时不同
+func metadataEqual(a, b map[string]interface{}) bool {
+	da, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	db, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return jsonEqual(da, db)
+}
@@ -0,0 +1,657 @@
+package tinkerbell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/event"
+)
+
+const testTemplate = `version: "0.1"
+name: ubuntu
+global_timeout: 1800
+tasks:
+  - name: os-installation
+    worker: "{{.device_1}}"
+    actions:
+      - name: stream-image
+        image: quay.io/tinkerbell-actions/image2disk:v1.0.0
+        timeout: 600
+      - name: kexec
+        image: quay.io/tinkerbell-actions/kexec:v1.0.0
+        timeout: 90
+`
+
+// syncEnv 使用内存仓储和FakeClient的同步器
+type syncEnv struct {
+	repos *repository.Repositories
+	fake  *FakeClient
+	r     *Reconciler
+}
+
+func newSyncEnv(t *testing.T) *syncEnv {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	fake := NewFakeClient(FakeConfig{})
+	return &syncEnv{repos: repos, fake: fake, r: NewReconciler(repos, NewClient(fake), Config{})}
+}
+
+// reconcile 同步一次，任何对象同步失败都视为测试失败
+func (env *syncEnv) reconcile(t *testing.T) *SyncResult {
+	t.Helper()
+	result, err := env.r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("Reconcile() errors = %v", result.Errors)
+	}
+	return result
+}
+
+func (env *syncEnv) createServer(t *testing.T, name, ip string) *models.Server {
+	t.Helper()
+	server := &models.Server{Name: name, ManagementIP: ip, Status: models.ServerStatusReady}
+	if err := env.repos.Servers.Create(context.Background(), server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return server
+}
+
+func (env *syncEnv) createTemplate(t *testing.T, name string) *models.TemplateCRD {
+	t.Helper()
+	template := &models.TemplateCRD{Name: name, Version: "22.04", Content: testTemplate}
+	if err := env.repos.TemplateCRDs.Create(context.Background(), template); err != nil {
+		t.Fatalf("failed to create template: %v", err)
+	}
+	return template
+}
+
+func (env *syncEnv) server(t *testing.T, name string) *models.Server {
+	t.Helper()
+	servers, err := env.repos.Servers.List(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list servers: %v", err)
+	}
+	for i := range servers {
+		if servers[i].Name == name {
+			return &servers[i]
+		}
+	}
+	t.Fatalf("server %s not found", name)
+	return nil
+}
+
+func (env *syncEnv) hardwareRow(t *testing.T, serverID string) *models.HardwareCRD {
+	t.Helper()
+	row, err := env.repos.HardwareCRDs.GetByServer(context.Background(), serverID)
+	if err != nil {
+		t.Fatalf("GetByServer(%s) error = %v", serverID, err)
+	}
+	return row
+}
+
+func (env *syncEnv) object(t *testing.T, resource Resource, name string) *Object {
+	t.Helper()
+	obj, err := env.fake.Get(context.Background(), resource, name)
+	if err != nil {
+		t.Fatalf("Get(%s %s) error = %v", resource, name, err)
+	}
+	return obj
+}
+
+// editSpec 模拟在Tinkerbell中直接修改对象的spec
+func (env *syncEnv) editSpec(t *testing.T, resource Resource, name string, edit func(spec map[string]interface{})) {
+	t.Helper()
+	obj := env.object(t, resource, name)
+	spec, err := decodeSpecMap(obj.Spec)
+	if err != nil {
+		t.Fatalf("failed to decode spec: %v", err)
+	}
+	edit(spec)
+	if obj.Spec, err = json.Marshal(spec); err != nil {
+		t.Fatalf("failed to encode spec: %v", err)
+	}
+	if _, err := env.fake.Update(context.Background(), resource, obj); err != nil {
+		t.Fatalf("Update(%s %s) error = %v", resource, name, err)
+	}
+}
+
+// outboxTopics 返回发件箱中待发布事件的主题
+func (env *syncEnv) outboxTopics(t *testing.T) []string {
+	t.Helper()
+	events, err := env.repos.Outbox.ListDue(context.Background(), time.Now().UTC().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("ListDue() error = %v", err)
+	}
+	topics := []string{}
+	for _, evt := range events {
+		topics = append(topics, evt.Topic)
+	}
+	return topics
+}
+
+// hardwareObject 构造Tinkerbell中由其他途径创建的Hardware
+func hardwareObject(t *testing.T, name, hostname, ip, mac string, labels map[string]string) Object {
+	t.Helper()
+	spec, err := json.Marshal(HardwareSpec{
+		Interfaces: []HardwareInterface{{DHCP: &DHCP{MAC: mac, Hostname: hostname, IP: &IP{Address: ip}}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode hardware spec: %v", err)
+	}
+	return Object{Metadata: ObjectMeta{Name: name, Labels: labels}, Spec: spec}
+}
+
+func managedLabels(key, value string) map[string]string {
+	return map[string]string{LabelManagedBy: ManagedBy, key: value}
+}
+
+func TestReconcileHardware(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, env *syncEnv)
+		// change 非nil时先同步一次，执行change后再同步，校验第二次同步
+		change func(t *testing.T, env *syncEnv)
+		want   SyncCounts
+		check  func(t *testing.T, env *syncEnv)
+	}{
+		{
+			name:  "create hardware for new server",
+			setup: func(t *testing.T, env *syncEnv) { env.createServer(t, "node-1", "10.0.0.11") },
+			want:  SyncCounts{Created: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-1")
+				row := env.hardwareRow(t, server.ID)
+				if row.Status != models.HardwareCRDStatusSynced || row.TinkerbellID == "" || server.TinkerbellHardwareID != row.TinkerbellID {
+					t.Fatalf("hardware row = %+v, server hardware = %q", row, server.TinkerbellHardwareID)
+				}
+				obj := env.object(t, ResourceHardware, row.TinkerbellID)
+				if !isManaged(obj) || obj.Metadata.Labels[LabelServerID] != server.ID {
+					t.Errorf("hardware labels = %v", obj.Metadata.Labels)
+				}
+				spec, _, err := DecodeHardware(obj)
+				if err != nil {
+					t.Fatalf("DecodeHardware() error = %v", err)
+				}
+				if spec.Hostname() != "node-1" || spec.IPAddress() != "10.0.0.11" {
+					t.Errorf("hardware hostname = %q, ip = %q", spec.Hostname(), spec.IPAddress())
+				}
+			},
+		},
+		{
+			name: "adopt unmanaged hardware with the same hostname",
+			setup: func(t *testing.T, env *syncEnv) {
+				env.createServer(t, "node-1", "10.0.0.11")
+				env.fake.Seed(ResourceHardware, hardwareObject(t, "rack1-slot3", "node-1", "", "aa:bb:cc:00:00:01", nil))
+			},
+			want: SyncCounts{Adopted: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-1")
+				row := env.hardwareRow(t, server.ID)
+				if server.TinkerbellHardwareID != "rack1-slot3" || row.Metadata[MetadataMAC] != "aa:bb:cc:00:00:01" {
+					t.Errorf("server hardware = %q, row metadata = %v", server.TinkerbellHardwareID, row.Metadata)
+				}
+				if obj := env.object(t, ResourceHardware, "rack1-slot3"); !isManaged(obj) {
+					t.Errorf("adopted hardware labels = %v", obj.Metadata.Labels)
+				}
+			},
+		},
+		{
+			name: "import unmanaged hardware as discovered server",
+			setup: func(t *testing.T, env *syncEnv) {
+				env.fake.Seed(ResourceHardware, hardwareObject(t, "hw-9", "node-9", "10.0.0.19", "aa:bb:cc:00:00:09", nil))
+			},
+			want: SyncCounts{Imported: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-9")
+				if server.Status != models.ServerStatusDiscovered || server.ManagementIP != "10.0.0.19" || server.TinkerbellHardwareID != "hw-9" {
+					t.Errorf("imported server = %+v", server)
+				}
+				if row := env.hardwareRow(t, server.ID); row.Metadata[MetadataMAC] != "aa:bb:cc:00:00:09" {
+					t.Errorf("row metadata = %v", row.Metadata)
+				}
+				if obj := env.object(t, ResourceHardware, "hw-9"); obj.Metadata.Labels[LabelServerID] != server.ID {
+					t.Errorf("imported hardware labels = %v", obj.Metadata.Labels)
+				}
+				if topics := env.outboxTopics(t); !reflect.DeepEqual(topics, []string{event.EventTypeHardwareDiscovered}) {
+					t.Errorf("outbox topics = %v", topics)
+				}
+			},
+		},
+		{
+			name: "delete hardware of deleted server",
+			setup: func(t *testing.T, env *syncEnv) {
+				env.fake.Seed(ResourceHardware, hardwareObject(t, "hw-old", "node-old", "", "", managedLabels(LabelServerID, "deleted-server")))
+			},
+			want: SyncCounts{Deleted: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				if _, err := env.fake.Get(context.Background(), ResourceHardware, "hw-old"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+				}
+			},
+		},
+		{
+			name:  "mac set in tinkerbell is written back",
+			setup: func(t *testing.T, env *syncEnv) { env.createServer(t, "node-1", "10.0.0.11") },
+			change: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-1")
+				env.editSpec(t, ResourceHardware, server.TinkerbellHardwareID, func(spec map[string]interface{}) {
+					childMap(firstInterface(spec), "dhcp")["mac"] = "aa:bb:cc:00:00:01"
+				})
+			},
+			check: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-1")
+				row := env.hardwareRow(t, server.ID)
+				obj := env.object(t, ResourceHardware, server.TinkerbellHardwareID)
+				if row.Metadata[MetadataMAC] != "aa:bb:cc:00:00:01" || row.Metadata[MetadataResourceVersion] != obj.Metadata.ResourceVersion {
+					t.Errorf("row metadata = %v, hardware version = %s", row.Metadata, obj.Metadata.ResourceVersion)
+				}
+			},
+		},
+		{
+			name:  "renamed server updates hardware",
+			setup: func(t *testing.T, env *syncEnv) { env.createServer(t, "node-1", "10.0.0.11") },
+			change: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-1")
+				server.Name = "node-1-renamed"
+				if err := env.repos.Servers.Update(context.Background(), server); err != nil {
+					t.Fatalf("failed to rename server: %v", err)
+				}
+			},
+			want: SyncCounts{Updated: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				server := env.server(t, "node-1-renamed")
+				spec, _, err := DecodeHardware(env.object(t, ResourceHardware, server.TinkerbellHardwareID))
+				if err != nil {
+					t.Fatalf("DecodeHardware() error = %v", err)
+				}
+				if spec.Hostname() != "node-1-renamed" {
+					t.Errorf("hardware hostname = %q, want node-1-renamed", spec.Hostname())
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newSyncEnv(t)
+			tt.setup(t, env)
+			result := env.reconcile(t)
+			if tt.change != nil {
+				tt.change(t, env)
+				result = env.reconcile(t)
+			}
+			if result.Hardware != tt.want {
+				t.Errorf("hardware counts = %+v, want %+v", result.Hardware, tt.want)
+			}
+			tt.check(t, env)
+		})
+	}
+}
+
+func TestReconcileTemplates(t *testing.T) {
+	templateObject := func(t *testing.T, name, data string, labels map[string]string) Object {
+		spec, err := json.Marshal(TemplateSpec{Data: data})
+		if err != nil {
+			t.Fatalf("failed to encode template spec: %v", err)
+		}
+		return Object{Metadata: ObjectMeta{Name: name, Labels: labels}, Spec: spec}
+	}
+	// templateData 返回数据库中唯一模板对应的Template内容
+	templateData := func(t *testing.T, env *syncEnv) (*models.TemplateCRD, string) {
+		rows, err := env.repos.TemplateCRDs.List(context.Background())
+		if err != nil || len(rows) != 1 {
+			t.Fatalf("templates = %+v, %v, want one", rows, err)
+		}
+		obj := env.object(t, ResourceTemplates, rows[0].TinkerbellID)
+		if obj.Metadata.Labels[LabelTemplateID] != rows[0].ID {
+			t.Errorf("template labels = %v", obj.Metadata.Labels)
+		}
+		spec, err := DecodeTemplate(obj)
+		if err != nil {
+			t.Fatalf("DecodeTemplate() error = %v", err)
+		}
+		return &rows[0], spec.Data
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, env *syncEnv)
+		change func(t *testing.T, env *syncEnv)
+		want   SyncCounts
+		check  func(t *testing.T, env *syncEnv)
+	}{
+		{
+			name:  "create template",
+			setup: func(t *testing.T, env *syncEnv) { env.createTemplate(t, "ubuntu") },
+			want:  SyncCounts{Created: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				if _, data := templateData(t, env); data != testTemplate {
+					t.Errorf("template data = %q", data)
+				}
+			},
+		},
+		{
+			name:  "database content overrides tinkerbell edits",
+			setup: func(t *testing.T, env *syncEnv) { env.createTemplate(t, "ubuntu") },
+			change: func(t *testing.T, env *syncEnv) {
+				row, _ := templateData(t, env)
+				env.editSpec(t, ResourceTemplates, row.TinkerbellID, func(spec map[string]interface{}) {
+					spec["data"] = "edited in tinkerbell"
+				})
+			},
+			want: SyncCounts{Updated: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				if _, data := templateData(t, env); data != testTemplate {
+					t.Errorf("template data = %q, want the database content", data)
+				}
+			},
+		},
+		{
+			name:  "updated content is pushed",
+			setup: func(t *testing.T, env *syncEnv) { env.createTemplate(t, "ubuntu") },
+			change: func(t *testing.T, env *syncEnv) {
+				row, _ := templateData(t, env)
+				row.Content = testTemplate + "# v2\n"
+				if err := env.repos.TemplateCRDs.Update(context.Background(), row); err != nil {
+					t.Fatalf("failed to update template: %v", err)
+				}
+			},
+			want: SyncCounts{Updated: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				if _, data := templateData(t, env); data != testTemplate+"# v2\n" {
+					t.Errorf("template data = %q", data)
+				}
+			},
+		},
+		{
+			name: "import unmanaged template",
+			setup: func(t *testing.T, env *syncEnv) {
+				env.fake.Seed(ResourceTemplates, templateObject(t, "legacy", testTemplate, nil))
+			},
+			want: SyncCounts{Imported: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				row, data := templateData(t, env)
+				if row.Name != "legacy" || row.Version != ImportedVersion || row.TinkerbellID != "legacy" || row.Content != data {
+					t.Errorf("imported template = %+v", row)
+				}
+			},
+		},
+		{
+			name: "delete template of deleted row",
+			setup: func(t *testing.T, env *syncEnv) {
+				env.fake.Seed(ResourceTemplates, templateObject(t, "old", testTemplate, managedLabels(LabelTemplateID, "deleted-template")))
+			},
+			want: SyncCounts{Deleted: 1},
+			check: func(t *testing.T, env *syncEnv) {
+				if _, err := env.fake.Get(context.Background(), ResourceTemplates, "old"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newSyncEnv(t)
+			tt.setup(t, env)
+			result := env.reconcile(t)
+			if tt.change != nil {
+				tt.change(t, env)
+				result = env.reconcile(t)
+			}
+			if result.Templates != tt.want {
+				t.Errorf("template counts = %+v, want %+v", result.Templates, tt.want)
+			}
+			tt.check(t, env)
+		})
+	}
+}
+
+// workflowStatus 构造Workflow的status，actions为两个动作的状态，为空的动作尚未开始
+func workflowStatus(state string, actions ...string) WorkflowStatus {
+	started := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	status := WorkflowStatus{State: state, Tasks: []WorkflowTask{{Name: "os-installation", WorkerAddr: "aa:bb:cc:00:00:01"}}}
+	for i, name := range []string{"stream-image", "kexec"} {
+		action := WorkflowAction{Name: name, Status: StatePending}
+		if i < len(actions) && actions[i] != "" {
+			action.Status = actions[i]
+			action.StartedAt = &started
+			action.Seconds = 10
+			if actions[i] == StateFailed {
+				action.Message = "image not found"
+			}
+		}
+		status.Tasks[0].Actions = append(status.Tasks[0].Actions, action)
+	}
+	return status
+}
+
+func TestReconcileWorkflows(t *testing.T) {
+	// submit 创建工作流并同步一次提交到Tinkerbell，返回工作流记录
+	submit := func(t *testing.T, env *syncEnv) *models.WorkflowCRD {
+		t.Helper()
+		templates, err := env.repos.TemplateCRDs.List(context.Background())
+		if err != nil {
+			t.Fatalf("failed to list templates: %v", err)
+		}
+		workflow, err := env.r.CreateWorkflow(context.Background(), &WorkflowRequest{ServerID: env.server(t, "node-1").ID, TemplateID: templates[0].ID})
+		if err != nil {
+			t.Fatalf("CreateWorkflow() error = %v", err)
+		}
+		env.reconcile(t)
+		if workflow, err = env.repos.WorkflowCRDs.Get(context.Background(), workflow.ID); err != nil {
+			t.Fatalf("failed to get workflow: %v", err)
+		}
+		return workflow
+	}
+	// setStatus 提交工作流后模拟Tinkerbell写入执行状态
+	setStatus := func(status WorkflowStatus) func(t *testing.T, env *syncEnv) {
+		return func(t *testing.T, env *syncEnv) {
+			workflow := submit(t, env)
+			if err := env.fake.SetStatus(ResourceWorkflows, workflow.TinkerbellID, status); err != nil {
+				t.Fatalf("SetStatus() error = %v", err)
+			}
+		}
+	}
+	// unmanagedWorkflow 在Tinkerbell中直接创建引用hardwareRef和已同步模板的Workflow
+	unmanagedWorkflow := func(hardwareRef string) func(t *testing.T, env *syncEnv) {
+		return func(t *testing.T, env *syncEnv) {
+			templates, err := env.repos.TemplateCRDs.List(context.Background())
+			if err != nil {
+				t.Fatalf("failed to list templates: %v", err)
+			}
+			if hardwareRef == "" {
+				hardwareRef = env.server(t, "node-1").TinkerbellHardwareID
+			}
+			spec, err := json.Marshal(WorkflowSpec{TemplateRef: templates[0].TinkerbellID, HardwareRef: hardwareRef})
+			if err != nil {
+				t.Fatalf("failed to encode workflow spec: %v", err)
+			}
+			status, err := json.Marshal(workflowStatus(StateSuccess, StateSuccess, StateSuccess))
+			if err != nil {
+				t.Fatalf("failed to encode workflow status: %v", err)
+			}
+			env.fake.Seed(ResourceWorkflows, Object{Metadata: ObjectMeta{Name: "manual-run"}, Spec: spec, Status: status})
+		}
+	}
+	// workflow 返回唯一的工作流记录
+	workflow := func(t *testing.T, env *syncEnv) *models.WorkflowCRD {
+		t.Helper()
+		rows, err := env.repos.WorkflowCRDs.List(context.Background(), repository.WorkflowCRDFilter{})
+		if err != nil || len(rows) != 1 {
+			t.Fatalf("workflows = %+v, %v, want one", rows, err)
+		}
+		return &rows[0]
+	}
+
+	tests := []struct {
+		name       string
+		change     func(t *testing.T, env *syncEnv)
+		want       SyncCounts
+		wantStatus string
+		// wantError 工作流步骤中记录的错误应包含的内容
+		wantError  string
+		wantTopics []string
+		check      func(t *testing.T, env *syncEnv, row *models.WorkflowCRD)
+	}{
+		{
+			name: "submit pending workflow",
+			change: func(t *testing.T, env *syncEnv) {
+				templates, _ := env.repos.TemplateCRDs.List(context.Background())
+				if _, err := env.r.CreateWorkflow(context.Background(), &WorkflowRequest{ServerID: env.server(t, "node-1").ID, TemplateID: templates[0].ID}); err != nil {
+					t.Fatalf("CreateWorkflow() error = %v", err)
+				}
+			},
+			want:       SyncCounts{Created: 1},
+			wantStatus: models.WorkflowStatusPending,
+			wantTopics: []string{},
+			check: func(t *testing.T, env *syncEnv, row *models.WorkflowCRD) {
+				spec, err := DecodeWorkflow(env.object(t, ResourceWorkflows, row.TinkerbellID))
+				if err != nil {
+					t.Fatalf("DecodeWorkflow() error = %v", err)
+				}
+				server := env.server(t, "node-1")
+				if spec.HardwareRef != server.TinkerbellHardwareID || spec.HardwareMap[DefaultDeviceKey] != "aa:bb:cc:00:00:01" {
+					t.Errorf("workflow spec = %+v", spec)
+				}
+			},
+		},
+		{
+			name:       "running",
+			change:     setStatus(workflowStatus(StateRunning, StateRunning)),
+			want:       SyncCounts{Updated: 1},
+			wantStatus: models.WorkflowStatusRunning,
+			wantTopics: []string{event.EventTypeWorkflowStarted},
+			check: func(t *testing.T, env *syncEnv, row *models.WorkflowCRD) {
+				if row.StartedAt == nil || row.CompletedAt != nil {
+					t.Errorf("started at = %v, completed at = %v", row.StartedAt, row.CompletedAt)
+				}
+			},
+		},
+		{
+			// 跳过running的状态先经过running
+			name:       "completed",
+			change:     setStatus(workflowStatus(StateSuccess, StateSuccess, StateSuccess)),
+			want:       SyncCounts{Updated: 1},
+			wantStatus: models.WorkflowStatusCompleted,
+			wantTopics: []string{event.EventTypeWorkflowStarted, event.EventTypeWorkflowCompleted},
+			check: func(t *testing.T, env *syncEnv, row *models.WorkflowCRD) {
+				if row.StartedAt == nil || row.CompletedAt == nil || row.CompletedAt.Before(*row.StartedAt) {
+					t.Errorf("started at = %v, completed at = %v", row.StartedAt, row.CompletedAt)
+				}
+			},
+		},
+		{
+			// pending可以直接迁移到failed，不发布started事件
+			name:       "action failed",
+			change:     setStatus(workflowStatus(StateFailed, StateFailed)),
+			want:       SyncCounts{Updated: 1},
+			wantStatus: models.WorkflowStatusFailed,
+			wantError:  "action stream-image ended in STATE_FAILED: image not found",
+			wantTopics: []string{event.EventTypeWorkflowFailed},
+		},
+		{
+			name: "workflow deleted in tinkerbell",
+			change: func(t *testing.T, env *syncEnv) {
+				workflow := submit(t, env)
+				if err := env.fake.Delete(context.Background(), ResourceWorkflows, workflow.TinkerbellID); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+			},
+			want:       SyncCounts{Updated: 1},
+			wantStatus: models.WorkflowStatusFailed,
+			wantError:  "no longer exists",
+			wantTopics: []string{event.EventTypeWorkflowFailed},
+		},
+		{
+			name:       "import unmanaged workflow of managed hardware",
+			change:     unmanagedWorkflow(""),
+			want:       SyncCounts{Imported: 1},
+			wantStatus: models.WorkflowStatusCompleted,
+			wantTopics: []string{},
+			check: func(t *testing.T, env *syncEnv, row *models.WorkflowCRD) {
+				if row.TinkerbellID != "manual-run" || row.CompletedAt == nil {
+					t.Errorf("imported workflow = %+v", row)
+				}
+				if obj := env.object(t, ResourceWorkflows, "manual-run"); obj.Metadata.Labels[LabelWorkflowID] != row.ID {
+					t.Errorf("imported workflow labels = %v", obj.Metadata.Labels)
+				}
+			},
+		},
+		{
+			name: "delete workflow of deleted row",
+			change: func(t *testing.T, env *syncEnv) {
+				workflow := submit(t, env)
+				if err := env.repos.WorkflowCRDs.Delete(context.Background(), workflow.ID); err != nil {
+					t.Fatalf("failed to delete workflow: %v", err)
+				}
+				if _, err := env.fake.Get(context.Background(), ResourceWorkflows, workflow.TinkerbellID); err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+			},
+			want: SyncCounts{Deleted: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newSyncEnv(t)
+			server := env.createServer(t, "node-1", "10.0.0.11")
+			env.createTemplate(t, "ubuntu")
+			if _, err := env.r.SetHardware(context.Background(), server.ID, &HardwareRequest{MAC: "AA:BB:CC:00:00:01"}); err != nil {
+				t.Fatalf("SetHardware() error = %v", err)
+			}
+			env.reconcile(t)
+
+			tt.change(t, env)
+			result := env.reconcile(t)
+			if result.Workflows != tt.want {
+				t.Errorf("workflow counts = %+v, want %+v", result.Workflows, tt.want)
+			}
+			if tt.wantStatus == "" {
+				objects, err := env.fake.List(context.Background(), ResourceWorkflows, "")
+				if err != nil || len(objects) != 0 {
+					t.Errorf("workflows left in tinkerbell = %+v, %v", objects, err)
+				}
+				return
+			}
+
+			row := workflow(t, env)
+			if row.Status != tt.wantStatus {
+				t.Errorf("workflow status = %s, want %s", row.Status, tt.wantStatus)
+			}
+			if errMsg, _ := row.Steps[MetadataError].(string); !strings.Contains(errMsg, tt.wantError) || (tt.wantError == "") != (errMsg == "") {
+				t.Errorf("workflow error = %q, want %q", errMsg, tt.wantError)
+			}
+			if topics := env.outboxTopics(t); !reflect.DeepEqual(topics, tt.wantTopics) {
+				t.Errorf("outbox topics = %v, want %v", topics, tt.wantTopics)
+			}
+			if tt.check != nil {
+				tt.check(t, env, row)
+			}
+		})
+	}
+
+	t.Run("ignore workflow of unknown hardware", func(t *testing.T) {
+		env := newSyncEnv(t)
+		env.createTemplate(t, "ubuntu")
+		env.reconcile(t)
+		unmanagedWorkflow("someone-elses-hardware")(t, env)
+
+		if result := env.reconcile(t); result.Workflows != (SyncCounts{}) {
+			t.Errorf("workflow counts = %+v, want none", result.Workflows)
+		}
+		if obj := env.object(t, ResourceWorkflows, "manual-run"); isManaged(obj) {
+			t.Errorf("workflow of unknown hardware was adopted: %v", obj.Metadata.Labels)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package tinkerbell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"gpu-management/internal/models"
+)
+
+// HardwareRequest 设置服务器装机参数请求
+type HardwareRequest struct {
+	// MAC 网络启动网卡的MAC地址
+	MAC string `json:"mac"`
+	// Disks 系统盘设备，例如 /dev/nvme0n1
+	Disks []string `json:"disks"`
+}
+
+// Validate 校验装机参数请求
+func (r *HardwareRequest) Validate() error {
+	_, err := r.params()
+	return err
+}
+
+// params 将请求转换为装机参数并校验
+func (r *HardwareRequest) params() (*HardwareParams, error) {
+	if r.MAC == "" {
+		return nil, errors.New("mac is required")
+	}
+	mac, err := net.ParseMAC(r.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac %q", r.MAC)
+	}
+	for _, device := range r.Disks {
+		if !strings.HasPrefix(device, "/dev/") {
+			return nil, fmt.Errorf("invalid disk %q: must be a device path under /dev/", device)
+		}
+	}
+	return &HardwareParams{MAC: mac.String(), Disks: r.Disks}, nil
+}
+
+// TemplateRequest 创建模板请求
+type TemplateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Content Tinkerbell模板YAML
+	Content string `json:"content"`
+	Version string `json:"version"`
+	OSType  string `json:"os_type"`
+}
+
+// Validate 校验模板请求
+func (r *TemplateRequest) Validate() error {
+	_, err := r.template()
+	return err
+}
+
+// template 将请求转换为模板并校验模板能够解析
+func (r *TemplateRequest) template() (*models.TemplateCRD, error) {
+	if r.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if r.Version == "" {
+		return nil, errors.New("version is required")
+	}
+	if r.Content == "" {
+		return nil, errors.New("content is required")
+	}
+	if _, err := ParseTemplate(r.Content); err != nil {
+		return nil, fmt.Errorf("invalid content: %w", err)
+	}
+	return &models.TemplateCRD{
+		Name:        r.Name,
+		Description: r.Description,
+		Content:     r.Content,
+		Version:     r.Version,
+		OSType:      r.OSType,
+	}, nil
+}
+
+// WorkflowRequest 创建工作流请求
+type WorkflowRequest struct {
+	ServerID   string `json:"server_id"`
+	TemplateID string `json:"template_id"`
+}
+
+// Validate 校验工作流请求
+func (r *WorkflowRequest) Validate() error {
+	if r.ServerID == "" {
+		return errors.New("server_id is required")
+	}
+	if r.TemplateID == "" {
+		return errors.New("template_id is required")
+	}
+	return nil
+}
+
+// ParseTemplate 解析Tinkerbell模板YAML，返回其中的任务和动作
+// 模板变量按只有一块网卡的占位硬件渲染，模板中至少需要一个任务
+func ParseTemplate(content string) ([]WorkflowTask, error) {
+	status, err := parseWorkflowTemplate(content, map[string]string{DefaultDeviceKey: "00:00:00:00:00:00"})
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Tasks) == 0 {
+		return nil, errors.New("template has no tasks")
+	}
+	return status.Tasks, nil
+}
+
+// ResumeTemplate 去掉模板中指定动作之前的所有动作，用于从失败的动作继续执行
+// 动作按任务顺序排列，前面的任务全部去掉，指定动作所在任务只保留该动作及之后的动作，其余内容原样保留
+func ResumeTemplate(content, task, action string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return "", err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return "", errors.New("template is not a yaml mapping")
+	}
+	tasks := mappingValue(doc.Content[0], "tasks")
+	if tasks == nil || tasks.Kind != yaml.SequenceNode {
+		return "", errors.New("template has no tasks")
+	}
+
+	for i, t := range tasks.Content {
+		if name := mappingValue(t, "name"); name == nil || name.Value != task {
+			continue
+		}
+		actions := mappingValue(t, "actions")
+		if actions == nil || actions.Kind != yaml.SequenceNode {
+			break
+		}
+		for j, a := range actions.Content {
+			if name := mappingValue(a, "name"); name != nil && name.Value == action {
+				actions.Content = actions.Content[j:]
+				tasks.Content = tasks.Content[i:]
+				var out bytes.Buffer
+				enc := yaml.NewEncoder(&out)
+				enc.SetIndent(2)
+				if err := enc.Encode(&doc); err != nil {
+					return "", err
+				}
+				return out.String(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("action %s not found in task %s", action, task)
+}
+
+// mappingValue 返回YAML映射中键对应的值节点，不存在时返回nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package tinkerbell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+)
+
+// ListHardware 查询服务器与Hardware的映射
+func (r *Reconciler) ListHardware(ctx context.Context) ([]models.HardwareCRD, error) {
+	return r.repos.HardwareCRDs.List(ctx)
+}
+
+// GetHardware 查询服务器对应的Hardware映射
+func (r *Reconciler) GetHardware(ctx context.Context, serverID string) (*models.HardwareCRD, error) {
+	return r.repos.HardwareCRDs.GetByServer(ctx, serverID)
+}
+
+// SetHardware 设置服务器的装机参数并立即同步到Tinkerbell
+// 同步失败时参数已保存，映射状态为error，下次同步重试
+func (r *Reconciler) SetHardware(ctx context.Context, serverID string, req *HardwareRequest) (*models.HardwareCRD, error) {
+	params, err := req.params()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	server, err := r.repos.Servers.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.repos.HardwareCRDs.GetByServer(ctx, serverID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if row == nil {
+		row = &models.HardwareCRD{ServerID: serverID, Status: models.HardwareCRDStatusPending}
+	}
+	row.Metadata = cloneMetadata(row.Metadata)
+	row.Metadata[MetadataMAC] = params.MAC
+	if len(params.Disks) > 0 {
+		row.Metadata[MetadataDisks] = params.Disks
+	}
+	if row.ID == "" {
+		err = r.repos.HardwareCRDs.Create(ctx, row)
+	} else {
+		err = r.repos.HardwareCRDs.Update(ctx, row)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := r.client.List(ctx, ResourceHardware, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tinkerbell hardware: %w", err)
+	}
+	objectsByName := make(map[string]*Object, len(objects))
+	for i := range objects {
+		objectsByName[objects[i].Metadata.Name] = &objects[i]
+	}
+	name, adopted := r.hardwareName(server, row, objectsByName, map[string]bool{})
+	if err := r.applyHardware(ctx, server, row, name, objectsByName[name], adopted, &SyncResult{}); err != nil {
+		return nil, err
+	}
+	return r.repos.HardwareCRDs.GetByServer(ctx, serverID)
+}
+
+// ListTemplates 查询模板
+func (r *Reconciler) ListTemplates(ctx context.Context) ([]models.TemplateCRD, error) {
+	return r.repos.TemplateCRDs.List(ctx)
+}
+
+// GetTemplate 查询模板详情
+func (r *Reconciler) GetTemplate(ctx context.Context, id string) (*models.TemplateCRD, error) {
+	return r.repos.TemplateCRDs.Get(ctx, id)
+}
+
+// CreateTemplate 创建模板，下次同步时创建Template
+func (r *Reconciler) CreateTemplate(ctx context.Context, req *TemplateRequest) (*models.TemplateCRD, error) {
+	template, err := req.template()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.repos.TemplateCRDs.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTemplate 删除模板及其Template，仍被工作流引用时返回ErrReferenceViolation
+func (r *Reconciler) DeleteTemplate(ctx context.Context, id string) error {
+	template, err := r.repos.TemplateCRDs.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.repos.TemplateCRDs.Delete(ctx, id); err != nil {
+		return err
+	}
+	// 删除失败时由下次同步清理
+	if template.TinkerbellID != "" {
+		if err := r.client.Delete(ctx, ResourceTemplates, template.TinkerbellID); err != nil {
+			return fmt.Errorf("template deleted but tinkerbell template %s was not: %w", template.TinkerbellID, err)
+		}
+	}
+	return nil
+}
+
+// ListWorkflows 查询工作流
+func (r *Reconciler) ListWorkflows(ctx context.Context, filter repository.WorkflowCRDFilter) ([]models.WorkflowCRD, error) {
+	return r.repos.WorkflowCRDs.List(ctx, filter)
+}
+
+// GetWorkflow 查询工作流详情
+func (r *Reconciler) GetWorkflow(ctx context.Context, id string) (*models.WorkflowCRD, error) {
+	return r.repos.WorkflowCRDs.Get(ctx, id)
+}
+
+// CreateWorkflow 为服务器创建待执行的工作流，下次同步时提交到Tinkerbell
+// 服务器尚未同步到Tinkerbell时返回ErrReferenceViolation
+func (r *Reconciler) CreateWorkflow(ctx context.Context, req *WorkflowRequest) (*models.WorkflowCRD, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	hardware, err := r.repos.HardwareCRDs.GetByServer(ctx, req.ServerID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: server %s has no tinkerbell hardware", repository.ErrReferenceViolation, req.ServerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &models.WorkflowCRD{
+		HardwareID: hardware.ID,
+		TemplateID: req.TemplateID,
+		Status:     models.WorkflowStatusPending,
+	}
+	if err := r.repos.WorkflowCRDs.Create(ctx, workflow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// RetryWorkflow 重试失败的工作流：删除原Workflow，工作流回到pending，下次同步时重新提交
+func (r *Reconciler) RetryWorkflow(ctx context.Context, id string) (*models.WorkflowCRD, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workflow, err := r.repos.WorkflowCRDs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.WorkflowTransitions.Validate("workflow", id, workflow.Status, models.WorkflowStatusPending); err != nil {
+		return nil, err
+	}
+	if workflow.TinkerbellID != "" {
+		if err := r.client.Delete(ctx, ResourceWorkflows, workflow.TinkerbellID); err != nil {
+			return nil, err
+		}
+	}
+
+	workflow.TinkerbellID = ""
+	workflow.Status = models.WorkflowStatusPending
+	workflow.Steps = nil
+	workflow.StartedAt = nil
+	workflow.CompletedAt = nil
+	if err := r.repos.WorkflowCRDs.UpdateIfStatus(ctx, workflow, models.WorkflowStatusFailed); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// DeleteWorkflow 删除工作流及其Workflow
+func (r *Reconciler) DeleteWorkflow(ctx context.Context, id string) error {
+	workflow, err := r.repos.WorkflowCRDs.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := r.repos.WorkflowCRDs.Delete(ctx, id); err != nil {
+		return err
+	}
+	// 删除失败时由下次同步清理
+	if workflow.TinkerbellID != "" {
+		if err := r.client.Delete(ctx, ResourceWorkflows, workflow.TinkerbellID); err != nil {
+			return fmt.Errorf("workflow deleted but tinkerbell workflow %s was not: %w", workflow.TinkerbellID, err)
+		}
+	}
+	return nil
+}
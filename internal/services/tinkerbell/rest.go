@@ -0,0 +1,417 @@
+package tinkerbell
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 集群内运行时ServiceAccount凭据的挂载位置
+const (
+	serviceAccountDir       = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountTokenFile = serviceAccountDir + "/token"
+	serviceAccountCAFile    = serviceAccountDir + "/ca.crt"
+)
+
+// defaultRequestTimeout 单个API请求的默认超时
+const defaultRequestTimeout = 30 * time.Second
+
+// APIError API Server返回的错误，404和409分别包装为ErrNotFound和ErrConflict
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message"`
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("kubernetes: %d %s", e.StatusCode, msg)
+}
+
+// Unwrap 使errors.Is可以判断ErrNotFound和ErrConflict
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	}
+	return nil
+}
+
+// RESTConfig Kubernetes API访问配置
+type RESTConfig struct {
+	// Server API Server地址，例如 https://10.0.0.1:6443
+	Server string
+	// Namespace Tinkerbell CRD所在的命名空间
+	Namespace string
+	// Token Bearer Token，TokenFile不为空时每次请求从文件读取，以支持自动轮换的ServiceAccount Token
+	Token     string
+	TokenFile string
+	// CAData API Server证书的CA，为空时使用系统根证书
+	CAData             []byte
+	InsecureSkipVerify bool
+	// CertData和KeyData 客户端证书认证
+	CertData []byte
+	KeyData  []byte
+	Timeout  time.Duration
+}
+
+// LoadRESTConfig 加载Kubernetes API访问配置
+// kubeconfigPath不为空时读取kubeconfig的当前上下文，否则使用集群内ServiceAccount，两者都不可用时返回ErrNotConfigured
+func LoadRESTConfig(kubeconfigPath, namespace string) (*RESTConfig, error) {
+	if kubeconfigPath != "" {
+		return loadKubeconfig(kubeconfigPath, namespace)
+	}
+	return loadInCluster(namespace)
+}
+
+// loadInCluster 读取集群内ServiceAccount配置
+func loadInCluster(namespace string) (*RESTConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotConfigured
+	}
+	if _, err := os.Stat(serviceAccountTokenFile); err != nil {
+		return nil, ErrNotConfigured
+	}
+
+	config := &RESTConfig{
+		Server:    "https://" + net.JoinHostPort(host, port),
+		Namespace: namespace,
+		TokenFile: serviceAccountTokenFile,
+	}
+	if ca, err := os.ReadFile(serviceAccountCAFile); err == nil {
+		config.CAData = ca
+	}
+	if config.Namespace == "" {
+		if ns, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+			config.Namespace = strings.TrimSpace(string(ns))
+		}
+	}
+	return config, nil
+}
+
+// kubeconfig kubeconfig文件中同步用到的字段
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// loadKubeconfig 读取kubeconfig当前上下文的集群和用户，namespace为空时使用上下文中的命名空间
+func loadKubeconfig(path, namespace string) (*RESTConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	// kubeconfig中的相对路径相对于文件所在目录
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	contextName := kc.CurrentContext
+	if contextName == "" && len(kc.Contexts) == 1 {
+		contextName = kc.Contexts[0].Name
+	}
+	var clusterName, userName, contextNamespace string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName, contextNamespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig context %q not found", contextName)
+	}
+
+	config := &RESTConfig{Namespace: namespace}
+	if config.Namespace == "" {
+		config.Namespace = contextNamespace
+	}
+
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		config.Server = c.Cluster.Server
+		config.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		if config.CAData, err = fileOrData(resolve(c.Cluster.CertificateAuthority), c.Cluster.CertificateAuthorityData); err != nil {
+			return nil, fmt.Errorf("failed to load cluster certificate authority: %w", err)
+		}
+		break
+	}
+	if !found || config.Server == "" {
+		return nil, fmt.Errorf("kubeconfig cluster %q not found", clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		config.Token = u.User.Token
+		config.TokenFile = resolve(u.User.TokenFile)
+		if config.CertData, err = fileOrData(resolve(u.User.ClientCertificate), u.User.ClientCertificateData); err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		if config.KeyData, err = fileOrData(resolve(u.User.ClientKey), u.User.ClientKeyData); err != nil {
+			return nil, fmt.Errorf("failed to load client key: %w", err)
+		}
+		break
+	}
+	return config, nil
+}
+
+// fileOrData 读取文件，文件为空时解码base64内容
+func fileOrData(path, data string) ([]byte, error) {
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	if data == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// RESTClient 通过API Server的REST接口访问Tinkerbell CRD
+type RESTClient struct {
+	baseURL    string
+	config     RESTConfig
+	httpClient *http.Client
+}
+
+// NewRESTClient 创建Kubernetes REST客户端
+func NewRESTClient(config RESTConfig) (*RESTClient, error) {
+	u, err := url.Parse(strings.TrimSpace(config.Server))
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubernetes api server %q: %w", config.Server, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid kubernetes api server %q: scheme and host are required", config.Server)
+	}
+	if config.Namespace == "" {
+		config.Namespace = "default"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultRequestTimeout
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if len(config.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.CAData) {
+			return nil, fmt.Errorf("invalid kubernetes certificate authority")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(config.CertData) > 0 {
+		cert, err := tls.X509KeyPair(config.CertData, config.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubernetes client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &RESTClient{
+		baseURL:    strings.TrimSuffix(u.String(), "/"),
+		config:     config,
+		httpClient: &http.Client{Transport: transport, Timeout: config.Timeout},
+	}, nil
+}
+
+// Namespace 返回客户端访问的命名空间
+func (c *RESTClient) Namespace() string {
+	return c.config.Namespace
+}
+
+// Get 查询对象
+func (c *RESTClient) Get(ctx context.Context, resource Resource, name string) (*Object, error) {
+	var obj Object
+	if err := c.do(ctx, http.MethodGet, c.path(resource, name), nil, nil, &obj); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// List 按标签选择器查询对象
+func (c *RESTClient) List(ctx context.Context, resource Resource, selector string) ([]Object, error) {
+	query := url.Values{}
+	if selector != "" {
+		query.Set("labelSelector", selector)
+	}
+	var list objectList
+	if err := c.do(ctx, http.MethodGet, c.path(resource, ""), query, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Create 创建对象
+func (c *RESTClient) Create(ctx context.Context, resource Resource, obj *Object) (*Object, error) {
+	body := c.prepare(resource, obj)
+	var created Object
+	if err := c.do(ctx, http.MethodPost, c.path(resource, ""), nil, body, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update 更新对象，status由Tinkerbell控制器维护，通过主资源写入的status会被API Server忽略
+func (c *RESTClient) Update(ctx context.Context, resource Resource, obj *Object) (*Object, error) {
+	body := c.prepare(resource, obj)
+	var updated Object
+	if err := c.do(ctx, http.MethodPut, c.path(resource, obj.Metadata.Name), nil, body, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete 删除对象
+func (c *RESTClient) Delete(ctx context.Context, resource Resource, name string) error {
+	return c.do(ctx, http.MethodDelete, c.path(resource, name), nil, nil, nil)
+}
+
+// prepare 补全apiVersion、kind和命名空间
+func (c *RESTClient) prepare(resource Resource, obj *Object) *Object {
+	out := *obj
+	out.APIVersion = APIVersion
+	out.Kind = resource.Kind()
+	out.Metadata.Namespace = c.config.Namespace
+	return &out
+}
+
+// path 返回资源地址，name为空时返回集合地址
+func (c *RESTClient) path(resource Resource, name string) string {
+	p := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", Group, Version, url.PathEscape(c.config.Namespace), resource)
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+// token 返回Bearer Token，配置了TokenFile时每次重新读取
+func (c *RESTClient) token() (string, error) {
+	if c.config.TokenFile == "" {
+		return c.config.Token, nil
+	}
+	data, err := os.ReadFile(c.config.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read kubernetes token: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// do 发送请求，out不为nil时解析响应体
+func (c *RESTClient) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return fmt.Errorf("kubernetes: failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		// 错误响应是metav1.Status，解析失败时只保留状态码
+		json.Unmarshal(data, apiErr)
+		return apiErr
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("kubernetes: failed to decode response: %w", err)
+	}
+	return nil
+}
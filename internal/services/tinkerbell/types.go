@@ -0,0 +1,179 @@
+package tinkerbell
+
+import (
+	"time"
+)
+
+// 本系统管理的CRD携带的标签
+const (
+	// LabelManagedBy 标记由本系统创建或接管的对象，值为ManagedBy
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	ManagedBy      = "gpu-management"
+	// LabelServerID Hardware对应的服务器ID
+	LabelServerID = "gpu-management/server-id"
+	// LabelTemplateID Template对应的模板ID
+	LabelTemplateID = "gpu-management/template-id"
+	// LabelWorkflowID Workflow对应的工作流ID
+	LabelWorkflowID = "gpu-management/workflow-id"
+)
+
+// Tinkerbell工作流和动作的状态
+const (
+	StatePending = "STATE_PENDING"
+	StateRunning = "STATE_RUNNING"
+	StateSuccess = "STATE_SUCCESS"
+	StateFailed  = "STATE_FAILED"
+	StateTimeout = "STATE_TIMEOUT"
+)
+
+// DefaultDeviceKey 工作流hardwareMap中目标设备的键，模板中以{{.device_1}}引用
+const DefaultDeviceKey = "device_1"
+
+// HardwareSpec Hardware的spec，只声明本系统读写的字段，其余字段由Object.Spec原样保留
+type HardwareSpec struct {
+	Interfaces []HardwareInterface `json:"interfaces,omitempty"`
+	Metadata   *HardwareMetadata   `json:"metadata,omitempty"`
+	Disks      []HardwareDisk      `json:"disks,omitempty"`
+}
+
+// HardwareInterface 网卡的DHCP和网络启动配置
+type HardwareInterface struct {
+	DHCP    *DHCP    `json:"dhcp,omitempty"`
+	Netboot *Netboot `json:"netboot,omitempty"`
+}
+
+// DHCP 网卡的DHCP配置
+type DHCP struct {
+	MAC      string `json:"mac,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	IP       *IP    `json:"ip,omitempty"`
+	Arch     string `json:"arch,omitempty"`
+}
+
+// IP 网卡地址
+type IP struct {
+	Address string `json:"address,omitempty"`
+	Netmask string `json:"netmask,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// Netboot 网络启动开关
+type Netboot struct {
+	AllowPXE      *bool `json:"allowPXE,omitempty"`
+	AllowWorkflow *bool `json:"allowWorkflow,omitempty"`
+}
+
+// HardwareMetadata 硬件元数据
+type HardwareMetadata struct {
+	Instance *Instance `json:"instance,omitempty"`
+}
+
+// Instance 硬件上的实例信息
+type Instance struct {
+	ID       string `json:"id,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// HardwareDisk 硬盘
+type HardwareDisk struct {
+	Device string `json:"device"`
+}
+
+// HardwareStatus Hardware的status
+type HardwareStatus struct {
+	State string `json:"state,omitempty"`
+}
+
+// TemplateSpec Template的spec，data为Tinkerbell模板YAML
+type TemplateSpec struct {
+	Data string `json:"data"`
+}
+
+// TemplateStatus Template的status
+type TemplateStatus struct {
+	State string `json:"state,omitempty"`
+}
+
+// WorkflowSpec Workflow的spec
+type WorkflowSpec struct {
+	TemplateRef string            `json:"templateRef"`
+	HardwareRef string            `json:"hardwareRef"`
+	HardwareMap map[string]string `json:"hardwareMap,omitempty"`
+}
+
+// WorkflowStatus Workflow的status，由Tinkerbell控制器和worker维护
+type WorkflowStatus struct {
+	State         string         `json:"state,omitempty"`
+	GlobalTimeout int64          `json:"globalTimeout,omitempty"`
+	Tasks         []WorkflowTask `json:"tasks,omitempty"`
+}
+
+// WorkflowTask 工作流任务，每个任务在一台worker上依次执行动作
+type WorkflowTask struct {
+	Name        string            `json:"name"`
+	WorkerAddr  string            `json:"worker"`
+	Actions     []WorkflowAction  `json:"actions"`
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// WorkflowAction 工作流动作的执行状态
+type WorkflowAction struct {
+	Name      string     `json:"name"`
+	Image     string     `json:"image"`
+	Timeout   int64      `json:"timeout,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	Seconds   int64      `json:"seconds,omitempty"`
+	Message   string     `json:"message,omitempty"`
+}
+
+// Terminal 判断工作流是否已结束
+func (s *WorkflowStatus) Terminal() bool {
+	switch s.State {
+	case StateSuccess, StateFailed, StateTimeout:
+		return true
+	}
+	return false
+}
+
+// StartedAt 返回第一个动作的开始时间，没有已开始的动作时返回nil
+func (s *WorkflowStatus) StartedAt() *time.Time {
+	for _, task := range s.Tasks {
+		for _, action := range task.Actions {
+			if action.StartedAt != nil {
+				return action.StartedAt
+			}
+		}
+	}
+	return nil
+}
+
+// CompletedAt 返回最后一个已开始动作的结束时间，没有已开始的动作时返回nil
+func (s *WorkflowStatus) CompletedAt() *time.Time {
+	var last *time.Time
+	for _, task := range s.Tasks {
+		for _, action := range task.Actions {
+			if action.StartedAt == nil {
+				continue
+			}
+			end := action.StartedAt.Add(time.Duration(action.Seconds) * time.Second)
+			if last == nil || end.After(*last) {
+				last = &end
+			}
+		}
+	}
+	return last
+}
+
+// FailedAction 返回第一个失败或超时的动作
+func (s *WorkflowStatus) FailedAction() *WorkflowAction {
+	for i := range s.Tasks {
+		for j := range s.Tasks[i].Actions {
+			switch s.Tasks[i].Actions[j].Status {
+			case StateFailed, StateTimeout:
+				return &s.Tasks[i].Actions[j]
+			}
+		}
+	}
+	return nil
+}
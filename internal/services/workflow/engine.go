@@ -0,0 +1,748 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/allocation"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/outbox"
+	"gpu-management/internal/services/templates"
+	"gpu-management/internal/services/tinkerbell"
+)
+
+// 工作流引擎错误
+var (
+	// ErrWorkflowInProgress 分配上已有未结束的工作流，或删除的工作流尚未结束
+	ErrWorkflowInProgress = errors.New("workflow in progress")
+	// ErrAllocationState 分配当前状态不能执行该类型的工作流
+	ErrAllocationState = errors.New("allocation state does not allow this workflow")
+	// ErrHardwareNotReady 服务器尚未设置Tinkerbell装机参数
+	ErrHardwareNotReady = errors.New("server hardware is not ready for provisioning")
+	// ErrNotResumable 失败的工作流已没有可以继续执行的Tinkerbell模板或步骤
+	ErrNotResumable = errors.New("workflow cannot be resumed")
+)
+
+// handlerPrefix 工作流事件处理器名称前缀，每个订阅主题一个处理器
+const handlerPrefix = "workflow-engine."
+
+// eventSource 工作流引擎发布事件的来源，也是GPU状态历史和配置版本的操作者
+const eventSource = "workflow-engine"
+
+// configResetKey 默认配置在驱动配置中的重置标记，值为configResetDefault
+const (
+	configResetKey     = "reset"
+	configResetDefault = "default"
+)
+
+// defaultDisk 服务器未设置系统盘时使用的设备
+const defaultDisk = "/dev/sda"
+
+// Engine 工作流引擎，把分配上的部署和清理请求转换为Tinkerbell工作流
+//
+//	部署：分配处于pending时在服务器上安装操作系统、GPU驱动和CUDA，完成后服务器ready、分配激活
+//	清理：分配处于active时擦除服务器磁盘，完成后GPU配置恢复默认、分配结束、GPU归还可用
+//
+// 工作流执行期间服务器处于provisioning，失败时服务器进入error并结束分配，清理失败的GPU进入error等待检修
+// 步骤失败且未达到重试策略的执行次数时，引擎只提交剩余的动作从该步骤继续执行，工作流保持running
+// 工作流按模板库中内置模板的最新版本渲染，执行进度来自工作流CRD，引擎订阅workflow.*事件同步进度，查询时也会同步一次
+type Engine struct {
+	repos       *repository.Repositories
+	allocations *allocation.Service
+	library     *templates.Library
+	tinkerbell  *tinkerbell.Reconciler
+
+	// mu 串行化工作流的创建和状态同步，同一分配不会同时创建两个工作流，结束时的状态联动只执行一次
+	mu sync.Mutex
+}
+
+// NewEngine 创建工作流引擎，调用InstallTemplates安装内置模板，调用Subscribe后开始跟踪工作流进度
+func NewEngine(repos *repository.Repositories, allocations *allocation.Service, library *templates.Library, reconciler *tinkerbell.Reconciler) *Engine {
+	return &Engine{
+		repos:       repos,
+		allocations: allocations,
+		library:     library,
+		tinkerbell:  reconciler,
+	}
+}
+
+// InstallTemplates 在模板库中创建尚不存在的内置模板，已存在的模板保留库中的版本
+func (e *Engine) InstallTemplates(ctx context.Context) error {
+	for i := range builtinTemplates {
+		req := builtinTemplates[i]
+		_, err := e.library.Get(ctx, req.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if _, err := e.library.Create(ctx, &req, eventSource); err != nil && !errors.Is(err, repository.ErrConflict) {
+			return fmt.Errorf("failed to install template %s: %w", req.Name, err)
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅工作流CRD的状态变化事件
+func (e *Engine) Subscribe(ctx context.Context, eventBus event.EventBus) error {
+	topics := []string{event.EventTypeWorkflowStarted, event.EventTypeWorkflowCompleted, event.EventTypeWorkflowFailed}
+	for _, topic := range topics {
+		if err := eventBus.Subscribe(ctx, topic, e.handle, event.WithHandlerName(handlerPrefix+topic)); err != nil {
+			return fmt.Errorf("failed to subscribe workflow engine to %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// handle 同步事件对应的工作流，状态联动失败时返回错误，由事件总线写入死信，重放时从未完成的联动继续
+func (e *Engine) handle(ctx context.Context, data []byte) error {
+	ce, err := event.ParseCloudEvent(data)
+	if err != nil {
+		return err
+	}
+	var evt event.WorkflowEvent
+	if err := ce.DecodeData(&evt); err != nil {
+		return fmt.Errorf("%w: %v", event.ErrInvalidEvent, err)
+	}
+
+	rows, err := e.repos.Workflows.List(ctx, repository.WorkflowFilter{WorkflowCRDID: evt.WorkflowID})
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := e.refresh(ctx, &rows[i]); err != nil {
+			return fmt.Errorf("workflow %s: %w", rows[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// List 查询工作流，未结束的工作流先同步执行进度
+func (e *Engine) List(ctx context.Context, filter repository.WorkflowFilter) ([]models.Workflow, error) {
+	rows, err := e.repos.Workflows.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if err := e.refresh(ctx, &rows[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// Get 查询工作流详情，未结束的工作流先同步执行进度
+func (e *Engine) Get(ctx context.Context, id string) (*models.Workflow, error) {
+	row, err := e.repos.Workflows.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.refresh(ctx, row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Create 按类型创建工作流
+func (e *Engine) Create(ctx context.Context, req *CreateRequest) (*models.Workflow, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Type == models.WorkflowTypeCleanup {
+		return e.Cleanup(ctx, req.cleanup())
+	}
+	return e.Deploy(ctx, req.deploy())
+}
+
+// Deploy 为pending的分配创建部署工作流
+func (e *Engine) Deploy(ctx context.Context, req *DeployRequest) (*models.Workflow, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alloc, server, err := e.target(ctx, req.AllocationID, models.WorkflowTypeDeploy, models.AllocationStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	hardware, disks, err := e.hardware(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := e.library.Render(ctx, DeployTemplateName, "", map[string]interface{}{
+		paramDisk:          disks[0],
+		paramPartition:     partitionOf(disks[0]),
+		ParamOSImage:       req.OSImage,
+		ParamDriverVersion: req.DriverVersion,
+		paramCUDAPackage:   cudaPackage(req.CUDAVersion),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.submit(ctx, alloc, server, hardware, models.WorkflowTypeDeploy, rendered, req.params())
+}
+
+// Cleanup 为active的分配创建清理工作流
+func (e *Engine) Cleanup(ctx context.Context, req *CleanupRequest) (*models.Workflow, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alloc, server, err := e.target(ctx, req.AllocationID, models.WorkflowTypeCleanup, models.AllocationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	hardware, disks, err := e.hardware(ctx, server.ID)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := e.library.Render(ctx, CleanupTemplateName, "", map[string]interface{}{paramDisks: disks})
+	if err != nil {
+		return nil, err
+	}
+	return e.submit(ctx, alloc, server, hardware, models.WorkflowTypeCleanup, rendered, nil)
+}
+
+// Delete 删除已结束的工作流及其Tinkerbell工作流
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	row, err := e.repos.Workflows.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !terminal(row.Status) {
+		return fmt.Errorf("%w: workflow %s is %s", ErrWorkflowInProgress, id, row.Status)
+	}
+	if err := e.repos.Workflows.Delete(ctx, id); err != nil {
+		return err
+	}
+	if row.WorkflowCRDID == "" {
+		return nil
+	}
+	if err := e.tinkerbell.DeleteWorkflow(ctx, row.WorkflowCRDID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Retry 从失败的步骤继续执行失败的工作流，之前已成功的步骤不再执行，失败的步骤再执行一次
+// 部署工作流的分配已失败时重新进入pending，重新占用原来的GPU
+func (e *Engine) Retry(ctx context.Context, id string) (*models.Workflow, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	row, err := e.repos.Workflows.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.WorkflowTransitions.Validate("workflow", id, row.Status, models.WorkflowStatusPending); err != nil {
+		return nil, err
+	}
+	statuses := []string{models.AllocationStatusFailed, models.AllocationStatusPending}
+	if row.Type == models.WorkflowTypeCleanup {
+		statuses = []string{models.AllocationStatusFailed, models.AllocationStatusActive}
+	}
+	alloc, server, err := e.target(ctx, row.AllocationID, row.Type, statuses...)
+	if err != nil {
+		return nil, err
+	}
+	crd, err := e.repos.WorkflowCRDs.Get(ctx, row.WorkflowCRDID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: tinkerbell workflow of %s no longer exists", ErrNotResumable, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := resumeIndex(row.Steps)
+	if index < 0 {
+		return nil, fmt.Errorf("%w: workflow %s has no unfinished step", ErrNotResumable, id)
+	}
+	if _, _, err := e.hardware(ctx, server.ID); err != nil {
+		return nil, err
+	}
+
+	// 失败的部署结束了分配，与工作流在同一事务中迁回pending，继续执行失败时分配保持failed
+	var requeue *models.Allocation
+	if row.Type == models.WorkflowTypeDeploy && alloc.Status == models.AllocationStatusFailed {
+		requeue = alloc
+	}
+
+	next := *row
+	next.Steps = append([]models.WorkflowStep(nil), row.Steps...)
+	if step := &next.Steps[index]; step.MaxAttempts <= step.Attempts {
+		step.MaxAttempts = step.Attempts + 1
+	}
+	next.Status = models.WorkflowStatusPending
+	next.Error = ""
+	next.CompletedAt = nil
+	server.Status = models.ServerStatusProvisioning
+	if err := e.resume(ctx, &next, row.Status, crd, index, server, requeue); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// target 读取工作流作用的分配和服务器，校验分配处于statuses之一且没有未结束的工作流
+func (e *Engine) target(ctx context.Context, allocationID, workflowType string, statuses ...string) (*models.Allocation, *models.Server, error) {
+	alloc, err := e.repos.Allocations.Get(ctx, allocationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	allowed := false
+	for _, status := range statuses {
+		allowed = allowed || alloc.Status == status
+	}
+	if !allowed {
+		return nil, nil, fmt.Errorf("%w: allocation %s is %s, %s requires %s", ErrAllocationState, alloc.ID, alloc.Status, workflowType, strings.Join(statuses, " or "))
+	}
+	running, err := e.repos.Workflows.List(ctx, repository.WorkflowFilter{
+		AllocationID: alloc.ID,
+		Statuses:     []string{models.WorkflowStatusPending, models.WorkflowStatusRunning},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(running) > 0 {
+		return nil, nil, fmt.Errorf("%w: allocation %s already has %s workflow %s", ErrWorkflowInProgress, alloc.ID, running[0].Type, running[0].ID)
+	}
+	server, err := e.repos.Servers.Get(ctx, alloc.ServerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return alloc, server, nil
+}
+
+// hardware 读取服务器的Hardware映射和磁盘，服务器必须已设置网络启动网卡的MAC
+func (e *Engine) hardware(ctx context.Context, serverID string) (*models.HardwareCRD, []string, error) {
+	row, err := e.repos.HardwareCRDs.GetByServer(ctx, serverID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, fmt.Errorf("%w: server %s has no tinkerbell hardware", ErrHardwareNotReady, serverID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if mac, _ := row.Metadata[tinkerbell.MetadataMAC].(string); mac == "" {
+		return nil, nil, fmt.Errorf("%w: server %s has no mac address", ErrHardwareNotReady, serverID)
+	}
+
+	var disks []string
+	switch values := row.Metadata[tinkerbell.MetadataDisks].(type) {
+	case []string:
+		disks = values
+	case []interface{}:
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				disks = append(disks, s)
+			}
+		}
+	}
+	if len(disks) == 0 {
+		disks = []string{defaultDisk}
+	}
+	for _, disk := range disks {
+		if !diskPattern.MatchString(disk) {
+			return nil, nil, fmt.Errorf("%w: server %s has invalid disk %q", ErrHardwareNotReady, serverID, disk)
+		}
+	}
+	return row, disks, nil
+}
+
+// submit 在一个事务中保存模板、工作流CRD和工作流，服务器进入provisioning，然后请求立即同步到Tinkerbell
+func (e *Engine) submit(ctx context.Context, alloc *models.Allocation, server *models.Server, hardware *models.HardwareCRD,
+	workflowType string, rendered *templates.Rendered, params map[string]string) (*models.Workflow, error) {
+	steps, err := newSteps(rendered.Content, rendered.Retries)
+	if err != nil {
+		return nil, fmt.Errorf("rendered %s template is invalid: %w", rendered.Name, err)
+	}
+	workflow := &models.Workflow{
+		AllocationID: alloc.ID,
+		Type:         workflowType,
+		Status:       models.WorkflowStatusPending,
+		Params:       params,
+		Steps:        steps,
+	}
+	err = e.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		template, err := ensureTemplate(ctx, tx, &tinkerbell.TemplateRequest{
+			Name:        rendered.Name,
+			Description: "rendered from " + rendered.Name + "@" + rendered.Version + " by " + eventSource,
+			Content:     rendered.Content,
+			Version:     rendered.Version + "-" + contentVersion(rendered.Content),
+			OSType:      rendered.OSType,
+		})
+		if err != nil {
+			return err
+		}
+		crd := &models.WorkflowCRD{
+			HardwareID: hardware.ID,
+			TemplateID: template.ID,
+			Status:     models.WorkflowStatusPending,
+		}
+		if err := tx.WorkflowCRDs.Create(ctx, crd); err != nil {
+			return err
+		}
+		workflow.WorkflowCRDID = crd.ID
+		if err := tx.Workflows.Create(ctx, workflow); err != nil {
+			return err
+		}
+		server.Status = models.ServerStatusProvisioning
+		return tx.Servers.Update(ctx, server)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.tinkerbell.Trigger()
+	return workflow, nil
+}
+
+// resume 提交只包含index及之后步骤的Tinkerbell工作流，在同一事务中更新工作流记录
+// server非空时一并保存服务器，requeue非空时一并把分配迁回pending
+// 失败的Tinkerbell工作流随后删除，删除失败只记录日志
+func (e *Engine) resume(ctx context.Context, workflow *models.Workflow, expected string, failed *models.WorkflowCRD, index int, server *models.Server, requeue *models.Allocation) error {
+	source, err := e.repos.TemplateCRDs.Get(ctx, failed.TemplateID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: template of workflow %s no longer exists", ErrNotResumable, workflow.ID)
+	}
+	if err != nil {
+		return err
+	}
+	step := &workflow.Steps[index]
+	content, err := tinkerbell.ResumeTemplate(source.Content, step.Task, step.Name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotResumable, err)
+	}
+	step.Status = models.WorkflowStepStatusPending
+
+	err = e.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		template, err := ensureTemplate(ctx, tx, &tinkerbell.TemplateRequest{
+			Name:        source.Name,
+			Description: "resumed from " + source.Name + "@" + source.Version + " at action " + step.Name + " by " + eventSource,
+			Content:     content,
+			Version:     "resume-" + contentVersion(content),
+			OSType:      source.OSType,
+		})
+		if err != nil {
+			return err
+		}
+		crd := &models.WorkflowCRD{
+			HardwareID: failed.HardwareID,
+			TemplateID: template.ID,
+			Status:     models.WorkflowStatusPending,
+		}
+		if err := tx.WorkflowCRDs.Create(ctx, crd); err != nil {
+			return err
+		}
+		workflow.WorkflowCRDID = crd.ID
+		workflow.TinkerbellID = ""
+		if err := tx.Workflows.UpdateIfStatus(ctx, workflow, expected); err != nil {
+			return err
+		}
+		if requeue != nil {
+			if _, err := e.allocations.TransitionInTx(ctx, tx, requeue.ID, models.AllocationStatusPending); err != nil {
+				return err
+			}
+		}
+		if server == nil {
+			return nil
+		}
+		return tx.Servers.Update(ctx, server)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := e.tinkerbell.DeleteWorkflow(ctx, failed.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("Failed to delete failed tinkerbell workflow %s of workflow %s: %v", failed.ID, workflow.ID, err)
+	}
+	e.tinkerbell.Trigger()
+	return nil
+}
+
+// ensureTemplate 查找名称和版本相同的Tinkerbell模板，不存在时校验并创建，版本中带有内容摘要，相同版本即相同内容
+func ensureTemplate(ctx context.Context, tx *repository.Repositories, req *tinkerbell.TemplateRequest) (*models.TemplateCRD, error) {
+	existing, err := tx.TemplateCRDs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		if existing[i].Name == req.Name && existing[i].Version == req.Version {
+			return &existing[i], nil
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("rendered %s template is invalid: %w", req.Name, err)
+	}
+	template := &models.TemplateCRD{
+		Name:        req.Name,
+		Description: req.Description,
+		Content:     req.Content,
+		Version:     req.Version,
+		OSType:      req.OSType,
+	}
+	if err := tx.TemplateCRDs.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// refresh 按工作流CRD同步未结束工作流的状态和步骤，工作流结束时先完成分配、服务器和GPU的状态联动
+// row会被更新为同步后的内容
+func (e *Engine) refresh(ctx context.Context, row *models.Workflow) error {
+	if terminal(row.Status) {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// 加锁后重新读取，其他调用可能已经同步过
+	current, err := e.repos.Workflows.Get(ctx, row.ID)
+	if err != nil {
+		return err
+	}
+	*row = *current
+	if terminal(row.Status) {
+		return nil
+	}
+
+	crd, err := e.repos.WorkflowCRDs.Get(ctx, row.WorkflowCRDID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	next := *row
+	if crd == nil {
+		next.Status = models.WorkflowStatusFailed
+		next.Error = "tinkerbell workflow record no longer exists"
+	} else {
+		status, err := tinkerbell.DecodeSteps(crd.Steps)
+		if err != nil {
+			return err
+		}
+		next.Status = crd.Status
+		next.TinkerbellID = crd.TinkerbellID
+		next.Steps = mergeSteps(row.Steps, status)
+		// 从失败步骤继续执行时保留第一次执行的开始时间
+		if next.StartedAt == nil {
+			next.StartedAt = crd.StartedAt
+		}
+		next.CompletedAt = crd.CompletedAt
+		next.Error, _ = crd.Steps[tinkerbell.MetadataError].(string)
+	}
+	if next.Status == row.Status && next.TinkerbellID == row.TinkerbellID && reflect.DeepEqual(next.Steps, row.Steps) {
+		return nil
+	}
+
+	// 失败的步骤还有执行次数时从该步骤继续执行，工作流保持running，不触发失败联动
+	if next.Status == models.WorkflowStatusFailed && crd != nil {
+		if i := resumeIndex(next.Steps); i >= 0 && next.Steps[i].Attempts < next.Steps[i].MaxAttempts {
+			next.Status = models.WorkflowStatusRunning
+			next.CompletedAt = nil
+			if err := e.resume(ctx, &next, row.Status, crd, i, nil, nil); err != nil {
+				return err
+			}
+			*row = next
+			return nil
+		}
+	}
+
+	if terminal(next.Status) {
+		if err := e.finish(ctx, &next); err != nil {
+			return err
+		}
+	}
+	if err := e.repos.Workflows.UpdateIfStatus(ctx, &next, row.Status); err != nil {
+		return err
+	}
+	*row = next
+	return nil
+}
+
+// finish 按工作流结果联动分配、服务器和GPU的状态
+// 每一步都先检查当前状态，联动中途失败后再次执行时跳过已完成的步骤
+func (e *Engine) finish(ctx context.Context, workflow *models.Workflow) error {
+	alloc, err := e.repos.Allocations.Get(ctx, workflow.AllocationID)
+	if err != nil {
+		return err
+	}
+	server, err := e.repos.Servers.Get(ctx, alloc.ServerID)
+	if err != nil {
+		return err
+	}
+	succeeded := workflow.Status == models.WorkflowStatusCompleted
+
+	switch {
+	case workflow.Type == models.WorkflowTypeDeploy && succeeded:
+		if err := e.updateGPUVersions(ctx, alloc.GPUIDs, workflow.Params); err != nil {
+			return err
+		}
+		if err := e.setServerStatus(ctx, server, models.ServerStatusReady, event.EventTypeHardwareProvisioned); err != nil {
+			return err
+		}
+		if alloc.Status == models.AllocationStatusPending {
+			if _, err := e.allocations.Start(ctx, alloc.ID); err != nil {
+				return err
+			}
+		}
+	case workflow.Type == models.WorkflowTypeDeploy:
+		if err := e.setServerStatus(ctx, server, models.ServerStatusError, event.EventTypeHardwareFailed); err != nil {
+			return err
+		}
+		if alloc.Status == models.AllocationStatusPending {
+			if _, err := e.allocations.Fail(ctx, alloc.ID); err != nil {
+				return err
+			}
+		}
+	case workflow.Type == models.WorkflowTypeCleanup && succeeded:
+		if err := e.resetGPUConfigs(ctx, alloc.GPUIDs); err != nil {
+			return err
+		}
+		if alloc.Status == models.AllocationStatusFailed {
+			// 清理失败后重试成功，等待检修的GPU归还可用
+			change := repository.StatusChange{
+				Actor:  eventSource,
+				Reason: fmt.Sprintf("cleanup workflow %s completed after retry", workflow.ID),
+			}
+			err := e.repos.GPUs.TransitionStatus(ctx, alloc.GPUIDs, models.GPUStatusError, models.GPUStatusAvailable, change)
+			if err != nil && !errors.Is(err, repository.ErrConflict) {
+				return err
+			}
+		}
+		if alloc.Status == models.AllocationStatusActive {
+			if _, err := e.allocations.Stop(ctx, alloc.ID); err != nil {
+				return err
+			}
+		}
+		if err := e.setServerStatus(ctx, server, models.ServerStatusReady, ""); err != nil {
+			return err
+		}
+	case workflow.Type == models.WorkflowTypeCleanup:
+		if alloc.Status == models.AllocationStatusActive {
+			if _, err := e.allocations.Fail(ctx, alloc.ID); err != nil {
+				return err
+			}
+			// 磁盘上可能仍有上一个用户的数据，GPU不能直接再分配
+			change := repository.StatusChange{
+				Actor:  eventSource,
+				Reason: fmt.Sprintf("cleanup workflow %s failed: %s", workflow.ID, workflow.Error),
+			}
+			err := e.repos.GPUs.TransitionStatus(ctx, alloc.GPUIDs, models.GPUStatusAvailable, models.GPUStatusError, change)
+			if err != nil && !errors.Is(err, repository.ErrConflict) {
+				return err
+			}
+		}
+		if err := e.setServerStatus(ctx, server, models.ServerStatusError, event.EventTypeHardwareFailed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setServerStatus 更新服务器状态，eventType非空时在同一事务中通过发件箱发布硬件事件
+func (e *Engine) setServerStatus(ctx context.Context, server *models.Server, status, eventType string) error {
+	if server.Status == status {
+		return nil
+	}
+	server.Status = status
+	return e.repos.InTx(ctx, func(tx *repository.Repositories) error {
+		if err := tx.Servers.Update(ctx, server); err != nil {
+			return err
+		}
+		if eventType == "" {
+			return nil
+		}
+		evt, err := event.NewCloudEvent(eventSource, eventType, server.ID, event.HardwareEvent{
+			HardwareID: server.ID,
+			Status:     server.Status,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Enqueue(ctx, tx.Outbox, evt)
+	})
+}
+
+// updateGPUVersions 部署完成后记录GPU的驱动和CUDA版本
+func (e *Engine) updateGPUVersions(ctx context.Context, gpuIDs []string, params map[string]string) error {
+	for _, id := range gpuIDs {
+		gpu, err := e.repos.GPUs.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if gpu.DriverVersion == params[ParamDriverVersion] && gpu.CUDAVersion == params[ParamCUDAVersion] {
+			continue
+		}
+		gpu.DriverVersion = params[ParamDriverVersion]
+		gpu.CUDAVersion = params[ParamCUDAVersion]
+		if err := e.repos.GPUs.Update(ctx, gpu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetGPUConfigs 清理完成后为每张GPU追加一个默认配置版本
+// 功率上限不能为空，默认配置沿用第一个版本的功率上限，显存频率为0表示使用驱动默认值，驱动配置中带有重置标记
+func (e *Engine) resetGPUConfigs(ctx context.Context, gpuIDs []string) error {
+	for _, id := range gpuIDs {
+		versions, err := e.repos.GPUConfigs.ListVersions(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 || isDefaultConfig(&versions[len(versions)-1]) {
+			continue
+		}
+
+		config := &models.GPUConfig{
+			GPUID:        id,
+			Version:      fmt.Sprintf("v%d", len(versions)+1),
+			PowerLimit:   versions[0].PowerLimit,
+			DriverConfig: map[string]string{configResetKey: configResetDefault},
+			CreatedBy:    eventSource,
+		}
+		err = e.repos.InTx(ctx, func(tx *repository.Repositories) error {
+			if err := tx.GPUConfigs.Create(ctx, config); err != nil {
+				return err
+			}
+			evt, err := event.NewCloudEvent(eventSource, event.EventTypeGPUConfigUpdated, id, event.GPUConfigEvent{
+				GPUID:     id,
+				ConfigID:  config.ID,
+				Version:   config.Version,
+				CreatedBy: config.CreatedBy,
+			})
+			if err != nil {
+				return err
+			}
+			return outbox.Enqueue(ctx, tx.Outbox, evt)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDefaultConfig 判断配置是否为清理后追加的默认配置
+func isDefaultConfig(config *models.GPUConfig) bool {
+	return config.DriverConfig[configResetKey] == configResetDefault
+}
+
+// terminal 判断工作流是否已结束
+func terminal(status string) bool {
+	return status == models.WorkflowStatusCompleted || status == models.WorkflowStatusFailed
+}
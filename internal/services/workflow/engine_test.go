@@ -0,0 +1,375 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/repository"
+	"gpu-management/internal/services/allocation"
+	"gpu-management/internal/services/event"
+	"gpu-management/internal/services/scheduler"
+	"gpu-management/internal/services/templates"
+	"gpu-management/internal/services/tinkerbell"
+)
+
+// testEngine 使用内存仓储和FakeClient的工作流引擎
+type testEngine struct {
+	repos      *repository.Repositories
+	fake       *tinkerbell.FakeClient
+	reconciler *tinkerbell.Reconciler
+	engine     *Engine
+}
+
+func newTestEngine(t *testing.T) *testEngine {
+	t.Helper()
+	repos := repository.NewMemoryRepositories()
+	fake := tinkerbell.NewFakeClient(tinkerbell.FakeConfig{})
+	reconciler := tinkerbell.NewReconciler(repos, tinkerbell.NewClient(fake), tinkerbell.Config{})
+	library := templates.NewLibrary(repos, templates.Config{AllowedActions: []string{
+		"quay.io/tinkerbell-actions/image2disk",
+		"quay.io/tinkerbell-actions/cexec",
+		"quay.io/tinkerbell-actions/kexec",
+		"quay.io/tinkerbell-actions/rootio",
+	}})
+	allocations := allocation.NewService(repos, event.NewMemoryEventBus(event.MemoryConfig{}, nil), scheduler.NewScheduler(repos))
+	engine := NewEngine(repos, allocations, library, reconciler)
+	if err := engine.InstallTemplates(context.Background()); err != nil {
+		t.Fatalf("InstallTemplates() error = %v", err)
+	}
+	return &testEngine{repos: repos, fake: fake, reconciler: reconciler, engine: engine}
+}
+
+// reconcile 同步一次Tinkerbell
+func (te *testEngine) reconcile(t *testing.T) {
+	t.Helper()
+	result, err := te.reconciler.Reconcile(context.Background())
+	if err != nil || len(result.Errors) != 0 {
+		t.Fatalf("Reconcile() = %+v, %v", result, err)
+	}
+}
+
+// newAllocation 创建一台设置了装机参数的服务器和一张GPU，并在其上创建pending的分配
+func (te *testEngine) newAllocation(t *testing.T) *models.Allocation {
+	t.Helper()
+	ctx := context.Background()
+
+	server := &models.Server{Name: "node-1", Status: models.ServerStatusReady}
+	if err := te.repos.Servers.Create(ctx, server); err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	gpu := &models.GPU{ServerID: server.ID, Model: "A100", Status: models.GPUStatusAvailable, MemoryGB: 80}
+	if err := te.repos.GPUs.Create(ctx, gpu); err != nil {
+		t.Fatalf("failed to create gpu: %v", err)
+	}
+	if _, err := te.reconciler.SetHardware(ctx, server.ID, &tinkerbell.HardwareRequest{MAC: "aa:bb:cc:00:00:01"}); err != nil {
+		t.Fatalf("SetHardware() error = %v", err)
+	}
+	alloc, err := te.engine.allocations.Create(ctx, &allocation.CreateRequest{UserID: "user-1", ServerID: server.ID, GPUCount: 1})
+	if err != nil {
+		t.Fatalf("failed to create allocation: %v", err)
+	}
+	return alloc
+}
+
+// deploy 在分配上提交部署工作流并同步到Tinkerbell
+func (te *testEngine) deploy(t *testing.T, alloc *models.Allocation) *models.Workflow {
+	t.Helper()
+	workflow, err := te.engine.Deploy(context.Background(), &DeployRequest{
+		AllocationID:  alloc.ID,
+		OSImage:       "http://images/ubuntu-22.04.img",
+		DriverVersion: "535.104.05",
+		CUDAVersion:   "12.2",
+	})
+	if err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	te.reconcile(t)
+	return workflow
+}
+
+// finishWorkflow 在Tinkerbell中结束工作流：task中的actions依次成功，failed非空时该动作失败，然后同步并返回工作流
+func (te *testEngine) finishWorkflow(t *testing.T, workflow *models.Workflow, task string, actions []string, failed string) *models.Workflow {
+	t.Helper()
+	ctx := context.Background()
+
+	crd, err := te.repos.WorkflowCRDs.Get(ctx, workflow.WorkflowCRDID)
+	if err != nil {
+		t.Fatalf("failed to get tinkerbell workflow: %v", err)
+	}
+	started := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	status := tinkerbell.WorkflowStatus{State: tinkerbell.StateSuccess, Tasks: []tinkerbell.WorkflowTask{{Name: task}}}
+	for _, name := range actions {
+		action := tinkerbell.WorkflowAction{Name: name, Status: tinkerbell.StateSuccess, StartedAt: &started, Seconds: 1}
+		if name == failed {
+			status.State = tinkerbell.StateFailed
+			action.Status = tinkerbell.StateFailed
+			action.Message = name + " failed"
+		}
+		status.Tasks[0].Actions = append(status.Tasks[0].Actions, action)
+	}
+	if err := te.fake.SetStatus(tinkerbell.ResourceWorkflows, crd.TinkerbellID, status); err != nil {
+		t.Fatalf("SetStatus() error = %v", err)
+	}
+	te.reconcile(t)
+
+	if workflow, err = te.engine.Get(ctx, workflow.ID); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	return workflow
+}
+
+// deployActions 部署模板os-installation任务中的动作
+var deployActions = []string{"stream-image", "install-gpu-driver", "install-cuda-toolkit", "kexec"}
+
+// failedDeploy 为一台服务器的一张GPU创建分配，部署工作流在kexec步骤失败，分配随之失败
+func (te *testEngine) failedDeploy(t *testing.T) (*models.Workflow, *models.Allocation) {
+	t.Helper()
+	alloc := te.newAllocation(t)
+	workflow := te.finishWorkflow(t, te.deploy(t, alloc), "os-installation", deployActions, "kexec")
+	if workflow.Status != models.WorkflowStatusFailed {
+		t.Fatalf("workflow status = %s, want %s", workflow.Status, models.WorkflowStatusFailed)
+	}
+	te.checkAllocation(t, alloc.ID, models.AllocationStatusFailed, models.GPUStatusAvailable)
+	return workflow, alloc
+}
+
+// outboxTopics 统计发件箱中待发布事件的主题
+func (te *testEngine) outboxTopics(t *testing.T) map[string]int {
+	t.Helper()
+	events, err := te.repos.Outbox.ListDue(context.Background(), time.Now().UTC().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("ListDue() error = %v", err)
+	}
+	topics := make(map[string]int)
+	for _, evt := range events {
+		topics[evt.Topic]++
+	}
+	return topics
+}
+
+// checkAllocation 校验分配及其GPU的状态，wantGPUStatus为空时不校验GPU
+func (te *testEngine) checkAllocation(t *testing.T, id, wantStatus, wantGPUStatus string) {
+	t.Helper()
+	ctx := context.Background()
+	alloc, err := te.repos.Allocations.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to get allocation: %v", err)
+	}
+	if alloc.Status != wantStatus {
+		t.Errorf("allocation status = %s, want %s", alloc.Status, wantStatus)
+	}
+	if wantGPUStatus == "" {
+		return
+	}
+	for _, gpuID := range alloc.GPUIDs {
+		gpu, err := te.repos.GPUs.Get(ctx, gpuID)
+		if err != nil {
+			t.Fatalf("failed to get gpu: %v", err)
+		}
+		if gpu.Status != wantGPUStatus {
+			t.Errorf("gpu %s status = %s, want %s", gpuID, gpu.Status, wantGPUStatus)
+		}
+	}
+}
+
+func TestRetryDeploy(t *testing.T) {
+	tests := []struct {
+		name string
+		// breakResume 在重试前破坏继续执行的条件，返回恢复函数
+		breakResume func(t *testing.T, te *testEngine, workflow *models.Workflow, alloc *models.Allocation) func()
+		wantErr     error
+	}{
+		{name: "resumed"},
+		{
+			name: "template can no longer be resumed",
+			breakResume: func(t *testing.T, te *testEngine, workflow *models.Workflow, alloc *models.Allocation) func() {
+				ctx := context.Background()
+				crd, err := te.repos.WorkflowCRDs.Get(ctx, workflow.WorkflowCRDID)
+				if err != nil {
+					t.Fatalf("failed to get tinkerbell workflow: %v", err)
+				}
+				template, err := te.repos.TemplateCRDs.Get(ctx, crd.TemplateID)
+				if err != nil {
+					t.Fatalf("failed to get template: %v", err)
+				}
+				content := template.Content
+				template.Content = "tasks: ["
+				if err := te.repos.TemplateCRDs.Update(ctx, template); err != nil {
+					t.Fatalf("failed to update template: %v", err)
+				}
+				return func() {
+					template.Content = content
+					if err := te.repos.TemplateCRDs.Update(ctx, template); err != nil {
+						t.Fatalf("failed to restore template: %v", err)
+					}
+				}
+			},
+			wantErr: ErrNotResumable,
+		},
+		{
+			// 分配迁回pending时GPU已被占用，事务回滚，工作流保持failed
+			name: "gpu taken before requeue",
+			breakResume: func(t *testing.T, te *testEngine, workflow *models.Workflow, alloc *models.Allocation) func() {
+				ctx := context.Background()
+				change := repository.StatusChange{Actor: "test"}
+				if err := te.repos.GPUs.TransitionStatus(ctx, alloc.GPUIDs, models.GPUStatusAvailable, models.GPUStatusError, change); err != nil {
+					t.Fatalf("failed to take gpu: %v", err)
+				}
+				return func() {
+					if err := te.repos.GPUs.TransitionStatus(ctx, alloc.GPUIDs, models.GPUStatusError, models.GPUStatusAvailable, change); err != nil {
+						t.Fatalf("failed to return gpu: %v", err)
+					}
+				}
+			},
+			wantErr: repository.ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := newTestEngine(t)
+			workflow, alloc := te.failedDeploy(t)
+			ctx := context.Background()
+
+			if tt.breakResume != nil {
+				restore := tt.breakResume(t, te, workflow, alloc)
+				crds, err := te.repos.WorkflowCRDs.List(ctx, repository.WorkflowCRDFilter{})
+				if err != nil {
+					t.Fatalf("failed to list tinkerbell workflows: %v", err)
+				}
+
+				if _, err := te.engine.Retry(ctx, workflow.ID); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Retry() error = %v, want %v", err, tt.wantErr)
+				}
+				// 继续执行失败时分配和工作流都保持失败，没有留下新的Tinkerbell工作流
+				te.checkAllocation(t, alloc.ID, models.AllocationStatusFailed, "")
+				got, err := te.repos.Workflows.Get(ctx, workflow.ID)
+				if err != nil {
+					t.Fatalf("failed to get workflow: %v", err)
+				}
+				if got.Status != models.WorkflowStatusFailed || got.WorkflowCRDID != workflow.WorkflowCRDID {
+					t.Errorf("workflow after failed retry = %s with tinkerbell workflow %s", got.Status, got.WorkflowCRDID)
+				}
+				after, err := te.repos.WorkflowCRDs.List(ctx, repository.WorkflowCRDFilter{})
+				if err != nil {
+					t.Fatalf("failed to list tinkerbell workflows: %v", err)
+				}
+				if len(after) != len(crds) {
+					t.Errorf("tinkerbell workflows = %d after failed retry, want %d", len(after), len(crds))
+				}
+				restore()
+			}
+
+			// 之前的重试失败不影响再次重试
+			resumed, err := te.engine.Retry(ctx, workflow.ID)
+			if err != nil {
+				t.Fatalf("Retry() error = %v", err)
+			}
+			if resumed.Status != models.WorkflowStatusPending || resumed.WorkflowCRDID == workflow.WorkflowCRDID {
+				t.Errorf("resumed workflow = %s with tinkerbell workflow %s", resumed.Status, resumed.WorkflowCRDID)
+			}
+			te.checkAllocation(t, alloc.ID, models.AllocationStatusPending, models.GPUStatusAllocated)
+
+			if got := te.outboxTopics(t)[event.EventTypeAllocationRequeued]; got != 1 {
+				t.Errorf("%d %s events in outbox, want 1", got, event.EventTypeAllocationRequeued)
+			}
+		})
+	}
+}
+
+func TestCleanupCompleted(t *testing.T) {
+	custom := map[string]string{"persistence_mode": "enabled"}
+	reset := map[string]string{configResetKey: configResetDefault}
+
+	tests := []struct {
+		name string
+		// configs 分配期间GPU的配置版本
+		configs []models.GPUConfig
+		// wantAppended 清理完成后是否追加默认配置
+		wantAppended bool
+	}{
+		{name: "no config"},
+		{
+			name: "custom config reset",
+			configs: []models.GPUConfig{
+				{Version: "v1", PowerLimit: 300},
+				{Version: "v2", PowerLimit: 250, MemoryClock: 1215, DriverConfig: custom},
+			},
+			wantAppended: true,
+		},
+		{
+			name: "already default",
+			configs: []models.GPUConfig{
+				{Version: "v1", PowerLimit: 300, DriverConfig: custom},
+				{Version: "v2", PowerLimit: 300, DriverConfig: reset},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := newTestEngine(t)
+			ctx := context.Background()
+			alloc := te.newAllocation(t)
+			deployed := te.finishWorkflow(t, te.deploy(t, alloc), "os-installation", deployActions, "")
+			if deployed.Status != models.WorkflowStatusCompleted {
+				t.Fatalf("deploy workflow status = %s, want %s", deployed.Status, models.WorkflowStatusCompleted)
+			}
+			te.checkAllocation(t, alloc.ID, models.AllocationStatusActive, models.GPUStatusInUse)
+
+			gpuID := alloc.GPUIDs[0]
+			for i := range tt.configs {
+				config := tt.configs[i]
+				config.GPUID = gpuID
+				if err := te.repos.GPUConfigs.Create(ctx, &config); err != nil {
+					t.Fatalf("failed to create gpu config: %v", err)
+				}
+			}
+
+			workflow, err := te.engine.Cleanup(ctx, &CleanupRequest{AllocationID: alloc.ID})
+			if err != nil {
+				t.Fatalf("Cleanup() error = %v", err)
+			}
+			te.reconcile(t)
+			workflow = te.finishWorkflow(t, workflow, "disk-wipe", []string{"wipe-disk-0"}, "")
+			if workflow.Status != models.WorkflowStatusCompleted {
+				t.Fatalf("cleanup workflow status = %s, want %s", workflow.Status, models.WorkflowStatusCompleted)
+			}
+			// 清理完成后分配结束，GPU归还可用，服务器重新ready
+			te.checkAllocation(t, alloc.ID, models.AllocationStatusCompleted, models.GPUStatusAvailable)
+			server, err := te.repos.Servers.Get(ctx, alloc.ServerID)
+			if err != nil {
+				t.Fatalf("failed to get server: %v", err)
+			}
+			if server.Status != models.ServerStatusReady {
+				t.Errorf("server status = %s, want %s", server.Status, models.ServerStatusReady)
+			}
+
+			versions, err := te.repos.GPUConfigs.ListVersions(ctx, gpuID)
+			if err != nil {
+				t.Fatalf("ListVersions() error = %v", err)
+			}
+			wantVersions, wantEvents := len(tt.configs), 0
+			if tt.wantAppended {
+				wantVersions, wantEvents = wantVersions+1, 1
+			}
+			if len(versions) != wantVersions {
+				t.Fatalf("gpu config versions = %d, want %d", len(versions), wantVersions)
+			}
+			if got := te.outboxTopics(t)[event.EventTypeGPUConfigUpdated]; got != wantEvents {
+				t.Errorf("%d %s events in outbox, want %d", got, event.EventTypeGPUConfigUpdated, wantEvents)
+			}
+			if !tt.wantAppended {
+				return
+			}
+			// 默认配置沿用第一个版本的功率上限，其余恢复驱动默认值
+			latest := versions[len(versions)-1]
+			if latest.Version != "v3" || latest.PowerLimit != tt.configs[0].PowerLimit || latest.MemoryClock != 0 || !isDefaultConfig(&latest) {
+				t.Errorf("default config = %+v, want v3 with power limit %d and the reset marker", latest, tt.configs[0].PowerLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"gpu-management/internal/models"
+)
+
+// 工作流参数名称，与工作流记录的Params一致
+const (
+	ParamOSImage       = "os_image"
+	ParamDriverVersion = "driver_version"
+	ParamCUDAVersion   = "cuda_version"
+)
+
+// versionPattern 驱动和CUDA版本号，例如535.104.05、12.2
+var versionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+){1,3}$`)
+
+// diskPattern 可以写入模板命令的磁盘设备路径
+var diskPattern = regexp.MustCompile(`^/dev/[A-Za-z0-9_./-]+$`)
+
+// DeployRequest 部署工作流请求，在分配的服务器上安装操作系统、GPU驱动和CUDA
+type DeployRequest struct {
+	AllocationID  string `json:"allocation_id"`
+	OSImage       string `json:"os_image"`
+	DriverVersion string `json:"driver_version"`
+	CUDAVersion   string `json:"cuda_version"`
+}
+
+// Validate 校验部署工作流请求
+func (r *DeployRequest) Validate() error {
+	if r.AllocationID == "" {
+		return errors.New("allocation_id is required")
+	}
+	if r.OSImage == "" {
+		return errors.New("os_image is required")
+	}
+	image, err := url.Parse(r.OSImage)
+	if err != nil || (image.Scheme != "http" && image.Scheme != "https") || image.Host == "" || !safeValue(r.OSImage) {
+		return fmt.Errorf("invalid os_image %q: must be an http(s) url", r.OSImage)
+	}
+	if !versionPattern.MatchString(r.DriverVersion) {
+		return fmt.Errorf("invalid driver_version %q", r.DriverVersion)
+	}
+	if !versionPattern.MatchString(r.CUDAVersion) {
+		return fmt.Errorf("invalid cuda_version %q", r.CUDAVersion)
+	}
+	return nil
+}
+
+// params 工作流记录中保存的参数
+func (r *DeployRequest) params() map[string]string {
+	return map[string]string{
+		ParamOSImage:       r.OSImage,
+		ParamDriverVersion: r.DriverVersion,
+		ParamCUDAVersion:   r.CUDAVersion,
+	}
+}
+
+// CleanupRequest 清理工作流请求，擦除分配服务器的磁盘并归还GPU
+type CleanupRequest struct {
+	AllocationID string `json:"allocation_id"`
+}
+
+// Validate 校验清理工作流请求
+func (r *CleanupRequest) Validate() error {
+	if r.AllocationID == "" {
+		return errors.New("allocation_id is required")
+	}
+	return nil
+}
+
+// CreateRequest 按类型创建工作流请求，参数与对应类型的请求一致
+type CreateRequest struct {
+	AllocationID string            `json:"allocation_id"`
+	Type         string            `json:"type"`
+	Params       map[string]string `json:"params"`
+}
+
+// Validate 校验创建工作流请求
+func (r *CreateRequest) Validate() error {
+	switch r.Type {
+	case models.WorkflowTypeDeploy:
+		return r.deploy().Validate()
+	case models.WorkflowTypeCleanup:
+		return r.cleanup().Validate()
+	case "":
+		return errors.New("type is required")
+	default:
+		return fmt.Errorf("unsupported workflow type %q", r.Type)
+	}
+}
+
+// deploy 转换为部署工作流请求
+func (r *CreateRequest) deploy() *DeployRequest {
+	return &DeployRequest{
+		AllocationID:  r.AllocationID,
+		OSImage:       r.Params[ParamOSImage],
+		DriverVersion: r.Params[ParamDriverVersion],
+		CUDAVersion:   r.Params[ParamCUDAVersion],
+	}
+}
+
+// cleanup 转换为清理工作流请求
+func (r *CreateRequest) cleanup() *CleanupRequest {
+	return &CleanupRequest{AllocationID: r.AllocationID}
+}
+
+// safeValue 判断参数能否直接写入模板中双引号括起的YAML字符串
+func safeValue(s string) bool {
+	for _, c := range s {
+		if c <= ' ' || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
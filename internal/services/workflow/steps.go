@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/templates"
+	"gpu-management/internal/services/tinkerbell"
+)
+
+// logExcerptSize 步骤日志片段保留的最大字节数，超出时保留末尾
+const logExcerptSize = 2048
+
+// exitCodePattern 从动作消息中提取退出码，例如exit status 1、exited with code 137
+var exitCodePattern = regexp.MustCompile(`exit(?:ed)?(?: with)? (?:status|code) (-?[0-9]+)`)
+
+// stepStatuses Tinkerbell动作状态对应的步骤状态
+var stepStatuses = map[string]string{
+	tinkerbell.StatePending: models.WorkflowStepStatusPending,
+	tinkerbell.StateRunning: models.WorkflowStepStatusRunning,
+	tinkerbell.StateSuccess: models.WorkflowStepStatusSucceeded,
+	tinkerbell.StateFailed:  models.WorkflowStepStatusFailed,
+	tinkerbell.StateTimeout: models.WorkflowStepStatusTimeout,
+}
+
+// newSteps 按渲染后的模板生成等待执行的步骤，每个步骤的最大执行次数来自模板的重试策略
+func newSteps(content string, retries map[string]models.RetryPolicy) ([]models.WorkflowStep, error) {
+	tasks, err := tinkerbell.ParseTemplate(content)
+	if err != nil {
+		return nil, err
+	}
+	var steps []models.WorkflowStep
+	for _, task := range tasks {
+		for _, action := range task.Actions {
+			steps = append(steps, models.WorkflowStep{
+				Task:        task.Name,
+				Name:        action.Name,
+				Image:       action.Image,
+				Status:      models.WorkflowStepStatusPending,
+				MaxAttempts: templates.RetryPolicyFor(retries, action.Name).MaxAttempts,
+			})
+		}
+	}
+	return steps, nil
+}
+
+// mergeSteps 把Tinkerbell工作流的动作状态合并到步骤中，返回新的步骤列表
+// 从失败步骤继续执行时Tinkerbell工作流只包含剩余的动作，之前已成功的步骤保持不变
+func mergeSteps(steps []models.WorkflowStep, status *tinkerbell.WorkflowStatus) []models.WorkflowStep {
+	merged := append([]models.WorkflowStep(nil), steps...)
+	for _, task := range status.Tasks {
+		for _, action := range task.Actions {
+			i := stepIndex(merged, task.Name, action.Name)
+			if i < 0 {
+				continue
+			}
+			next, ok := stepStatuses[action.Status]
+			if !ok || next == models.WorkflowStepStatusPending {
+				continue
+			}
+
+			step := &merged[i]
+			// 步骤从等待进入执行时计为一次执行，上一次执行的结果清空
+			if step.Status == models.WorkflowStepStatusPending {
+				step.Attempts++
+				step.StartedAt, step.CompletedAt, step.ExitCode, step.Log = nil, nil, nil, ""
+			}
+			step.Status = next
+			if action.StartedAt != nil {
+				startedAt := action.StartedAt.UTC()
+				step.StartedAt = &startedAt
+			}
+			if next == models.WorkflowStepStatusRunning {
+				continue
+			}
+
+			if step.StartedAt != nil {
+				completedAt := step.StartedAt.Add(time.Duration(action.Seconds) * time.Second)
+				step.CompletedAt = &completedAt
+			}
+			step.ExitCode = exitCode(next, action.Message)
+			step.Log = excerpt(action.Message)
+		}
+	}
+	return merged
+}
+
+// stepIndex 返回任务中动作对应的步骤下标，不存在时返回-1
+func stepIndex(steps []models.WorkflowStep, task, name string) int {
+	for i := range steps {
+		if steps[i].Task == task && steps[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// resumeIndex 返回继续执行时的第一个步骤：第一个失败或超时的步骤，没有时为第一个未成功的步骤，全部成功时返回-1
+func resumeIndex(steps []models.WorkflowStep) int {
+	for i := range steps {
+		switch steps[i].Status {
+		case models.WorkflowStepStatusFailed, models.WorkflowStepStatusTimeout:
+			return i
+		}
+	}
+	for i := range steps {
+		if steps[i].Status != models.WorkflowStepStatusSucceeded {
+			return i
+		}
+	}
+	return -1
+}
+
+// exitCode 成功的步骤退出码为0，失败的步骤从消息中提取，提取不到时为空
+func exitCode(status, message string) *int {
+	if status == models.WorkflowStepStatusSucceeded {
+		code := 0
+		return &code
+	}
+	m := exitCodePattern.FindStringSubmatch(message)
+	if m == nil {
+		return nil
+	}
+	code, err := strconv.Atoi(m[1])
+	if err != nil {
+		return nil
+	}
+	return &code
+}
+
+// excerpt 保留日志末尾不超过logExcerptSize字节的内容
+func excerpt(log string) string {
+	if len(log) <= logExcerptSize {
+		return log
+	}
+	log = log[len(log)-logExcerptSize:]
+	// 跳过被截断的UTF-8字符
+	for i := 0; i < len(log) && i < 4; i++ {
+		if log[i]&0xC0 != 0x80 {
+			return log[i:]
+		}
+	}
+	return log
+}
@@ -0,0 +1,173 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"gpu-management/internal/models"
+	"gpu-management/internal/services/templates"
+)
+
+// 内置模板名称，模板在模板库中维护，同一模板版本的不同渲染结果按内容摘要区分Tinkerbell模板版本
+const (
+	DeployTemplateName  = "gpu-deploy"
+	CleanupTemplateName = "gpu-cleanup"
+)
+
+// deployTemplate 部署模板：写入操作系统镜像，在新系统中安装GPU驱动和CUDA，然后从系统盘启动
+const deployTemplate = `version: "0.1"
+name: gpu-deploy
+global_timeout: 7200
+tasks:
+  - name: "os-installation"
+    worker: "{{.device_1}}"
+    volumes:
+      - /dev:/dev
+      - /dev/console:/dev/console
+      - /lib/firmware:/lib/firmware:ro
+    actions:
+      - name: "stream-image"
+        image: quay.io/tinkerbell-actions/image2disk:v1.0.0
+        timeout: 1800
+        environment:
+          DEST_DISK: "[[.disk]]"
+          IMG_URL: "[[.os_image]]"
+          COMPRESSED: "true"
+      - name: "install-gpu-driver"
+        image: quay.io/tinkerbell-actions/cexec:v1.0.0
+        timeout: 1800
+        environment:
+          BLOCK_DEVICE: "[[.partition]]"
+          FS_TYPE: ext4
+          CHROOT: "y"
+          DEFAULT_INTERPRETER: "/bin/sh -c"
+          CMD_LINE: "curl -fsSL -o /tmp/driver.run https://us.download.nvidia.com/tesla/[[.driver_version]]/NVIDIA-Linux-x86_64-[[.driver_version]].run && sh /tmp/driver.run --silent --no-questions && rm -f /tmp/driver.run"
+      - name: "install-cuda-toolkit"
+        image: quay.io/tinkerbell-actions/cexec:v1.0.0
+        timeout: 1800
+        environment:
+          BLOCK_DEVICE: "[[.partition]]"
+          FS_TYPE: ext4
+          CHROOT: "y"
+          DEFAULT_INTERPRETER: "/bin/sh -c"
+          CMD_LINE: "apt-get update && apt-get install -y cuda-toolkit-[[.cuda_package]]"
+      - name: "kexec"
+        image: quay.io/tinkerbell-actions/kexec:v1.0.0
+        timeout: 90
+        pid: host
+        environment:
+          BLOCK_DEVICE: "[[.partition]]"
+          FS_TYPE: ext4
+`
+
+// cleanupTemplate 清理模板：擦除所有数据盘上的文件系统和分区表
+const cleanupTemplate = `version: "0.1"
+name: gpu-cleanup
+global_timeout: 3600
+tasks:
+  - name: "disk-wipe"
+    worker: "{{.device_1}}"
+    volumes:
+      - /dev:/dev
+    actions:
+[[- range $i, $disk := .disks]]
+      - name: "wipe-disk-[[$i]]"
+        image: quay.io/tinkerbell-actions/rootio:v1.0.0
+        timeout: 1800
+        command: ["wipe"]
+        environment:
+          DEST_DISK: "[[$disk]]"
+[[- end]]
+`
+
+// 内置模板参数名称
+const (
+	paramDisk        = "disk"
+	paramPartition   = "partition"
+	paramDisks       = "disks"
+	paramCUDAPackage = "cuda_package"
+)
+
+// stringParam 匹配pattern的字符串参数定义
+func stringParam(description, pattern string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description, "pattern": pattern}
+}
+
+// builtinTemplates 启动时安装到模板库的内置模板
+// 参数值会写入模板中双引号括起的YAML字符串，参数定义用pattern限制可用字符
+var builtinTemplates = []templates.CreateRequest{
+	{
+		Name: DeployTemplateName,
+		VersionRequest: templates.VersionRequest{
+			Description: "Install OS image, NVIDIA driver and CUDA toolkit",
+			OSType:      "linux",
+			Content:     deployTemplate,
+			Parameters: map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []interface{}{paramDisk, paramPartition, ParamOSImage, ParamDriverVersion, paramCUDAPackage},
+				"properties": map[string]interface{}{
+					paramDisk:          stringParam("system disk device", diskPattern.String()),
+					paramPartition:     stringParam("root partition on the system disk", diskPattern.String()),
+					ParamOSImage:       stringParam("http(s) url of the OS image", `^https?://[^\s"\\]+$`),
+					ParamDriverVersion: stringParam("NVIDIA driver version", versionPattern.String()),
+					paramCUDAPackage:   stringParam("cuda-toolkit package suffix, e.g. 12-2", `^[0-9]+(-[0-9]+)?$`),
+				},
+			},
+			// 下载镜像和安装软件包受网络影响，失败时重试
+			Retries: map[string]models.RetryPolicy{
+				"stream-image":         {MaxAttempts: 2},
+				"install-gpu-driver":   {MaxAttempts: 3},
+				"install-cuda-toolkit": {MaxAttempts: 3},
+			},
+		},
+	},
+	{
+		Name: CleanupTemplateName,
+		VersionRequest: templates.VersionRequest{
+			Description: "Wipe filesystems and partition tables on all disks",
+			OSType:      "linux",
+			Content:     cleanupTemplate,
+			Parameters: map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []interface{}{paramDisks},
+				"properties": map[string]interface{}{
+					paramDisks: map[string]interface{}{
+						"type":        "array",
+						"description": "disk devices to wipe",
+						"minItems":    1,
+						"items":       stringParam("disk device", diskPattern.String()),
+					},
+				},
+			},
+			Retries: map[string]models.RetryPolicy{
+				"wipe-disk-*": {MaxAttempts: 2},
+			},
+		},
+	},
+}
+
+// contentVersion 按模板内容生成版本号，相同内容复用同一个模板
+func contentVersion(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// partitionOf 系统盘上第一个分区的设备路径，nvme等以数字结尾的设备分区名带p
+func partitionOf(disk string) string {
+	if disk != "" && disk[len(disk)-1] >= '0' && disk[len(disk)-1] <= '9' {
+		return disk + "p1"
+	}
+	return disk + "1"
+}
+
+// cudaPackage CUDA版本对应的软件包后缀，例如12.2对应12-2
+func cudaPackage(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,22 @@
+package uuid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// New 生成随机UUID (v4)
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// 随机源不可用时退化为基于时间的ID，保证调用方总能拿到唯一值
+		return fmt.Sprintf("id-%d", time.Now().UnixNano())
+	}
+
+	// 设置版本号(4)和变体(RFC 4122)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}